@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -169,7 +170,7 @@ func (c *BunClient) CreatePublicKey(ctx context.Context, key string, comment str
 	}
 
 	// Parse the key to extract algorithm and key data.
-	alg, keyData, _, perr := sshkey.Parse(key)
+	alg, keyData, _, perr := sshkey.Normalize(key)
 	if perr != nil {
 		// If parsing fails, treat the input as raw key data.
 		alg = "ssh-ed25519"
@@ -666,11 +667,47 @@ func (c *BunClient) VerifyAccounts(ctx context.Context, accountIds ...client.Acc
 	return nil, errors.New("VerifyAccounts: TODO - not yet implemented")
 }
 
+func (c *BunClient) RenderAuthorizedKeys(ctx context.Context, accountId client.AccountId) (string, error) {
+	if _, err := c.GetAccount(ctx, accountId); err != nil {
+		return "", err
+	}
+	content, err := core.GenerateKeysContent(int(accountId))
+	if err != nil {
+		return "", fmt.Errorf("failed to render authorized_keys: %w", err)
+	}
+	return content, nil
+}
+
 // --- Other Operations ---
 
+// ListAuditLogs returns the limit most recent audit log entries in
+// chronological order. A limit of zero or less returns all entries.
 func (c *BunClient) ListAuditLogs(ctx context.Context, limit int) ([]client.AuditLog, error) {
-	// TODO: Implement audit log listing.
-	return nil, errors.New("ListAuditLogs: TODO - not yet implemented")
+	reader, ok := c.store.(core.AuditLogReader)
+	if !ok {
+		return nil, errors.New("store does not support reading the audit log")
+	}
+	entries, err := reader.GetAllAuditLogEntries()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit logs: %w", err)
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	// The store returns the newest entry first.
+	result := make([]client.AuditLog, 0, len(entries))
+	for _, e := range slices.Backward(entries) {
+		ts, _ := core.ParseAuditTimestamp(e.Timestamp)
+		result = append(result, client.AuditLog{
+			Id:        client.AuditLogId(e.ID),
+			Timestamp: ts,
+			Metadata:  client.AuditLogMetadata{Hostuser: e.Username},
+			Action:    e.Action,
+			Details:   e.Details,
+		})
+	}
+	return result, nil
 }
 
 func (c *BunClient) ListExistingTags(ctx context.Context) tags.Tags {
@@ -8,6 +8,7 @@ import (
 	"context"
 	"io"
 	"log"
+	"strings"
 	"testing"
 
 	"github.com/toeirei/keymaster/client/bun"
@@ -183,3 +184,41 @@ func TestBunClient_DeleteAccount(t *testing.T) {
 		t.Fatal("expected error when getting deleted account")
 	}
 }
+
+func TestBunClient_ListAuditLogs(t *testing.T) {
+	cfg := config.Config{Database: config.ConfigDatabase{Type: "sqlite", Dsn: ":memory:"}}
+	logger := log.New(io.Discard, "", 0)
+
+	client, err := bun.NewBunClient(cfg, logger)
+	if err != nil {
+		t.Fatalf("NewBunClient failed: %v", err)
+	}
+	defer func() { _ = client.Close(context.Background()) }()
+
+	ctx := context.Background()
+	if _, err := client.CreateAccount(ctx, "audited", "audit-host.com", 22, "ssh", ""); err != nil {
+		t.Fatalf("CreateAccount failed: %v", err)
+	}
+
+	logs, err := client.ListAuditLogs(ctx, 0)
+	if err != nil {
+		t.Fatalf("ListAuditLogs failed: %v", err)
+	}
+	found := false
+	for _, l := range logs {
+		if l.Action == "ADD_ACCOUNT" && strings.Contains(l.Details, "audited@audit-host.com") {
+			found = true
+			if l.Timestamp.IsZero() {
+				t.Errorf("expected a parsed timestamp, got zero for %q", l.Details)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("expected ADD_ACCOUNT entry in audit logs, got %+v", logs)
+	}
+
+	limited, err := client.ListAuditLogs(ctx, 1)
+	if err != nil || len(limited) != 1 {
+		t.Fatalf("expected 1 entry with limit 1, got %d (%v)", len(limited), err)
+	}
+}
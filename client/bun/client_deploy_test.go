@@ -8,10 +8,12 @@ import (
 	"context"
 	"io"
 	"log"
+	"strings"
 	"testing"
 
 	"github.com/toeirei/keymaster/client/bun"
 	"github.com/toeirei/keymaster/config"
+	"github.com/toeirei/keymaster/core/deploy"
 )
 
 // TestBunClient_DeployAccount_NotYetImplemented verifies that deploy operations
@@ -43,3 +45,30 @@ func TestBunClient_DeployAccount_NotYetImplemented(t *testing.T) {
 		t.Error("expected nil channel for failed DeployAccount")
 	}
 }
+
+func TestBunClient_RenderAuthorizedKeys_Errors(t *testing.T) {
+	// Rendering uses the core defaults the CLI registers on startup.
+	deploy.InitializeDefaults()
+	cfg := config.Config{Database: config.ConfigDatabase{Type: "sqlite", Dsn: ":memory:"}}
+	logger := log.New(io.Discard, "", 0)
+
+	client, err := bun.NewBunClient(cfg, logger)
+	if err != nil {
+		t.Fatalf("NewBunClient failed: %v", err)
+	}
+	defer func() { _ = client.Close(context.Background()) }()
+
+	ctx := context.Background()
+	if _, err := client.RenderAuthorizedKeys(ctx, 99999); err == nil {
+		t.Fatal("expected error for unknown account")
+	}
+
+	acc, err := client.CreateAccount(ctx, "render", "example.com", 22, "ssh", "")
+	if err != nil {
+		t.Fatalf("CreateAccount failed: %v", err)
+	}
+	// A fresh database has no system key to render.
+	if _, err := client.RenderAuthorizedKeys(ctx, acc.Id); err == nil || !strings.Contains(err.Error(), "system key") {
+		t.Fatalf("expected missing system key error, got %v", err)
+	}
+}
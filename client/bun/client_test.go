@@ -86,7 +86,7 @@ func TestBunClient_ListPublicKeys(t *testing.T) {
 		t.Fatalf("CreatePublicKey 1 failed: %v", err)
 	}
 
-	_, err = client.CreatePublicKey(ctx, "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5 key2", "key2", nil)
+	_, err = client.CreatePublicKey(ctx, "ssh-ed25519 AAAAC3NzaC1lZDI1NTE6 key2", "key2", nil)
 	if err != nil {
 		t.Fatalf("CreatePublicKey 2 failed: %v", err)
 	}
@@ -77,6 +77,10 @@ type Client interface {
 
 	VerifyAccounts(ctx context.Context, accountIds ...AccountId) (chan VerifyProgressAccounts, error)
 
+	// RenderAuthorizedKeys returns the authorized_keys content a deployment
+	// of the account would write.
+	RenderAuthorizedKeys(ctx context.Context, accountId AccountId) (string, error)
+
 	// --- Other ---
 
 	ListAuditLogs(ctx context.Context, limit int) ([]AuditLog, error) // TODO doesn't account for filtering and pagination
@@ -52,10 +52,11 @@ type ClientOverwrites struct {
 	DeleteLinks           func(ctx context.Context, ids ...client.LinkId) error
 
 	// --- Deploy & Verify ---
-	DeployAccount  func(ctx context.Context, accountId client.AccountId) (chan client.DeployProgressAccount, error)
-	DeployAccounts func(ctx context.Context, accountIds ...client.AccountId) (chan client.DeployProgressAccounts, error)
-	VerifyAccount  func(ctx context.Context, accountId client.AccountId) (chan client.VerifyProgressAccount, error)
-	VerifyAccounts func(ctx context.Context, accountIds ...client.AccountId) (chan client.VerifyProgressAccounts, error)
+	DeployAccount        func(ctx context.Context, accountId client.AccountId) (chan client.DeployProgressAccount, error)
+	DeployAccounts       func(ctx context.Context, accountIds ...client.AccountId) (chan client.DeployProgressAccounts, error)
+	VerifyAccount        func(ctx context.Context, accountId client.AccountId) (chan client.VerifyProgressAccount, error)
+	VerifyAccounts       func(ctx context.Context, accountIds ...client.AccountId) (chan client.VerifyProgressAccounts, error)
+	RenderAuthorizedKeys func(ctx context.Context, accountId client.AccountId) (string, error)
 
 	// --- Other ---
 	ListAuditLogs      func(ctx context.Context, limit int) ([]client.AuditLog, error)
@@ -558,6 +559,21 @@ func (m *Client) VerifyAccounts(ctx context.Context, accountIds ...client.Accoun
 	panic("Client.VerifyAccounts not implemented")
 }
 
+func (m *Client) RenderAuthorizedKeys(ctx context.Context, accountId client.AccountId) (string, error) {
+	if m.Pre != nil {
+		err := m.Pre("RenderAuthorizedKeys", map[string]any{"ctx": ctx, "accountId": accountId})
+		if err != nil {
+			return "", err
+		}
+	}
+	if m.Overwrites.RenderAuthorizedKeys != nil {
+		return m.Overwrites.RenderAuthorizedKeys(ctx, accountId)
+	} else if m.BaseClient != nil {
+		return m.BaseClient.RenderAuthorizedKeys(ctx, accountId)
+	}
+	panic("Client.RenderAuthorizedKeys not implemented")
+}
+
 // --- Other ---
 
 func (m *Client) ListAuditLogs(ctx context.Context, limit int) ([]client.AuditLog, error) {
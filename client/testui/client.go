@@ -608,6 +608,23 @@ func (c *Client) VerifyAccounts(ctx context.Context, accountIds ...client.Accoun
 	return verifyProgressChan, nil
 }
 
+func (c *Client) RenderAuthorizedKeys(ctx context.Context, accountId client.AccountId) (string, error) {
+	if _, err := c.GetAccount(ctx, accountId); err != nil {
+		return "", err
+	}
+	publicKeys, err := c.ListPublicKeysLinkedToAccount(ctx, accountId, false)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# Keymaster Managed Keys\n")
+	for _, publicKey := range publicKeys {
+		fmt.Fprintf(&sb, "%s %s %s\n", publicKey.Algorithm, publicKey.Data, publicKey.Comment)
+	}
+	return sb.String(), nil
+}
+
 // --- Other ---
 
 func (c *Client) ListAuditLogs(ctx context.Context, limit int) ([]client.AuditLog, error) {
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	log "github.com/charmbracelet/log"
 	"github.com/spf13/cobra"
@@ -21,12 +22,87 @@ const RuntimeOS = runtime.GOOS
 
 // Config holds the application's configuration, loaded from file/env/flags.
 type Config struct {
-	Database ConfigDatabase `mapstructure:"database"`
-	Language string         `mapstructure:"language"`
+	Database ConfigDatabase `mapstructure:"database" desc:"Database connection settings."`
+	Deploy   ConfigDeploy   `mapstructure:"deploy" yaml:"deploy,omitempty" desc:"Settings applied to every authorized_keys deployment."`
+	Log      ConfigLog      `mapstructure:"log" yaml:"log,omitempty" desc:"Diagnostic log output."`
+	Security ConfigSecurity `mapstructure:"security" yaml:"security,omitempty" desc:"Key policy and confirmation settings."`
+	SSH      ConfigSSH      `mapstructure:"ssh" yaml:"ssh,omitempty" desc:"Settings for outgoing SSH connections."`
+	Language string         `mapstructure:"language" default:"en" desc:"Language of the TUI, e.g. en or de."`
+	// AuditIdentity replaces the OS user recorded in audit log entries, e.g.
+	// the pipeline or operator behind an automation account. The
+	// KEYMASTER_AUDIT_USER environment variable takes precedence.
+	AuditIdentity string `mapstructure:"audit_identity" yaml:"audit_identity,omitempty" desc:"Identity recorded in audit log entries instead of the OS user. KEYMASTER_AUDIT_USER takes precedence."`
+	// RequireUniqueLabels rejects label updates that reuse another account's
+	// label, since labels become Host aliases in exported SSH configs.
+	RequireUniqueLabels bool `mapstructure:"require_unique_labels" yaml:"require_unique_labels,omitempty" desc:"Reject setting an account label that another account already uses; labels are Host aliases in exported SSH configs."`
 }
+
+// ConfigDatabase selects the database backend and how to reach it.
 type ConfigDatabase struct {
-	Type string `mapstructure:"type"`
-	Dsn  string `mapstructure:"dsn"`
+	Type string `mapstructure:"type" default:"sqlite" enum:"sqlite,postgres,mysql" desc:"Database backend."`
+	Dsn  string `mapstructure:"dsn" default:"./keymaster.db" desc:"Data source name: a file path for SQLite, a connection URL or DSN otherwise."`
+}
+
+// ConfigLog controls diagnostic log output.
+type ConfigLog struct {
+	// Format selects "text" (default) or "json" output.
+	Format string `mapstructure:"format" yaml:"format,omitempty" default:"text" enum:"text,json" desc:"Log output format."`
+}
+
+// ConfigSecurity holds key policy and confirmation settings.
+type ConfigSecurity struct {
+	// AllowedAlgorithms restricts the public key algorithms that may be
+	// added, imported, assigned and deployed. Empty allows all algorithms.
+	AllowedAlgorithms []string `mapstructure:"allowed_algorithms" yaml:"allowed_algorithms,omitempty" desc:"Public key algorithms that may be added, imported, assigned and deployed. Empty allows all algorithms."`
+	// TOTPSecret, a base32 secret shared with an authenticator app, makes
+	// rotate-key, decommission and full restore ask for a current TOTP code.
+	// Empty disables the check.
+	TOTPSecret string `mapstructure:"totp_secret" yaml:"totp_secret,omitempty" desc:"Base32 TOTP secret; when set, rotate-key, decommission and full restore ask for a current code."`
+}
+
+// ConfigSSH holds settings for outgoing SSH connections.
+type ConfigSSH struct {
+	// HostKeyPolicy decides how deploy and audit connections treat host keys:
+	// "strict" (default) only accepts hosts trusted beforehand, "tofu" trusts
+	// and saves a host's key on first use, and "insecure" accepts any key
+	// with a warning.
+	HostKeyPolicy string `mapstructure:"host_key_policy" yaml:"host_key_policy,omitempty" default:"strict" enum:"strict,tofu,insecure" desc:"How deploy and audit connections treat host keys: strict only accepts trusted hosts, tofu trusts and saves a key on first use, insecure accepts any key."`
+	// AutoRetrustHosts lists host patterns whose changed host keys
+	// 'audit --repair-known-hosts' replaces without asking, for hosts that
+	// are routinely rebuilt.
+	AutoRetrustHosts []string `mapstructure:"auto_retrust_hosts" yaml:"auto_retrust_hosts,omitempty" desc:"Host name globs, e.g. web-*.example.com, whose changed host keys audit --repair-known-hosts stores without asking. Empty allows none."`
+	// Ciphers, MACs and KexAlgorithms replace the algorithms offered on
+	// every connection, in order of preference, for FIPS-restricted or
+	// legacy sshd. Empty lists keep the Go defaults.
+	Ciphers       []string `mapstructure:"ciphers" yaml:"ciphers,omitempty" desc:"Ciphers offered on SSH connections, in order of preference, e.g. aes256-gcm@openssh.com. Empty keeps the Go defaults."`
+	MACs          []string `mapstructure:"macs" yaml:"macs,omitempty" desc:"MAC algorithms offered on SSH connections, in order of preference, e.g. hmac-sha2-256-etm@openssh.com. Empty keeps the Go defaults."`
+	KexAlgorithms []string `mapstructure:"kex_algorithms" yaml:"kex_algorithms,omitempty" desc:"Key exchange algorithms offered on SSH connections, in order of preference, e.g. curve25519-sha256. Empty keeps the Go defaults."`
+}
+
+// ConfigDeploy holds settings applied to every authorized_keys deployment.
+type ConfigDeploy struct {
+	// PostDeployCommand is run on the remote host over the deploy connection
+	// after authorized_keys was written (e.g. "sshd -t"). Empty disables it.
+	PostDeployCommand string `mapstructure:"post_deploy_command" yaml:"post_deploy_command,omitempty" desc:"Command run on the remote host after authorized_keys was written, e.g. sshd -t. Empty disables it."`
+	// PostDeployCommandWindows replaces PostDeployCommand for accounts on
+	// Windows hosts, whose shell is cmd.exe or PowerShell. Empty skips the
+	// post-deploy step there.
+	PostDeployCommandWindows string `mapstructure:"post_deploy_command_windows" yaml:"post_deploy_command_windows,omitempty" desc:"Replaces post_deploy_command for accounts on Windows hosts. Empty skips the post-deploy step there."`
+	// RollbackOnPostDeployFailure restores the previous authorized_keys when
+	// the post-deploy command exits non-zero.
+	RollbackOnPostDeployFailure bool `mapstructure:"rollback_on_post_deploy_failure" yaml:"rollback_on_post_deploy_failure,omitempty" default:"false" desc:"Restore the previous authorized_keys when the post-deploy command exits non-zero."`
+	// MinHostConnectionInterval is the minimum time between two SSH
+	// connections to the same host (e.g. "2s"). Zero disables throttling.
+	MinHostConnectionInterval time.Duration `mapstructure:"min_host_connection_interval" yaml:"min_host_connection_interval,omitempty" default:"0s" desc:"Minimum time between two SSH connections to the same host, e.g. 2s. Zero disables throttling."`
+	// MaxHostThrottleWait caps how long a connection waits for its turn
+	// before proceeding anyway. Zero uses the built-in default.
+	MaxHostThrottleWait time.Duration `mapstructure:"max_host_throttle_wait" yaml:"max_host_throttle_wait,omitempty" desc:"Longest a throttled connection waits for its turn before proceeding anyway. Zero uses the built-in default."`
+	// LockTimeout caps how long a deployment waits for another deployment to
+	// the same account to finish. Zero waits until it is done.
+	LockTimeout time.Duration `mapstructure:"lock_timeout" yaml:"lock_timeout,omitempty" default:"0s" desc:"Longest a deployment waits for another deployment to the same account. Zero waits until it is done."`
+	// LockFailFast fails a deployment right away when another deployment to
+	// the same account is in progress.
+	LockFailFast bool `mapstructure:"lock_fail_fast" yaml:"lock_fail_fast,omitempty" default:"false" desc:"Fail a deployment right away when another deployment to the same account is in progress."`
 }
 
 // GetConfigPath returns the full path for the configuration file.
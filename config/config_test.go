@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -101,6 +102,52 @@ func TestLoadConfig_ReadsExplicitFile(t *testing.T) {
 	}
 }
 
+func TestLoadConfig_ReadsDeploySection(t *testing.T) {
+	tmp := t.TempDir()
+	yaml := "database:\n  type: sqlite\n  dsn: ./k.db\ndeploy:\n  post_deploy_command: sshd -t\n  rollback_on_post_deploy_failure: true\n  min_host_connection_interval: 2s\n  lock_timeout: 1m\n  lock_fail_fast: true\n"
+	file := filepath.Join(tmp, "cfg.yaml")
+	if err := os.WriteFile(file, []byte(yaml), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	resetViper()
+	defer resetViper()
+
+	got, err := cfg.LoadConfig[cfg.Config](&cobra.Command{}, map[string]any{}, &file)
+	if err != nil {
+		t.Fatalf("LoadConfig returned error: %v", err)
+	}
+	if got.Deploy.PostDeployCommand != "sshd -t" || !got.Deploy.RollbackOnPostDeployFailure {
+		t.Fatalf("unexpected deploy config: %+v", got.Deploy)
+	}
+	if got.Deploy.MinHostConnectionInterval != 2*time.Second {
+		t.Fatalf("expected 2s interval, got %v", got.Deploy.MinHostConnectionInterval)
+	}
+	if got.Deploy.LockTimeout != time.Minute || !got.Deploy.LockFailFast {
+		t.Fatalf("unexpected deploy lock config: %+v", got.Deploy)
+	}
+}
+
+func TestLoadConfig_ReadsSSHSection(t *testing.T) {
+	tmp := t.TempDir()
+	yaml := "database:\n  type: sqlite\n  dsn: ./k.db\nssh:\n  host_key_policy: tofu\n"
+	file := filepath.Join(tmp, "cfg.yaml")
+	if err := os.WriteFile(file, []byte(yaml), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	resetViper()
+	defer resetViper()
+
+	got, err := cfg.LoadConfig[cfg.Config](&cobra.Command{}, map[string]any{}, &file)
+	if err != nil {
+		t.Fatalf("LoadConfig returned error: %v", err)
+	}
+	if got.SSH.HostKeyPolicy != "tofu" {
+		t.Fatalf("expected tofu host key policy, got %+v", got.SSH)
+	}
+}
+
 func TestLoadConfig_BrokenConfig_ReturnsParseError(t *testing.T) {
 	tmp := t.TempDir()
 	// Write a file containing a control character (0x01) which YAML forbids
@@ -0,0 +1,104 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// durationPattern matches the durations accepted by time.ParseDuration.
+const durationPattern = `^(0|([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+)$`
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Schema describes keymaster.yaml as a JSON Schema document. It is derived
+// from [Config] by reflection: keys come from the mapstructure tags, and the
+// desc, default and enum tags supply descriptions, defaults and allowed
+// values. Unknown keys are rejected so editors flag typos.
+func Schema() map[string]any {
+	s := structSchema(reflect.TypeOf(Config{}))
+	s["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	s["title"] = "Keymaster configuration"
+	return s
+}
+
+// JSONSchema returns [Schema] as indented JSON.
+func JSONSchema() ([]byte, error) {
+	return json.MarshalIndent(Schema(), "", "  ")
+}
+
+func structSchema(t reflect.Type) map[string]any {
+	props := make(map[string]any, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("mapstructure"), ",")[0]
+		if !f.IsExported() || name == "" || name == "-" {
+			continue
+		}
+		props[name] = fieldSchema(f)
+	}
+	return map[string]any{
+		"type":                 "object",
+		"properties":           props,
+		"additionalProperties": false,
+	}
+}
+
+func fieldSchema(f reflect.StructField) map[string]any {
+	s := typeSchema(f.Type)
+	if desc := f.Tag.Get("desc"); desc != "" {
+		s["description"] = desc
+	}
+	if enum := f.Tag.Get("enum"); enum != "" {
+		s["enum"] = strings.Split(enum, ",")
+	}
+	if def, ok := f.Tag.Lookup("default"); ok {
+		s["default"] = defaultValue(f.Type, def)
+	}
+	return s
+}
+
+func typeSchema(t reflect.Type) map[string]any {
+	if t == durationType {
+		return map[string]any{"type": "string", "pattern": durationPattern}
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		return structSchema(t)
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		return map[string]any{"type": "string"}
+	}
+}
+
+// defaultValue converts a default tag to the JSON type of the field. Tags
+// that do not parse are kept as strings.
+func defaultValue(t reflect.Type, def string) any {
+	if t == durationType {
+		return def
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(def); err == nil {
+			return b
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, err := strconv.ParseInt(def, 10, 64); err == nil {
+			return n
+		}
+	}
+	return def
+}
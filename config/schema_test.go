@@ -0,0 +1,73 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package config_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	cfg "github.com/toeirei/keymaster/config"
+)
+
+func TestJSONSchema_CoversEveryField(t *testing.T) {
+	raw, err := cfg.JSONSchema()
+	if err != nil {
+		t.Fatalf("JSONSchema: %v", err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+	if schema["$schema"] == nil || schema["type"] != "object" {
+		t.Fatalf("unexpected schema header: %v", schema)
+	}
+
+	var check func(path string, typ reflect.Type, node map[string]any)
+	check = func(path string, typ reflect.Type, node map[string]any) {
+		props, _ := node["properties"].(map[string]any)
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i)
+			name := strings.Split(f.Tag.Get("mapstructure"), ",")[0]
+			prop, ok := props[name].(map[string]any)
+			if !ok {
+				t.Fatalf("schema is missing %s%s", path, name)
+			}
+			if desc, _ := prop["description"].(string); desc == "" {
+				t.Errorf("%s%s has no description", path, name)
+			}
+			if f.Type.Kind() == reflect.Struct {
+				check(path+name+".", f.Type, prop)
+			}
+		}
+	}
+	check("", reflect.TypeOf(cfg.Config{}), schema)
+}
+
+func TestSchema_TypesDefaultsAndEnums(t *testing.T) {
+	props := cfg.Schema()["properties"].(map[string]any)
+	prop := func(section, name string) map[string]any {
+		t.Helper()
+		p := props[section].(map[string]any)["properties"].(map[string]any)[name]
+		if p == nil {
+			t.Fatalf("missing %s.%s", section, name)
+		}
+		return p.(map[string]any)
+	}
+
+	db := prop("database", "type")
+	if db["default"] != "sqlite" || !reflect.DeepEqual(db["enum"], []string{"sqlite", "postgres", "mysql"}) {
+		t.Fatalf("unexpected database.type schema: %v", db)
+	}
+	if p := prop("deploy", "lock_fail_fast"); p["type"] != "boolean" || p["default"] != false {
+		t.Fatalf("unexpected deploy.lock_fail_fast schema: %v", p)
+	}
+	if p := prop("deploy", "lock_timeout"); p["type"] != "string" || p["pattern"] == nil {
+		t.Fatalf("expected durations to be pattern-checked strings, got %v", p)
+	}
+	if p := prop("security", "allowed_algorithms"); p["type"] != "array" {
+		t.Fatalf("unexpected security.allowed_algorithms schema: %v", p)
+	}
+}
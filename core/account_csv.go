@@ -0,0 +1,153 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// accountCSVColumns is the column order of an account CSV without header.
+var accountCSVColumns = []string{"username", "hostname", "label", "tags"}
+
+// AccountCSVImportOptions controls ImportAccountsCSV.
+type AccountCSVImportOptions struct {
+	// Strict rejects the whole file, before any account is created, when a
+	// row is invalid. Otherwise invalid rows are reported and skipped.
+	Strict bool
+}
+
+// AccountCSVRowError reports a problem with a single CSV row.
+type AccountCSVRowError struct {
+	Line int
+	Err  error
+}
+
+func (e AccountCSVRowError) Error() string { return fmt.Sprintf("line %d: %v", e.Line, e.Err) }
+
+// AccountCSVImportResult summarizes an account CSV import.
+type AccountCSVImportResult struct {
+	Created int
+	// Skipped counts rows whose username@hostname already exists.
+	Skipped int
+	Errors  []AccountCSVRowError
+}
+
+type accountCSVRow struct {
+	line                            int
+	username, hostname, label, tags string
+}
+
+// ImportAccountsCSV creates an account for each row of r via CreateAccount.
+// Rows are username,hostname,label,tags; label and tags may be omitted. An
+// optional header row naming the columns may reorder them. Rows whose
+// username@hostname matches an account in existing, or an earlier row, are
+// skipped.
+func ImportAccountsCSV(am AccountManager, existing []model.Account, r io.Reader, opts AccountCSVImportOptions) (AccountCSVImportResult, error) {
+	var res AccountCSVImportResult
+	rows, rowErrs, err := parseAccountCSV(r)
+	if err != nil {
+		return res, err
+	}
+	res.Errors = rowErrs
+	if opts.Strict && len(rowErrs) > 0 {
+		return res, fmt.Errorf("%d invalid rows; no accounts were created", len(rowErrs))
+	}
+
+	seen := make(map[string]bool, len(existing))
+	for _, acc := range existing {
+		seen[strings.ToLower(acc.Username+"@"+acc.Hostname)] = true
+	}
+	for _, row := range rows {
+		key := strings.ToLower(row.username + "@" + row.hostname)
+		if seen[key] {
+			res.Skipped++
+			continue
+		}
+		if _, err := CreateAccount(am, row.username, row.hostname, row.label, row.tags); err != nil {
+			rowErr := AccountCSVRowError{Line: row.line, Err: err}
+			res.Errors = append(res.Errors, rowErr)
+			if opts.Strict {
+				return res, rowErr
+			}
+			continue
+		}
+		seen[key] = true
+		res.Created++
+	}
+	return res, nil
+}
+
+// parseAccountCSV reads and validates all rows. Malformed or incomplete rows
+// are returned as row errors; the error return is reserved for read failures.
+func parseAccountCSV(r io.Reader) ([]accountCSVRow, []AccountCSVRowError, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	cr.Comment = '#'
+
+	columns := map[string]int{}
+	for i, name := range accountCSVColumns {
+		columns[name] = i
+	}
+	var rows []accountCSVRow
+	var rowErrs []AccountCSVRowError
+	first := true
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var pe *csv.ParseError
+			if errors.As(err, &pe) {
+				rowErrs = append(rowErrs, AccountCSVRowError{Line: pe.Line, Err: pe.Err})
+				continue
+			}
+			return nil, nil, fmt.Errorf("read csv: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+		if first {
+			first = false
+			if header, ok := accountCSVHeader(record); ok {
+				columns = header
+				continue
+			}
+		}
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		row := accountCSVRow{line: line, username: field("username"), hostname: field("hostname"), label: field("label"), tags: field("tags")}
+		switch {
+		case row.username == "":
+			rowErrs = append(rowErrs, AccountCSVRowError{Line: line, Err: errors.New("username is required")})
+		case row.hostname == "":
+			rowErrs = append(rowErrs, AccountCSVRowError{Line: line, Err: errors.New("hostname is required")})
+		default:
+			rows = append(rows, row)
+		}
+	}
+	return rows, rowErrs, nil
+}
+
+// accountCSVHeader maps column names to indexes when record is a header row
+// naming at least the username and hostname columns.
+func accountCSVHeader(record []string) (map[string]int, bool) {
+	columns := map[string]int{}
+	for i, name := range record {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	_, hasUser := columns["username"]
+	_, hasHost := columns["hostname"]
+	return columns, hasUser && hasHost
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// csvAccountManager records created accounts.
+type csvAccountManager struct {
+	created []string
+}
+
+func (m *csvAccountManager) AddAccount(username, hostname, label, tags string) (int, error) {
+	m.created = append(m.created, username+"@"+hostname+"|"+label+"|"+tags)
+	return len(m.created), nil
+}
+func (m *csvAccountManager) DeleteAccount(id int) error { return nil }
+
+const accountCSVFixture = `username,hostname,label,tags
+deploy,web-01,Web 1,"env:prod,team:web"
+deploy,db-01,,
+,web-02,missing user,
+app,,missing host,
+Deploy,WEB-01,duplicate of existing,
+backup,db-01,Backup,env:prod
+`
+
+func TestImportAccountsCSV(t *testing.T) {
+	am := &csvAccountManager{}
+	existing := []model.Account{{ID: 9, Username: "deploy", Hostname: "web-01"}}
+
+	res, err := ImportAccountsCSV(am, existing, strings.NewReader(accountCSVFixture), AccountCSVImportOptions{})
+	if err != nil {
+		t.Fatalf("ImportAccountsCSV: %v", err)
+	}
+	if res.Created != 2 || res.Skipped != 2 || len(res.Errors) != 2 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if res.Errors[0].Line != 4 || res.Errors[1].Line != 5 {
+		t.Fatalf("unexpected error lines %+v", res.Errors)
+	}
+	want := []string{"deploy@db-01||", "backup@db-01|Backup|env:prod"}
+	if !reflect.DeepEqual(am.created, want) {
+		t.Fatalf("created %v, want %v", am.created, want)
+	}
+}
+
+func TestImportAccountsCSV_StrictCreatesNothing(t *testing.T) {
+	am := &csvAccountManager{}
+	res, err := ImportAccountsCSV(am, nil, strings.NewReader(accountCSVFixture), AccountCSVImportOptions{Strict: true})
+	if err == nil {
+		t.Fatal("expected strict import to fail on invalid rows")
+	}
+	if len(am.created) != 0 || res.Created != 0 || len(res.Errors) != 2 {
+		t.Fatalf("expected no accounts and two row errors, got %+v created=%v", res, am.created)
+	}
+}
+
+func TestImportAccountsCSV_NoHeaderAndDuplicateRows(t *testing.T) {
+	am := &csvAccountManager{}
+	csv := "root,host-a\nroot,host-a,again\nops,host-b,Ops\n"
+	res, err := ImportAccountsCSV(am, nil, strings.NewReader(csv), AccountCSVImportOptions{})
+	if err != nil {
+		t.Fatalf("ImportAccountsCSV: %v", err)
+	}
+	if res.Created != 2 || res.Skipped != 1 || len(res.Errors) != 0 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// ValidateAccountGroup checks a group name. Names are matched exactly, so
+// they may not contain whitespace or commas, which would be easy to confuse
+// with a tag list.
+func ValidateAccountGroup(group string) error {
+	if strings.ContainsAny(group, ", \t\r\n") {
+		return fmt.Errorf("invalid group name %q: whitespace and commas are not allowed", group)
+	}
+	return nil
+}
+
+// MoveAccountToGroup moves account id into group. An empty group removes the
+// account from its group.
+func MoveAccountToGroup(st AccountGroupStore, id int, group string) error {
+	group = strings.TrimSpace(group)
+	if err := ValidateAccountGroup(group); err != nil {
+		return err
+	}
+	accounts, err := st.GetAllAccounts()
+	if err != nil {
+		return fmt.Errorf("failed to load accounts: %w", err)
+	}
+	for _, acc := range accounts {
+		if acc.ID == id {
+			if err := st.SetAccountGroup(id, group); err != nil {
+				return fmt.Errorf("failed to save group: %w", err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("account not found: %d", id)
+}
+
+// AccountsInGroup returns the accounts whose group is exactly group, keeping
+// their order.
+func AccountsInGroup(accounts []model.Account, group string) []model.Account {
+	var out []model.Account
+	for _, acc := range accounts {
+		if acc.Group == group {
+			out = append(out, acc)
+		}
+	}
+	return out
+}
+
+// selectAccountGroup narrows accounts to the members of group. An empty group
+// keeps every account; a group without members among accounts is an error,
+// so a mistyped name never silently selects nothing.
+func selectAccountGroup(accounts []model.Account, group string) ([]model.Account, error) {
+	group = strings.TrimSpace(group)
+	if group == "" {
+		return accounts, nil
+	}
+	members := AccountsInGroup(accounts, group)
+	if len(members) == 0 {
+		return nil, fmt.Errorf("no accounts in group %q", group)
+	}
+	return members, nil
+}
+
+// AccountGroupInfo summarizes an account group for listing.
+type AccountGroupInfo struct {
+	Name    string
+	Members int
+	// Active counts the members that are active.
+	Active int
+}
+
+// ListAccountGroups returns the groups used by accounts, ordered by name.
+func ListAccountGroups(accounts []model.Account) []AccountGroupInfo {
+	byName := make(map[string]*AccountGroupInfo)
+	for _, acc := range accounts {
+		if acc.Group == "" {
+			continue
+		}
+		info, ok := byName[acc.Group]
+		if !ok {
+			info = &AccountGroupInfo{Name: acc.Group}
+			byName[acc.Group] = info
+		}
+		info.Members++
+		if acc.IsActive {
+			info.Active++
+		}
+	}
+	out := make([]AccountGroupInfo, 0, len(byName))
+	for _, info := range byName {
+		out = append(out, *info)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
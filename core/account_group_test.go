@@ -0,0 +1,113 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// groupStore is an in-memory AccountGroupStore.
+type groupStore struct {
+	accounts []model.Account
+}
+
+func (s *groupStore) GetAllAccounts() ([]model.Account, error) { return s.accounts, nil }
+
+func (s *groupStore) SetAccountGroup(id int, group string) error {
+	for i := range s.accounts {
+		if s.accounts[i].ID == id {
+			s.accounts[i].Group = group
+		}
+	}
+	return nil
+}
+
+func groupAccounts() []model.Account {
+	return []model.Account{
+		{ID: 1, Username: "app", Hostname: "web-01", Group: "web", IsActive: true, DeployEnabled: true},
+		{ID: 2, Username: "app", Hostname: "web-02", Group: "web", IsActive: true, DeployEnabled: true},
+		{ID: 3, Username: "app", Hostname: "webhook-01", Group: "webhook", IsActive: true, DeployEnabled: true},
+		{ID: 4, Username: "app", Hostname: "db-01", IsActive: true, DeployEnabled: true},
+	}
+}
+
+func TestDeployAccountGroup_TargetsOnlyMembers(t *testing.T) {
+	dm := &canaryDM{}
+	results, err := DeployAccountsWithOptions(context.Background(), &simpleFakeStore{accounts: groupAccounts()}, dm, nil, DeployRunOptions{AccountGroup: "web"}, nil)
+	if err != nil {
+		t.Fatalf("DeployAccountsWithOptions: %v", err)
+	}
+	if !reflect.DeepEqual(dm.deployed, []int{1, 2}) {
+		t.Fatalf("expected only members of web deployed, got %v", dm.deployed)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected a result per member, got %+v", results)
+	}
+}
+
+func TestDeployAccountGroup_UnknownGroupErrors(t *testing.T) {
+	dm := &canaryDM{}
+	_, err := DeployAccountsWithOptions(context.Background(), &simpleFakeStore{accounts: groupAccounts()}, dm, nil, DeployRunOptions{AccountGroup: "we"}, nil)
+	if err == nil || !strings.Contains(err.Error(), `no accounts in group "we"`) {
+		t.Fatalf("expected unknown group error, got %v", err)
+	}
+	if len(dm.deployed) != 0 {
+		t.Fatalf("expected nothing deployed, got %v", dm.deployed)
+	}
+}
+
+func TestAuditAccountGroup_TargetsOnlyMembers(t *testing.T) {
+	dm := &auditRecordingDM{}
+	if _, err := AuditAccountsWithOptions(context.Background(), &simpleFakeStore{accounts: groupAccounts()}, dm, "serial", AuditOptions{AccountGroup: "webhook"}, nil); err != nil {
+		t.Fatalf("AuditAccountsWithOptions: %v", err)
+	}
+	if !reflect.DeepEqual(dm.audited, []int{3}) {
+		t.Fatalf("expected only members of webhook audited, got %v", dm.audited)
+	}
+}
+
+func TestMoveAccountToGroup(t *testing.T) {
+	st := &groupStore{accounts: groupAccounts()}
+	if err := MoveAccountToGroup(st, 4, " db "); err != nil {
+		t.Fatalf("MoveAccountToGroup: %v", err)
+	}
+	if st.accounts[3].Group != "db" {
+		t.Fatalf("expected account 4 in group db, got %q", st.accounts[3].Group)
+	}
+	if err := MoveAccountToGroup(st, 1, ""); err != nil {
+		t.Fatalf("MoveAccountToGroup remove: %v", err)
+	}
+	if st.accounts[0].Group != "" {
+		t.Fatalf("expected account 1 removed from its group, got %q", st.accounts[0].Group)
+	}
+	if err := MoveAccountToGroup(st, 2, "web,db"); err == nil {
+		t.Fatal("expected a group name with a comma to be rejected")
+	}
+	if err := MoveAccountToGroup(st, 99, "web"); err == nil {
+		t.Fatal("expected an unknown account to be rejected")
+	}
+}
+
+func TestListAccountGroups(t *testing.T) {
+	accounts := groupAccounts()
+	accounts[1].IsActive = false
+	got := ListAccountGroups(accounts)
+	want := []AccountGroupInfo{{Name: "web", Members: 2, Active: 1}, {Name: "webhook", Members: 1, Active: 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ListAccountGroups = %+v, want %+v", got, want)
+	}
+	names := make([]string, 0, len(got))
+	for _, g := range got {
+		names = append(names, g.Name)
+	}
+	if !slices.IsSorted(names) {
+		t.Fatalf("expected groups sorted by name, got %v", names)
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// ErrDuplicateLabel is returned when unique labels are required and another
+// account already uses the label.
+var ErrDuplicateLabel = errors.New("label already used by another account")
+
+var requireUniqueLabels atomic.Bool
+
+// SetRequireUniqueLabels makes label updates reject a label another account
+// already uses. Labels become Host aliases in exported SSH configs, where
+// duplicates produce conflicting entries.
+func SetRequireUniqueLabels(require bool) { requireUniqueLabels.Store(require) }
+
+// CheckLabelAvailable returns ErrDuplicateLabel when unique labels are
+// required and an account other than id uses label. Labels are compared
+// case-insensitively, as SSH matches Host aliases; an empty label is always
+// available.
+func CheckLabelAvailable(accounts []model.Account, id int, label string) error {
+	label = strings.TrimSpace(label)
+	if !requireUniqueLabels.Load() || label == "" {
+		return nil
+	}
+	for _, acc := range accounts {
+		if acc.ID != id && strings.EqualFold(strings.TrimSpace(acc.Label), label) {
+			return fmt.Errorf("%w: %q is the label of %s (ID %d)", ErrDuplicateLabel, label, acc.String(), acc.ID)
+		}
+	}
+	return nil
+}
+
+// SetAccountLabel sets the label of the account with the given ID, checking
+// it with CheckLabelAvailable first.
+func SetAccountLabel(st Store, id int, label string) error {
+	return UpdateAccount(st, id, nil, &label, nil)
+}
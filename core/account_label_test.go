@@ -0,0 +1,74 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// labelStore records label updates on its accounts.
+type labelStore struct {
+	simpleFakeStore
+}
+
+func (s *labelStore) GetAllAccounts() ([]model.Account, error) { return s.accounts, nil }
+func (s *labelStore) UpdateAccountLabel(id int, label string) error {
+	for i := range s.accounts {
+		if s.accounts[i].ID == id {
+			s.accounts[i].Label = label
+		}
+	}
+	return nil
+}
+
+func withUniqueLabels(t *testing.T) {
+	t.Helper()
+	SetRequireUniqueLabels(true)
+	t.Cleanup(func() { SetRequireUniqueLabels(false) })
+}
+
+func TestSetAccountLabel_RequireUniqueLabels(t *testing.T) {
+	withUniqueLabels(t)
+	st := &labelStore{simpleFakeStore{accounts: []model.Account{
+		{ID: 1, Username: "app", Hostname: "web-01", Label: "web"},
+		{ID: 2, Username: "app", Hostname: "web-02", Label: "web-2"},
+	}}}
+
+	err := SetAccountLabel(st, 2, " WEB ")
+	if !errors.Is(err, ErrDuplicateLabel) {
+		t.Fatalf("expected ErrDuplicateLabel, got %v", err)
+	}
+	if st.accounts[1].Label != "web-2" {
+		t.Fatalf("rejected label must not be stored, got %q", st.accounts[1].Label)
+	}
+
+	// An account keeping its own label, a new label and clearing are allowed.
+	for _, tc := range []struct {
+		id    int
+		label string
+	}{{1, "web"}, {2, "web-02"}, {2, ""}} {
+		if err := SetAccountLabel(st, tc.id, tc.label); err != nil {
+			t.Fatalf("SetAccountLabel(%d, %q): %v", tc.id, tc.label, err)
+		}
+	}
+	if err := SetAccountLabel(st, 99, "db"); err == nil {
+		t.Fatal("expected error for unknown account")
+	}
+}
+
+func TestSetAccountLabel_DuplicatesAllowedByDefault(t *testing.T) {
+	st := &labelStore{simpleFakeStore{accounts: []model.Account{
+		{ID: 1, Label: "web"},
+		{ID: 2},
+	}}}
+	if err := SetAccountLabel(st, 2, "web"); err != nil {
+		t.Fatalf("expected duplicate label to be accepted without the setting, got %v", err)
+	}
+	if st.accounts[1].Label != "web" {
+		t.Fatalf("expected label to be stored, got %q", st.accounts[1].Label)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/toeirei/keymaster/core/db"
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// ScheduledStatusChange records an activation change applied by
+// ApplyAccountSchedules.
+type ScheduledStatusChange struct {
+	Account model.Account
+	// Enabled is the account's new active state.
+	Enabled bool
+}
+
+// scheduleNow is the clock used by the background schedule task.
+var scheduleNow = time.Now
+
+var registerAccountScheduleTask sync.Once
+
+// ScheduleAccount sets when an account is automatically disabled and/or
+// re-enabled. A zero time clears that part of the schedule. Both orders are
+// allowed: disable-then-enable describes a maintenance window, while
+// enable-then-disable grants temporary access to an inactive account.
+func ScheduleAccount(st AccountScheduler, id int, disableAt, enableAt time.Time) error {
+	accounts, err := st.GetAllAccounts()
+	if err != nil {
+		return fmt.Errorf("failed to load accounts: %w", err)
+	}
+	found := false
+	for _, acc := range accounts {
+		if acc.ID == id {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("account not found: %d", id)
+	}
+	if !disableAt.IsZero() && !enableAt.IsZero() && disableAt.Equal(enableAt) {
+		return fmt.Errorf("disable and enable times must differ")
+	}
+	if err := st.SetAccountSchedule(id, disableAt, enableAt); err != nil {
+		return fmt.Errorf("failed to save schedule: %w", err)
+	}
+	return nil
+}
+
+// ApplyAccountSchedules flips the status of accounts whose scheduled disable
+// or enable time is at or before now and clears the schedule entries it
+// consumed. When both entries are due, the later one determines the final
+// state. Errors for individual accounts are collected and returned together
+// after all accounts were processed.
+func ApplyAccountSchedules(st AccountScheduler, now time.Time) ([]ScheduledStatusChange, error) {
+	accounts, err := st.GetAllAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load accounts: %w", err)
+	}
+
+	var changes []ScheduledStatusChange
+	var errs []error
+	for _, acc := range accounts {
+		dueDisable := !acc.DisableAt.IsZero() && !now.Before(acc.DisableAt)
+		dueEnable := !acc.EnableAt.IsZero() && !now.Before(acc.EnableAt)
+		if !dueDisable && !dueEnable {
+			continue
+		}
+
+		enabled := dueEnable
+		if dueDisable && dueEnable {
+			enabled = acc.EnableAt.After(acc.DisableAt)
+		}
+
+		if enabled != acc.IsActive {
+			if err := st.ToggleAccountStatus(acc.ID, enabled); err != nil {
+				errs = append(errs, fmt.Errorf("account %d: %w", acc.ID, err))
+				continue
+			}
+			changes = append(changes, ScheduledStatusChange{Account: acc, Enabled: enabled})
+			action := "ACCOUNT_SCHEDULED_DISABLE"
+			if enabled {
+				action = "ACCOUNT_SCHEDULED_ENABLE"
+			}
+			if aw := DefaultAuditWriter(); aw != nil {
+				_ = aw.LogAction(action, fmt.Sprintf("%s %s", model.AccountRef(acc.ID), acc.String()))
+			}
+			DefaultLogger().Info("applied account schedule", "account", acc.String(), "enabled", enabled)
+		}
+
+		disableAt, enableAt := acc.DisableAt, acc.EnableAt
+		if dueDisable {
+			disableAt = time.Time{}
+		}
+		if dueEnable {
+			enableAt = time.Time{}
+		}
+		if err := st.SetAccountSchedule(acc.ID, disableAt, enableAt); err != nil {
+			errs = append(errs, fmt.Errorf("account %d: clear schedule: %w", acc.ID, err))
+		}
+	}
+	return changes, errors.Join(errs...)
+}
+
+// runAccountSchedules is the session reaper task applying due schedules
+// against the package-level store.
+func runAccountSchedules() {
+	st := db.DefaultStore()
+	if st == nil {
+		return
+	}
+	if _, err := ApplyAccountSchedules(st, scheduleNow()); err != nil {
+		DefaultLogger().Warn("applying account schedules failed", "err", err)
+	}
+}
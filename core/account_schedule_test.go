@@ -0,0 +1,128 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// memScheduler is an in-memory AccountScheduler.
+type memScheduler struct {
+	accounts map[int]*model.Account
+}
+
+func newMemScheduler(accts ...model.Account) *memScheduler {
+	m := &memScheduler{accounts: make(map[int]*model.Account)}
+	for i := range accts {
+		a := accts[i]
+		m.accounts[a.ID] = &a
+	}
+	return m
+}
+
+func (m *memScheduler) GetAllAccounts() ([]model.Account, error) {
+	out := make([]model.Account, 0, len(m.accounts))
+	for _, a := range m.accounts {
+		out = append(out, *a)
+	}
+	return out, nil
+}
+
+func (m *memScheduler) ToggleAccountStatus(id int, enabled bool) error {
+	m.accounts[id].IsActive = enabled
+	return nil
+}
+
+func (m *memScheduler) SetAccountSchedule(id int, disableAt, enableAt time.Time) error {
+	m.accounts[id].DisableAt = disableAt
+	m.accounts[id].EnableAt = enableAt
+	return nil
+}
+
+func TestApplyAccountSchedules_MaintenanceWindow(t *testing.T) {
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	disableAt := base.Add(time.Hour)
+	enableAt := base.Add(3 * time.Hour)
+
+	st := newMemScheduler(model.Account{ID: 1, Username: "u", Hostname: "h", IsActive: true})
+	if err := ScheduleAccount(st, 1, disableAt, enableAt); err != nil {
+		t.Fatalf("ScheduleAccount: %v", err)
+	}
+
+	steps := []struct {
+		now    time.Time
+		active bool
+	}{
+		{base, true},
+		{disableAt.Add(-time.Second), true},
+		{disableAt, false},
+		{enableAt.Add(-time.Minute), false},
+		{enableAt, true},
+		{enableAt.Add(time.Hour), true},
+	}
+	for i, step := range steps {
+		if _, err := ApplyAccountSchedules(st, step.now); err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		if got := st.accounts[1].IsActive; got != step.active {
+			t.Fatalf("step %d (%s): expected active=%v, got %v", i, step.now, step.active, got)
+		}
+	}
+	if !st.accounts[1].DisableAt.IsZero() || !st.accounts[1].EnableAt.IsZero() {
+		t.Fatalf("expected schedule to be cleared, got %+v", st.accounts[1])
+	}
+}
+
+func TestApplyAccountSchedules_BothDueLaterWins(t *testing.T) {
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	// The reaper missed the whole window: the later enable wins.
+	st := newMemScheduler(
+		model.Account{ID: 1, IsActive: true, DisableAt: base, EnableAt: base.Add(time.Hour)},
+		// Temporary access for an inactive account: the later disable wins.
+		model.Account{ID: 2, IsActive: false, EnableAt: base, DisableAt: base.Add(time.Hour)},
+	)
+
+	changes, err := ApplyAccountSchedules(st, base.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("ApplyAccountSchedules: %v", err)
+	}
+	if len(changes) != 0 {
+		t.Fatalf("expected no net status changes, got %+v", changes)
+	}
+	if !st.accounts[1].IsActive || st.accounts[2].IsActive {
+		t.Fatalf("unexpected final states: %+v %+v", st.accounts[1], st.accounts[2])
+	}
+}
+
+func TestApplyAccountSchedules_RecordsChanges(t *testing.T) {
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	st := newMemScheduler(
+		model.Account{ID: 1, IsActive: true, DisableAt: now.Add(-time.Minute)},
+		model.Account{ID: 2, IsActive: true, DisableAt: now.Add(time.Minute)},
+	)
+	changes, err := ApplyAccountSchedules(st, now)
+	if err != nil {
+		t.Fatalf("ApplyAccountSchedules: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Account.ID != 1 || changes[0].Enabled {
+		t.Fatalf("unexpected changes: %+v", changes)
+	}
+	if st.accounts[2].DisableAt.IsZero() || !st.accounts[2].IsActive {
+		t.Fatalf("future schedule must be left untouched: %+v", st.accounts[2])
+	}
+}
+
+func TestScheduleAccount_Validation(t *testing.T) {
+	st := newMemScheduler(model.Account{ID: 1})
+	at := time.Now()
+	if err := ScheduleAccount(st, 99, at, time.Time{}); err == nil {
+		t.Fatalf("expected error for unknown account")
+	}
+	if err := ScheduleAccount(st, 1, at, at); err == nil {
+		t.Fatalf("expected error for identical disable/enable times")
+	}
+}
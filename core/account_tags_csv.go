@@ -0,0 +1,136 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// accountTagPattern is the syntax of a single account tag. It keeps tags
+// matchable by tag expressions ("&", "|", "!", "(" and "*" are operators
+// there) and free of the comma and spaces that separate them.
+var accountTagPattern = regexp.MustCompile(`^[a-zA-Z0-9_\-+/.:~=@]+$`)
+
+// ValidateAccountTags checks every tag of a comma-separated tags string.
+// Empty entries are ignored.
+func ValidateAccountTags(tags string) error {
+	for _, t := range SplitTags(tags) {
+		if !accountTagPattern.MatchString(t) {
+			return fmt.Errorf("invalid tag %q: only letters, digits and _-+/.:~=@ are allowed", t)
+		}
+	}
+	return nil
+}
+
+// AccountTagsCSVOptions controls SetAccountTagsCSV.
+type AccountTagsCSVOptions struct {
+	// Strict rejects the whole file, before any account is changed, when a
+	// row is invalid or names an unknown account. Otherwise such rows are
+	// reported and skipped.
+	Strict bool
+}
+
+// AccountTagsCSVResult summarizes a bulk tag update.
+type AccountTagsCSVResult struct {
+	Applied int
+	// Skipped counts rows whose account already has exactly these tags.
+	Skipped int
+	Errors  []AccountCSVRowError
+}
+
+type accountTagsCSVRow struct {
+	line      int
+	accountID int
+	account   string
+	tags      string
+}
+
+// SetAccountTagsCSV replaces the tags of the accounts listed in r via
+// UpdateAccountTags. Rows are identifier,tags where identifier is an account
+// ID, user@host or label; tags may be quoted or spread over the remaining
+// fields, and an empty tags field clears them. A header row starting with
+// "identifier" is skipped. Rows naming an unknown account or carrying a
+// malformed tag are reported as errors.
+func SetAccountTagsCSV(st AccountTagsStore, r io.Reader, opts AccountTagsCSVOptions) (AccountTagsCSVResult, error) {
+	var res AccountTagsCSVResult
+	accounts, err := st.GetAllAccounts()
+	if err != nil {
+		return res, fmt.Errorf("failed to load accounts: %w", err)
+	}
+	current := make(map[int]string, len(accounts))
+	for _, acc := range accounts {
+		current[acc.ID] = ModifyAccountTags(acc.Tags, nil, nil)
+	}
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	cr.Comment = '#'
+
+	var rows []accountTagsCSVRow
+	first := true
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var pe *csv.ParseError
+			if errors.As(err, &pe) {
+				res.Errors = append(res.Errors, AccountCSVRowError{Line: pe.Line, Err: pe.Err})
+				continue
+			}
+			return res, fmt.Errorf("read csv: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+		identifier := strings.TrimSpace(record[0])
+		if first {
+			first = false
+			if strings.EqualFold(identifier, "identifier") {
+				continue
+			}
+		}
+		if identifier == "" {
+			res.Errors = append(res.Errors, AccountCSVRowError{Line: line, Err: errors.New("identifier is required")})
+			continue
+		}
+		tags := strings.Join(record[1:], ",")
+		if err := ValidateAccountTags(tags); err != nil {
+			res.Errors = append(res.Errors, AccountCSVRowError{Line: line, Err: err})
+			continue
+		}
+		acc, err := FindAccountByIdentifier(identifier, accounts)
+		if err != nil {
+			res.Errors = append(res.Errors, AccountCSVRowError{Line: line, Err: err})
+			continue
+		}
+		rows = append(rows, accountTagsCSVRow{line: line, accountID: acc.ID, account: acc.String(), tags: ModifyAccountTags("", SplitTags(tags), nil)})
+	}
+	if opts.Strict && len(res.Errors) > 0 {
+		return res, fmt.Errorf("%d invalid rows; no tags were changed", len(res.Errors))
+	}
+
+	for _, row := range rows {
+		if current[row.accountID] == row.tags {
+			res.Skipped++
+			continue
+		}
+		if err := st.UpdateAccountTags(row.accountID, row.tags); err != nil {
+			rowErr := AccountCSVRowError{Line: row.line, Err: fmt.Errorf("update tags of %s: %w", row.account, err)}
+			res.Errors = append(res.Errors, rowErr)
+			if opts.Strict {
+				return res, rowErr
+			}
+			continue
+		}
+		current[row.accountID] = row.tags
+		res.Applied++
+	}
+	return res, nil
+}
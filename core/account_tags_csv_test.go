@@ -0,0 +1,96 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// tagsStore records tag updates.
+type tagsStore struct {
+	accounts []model.Account
+	updates  []string
+}
+
+func (s *tagsStore) GetAllAccounts() ([]model.Account, error) { return s.accounts, nil }
+
+func (s *tagsStore) UpdateAccountTags(accountID int, tags string) error {
+	s.updates = append(s.updates, model.AccountRef(accountID)+"="+tags)
+	return nil
+}
+
+func newTagsStore() *tagsStore {
+	return &tagsStore{accounts: []model.Account{
+		{ID: 1, Username: "deploy", Hostname: "web-01", Tags: "env:dev"},
+		{ID: 2, Username: "deploy", Hostname: "db-01", Label: "db", Tags: "env:prod, role:db"},
+		{ID: 3, Username: "app", Hostname: "web-02"},
+	}}
+}
+
+func TestSetAccountTagsCSV_ValidRows(t *testing.T) {
+	st := newTagsStore()
+	csvData := `identifier,tags
+deploy@web-01,"env:prod,team:web"
+db,env:prod,role:db
+3,role:app,role:app
+`
+	res, err := SetAccountTagsCSV(st, strings.NewReader(csvData), AccountTagsCSVOptions{})
+	if err != nil {
+		t.Fatalf("SetAccountTagsCSV: %v", err)
+	}
+	if res.Applied != 2 || res.Skipped != 1 || len(res.Errors) != 0 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	want := []string{model.AccountRef(1) + "=env:prod, team:web", model.AccountRef(3) + "=role:app"}
+	if !reflect.DeepEqual(st.updates, want) {
+		t.Fatalf("updates %v, want %v", st.updates, want)
+	}
+}
+
+func TestSetAccountTagsCSV_UnknownIdentifierWarns(t *testing.T) {
+	st := newTagsStore()
+	csvData := "nobody@nowhere,env:prod\ndeploy@web-01,env:prod\n"
+
+	res, err := SetAccountTagsCSV(st, strings.NewReader(csvData), AccountTagsCSVOptions{})
+	if err != nil {
+		t.Fatalf("SetAccountTagsCSV: %v", err)
+	}
+	if res.Applied != 1 || len(res.Errors) != 1 || res.Errors[0].Line != 1 {
+		t.Fatalf("expected the unknown row reported and the rest applied, got %+v", res)
+	}
+
+	st = newTagsStore()
+	res, err = SetAccountTagsCSV(st, strings.NewReader(csvData), AccountTagsCSVOptions{Strict: true})
+	if err == nil || len(st.updates) != 0 || res.Applied != 0 {
+		t.Fatalf("expected strict mode to change nothing, got %+v, %v, updates %v", res, err, st.updates)
+	}
+}
+
+func TestSetAccountTagsCSV_MalformedTags(t *testing.T) {
+	st := newTagsStore()
+	csvData := `deploy@web-01,"env:prod,bad tag"
+3,role:(app)
+db,env:prod|env:dev
+deploy@web-01,proxyjump:admin@bastion
+`
+	res, err := SetAccountTagsCSV(st, strings.NewReader(csvData), AccountTagsCSVOptions{})
+	if err != nil {
+		t.Fatalf("SetAccountTagsCSV: %v", err)
+	}
+	if len(res.Errors) != 3 || res.Applied != 1 {
+		t.Fatalf("expected 3 malformed rows and 1 applied, got %+v", res)
+	}
+	for i, line := range []int{1, 2, 3} {
+		if res.Errors[i].Line != line || !strings.Contains(res.Errors[i].Error(), "invalid tag") {
+			t.Errorf("error %d = %v, want an invalid tag on line %d", i, res.Errors[i], line)
+		}
+	}
+	if want := []string{model.AccountRef(1) + "=proxyjump:admin@bastion"}; !reflect.DeepEqual(st.updates, want) {
+		t.Fatalf("updates %v, want %v", st.updates, want)
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// SetDeployMode validates mode and stores it for account id. An empty mode
+// restores the default (replace).
+func SetDeployMode(st DeployModeStore, id int, mode string) error {
+	if mode != "" && !model.IsValidDeployMode(mode) {
+		return fmt.Errorf("invalid deploy mode %q (use %s or %s)", mode, model.DeployModeReplace, model.DeployModeAppendOnly)
+	}
+	accounts, err := st.GetAllAccounts()
+	if err != nil {
+		return fmt.Errorf("failed to load accounts: %w", err)
+	}
+	for _, acc := range accounts {
+		if acc.ID == id {
+			if err := st.SetAccountDeployMode(id, mode); err != nil {
+				return fmt.Errorf("failed to save deploy mode: %w", err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("account not found: %d", id)
+}
+
+// DeployedKeyIdentities returns the identities of the key lines in rendered
+// authorized_keys content, in file order. They are recorded after a deploy
+// so later append-only deploys know which keys Keymaster is responsible for.
+func DeployedKeyIdentities(content string) []string {
+	return authorizedKeyLines(content).order
+}
+
+// MergeAppendOnly builds the authorized_keys content written by an
+// append-only deploy. desired is the rendered Keymaster content and tracked
+// the identities recorded by the previous deploy. Remote key lines that are
+// neither desired nor tracked belong to someone else and are kept, as are
+// remote comments; tracked keys that are no longer desired are dropped.
+// The Keymaster content comes first so its serial header stays the first
+// line of the file.
+func MergeAppendOnly(remote, desired string, tracked []string) string {
+	ours := make(map[string]bool)
+	for _, id := range tracked {
+		ours[id] = true
+	}
+	for _, id := range DeployedKeyIdentities(desired) {
+		ours[id] = true
+	}
+
+	var foreign []string
+	for _, line := range strings.Split(strings.ReplaceAll(remote, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || isKeymasterComment(trimmed) {
+			continue
+		}
+		if !strings.HasPrefix(trimmed, "#") && ours[keyLineIdentity(trimmed)] {
+			continue
+		}
+		foreign = append(foreign, trimmed)
+	}
+	if len(foreign) == 0 {
+		return desired
+	}
+	return strings.TrimRight(desired, "\n") + "\n\n" + strings.Join(foreign, "\n") + "\n"
+}
+
+// AnalyzeAppendOnlyDrift is AnalyzeDrift for append-only accounts: keys
+// Keymaster did not deploy are ignored, so only missing desired keys and
+// tracked keys that should have been removed count as drift. Expected is the
+// merged content an append-only deploy would write.
+func AnalyzeAppendOnlyDrift(expected, remote string, tracked []string) model.DriftAnalysis {
+	want := authorizedKeyLines(expected)
+	have := authorizedKeyLines(remote)
+
+	d := model.DriftAnalysis{Expected: MergeAppendOnly(remote, expected, tracked), Remote: remote}
+	for _, id := range tracked {
+		if _, wanted := want.lines[id]; wanted {
+			continue
+		}
+		if line, ok := have.lines[id]; ok {
+			d.Added = append(d.Added, line)
+		}
+	}
+	for _, id := range want.order {
+		if _, ok := have.lines[id]; !ok {
+			d.Removed = append(d.Removed, want.lines[id])
+		}
+	}
+	return d
+}
+
+// isKeymasterComment reports whether line is one of the comment lines
+// Keymaster writes into rendered content.
+func isKeymasterComment(line string) bool {
+	return strings.HasPrefix(line, "# Keymaster Managed Keys") || line == "# User Keys"
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/core/security"
+	"github.com/toeirei/keymaster/ui/i18n"
+)
+
+const (
+	foreignKey = "ssh-ed25519 FOREIGN team-b@laptop"
+	revokedKey = "ssh-ed25519 REVOKED old@keymaster"
+)
+
+// aliceKL renders one account key for every account.
+type aliceKL struct{ fakeKL }
+
+func (*aliceKL) GetKeysForAccount(accountID int) ([]model.PublicKey, error) {
+	return []model.PublicKey{{ID: 1, Algorithm: "ssh-ed25519", KeyData: "ALICE", Comment: "alice"}}, nil
+}
+
+// captureRemote serves content and records what was deployed.
+type captureRemote struct {
+	content  string
+	deployed string
+}
+
+func (c *captureRemote) DeployAuthorizedKeys(content string) error {
+	c.deployed = content
+	return nil
+}
+func (c *captureRemote) GetAuthorizedKeys() ([]byte, error) { return []byte(c.content), nil }
+func (c *captureRemote) Close()                             {}
+
+type keysRecorder struct {
+	id   int
+	keys []string
+}
+
+func (r *keysRecorder) SetAccountDeployedKeys(id int, keys []string) error {
+	r.id, r.keys = id, keys
+	return nil
+}
+
+func TestMergeAppendOnly(t *testing.T) {
+	desired := "# Keymaster Managed Keys (Serial: 2)\nsys-line\n\n# User Keys\nssh-ed25519 ALICE alice\n"
+	remote := "# Keymaster Managed Keys (Serial: 1)\nsys-line\n\n# User Keys\n" + revokedKey + "\n# team b\n" + foreignKey + "\n"
+
+	got := MergeAppendOnly(remote, desired, []string{"sys-line", "ssh-ed25519 REVOKED"})
+	if !strings.HasPrefix(got, desired[:len(desired)-1]) {
+		t.Fatalf("expected Keymaster content first, got %q", got)
+	}
+	if !strings.Contains(got, foreignKey) || !strings.Contains(got, "# team b") {
+		t.Fatalf("expected foreign key and its comment to survive, got %q", got)
+	}
+	if strings.Contains(got, "REVOKED") || strings.Contains(got, "Serial: 1") {
+		t.Fatalf("expected tracked key and old header to be replaced, got %q", got)
+	}
+	if MergeAppendOnly("", desired, nil) != desired {
+		t.Fatal("expected desired content unchanged without foreign keys")
+	}
+}
+
+func TestAnalyzeAppendOnlyDrift(t *testing.T) {
+	expected := "# Keymaster Managed Keys (Serial: 1)\nssh-ed25519 ALICE alice\n"
+	tracked := []string{"ssh-ed25519 ALICE", "ssh-ed25519 REVOKED"}
+
+	if d := AnalyzeAppendOnlyDrift(expected, expected+foreignKey+"\n", tracked); d.HasKeyDrift() {
+		t.Fatalf("foreign keys must not count as drift, got %+v", d)
+	}
+	d := AnalyzeAppendOnlyDrift(expected, revokedKey+"\n"+foreignKey+"\n", tracked)
+	if len(d.Added) != 1 || d.Added[0] != revokedKey || len(d.Removed) != 1 {
+		t.Fatalf("expected revoked key added and alice removed, got %+v", d)
+	}
+}
+
+func TestRunDeploymentForAccount_AppendOnlyKeepsForeignKeys(t *testing.T) {
+	i18n.Init("en")
+	SetDefaultKeyReader(&fakeKR{})
+	SetDefaultKeyLister(&aliceKL{})
+
+	remote := &captureRemote{content: "# Keymaster Managed Keys (Serial: 1)\n" + revokedKey + "\n" + foreignKey + "\n"}
+	origFactory := NewDeployerFactory
+	defer func() { NewDeployerFactory = origFactory }()
+	NewDeployerFactory = func(host, user string, privateKey security.Secret, passphrase []byte) (RemoteDeployer, error) {
+		return remote, nil
+	}
+	origUpd := DefaultAccountSerialUpdater()
+	SetDefaultAccountSerialUpdater(&recordingUpdater{})
+	defer SetDefaultAccountSerialUpdater(origUpd)
+	rec := &keysRecorder{}
+	origRec := DefaultDeployedKeysRecorder()
+	SetDefaultDeployedKeysRecorder(rec)
+	defer SetDefaultDeployedKeysRecorder(origRec)
+
+	acct := model.Account{ID: 7, Username: "svc", Hostname: "shared", Serial: 1, DeployMode: model.DeployModeAppendOnly, DeployedKeys: []string{"ssh-ed25519 REVOKED"}}
+	if err := RunDeploymentForAccount(acct, false); err != nil {
+		t.Fatalf("RunDeploymentForAccount: %v", err)
+	}
+	if !strings.Contains(remote.deployed, foreignKey) {
+		t.Fatalf("foreign key must survive an append-only deploy, got %q", remote.deployed)
+	}
+	if strings.Contains(remote.deployed, "REVOKED") || !strings.Contains(remote.deployed, "ALICE") {
+		t.Fatalf("expected revoked key removed and alice deployed, got %q", remote.deployed)
+	}
+	if rec.id != 7 || strings.Contains(strings.Join(rec.keys, ","), "FOREIGN") || !strings.Contains(strings.Join(rec.keys, ","), "ssh-ed25519 ALICE") {
+		t.Fatalf("expected only Keymaster keys to be recorded, got %+v", rec)
+	}
+
+	// The deployed file passes a strict audit despite the foreign key.
+	acct.DeployedKeys = rec.keys
+	st := &simpleFakeStore{accounts: []model.Account{acct}}
+	res, err := AuditAccounts(context.TODO(), st, &fakeDeployerManager{content: []byte(remote.deployed)}, "strict", nil)
+	if err != nil || len(res) != 1 || res[0].Error != nil {
+		t.Fatalf("expected append-only host to pass the audit, got %+v (%v)", res, err)
+	}
+}
+
+func TestRunDeploymentForAccount_ReplaceDropsForeignKeys(t *testing.T) {
+	i18n.Init("en")
+	SetDefaultKeyReader(&fakeKR{})
+	SetDefaultKeyLister(&aliceKL{})
+
+	remote := &captureRemote{content: foreignKey + "\n"}
+	origFactory := NewDeployerFactory
+	defer func() { NewDeployerFactory = origFactory }()
+	NewDeployerFactory = func(host, user string, privateKey security.Secret, passphrase []byte) (RemoteDeployer, error) {
+		return remote, nil
+	}
+	origUpd := DefaultAccountSerialUpdater()
+	SetDefaultAccountSerialUpdater(&recordingUpdater{})
+	defer SetDefaultAccountSerialUpdater(origUpd)
+
+	if err := RunDeploymentForAccount(model.Account{ID: 8, Username: "svc", Hostname: "h", Serial: 1}, false); err != nil {
+		t.Fatalf("RunDeploymentForAccount: %v", err)
+	}
+	if strings.Contains(remote.deployed, "FOREIGN") {
+		t.Fatalf("replace mode must overwrite the file, got %q", remote.deployed)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// driftDM fails the serial audit for the account IDs in drift and records
+// which accounts were audited.
+type driftDM struct {
+	fakeDM
+	drift   map[int]bool
+	audited []int
+}
+
+func (d *driftDM) AuditSerial(account model.Account) error {
+	d.audited = append(d.audited, account.ID)
+	if d.drift[account.ID] {
+		return errors.New("drift")
+	}
+	return nil
+}
+
+func auditFixture() *simpleFakeStore {
+	return &simpleFakeStore{accounts: []model.Account{
+		{ID: 1, Username: "a", Hostname: "h1", Serial: 1, IsActive: true},
+		{ID: 2, Username: "b", Hostname: "h2", Serial: 1, IsActive: true},
+		{ID: 3, Username: "c", Hostname: "h3", Serial: 1, IsActive: true},
+	}}
+}
+
+func TestAuditExitCode_AllPass(t *testing.T) {
+	dm := &driftDM{}
+	res, err := AuditAccountsWithOptions(context.TODO(), auditFixture(), dm, "serial", AuditOptions{}, nil)
+	if err != nil {
+		t.Fatalf("audit: %v", err)
+	}
+	if got := AuditExitCode(res); got != AuditExitOK {
+		t.Fatalf("expected exit %d, got %d", AuditExitOK, got)
+	}
+	if len(dm.audited) != 3 {
+		t.Fatalf("expected all accounts audited, got %v", dm.audited)
+	}
+}
+
+func TestAuditExitCode_SomeDrift(t *testing.T) {
+	dm := &driftDM{drift: map[int]bool{2: true}}
+	res, err := AuditAccountsWithOptions(context.TODO(), auditFixture(), dm, "serial", AuditOptions{}, nil)
+	if err != nil {
+		t.Fatalf("audit: %v", err)
+	}
+	if got := AuditExitCode(res); got != AuditExitDrift {
+		t.Fatalf("expected exit %d, got %d", AuditExitDrift, got)
+	}
+	// Without fail-fast the audit continues past the drifting account.
+	if len(res) != 3 || len(dm.audited) != 3 {
+		t.Fatalf("expected 3 results, got %d (audited %v)", len(res), dm.audited)
+	}
+}
+
+func TestAuditExitCode_FailFast(t *testing.T) {
+	dm := &driftDM{drift: map[int]bool{2: true, 3: true}}
+	res, err := AuditAccountsWithOptions(context.TODO(), auditFixture(), dm, "serial", AuditOptions{FailFast: true}, nil)
+	if err != nil {
+		t.Fatalf("fail-fast stop should not be reported as an error: %v", err)
+	}
+	if len(res) != 2 || res[1].Account.ID != 2 || res[1].Error == nil {
+		t.Fatalf("expected audit to stop after account 2, got %+v", res)
+	}
+	if len(dm.audited) != 2 {
+		t.Fatalf("account 3 should not be audited, audited %v", dm.audited)
+	}
+	if got := AuditExitCode(res); got != AuditExitDrift {
+		t.Fatalf("expected exit %d, got %d", AuditExitDrift, got)
+	}
+}
+
+func TestAuditAccountsWithOptions_CancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	dm := &driftDM{}
+	res, err := AuditAccountsWithOptions(ctx, auditFixture(), dm, "serial", AuditOptions{}, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if len(res) != 0 || len(dm.audited) != 0 {
+		t.Fatalf("expected no accounts audited, got %v", dm.audited)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"strings"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// partitionByGroups splits accounts into one partition per group, in the
+// order given, followed by a final partition of the accounts carrying none of
+// the groups. An account carrying several groups lands in the first one.
+// Empty partitions are dropped; accounts keep their input order.
+func partitionByGroups(accounts []model.Account, groups []string) [][]model.Account {
+	byTag := BuildAccountsByTag(accounts)
+	seen := make(map[int]bool, len(accounts))
+	var partitions [][]model.Account
+	for _, g := range groups {
+		g = strings.TrimSpace(g)
+		if g == "" {
+			continue
+		}
+		var part []model.Account
+		for _, acc := range byTag[g] {
+			if !seen[acc.ID] {
+				seen[acc.ID] = true
+				part = append(part, acc)
+			}
+		}
+		if len(part) > 0 {
+			partitions = append(partitions, part)
+		}
+	}
+	var rest []model.Account
+	for _, acc := range accounts {
+		if !seen[acc.ID] {
+			rest = append(rest, acc)
+		}
+	}
+	if len(rest) > 0 {
+		partitions = append(partitions, rest)
+	}
+	return partitions
+}
+
+// auditGroupsParallel audits the partitions of accounts one after another,
+// auditing the hosts within a partition concurrently via ParallelRun with
+// audit. A
+// partition is only started once the previous one has finished; with
+// opts.FailFast no further partition is started after one with a failure.
+func auditGroupsParallel(ctx context.Context, accounts []model.Account, opts AuditOptions, audit func(model.Account) (AuditResult, error)) ([]AuditResult, error) {
+	lg := DefaultLogger()
+	partitions := partitionByGroups(accounts, opts.Groups)
+	lg.Debug("starting grouped audit", "accounts", len(accounts), "partitions", len(partitions), "fail_fast", opts.FailFast)
+
+	results := make([]AuditResult, 0, len(accounts))
+	for i, part := range partitions {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		lg.Debug("auditing partition", "partition", i+1, "accounts", len(part))
+
+		index := make(map[int]int, len(part))
+		for j, acc := range part {
+			index[acc.ID] = j
+		}
+		partResults := make([]AuditResult, len(part))
+		for _, pr := range ParallelRun(ctx, part, func(acc model.Account) error {
+			res, err := audit(acc)
+			partResults[index[acc.ID]] = res
+			return err
+		}) {
+			if pr.Error != nil {
+				return nil, pr.Error
+			}
+		}
+
+		failed := false
+		for _, res := range partResults {
+			if res.Error != nil {
+				failed = true
+				lg.Warn("audit failed", "account", res.Account.String(), "err", res.Error)
+			} else {
+				lg.Debug("audit passed", "account", res.Account.String())
+			}
+		}
+		results = append(results, partResults...)
+		if failed && opts.FailFast {
+			lg.Info("stopping audit after failing partition", "partition", i+1)
+			break
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return results, err
+	}
+	return results, nil
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// groupAuditDM records when serial audits start and finish as sequence
+// numbers. Audits of the accounts in barrier only return once all of them
+// have started, so they can only complete when run concurrently.
+type groupAuditDM struct {
+	fakeDM
+	mu       sync.Mutex
+	seq      int
+	started  map[int]int
+	finished map[int]int
+	drift    map[int]bool
+
+	barrier map[int]bool
+	arrived chan struct{}
+}
+
+func (d *groupAuditDM) AuditSerial(account model.Account) error {
+	d.record(&d.started, account.ID)
+
+	if d.barrier[account.ID] {
+		d.arrived <- struct{}{}
+		deadline := time.After(5 * time.Second)
+		for len(d.arrived) < cap(d.arrived) {
+			select {
+			case <-deadline:
+				return errors.New("barrier timeout: audits did not run in parallel")
+			case <-time.After(time.Millisecond):
+			}
+		}
+	}
+
+	d.record(&d.finished, account.ID)
+	if d.drift[account.ID] {
+		return errors.New("drift")
+	}
+	return nil
+}
+
+func (d *groupAuditDM) record(events *map[int]int, id int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if *events == nil {
+		*events = make(map[int]int)
+	}
+	d.seq++
+	(*events)[id] = d.seq
+}
+
+func groupAuditAccounts() []model.Account {
+	return []model.Account{
+		{ID: 1, Username: "app", Hostname: "db-01", Tags: "db", Serial: 1, IsActive: true},
+		{ID: 2, Username: "app", Hostname: "web-01", Tags: "web", Serial: 1, IsActive: true},
+		{ID: 3, Username: "app", Hostname: "web-02", Tags: "web,db", Serial: 1, IsActive: true},
+		{ID: 4, Username: "app", Hostname: "misc-01", Serial: 1, IsActive: true},
+		{ID: 5, Username: "app", Hostname: "db-02", Tags: "db", Serial: 1, IsActive: true},
+	}
+}
+
+func TestAuditAccountsWithOptions_GroupsRunInOrderAndInParallel(t *testing.T) {
+	st := &simpleFakeStore{accounts: groupAuditAccounts()}
+	dm := &groupAuditDM{barrier: map[int]bool{2: true, 3: true}, arrived: make(chan struct{}, 2)}
+
+	res, err := AuditAccountsWithOptions(context.Background(), st, dm, "serial", AuditOptions{Groups: []string{"web", "db"}}, nil)
+	if err != nil {
+		t.Fatalf("audit: %v", err)
+	}
+
+	var got []int
+	for _, r := range res {
+		if r.Error != nil {
+			t.Fatalf("unexpected failure for %d: %v", r.Account.ID, r.Error)
+		}
+		got = append(got, r.Account.ID)
+	}
+	// web first, then db (without 3, already audited with web), then the rest.
+	if want := []int{2, 3, 1, 5, 4}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected results %v, got %v", want, got)
+	}
+
+	// Every group finishes before the next one starts.
+	for _, order := range [][2][]int{{{2, 3}, {1, 5}}, {{1, 5}, {4}}} {
+		for _, before := range order[0] {
+			for _, after := range order[1] {
+				if dm.finished[before] > dm.started[after] {
+					t.Fatalf("account %d started before account %d finished", after, before)
+				}
+			}
+		}
+	}
+}
+
+func TestAuditAccountsWithOptions_GroupsFailFast(t *testing.T) {
+	st := &simpleFakeStore{accounts: groupAuditAccounts()}
+	dm := &groupAuditDM{drift: map[int]bool{2: true}}
+
+	res, err := AuditAccountsWithOptions(context.Background(), st, dm, "serial", AuditOptions{Groups: []string{"web", "db"}, FailFast: true}, nil)
+	if err != nil {
+		t.Fatalf("fail-fast stop should not be reported as an error: %v", err)
+	}
+	// The failing group completes; later groups are not started.
+	if len(res) != 2 || AuditExitCode(res) != AuditExitDrift {
+		t.Fatalf("expected only the web group to be audited, got %+v", res)
+	}
+	if len(dm.started) != 2 {
+		t.Fatalf("expected 2 audits, got %v", dm.started)
+	}
+}
+
+func TestPartitionByGroups(t *testing.T) {
+	parts := partitionByGroups(groupAuditAccounts(), []string{" db ", "missing", ""})
+	var got [][]int
+	for _, p := range parts {
+		var ids []int
+		for _, acc := range p {
+			ids = append(ids, acc.ID)
+		}
+		got = append(got, ids)
+	}
+	if want := [][]int{{1, 3, 5}, {2, 4}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected partitions %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/ui/i18n"
+)
+
+// junitTestSuites is the root element of a JUnit XML report.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+}
+
+// junitProblem is the body of a <failure> or <error> element.
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// IsAuditDrift reports whether a failed audit result is drift (the host
+// content differs from what Keymaster expects) rather than an error reaching
+// or reading the host. Never-deployed accounts count as drift.
+func IsAuditDrift(r AuditResult) bool {
+	if r.Error == nil {
+		return false
+	}
+	if r.Drift != nil {
+		return true
+	}
+	msg := r.Error.Error()
+	return msg == i18n.T("audit.error_drift_detected") || msg == i18n.T("audit.error_not_deployed")
+}
+
+// WriteAuditJUnit writes results as a JUnit XML report with one test case per
+// account so CI systems can render audits as test results. Drift is reported
+// as a failure, anything else that went wrong as an error.
+func WriteAuditJUnit(w io.Writer, mode string, startedAt time.Time, duration time.Duration, results []AuditResult) error {
+	if mode == "" {
+		mode = "strict"
+	}
+	suite := junitTestSuite{
+		Name:      "keymaster audit (" + mode + ")",
+		Tests:     len(results),
+		Time:      junitSeconds(duration),
+		Timestamp: startedAt.UTC().Format("2006-01-02T15:04:05"),
+		Cases:     make([]junitTestCase, 0, len(results)),
+	}
+	for _, r := range results {
+		tc := junitTestCase{
+			Name:      r.Account.String(),
+			ClassName: "keymaster.audit." + junitClassSegment(r.Account.Hostname),
+			Time:      junitSeconds(r.Duration),
+		}
+		switch {
+		case r.Error == nil:
+		case IsAuditDrift(r):
+			suite.Failures++
+			tc.Failure = &junitProblem{Message: r.Error.Error(), Type: "drift", Text: junitDriftText(r.Drift)}
+		case errors.Is(r.Error, ErrHostUnreachable):
+			suite.Errors++
+			tc.Error = &junitProblem{Message: r.Error.Error(), Type: "unreachable"}
+		default:
+			suite.Errors++
+			tc.Error = &junitProblem{Message: r.Error.Error(), Type: "error"}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	doc := junitTestSuites{
+		Name:     "keymaster",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Errors:   suite.Errors,
+		Time:     suite.Time,
+		Suites:   []junitTestSuite{suite},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("encode junit report: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// junitSeconds formats d as fractional seconds, the unit JUnit uses.
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// junitClassSegment turns a hostname into a classname segment. Dots would
+// otherwise be read as package separators and split the host into a tree.
+func junitClassSegment(host string) string {
+	return strings.ReplaceAll(host, ".", "_")
+}
+
+// junitDriftText lists the added and removed keys of a drifted host.
+func junitDriftText(d *model.DriftAnalysis) string {
+	if d == nil {
+		return ""
+	}
+	var b strings.Builder
+	for _, line := range d.Added {
+		b.WriteString("+ " + line + "\n")
+	}
+	for _, line := range d.Removed {
+		b.WriteString("- " + line + "\n")
+	}
+	return b.String()
+}
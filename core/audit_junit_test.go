@@ -0,0 +1,74 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/ui/i18n"
+)
+
+func TestWriteAuditJUnit(t *testing.T) {
+	results := []AuditResult{
+		{Account: model.Account{ID: 1, Username: "app", Hostname: "web-01.example.com"}, Duration: 120 * time.Millisecond},
+		{
+			Account:  model.Account{ID: 2, Username: "app", Hostname: "db<01>&\"prod\""},
+			Error:    errors.New(i18n.T("audit.error_drift_detected")),
+			Drift:    &model.DriftAnalysis{Added: []string{"ssh-ed25519 AAAA intruder@x"}},
+			Duration: time.Second,
+		},
+		{Account: model.Account{ID: 3, Username: "app", Hostname: "cache-01"}, Error: errors.New("dial tcp: connection refused")},
+		{Account: model.Account{ID: 4, Username: "app", Hostname: "cache-02"}, Error: errors.New(i18n.T("audit.error_drift_detected"))},
+	}
+
+	var buf bytes.Buffer
+	started := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := WriteAuditJUnit(&buf, "strict", started, 2*time.Second, results); err != nil {
+		t.Fatalf("WriteAuditJUnit: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "<?xml") {
+		t.Fatalf("expected XML header, got %q", buf.String())
+	}
+
+	var doc junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("report is not well-formed XML: %v\n%s", err, buf.String())
+	}
+	if doc.Tests != 4 || doc.Failures != 2 || doc.Errors != 1 || len(doc.Suites) != 1 {
+		t.Fatalf("unexpected totals: tests=%d failures=%d errors=%d suites=%d", doc.Tests, doc.Failures, doc.Errors, len(doc.Suites))
+	}
+	suite := doc.Suites[0]
+	if suite.Timestamp != "2026-01-02T03:04:05" || suite.Time != "2.000" {
+		t.Fatalf("unexpected suite timing: %+v", suite)
+	}
+
+	cases := suite.Cases
+	if cases[0].Failure != nil || cases[0].Error != nil || cases[0].Time != "0.120" {
+		t.Fatalf("expected passing case, got %+v", cases[0])
+	}
+	if cases[0].ClassName != "keymaster.audit.web-01_example_com" {
+		t.Fatalf("unexpected classname %q", cases[0].ClassName)
+	}
+	if cases[1].Name != `app@db<01>&"prod"` {
+		t.Fatalf("hostname did not survive escaping: %q", cases[1].Name)
+	}
+	if cases[1].Failure == nil || !strings.Contains(cases[1].Failure.Text, "+ ssh-ed25519 AAAA intruder@x") {
+		t.Fatalf("expected drift failure with details, got %+v", cases[1])
+	}
+	if cases[2].Error == nil || cases[2].Failure != nil || cases[2].Error.Message != "dial tcp: connection refused" {
+		t.Fatalf("expected connection error, got %+v", cases[2])
+	}
+	if cases[3].Failure == nil {
+		t.Fatalf("expected serial drift to be a failure, got %+v", cases[3])
+	}
+	if strings.Contains(buf.String(), `db<01>`) {
+		t.Fatal("expected raw '<' in hostname to be escaped")
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// auditRecordingDM records the accounts whose host was contacted for a
+// serial audit.
+type auditRecordingDM struct {
+	fakeDeployerManager
+	mu      sync.Mutex
+	audited []int
+}
+
+func (d *auditRecordingDM) AuditSerial(account model.Account) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.audited = append(d.audited, account.ID)
+	return nil
+}
+
+// fakeReachability answers pings for hosts named "down*" with a timeout and
+// accepts every other host.
+func fakeReachability(t *testing.T) {
+	t.Helper()
+	orig := pingDial
+	pingDial = func(network, addr string, timeout time.Duration) (net.Conn, error) {
+		if strings.HasPrefix(addr, "down") {
+			return nil, &net.OpError{Op: "dial", Net: network, Err: timeoutError{}}
+		}
+		client, server := net.Pipe()
+		_ = server.Close()
+		return client, nil
+	}
+	t.Cleanup(func() { pingDial = orig })
+}
+
+func reachabilityAccounts() []model.Account {
+	return []model.Account{
+		{ID: 1, Username: "app", Hostname: "web-01", Tags: "web", Serial: 1, IsActive: true},
+		{ID: 2, Username: "app", Hostname: "down-01", Tags: "web", Serial: 1, IsActive: true},
+		{ID: 3, Username: "app", Hostname: "db-01", Tags: "db", Serial: 1, IsActive: true},
+	}
+}
+
+func TestAuditOnlyReachable_SkipsDownHostsWithoutSSH(t *testing.T) {
+	fakeReachability(t)
+	for _, tc := range []struct {
+		name   string
+		groups []string
+	}{
+		{"sequential", nil},
+		{"parallel groups", []string{"web", "db"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			st := &simpleFakeStore{accounts: reachabilityAccounts()}
+			dm := &auditRecordingDM{}
+			opts := AuditOptions{OnlyReachable: true, PingTimeout: time.Second, Groups: tc.groups}
+			results, err := AuditAccountsWithOptions(context.Background(), st, dm, "serial", opts, nil)
+			if err != nil {
+				t.Fatalf("AuditAccountsWithOptions: %v", err)
+			}
+			slices.Sort(dm.audited)
+			if !reflect.DeepEqual(dm.audited, []int{1, 3}) {
+				t.Fatalf("expected only reachable hosts audited over SSH, got %v", dm.audited)
+			}
+			if len(results) != 3 {
+				t.Fatalf("expected a result per account, got %+v", results)
+			}
+			down := results[1]
+			if down.Account.ID != 2 || !errors.Is(down.Error, ErrHostUnreachable) {
+				t.Fatalf("expected account 2 reported unreachable, got %+v", down)
+			}
+			if !strings.Contains(down.Error.Error(), "down-01 timeout") {
+				t.Fatalf("expected the address and ping status in %q", down.Error)
+			}
+			if results[0].Error != nil || results[2].Error != nil {
+				t.Fatalf("expected reachable hosts to pass, got %+v", results)
+			}
+		})
+	}
+}
+
+func TestAuditOnlyReachable_OffByDefault(t *testing.T) {
+	fakeReachability(t)
+	dm := &auditRecordingDM{}
+	if _, err := AuditAccountsWithOptions(context.Background(), &simpleFakeStore{accounts: reachabilityAccounts()}, dm, "serial", AuditOptions{}, nil); err != nil {
+		t.Fatalf("AuditAccountsWithOptions: %v", err)
+	}
+	if !reflect.DeepEqual(dm.audited, []int{1, 2, 3}) {
+		t.Fatalf("expected every host audited without --only-reachable, got %v", dm.audited)
+	}
+}
+
+func TestAuditReports_ClassifyUnreachable(t *testing.T) {
+	results := []AuditResult{{
+		Account: model.Account{ID: 2, Username: "app", Hostname: "down-01"},
+		Error:   errors.Join(ErrHostUnreachable, errors.New("i/o timeout")),
+	}}
+	report := NewAuditReport("serial", time.Now(), time.Second, results)
+	if report.Results[0].Status != AuditReportStatusUnreachable || report.Failed != 1 {
+		t.Fatalf("expected an unreachable, failed entry, got %+v", report)
+	}
+	var buf bytes.Buffer
+	if err := WriteAuditJUnit(&buf, "serial", time.Now(), time.Second, results); err != nil {
+		t.Fatalf("WriteAuditJUnit: %v", err)
+	}
+	if !strings.Contains(buf.String(), `type="unreachable"`) {
+		t.Fatalf("expected an unreachable JUnit error, got %s", buf.String())
+	}
+}
@@ -3,7 +3,18 @@
 // This source code is licensed under the MIT license found in the LICENSE file.
 package core
 
-import "strings"
+import (
+	"strings"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// LogAuditFields records an audit event with a human summary and structured
+// fields through w. The fields are stored as JSON next to the summary, so
+// any AuditWriter accepts them.
+func LogAuditFields(w AuditWriter, action, summary string, fields model.AuditFields) error {
+	return w.LogAction(action, model.EncodeAuditDetails(summary, fields))
+}
 
 // AuditActionRisk classifies an audit action into a risk category.
 // Returns one of: "high", "medium", "low", "info".
@@ -21,6 +32,7 @@ func AuditActionRisk(action string) string {
 		strings.HasPrefix(action, "UPDATE_ACCOUNT_TAGS"),
 		strings.HasPrefix(action, "ASSIGN_KEY"),
 		strings.HasPrefix(action, "TRUST_HOST"),
+		strings.HasPrefix(action, "HOST_KEY_AUTO_RETRUST"),
 		strings.HasPrefix(action, "CREATE_SYSTEM_KEY"):
 		return "medium"
 	case strings.HasPrefix(action, "ADD_ACCOUNT"),
@@ -0,0 +1,132 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// AuditReport is the machine-readable record of one audit run, written by
+// WriteAuditReport so drift can be tracked over time.
+type AuditReport struct {
+	Mode       string             `json:"mode"`
+	StartedAt  time.Time          `json:"started_at"`
+	DurationMS int64              `json:"duration_ms"`
+	Total      int                `json:"total"`
+	Passed     int                `json:"passed"`
+	Failed     int                `json:"failed"`
+	ExitCode   int                `json:"exit_code"`
+	Results    []AuditReportEntry `json:"results"`
+}
+
+// AuditReportEntry is the outcome for a single account in an AuditReport.
+type AuditReportEntry struct {
+	AccountID int    `json:"account_id"`
+	Username  string `json:"username"`
+	Hostname  string `json:"hostname"`
+	Label     string `json:"label,omitempty"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	// DurationMS is the time spent auditing this account.
+	DurationMS int64 `json:"duration_ms"`
+	// Drift is set for strict audits that found differing content.
+	Drift *model.DriftAnalysis `json:"drift,omitempty"`
+}
+
+// Status values used in AuditReportEntry.
+const (
+	AuditReportStatusOK          = "ok"
+	AuditReportStatusDrift       = "drift"
+	AuditReportStatusUnreachable = "unreachable"
+)
+
+// NewAuditReport builds an AuditReport from the results of a run that
+// started at startedAt and took duration.
+func NewAuditReport(mode string, startedAt time.Time, duration time.Duration, results []AuditResult) AuditReport {
+	report := AuditReport{
+		Mode:       mode,
+		StartedAt:  startedAt.UTC(),
+		DurationMS: duration.Milliseconds(),
+		Total:      len(results),
+		ExitCode:   AuditExitCode(results),
+		Results:    make([]AuditReportEntry, 0, len(results)),
+	}
+	for _, r := range results {
+		entry := AuditReportEntry{
+			AccountID:  r.Account.ID,
+			Username:   r.Account.Username,
+			Hostname:   r.Account.Hostname,
+			Label:      r.Account.Label,
+			Status:     AuditReportStatusOK,
+			Drift:      r.Drift,
+			DurationMS: r.Duration.Milliseconds(),
+		}
+		if r.Error != nil {
+			entry.Status = AuditReportStatusDrift
+			if errors.Is(r.Error, ErrHostUnreachable) {
+				entry.Status = AuditReportStatusUnreachable
+			}
+			entry.Error = r.Error.Error()
+			report.Failed++
+		} else {
+			report.Passed++
+		}
+		report.Results = append(report.Results, entry)
+	}
+	return report
+}
+
+// auditReportPathData is the data available to report path templates.
+type auditReportPathData struct {
+	// Date is the run date as 2006-01-02.
+	Date string
+	// Time is the run time of day as 150405.
+	Time string
+	// Timestamp is the run start as 20060102T150405Z.
+	Timestamp string
+	// Mode is the audit mode.
+	Mode string
+}
+
+// ExpandAuditReportPath expands a text/template path such as
+// "report-{{.Date}}.json" for a run started at t. Available fields are Date,
+// Time, Timestamp (all UTC) and Mode.
+func ExpandAuditReportPath(pattern string, t time.Time, mode string) (string, error) {
+	tmpl, err := template.New("report").Option("missingkey=error").Parse(pattern)
+	if err != nil {
+		return "", fmt.Errorf("parse report path %q: %w", pattern, err)
+	}
+	t = t.UTC()
+	var b strings.Builder
+	data := auditReportPathData{
+		Date:      t.Format("2006-01-02"),
+		Time:      t.Format("150405"),
+		Timestamp: t.Format("20060102T150405Z"),
+		Mode:      mode,
+	}
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("expand report path %q: %w", pattern, err)
+	}
+	return b.String(), nil
+}
+
+// WriteAuditReport writes report as indented JSON to path.
+func WriteAuditReport(path string, report AuditReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode audit report: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write audit report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+func TestExpandAuditReportPath(t *testing.T) {
+	ts := time.Date(2026, 3, 14, 9, 26, 53, 0, time.UTC)
+	cases := map[string]string{
+		"report-{{.Date}}.json":         "report-2026-03-14.json",
+		"audit-{{.Timestamp}}.json":     "audit-20260314T092653Z.json",
+		"{{.Mode}}-{{.Date}}-{{.Time}}": "serial-2026-03-14-092653",
+		"plain.json":                    "plain.json",
+	}
+	for pattern, want := range cases {
+		got, err := ExpandAuditReportPath(pattern, ts, "serial")
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", pattern, err)
+		}
+		if got != want {
+			t.Errorf("%q: expected %q, got %q", pattern, want, got)
+		}
+	}
+}
+
+func TestExpandAuditReportPath_Invalid(t *testing.T) {
+	if _, err := ExpandAuditReportPath("report-{{.Date", time.Now(), "strict"); err == nil {
+		t.Fatal("expected error for unterminated template")
+	}
+	if _, err := ExpandAuditReportPath("report-{{.Nope}}.json", time.Now(), "strict"); err == nil {
+		t.Fatal("expected error for unknown field")
+	}
+}
+
+func TestWriteAuditReport(t *testing.T) {
+	started := time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC)
+	results := []AuditResult{
+		{Account: model.Account{ID: 1, Username: "a", Hostname: "h1", Label: "web"}},
+		{Account: model.Account{ID: 2, Username: "b", Hostname: "h2"}, Error: errors.New("drift detected")},
+	}
+	report := NewAuditReport("strict", started, 1500*time.Millisecond, results)
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := WriteAuditReport(path, report); err != nil {
+		t.Fatalf("WriteAuditReport: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	var got AuditReport
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("decode report: %v", err)
+	}
+
+	if got.Mode != "strict" || !got.StartedAt.Equal(started) || got.DurationMS != 1500 {
+		t.Fatalf("unexpected metadata: %+v", got)
+	}
+	if got.Total != 2 || got.Passed != 1 || got.Failed != 1 || got.ExitCode != AuditExitDrift {
+		t.Fatalf("unexpected counts: %+v", got)
+	}
+	if len(got.Results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(got.Results))
+	}
+	if r := got.Results[0]; r.AccountID != 1 || r.Label != "web" || r.Status != AuditReportStatusOK || r.Error != "" {
+		t.Errorf("unexpected first result: %+v", r)
+	}
+	if r := got.Results[1]; r.AccountID != 2 || r.Status != AuditReportStatusDrift || r.Error != "drift detected" {
+		t.Errorf("unexpected second result: %+v", r)
+	}
+}
+
+func TestNewAuditReport_EmptyResults(t *testing.T) {
+	report := NewAuditReport("serial", time.Now(), 0, nil)
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if res, ok := raw["results"].([]any); !ok || len(res) != 0 {
+		t.Fatalf("expected empty results array, got %v", raw["results"])
+	}
+	if report.ExitCode != AuditExitOK {
+		t.Fatalf("expected exit code %d, got %d", AuditExitOK, report.ExitCode)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// storeAuditRecorder writes audit times back into a simpleFakeStore, like the
+// database-backed recorder does.
+type storeAuditRecorder struct{ st *simpleFakeStore }
+
+func (r storeAuditRecorder) SetAccountLastAudit(id int, at time.Time) error {
+	for i := range r.st.accounts {
+		if r.st.accounts[i].ID == id {
+			r.st.accounts[i].LastAuditAt = at
+		}
+	}
+	return nil
+}
+
+func auditedIDs(results []AuditResult) []int {
+	ids := make([]int, 0, len(results))
+	for _, r := range results {
+		ids = append(ids, r.Account.ID)
+	}
+	return ids
+}
+
+func TestAuditAccountsWithOptions_SkipRecent(t *testing.T) {
+	st := &simpleFakeStore{accounts: []model.Account{
+		{ID: 1, Username: "app", Hostname: "clean-01", Serial: 1, IsActive: true},
+		{ID: 2, Username: "app", Hostname: "drift-01", Serial: 1, IsActive: true, LastAuditAt: time.Now().Add(-time.Minute)},
+		{ID: 3, Username: "app", Hostname: "stale-01", Serial: 1, IsActive: true, LastAuditAt: time.Now().Add(-2 * time.Hour)},
+	}}
+	SetDefaultLastAuditRecorder(storeAuditRecorder{st: st})
+	defer SetDefaultLastAuditRecorder(nil)
+	dm := &groupAuditDM{drift: map[int]bool{2: true}}
+	opts := AuditOptions{SkipRecent: 30 * time.Minute}
+
+	// Account 2 was clean a minute ago, so only accounts 1 and 3 are due.
+	res, err := AuditAccountsWithOptions(context.Background(), st, dm, "serial", opts, nil)
+	if err != nil {
+		t.Fatalf("audit: %v", err)
+	}
+	if got := auditedIDs(res); len(got) != 2 || got[0] != 1 || got[1] != 3 {
+		t.Fatalf("expected accounts 1 and 3 to be audited, got %v", got)
+	}
+	for _, acc := range []model.Account{st.accounts[0], st.accounts[2]} {
+		if time.Since(acc.LastAuditAt) > time.Minute {
+			t.Fatalf("expected a fresh audit time for clean account %d, got %v", acc.ID, acc.LastAuditAt)
+		}
+	}
+
+	// Once its window has passed, account 2 drifts and loses its audit time.
+	st.accounts[1].LastAuditAt = time.Now().Add(-time.Hour)
+	res, err = AuditAccountsWithOptions(context.Background(), st, dm, "serial", opts, nil)
+	if err != nil {
+		t.Fatalf("audit: %v", err)
+	}
+	if got := auditedIDs(res); len(got) != 1 || got[0] != 2 || res[0].Error == nil {
+		t.Fatalf("expected only drifted account 2 to be audited, got %+v", res)
+	}
+	if !st.accounts[1].LastAuditAt.IsZero() {
+		t.Fatalf("expected the audit time of the drifted account to be cleared, got %v", st.accounts[1].LastAuditAt)
+	}
+
+	// The drifted host is audited again on the next run; clean hosts are not.
+	res, err = AuditAccountsWithOptions(context.Background(), st, dm, "serial", opts, nil)
+	if err != nil {
+		t.Fatalf("audit: %v", err)
+	}
+	if got := auditedIDs(res); len(got) != 1 || got[0] != 2 {
+		t.Fatalf("expected drifted account 2 to be re-audited, got %v", got)
+	}
+}
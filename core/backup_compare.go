@@ -0,0 +1,213 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"sort"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// backupCompareTables are the tables compared by CompareToBackup.
+var backupCompareTables = []string{model.BackupTableAccounts, model.BackupTablePublicKeys, model.BackupTableAccountKeys}
+
+// AccountChange is an account present in the backup and the live database
+// whose settings differ. Fields names the changed settings.
+type AccountChange struct {
+	Account model.Account
+	Fields  []string
+}
+
+// KeyChange is a public key present in both whose settings differ.
+type KeyChange struct {
+	Key    model.PublicKey
+	Fields []string
+}
+
+// AssignmentChange is a key assignment added or removed since the backup,
+// with the account and key it links for display.
+type AssignmentChange struct {
+	Account model.Account
+	Key     model.PublicKey
+}
+
+// BackupComparison lists what changed in the live database since a backup:
+// "added" rows exist only live, "removed" rows only in the backup.
+type BackupComparison struct {
+	AddedAccounts      []model.Account
+	RemovedAccounts    []model.Account
+	ChangedAccounts    []AccountChange
+	AddedKeys          []model.PublicKey
+	RemovedKeys        []model.PublicKey
+	ChangedKeys        []KeyChange
+	AddedAssignments   []AssignmentChange
+	RemovedAssignments []AssignmentChange
+}
+
+// Empty reports whether the live database matches the backup.
+func (c *BackupComparison) Empty() bool {
+	return len(c.AddedAccounts)+len(c.RemovedAccounts)+len(c.ChangedAccounts)+
+		len(c.AddedKeys)+len(c.RemovedKeys)+len(c.ChangedKeys)+
+		len(c.AddedAssignments)+len(c.RemovedAssignments) == 0
+}
+
+// LoadBackup reads a backup file written by `keymaster backup`, compressed
+// or not.
+func LoadBackup(path string) (*model.BackupData, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return ReadBackup(f)
+}
+
+// CompareToBackup diffs the accounts, public keys and key assignments of the
+// live database against backup. Rows are matched by ID. Serials, dirty flags
+// and deployed key lists are deploy state rather than configuration and are
+// ignored. A selective backup must include all three tables.
+func CompareToBackup(live BackupExporter, backup *model.BackupData) (*BackupComparison, error) {
+	for _, table := range backupCompareTables {
+		if !backup.IncludesTable(table) {
+			return nil, fmt.Errorf("backup does not include the %s table", table)
+		}
+	}
+	current, err := live.ExportTablesForBackup(backupCompareTables)
+	if err != nil {
+		return nil, fmt.Errorf("export database: %w", err)
+	}
+
+	c := &BackupComparison{}
+	oldAccounts := make(map[int]model.Account, len(backup.Accounts))
+	for _, acc := range backup.Accounts {
+		// Fill in settings that older backups predate, as a restore would.
+		acc.ManageSystemKey = backup.ManagesSystemKey(acc)
+		acc.DeployEnabled = backup.DeploysEnabled(acc)
+		oldAccounts[acc.ID] = acc
+	}
+	newAccounts := make(map[int]model.Account, len(current.Accounts))
+	for _, acc := range current.Accounts {
+		newAccounts[acc.ID] = acc
+		old, ok := oldAccounts[acc.ID]
+		if !ok {
+			c.AddedAccounts = append(c.AddedAccounts, acc)
+		} else if fields := changedAccountFields(old, acc); len(fields) > 0 {
+			c.ChangedAccounts = append(c.ChangedAccounts, AccountChange{Account: acc, Fields: fields})
+		}
+	}
+	for _, acc := range backup.Accounts {
+		if _, ok := newAccounts[acc.ID]; !ok {
+			c.RemovedAccounts = append(c.RemovedAccounts, acc)
+		}
+	}
+
+	oldKeys := make(map[int]model.PublicKey, len(backup.PublicKeys))
+	for _, k := range backup.PublicKeys {
+		oldKeys[k.ID] = k
+	}
+	newKeys := make(map[int]model.PublicKey, len(current.PublicKeys))
+	for _, k := range current.PublicKeys {
+		newKeys[k.ID] = k
+		old, ok := oldKeys[k.ID]
+		if !ok {
+			c.AddedKeys = append(c.AddedKeys, k)
+		} else if fields := changedKeyFields(old, k); len(fields) > 0 {
+			c.ChangedKeys = append(c.ChangedKeys, KeyChange{Key: k, Fields: fields})
+		}
+	}
+	for _, k := range backup.PublicKeys {
+		if _, ok := newKeys[k.ID]; !ok {
+			c.RemovedKeys = append(c.RemovedKeys, k)
+		}
+	}
+
+	// Resolve assignments against the side they exist on, so removed
+	// assignments of deleted accounts or keys still name them.
+	resolve := func(ak model.AccountKey, accounts map[int]model.Account, keys map[int]model.PublicKey) AssignmentChange {
+		acc, ok := accounts[ak.AccountID]
+		if !ok {
+			acc = model.Account{ID: ak.AccountID}
+		}
+		key, ok := keys[ak.KeyID]
+		if !ok {
+			key = model.PublicKey{ID: ak.KeyID}
+		}
+		return AssignmentChange{Account: acc, Key: key}
+	}
+	oldAssignments := make(map[model.AccountKey]bool, len(backup.AccountKeys))
+	for _, ak := range backup.AccountKeys {
+		oldAssignments[ak] = true
+	}
+	newAssignments := make(map[model.AccountKey]bool, len(current.AccountKeys))
+	for _, ak := range current.AccountKeys {
+		newAssignments[ak] = true
+		if !oldAssignments[ak] {
+			c.AddedAssignments = append(c.AddedAssignments, resolve(ak, newAccounts, newKeys))
+		}
+	}
+	for _, ak := range backup.AccountKeys {
+		if !newAssignments[ak] {
+			c.RemovedAssignments = append(c.RemovedAssignments, resolve(ak, oldAccounts, oldKeys))
+		}
+	}
+	sortAssignmentChanges(c.AddedAssignments)
+	sortAssignmentChanges(c.RemovedAssignments)
+	return c, nil
+}
+
+func changedAccountFields(old, cur model.Account) []string {
+	var fields []string
+	add := func(name string, changed bool) {
+		if changed {
+			fields = append(fields, name)
+		}
+	}
+	add("username", old.Username != cur.Username)
+	add("hostname", old.Hostname != cur.Hostname)
+	add("label", old.Label != cur.Label)
+	add("tags", old.Tags != cur.Tags)
+	add("active", old.IsActive != cur.IsActive)
+	add("deploy_enabled", old.DeployEnabled != cur.DeployEnabled)
+	add("group", old.Group != cur.Group)
+	add("deploy_known_hosts", old.DeployKnownHosts != cur.DeployKnownHosts)
+	add("excluded_global_keys", !slices.Equal(old.ExcludedGlobalKeys, cur.ExcludedGlobalKeys))
+	add("authorized_principals", !slices.Equal(old.AuthorizedPrincipals, cur.AuthorizedPrincipals))
+	add("disable_at", !old.DisableAt.Equal(cur.DisableAt))
+	add("enable_at", !old.EnableAt.Equal(cur.EnableAt))
+	add("remediation_policy", old.RemediationPolicy != cur.RemediationPolicy)
+	add("manage_system_key", old.ManageSystemKey != cur.ManageSystemKey)
+	add("deploy_mode", old.DeployMode != cur.DeployMode)
+	add("os_family", old.OSFamily != cur.OSFamily)
+	add("metadata", !maps.Equal(old.Metadata, cur.Metadata))
+	return fields
+}
+
+func changedKeyFields(old, cur model.PublicKey) []string {
+	var fields []string
+	add := func(name string, changed bool) {
+		if changed {
+			fields = append(fields, name)
+		}
+	}
+	add("key", old.Algorithm != cur.Algorithm || old.KeyData != cur.KeyData)
+	add("comment", old.Comment != cur.Comment)
+	add("global", old.IsGlobal != cur.IsGlobal)
+	add("expires_at", !old.ExpiresAt.Equal(cur.ExpiresAt))
+	add("metadata", !maps.Equal(old.Metadata, cur.Metadata))
+	add("cert_authority", old.IsCA != cur.IsCA || !slices.Equal(old.Principals, cur.Principals))
+	return fields
+}
+
+func sortAssignmentChanges(changes []AssignmentChange) {
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Account.ID != changes[j].Account.ID {
+			return changes[i].Account.ID < changes[j].Account.ID
+		}
+		return changes[i].Key.ID < changes[j].Key.ID
+	})
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// staticExporter serves fixed data as the live database.
+type staticExporter struct{ data *model.BackupData }
+
+func (s staticExporter) ExportTablesForBackup([]string) (*model.BackupData, error) {
+	return s.data, nil
+}
+
+func compareFixture() *model.BackupData {
+	return &model.BackupData{
+		SchemaVersion: model.BackupSchemaVersion,
+		Accounts: []model.Account{
+			{ID: 1, Username: "app", Hostname: "web-01", IsActive: true, Serial: 3},
+			{ID: 2, Username: "app", Hostname: "db-01", IsActive: true},
+		},
+		PublicKeys: []model.PublicKey{
+			{ID: 10, Algorithm: "ssh-ed25519", KeyData: "AAAA", Comment: "alice"},
+			{ID: 11, Algorithm: "ssh-ed25519", KeyData: "BBBB", Comment: "bob"},
+		},
+		AccountKeys: []model.AccountKey{{KeyID: 10, AccountID: 1}},
+	}
+}
+
+func TestCompareToBackup_NewAccountAndAssignment(t *testing.T) {
+	backup := compareFixture()
+	live := compareFixture()
+	live.Accounts = append(live.Accounts, model.Account{ID: 3, Username: "deploy", Hostname: "web-02", IsActive: true})
+	live.AccountKeys = append(live.AccountKeys, model.AccountKey{KeyID: 11, AccountID: 2})
+	// Deploy state is not a change.
+	live.Accounts[0].Serial = 4
+	live.Accounts[0].IsDirty = true
+
+	cmp, err := CompareToBackup(staticExporter{live}, backup)
+	if err != nil {
+		t.Fatalf("compare: %v", err)
+	}
+	if cmp.Empty() {
+		t.Fatal("expected changes")
+	}
+	if len(cmp.AddedAccounts) != 1 || cmp.AddedAccounts[0].ID != 3 {
+		t.Fatalf("expected account 3 added, got %+v", cmp.AddedAccounts)
+	}
+	if len(cmp.AddedAssignments) != 1 || cmp.AddedAssignments[0].Key.Comment != "bob" || cmp.AddedAssignments[0].Account.Hostname != "db-01" {
+		t.Fatalf("expected bob assigned to db-01, got %+v", cmp.AddedAssignments)
+	}
+	if len(cmp.RemovedAccounts)+len(cmp.ChangedAccounts)+len(cmp.AddedKeys)+len(cmp.RemovedKeys)+len(cmp.ChangedKeys)+len(cmp.RemovedAssignments) != 0 {
+		t.Fatalf("unexpected further changes: %+v", cmp)
+	}
+}
+
+func TestCompareToBackup_RemovalsAndChanges(t *testing.T) {
+	backup := compareFixture()
+	live := compareFixture()
+	live.Accounts = live.Accounts[:1]
+	live.Accounts[0].Tags = "web"
+	live.Accounts[0].IsActive = false
+	live.PublicKeys[1].IsGlobal = true
+	live.AccountKeys = nil
+
+	cmp, err := CompareToBackup(staticExporter{live}, backup)
+	if err != nil {
+		t.Fatalf("compare: %v", err)
+	}
+	if len(cmp.RemovedAccounts) != 1 || cmp.RemovedAccounts[0].ID != 2 {
+		t.Fatalf("expected account 2 removed, got %+v", cmp.RemovedAccounts)
+	}
+	if len(cmp.ChangedAccounts) != 1 || !reflect.DeepEqual(cmp.ChangedAccounts[0].Fields, []string{"tags", "active"}) {
+		t.Fatalf("unexpected account changes: %+v", cmp.ChangedAccounts)
+	}
+	if len(cmp.ChangedKeys) != 1 || !reflect.DeepEqual(cmp.ChangedKeys[0].Fields, []string{"global"}) {
+		t.Fatalf("unexpected key changes: %+v", cmp.ChangedKeys)
+	}
+	if len(cmp.RemovedAssignments) != 1 || cmp.RemovedAssignments[0].Key.Comment != "alice" {
+		t.Fatalf("expected alice's assignment removed, got %+v", cmp.RemovedAssignments)
+	}
+
+	same, err := CompareToBackup(staticExporter{compareFixture()}, compareFixture())
+	if err != nil || !same.Empty() {
+		t.Fatalf("expected no changes against an identical backup, got %+v (err %v)", same, err)
+	}
+}
+
+func TestCompareToBackup_RequiresTables(t *testing.T) {
+	backup := compareFixture()
+	backup.Tables = []string{model.BackupTableAccounts}
+	if _, err := CompareToBackup(staticExporter{compareFixture()}, backup); err == nil {
+		t.Fatal("expected a selective backup without keys to be rejected")
+	}
+}
+
+func TestLoadBackup(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteBackupWithOptions(context.Background(), compareFixture(), &buf, WriteBackupOptions{}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "backup.json.zst")
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	data, err := LoadBackup(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(data.Accounts) != 2 || len(data.AccountKeys) != 1 {
+		t.Fatalf("unexpected backup contents: %+v", data)
+	}
+	if _, err := LoadBackup(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+}
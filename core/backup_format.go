@@ -0,0 +1,102 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// Backup compression settings accepted by WriteBackupWithOptions.
+const (
+	BackupCompressionFast    = "fast"
+	BackupCompressionDefault = "default"
+	BackupCompressionBest    = "best"
+	// BackupCompressionNone writes plain JSON.
+	BackupCompressionNone = "none"
+)
+
+// zstdMagic is the frame header every zstd stream starts with.
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+// WriteBackupOptions controls how WriteBackupWithOptions encodes a backup.
+type WriteBackupOptions struct {
+	// Compression is one of the BackupCompression* values. Empty selects
+	// BackupCompressionDefault.
+	Compression string
+}
+
+// zstdLevel maps a compression setting to a zstd encoder level. ok is false
+// for BackupCompressionNone and unknown values are reported as errors.
+func zstdLevel(compression string) (level zstd.EncoderLevel, ok bool, err error) {
+	switch compression {
+	case BackupCompressionFast:
+		return zstd.SpeedFastest, true, nil
+	case "", BackupCompressionDefault:
+		return zstd.SpeedDefault, true, nil
+	case BackupCompressionBest:
+		return zstd.SpeedBestCompression, true, nil
+	case BackupCompressionNone:
+		return 0, false, nil
+	default:
+		return 0, false, fmt.Errorf("unknown backup compression %q (use fast, default, best or none)", compression)
+	}
+}
+
+// ValidateBackupCompression reports an error for unknown compression settings.
+func ValidateBackupCompression(compression string) error {
+	_, _, err := zstdLevel(compression)
+	return err
+}
+
+// WriteBackupWithOptions writes data as indented JSON to w, zstd-compressed
+// at the requested level unless opts selects BackupCompressionNone.
+func WriteBackupWithOptions(ctx context.Context, data *model.BackupData, w io.Writer, opts WriteBackupOptions) error {
+	level, compress, err := zstdLevel(opts.Compression)
+	if err != nil {
+		return err
+	}
+	out := w
+	if compress {
+		zw, err := zstd.NewWriter(w, zstd.WithEncoderLevel(level))
+		if err != nil {
+			return fmt.Errorf("create zstd writer: %w", err)
+		}
+		defer func() { _ = zw.Close() }()
+		out = zw
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(data); err != nil {
+		return fmt.Errorf("encode backup: %w", err)
+	}
+	return nil
+}
+
+// ReadBackup decodes a backup written by WriteBackupWithOptions. zstd input
+// is recognized by its magic bytes; anything else is read as plain JSON.
+func ReadBackup(r io.Reader) (*model.BackupData, error) {
+	br := bufio.NewReader(r)
+	var src io.Reader = br
+	if head, _ := br.Peek(len(zstdMagic)); bytes.Equal(head, zstdMagic) {
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("create zstd reader: %w", err)
+		}
+		defer zr.Close()
+		src = zr
+	}
+	var data model.BackupData
+	if err := json.NewDecoder(src).Decode(&data); err != nil {
+		return nil, fmt.Errorf("decode backup: %w", err)
+	}
+	return &data, nil
+}
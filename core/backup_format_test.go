@@ -0,0 +1,76 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+func TestWriteBackupWithOptions_RoundTrip(t *testing.T) {
+	data := &model.BackupData{
+		SchemaVersion: 1,
+		Accounts:      []model.Account{{ID: 1, Username: "deploy", Hostname: "web-01", IsActive: true}},
+		PublicKeys:    []model.PublicKey{{ID: 2, Algorithm: "ssh-ed25519", KeyData: "AAAA", Comment: "k"}},
+	}
+	for _, c := range []string{"", BackupCompressionFast, BackupCompressionDefault, BackupCompressionBest, BackupCompressionNone} {
+		t.Run("compression="+c, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteBackupWithOptions(context.TODO(), data, &buf, WriteBackupOptions{Compression: c}); err != nil {
+				t.Fatalf("WriteBackupWithOptions: %v", err)
+			}
+			compressed := bytes.HasPrefix(buf.Bytes(), zstdMagic)
+			if compressed == (c == BackupCompressionNone) {
+				t.Fatalf("unexpected encoding for %q: zstd=%v", c, compressed)
+			}
+			got, err := ReadBackup(&buf)
+			if err != nil {
+				t.Fatalf("ReadBackup: %v", err)
+			}
+			if len(got.Accounts) != 1 || got.Accounts[0].Username != "deploy" || len(got.PublicKeys) != 1 {
+				t.Fatalf("round trip mismatch: %+v", got)
+			}
+		})
+	}
+}
+
+func TestWriteBackupWithOptions_PlainJSONIsReadable(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteBackupWithOptions(context.TODO(), &model.BackupData{SchemaVersion: 3}, &buf, WriteBackupOptions{Compression: BackupCompressionNone}); err != nil {
+		t.Fatalf("WriteBackupWithOptions: %v", err)
+	}
+	var decoded model.BackupData
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || decoded.SchemaVersion != 3 {
+		t.Fatalf("expected plain JSON, got %q (%v)", buf.String(), err)
+	}
+}
+
+func TestWriteBackupWithOptions_UnknownCompression(t *testing.T) {
+	if err := ValidateBackupCompression("ultra"); err == nil {
+		t.Fatalf("expected error for unknown compression")
+	}
+	var buf bytes.Buffer
+	if err := WriteBackupWithOptions(context.TODO(), &model.BackupData{}, &buf, WriteBackupOptions{Compression: "ultra"}); err == nil {
+		t.Fatalf("expected error for unknown compression")
+	}
+}
+
+func TestRestore_PlainJSON(t *testing.T) {
+	var buf bytes.Buffer
+	data := &model.BackupData{SchemaVersion: 1, Accounts: []model.Account{{ID: 1, Username: "u", Hostname: "h"}}}
+	if err := WriteBackupWithOptions(context.TODO(), data, &buf, WriteBackupOptions{Compression: BackupCompressionNone}); err != nil {
+		t.Fatalf("WriteBackupWithOptions: %v", err)
+	}
+	st := &fStore{}
+	if _, err := Restore(context.TODO(), &buf, RestoreOptions{Full: true}, st); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if st.gotExport == nil || len(st.gotExport.Accounts) != 1 {
+		t.Fatalf("store did not receive plain JSON backup: %+v", st.gotExport)
+	}
+}
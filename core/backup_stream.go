@@ -0,0 +1,176 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// backupJSONKeys maps backup table names to their key in the backup JSON.
+var backupJSONKeys = map[string]string{
+	model.BackupTableAccounts:          "accounts",
+	model.BackupTablePublicKeys:        "public_keys",
+	model.BackupTableAccountKeys:       "account_keys",
+	model.BackupTableSystemKeys:        "system_keys",
+	model.BackupTableKnownHosts:        "known_hosts",
+	model.BackupTableAuditLog:          "audit_log_entries",
+	model.BackupTableBootstrapSessions: "bootstrap_sessions",
+}
+
+// WriteBackupStream writes a backup of the tables selected by opts (all when
+// empty) to w. Stores implementing BackupStreamer are read through a cursor
+// and each row is encoded as soon as it arrives, so memory use stays flat
+// for very large tables. Other stores fall back to BackupWithOptions and
+// WriteBackupWithOptions. The output is read by ReadBackup either way.
+func WriteBackupStream(ctx context.Context, st Store, opts BackupOptions, w io.Writer, wopts WriteBackupOptions) error {
+	if err := model.ValidateBackupTables(opts.Tables); err != nil {
+		return err
+	}
+	level, compress, err := zstdLevel(wopts.Compression)
+	if err != nil {
+		return err
+	}
+	streamer, ok := st.(BackupStreamer)
+	if !ok {
+		data, err := BackupWithOptions(ctx, st, opts)
+		if err != nil {
+			return err
+		}
+		return WriteBackupWithOptions(ctx, data, w, wopts)
+	}
+
+	out := w
+	var zw *zstd.Encoder
+	if compress {
+		zw, err = zstd.NewWriter(w, zstd.WithEncoderLevel(level))
+		if err != nil {
+			return fmt.Errorf("create zstd writer: %w", err)
+		}
+		defer func() { _ = zw.Close() }()
+		out = zw
+	}
+	bw := bufio.NewWriter(out)
+	enc := &backupStreamEncoder{w: bw}
+	if err := enc.begin(opts.Tables); err != nil {
+		return fmt.Errorf("encode backup: %w", err)
+	}
+	if err := streamer.StreamBackup(opts.Tables, enc.row); err != nil {
+		return err
+	}
+	if err := enc.end(); err != nil {
+		return fmt.Errorf("encode backup: %w", err)
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("encode backup: %w", err)
+	}
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			return fmt.Errorf("close zstd writer: %w", err)
+		}
+	}
+	return nil
+}
+
+// backupStreamEncoder writes the model.BackupData JSON layout piecewise: one
+// array per table in model.BackupTables order, filled row by row.
+type backupStreamEncoder struct {
+	w *bufio.Writer
+	// next is the index in model.BackupTables of the first table whose
+	// array has not been opened yet.
+	next int
+	// rows counts the rows written to the open array.
+	rows int
+}
+
+func (e *backupStreamEncoder) begin(tables []string) error {
+	if _, err := fmt.Fprintf(e.w, "{\n  \"schema_version\": %d", model.BackupSchemaVersion); err != nil {
+		return err
+	}
+	if len(tables) > 0 {
+		b, err := json.Marshal(tables)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(e.w, ",\n  \"tables\": %s", b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// row appends one row to the array of table, closing arrays of earlier
+// tables as needed.
+func (e *backupStreamEncoder) row(table string, row any) error {
+	if err := e.advanceTo(table); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(row, "    ", "  ")
+	if err != nil {
+		return fmt.Errorf("encode %s row: %w", table, err)
+	}
+	sep := ",\n    "
+	if e.rows == 0 {
+		sep = "\n    "
+	}
+	e.rows++
+	if _, err := e.w.WriteString(sep); err != nil {
+		return err
+	}
+	_, err = e.w.Write(b)
+	return err
+}
+
+// advanceTo makes table's array the open one. Rows for a table that was
+// already closed are rejected since they would corrupt the document.
+func (e *backupStreamEncoder) advanceTo(table string) error {
+	for e.next == 0 || model.BackupTables[e.next-1] != table {
+		if e.next >= len(model.BackupTables) {
+			return fmt.Errorf("backup row for table %q out of order", table)
+		}
+		if err := e.closeArray(); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(e.w, ",\n  %q: [", backupJSONKeys[model.BackupTables[e.next]]); err != nil {
+			return err
+		}
+		e.next++
+		e.rows = 0
+	}
+	return nil
+}
+
+func (e *backupStreamEncoder) closeArray() error {
+	if e.next == 0 {
+		return nil
+	}
+	end := "]"
+	if e.rows > 0 {
+		end = "\n  ]"
+	}
+	_, err := e.w.WriteString(end)
+	return err
+}
+
+// end closes the open array, writes empty arrays for the remaining tables
+// and closes the document.
+func (e *backupStreamEncoder) end() error {
+	last := model.BackupTables[len(model.BackupTables)-1]
+	if e.next < len(model.BackupTables) {
+		if err := e.advanceTo(last); err != nil {
+			return err
+		}
+	}
+	if err := e.closeArray(); err != nil {
+		return err
+	}
+	_, err := e.w.WriteString("\n}\n")
+	return err
+}
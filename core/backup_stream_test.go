@@ -0,0 +1,131 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// countingWriter records how many bytes reached it.
+type countingWriter struct {
+	buf bytes.Buffer
+	n   int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += len(p)
+	return w.buf.Write(p)
+}
+
+// syntheticAuditStore streams a generated audit log without ever holding
+// it in memory, recording how much output had been written at the halfway
+// point.
+type syntheticAuditStore struct {
+	*fStore
+	entries   int
+	out       *countingWriter
+	atHalfway int
+}
+
+func (s *syntheticAuditStore) StreamBackup(tables []string, fn func(table string, row any) error) error {
+	if err := fn(model.BackupTableAccounts, model.Account{ID: 1, Username: "app", Hostname: "web-01", IsActive: true}); err != nil {
+		return err
+	}
+	for i := 1; i <= s.entries; i++ {
+		if i == s.entries/2 {
+			s.atHalfway = s.out.n
+		}
+		e := model.AuditLogEntry{ID: i, Timestamp: "2026-01-02T03:04:05Z", Username: "ops", Action: "DEPLOY", Details: fmt.Sprintf("deployed to account %d", i)}
+		if err := fn(model.BackupTableAuditLog, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestWriteBackupStream_StreamsLargeAuditLog(t *testing.T) {
+	for _, compression := range []string{BackupCompressionNone, BackupCompressionFast} {
+		t.Run(compression, func(t *testing.T) {
+			out := &countingWriter{}
+			st := &syntheticAuditStore{fStore: &fStore{}, entries: 50000, out: out}
+			if err := RunWriteBackupStreamCmd(context.TODO(), st, BackupOptions{}, out, WriteBackupOptions{Compression: compression}); err != nil {
+				t.Fatalf("WriteBackupStream: %v", err)
+			}
+			if st.atHalfway == 0 {
+				t.Fatal("expected output to be written while rows were still streaming")
+			}
+			if st.atHalfway >= out.n {
+				t.Fatalf("expected more output after the halfway point, got %d of %d bytes", st.atHalfway, out.n)
+			}
+
+			data, err := ReadBackup(&out.buf)
+			if err != nil {
+				t.Fatalf("ReadBackup: %v", err)
+			}
+			if data.SchemaVersion != model.BackupSchemaVersion || len(data.Tables) != 0 {
+				t.Fatalf("unexpected header: version=%d tables=%v", data.SchemaVersion, data.Tables)
+			}
+			if len(data.Accounts) != 1 || data.Accounts[0].Username != "app" {
+				t.Fatalf("unexpected accounts: %+v", data.Accounts)
+			}
+			if len(data.AuditLogEntries) != 50000 || data.AuditLogEntries[49999].Details != "deployed to account 50000" {
+				t.Fatalf("expected 50000 audit entries, got %d", len(data.AuditLogEntries))
+			}
+			if len(data.PublicKeys) != 0 || len(data.BootstrapSessions) != 0 {
+				t.Fatalf("expected empty tables to stay empty: %+v", data)
+			}
+		})
+	}
+}
+
+func TestWriteBackupStream_SelectiveAndFallback(t *testing.T) {
+	out := &countingWriter{}
+	st := &syntheticAuditStore{fStore: &fStore{}, entries: 3, out: out}
+	tables := []string{model.BackupTableAccounts, model.BackupTableAuditLog}
+	if err := WriteBackupStream(context.TODO(), st, BackupOptions{Tables: tables}, out, WriteBackupOptions{Compression: BackupCompressionNone}); err != nil {
+		t.Fatalf("WriteBackupStream: %v", err)
+	}
+	data, err := ReadBackup(&out.buf)
+	if err != nil {
+		t.Fatalf("ReadBackup: %v", err)
+	}
+	if len(data.Tables) != 2 || !data.IncludesTable(model.BackupTableAuditLog) || data.IncludesTable(model.BackupTablePublicKeys) {
+		t.Fatalf("unexpected tables: %v", data.Tables)
+	}
+	if err := WriteBackupStream(context.TODO(), st, BackupOptions{Tables: []string{"nope"}}, out, WriteBackupOptions{}); err == nil {
+		t.Fatal("expected error for unknown table")
+	}
+
+	// Stores without streaming support go through the in-memory export.
+	var buf bytes.Buffer
+	plain := &fStore{gotExport: &model.BackupData{SchemaVersion: 2, KnownHosts: []model.KnownHost{{Hostname: "h", Key: "k"}}}}
+	if err := WriteBackupStream(context.TODO(), plain, BackupOptions{}, &buf, WriteBackupOptions{}); err != nil {
+		t.Fatalf("fallback: %v", err)
+	}
+	if data, err := ReadBackup(&buf); err != nil || len(data.KnownHosts) != 1 {
+		t.Fatalf("fallback round trip: %+v, %v", data, err)
+	}
+}
+
+func TestBackupStreamEncoder_RejectsOutOfOrderRows(t *testing.T) {
+	var buf bytes.Buffer
+	st := &outOfOrderStore{fStore: &fStore{}}
+	if err := WriteBackupStream(context.TODO(), st, BackupOptions{}, &buf, WriteBackupOptions{Compression: BackupCompressionNone}); err == nil {
+		t.Fatal("expected error for rows of an already closed table")
+	}
+}
+
+type outOfOrderStore struct{ *fStore }
+
+func (s *outOfOrderStore) StreamBackup(tables []string, fn func(table string, row any) error) error {
+	if err := fn(model.BackupTableAuditLog, model.AuditLogEntry{ID: 1}); err != nil {
+		return err
+	}
+	return fn(model.BackupTableAccounts, model.Account{ID: 1})
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// BackupValidationError lists every problem ValidateBackup found.
+type BackupValidationError struct {
+	Problems []string
+}
+
+func (e *BackupValidationError) Error() string {
+	return "invalid backup: " + strings.Join(e.Problems, "; ")
+}
+
+// ValidateBackup checks a decoded backup for problems that would make a
+// restore fail or leave broken data behind: an unknown schema version or
+// table, duplicate IDs and assignments, and references to accounts, keys
+// or system keys missing from the backup. References are only checked when
+// the backup contains the referenced table.
+func ValidateBackup(data *model.BackupData) error {
+	if data == nil {
+		return &BackupValidationError{Problems: []string{"backup is empty"}}
+	}
+	var problems []string
+	addf := func(format string, args ...any) { problems = append(problems, fmt.Sprintf(format, args...)) }
+
+	if data.SchemaVersion > model.BackupSchemaVersion {
+		addf("schema version %d is newer than the supported version %d", data.SchemaVersion, model.BackupSchemaVersion)
+	}
+	if err := model.ValidateBackupTables(data.Tables); err != nil {
+		addf("%v", err)
+	}
+
+	accounts := make(map[int]bool, len(data.Accounts))
+	for _, a := range data.Accounts {
+		if accounts[a.ID] {
+			addf("duplicate account ID %d", a.ID)
+		}
+		accounts[a.ID] = true
+	}
+	keys := make(map[int]bool, len(data.PublicKeys))
+	for _, k := range data.PublicKeys {
+		if keys[k.ID] {
+			addf("duplicate public key ID %d", k.ID)
+		}
+		keys[k.ID] = true
+	}
+	serials := make(map[int]bool, len(data.SystemKeys))
+	for _, sk := range data.SystemKeys {
+		if serials[sk.Serial] {
+			addf("duplicate system key serial %d", sk.Serial)
+		}
+		serials[sk.Serial] = true
+	}
+
+	checkAccounts := data.IncludesTable(model.BackupTableAccounts)
+	checkKeys := data.IncludesTable(model.BackupTablePublicKeys)
+	assignments := make(map[[2]int]bool, len(data.AccountKeys))
+	for _, ak := range data.AccountKeys {
+		pair := [2]int{ak.AccountID, ak.KeyID}
+		if assignments[pair] {
+			addf("duplicate assignment of key %d to account %d", ak.KeyID, ak.AccountID)
+		}
+		assignments[pair] = true
+		if checkAccounts && !accounts[ak.AccountID] {
+			addf("assignment of key %d references missing account %d", ak.KeyID, ak.AccountID)
+		}
+		if checkKeys && !keys[ak.KeyID] {
+			addf("assignment to account %d references missing public key %d", ak.AccountID, ak.KeyID)
+		}
+	}
+	if data.IncludesTable(model.BackupTableSystemKeys) {
+		for _, a := range data.Accounts {
+			if a.Serial != 0 && !serials[a.Serial] {
+				addf("account %d references missing system key serial %d", a.ID, a.Serial)
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return &BackupValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// VerifyBackupRestore validates a backup and restores it into a throwaway
+// in-memory SQLite store created by factory, then checks that the restored
+// data matches the backup table by table. The real database is never
+// touched. It returns the digests of the backup's tables.
+func VerifyBackupRestore(ctx context.Context, factory StoreFactory, data *model.BackupData) ([]TableDigest, error) {
+	if err := ValidateBackup(data); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	trial, err := factory.NewStoreFromDSN("sqlite", ":memory:")
+	if err != nil {
+		return nil, fmt.Errorf("init trial store: %w", err)
+	}
+	defer func() { _ = CloseStore(trial) }()
+
+	if err := trial.ImportDataFromBackup(data); err != nil {
+		return nil, fmt.Errorf("trial restore failed: %w", err)
+	}
+	restored, err := trial.ExportDataForBackup()
+	if err != nil {
+		return nil, fmt.Errorf("export trial store: %w", err)
+	}
+	digests := DigestBackup(data)
+	if mismatches := CompareBackupDigests(digests, DigestBackup(restored)); len(mismatches) > 0 {
+		parts := make([]string, 0, len(mismatches))
+		for _, m := range mismatches {
+			if m.Source.Rows != m.Target.Rows {
+				parts = append(parts, fmt.Sprintf("%s: %d rows in backup, %d restored", m.Table, m.Source.Rows, m.Target.Rows))
+			} else {
+				parts = append(parts, fmt.Sprintf("%s: restored rows differ from backup", m.Table))
+			}
+		}
+		return digests, fmt.Errorf("trial restore does not match the backup: %s", strings.Join(parts, "; "))
+	}
+	return digests, nil
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+func TestVerifyBackupRestore_SQLiteRoundTrip(t *testing.T) {
+	src, err := NewStoreFromDSN("sqlite", "file:"+t.Name()+"?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("source store: %v", err)
+	}
+	if _, err := src.CreateSystemKey("sys-pub", "sys-priv"); err != nil {
+		t.Fatalf("CreateSystemKey: %v", err)
+	}
+	if _, err := src.AddAccount("app", "web-01", "web", "env:prod"); err != nil {
+		t.Fatalf("AddAccount: %v", err)
+	}
+	if err := src.AddKnownHostKey("web-01", "ssh-ed25519 HOSTKEY"); err != nil {
+		t.Fatalf("AddKnownHostKey: %v", err)
+	}
+	data, err := src.ExportDataForBackup()
+	if err != nil {
+		t.Fatalf("ExportDataForBackup: %v", err)
+	}
+
+	digests, err := VerifyBackupRestore(context.TODO(), dsnFactory{}, data)
+	if err != nil {
+		t.Fatalf("VerifyBackupRestore: %v", err)
+	}
+	if len(digests) != len(model.BackupTables) || digests[0].Table != "accounts" || digests[0].Rows != 1 {
+		t.Fatalf("unexpected digests %+v", digests)
+	}
+}
+
+func TestVerifyBackupRestore_RejectsDanglingReferences(t *testing.T) {
+	data := migrateVerifyBackup()
+	data.AccountKeys = append(data.AccountKeys,
+		model.AccountKey{KeyID: 1, AccountID: 9},
+		model.AccountKey{KeyID: 7, AccountID: 2},
+		model.AccountKey{KeyID: 1, AccountID: 1},
+	)
+
+	_, err := VerifyBackupRestore(context.TODO(), fFactory{target: &fStore{}}, data)
+	var verr *BackupValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected BackupValidationError, got %v", err)
+	}
+	want := []string{
+		"assignment of key 1 references missing account 9",
+		"assignment to account 2 references missing public key 7",
+		"duplicate assignment of key 1 to account 1",
+	}
+	if len(verr.Problems) != len(want) {
+		t.Fatalf("expected %d problems, got %q", len(want), verr.Problems)
+	}
+	for i, w := range want {
+		if verr.Problems[i] != w {
+			t.Fatalf("problem %d: expected %q, got %q", i, w, verr.Problems[i])
+		}
+	}
+}
+
+func TestValidateBackup(t *testing.T) {
+	if err := ValidateBackup(migrateVerifyBackup()); err != nil {
+		t.Fatalf("valid backup rejected: %v", err)
+	}
+	if err := ValidateBackup(nil); err == nil {
+		t.Fatal("expected a nil backup to be rejected")
+	}
+
+	data := migrateVerifyBackup()
+	data.SchemaVersion = model.BackupSchemaVersion + 1
+	data.Accounts = append(data.Accounts, model.Account{ID: 2, Username: "dup", Hostname: "web-03", Serial: 5})
+	data.SystemKeys = []model.SystemKey{{Serial: 1}}
+	err := ValidateBackup(data)
+	if err == nil {
+		t.Fatal("expected problems to be reported")
+	}
+	newer := fmt.Sprintf("schema version %d is newer", model.BackupSchemaVersion+1)
+	for _, w := range []string{newer, "duplicate account ID 2", "account 2 references missing system key serial 5"} {
+		if !strings.Contains(err.Error(), w) {
+			t.Fatalf("expected %q in %v", w, err)
+		}
+	}
+
+	// A selective backup without the accounts table may reference accounts
+	// that live only in the target database.
+	partial := &model.BackupData{
+		SchemaVersion: model.BackupSchemaVersion,
+		Tables:        []string{model.BackupTablePublicKeys, model.BackupTableAccountKeys},
+		PublicKeys:    []model.PublicKey{{ID: 1}},
+		AccountKeys:   []model.AccountKey{{KeyID: 1, AccountID: 42}},
+	}
+	if err := ValidateBackup(partial); err != nil {
+		t.Fatalf("selective backup rejected: %v", err)
+	}
+}
+
+func TestVerifyBackupRestore_ReportsIncompleteRestore(t *testing.T) {
+	_, err := VerifyBackupRestore(context.TODO(), lossyFactory{&lossyStore{&fStore{}}}, migrateVerifyBackup())
+	if err == nil || !strings.Contains(err.Error(), "accounts: 2 rows in backup, 1 restored") {
+		t.Fatalf("expected an incomplete trial restore to be reported, got %v", err)
+	}
+}
@@ -6,6 +6,8 @@ package bootstrap
 import (
 	"testing"
 	"time"
+
+	"github.com/toeirei/keymaster/core/model"
 )
 
 func TestRemoveLine_RemovesCorrectLine(t *testing.T) {
@@ -58,6 +60,22 @@ func TestNewBootstrapSession_BasicsAndCommand(t *testing.T) {
 	}
 }
 
+func TestGetBootstrapCommandFor(t *testing.T) {
+	s := &BootstrapSession{TempKeyPair: &TemporaryKeyPair{publicKey: "ssh-ed25519 AAAA o'brien"}}
+
+	if cmd := s.GetBootstrapCommandFor(model.OSFamilyPOSIX); !containsSubstring(cmd, `echo 'ssh-ed25519 AAAA o'\''brien' >> ~/.ssh/authorized_keys`) || !containsSubstring(cmd, "chmod 600") {
+		t.Fatalf("unexpected POSIX command: %q", cmd)
+	}
+	cmd := s.GetBootstrapCommandFor(model.OSFamilyWindows)
+	if !containsSubstring(cmd, `$env:USERPROFILE\.ssh\authorized_keys" -Value 'ssh-ed25519 AAAA o''brien'`) || containsSubstring(cmd, "chmod") {
+		t.Fatalf("unexpected Windows command: %q", cmd)
+	}
+	cmd = s.GetBootstrapCommandFor(model.OSFamilyWindowsAdmin)
+	if !containsSubstring(cmd, `$env:ProgramData\ssh\administrators_authorized_keys`) || !containsSubstring(cmd, "icacls.exe") {
+		t.Fatalf("unexpected Windows administrator command: %q", cmd)
+	}
+}
+
 func TestIsExpired_Behavior(t *testing.T) {
 	s := &BootstrapSession{}
 	s.ExpiresAt = time.Now().Add(-time.Hour)
@@ -21,6 +21,7 @@ import (
 	"github.com/pkg/sftp"
 	"github.com/toeirei/keymaster/core/db"
 	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/core/sshkey"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -39,6 +40,9 @@ var (
 	// currentReaperTicker holds the active ticker started by StartSessionReaper
 	// so tests can stop it when needed.
 	currentReaperTicker *time.Ticker
+	// reaperTasks are additional periodic jobs run on every reaper tick.
+	reaperTasks   []func()
+	reaperTasksMu sync.Mutex
 	// Package-level hooks to allow tests to override SSH and SFTP creation.
 	sshDialFunc = ssh.Dial
 	// sftpNewClient constructs an sftp client adapter; tests may override this to provide fakes.
@@ -188,10 +192,28 @@ func StartSessionReaper() {
 	go func() {
 		for range ticker.C {
 			_ = CleanupExpiredSessions()
+			runReaperTasks()
 		}
 	}()
 }
 
+// RegisterReaperTask adds a job that runs on every tick of the session
+// reaper. It lets other packages piggyback on the same background loop.
+func RegisterReaperTask(fn func()) {
+	reaperTasksMu.Lock()
+	defer reaperTasksMu.Unlock()
+	reaperTasks = append(reaperTasks, fn)
+}
+
+func runReaperTasks() {
+	reaperTasksMu.Lock()
+	tasks := append([]func(){}, reaperTasks...)
+	reaperTasksMu.Unlock()
+	for _, fn := range tasks {
+		fn()
+	}
+}
+
 // markActiveSessionsAsOrphaned marks all currently active sessions as orphaned.
 // This is called during startup to identify sessions that were interrupted by a crash.
 func markActiveSessionsAsOrphaned() error {
@@ -288,6 +310,7 @@ func removeTempKeyFromRemoteHost(session *BootstrapSession) error {
 		HostKeyCallback: hostKeyCallback,
 		Timeout:         10 * time.Second,
 	}
+	sshkey.ApplyConnectionAlgorithms(&config.Config)
 
 	// Connect to the remote host
 	conn, err := sshDialFunc("tcp", session.PendingAccount.Hostname+":22", config)
@@ -311,17 +334,9 @@ func removeTempKeyFromRemoteHost(session *BootstrapSession) error {
 	}
 	defer func() { _ = file.Close() }()
 
-	content := make([]byte, 0, 4096)
-	buffer := make([]byte, 1024)
-	for {
-		n, err := file.Read(buffer)
-		if err != nil && err != io.EOF {
-			return fmt.Errorf("failed to read authorized_keys: %w", err)
-		}
-		content = append(content, buffer[:n]...)
-		if err == io.EOF {
-			break
-		}
+	content, err := sshkey.ReadAuthorizedKeys(file)
+	if err != nil {
+		return fmt.Errorf("failed to read authorized_keys: %w", err)
 	}
 
 	// Remove our temporary key from the content
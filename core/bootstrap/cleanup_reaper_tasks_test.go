@@ -0,0 +1,26 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package bootstrap
+
+import "testing"
+
+func TestRegisterReaperTask_RunsOnTick(t *testing.T) {
+	reaperTasksMu.Lock()
+	prev := reaperTasks
+	reaperTasks = nil
+	reaperTasksMu.Unlock()
+	defer func() {
+		reaperTasksMu.Lock()
+		reaperTasks = prev
+		reaperTasksMu.Unlock()
+	}()
+
+	calls := 0
+	RegisterReaperTask(func() { calls++ })
+	RegisterReaperTask(func() { calls += 10 })
+	runReaperTasks()
+	if calls != 11 {
+		t.Fatalf("expected both tasks to run once, got %d", calls)
+	}
+}
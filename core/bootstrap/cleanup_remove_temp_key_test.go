@@ -11,6 +11,7 @@ import (
 
 	"github.com/pkg/sftp"
 	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/core/sshkey"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -99,6 +100,29 @@ func TestRemoveTempKeyFromRemoteHost_SftpError(t *testing.T) {
 	}
 }
 
+func TestRemoveTempKeyFromRemoteHost_UsesConnectionAlgorithms(t *testing.T) {
+	origSSHDial := sshDialFunc
+	defer func() { sshDialFunc = origSSHDial }()
+	sshkey.SetConnectionAlgorithms(sshkey.ConnectionAlgorithms{Ciphers: []string{"aes128-ctr"}})
+	defer sshkey.SetConnectionAlgorithms(sshkey.ConnectionAlgorithms{})
+
+	tk, err := generateTemporaryKeyPair()
+	if err != nil {
+		t.Fatalf("generateTemporaryKeyPair: %v", err)
+	}
+	sess := &BootstrapSession{PendingAccount: model.Account{Username: "test", Hostname: "example.com"}, TempKeyPair: tk}
+
+	var ciphers []string
+	sshDialFunc = func(network, addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
+		ciphers = config.Ciphers
+		return nil, errors.New("dial failure")
+	}
+	_ = removeTempKeyFromRemoteHost(sess)
+	if len(ciphers) != 1 || ciphers[0] != "aes128-ctr" {
+		t.Fatalf("expected the configured ciphers in the client config, got %v", ciphers)
+	}
+}
+
 // Full success path: override sshDialFunc and sftpNewClient to use a fake SFTP
 func TestRemoveTempKeyFromRemoteHost_FullSuccess(t *testing.T) {
 	origSSHDial := sshDialFunc
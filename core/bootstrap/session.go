@@ -12,6 +12,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"strings"
 	"time"
 
 	internalSSH "github.com/toeirei/keymaster/core/crypto/ssh"
@@ -96,10 +97,43 @@ func NewBootstrapSession(username, hostname, label, tags string) (*BootstrapSess
 // to install the temporary SSH key. This command creates the .ssh directory if needed,
 // adds the temporary key, and sets proper permissions.
 func (s *BootstrapSession) GetBootstrapCommand() string {
-	return fmt.Sprintf(
-		"mkdir -p ~/.ssh && echo '%s' >> ~/.ssh/authorized_keys && chmod 700 ~/.ssh && chmod 600 ~/.ssh/authorized_keys",
-		s.TempKeyPair.publicKey,
-	)
+	return s.GetBootstrapCommandFor(model.OSFamilyPOSIX)
+}
+
+// GetBootstrapCommandFor returns the bootstrap command for a host of the given
+// OS family (see model.OSFamilyPOSIX and friends). Windows hosts get a
+// PowerShell command; for administrators it also restricts the ACL of
+// administrators_authorized_keys, which sshd otherwise ignores.
+func (s *BootstrapSession) GetBootstrapCommandFor(osFamily string) string {
+	key := s.TempKeyPair.publicKey
+	switch osFamily {
+	case model.OSFamilyWindows:
+		return fmt.Sprintf(
+			`New-Item -ItemType Directory -Force -Path "$env:USERPROFILE\.ssh" | Out-Null; Add-Content -Path "$env:USERPROFILE\.ssh\authorized_keys" -Value %s`,
+			quotePowerShell(key),
+		)
+	case model.OSFamilyWindowsAdmin:
+		return fmt.Sprintf(
+			`Add-Content -Path "$env:ProgramData\ssh\administrators_authorized_keys" -Value %s; icacls.exe "$env:ProgramData\ssh\administrators_authorized_keys" /inheritance:r /grant "Administrators:F" /grant "SYSTEM:F"`,
+			quotePowerShell(key),
+		)
+	default:
+		return fmt.Sprintf(
+			"mkdir -p ~/.ssh && echo %s >> ~/.ssh/authorized_keys && chmod 700 ~/.ssh && chmod 600 ~/.ssh/authorized_keys",
+			quotePOSIX(key),
+		)
+	}
+}
+
+// quotePOSIX single-quotes s for a POSIX shell.
+func quotePOSIX(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// quotePowerShell single-quotes s for PowerShell, where a literal quote is
+// written twice.
+func quotePowerShell(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
 }
 
 // IsExpired returns true if the session has exceeded its timeout duration.
@@ -33,6 +33,10 @@ type BootstrapParams struct {
 	// SessionID, if set, links this operation to an existing bootstrap session
 	// so core can update or remove the persisted session record.
 	SessionID string
+	// OSFamily is the remote OS family (see model.OSFamilyPOSIX and friends).
+	// It selects the authorized_keys location and the privilege preflight;
+	// empty means posix.
+	OSFamily string
 }
 
 // BootstrapResult contains the outcome of a bootstrap deployment.
@@ -231,6 +235,24 @@ func PerformBootstrapDeployment(ctx context.Context, params BootstrapParams, dep
 			return res, fmt.Errorf("failed to create bootstrap deployer: %w", derr)
 		}
 		if d != nil {
+			if fd, ok := d.(OSFamilyDeployer); ok && params.OSFamily != "" {
+				fd.SetOSFamily(params.OSFamily)
+			}
+			// Preflight: fail before writing anything when the user cannot
+			// write its authorized_keys or the OS family points at the wrong
+			// file, instead of failing halfway through the deploy.
+			if runner, ok := d.(RemoteCommandRunner); ok {
+				priv, perr := ProbeBootstrapPrivileges(runner, params.OSFamily)
+				if perr != nil {
+					res.Warnings = append(res.Warnings, fmt.Sprintf("privilege preflight skipped: %v", perr))
+				} else if cerr := priv.Check(params.OSFamily); cerr != nil {
+					d.Close()
+					cleanupAccount()
+					return res, fmt.Errorf("bootstrap preflight failed: %w", cerr)
+				} else {
+					res.Warnings = append(res.Warnings, priv.Warnings()...)
+				}
+			}
 			if err := d.DeployAuthorizedKeys(content); err != nil {
 				d.Close()
 				cleanupAccount()
@@ -251,7 +273,7 @@ func PerformBootstrapDeployment(ctx context.Context, params BootstrapParams, dep
 	}
 
 	// Step 6: Audit
-	auditDetails := fmt.Sprintf("bootstrap: account=%s@%s id=%d deployed=%v", params.Username, params.Hostname, accountID, deployed)
+	auditDetails := fmt.Sprintf("bootstrap: account=%s@%s %s deployed=%v", params.Username, params.Hostname, model.AccountRef(accountID), deployed)
 	if deps.Auditor != nil {
 		_ = deps.Auditor.LogAction("BOOTSTRAP_SUCCESS", auditDetails)
 	} else if deps.LogAudit != nil {
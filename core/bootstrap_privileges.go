@@ -0,0 +1,117 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// ErrAuthorizedKeysNotWritable is returned by BootstrapPrivileges.Check when
+// the bootstrap user cannot write its authorized_keys.
+var ErrAuthorizedKeysNotWritable = errors.New("bootstrap user cannot write authorized_keys")
+
+// ErrOSFamilyMismatch is returned by BootstrapPrivileges.Check when the host
+// reads the user's keys from another file than the OS family implies.
+var ErrOSFamilyMismatch = errors.New("OS family does not match the bootstrap user")
+
+// posixPrivilegeProbe prints the uid and whether the user can write the
+// files a deploy touches. Deploys write a temporary file next to
+// authorized_keys and rename it, so the directory must be writable too; when
+// ~/.ssh does not exist yet, home must be writable to create it.
+const posixPrivilegeProbe = `printf 'uid=%s\n' "$(id -u)"; ` +
+	`for p in "$HOME/.ssh/authorized_keys" "$HOME/.ssh" "$HOME"; do ` +
+	`if [ -e "$p" ]; then if [ ! -w "$p" ]; then printf 'unwritable=%s\n' "$p"; fi; ` +
+	`if [ -d "$p" ]; then break; fi; fi; done`
+
+// windowsPrivilegeProbe prints whether the user is in the Administrators group
+// (S-1-5-32-544), whose keys sshd reads from administrators_authorized_keys.
+const windowsPrivilegeProbe = `powershell -NoProfile -NonInteractive -Command "` +
+	`'admin=' + [bool]([Security.Principal.WindowsIdentity]::GetCurrent().Groups | Where-Object { $_.Value -eq 'S-1-5-32-544' })"`
+
+// BootstrapPrivileges is what a bootstrap preflight found out about the
+// bootstrap user on the remote host.
+type BootstrapPrivileges struct {
+	// IsRoot is true for uid 0 on POSIX hosts.
+	IsRoot bool
+	// IsAdministrator is true for Windows users in the Administrators group.
+	IsAdministrator bool
+	// Unwritable lists the paths a POSIX deploy needs to write but cannot.
+	Unwritable []string
+}
+
+// ProbeBootstrapPrivileges runs a read-only check over runner to find out
+// whether a bootstrap as a host of the given OS family can write the user's
+// authorized_keys. An empty family means model.OSFamilyPOSIX.
+func ProbeBootstrapPrivileges(runner RemoteCommandRunner, family string) (BootstrapPrivileges, error) {
+	probe := posixPrivilegeProbe
+	if model.IsWindowsOSFamily(family) {
+		probe = windowsPrivilegeProbe
+	}
+	res, err := runner.RunCommand(probe)
+	if err != nil {
+		return BootstrapPrivileges{}, fmt.Errorf("privilege probe failed: %w", err)
+	}
+	if res.ExitCode != 0 {
+		return BootstrapPrivileges{}, fmt.Errorf("privilege probe exited with status %d: %s", res.ExitCode, strings.TrimSpace(res.Stderr))
+	}
+	return parseBootstrapPrivileges(res.Stdout)
+}
+
+// parseBootstrapPrivileges reads the key=value lines printed by the probes.
+func parseBootstrapPrivileges(out string) (BootstrapPrivileges, error) {
+	var p BootstrapPrivileges
+	seen := false
+	sc := bufio.NewScanner(strings.NewReader(out))
+	for sc.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(sc.Text()), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "uid":
+			seen = true
+			p.IsRoot = value == "0"
+		case "admin":
+			seen = true
+			p.IsAdministrator = strings.EqualFold(value, "true")
+		case "unwritable":
+			p.Unwritable = append(p.Unwritable, value)
+		}
+	}
+	if !seen {
+		return BootstrapPrivileges{}, fmt.Errorf("unexpected privilege probe output: %q", strings.TrimSpace(out))
+	}
+	return p, nil
+}
+
+// Check returns an error telling the operator how to fix the host or the
+// account when a bootstrap with the given OS family would fail to deploy.
+func (p BootstrapPrivileges) Check(family string) error {
+	switch {
+	case family == model.OSFamilyWindows && p.IsAdministrator:
+		return fmt.Errorf("%w: the user is an administrator, so sshd reads %s; use OS family %s",
+			ErrOSFamilyMismatch, model.Account{OSFamily: model.OSFamilyWindowsAdmin}.AuthorizedKeysPath(), model.OSFamilyWindowsAdmin)
+	case family == model.OSFamilyWindowsAdmin && !p.IsAdministrator:
+		return fmt.Errorf("%w: the user is not an administrator, so sshd ignores administrators_authorized_keys; use OS family %s",
+			ErrOSFamilyMismatch, model.OSFamilyWindows)
+	case len(p.Unwritable) > 0:
+		return fmt.Errorf("%w: %s not writable by the user; fix the ownership or bootstrap as the owner of the home directory",
+			ErrAuthorizedKeysNotWritable, strings.Join(p.Unwritable, ", "))
+	}
+	return nil
+}
+
+// Warnings returns non-fatal notes on the privileges, such as bootstrapping
+// root.
+func (p BootstrapPrivileges) Warnings() []string {
+	if p.IsRoot {
+		return []string{"bootstrapping root: every key assigned to this account gets full control of the host"}
+	}
+	return nil
+}
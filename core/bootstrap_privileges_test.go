@@ -0,0 +1,153 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// probeRunner answers every command with a canned result.
+type probeRunner struct {
+	res RemoteCommandResult
+	err error
+	cmd string
+}
+
+func (r *probeRunner) RunCommand(cmd string) (RemoteCommandResult, error) {
+	r.cmd = cmd
+	return r.res, r.err
+}
+
+// probeDeployer is a bootstrap deployer that also answers the privilege probe.
+type probeDeployer struct {
+	testDeployer
+	probeRunner
+	family string
+}
+
+func (d *probeDeployer) SetOSFamily(family string) { d.family = family }
+
+func TestProbeBootstrapPrivileges_POSIX(t *testing.T) {
+	cases := []struct {
+		name     string
+		stdout   string
+		root     bool
+		checkErr error
+	}{
+		{"writable home", "uid=1000\n", false, nil},
+		{"root", "uid=0\n", true, nil},
+		{"read-only home", "uid=1000\nunwritable=/home/svc\n", false, ErrAuthorizedKeysNotWritable},
+		{"root-owned authorized_keys", "uid=1000\nunwritable=/home/svc/.ssh/authorized_keys\n", false, ErrAuthorizedKeysNotWritable},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &probeRunner{res: RemoteCommandResult{Stdout: tc.stdout}}
+			p, err := ProbeBootstrapPrivileges(r, "")
+			if err != nil {
+				t.Fatalf("ProbeBootstrapPrivileges: %v", err)
+			}
+			if !strings.Contains(r.cmd, "id -u") {
+				t.Fatalf("expected the POSIX probe, ran %q", r.cmd)
+			}
+			if p.IsRoot != tc.root {
+				t.Fatalf("IsRoot = %v, want %v", p.IsRoot, tc.root)
+			}
+			if err := p.Check(model.OSFamilyPOSIX); !errors.Is(err, tc.checkErr) {
+				t.Fatalf("Check = %v, want %v", err, tc.checkErr)
+			}
+			if tc.checkErr != nil && !strings.Contains(p.Check("").Error(), "/home/svc") {
+				t.Fatalf("expected the unwritable path in %v", p.Check(""))
+			}
+		})
+	}
+}
+
+func TestProbeBootstrapPrivileges_Windows(t *testing.T) {
+	admin := &probeRunner{res: RemoteCommandResult{Stdout: "admin=True\r\n"}}
+	p, err := ProbeBootstrapPrivileges(admin, model.OSFamilyWindows)
+	if err != nil {
+		t.Fatalf("ProbeBootstrapPrivileges: %v", err)
+	}
+	if !strings.Contains(admin.cmd, "powershell") || !p.IsAdministrator {
+		t.Fatalf("expected an administrator from the PowerShell probe, got %+v (ran %q)", p, admin.cmd)
+	}
+	if err := p.Check(model.OSFamilyWindows); !errors.Is(err, ErrOSFamilyMismatch) || !strings.Contains(err.Error(), "administrators_authorized_keys") {
+		t.Fatalf("expected administrators_authorized_keys to be required, got %v", err)
+	}
+	if err := p.Check(model.OSFamilyWindowsAdmin); err != nil {
+		t.Fatalf("expected windows-admin to fit an administrator, got %v", err)
+	}
+
+	user, err := ProbeBootstrapPrivileges(&probeRunner{res: RemoteCommandResult{Stdout: "admin=False\r\n"}}, model.OSFamilyWindowsAdmin)
+	if err != nil {
+		t.Fatalf("ProbeBootstrapPrivileges: %v", err)
+	}
+	if err := user.Check(model.OSFamilyWindowsAdmin); !errors.Is(err, ErrOSFamilyMismatch) {
+		t.Fatalf("expected a mismatch for a non-administrator, got %v", err)
+	}
+}
+
+func TestProbeBootstrapPrivileges_ProbeFailures(t *testing.T) {
+	if _, err := ProbeBootstrapPrivileges(&probeRunner{res: RemoteCommandResult{ExitCode: 127, Stderr: "sh: not found"}}, ""); err == nil {
+		t.Fatal("expected error for a failing probe")
+	}
+	if _, err := ProbeBootstrapPrivileges(&probeRunner{res: RemoteCommandResult{Stdout: "Microsoft Windows"}}, ""); err == nil {
+		t.Fatal("expected error for unrecognised output")
+	}
+}
+
+func TestPerformBootstrapDeployment_PreflightStopsUnwritableHome(t *testing.T) {
+	d := &probeDeployer{probeRunner: probeRunner{res: RemoteCommandResult{Stdout: "uid=1000\nunwritable=/home/svc\n"}}}
+	deleted := 0
+	deps := BootstrapDeps{
+		AddAccount:          func(u, h, l, tags string) (int, error) { return 7, nil },
+		DeleteAccount:       func(id int) error { deleted = id; return nil },
+		GenerateKeysContent: func(accountID int) (string, error) { return "keys", nil },
+		NewBootstrapDeployer: func(hostname, username string, privateKey interface{}, expectedHostKey string) (BootstrapDeployer, error) {
+			return d, nil
+		},
+	}
+	_, err := PerformBootstrapDeployment(context.Background(), BootstrapParams{Username: "svc", Hostname: "h"}, deps)
+	if !errors.Is(err, ErrAuthorizedKeysNotWritable) {
+		t.Fatalf("expected preflight failure, got %v", err)
+	}
+	if d.used || !d.closed || deleted != 7 {
+		t.Fatalf("expected no deploy, a closed deployer and the account removed; used=%v closed=%v deleted=%d", d.used, d.closed, deleted)
+	}
+}
+
+func TestPerformBootstrapDeployment_PreflightPassesFamilyAndWarnsRoot(t *testing.T) {
+	d := &probeDeployer{probeRunner: probeRunner{res: RemoteCommandResult{Stdout: "admin=True\n"}}}
+	deps := BootstrapDeps{
+		AddAccount:          func(u, h, l, tags string) (int, error) { return 7, nil },
+		GenerateKeysContent: func(accountID int) (string, error) { return "keys", nil },
+		NewBootstrapDeployer: func(hostname, username string, privateKey interface{}, expectedHostKey string) (BootstrapDeployer, error) {
+			return d, nil
+		},
+	}
+	res, err := PerformBootstrapDeployment(context.Background(), BootstrapParams{Username: "admin", Hostname: "h", OSFamily: model.OSFamilyWindowsAdmin}, deps)
+	if err != nil {
+		t.Fatalf("PerformBootstrapDeployment: %v", err)
+	}
+	if !res.RemoteDeployed || d.family != model.OSFamilyWindowsAdmin {
+		t.Fatalf("expected a deploy to the windows-admin layout, got deployed=%v family=%q", res.RemoteDeployed, d.family)
+	}
+
+	root := &probeDeployer{probeRunner: probeRunner{res: RemoteCommandResult{Stdout: "uid=0\n"}}}
+	deps.NewBootstrapDeployer = func(hostname, username string, privateKey interface{}, expectedHostKey string) (BootstrapDeployer, error) {
+		return root, nil
+	}
+	res, err = PerformBootstrapDeployment(context.Background(), BootstrapParams{Username: "root", Hostname: "h"}, deps)
+	if err != nil {
+		t.Fatalf("PerformBootstrapDeployment: %v", err)
+	}
+	if !strings.Contains(strings.Join(res.Warnings, "\n"), "bootstrapping root") {
+		t.Fatalf("expected a root warning, got %v", res.Warnings)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"fmt"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// BulkKeyPlan describes a bulk key assignment before it is applied. Change
+// holds the accounts whose assignment changes; Unchanged those already in the
+// requested state.
+type BulkKeyPlan struct {
+	Key       model.PublicKey
+	Unassign  bool
+	Change    []model.Account
+	Unchanged []model.Account
+}
+
+// Summary returns a one-line description of the plan for confirmation
+// prompts.
+func (p *BulkKeyPlan) Summary() string {
+	if p.Unassign {
+		return fmt.Sprintf("Unassign key %d (%s) from %d account(s); %d already without it",
+			p.Key.ID, p.Key.Comment, len(p.Change), len(p.Unchanged))
+	}
+	return fmt.Sprintf("Assign key %d (%s) to %d account(s); %d already have it",
+		p.Key.ID, p.Key.Comment, len(p.Change), len(p.Unchanged))
+}
+
+// BulkKeyFailure records an account a bulk key assignment could not update.
+type BulkKeyFailure struct {
+	Account model.Account
+	Err     error
+}
+
+// BulkKeyResult is the outcome of [ApplyBulkKeyAssignment].
+type BulkKeyResult struct {
+	Changed []model.Account
+	Failed  []BulkKeyFailure
+}
+
+// PlanBulkKeyAssignment works out which of accounts an assignment (or, with
+// unassign, removal) of key keyID would change. Global keys are deployed to
+// every account already and cannot be assigned.
+func PlanBulkKeyAssignment(km KeyManager, keyID int, accounts []model.Account, unassign bool) (*BulkKeyPlan, error) {
+	keys, err := km.GetAllPublicKeys()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load keys: %w", err)
+	}
+	plan := &BulkKeyPlan{Unassign: unassign}
+	found := false
+	for _, k := range keys {
+		if k.ID == keyID {
+			plan.Key, found = k, true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("key not found: %d", keyID)
+	}
+	if plan.Key.IsGlobal && !unassign {
+		return nil, fmt.Errorf("key %d is global and already deployed to every account", keyID)
+	}
+
+	holders, err := km.GetAccountsForKey(keyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load accounts for key: %w", err)
+	}
+	assigned := make(map[int]bool, len(holders))
+	for _, a := range holders {
+		assigned[a.ID] = true
+	}
+	for _, acc := range accounts {
+		if assigned[acc.ID] == unassign {
+			plan.Change = append(plan.Change, acc)
+		} else {
+			plan.Unchanged = append(plan.Unchanged, acc)
+		}
+	}
+	return plan, nil
+}
+
+// ApplyBulkKeyAssignment applies plan. Only accounts in plan.Change are
+// touched and marked dirty for redeployment, so planning and applying the
+// same selection again changes nothing. A failing account does not stop the
+// others.
+func ApplyBulkKeyAssignment(st AccountDirtyMarker, km KeyManager, plan *BulkKeyPlan) BulkKeyResult {
+	var res BulkKeyResult
+	for _, acc := range plan.Change {
+		var err error
+		if plan.Unassign {
+			err = km.UnassignKeyFromAccount(plan.Key.ID, acc.ID)
+		} else {
+			err = km.AssignKeyToAccount(plan.Key.ID, acc.ID)
+		}
+		if err == nil {
+			if derr := st.UpdateAccountIsDirty(acc.ID, true); derr != nil {
+				err = fmt.Errorf("failed to mark account dirty: %w", derr)
+			}
+		}
+		if err != nil {
+			res.Failed = append(res.Failed, BulkKeyFailure{Account: acc, Err: err})
+			continue
+		}
+		res.Changed = append(res.Changed, acc)
+	}
+	return res
+}
+
+// PlanKeyAccountAssignments plans assigning every key in keyIDs to every
+// account in accounts, the cross product of two selections. It returns one
+// plan per distinct key, in the order the keys were given.
+func PlanKeyAccountAssignments(km KeyManager, keyIDs []int, accounts []model.Account) ([]*BulkKeyPlan, error) {
+	if len(keyIDs) == 0 || len(accounts) == 0 {
+		return nil, fmt.Errorf("select at least one key and one account")
+	}
+	seen := make(map[int]bool, len(keyIDs))
+	var plans []*BulkKeyPlan
+	for _, id := range keyIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		plan, err := PlanBulkKeyAssignment(km, id, accounts, false)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
@@ -0,0 +1,162 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/testutil"
+)
+
+// assignKM tracks key assignments like the real store does.
+type assignKM struct {
+	testutil.FakeKeyManager
+	assigned map[int]bool // account IDs holding the key
+	failFor  int
+}
+
+func (a *assignKM) AssignKeyToAccount(keyID, accountID int) error {
+	if accountID == a.failFor {
+		return errors.New("boom")
+	}
+	_ = a.FakeKeyManager.AssignKeyToAccount(keyID, accountID)
+	a.assigned[accountID] = true
+	return nil
+}
+
+func (a *assignKM) UnassignKeyFromAccount(keyID, accountID int) error {
+	_ = a.FakeKeyManager.UnassignKeyFromAccount(keyID, accountID)
+	delete(a.assigned, accountID)
+	return nil
+}
+
+func (a *assignKM) GetAccountsForKey(keyID int) ([]model.Account, error) {
+	var out []model.Account
+	for id := range a.assigned {
+		out = append(out, model.Account{ID: id})
+	}
+	return out, nil
+}
+
+func TestBulkKeyAssignment_IdempotentAndMarksDirty(t *testing.T) {
+	km := &assignKM{assigned: map[int]bool{2: true}}
+	km.Results = []model.PublicKey{{ID: 7, Comment: "ops"}, {ID: 8, Comment: "all", IsGlobal: true}}
+	accounts := []model.Account{{ID: 1}, {ID: 2}, {ID: 3}}
+	st := &dirtyStore{accounts: accounts, dirty: map[int]bool{}}
+
+	plan, err := PlanBulkKeyAssignment(km, 7, accounts, false)
+	if err != nil {
+		t.Fatalf("PlanBulkKeyAssignment: %v", err)
+	}
+	if len(plan.Change) != 2 || len(plan.Unchanged) != 1 || plan.Unchanged[0].ID != 2 {
+		t.Fatalf("unexpected plan: %+v", plan)
+	}
+	res := ApplyBulkKeyAssignment(st, km, plan)
+	if len(res.Changed) != 2 || len(res.Failed) != 0 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if !st.dirty[1] || !st.dirty[3] || st.dirty[2] {
+		t.Fatalf("expected only changed accounts marked dirty, got %v", st.dirty)
+	}
+
+	// A second run finds nothing to do and leaves the accounts alone.
+	calls := len(km.Calls)
+	st.dirty = map[int]bool{}
+	plan, err = PlanBulkKeyAssignment(km, 7, accounts, false)
+	if err != nil || len(plan.Change) != 0 || len(plan.Unchanged) != 3 {
+		t.Fatalf("expected no-op plan, got %+v (%v)", plan, err)
+	}
+	res = ApplyBulkKeyAssignment(st, km, plan)
+	if len(res.Changed) != 0 || len(km.Calls) != calls || len(st.dirty) != 0 {
+		t.Fatalf("expected no changes, got %+v calls=%d dirty=%v", res, len(km.Calls)-calls, st.dirty)
+	}
+
+	// Unassigning only touches accounts holding the key.
+	plan, _ = PlanBulkKeyAssignment(km, 7, accounts[:2], true)
+	res = ApplyBulkKeyAssignment(st, km, plan)
+	if len(res.Changed) != 2 || km.assigned[1] || km.assigned[2] || !km.assigned[3] {
+		t.Fatalf("unexpected unassign result: %+v, assigned=%v", res, km.assigned)
+	}
+	if !st.dirty[1] || !st.dirty[2] || st.dirty[3] {
+		t.Fatalf("expected unassigned accounts marked dirty, got %v", st.dirty)
+	}
+}
+
+func TestBulkKeyAssignment_FailuresAndValidation(t *testing.T) {
+	km := &assignKM{assigned: map[int]bool{}, failFor: 2}
+	km.Results = []model.PublicKey{{ID: 7, Comment: "ops"}, {ID: 8, Comment: "all", IsGlobal: true}}
+	accounts := []model.Account{{ID: 1}, {ID: 2}, {ID: 3}}
+	st := &dirtyStore{accounts: accounts, dirty: map[int]bool{}}
+
+	plan, _ := PlanBulkKeyAssignment(km, 7, accounts, false)
+	res := ApplyBulkKeyAssignment(st, km, plan)
+	if len(res.Changed) != 2 || len(res.Failed) != 1 || res.Failed[0].Account.ID != 2 {
+		t.Fatalf("expected one failure for account 2, got %+v", res)
+	}
+	if st.dirty[2] {
+		t.Fatal("failed account must not be marked dirty")
+	}
+
+	if _, err := PlanBulkKeyAssignment(km, 99, accounts, false); err == nil {
+		t.Fatal("expected error for unknown key")
+	}
+	if _, err := PlanBulkKeyAssignment(km, 8, accounts, false); err == nil {
+		t.Fatal("expected error assigning a global key")
+	}
+}
+
+// holdersKM reports key holders per key ID.
+type holdersKM struct {
+	testutil.FakeKeyManager
+	holders map[int][]int
+}
+
+func (h *holdersKM) GetAccountsForKey(keyID int) ([]model.Account, error) {
+	var out []model.Account
+	for _, id := range h.holders[keyID] {
+		out = append(out, model.Account{ID: id})
+	}
+	return out, nil
+}
+
+func TestPlanKeyAccountAssignments_CrossProduct(t *testing.T) {
+	km := &holdersKM{holders: map[int][]int{7: {2}}}
+	km.Results = []model.PublicKey{{ID: 7, Comment: "ops"}, {ID: 9, Comment: "dev"}, {ID: 8, Comment: "all", IsGlobal: true}}
+	accounts := []model.Account{{ID: 1}, {ID: 2}, {ID: 3}}
+
+	plans, err := PlanKeyAccountAssignments(km, []int{9, 7, 9}, accounts)
+	if err != nil {
+		t.Fatalf("PlanKeyAccountAssignments: %v", err)
+	}
+	if len(plans) != 2 || plans[0].Key.ID != 9 || plans[1].Key.ID != 7 {
+		t.Fatalf("expected one plan per distinct key in selection order, got %+v", plans)
+	}
+	if len(plans[0].Change) != 3 || len(plans[0].Unchanged) != 0 {
+		t.Fatalf("expected key 9 to change all accounts, got %+v", plans[0])
+	}
+	if len(plans[1].Change) != 2 || len(plans[1].Unchanged) != 1 || plans[1].Unchanged[0].ID != 2 {
+		t.Fatalf("expected key 7 to skip account 2, got %+v", plans[1])
+	}
+
+	st := &dirtyStore{accounts: accounts, dirty: map[int]bool{}}
+	changed := 0
+	for _, p := range plans {
+		changed += len(ApplyBulkKeyAssignment(st, km, p).Changed)
+	}
+	if changed != 5 || len(km.Calls) != 5 {
+		t.Fatalf("expected 5 assignments, got changed=%d calls=%v", changed, km.Calls)
+	}
+
+	if _, err := PlanKeyAccountAssignments(km, []int{7, 8}, accounts); err == nil {
+		t.Fatal("expected error when a global key is selected")
+	}
+	if _, err := PlanKeyAccountAssignments(km, nil, accounts); err == nil {
+		t.Fatal("expected error for an empty key selection")
+	}
+	if _, err := PlanKeyAccountAssignments(km, []int{7}, nil); err == nil {
+		t.Fatal("expected error for an empty account selection")
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// CanaryOptions controls a staged rollout run by RunCanaryDeploy.
+type CanaryOptions struct {
+	// Canaries is the number of accounts deployed and audited before the
+	// remaining accounts are touched. It must be at least one.
+	Canaries int
+	// Tag limits the rollout to deployable accounts carrying this tag.
+	// Empty selects every active account with deploys enabled.
+	Tag string
+	// AccountGroup limits the rollout to the deployable members of this
+	// account group (see model.Account.Group).
+	AccountGroup string
+	// AuditMode is the audit mode used to verify the canaries ("strict" or
+	// "serial"). Empty selects strict.
+	AuditMode string
+}
+
+// CanaryDeployResult reports every stage of a canary rollout. Rest is empty
+// when the rollout was aborted.
+type CanaryDeployResult struct {
+	Canaries    []DeployResult
+	CanaryAudit []AuditResult
+	Rest        []DeployResult
+	Aborted     bool
+}
+
+// CanaryFailedError is returned when a canary failed to deploy or to pass
+// its post-deploy audit. The remaining accounts were not touched.
+type CanaryFailedError struct {
+	Account model.Account
+	// Stage is "deploy" or "audit".
+	Stage string
+	Err   error
+}
+
+func (e *CanaryFailedError) Error() string {
+	return fmt.Sprintf("canary %s failed %s: %v; rollout aborted", e.Account.String(), e.Stage, e.Err)
+}
+
+func (e *CanaryFailedError) Unwrap() error { return e.Err }
+
+// RunCanaryDeploy deploys to opts.Canaries of the selected accounts,
+// audits them and only then deploys to the remaining accounts. The first
+// canary that fails to deploy or audit aborts the rollout with a
+// *CanaryFailedError; the partial result is returned alongside it.
+func RunCanaryDeploy(ctx context.Context, st Store, dm DeployerManager, opts CanaryOptions, rep Reporter) (*CanaryDeployResult, error) {
+	if opts.Canaries < 1 {
+		return nil, fmt.Errorf("canary count must be at least 1, got %d", opts.Canaries)
+	}
+	accounts, err := st.GetAllActiveAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("get accounts: %w", err)
+	}
+	accounts = DeployableAccounts(accounts)
+	if tag := strings.TrimSpace(opts.Tag); tag != "" {
+		accounts = BuildAccountsByTag(accounts)[tag]
+		if len(accounts) == 0 {
+			return nil, fmt.Errorf("no deployable accounts tagged %q", tag)
+		}
+	}
+	if group := strings.TrimSpace(opts.AccountGroup); group != "" {
+		accounts = AccountsInGroup(accounts, group)
+		if len(accounts) == 0 {
+			return nil, fmt.Errorf("no deployable accounts in group %q", group)
+		}
+	}
+	if len(accounts) == 0 {
+		return nil, errors.New("no deployable accounts")
+	}
+
+	canaries, rest := partitionCanaries(accounts, opts.Canaries)
+	lg := DefaultLogger()
+	lg.Info("starting canary rollout", "canaries", len(canaries), "remaining", len(rest), "tag", opts.Tag, "group", opts.AccountGroup)
+
+	res := &CanaryDeployResult{}
+	res.Canaries = deployTargets(dm, canaries)
+	for _, r := range res.Canaries {
+		if r.Error != nil {
+			res.Aborted = true
+			return res, &CanaryFailedError{Account: r.Account, Stage: "deploy", Err: r.Error}
+		}
+	}
+
+	// Deploying updated the serials; audit against the stored state.
+	refreshed, err := st.GetAllActiveAccounts()
+	if err != nil {
+		res.Aborted = true
+		return res, fmt.Errorf("get accounts: %w", err)
+	}
+	byID := make(map[int]model.Account, len(refreshed))
+	for _, acc := range refreshed {
+		byID[acc.ID] = acc
+	}
+	for _, acc := range canaries {
+		if ctx.Err() != nil {
+			res.Aborted = true
+			return res, ctx.Err()
+		}
+		if cur, ok := byID[acc.ID]; ok {
+			acc = cur
+		}
+		ar, merr := auditAccount(st, dm, acc, opts.AuditMode)
+		if merr != nil {
+			res.Aborted = true
+			return res, merr
+		}
+		res.CanaryAudit = append(res.CanaryAudit, ar)
+		if ar.Error != nil {
+			res.Aborted = true
+			lg.Warn("canary audit failed; aborting rollout", "account", acc.String(), "err", ar.Error)
+			return res, &CanaryFailedError{Account: acc, Stage: "audit", Err: ar.Error}
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		res.Aborted = true
+		return res, err
+	}
+	if rep != nil {
+		rep.Reportf("Canaries passed; deploying to %d remaining accounts\n", len(rest))
+	}
+	res.Rest = deployTargets(dm, rest)
+	return res, nil
+}
+
+// partitionCanaries picks n canaries, preferring accounts on distinct hosts so
+// the canaries are representative, and returns them with the remaining
+// accounts. Both slices keep the input order.
+func partitionCanaries(accounts []model.Account, n int) (canaries, rest []model.Account) {
+	if n >= len(accounts) {
+		return accounts, nil
+	}
+	picked := make(map[int]bool, n)
+	seenHosts := make(map[string]bool)
+	for i, acc := range accounts {
+		if len(picked) == n {
+			break
+		}
+		host := strings.ToLower(acc.Hostname)
+		if !seenHosts[host] {
+			seenHosts[host] = true
+			picked[i] = true
+		}
+	}
+	// Fewer distinct hosts than canaries: fill up in order.
+	for i := range accounts {
+		if len(picked) == n {
+			break
+		}
+		picked[i] = true
+	}
+	for i, acc := range accounts {
+		if picked[i] {
+			canaries = append(canaries, acc)
+		} else {
+			rest = append(rest, acc)
+		}
+	}
+	return canaries, rest
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// canaryDM records deployments and fails the serial audit for selected ids.
+type canaryDM struct {
+	fakeDeployerManager
+	deployed  []int
+	deployErr map[int]error
+	auditErr  map[int]error
+}
+
+func (d *canaryDM) DeployForAccount(account model.Account, keepFile bool) error {
+	d.deployed = append(d.deployed, account.ID)
+	return d.deployErr[account.ID]
+}
+
+func (d *canaryDM) AuditSerial(account model.Account) error { return d.auditErr[account.ID] }
+
+func canaryAccounts() []model.Account {
+	return []model.Account{
+		{ID: 1, Username: "app", Hostname: "web-01", Tags: "web", IsActive: true, DeployEnabled: true},
+		{ID: 2, Username: "deploy", Hostname: "web-01", Tags: "web", IsActive: true, DeployEnabled: true},
+		{ID: 3, Username: "app", Hostname: "web-02", Tags: "web", IsActive: true, DeployEnabled: true},
+		{ID: 4, Username: "app", Hostname: "db-01", Tags: "db", IsActive: true, DeployEnabled: true},
+	}
+}
+
+func TestRunCanaryDeploy_AbortsWhenCanaryAuditFails(t *testing.T) {
+	st := &simpleFakeStore{accounts: canaryAccounts()}
+	dm := &canaryDM{auditErr: map[int]error{1: errors.New("serial mismatch")}}
+
+	res, err := RunCanaryDeploy(context.Background(), st, dm, CanaryOptions{Canaries: 1, Tag: "web", AuditMode: "serial"}, nil)
+	var cfe *CanaryFailedError
+	if !errors.As(err, &cfe) || cfe.Stage != "audit" || cfe.Account.ID != 1 {
+		t.Fatalf("expected audit CanaryFailedError for account 1, got %v", err)
+	}
+	if res == nil || !res.Aborted || len(res.Rest) != 0 {
+		t.Fatalf("expected aborted result without remaining deploys, got %+v", res)
+	}
+	if !reflect.DeepEqual(dm.deployed, []int{1}) {
+		t.Fatalf("expected only the canary to be deployed, got %v", dm.deployed)
+	}
+}
+
+func TestRunCanaryDeploy_AbortsWhenCanaryDeployFails(t *testing.T) {
+	st := &simpleFakeStore{accounts: canaryAccounts()}
+	dm := &canaryDM{deployErr: map[int]error{1: errors.New("connection refused")}}
+
+	res, err := RunCanaryDeploy(context.Background(), st, dm, CanaryOptions{Canaries: 2, Tag: "web", AuditMode: "serial"}, nil)
+	var cfe *CanaryFailedError
+	if !errors.As(err, &cfe) || cfe.Stage != "deploy" {
+		t.Fatalf("expected deploy CanaryFailedError, got %v", err)
+	}
+	if len(res.CanaryAudit) != 0 || len(res.Rest) != 0 {
+		t.Fatalf("expected no audit and no remaining deploys, got %+v", res)
+	}
+	// Canaries 1 and 3 sit on distinct hosts; 2 shares web-01 with 1.
+	if !reflect.DeepEqual(dm.deployed, []int{1, 3}) {
+		t.Fatalf("expected canaries 1 and 3 to be deployed, got %v", dm.deployed)
+	}
+}
+
+func TestRunCanaryDeploy_ContinuesAfterPassingCanaries(t *testing.T) {
+	st := &simpleFakeStore{accounts: canaryAccounts()}
+	dm := &canaryDM{}
+
+	res, err := RunCanaryDeploy(context.Background(), st, dm, CanaryOptions{Canaries: 1, Tag: "web", AuditMode: "serial"}, nil)
+	if err != nil {
+		t.Fatalf("RunCanaryDeploy: %v", err)
+	}
+	if res.Aborted || len(res.Canaries) != 1 || len(res.CanaryAudit) != 1 || len(res.Rest) != 2 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	// Account 4 is outside the web group and must not be touched.
+	if !reflect.DeepEqual(dm.deployed, []int{1, 2, 3}) {
+		t.Fatalf("unexpected deploy order %v", dm.deployed)
+	}
+}
+
+func TestRunCanaryDeploy_Validation(t *testing.T) {
+	st := &simpleFakeStore{accounts: canaryAccounts()}
+	if _, err := RunCanaryDeploy(context.Background(), st, &canaryDM{}, CanaryOptions{Canaries: 0}, nil); err == nil {
+		t.Fatal("expected error for zero canaries")
+	}
+	if _, err := RunCanaryDeploy(context.Background(), st, &canaryDM{}, CanaryOptions{Canaries: 1, Tag: "missing"}, nil); err == nil {
+		t.Fatal("expected error for unknown group")
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"fmt"
+
+	"github.com/toeirei/keymaster/core/sshkey"
+)
+
+// SetKeyCertAuthority marks public key id as an SSH certificate authority
+// whose signed certificates are accepted for the listed principals, or
+// clears the mark when isCA is false. Accounts holding the key are marked
+// dirty so the next deploy renders the cert-authority option.
+func SetKeyCertAuthority(km KeyManager, id int, isCA bool, principals []string) error {
+	if !isCA && len(principals) > 0 {
+		return fmt.Errorf("principals require a certificate authority key")
+	}
+	if err := sshkey.ValidatePrincipals(principals); err != nil {
+		return err
+	}
+	keys, err := km.GetAllPublicKeys()
+	if err != nil {
+		return fmt.Errorf("failed to load keys: %w", err)
+	}
+	for _, k := range keys {
+		if k.ID == id {
+			if err := km.SetPublicKeyCertAuthority(id, isCA, principals); err != nil {
+				return fmt.Errorf("failed to update key: %w", err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("key not found: %d", id)
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/testutil"
+	"golang.org/x/crypto/ssh"
+)
+
+// storingKM is a FakeKeyManager whose AddPublicKey stores the key, so it can
+// be looked up again by comment.
+type storingKM struct{ testutil.FakeKeyManager }
+
+func (s *storingKM) AddPublicKey(alg, keyData, comment string, isGlobal bool, expiresAt time.Time) error {
+	s.Results = append(s.Results, model.PublicKey{ID: len(s.Results) + 1, Algorithm: alg, KeyData: keyData, Comment: comment})
+	return nil
+}
+
+func TestImportAuthorizedKeys_CertAuthority(t *testing.T) {
+	line := func(seed byte) string {
+		return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(craftedKey(t, seed))))
+	}
+	input := `cert-authority,principals="deploy,ops" ` + line(1) + " corp-ca\n" +
+		line(2) + " alice\n" +
+		"cert-authority " + line(3) + " open-ca\n"
+	km := &storingKM{}
+	imported, skipped, err := ImportAuthorizedKeys(context.TODO(), strings.NewReader(input), km, nil)
+	if err != nil || imported != 3 || skipped != 0 {
+		t.Fatalf("expected 3 imported, got %d/%d %v", imported, skipped, err)
+	}
+	byComment := map[string]model.PublicKey{}
+	for _, k := range km.Results {
+		byComment[k.Comment] = k
+	}
+	if k := byComment["corp-ca"]; !k.IsCA || !reflect.DeepEqual(k.Principals, []string{"deploy", "ops"}) {
+		t.Fatalf("expected a restricted CA, got %+v", k)
+	}
+	if k := byComment["open-ca"]; !k.IsCA || k.Principals != nil {
+		t.Fatalf("expected an unrestricted CA, got %+v", k)
+	}
+	if k := byComment["alice"]; k.IsCA {
+		t.Fatalf("expected a plain key, got %+v", k)
+	}
+	// The stored CA renders back to the imported line.
+	if got, want := byComment["corp-ca"].AuthorizedKeysLine(), strings.SplitN(input, "\n", 2)[0]; got != want {
+		t.Fatalf("round trip mismatch:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestSetKeyCertAuthority(t *testing.T) {
+	km := &testutil.FakeKeyManager{Results: []model.PublicKey{{ID: 1, Comment: "ca"}}}
+	if err := SetKeyCertAuthority(km, 1, true, []string{"deploy"}); err != nil {
+		t.Fatalf("SetKeyCertAuthority: %v", err)
+	}
+	if k := km.Results[0]; !k.IsCA || !reflect.DeepEqual(k.Principals, []string{"deploy"}) {
+		t.Fatalf("CA mark not applied: %+v", k)
+	}
+	if err := SetKeyCertAuthority(km, 1, true, []string{"bad name"}); err == nil {
+		t.Fatal("expected an invalid principal to be rejected")
+	}
+	if err := SetKeyCertAuthority(km, 1, false, []string{"deploy"}); err == nil {
+		t.Fatal("expected principals without a CA to be rejected")
+	}
+	if err := SetKeyCertAuthority(km, 2, true, nil); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected key not found, got %v", err)
+	}
+}
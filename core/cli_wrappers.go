@@ -6,6 +6,8 @@ package core
 import (
 	"context"
 	"fmt"
+	"io"
+	"time"
 
 	sshgen "github.com/toeirei/keymaster/core/crypto/ssh"
 	"github.com/toeirei/keymaster/core/db"
@@ -23,6 +25,23 @@ func DefaultKeyManager() KeyManager {
 	return km
 }
 
+// KeyManagerFromContext is DefaultKeyManager for callers holding a context:
+// a KeyManager installed with ContextWithKeyManager takes precedence over the
+// package-level default.
+func KeyManagerFromContext(ctx context.Context) KeyManager {
+	km := db.KeyManagerFromContext(ctx)
+	if km == nil {
+		return nil
+	}
+	return km
+}
+
+// ContextWithKeyManager returns a copy of ctx that resolves km as its
+// KeyManager.
+func ContextWithKeyManager(ctx context.Context, km db.KeyManager) context.Context {
+	return db.ContextWithKeyManager(ctx, km)
+}
+
 // GetAccountKeyHash reads the raw key_hash column for an account. This is a
 // small convenience to avoid UIs importing db helpers directly.
 func GetAccountKeyHash(accountID int) (string, error) {
@@ -40,6 +59,29 @@ func InitDB(dbType, dsn string) error {
 	return err
 }
 
+// EnableDBDryRun wraps the initialized store so that database writes are
+// recorded instead of applied. See db.DryRunStore.
+func EnableDBDryRun() error {
+	_, err := db.EnableDryRun()
+	return err
+}
+
+// WriteDBDryRunSummary prints the writes skipped since EnableDBDryRun. It
+// prints nothing when dry-run is not enabled.
+func WriteDBDryRunSummary(w io.Writer) error {
+	d, ok := db.DefaultStore().(*db.DryRunStore)
+	if !ok {
+		return nil
+	}
+	return d.WriteSummary(w)
+}
+
+// MigrationStatus lists the embedded migrations not yet applied to the
+// initialized database and the applied ones this build does not know.
+func MigrationStatus(dbType string) (pending, unknown []string, err error) {
+	return db.MigrationStatus(dbType)
+}
+
 // SetDBDebug toggles DB debug logging.
 func SetDBDebug(enabled bool) { db.SetDebug(enabled) }
 
@@ -75,8 +117,12 @@ func GetAllActiveAccounts() ([]model.Account, error) { return db.GetAllActiveAcc
 // to the db package's RunDBMaintenance helper.
 type dbMaintainer struct{}
 
-func (d dbMaintainer) RunDBMaintenance(dbType, dsn string) error {
-	return db.RunDBMaintenance(dbType, dsn)
+func (d dbMaintainer) RunDBMaintenance(ctx context.Context, dbType, dsn string) error {
+	return db.RunDBMaintenance(ctx, dbType, dsn)
+}
+
+func (d dbMaintainer) AnalyzeDatabase(ctx context.Context, dbType, dsn string) ([]model.TableStats, error) {
+	return db.AnalyzeDatabase(ctx, dbType, dsn)
 }
 
 func DefaultDBMaintainer() DBMaintainer { return dbMaintainer{} }
@@ -101,6 +147,14 @@ func SetAuditContext(clientImplementation, referrer string) {
 	db.SetAuditContext(clientImplementation, referrer)
 }
 
+// SetAuditIdentity overrides the username recorded in audit entries. An
+// empty identity restores the OS-user behavior.
+func SetAuditIdentity(identity string) { db.SetAuditIdentity(identity) }
+
+// CurrentAuditUser returns the username that audit log entries record for
+// this process.
+func CurrentAuditUser() string { return db.CurrentAuditUser() }
+
 // ClearAuditContext clears process-level audit metadata for subsequent writes.
 func ClearAuditContext() {
 	db.ClearAuditContext()
@@ -138,6 +192,9 @@ func (w *dbStoreWrapper) GetAllActiveAccounts() ([]model.Account, error) {
 	return w.inner.GetAllActiveAccounts()
 }
 func (w *dbStoreWrapper) GetAllAccounts() ([]model.Account, error) { return w.inner.GetAllAccounts() }
+func (w *dbStoreWrapper) GetAccountsBelowSerial(serial int) ([]model.Account, error) {
+	return w.inner.GetAccountsBelowSerial(serial)
+}
 func (w *dbStoreWrapper) GetAccount(id int) (*model.Account, error) {
 	if w == nil || w.inner == nil {
 		return nil, fmt.Errorf("dbStoreWrapper: no inner store available")
@@ -195,15 +252,105 @@ func (w *dbStoreWrapper) RotateSystemKey(publicKey, privateKey string) (int, err
 func (w *dbStoreWrapper) GetActiveSystemKey() (*model.SystemKey, error) {
 	return w.inner.GetActiveSystemKey()
 }
+func (w *dbStoreWrapper) GetAllSystemKeys() ([]model.SystemKey, error) {
+	return w.inner.GetAllSystemKeys()
+}
+func (w *dbStoreWrapper) DeleteSystemKey(serial int) error {
+	return w.inner.DeleteSystemKey(serial)
+}
+func (w *dbStoreWrapper) DeactivateSystemKeysBelow(serial int) (int, error) {
+	return w.inner.DeactivateSystemKeysBelow(serial)
+}
 func (w *dbStoreWrapper) AddKnownHostKey(hostname, key string) error {
 	return w.inner.AddKnownHostKey(hostname, key)
 }
 func (w *dbStoreWrapper) ExportDataForBackup() (*model.BackupData, error) {
 	return w.inner.ExportDataForBackup()
 }
+func (w *dbStoreWrapper) ExportTablesForBackup(tables []string) (*model.BackupData, error) {
+	return w.inner.ExportTablesForBackup(tables)
+}
+func (w *dbStoreWrapper) StreamBackup(tables []string, fn func(table string, row any) error) error {
+	return w.inner.StreamBackup(tables, fn)
+}
 func (w *dbStoreWrapper) ImportDataFromBackup(d *model.BackupData) error {
 	return w.inner.ImportDataFromBackup(d)
 }
-func (w *dbStoreWrapper) IntegrateDataFromBackup(d *model.BackupData) error {
+func (w *dbStoreWrapper) IntegrateDataFromBackup(d *model.BackupData) (model.RestoreSummary, error) {
 	return w.inner.IntegrateDataFromBackup(d)
 }
+func (w *dbStoreWrapper) SetAccountSchedule(id int, disableAt, enableAt time.Time) error {
+	return w.inner.SetAccountSchedule(id, disableAt, enableAt)
+}
+
+func (w *dbStoreWrapper) SetAccountRemediationPolicy(id int, policy string) error {
+	return w.inner.SetAccountRemediationPolicy(id, policy)
+}
+
+func (w *dbStoreWrapper) SetAccountDeployEnabled(id int, enabled bool) error {
+	return w.inner.SetAccountDeployEnabled(id, enabled)
+}
+
+func (w *dbStoreWrapper) SetAccountManageSystemKey(id int, manage bool) error {
+	return w.inner.SetAccountManageSystemKey(id, manage)
+}
+
+func (w *dbStoreWrapper) SetAccountDeployMode(id int, mode string) error {
+	return w.inner.SetAccountDeployMode(id, mode)
+}
+
+func (w *dbStoreWrapper) SetAccountOSFamily(id int, family string) error {
+	return w.inner.SetAccountOSFamily(id, family)
+}
+
+func (w *dbStoreWrapper) SetAccountDeployKnownHosts(id int, enabled bool) error {
+	return w.inner.SetAccountDeployKnownHosts(id, enabled)
+}
+
+func (w *dbStoreWrapper) SetAccountExcludedGlobalKeys(id int, keyIDs []int) error {
+	return w.inner.SetAccountExcludedGlobalKeys(id, keyIDs)
+}
+
+func (w *dbStoreWrapper) SetAccountAuthorizedPrincipals(id int, principals []string) error {
+	return w.inner.SetAccountAuthorizedPrincipals(id, principals)
+}
+
+func (w *dbStoreWrapper) SetAccountGroup(id int, group string) error {
+	return w.inner.SetAccountGroup(id, group)
+}
+
+func (w *dbStoreWrapper) SetAccountMetadata(id int, metadata map[string]string) error {
+	return w.inner.SetAccountMetadata(id, metadata)
+}
+
+func (w *dbStoreWrapper) GetAllAuditLogEntries() ([]model.AuditLogEntry, error) {
+	return w.inner.GetAllAuditLogEntries()
+}
+func (w *dbStoreWrapper) GetAuditLogForAccount(accountID int) ([]model.AuditLogEntry, error) {
+	return w.inner.GetAuditLogForAccount(accountID)
+}
+func (w *dbStoreWrapper) RecordOperationResult(r model.OperationResult) error {
+	return w.inner.RecordOperationResult(r)
+}
+func (w *dbStoreWrapper) GetOperationResults(accountID int, since time.Time) ([]model.OperationResult, error) {
+	return w.inner.GetOperationResults(accountID, since)
+}
+func (w *dbStoreWrapper) SetAccountDeployedKeys(id int, keys []string) error {
+	return w.inner.SetAccountDeployedKeys(id, keys)
+}
+
+func (w *dbStoreWrapper) AcquireLock(name string, ttl time.Duration) (bool, error) {
+	return w.inner.AcquireLock(name, ttl)
+}
+
+func (w *dbStoreWrapper) ReleaseLock(name string) error {
+	return w.inner.ReleaseLock(name)
+}
+
+func (w *dbStoreWrapper) RenewLock(name string, ttl time.Duration) error {
+	return w.inner.RenewLock(name, ttl)
+}
+
+func (w *dbStoreWrapper) GetUnassignedPublicKeys() ([]model.PublicKey, error) {
+	return w.inner.GetUnassignedPublicKeys()
+}
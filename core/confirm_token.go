@@ -0,0 +1,32 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ConfirmTokenMismatchError is returned by CheckConfirmToken when the token
+// does not match the number of accounts an operation would affect.
+type ConfirmTokenMismatchError struct {
+	Token    string
+	Affected int
+}
+
+func (e *ConfirmTokenMismatchError) Error() string {
+	return fmt.Sprintf("confirmation token %q does not match the %d affected account(s); refusing to proceed", e.Token, e.Affected)
+}
+
+// CheckConfirmToken verifies a non-interactive confirmation token. The token
+// must be the number of affected accounts, so a CI job that expected to touch
+// 3 hosts aborts instead of silently touching 40 after a tag filter changed.
+func CheckConfirmToken(token string, affected int) error {
+	n, err := strconv.Atoi(strings.TrimSpace(token))
+	if err != nil || n != affected {
+		return &ConfirmTokenMismatchError{Token: token, Affected: affected}
+	}
+	return nil
+}
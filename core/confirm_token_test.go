@@ -0,0 +1,25 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckConfirmToken(t *testing.T) {
+	if err := CheckConfirmToken("42", 42); err != nil {
+		t.Fatalf("expected matching token to pass, got %v", err)
+	}
+	if err := CheckConfirmToken(" 3 ", 3); err != nil {
+		t.Fatalf("expected surrounding whitespace to be ignored, got %v", err)
+	}
+	for _, token := range []string{"41", "", "yes", "-42"} {
+		err := CheckConfirmToken(token, 42)
+		var mm *ConfirmTokenMismatchError
+		if !errors.As(err, &mm) || mm.Affected != 42 || mm.Token != token {
+			t.Errorf("CheckConfirmToken(%q, 42): expected mismatch error, got %v", token, err)
+		}
+	}
+}
@@ -21,8 +21,19 @@ type DashboardData struct {
 	AlgoCounts         map[string]int
 	HostsUpToDate      int
 	HostsOutdated      int
+	DirtyAccountCount  int
 	SystemKeySerial    int
 	RecentLogs         []model.AuditLogEntry
+	DriftEvents        []model.AuditLogEntry
+}
+
+// maxDashboardDriftEvents caps the drift events kept in [DashboardData].
+const maxDashboardDriftEvents = 5
+
+// IsDriftAction reports whether an audit action records configuration drift
+// on a host, e.g. AUDIT_DRIFT_ALERT or DRIFT_REMEDIATED.
+func IsDriftAction(action string) bool {
+	return strings.Contains(strings.ToUpper(action), "DRIFT")
 }
 
 // BuildDashboardData collects accounts, keys, system key and recent audit logs,
@@ -83,6 +94,9 @@ func BuildDashboardData(reader DashboardReader) (DashboardData, error) {
 
 	out.AccountCount = len(accs)
 	for _, acc := range accs {
+		if acc.IsDirty {
+			out.DirtyAccountCount++
+		}
 		if acc.IsActive {
 			out.ActiveAccountCount++
 			if sysKey != nil && sysKey.Serial > 0 {
@@ -108,6 +122,17 @@ func BuildDashboardData(reader DashboardReader) (DashboardData, error) {
 		out.RecentLogs = enrichDashboardLogs(logs, accountsByID, keysByID)
 	}
 
+	var drift []model.AuditLogEntry
+	for _, e := range logs {
+		if IsDriftAction(e.Action) {
+			drift = append(drift, e)
+			if len(drift) == maxDashboardDriftEvents {
+				break
+			}
+		}
+	}
+	out.DriftEvents = enrichDashboardLogs(drift, accountsByID, keysByID)
+
 	return out, nil
 }
 
@@ -123,15 +148,16 @@ func enrichDashboardLogs(logs []model.AuditLogEntry, accountsByID map[int]model.
 
 	out := make([]model.AuditLogEntry, len(logs))
 	for i, logEntry := range logs {
-		details := strings.TrimSpace(logEntry.Details)
+		summary, fields := model.DecodeAuditDetails(logEntry.Details)
+		details := strings.TrimSpace(summary)
 
-		if accID, ok := extractID(accountIDPattern, details); ok {
+		if accID, ok := fieldOrExtractID(fields, model.AuditFieldAccountID, accountIDPattern, details); ok {
 			if acc, found := accountsByID[accID]; found {
 				details = appendRef(details, fmt.Sprintf("account=%s(#%d)", acc.String(), accID))
 			}
 		}
 
-		if keyID, ok := extractID(keyIDPattern, details); ok {
+		if keyID, ok := fieldOrExtractID(fields, model.AuditFieldKeyID, keyIDPattern, details); ok {
 			if key, found := keysByID[keyID]; found {
 				keyName := key.Comment
 				if keyName == "" {
@@ -148,6 +174,15 @@ func enrichDashboardLogs(logs []model.AuditLogEntry, accountsByID map[int]model.
 	return out
 }
 
+// fieldOrExtractID reads an ID from a structured audit field, falling back
+// to matching pattern against the summary of plain text entries.
+func fieldOrExtractID(fields model.AuditFields, name string, pattern *regexp.Regexp, summary string) (int, bool) {
+	if id, err := strconv.Atoi(fields[name]); err == nil {
+		return id, true
+	}
+	return extractID(pattern, summary)
+}
+
 func extractID(pattern *regexp.Regexp, input string) (int, bool) {
 	m := pattern.FindStringSubmatch(input)
 	if len(m) < 2 {
@@ -22,30 +22,51 @@ type fakeStore struct {
 }
 
 func (f fakeStore) GetAllAccounts() ([]model.Account, error)              { return f.accounts, nil }
+func (f fakeStore) GetAllSystemKeys() ([]model.SystemKey, error)          { return nil, nil }
+func (f fakeStore) DeleteSystemKey(serial int) error                      { return nil }
 func (f fakeStore) GetActiveSystemKey() (*model.SystemKey, error)         { return f.sysKey, nil }
 func (f fakeStore) GetAllAuditLogEntries() ([]model.AuditLogEntry, error) { return f.logs, nil }
+func (f fakeStore) GetAuditLogForAccount(accountID int) ([]model.AuditLogEntry, error) {
+	return nil, nil
+}
 
 // Stub methods to satisfy db.Store interface (not used by BuildDashboardData)
-func (f fakeStore) GetAllPublicKeys() ([]model.PublicKey, error)                   { return f.keys, nil }
-func (f fakeStore) GetGlobalPublicKeys() ([]model.PublicKey, error)                { return nil, nil }
-func (f fakeStore) GetKeysForAccount(accountID int) ([]model.PublicKey, error)     { return nil, nil }
-func (f fakeStore) AddAccount(username, hostname, label, tags string) (int, error) { return 0, nil }
-func (f fakeStore) DeleteAccount(id int) error                                     { return nil }
-func (f fakeStore) UpdateAccountSerial(id, serial int) error                       { return nil }
-func (f fakeStore) ToggleAccountStatus(id int, enabled bool) error                 { return nil }
-func (f fakeStore) UpdateAccountLabel(id int, label string) error                  { return nil }
-func (f fakeStore) UpdateAccountHostname(id int, hostname string) error            { return nil }
-func (f fakeStore) UpdateAccountTags(id int, tags string) error                    { return nil }
-func (f fakeStore) GetAllActiveAccounts() ([]model.Account, error)                 { return nil, nil }
-func (f fakeStore) UpdateAccountIsDirty(id int, dirty bool) error                  { return nil }
-func (f fakeStore) GetKnownHostKey(hostname string) (string, error)                { return "", nil }
-func (f fakeStore) AddKnownHostKey(hostname, key string) error                     { return nil }
-func (f fakeStore) CreateSystemKey(publicKey, privateKey string) (int, error)      { return 0, nil }
-func (f fakeStore) RotateSystemKey(publicKey, privateKey string) (int, error)      { return 0, nil }
-func (f fakeStore) GetSystemKeyBySerial(serial int) (*model.SystemKey, error)      { return nil, nil }
-func (f fakeStore) HasSystemKeys() (bool, error)                                   { return false, nil }
-func (f fakeStore) SearchAccounts(query string) ([]model.Account, error)           { return nil, nil }
-func (f fakeStore) LogAction(action, details string) error                         { return nil }
+func (f fakeStore) GetAllPublicKeys() ([]model.PublicKey, error)                     { return f.keys, nil }
+func (f fakeStore) GetGlobalPublicKeys() ([]model.PublicKey, error)                  { return nil, nil }
+func (f fakeStore) GetKeysForAccount(accountID int) ([]model.PublicKey, error)       { return nil, nil }
+func (f fakeStore) AddAccount(username, hostname, label, tags string) (int, error)   { return 0, nil }
+func (f fakeStore) DeleteAccount(id int) error                                       { return nil }
+func (f fakeStore) UpdateAccountSerial(id, serial int) error                         { return nil }
+func (f fakeStore) ToggleAccountStatus(id int, enabled bool) error                   { return nil }
+func (f fakeStore) UpdateAccountLabel(id int, label string) error                    { return nil }
+func (f fakeStore) UpdateAccountHostname(id int, hostname string) error              { return nil }
+func (f fakeStore) UpdateAccountTags(id int, tags string) error                      { return nil }
+func (f fakeStore) GetAllActiveAccounts() ([]model.Account, error)                   { return nil, nil }
+func (f fakeStore) GetAccountsBelowSerial(serial int) ([]model.Account, error)       { return nil, nil }
+func (f fakeStore) UpdateAccountIsDirty(id int, dirty bool) error                    { return nil }
+func (f fakeStore) GetKnownHostKey(hostname string) (string, error)                  { return "", nil }
+func (f fakeStore) AddKnownHostKey(hostname, key string) error                       { return nil }
+func (f fakeStore) CreateSystemKey(publicKey, privateKey string) (int, error)        { return 0, nil }
+func (f fakeStore) RotateSystemKey(publicKey, privateKey string) (int, error)        { return 0, nil }
+func (f fakeStore) GetSystemKeyBySerial(serial int) (*model.SystemKey, error)        { return nil, nil }
+func (f fakeStore) HasSystemKeys() (bool, error)                                     { return false, nil }
+func (f fakeStore) GetUnassignedPublicKeys() ([]model.PublicKey, error)              { return nil, nil }
+func (f fakeStore) SetAccountSchedule(id int, disableAt, enableAt time.Time) error   { return nil }
+func (f fakeStore) SetAccountRemediationPolicy(id int, policy string) error          { return nil }
+func (f fakeStore) SetAccountManageSystemKey(id int, manage bool) error              { return nil }
+func (f fakeStore) SetAccountDeployMode(id int, mode string) error                   { return nil }
+func (f fakeStore) SetAccountDeployEnabled(id int, enabled bool) error               { return nil }
+func (f fakeStore) SetAccountDeployedKeys(id int, keys []string) error               { return nil }
+func (f fakeStore) SetAccountMetadata(id int, metadata map[string]string) error      { return nil }
+func (f fakeStore) SetAccountOSFamily(id int, family string) error                   { return nil }
+func (f fakeStore) SetAccountGroup(id int, group string) error                       { return nil }
+func (f fakeStore) SetAccountDeployKnownHosts(id int, enabled bool) error            { return nil }
+func (f fakeStore) SetAccountExcludedGlobalKeys(id int, keyIDs []int) error          { return nil }
+func (f fakeStore) SetAccountAuthorizedPrincipals(id int, principals []string) error { return nil }
+func (f fakeStore) AcquireLock(name string, ttl time.Duration) (bool, error)         { return true, nil }
+func (f fakeStore) ReleaseLock(name string) error                                    { return nil }
+func (f fakeStore) SearchAccounts(query string) ([]model.Account, error)             { return nil, nil }
+func (f fakeStore) LogAction(action, details string) error                           { return nil }
 func (f fakeStore) SaveBootstrapSession(id, username, hostname, label, tags, tempPublicKey string, expiresAt time.Time, status string) error {
 	return nil
 }
@@ -55,9 +76,15 @@ func (f fakeStore) UpdateBootstrapSessionStatus(id string, status string) error
 func (f fakeStore) GetExpiredBootstrapSessions() ([]*model.BootstrapSession, error)  { return nil, nil }
 func (f fakeStore) GetOrphanedBootstrapSessions() ([]*model.BootstrapSession, error) { return nil, nil }
 func (f fakeStore) ExportDataForBackup() (*model.BackupData, error)                  { return nil, nil }
-func (f fakeStore) ImportDataFromBackup(*model.BackupData) error                     { return nil }
-func (f fakeStore) IntegrateDataFromBackup(*model.BackupData) error                  { return nil }
-func (f fakeStore) BunDB() *bun.DB                                                   { return nil }
+func (f fakeStore) ExportTablesForBackup(tables []string) (*model.BackupData, error) { return nil, nil }
+func (f fakeStore) StreamBackup(tables []string, fn func(table string, row any) error) error {
+	return nil
+}
+func (f fakeStore) ImportDataFromBackup(*model.BackupData) error { return nil }
+func (f fakeStore) IntegrateDataFromBackup(*model.BackupData) (model.RestoreSummary, error) {
+	return model.RestoreSummary{}, nil
+}
+func (f fakeStore) BunDB() *bun.DB { return nil }
 
 func (f fakeStore) AddPublicKeyAndGetModel(algorithm, keyData, comment string, isGlobal bool, expiresAt time.Time) (*model.PublicKey, error) {
 	return nil, nil
@@ -112,6 +139,42 @@ func TestBuildDashboardData(t *testing.T) {
 	if !reflect.DeepEqual(out.RecentLogs, logs) {
 		t.Fatalf("unexpected recent logs")
 	}
+	if out.DirtyAccountCount != 0 || len(out.DriftEvents) != 0 {
+		t.Fatalf("expected no dirty accounts or drift events, got %d/%v", out.DirtyAccountCount, out.DriftEvents)
+	}
+}
+
+func TestBuildDashboardData_DirtyAndDrift(t *testing.T) {
+	store := fakeStore{
+		accounts: []model.Account{
+			{ID: 1, Username: "app", Hostname: "web-01", IsActive: true, IsDirty: true},
+			{ID: 2, IsActive: false, IsDirty: true},
+			{ID: 3, IsActive: true},
+		},
+		logs: []model.AuditLogEntry{
+			{ID: 9, Action: "DEPLOY_SUCCESS"},
+			{ID: 8, Action: "AUDIT_DRIFT_ALERT", Details: "account_id:1 app@web-01"},
+			{ID: 7, Action: "DRIFT_REMEDIATED", Details: "account_id:1 app@web-01"},
+			{ID: 6, Action: "ADD_ACCOUNT"},
+		},
+	}
+	for i := 0; i < maxDashboardDriftEvents; i++ {
+		store.logs = append(store.logs, model.AuditLogEntry{ID: 5 - i, Action: "AUDIT_DRIFT_ALERT"})
+	}
+
+	out, err := BuildDashboardData(store)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.DirtyAccountCount != 2 {
+		t.Fatalf("expected 2 dirty accounts, got %d", out.DirtyAccountCount)
+	}
+	if len(out.DriftEvents) != maxDashboardDriftEvents || out.DriftEvents[0].ID != 8 || out.DriftEvents[1].ID != 7 {
+		t.Fatalf("expected the newest %d drift events, got %+v", maxDashboardDriftEvents, out.DriftEvents)
+	}
+	if !strings.Contains(out.DriftEvents[0].Details, "account=app@web-01(#1)") {
+		t.Fatalf("expected enriched drift details, got %q", out.DriftEvents[0].Details)
+	}
 }
 
 func TestBuildDashboardData_EnrichesLogDetails(t *testing.T) {
@@ -141,6 +204,25 @@ func TestBuildDashboardData_EnrichesLogDetails(t *testing.T) {
 	}
 }
 
+func TestBuildDashboardData_EnrichesStructuredLogDetails(t *testing.T) {
+	accounts := []model.Account{{ID: 7, Username: "deploy", Hostname: "prod-01", IsActive: true, Serial: 11}}
+	keys := []model.PublicKey{{ID: 42, Algorithm: "ssh-ed25519", KeyData: "AAAAB3NzaC1yc2EAAAADAQABAAABAQC", Comment: "ops-key"}}
+	logs := []model.AuditLogEntry{{
+		Timestamp: "2026-05-23 10:00:00",
+		Username:  "tester",
+		Action:    "ASSIGN_KEY",
+		Details:   model.EncodeAuditDetails("key assigned", model.AuditFields{model.AuditFieldKeyID: "42", model.AuditFieldAccountID: "7"}),
+	}}
+
+	out, err := BuildDashboardData(fakeStore{accounts: accounts, sysKey: &model.SystemKey{Serial: 11}, logs: logs, keys: keys})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := out.RecentLogs[0].Details; got != "key assigned | account=deploy@prod-01(#7) | key=ops-key(#42)" {
+		t.Fatalf("expected enriched summary without JSON, got: %q", got)
+	}
+}
+
 func containsAll(input string, parts []string) bool {
 	for _, p := range parts {
 		if !strings.Contains(input, p) {
@@ -0,0 +1,33 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package db
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSetAccountAuthorizedPrincipalsBun_RoundTrip(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		id, err := AddAccountBun(s.BunDB(), "u", "h", "", "")
+		if err != nil {
+			t.Fatalf("AddAccountBun: %v", err)
+		}
+		if acc, _ := s.GetAccount(id); len(acc.AuthorizedPrincipals) != 0 {
+			t.Fatalf("expected no principals for new accounts, got %v", acc.AuthorizedPrincipals)
+		}
+		if err := s.SetAccountAuthorizedPrincipals(id, []string{"deploy", "ops-oncall"}); err != nil {
+			t.Fatalf("SetAccountAuthorizedPrincipals: %v", err)
+		}
+		if acc, _ := s.GetAccount(id); !slices.Equal(acc.AuthorizedPrincipals, []string{"deploy", "ops-oncall"}) {
+			t.Fatalf("principals not persisted: %+v", acc)
+		}
+		if err := s.SetAccountAuthorizedPrincipals(id, nil); err != nil {
+			t.Fatalf("SetAccountAuthorizedPrincipals: %v", err)
+		}
+		if acc, _ := s.GetAccount(id); len(acc.AuthorizedPrincipals) != 0 {
+			t.Fatalf("expected principals to be cleared, got %v", acc.AuthorizedPrincipals)
+		}
+	})
+}
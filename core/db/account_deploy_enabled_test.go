@@ -0,0 +1,52 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package db
+
+import (
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+func TestSetAccountDeployEnabledBun_RoundTrip(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		id, err := AddAccountBun(s.BunDB(), "u", "h", "", "")
+		if err != nil {
+			t.Fatalf("AddAccountBun: %v", err)
+		}
+		acc, _ := s.GetAccount(id)
+		if acc == nil || !acc.DeployEnabled {
+			t.Fatalf("expected new accounts to have deploys enabled, got %+v", acc)
+		}
+
+		if err := s.SetAccountDeployEnabled(id, false); err != nil {
+			t.Fatalf("SetAccountDeployEnabled: %v", err)
+		}
+		acc, _ = s.GetAccount(id)
+		if acc.DeployEnabled || !acc.IsActive {
+			t.Fatalf("expected an active account with deploys disabled, got %+v", acc)
+		}
+	})
+}
+
+func TestImportDataFromBackupBun_DeployEnabledBySchemaVersion(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		// Version 2 backups predate the setting and must stay deployable.
+		legacy := &model.BackupData{SchemaVersion: 2, Accounts: []model.Account{{ID: 1, Username: "u", Hostname: "h", IsActive: true}}}
+		if err := ImportDataFromBackupBun(s.BunDB(), legacy); err != nil {
+			t.Fatalf("ImportDataFromBackupBun: %v", err)
+		}
+		if acc, _ := s.GetAccount(1); acc == nil || !acc.DeployEnabled {
+			t.Fatalf("expected legacy account to have deploys enabled, got %+v", acc)
+		}
+
+		current := &model.BackupData{SchemaVersion: model.BackupSchemaVersion, Accounts: []model.Account{{ID: 1, Username: "u", Hostname: "h", IsActive: true}}}
+		if err := ImportDataFromBackupBun(s.BunDB(), current); err != nil {
+			t.Fatalf("ImportDataFromBackupBun: %v", err)
+		}
+		if acc, _ := s.GetAccount(1); acc == nil || acc.DeployEnabled {
+			t.Fatalf("expected restored setting to be kept, got %+v", acc)
+		}
+	})
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package db
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+func TestSetAccountDeployModeAndKeysBun_RoundTrip(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		id, err := AddAccountBun(s.BunDB(), "u", "h", "", "")
+		if err != nil {
+			t.Fatalf("AddAccountBun: %v", err)
+		}
+		acc, _ := s.GetAccount(id)
+		if acc.EffectiveDeployMode() != model.DeployModeReplace || acc.DeployedKeys != nil {
+			t.Fatalf("expected default deploy mode and no recorded keys, got %+v", acc)
+		}
+
+		if err := s.SetAccountDeployMode(id, model.DeployModeAppendOnly); err != nil {
+			t.Fatalf("SetAccountDeployMode: %v", err)
+		}
+		keys := []string{"ssh-ed25519 AAA", "ssh-rsa BBB"}
+		if err := s.SetAccountDeployedKeys(id, keys); err != nil {
+			t.Fatalf("SetAccountDeployedKeys: %v", err)
+		}
+		acc, _ = s.GetAccount(id)
+		if acc.DeployMode != model.DeployModeAppendOnly || !reflect.DeepEqual(acc.DeployedKeys, keys) {
+			t.Fatalf("settings not persisted: %+v", acc)
+		}
+	})
+}
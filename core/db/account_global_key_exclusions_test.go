@@ -0,0 +1,33 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package db
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSetAccountExcludedGlobalKeysBun_RoundTrip(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		id, err := AddAccountBun(s.BunDB(), "u", "h", "", "")
+		if err != nil {
+			t.Fatalf("AddAccountBun: %v", err)
+		}
+		if acc, _ := s.GetAccount(id); len(acc.ExcludedGlobalKeys) != 0 {
+			t.Fatalf("expected no exclusions for new accounts, got %v", acc.ExcludedGlobalKeys)
+		}
+		if err := s.SetAccountExcludedGlobalKeys(id, []int{3, 12}); err != nil {
+			t.Fatalf("SetAccountExcludedGlobalKeys: %v", err)
+		}
+		if acc, _ := s.GetAccount(id); !slices.Equal(acc.ExcludedGlobalKeys, []int{3, 12}) {
+			t.Fatalf("exclusions not persisted: %+v", acc)
+		}
+		if err := s.SetAccountExcludedGlobalKeys(id, nil); err != nil {
+			t.Fatalf("SetAccountExcludedGlobalKeys: %v", err)
+		}
+		if acc, _ := s.GetAccount(id); len(acc.ExcludedGlobalKeys) != 0 {
+			t.Fatalf("expected exclusions to be cleared, got %v", acc.ExcludedGlobalKeys)
+		}
+	})
+}
@@ -0,0 +1,32 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package db
+
+import "testing"
+
+func TestSetAccountGroupBun_RoundTrip(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		id, err := AddAccountBun(s.BunDB(), "u", "h", "", "")
+		if err != nil {
+			t.Fatalf("AddAccountBun: %v", err)
+		}
+		if acc, _ := s.GetAccount(id); acc.Group != "" {
+			t.Fatalf("expected no group by default, got %q", acc.Group)
+		}
+
+		if err := s.SetAccountGroup(id, "web"); err != nil {
+			t.Fatalf("SetAccountGroup: %v", err)
+		}
+		if acc, _ := s.GetAccount(id); acc.Group != "web" {
+			t.Fatalf("group not persisted: %+v", acc)
+		}
+
+		if err := s.SetAccountGroup(id, ""); err != nil {
+			t.Fatalf("SetAccountGroup clear: %v", err)
+		}
+		if acc, _ := s.GetAccount(id); acc.Group != "" {
+			t.Fatalf("expected group to be cleared, got %q", acc.Group)
+		}
+	})
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package db
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+func TestGetAuditLogForAccount_FiltersOneAccount(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		id, err := s.AddAccount("app", "web-01", "", "")
+		if err != nil {
+			t.Fatalf("AddAccount: %v", err)
+		}
+		other, err := s.AddAccount("app", "web-02", "", "")
+		if err != nil {
+			t.Fatalf("AddAccount: %v", err)
+		}
+		if err := s.UpdateAccountLabel(id, "primary"); err != nil {
+			t.Fatalf("UpdateAccountLabel: %v", err)
+		}
+		if err := s.ToggleAccountStatus(other, false); err != nil {
+			t.Fatalf("ToggleAccountStatus: %v", err)
+		}
+		// An ID sharing a prefix with id, and an entry in the legacy format.
+		_ = s.LogAction("DEPLOY_SUCCESS", model.AccountRef(id*10+2))
+		_ = s.LogAction("AUDIT_DRIFT_ALERT", fmt.Sprintf("account:%d app@web-01", id))
+		if err := s.ToggleAccountStatus(id, false); err != nil {
+			t.Fatalf("ToggleAccountStatus: %v", err)
+		}
+
+		entries, err := s.GetAuditLogForAccount(id)
+		if err != nil {
+			t.Fatalf("GetAuditLogForAccount: %v", err)
+		}
+		var actions []string
+		for _, e := range entries {
+			if !model.AuditReferencesAccount(e.Details, id) {
+				t.Fatalf("entry %d does not reference account %d: %q", e.ID, id, e.Details)
+			}
+			actions = append(actions, e.Action)
+		}
+		want := []string{"ADD_ACCOUNT", "UPDATE_ACCOUNT_LABEL", "AUDIT_DRIFT_ALERT", "TOGGLE_ACCOUNT_STATUS"}
+		if len(actions) != len(want) {
+			t.Fatalf("expected %v, got %v", want, actions)
+		}
+		for i := range want {
+			if actions[i] != want[i] {
+				t.Fatalf("expected %v in order, got %v", want, actions)
+			}
+		}
+
+		entries, err = s.GetAuditLogForAccount(other)
+		if err != nil || len(entries) != 2 {
+			t.Fatalf("expected 2 entries for account %d, got %v (%v)", other, entries, err)
+		}
+		if entries, err := s.GetAuditLogForAccount(999); err != nil || len(entries) != 0 {
+			t.Fatalf("expected no entries for unknown account, got %v (%v)", entries, err)
+		}
+	})
+}
+
+func TestAuditFields_RoundTripAndQueryable(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		id, err := s.AddAccount("app", "web-01", "", "")
+		if err != nil {
+			t.Fatalf("AddAccount: %v", err)
+		}
+		km := &bunKeyManager{bStore: s}
+		pk, err := km.AddPublicKeyAndGetModel("ssh-ed25519", "AAAAhistory", "ops-key", false, time.Time{})
+		if err != nil || pk == nil {
+			t.Fatalf("AddPublicKeyAndGetModel: %v", err)
+		}
+		if err := km.AssignKeyToAccount(pk.ID, id); err != nil {
+			t.Fatalf("AssignKeyToAccount: %v", err)
+		}
+		fields := model.AuditFields{model.AuditFieldAccountID: strconv.Itoa(id), model.AuditFieldError: `quote " and 'apostrophe'`}
+		if err := s.LogActionFields("DEPLOY_FAILED", "deploy to app@web-01 failed", fields); err != nil {
+			t.Fatalf("LogActionFields: %v", err)
+		}
+
+		entries, err := s.GetAuditLogForAccount(id)
+		if err != nil {
+			t.Fatalf("GetAuditLogForAccount: %v", err)
+		}
+		byAction := map[string]model.AuditLogEntry{}
+		for _, e := range entries {
+			byAction[e.Action] = e
+		}
+		assign, ok := byAction["ASSIGN_KEY"]
+		if !ok || assign.Summary() != "key: 'ops-key' to account: app@web-01" {
+			t.Fatalf("expected structured ASSIGN_KEY in history, got %+v", entries)
+		}
+		if f := assign.Fields(); f[model.AuditFieldKeyID] != strconv.Itoa(pk.ID) || f[model.AuditFieldAccount] != "app@web-01" {
+			t.Fatalf("unexpected ASSIGN_KEY fields: %v", f)
+		}
+		deploy, ok := byAction["DEPLOY_FAILED"]
+		if !ok || deploy.Summary() != "deploy to app@web-01 failed" || !reflect.DeepEqual(deploy.Fields(), fields) {
+			t.Fatalf("expected DEPLOY_FAILED fields to round-trip, got %+v", deploy)
+		}
+
+		// Another account whose ID starts with the same digit is not matched.
+		other := model.AuditFields{model.AuditFieldAccountID: strconv.Itoa(id*10 + 1)}
+		_ = s.LogActionFields("DEPLOY_SUCCESS", "deployed", other)
+		if again, _ := s.GetAuditLogForAccount(id); len(again) != len(entries) {
+			t.Fatalf("expected %d entries after logging for another account, got %d", len(entries), len(again))
+		}
+	})
+}
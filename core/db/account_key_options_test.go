@@ -0,0 +1,62 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+func TestAssignKeyToAccountWithOptionsBun_RoundTrip(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		backupID, err := AddAccountBun(s.BunDB(), "backup", "nas", "", "")
+		if err != nil {
+			t.Fatalf("AddAccountBun: %v", err)
+		}
+		shellID, err := AddAccountBun(s.BunDB(), "ops", "web", "", "")
+		if err != nil {
+			t.Fatalf("AddAccountBun: %v", err)
+		}
+		pk, err := AddPublicKeyAndGetModelBun(s.BunDB(), "ssh-ed25519", "AAAAopts", "alice", false, time.Time{})
+		if err != nil || pk == nil {
+			t.Fatalf("AddPublicKeyAndGetModelBun: %v", err)
+		}
+		opts := `command="rsync --server . /srv",no-pty`
+		if err := AssignKeyToAccountWithOptionsBun(s.BunDB(), pk.ID, backupID, opts); err != nil {
+			t.Fatalf("AssignKeyToAccountWithOptionsBun: %v", err)
+		}
+		if err := AssignKeyToAccountBun(s.BunDB(), pk.ID, shellID); err != nil {
+			t.Fatalf("AssignKeyToAccountBun: %v", err)
+		}
+
+		keys, err := GetKeysForAccountBun(s.BunDB(), backupID)
+		if err != nil || len(keys) != 1 || keys[0].AssignmentOptions != opts {
+			t.Fatalf("options not persisted: %+v %v", keys, err)
+		}
+		keys, err = GetKeysForAccountBun(s.BunDB(), shellID)
+		if err != nil || len(keys) != 1 || keys[0].AssignmentOptions != "" {
+			t.Fatalf("expected no options on the second assignment: %+v %v", keys, err)
+		}
+		if got, _ := GetPublicKeyByIDBun(s.BunDB(), pk.ID); got == nil || got.AssignmentOptions != "" {
+			t.Fatalf("options must not leak into the key itself: %+v", got)
+		}
+
+		backup, err := s.ExportDataForBackup()
+		if err != nil {
+			t.Fatalf("ExportDataForBackup: %v", err)
+		}
+		if err := s.ImportDataFromBackup(&model.BackupData{SchemaVersion: backup.SchemaVersion}); err != nil {
+			t.Fatalf("wipe: %v", err)
+		}
+		if err := s.ImportDataFromBackup(backup); err != nil {
+			t.Fatalf("ImportDataFromBackup: %v", err)
+		}
+		keys, err = GetKeysForAccountBun(s.BunDB(), backupID)
+		if err != nil || len(keys) != 1 || keys[0].AssignmentOptions != opts {
+			t.Fatalf("options lost in restore: %+v %v", keys, err)
+		}
+	})
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package db
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+func TestSetAccountDeployKnownHostsBun_RoundTrip(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		id, err := AddAccountBun(s.BunDB(), "u", "h", "", "")
+		if err != nil {
+			t.Fatalf("AddAccountBun: %v", err)
+		}
+		if acc, _ := s.GetAccount(id); acc.DeployKnownHosts {
+			t.Fatal("expected known_hosts deployment to be off for new accounts")
+		}
+		if err := s.SetAccountDeployKnownHosts(id, true); err != nil {
+			t.Fatalf("SetAccountDeployKnownHosts: %v", err)
+		}
+		if acc, _ := s.GetAccount(id); !acc.DeployKnownHosts {
+			t.Fatalf("setting not persisted: %+v", acc)
+		}
+	})
+}
+
+func TestGetAllKnownHostsBun_OrderedByHostname(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		for _, kh := range []model.KnownHost{{Hostname: "web-02:22", Key: "ssh-ed25519 BBBB"}, {Hostname: "db-01:22", Key: "ssh-ed25519 AAAA"}} {
+			if err := s.AddKnownHostKey(kh.Hostname, kh.Key); err != nil {
+				t.Fatalf("AddKnownHostKey: %v", err)
+			}
+		}
+		got, err := s.GetAllKnownHosts()
+		if err != nil {
+			t.Fatalf("GetAllKnownHosts: %v", err)
+		}
+		want := []model.KnownHost{{Hostname: "db-01:22", Key: "ssh-ed25519 AAAA"}, {Hostname: "web-02:22", Key: "ssh-ed25519 BBBB"}}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("GetAllKnownHosts = %+v, want %+v", got, want)
+		}
+	})
+}
@@ -0,0 +1,38 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetAccountLastAuditBun_RoundTrip(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		id, err := AddAccountBun(s.BunDB(), "u", "h", "", "")
+		if err != nil {
+			t.Fatalf("AddAccountBun: %v", err)
+		}
+		acc, _ := s.GetAccount(id)
+		if !acc.LastAuditAt.IsZero() {
+			t.Fatalf("expected no audit time on a new account, got %v", acc.LastAuditAt)
+		}
+
+		at := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+		if err := s.SetAccountLastAudit(id, at); err != nil {
+			t.Fatalf("SetAccountLastAudit: %v", err)
+		}
+		acc, _ = s.GetAccount(id)
+		if !acc.LastAuditAt.Equal(at) {
+			t.Fatalf("audit time not persisted: got %v, want %v", acc.LastAuditAt, at)
+		}
+
+		if err := s.SetAccountLastAudit(id, time.Time{}); err != nil {
+			t.Fatalf("SetAccountLastAudit clear: %v", err)
+		}
+		if acc, _ = s.GetAccount(id); !acc.LastAuditAt.IsZero() {
+			t.Fatalf("expected audit time to be cleared, got %v", acc.LastAuditAt)
+		}
+	})
+}
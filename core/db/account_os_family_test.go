@@ -0,0 +1,38 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package db
+
+import (
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+func TestSetAccountOSFamilyBun_RoundTrip(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		id, err := AddAccountBun(s.BunDB(), "u", "h", "", "")
+		if err != nil {
+			t.Fatalf("AddAccountBun: %v", err)
+		}
+		acc, _ := s.GetAccount(id)
+		if acc.OSFamily != "" || acc.EffectiveOSFamily() != model.OSFamilyPOSIX {
+			t.Fatalf("expected default OS family, got %+v", acc)
+		}
+
+		if err := s.SetAccountOSFamily(id, model.OSFamilyWindowsAdmin); err != nil {
+			t.Fatalf("SetAccountOSFamily: %v", err)
+		}
+		acc, _ = s.GetAccount(id)
+		if acc.OSFamily != model.OSFamilyWindowsAdmin {
+			t.Fatalf("OS family not persisted: %+v", acc)
+		}
+
+		if err := s.SetAccountOSFamily(id, ""); err != nil {
+			t.Fatalf("SetAccountOSFamily reset: %v", err)
+		}
+		if acc, _ = s.GetAccount(id); acc.OSFamily != "" {
+			t.Fatalf("expected OS family to be reset, got %q", acc.OSFamily)
+		}
+	})
+}
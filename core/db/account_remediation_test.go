@@ -0,0 +1,39 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package db
+
+import (
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+func TestSetAccountRemediationPolicyBun_RoundTrip(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		id, err := AddAccountBun(s.BunDB(), "u", "h", "", "")
+		if err != nil {
+			t.Fatalf("AddAccountBun: %v", err)
+		}
+		accts, _ := s.GetAllAccounts()
+		if accts[0].RemediationPolicy != "" || accts[0].EffectiveRemediationPolicy() != model.RemediationAlert {
+			t.Fatalf("expected default policy, got %+v", accts[0])
+		}
+
+		if err := s.SetAccountRemediationPolicy(id, model.RemediationAuto); err != nil {
+			t.Fatalf("SetAccountRemediationPolicy: %v", err)
+		}
+		accts, _ = s.GetAllAccounts()
+		if accts[0].RemediationPolicy != model.RemediationAuto {
+			t.Fatalf("policy not persisted: %+v", accts[0])
+		}
+
+		if err := s.SetAccountRemediationPolicy(id, ""); err != nil {
+			t.Fatalf("reset policy: %v", err)
+		}
+		accts, _ = s.GetAllAccounts()
+		if accts[0].RemediationPolicy != "" {
+			t.Fatalf("policy not reset: %+v", accts[0])
+		}
+	})
+}
@@ -0,0 +1,39 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetAccountScheduleBun_RoundTrip(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		id, err := AddAccountBun(s.BunDB(), "u", "h", "", "")
+		if err != nil {
+			t.Fatalf("AddAccountBun: %v", err)
+		}
+		disableAt := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+		enableAt := disableAt.Add(2 * time.Hour)
+		if err := s.SetAccountSchedule(id, disableAt, enableAt); err != nil {
+			t.Fatalf("SetAccountSchedule: %v", err)
+		}
+
+		accts, err := s.GetAllAccounts()
+		if err != nil || len(accts) != 1 {
+			t.Fatalf("GetAllAccounts: %v %+v", err, accts)
+		}
+		if !accts[0].DisableAt.Equal(disableAt) || !accts[0].EnableAt.Equal(enableAt) {
+			t.Fatalf("schedule not persisted: %+v", accts[0])
+		}
+
+		if err := s.SetAccountSchedule(id, time.Time{}, time.Time{}); err != nil {
+			t.Fatalf("clear schedule: %v", err)
+		}
+		accts, _ = s.GetAllAccounts()
+		if !accts[0].DisableAt.IsZero() || !accts[0].EnableAt.IsZero() {
+			t.Fatalf("schedule not cleared: %+v", accts[0])
+		}
+	})
+}
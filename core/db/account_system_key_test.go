@@ -0,0 +1,52 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package db
+
+import (
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+func TestSetAccountManageSystemKeyBun_RoundTrip(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		id, err := AddAccountBun(s.BunDB(), "u", "h", "", "")
+		if err != nil {
+			t.Fatalf("AddAccountBun: %v", err)
+		}
+		acc, _ := s.GetAccount(id)
+		if acc == nil || !acc.ManageSystemKey {
+			t.Fatalf("expected new accounts to manage the system key, got %+v", acc)
+		}
+
+		if err := s.SetAccountManageSystemKey(id, false); err != nil {
+			t.Fatalf("SetAccountManageSystemKey: %v", err)
+		}
+		acc, _ = s.GetAccount(id)
+		if acc.ManageSystemKey {
+			t.Fatalf("setting not persisted: %+v", acc)
+		}
+	})
+}
+
+func TestImportDataFromBackupBun_ManageSystemKeyBySchemaVersion(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		// Version 1 backups predate the setting and must keep the system key.
+		legacy := &model.BackupData{SchemaVersion: 1, Accounts: []model.Account{{ID: 1, Username: "u", Hostname: "h", IsActive: true}}}
+		if err := ImportDataFromBackupBun(s.BunDB(), legacy); err != nil {
+			t.Fatalf("ImportDataFromBackupBun: %v", err)
+		}
+		if acc, _ := s.GetAccount(1); acc == nil || !acc.ManageSystemKey {
+			t.Fatalf("expected legacy account to manage the system key, got %+v", acc)
+		}
+
+		current := &model.BackupData{SchemaVersion: model.BackupSchemaVersion, Accounts: []model.Account{{ID: 1, Username: "u", Hostname: "h", IsActive: true}}}
+		if err := ImportDataFromBackupBun(s.BunDB(), current); err != nil {
+			t.Fatalf("ImportDataFromBackupBun: %v", err)
+		}
+		if acc, _ := s.GetAccount(1); acc == nil || acc.ManageSystemKey {
+			t.Fatalf("expected restored setting to be kept, got %+v", acc)
+		}
+	})
+}
@@ -0,0 +1,33 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package db
+
+import "testing"
+
+func TestGetAccountsBelowSerialBun(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		serials := map[string]int{"never": 0, "old": 1, "older": 2, "current": 3, "ahead": 4}
+		for host, serial := range serials {
+			id, err := AddAccountBun(s.BunDB(), "app", host, "", "")
+			if err != nil {
+				t.Fatalf("AddAccountBun: %v", err)
+			}
+			if err := s.UpdateAccountSerial(id, serial); err != nil {
+				t.Fatalf("UpdateAccountSerial: %v", err)
+			}
+		}
+
+		got, err := s.GetAccountsBelowSerial(3)
+		if err != nil {
+			t.Fatalf("GetAccountsBelowSerial: %v", err)
+		}
+		var hosts []string
+		for _, a := range got {
+			hosts = append(hosts, a.Hostname)
+		}
+		if len(hosts) != 3 || hosts[0] != "never" || hosts[1] != "old" || hosts[2] != "older" {
+			t.Fatalf("expected accounts below serial 3 ordered by serial, got %v", hosts)
+		}
+	})
+}
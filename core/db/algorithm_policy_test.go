@@ -0,0 +1,50 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package db
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/toeirei/keymaster/core/sshkey"
+)
+
+func TestKeyManager_RejectsDisallowedAlgorithm(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		// A key stored before the allow-list was configured.
+		if err := AddPublicKeyBun(s.BunDB(), "ssh-rsa", "AAAAB3rsa", "legacy-rsa", false, time.Time{}); err != nil {
+			t.Fatalf("AddPublicKeyBun: %v", err)
+		}
+		accID, err := s.AddAccount("deploy", "web-01", "", "")
+		if err != nil {
+			t.Fatalf("AddAccount: %v", err)
+		}
+
+		sshkey.SetAllowedAlgorithms([]string{"ssh-ed25519"})
+		t.Cleanup(func() { sshkey.SetAllowedAlgorithms(nil) })
+
+		km := DefaultKeyManager()
+		if err := km.AddPublicKey("ssh-rsa", "AAAAB3new", "new-rsa", false, time.Time{}); !errors.Is(err, sshkey.ErrAlgorithmNotAllowed) {
+			t.Fatalf("AddPublicKey: expected ErrAlgorithmNotAllowed, got %v", err)
+		}
+		if _, err := km.AddPublicKeyAndGetModel("ssh-rsa", "AAAAB3new", "new-rsa", false, time.Time{}); !errors.Is(err, sshkey.ErrAlgorithmNotAllowed) {
+			t.Fatalf("AddPublicKeyAndGetModel: expected ErrAlgorithmNotAllowed, got %v", err)
+		}
+		if err := km.AddPublicKey("ssh-ed25519", "AAAAC3ok", "ok-ed25519", false, time.Time{}); err != nil {
+			t.Fatalf("AddPublicKey allowed algorithm: %v", err)
+		}
+
+		legacy, err := km.GetPublicKeyByComment("legacy-rsa")
+		if err != nil || legacy == nil {
+			t.Fatalf("GetPublicKeyByComment: %v", err)
+		}
+		if err := km.AssignKeyToAccount(legacy.ID, accID); !errors.Is(err, sshkey.ErrAlgorithmNotAllowed) {
+			t.Fatalf("AssignKeyToAccount: expected ErrAlgorithmNotAllowed, got %v", err)
+		}
+		if keys, _ := km.GetKeysForAccount(accID); len(keys) != 0 {
+			t.Fatalf("expected no assignment, got %d keys", len(keys))
+		}
+	})
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// AnalyzeDatabase reports row counts and table/index sizes for every table
+// of the given database. For SQLite it first runs ANALYZE to refresh the
+// query planner statistics; Postgres statistics are refreshed by the VACUUM
+// ANALYZE of RunDBMaintenance. Queries run with ctx; without a deadline on
+// ctx a two-minute limit applies.
+func AnalyzeDatabase(ctx context.Context, dbType, dsn string) ([]model.TableStats, error) {
+	driverName := dbType
+	if dbType == "postgres" {
+		driverName = "pgx"
+	}
+	sqlDB, err := sqlOpenFunc(driverName, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database for analysis: %w", err)
+	}
+	defer func() { _ = sqlDB.Close() }()
+	// One connection keeps every query on the same database, which matters
+	// for in-memory SQLite.
+	sqlDB.SetMaxOpenConns(1)
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, 2*time.Minute)
+		defer cancel()
+	}
+
+	var stats []model.TableStats
+	switch dbType {
+	case "sqlite":
+		if _, err := sqlDB.ExecContext(ctx, "ANALYZE;"); err != nil {
+			return nil, fmt.Errorf("sqlite analyze failed: %w", err)
+		}
+		stats, err = sqliteTableStats(ctx, sqlDB)
+	case "postgres":
+		stats, err = scanTableStats(ctx, sqlDB, `SELECT c.relname, pg_table_size(c.oid), pg_indexes_size(c.oid),
+	(SELECT COUNT(*) FROM pg_index i WHERE i.indrelid = c.oid)
+FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE c.relkind = 'r' AND n.nspname = current_schema()
+ORDER BY c.relname`)
+	case "mysql":
+		stats, err = scanTableStats(ctx, sqlDB, `SELECT t.TABLE_NAME, t.DATA_LENGTH, t.INDEX_LENGTH,
+	(SELECT COUNT(DISTINCT s.INDEX_NAME) FROM information_schema.STATISTICS s
+		WHERE s.TABLE_SCHEMA = t.TABLE_SCHEMA AND s.TABLE_NAME = t.TABLE_NAME)
+FROM information_schema.TABLES t
+WHERE t.TABLE_SCHEMA = DATABASE() AND t.TABLE_TYPE = 'BASE TABLE'
+ORDER BY t.TABLE_NAME`)
+	default:
+		return nil, fmt.Errorf("unsupported db type for analysis: %s", dbType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Catalog row estimates can be stale; count exactly.
+	for i := range stats {
+		q := fmt.Sprintf("SELECT COUNT(*) FROM %s", quoteIdent(dbType, stats[i].Table))
+		if err := sqlDB.QueryRowContext(ctx, q).Scan(&stats[i].Rows); err != nil {
+			return nil, fmt.Errorf("count rows of %s: %w", stats[i].Table, err)
+		}
+	}
+	return stats, nil
+}
+
+// sqliteTableStats lists user tables with sizes from the dbstat virtual
+// table. Sizes are -1 when SQLite was built without dbstat.
+func sqliteTableStats(ctx context.Context, sqlDB *sql.DB) ([]model.TableStats, error) {
+	stats, err := scanTableStats(ctx, sqlDB, `SELECT t.name, -1, -1,
+	(SELECT COUNT(*) FROM sqlite_master i WHERE i.type = 'index' AND i.tbl_name = t.name)
+FROM sqlite_master t
+WHERE t.type = 'table' AND t.name NOT LIKE 'sqlite_%'
+ORDER BY t.name`)
+	if err != nil {
+		return nil, err
+	}
+	for i := range stats {
+		var table, index sql.NullInt64
+		err := sqlDB.QueryRowContext(ctx, `SELECT
+	(SELECT SUM(pgsize) FROM dbstat WHERE name = ?),
+	(SELECT SUM(d.pgsize) FROM dbstat d JOIN sqlite_master m ON m.name = d.name WHERE m.type = 'index' AND m.tbl_name = ?)`,
+			stats[i].Table, stats[i].Table).Scan(&table, &index)
+		if err != nil {
+			dbLogf("db: sqlite dbstat unavailable (sizes omitted): %v", err)
+			break
+		}
+		stats[i].TableBytes = table.Int64
+		stats[i].IndexBytes = index.Int64
+	}
+	return stats, nil
+}
+
+// scanTableStats runs a catalog query returning name, table bytes, index
+// bytes and index count per table.
+func scanTableStats(ctx context.Context, sqlDB *sql.DB, query string) ([]model.TableStats, error) {
+	rows, err := sqlDB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("list tables failed: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	var stats []model.TableStats
+	for rows.Next() {
+		var s model.TableStats
+		var tableBytes, indexBytes sql.NullInt64
+		if err := rows.Scan(&s.Table, &tableBytes, &indexBytes, &s.Indexes); err != nil {
+			return nil, fmt.Errorf("read table stats failed: %w", err)
+		}
+		s.TableBytes, s.IndexBytes = -1, -1
+		if tableBytes.Valid {
+			s.TableBytes = tableBytes.Int64
+		}
+		if indexBytes.Valid {
+			s.IndexBytes = indexBytes.Int64
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
+
+// quoteIdent quotes a table name for the given backend.
+func quoteIdent(dbType, name string) string {
+	if dbType == "mysql" {
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
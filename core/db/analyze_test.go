@@ -0,0 +1,69 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package db
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+func TestAnalyzeDatabase_SqliteCountsMatchInsertedRows(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		for i := 0; i < 3; i++ {
+			if _, err := s.AddAccount(fmt.Sprintf("user%d", i), "web-01", "", ""); err != nil {
+				t.Fatalf("AddAccount: %v", err)
+			}
+		}
+		for _, key := range []string{"AAAAanalyzeOne", "AAAAanalyzeTwo"} {
+			if err := AddPublicKeyBun(s.BunDB(), "ssh-ed25519", key, key, false, time.Time{}); err != nil {
+				t.Fatalf("AddPublicKeyBun: %v", err)
+			}
+		}
+		for i := 0; i < 40; i++ {
+			if err := s.LogAction("TEST", fmt.Sprintf("entry %d", i)); err != nil {
+				t.Fatalf("LogAction: %v", err)
+			}
+		}
+		audit, err := s.GetAllAuditLogEntries()
+		if err != nil {
+			t.Fatalf("GetAllAuditLogEntries: %v", err)
+		}
+
+		stats, err := AnalyzeDatabase(context.Background(), "sqlite", "file:"+t.Name()+"?mode=memory&cache=shared")
+		if err != nil {
+			t.Fatalf("AnalyzeDatabase: %v", err)
+		}
+		byTable := map[string]model.TableStats{}
+		for _, st := range stats {
+			byTable[st.Table] = st
+		}
+		want := map[string]int64{"accounts": 3, "public_keys": 2, "account_keys": 0, "audit_log": int64(len(audit))}
+		for table, rows := range want {
+			got, ok := byTable[table]
+			if !ok {
+				t.Fatalf("table %s missing from stats: %+v", table, stats)
+			}
+			if got.Rows != rows {
+				t.Fatalf("%s: expected %d rows, got %d", table, rows, got.Rows)
+			}
+		}
+		if acc := byTable["accounts"]; acc.TableBytes <= 0 || acc.Indexes == 0 || acc.IndexBytes <= 0 {
+			t.Fatalf("expected sizes and indexes for accounts, got %+v", acc)
+		}
+		// ANALYZE creates sqlite_stat1, but internal tables are not reported.
+		if _, ok := byTable["sqlite_stat1"]; ok {
+			t.Fatalf("internal sqlite table reported: %+v", stats)
+		}
+	})
+}
+
+func TestAnalyzeDatabase_UnsupportedType(t *testing.T) {
+	if _, err := AnalyzeDatabase(context.Background(), "sqlite3-unknown", "dsn"); err == nil {
+		t.Fatalf("expected error for unknown db type")
+	}
+}
@@ -3,14 +3,25 @@
 // This source code is licensed under the MIT license found in the LICENSE file.
 package db
 
-import "sync"
+import (
+	"os"
+	"os/user"
+	"strings"
+	"sync"
+)
 
 // AuditContext holds optional metadata captured for audit log writes.
 type AuditContext struct {
 	ClientImplementation string
 	Referrer             string
+	// Identity, when set, replaces the OS user recorded in audit entries.
+	Identity string
 }
 
+// AuditUserEnv names the environment variable that overrides the identity
+// recorded in audit entries. It takes precedence over SetAuditIdentity.
+const AuditUserEnv = "KEYMASTER_AUDIT_USER"
+
 var (
 	auditContextMu sync.RWMutex
 	auditContext   AuditContext
@@ -24,6 +35,14 @@ func SetAuditContext(clientImplementation, referrer string) {
 	auditContext.Referrer = referrer
 }
 
+// SetAuditIdentity sets the identity recorded in future audit writes in place
+// of the OS user. An empty identity restores the OS-user behavior.
+func SetAuditIdentity(identity string) {
+	auditContextMu.Lock()
+	defer auditContextMu.Unlock()
+	auditContext.Identity = strings.TrimSpace(identity)
+}
+
 // ClearAuditContext clears process-level metadata used in audit writes.
 func ClearAuditContext() {
 	SetAuditContext("", "")
@@ -34,3 +53,40 @@ func getAuditContext() AuditContext {
 	defer auditContextMu.RUnlock()
 	return auditContext
 }
+
+// currentOSUsername returns the OS username of the running process. Tests
+// may override it.
+var currentOSUsername = func() (string, error) {
+	u, err := user.Current()
+	if err != nil {
+		return "", err
+	}
+	return u.Username, nil
+}
+
+// CurrentAuditUser returns the username recorded in audit log entries for
+// the running process. The KEYMASTER_AUDIT_USER environment variable wins,
+// then an identity set via SetAuditIdentity, then the OS user. Windows
+// "DOMAIN\user" names are reduced to "user"; "unknown" is returned when the
+// OS user cannot be determined.
+func CurrentAuditUser() string {
+	if env := strings.TrimSpace(os.Getenv(AuditUserEnv)); env != "" {
+		return env
+	}
+	if id := getAuditContext().Identity; id != "" {
+		return id
+	}
+	name, err := currentOSUsername()
+	if err != nil {
+		return "unknown"
+	}
+	return auditUsername(name)
+}
+
+// auditUsername strips a Windows domain prefix from an OS username.
+func auditUsername(osUser string) string {
+	if parts := strings.Split(osUser, `\`); len(parts) > 1 {
+		return parts[1]
+	}
+	return osUser
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package db
+
+import (
+	"errors"
+	"testing"
+)
+
+func withOSUsername(t *testing.T, name string, err error) {
+	t.Helper()
+	prev := currentOSUsername
+	currentOSUsername = func() (string, error) { return name, err }
+	t.Cleanup(func() { currentOSUsername = prev })
+	t.Setenv(AuditUserEnv, "")
+}
+
+func withAuditIdentity(t *testing.T, identity string) {
+	t.Helper()
+	SetAuditIdentity(identity)
+	t.Cleanup(func() { SetAuditIdentity("") })
+}
+
+func TestCurrentAuditUser(t *testing.T) {
+	cases := []struct {
+		name   string
+		osUser string
+		err    error
+		want   string
+	}{
+		{"plain", "alice", nil, "alice"},
+		{"domain", `CORP\bob`, nil, "bob"},
+		{"lookup error", "", errors.New("no user"), "unknown"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			withOSUsername(t, tc.osUser, tc.err)
+			if got := CurrentAuditUser(); got != tc.want {
+				t.Fatalf("CurrentAuditUser() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLogActionBun_RecordsCurrentAuditUser(t *testing.T) {
+	withOSUsername(t, `CORP\carol`, nil)
+	WithTestStore(t, func(s *BunStore) {
+		if err := s.LogAction("TEST", "details"); err != nil {
+			t.Fatalf("LogAction: %v", err)
+		}
+		entries, err := s.GetAllAuditLogEntries()
+		if err != nil || len(entries) == 0 {
+			t.Fatalf("GetAllAuditLogEntries: %v (%d entries)", err, len(entries))
+		}
+		if entries[0].Username != "carol" {
+			t.Fatalf("expected username carol, got %q", entries[0].Username)
+		}
+	})
+}
+
+func TestCurrentAuditUser_IdentityOverride(t *testing.T) {
+	withOSUsername(t, "alice", nil)
+
+	withAuditIdentity(t, " ci-pipeline ")
+	if got := CurrentAuditUser(); got != "ci-pipeline" {
+		t.Fatalf("with identity: got %q, want ci-pipeline", got)
+	}
+
+	t.Setenv(AuditUserEnv, "release-bot")
+	if got := CurrentAuditUser(); got != "release-bot" {
+		t.Fatalf("env should win over configured identity: got %q", got)
+	}
+
+	t.Setenv(AuditUserEnv, "")
+	SetAuditIdentity("")
+	if got := CurrentAuditUser(); got != "alice" {
+		t.Fatalf("without override: got %q, want alice", got)
+	}
+}
+
+func TestLogActionBun_RecordsAuditIdentity(t *testing.T) {
+	withOSUsername(t, "alice", nil)
+	withAuditIdentity(t, "ci-pipeline")
+	WithTestStore(t, func(s *BunStore) {
+		if err := s.LogAction("TEST", "details"); err != nil {
+			t.Fatalf("LogAction: %v", err)
+		}
+		entries, err := s.GetAllAuditLogEntries()
+		if err != nil || len(entries) == 0 {
+			t.Fatalf("GetAllAuditLogEntries: %v (%d entries)", err, len(entries))
+		}
+		if entries[0].Username != "ci-pipeline" {
+			t.Fatalf("expected username ci-pipeline, got %q", entries[0].Username)
+		}
+	})
+}
@@ -4,6 +4,8 @@
 package db
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -108,3 +110,65 @@ func TestBackupImport_RoundTrip(t *testing.T) {
 		t.Fatalf("account_keys count mismatch after restore: want=%d got=%d", len(backup.AccountKeys), len(restored.AccountKeys))
 	}
 }
+
+func TestStreamBackup_TableOrderAndAbort(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		accID, err := s.AddAccount("deploy", "web-01", "", "")
+		if err != nil {
+			t.Fatalf("AddAccount: %v", err)
+		}
+		pk, err := AddPublicKeyAndGetModelBun(s.BunDB(), "ssh-ed25519", "AAAAstream", "k1", false, time.Time{})
+		if err != nil || pk == nil {
+			t.Fatalf("AddPublicKeyAndGetModelBun: %v", err)
+		}
+		if err := AssignKeyToAccountBun(s.BunDB(), pk.ID, accID); err != nil {
+			t.Fatalf("AssignKeyToAccountBun: %v", err)
+		}
+		for i := 0; i < 250; i++ {
+			if err := s.LogAction("TEST", fmt.Sprintf("entry %d", i)); err != nil {
+				t.Fatalf("LogAction: %v", err)
+			}
+		}
+
+		var order []string
+		counts := map[string]int{}
+		err = s.StreamBackup(nil, func(table string, row any) error {
+			if len(order) == 0 || order[len(order)-1] != table {
+				order = append(order, table)
+			}
+			counts[table]++
+			if ak, ok := row.(model.AccountKey); ok && (ak.KeyID != pk.ID || ak.AccountID != accID) {
+				t.Fatalf("unexpected account key row %+v", ak)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("StreamBackup: %v", err)
+		}
+		want := []string{model.BackupTableAccounts, model.BackupTablePublicKeys, model.BackupTableAccountKeys, model.BackupTableAuditLog}
+		if fmt.Sprint(order) != fmt.Sprint(want) {
+			t.Fatalf("expected tables streamed in order %v, got %v", want, order)
+		}
+		if counts[model.BackupTableAuditLog] < 250 || counts[model.BackupTableAccountKeys] != 1 {
+			t.Fatalf("unexpected row counts: %v", counts)
+		}
+
+		// An error from fn stops the stream and is returned.
+		stop := errors.New("stop")
+		seen := 0
+		err = s.StreamBackup([]string{model.BackupTableAuditLog}, func(table string, row any) error {
+			seen++
+			if seen == 10 {
+				return stop
+			}
+			return nil
+		})
+		if !errors.Is(err, stop) || seen != 10 {
+			t.Fatalf("expected stream to stop after 10 rows with %v, got %d rows and %v", stop, seen, err)
+		}
+
+		if err := s.StreamBackup([]string{"nope"}, func(string, any) error { return nil }); err == nil {
+			t.Fatalf("expected error for unknown table")
+		}
+	})
+}
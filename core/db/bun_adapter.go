@@ -8,33 +8,38 @@ import (
 	"database/sql"
 	"fmt"
 	"os"
-	"os/user"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/bobg/go-generics/v4/slices"
 	"github.com/toeirei/keymaster/core/db/tags"
 	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/core/sshkey"
 	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
 )
 
 // SystemKeyModel is a local mapping used by Bun for queries.
 type SystemKeyModel struct {
 	bun.BaseModel `bun:"table:system_keys"`
-	ID            int    `bun:"id,pk,autoincrement"`
-	Serial        int    `bun:"serial"`
-	PublicKey     string `bun:"public_key"`
-	PrivateKey    string `bun:"private_key"`
-	IsActive      bool   `bun:"is_active"`
+	ID            int          `bun:"id,pk,autoincrement"`
+	Serial        int          `bun:"serial"`
+	PublicKey     string       `bun:"public_key"`
+	PrivateKey    string       `bun:"private_key"`
+	IsActive      bool         `bun:"is_active"`
+	CreatedAt     sql.NullTime `bun:"created_at"`
 }
 
 // GetActiveSystemKeyBun returns the active system key using Bun for SQLite.
 // This is a small, focused adapter used incrementally by the sqlite store.
+// During a staged rotation several keys are active; the newest one is
+// returned.
 func GetActiveSystemKeyBun(bdb *bun.DB) (*model.SystemKey, error) {
 	ctx := context.Background()
 
 	var sk SystemKeyModel
-	err := bdb.NewSelect().Model(&sk).Where("is_active = ?", 1).Limit(1).Scan(ctx)
+	err := bdb.NewSelect().Model(&sk).Where("is_active = ?", 1).OrderExpr("serial DESC").Limit(1).Scan(ctx)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -79,6 +84,7 @@ func RotateSystemKeyBun(bdb *bun.DB, publicKey, privateKey string) (int, error)
 		PublicKey:  publicKey,
 		PrivateKey: privateKey,
 		IsActive:   true,
+		CreatedAt:  sql.NullTime{Time: time.Now().UTC(), Valid: true},
 	}).Exec(ctx)
 	if err != nil {
 		return 0, fmt.Errorf("failed to insert new system key: %w", err)
@@ -114,6 +120,32 @@ type AccountModel struct {
 	Serial        int            `bun:"serial"`
 	IsActive      bool           `bun:"is_active"`
 	IsDirty       bool           `bun:"is_dirty"`
+	DeployEnabled bool           `bun:"deploy_enabled"`
+	// DeployKnownHosts is true for accounts that also get a known_hosts file.
+	DeployKnownHosts bool         `bun:"deploy_known_hosts"`
+	DisableAt        sql.NullTime `bun:"disable_at"`
+	EnableAt         sql.NullTime `bun:"enable_at"`
+	// RemediationPolicy is NULL for accounts using the default policy.
+	RemediationPolicy sql.NullString `bun:"remediation_policy"`
+	ManageSystemKey   bool           `bun:"manage_system_key"`
+	// DeployMode is NULL for accounts using the default (replace) mode.
+	DeployMode sql.NullString `bun:"deploy_mode"`
+	// DeployedKeys holds newline-separated key identities.
+	DeployedKeys sql.NullString `bun:"deployed_keys"`
+	// Metadata holds a JSON object of free-form annotations.
+	Metadata sql.NullString `bun:"metadata"`
+	// OSFamily is NULL for accounts on POSIX hosts.
+	OSFamily sql.NullString `bun:"os_family"`
+	// Group is NULL for accounts in no group.
+	Group sql.NullString `bun:"account_group"`
+	// ExcludedGlobalKeys holds comma-separated global key IDs; NULL when
+	// none are excluded.
+	ExcludedGlobalKeys sql.NullString `bun:"excluded_global_keys"`
+	// AuthorizedPrincipals holds comma-separated principals; NULL when the
+	// account does not manage authorized_principals.
+	AuthorizedPrincipals sql.NullString `bun:"authorized_principals"`
+	// LastAuditAt is NULL unless the last audit found the host clean.
+	LastAuditAt sql.NullTime `bun:"last_audit_at"`
 
 	Links []LinkModel `bun:"rel:has-many,join:id=account_id"`
 }
@@ -127,6 +159,14 @@ type PublicKeyModel struct {
 	Comment       string       `bun:"comment"`
 	ExpiresAt     sql.NullTime `bun:"expires_at"`
 	IsGlobal      bool         `bun:"is_global"`
+	// Metadata holds a JSON object of free-form annotations.
+	Metadata sql.NullString `bun:"metadata"`
+	IsCA     bool           `bun:"is_ca"`
+	// Principals holds comma-separated CA principals; NULL is unrestricted.
+	Principals sql.NullString `bun:"principals"`
+	// AssignmentOptions is scanned from account_keys.options by queries
+	// reading the keys of one account; it is not a public_keys column.
+	AssignmentOptions sql.NullString `bun:"options,scanonly"`
 
 	Tags []TagModel `bun:"m2m:public_key_to_tags,join:PublicKey=Tag"`
 }
@@ -183,6 +223,18 @@ type KnownHostModel struct {
 	Key           string `bun:"key"`
 }
 
+// [OperationResultModel] maps operation_results.
+type OperationResultModel struct {
+	bun.BaseModel `bun:"table:operation_results"`
+	ID            int       `bun:"id,pk,autoincrement"`
+	RecordedAt    time.Time `bun:"recorded_at"`
+	AccountID     int       `bun:"account_id"`
+	Operation     string    `bun:"operation"`
+	Success       bool      `bun:"success"`
+	ErrorCategory string    `bun:"error_category"`
+	DurationMs    int64     `bun:"duration_ms"`
+}
+
 // [BootstrapSessionModel] maps bootstrap_sessions for export/import.
 type BootstrapSessionModel struct {
 	bun.BaseModel `bun:"table:bootstrap_sessions"`
@@ -206,6 +258,10 @@ func accountModelToModel(a AccountModel) model.Account {
 		Serial:   a.Serial,
 		IsActive: a.IsActive,
 		IsDirty:  a.IsDirty,
+
+		DeployEnabled:    a.DeployEnabled,
+		DeployKnownHosts: a.DeployKnownHosts,
+		ManageSystemKey:  a.ManageSystemKey,
 	}
 	if a.Label.Valid {
 		acc.Label = a.Label.String
@@ -213,9 +269,62 @@ func accountModelToModel(a AccountModel) model.Account {
 	if a.Tags.Valid {
 		acc.Tags = a.Tags.String
 	}
+	if a.DisableAt.Valid {
+		acc.DisableAt = a.DisableAt.Time
+	}
+	if a.EnableAt.Valid {
+		acc.EnableAt = a.EnableAt.Time
+	}
+	if a.RemediationPolicy.Valid {
+		acc.RemediationPolicy = a.RemediationPolicy.String
+	}
+	if a.DeployMode.Valid {
+		acc.DeployMode = a.DeployMode.String
+	}
+	if a.DeployedKeys.Valid && a.DeployedKeys.String != "" {
+		acc.DeployedKeys = strings.Split(a.DeployedKeys.String, "\n")
+	}
+	acc.Metadata = metadataFromColumn(a.Metadata, "account", a.ID)
+	if a.OSFamily.Valid {
+		acc.OSFamily = a.OSFamily.String
+	}
+	if a.Group.Valid {
+		acc.Group = a.Group.String
+	}
+	if a.LastAuditAt.Valid {
+		acc.LastAuditAt = a.LastAuditAt.Time
+	}
+	if a.ExcludedGlobalKeys.Valid {
+		acc.ExcludedGlobalKeys = keyIDsFromColumn(a.ExcludedGlobalKeys.String)
+	}
+	if a.AuthorizedPrincipals.Valid && a.AuthorizedPrincipals.String != "" {
+		acc.AuthorizedPrincipals = strings.Split(a.AuthorizedPrincipals.String, ",")
+	}
 	return acc
 }
 
+// keyIDsColumn stores key IDs as a comma-separated column value, NULL when
+// there are none.
+func keyIDsColumn(ids []int) sql.NullString {
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		parts = append(parts, strconv.Itoa(id))
+	}
+	return nullStringOf(strings.Join(parts, ","))
+}
+
+// keyIDsFromColumn parses a column written by keyIDsColumn, skipping
+// malformed entries.
+func keyIDsFromColumn(v string) []int {
+	var ids []int
+	for _, part := range strings.Split(v, ",") {
+		if id, err := strconv.Atoi(strings.TrimSpace(part)); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
 func bootstrapSessionModelToModel(bsm BootstrapSessionModel) model.BootstrapSession {
 	bs := model.BootstrapSession{
 		ID:            bsm.ID,
@@ -241,11 +350,45 @@ func publicKeyModelToModel(p PublicKeyModel) model.PublicKey {
 		pk.ExpiresAt = p.ExpiresAt.Time
 	}
 	pk.IsGlobal = p.IsGlobal
+	pk.Metadata = metadataFromColumn(p.Metadata, "public key", p.ID)
+	pk.IsCA = p.IsCA
+	if p.Principals.Valid && p.Principals.String != "" {
+		pk.Principals = strings.Split(p.Principals.String, ",")
+	}
+	pk.AssignmentOptions = p.AssignmentOptions.String
 	return pk
 }
 
+// metadataFromColumn decodes a metadata column. Undecodable values are
+// logged and treated as empty rather than failing the whole read.
+func metadataFromColumn(col sql.NullString, kind string, id int) map[string]string {
+	if !col.Valid {
+		return nil
+	}
+	m, err := model.UnmarshalMetadata(col.String)
+	if err != nil {
+		dbLogf("db: ignoring invalid metadata of %s %d: %v", kind, id, err)
+		return nil
+	}
+	return m
+}
+
+// metadataColumn encodes metadata for the metadata column; empty metadata
+// is stored as NULL.
+func metadataColumn(m map[string]string) (sql.NullString, error) {
+	s, err := model.MarshalMetadata(m)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return nullStringOf(s), nil
+}
+
 func systemKeyModelToModel(skm SystemKeyModel) model.SystemKey {
-	return model.SystemKey{ID: skm.ID, Serial: skm.Serial, PublicKey: skm.PublicKey, PrivateKey: skm.PrivateKey, IsActive: skm.IsActive}
+	sk := model.SystemKey{ID: skm.ID, Serial: skm.Serial, PublicKey: skm.PublicKey, PrivateKey: skm.PrivateKey, IsActive: skm.IsActive}
+	if skm.CreatedAt.Valid {
+		sk.CreatedAt = skm.CreatedAt.Time
+	}
+	return sk
 }
 
 func getMultipleAccountsBun(ctx context.Context, bdb *bun.DB, opts ...func(*bun.SelectQuery) *bun.SelectQuery) ([]model.Account, error) {
@@ -285,6 +428,23 @@ func GetAllAccountsBun(bdb *bun.DB) ([]model.Account, error) {
 	return out, nil
 }
 
+// GetAccountsBelowSerialBun returns the accounts whose last deployed serial
+// is lower than serial, including never-deployed accounts (serial 0),
+// ordered by serial.
+func GetAccountsBelowSerialBun(bdb *bun.DB, serial int) ([]model.Account, error) {
+	ctx := context.Background()
+	var am []AccountModel
+	err := bdb.NewSelect().Model(&am).Where("serial < ?", serial).OrderExpr("serial, label, hostname, username").Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]model.Account, 0, len(am))
+	for _, a := range am {
+		out = append(out, accountModelToModel(a))
+	}
+	return out, nil
+}
+
 // GetAllActiveAccountsBun returns all active accounts.
 func GetAllActiveAccountsBun(bdb *bun.DB) ([]model.Account, error) {
 	ctx := context.Background()
@@ -334,6 +494,13 @@ func DeleteAccountBun(bdb *bun.DB, id int) error {
 // automatically deployed everywhere. This function returns an error if attempting
 // to assign a global key.
 func AssignKeyToAccountBun(bdb *bun.DB, keyID, accountID int) error {
+	return AssignKeyToAccountWithOptionsBun(bdb, keyID, accountID, "")
+}
+
+// AssignKeyToAccountWithOptionsBun is AssignKeyToAccountBun with
+// authorized_keys options rendered for this assignment only. Empty options
+// are stored as NULL.
+func AssignKeyToAccountWithOptionsBun(bdb *bun.DB, keyID, accountID int, options string) error {
 	ctx := context.Background()
 
 	// Check if the key is global - global keys should not be in account_keys
@@ -342,14 +509,14 @@ func AssignKeyToAccountBun(bdb *bun.DB, keyID, accountID int) error {
 		return err
 	}
 	if pk == nil {
-		return fmt.Errorf("key with ID %d not found", keyID)
+		return fmt.Errorf("key with ID %d %w", keyID, ErrNotFound)
 	}
 	if pk.IsGlobal {
 		return fmt.Errorf("cannot assign global key '%s' to individual accounts (it's already deployed everywhere)", pk.Comment)
 	}
 
 	// Use raw insert since account_keys likely has no PK model in codebase.
-	if _, err := ExecRaw(ctx, bdb, "INSERT INTO account_keys(key_id, account_id) VALUES(?, ?)", keyID, accountID); err != nil {
+	if _, err := ExecRaw(ctx, bdb, "INSERT INTO account_keys(key_id, account_id, options) VALUES(?, ?, ?)", keyID, accountID, nullStringOf(options)); err != nil {
 		return MapDBError(err)
 	}
 	// Mark the affected account dirty so authorized_keys needs redeploy
@@ -379,6 +546,8 @@ func GetKeysForAccountBun(bdb *bun.DB, accountID int) ([]model.PublicKey, error)
 	// Use BUN's proper join syntax with unqualified column names in the join condition
 	err := bdb.NewSelect().
 		Model(&pks).
+		ColumnExpr("?TableAlias.*").
+		ColumnExpr("account_keys.options").
 		Join("INNER JOIN account_keys ON id = account_keys.key_id").
 		Where("account_keys.account_id = ?", accountID).
 		OrderExpr("comment").
@@ -466,19 +635,39 @@ func GetAllAuditLogEntriesBun(bdb *bun.DB) ([]model.AuditLogEntry, error) {
 	return out, nil
 }
 
+// GetAuditLogForAccountBun retrieves the audit log entries referencing the
+// given account, oldest first. Candidates are preselected with LIKE and then
+// filtered with model.AuditReferencesAccount so that account 1 does not match
+// entries of account 12.
+func GetAuditLogForAccountBun(bdb *bun.DB, accountID int) ([]model.AuditLogEntry, error) {
+	ctx := context.Background()
+	var am []AuditLogModel
+	err := bdb.NewSelect().Model(&am).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("details LIKE ?", fmt.Sprintf("%%account_id:%d%%", accountID)).
+				WhereOr("details LIKE ?", fmt.Sprintf("%%account_id: %d%%", accountID)).
+				WhereOr("details LIKE ?", fmt.Sprintf("%%account:%d%%", accountID)).
+				WhereOr("details LIKE ?", fmt.Sprintf(`%%"account_id":"%d"%%`, accountID))
+		}).
+		OrderExpr("timestamp ASC, id ASC").Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]model.AuditLogEntry, 0, len(am))
+	for _, a := range am {
+		if !model.AuditReferencesAccount(a.Details, accountID) {
+			continue
+		}
+		out = append(out, model.AuditLogEntry{ID: a.ID, Timestamp: a.Timestamp, Username: a.Username, Action: a.Action, Details: a.Details})
+	}
+	return out, nil
+}
+
 // LogActionBun inserts an audit log entry with the current OS user.
 func LogActionBun(bdb *bun.DB, action string, details string) error {
 	ctx := context.Background()
 	auditCtx := getAuditContext()
-	curUser, err := user.Current()
-	username := "unknown"
-	if err == nil {
-		if parts := strings.Split(curUser.Username, `\`); len(parts) > 1 {
-			username = parts[1]
-		} else {
-			username = curUser.Username
-		}
-	}
+	username := CurrentAuditUser()
 	hostname, err := os.Hostname()
 	if err != nil {
 		hostname = ""
@@ -502,94 +691,164 @@ func LogActionBun(bdb *bun.DB, action string, details string) error {
 
 // ExportDataForBackupBun exports all tables' data into a model.BackupData using a Bun transaction.
 func ExportDataForBackupBun(bdb *bun.DB) (*model.BackupData, error) {
-	ctx := context.Background()
-	var backup *model.BackupData
-	err := WithTx(ctx, bdb, func(ctx context.Context, tx bun.Tx) error {
-		backup = &model.BackupData{SchemaVersion: 1}
+	return ExportTablesForBackupBun(bdb, nil)
+}
 
-		// Accounts
-		var accounts []AccountModel
-		if err := tx.NewSelect().Model(&accounts).Scan(ctx); err != nil {
-			return err
-		}
-		for _, a := range accounts {
-			backup.Accounts = append(backup.Accounts, accountModelToModel(a))
+// ExportTablesForBackupBun exports only the given tables (see
+// model.BackupTables); the slices of other tables stay empty and the backup
+// records which tables it contains. An empty list exports every table.
+func ExportTablesForBackupBun(bdb *bun.DB, tables []string) (*model.BackupData, error) {
+	backup := &model.BackupData{SchemaVersion: model.BackupSchemaVersion, Tables: tables}
+	err := StreamBackupBun(bdb, tables, func(table string, row any) error {
+		switch r := row.(type) {
+		case model.Account:
+			backup.Accounts = append(backup.Accounts, r)
+		case model.PublicKey:
+			backup.PublicKeys = append(backup.PublicKeys, r)
+		case model.AccountKey:
+			backup.AccountKeys = append(backup.AccountKeys, r)
+		case model.SystemKey:
+			backup.SystemKeys = append(backup.SystemKeys, r)
+		case model.KnownHost:
+			backup.KnownHosts = append(backup.KnownHosts, r)
+		case model.AuditLogEntry:
+			backup.AuditLogEntries = append(backup.AuditLogEntries, r)
+		case model.BootstrapSession:
+			backup.BootstrapSessions = append(backup.BootstrapSessions, r)
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return backup, nil
+}
 
-		// Public keys
-		var pks []PublicKeyModel
-		if err := tx.NewSelect().Model(&pks).Scan(ctx); err != nil {
-			return err
-		}
-		for _, p := range pks {
-			backup.PublicKeys = append(backup.PublicKeys, publicKeyModelToModel(p))
+// StreamBackupBun passes every row of the given tables (all tables when
+// empty) to fn, one table after the other in model.BackupTables order. Rows
+// are read through a cursor inside one transaction, so memory use does not
+// grow with the table size. Rows are model values, e.g. model.Account.
+func StreamBackupBun(bdb *bun.DB, tables []string, fn func(table string, row any) error) error {
+	if err := model.ValidateBackupTables(tables); err != nil {
+		return err
+	}
+	include := (&model.BackupData{Tables: tables}).IncludesTable
+	ctx := context.Background()
+	return WithTx(ctx, bdb, func(ctx context.Context, tx bun.Tx) error {
+		if include(model.BackupTableAccounts) {
+			if err := streamModelRowsBun(ctx, bdb, tx.NewSelect().Model((*AccountModel)(nil)), model.BackupTableAccounts, func(a AccountModel) any {
+				return accountModelToModel(a)
+			}, fn); err != nil {
+				return err
+			}
 		}
-
-		// Account keys
-		type akRow struct{ KeyID, AccountID int }
-		var aks []akRow
-		if err := QueryRawInto(ctx, tx, &aks, "SELECT key_id, account_id FROM account_keys"); err != nil {
-			return err
+		if include(model.BackupTablePublicKeys) {
+			if err := streamModelRowsBun(ctx, bdb, tx.NewSelect().Model((*PublicKeyModel)(nil)), model.BackupTablePublicKeys, func(p PublicKeyModel) any {
+				return publicKeyModelToModel(p)
+			}, fn); err != nil {
+				return err
+			}
 		}
-		for _, r := range aks {
-			backup.AccountKeys = append(backup.AccountKeys, model.AccountKey{KeyID: r.KeyID, AccountID: r.AccountID})
+		if include(model.BackupTableAccountKeys) {
+			rows, err := tx.QueryContext(ctx, "SELECT key_id, account_id, options FROM account_keys")
+			if err != nil {
+				return err
+			}
+			type akRow struct {
+				KeyID, AccountID int
+				Options          sql.NullString
+			}
+			if err := streamRowsBun(ctx, bdb, rows, model.BackupTableAccountKeys, func(r akRow) any {
+				return model.AccountKey{KeyID: r.KeyID, AccountID: r.AccountID, Options: r.Options.String}
+			}, fn); err != nil {
+				return err
+			}
 		}
-
-		// System keys
-		var sks []SystemKeyModel
-		if err := tx.NewSelect().Model(&sks).Scan(ctx); err != nil {
-			return err
+		if include(model.BackupTableSystemKeys) {
+			if err := streamModelRowsBun(ctx, bdb, tx.NewSelect().Model((*SystemKeyModel)(nil)), model.BackupTableSystemKeys, func(s SystemKeyModel) any {
+				return systemKeyModelToModel(s)
+			}, fn); err != nil {
+				return err
+			}
 		}
-		for _, s := range sks {
-			backup.SystemKeys = append(backup.SystemKeys, systemKeyModelToModel(s))
+		if include(model.BackupTableKnownHosts) {
+			if err := streamModelRowsBun(ctx, bdb, tx.NewSelect().Model((*KnownHostModel)(nil)), model.BackupTableKnownHosts, func(k KnownHostModel) any {
+				return model.KnownHost{Hostname: k.Hostname, Key: k.Key}
+			}, fn); err != nil {
+				return err
+			}
 		}
-
-		// Known hosts
-		var khs []KnownHostModel
-		if err := tx.NewSelect().Model(&khs).Scan(ctx); err != nil {
-			return err
+		if include(model.BackupTableAuditLog) {
+			if err := streamModelRowsBun(ctx, bdb, tx.NewSelect().Model((*AuditLogModel)(nil)), model.BackupTableAuditLog, func(a AuditLogModel) any {
+				return model.AuditLogEntry{ID: a.ID, Timestamp: a.Timestamp, Username: a.Username, Action: a.Action, Details: a.Details}
+			}, fn); err != nil {
+				return err
+			}
 		}
-		for _, k := range khs {
-			backup.KnownHosts = append(backup.KnownHosts, model.KnownHost{Hostname: k.Hostname, Key: k.Key})
+		if include(model.BackupTableBootstrapSessions) {
+			if err := streamModelRowsBun(ctx, bdb, tx.NewSelect().Model((*BootstrapSessionModel)(nil)), model.BackupTableBootstrapSessions, func(b BootstrapSessionModel) any {
+				bs := model.BootstrapSession{ID: b.ID, Username: b.Username, Hostname: b.Hostname, TempPublicKey: b.TempPublicKey, CreatedAt: b.CreatedAt, ExpiresAt: b.ExpiresAt, Status: b.Status}
+				if b.Label.Valid {
+					bs.Label = b.Label.String
+				}
+				if b.Tags.Valid {
+					bs.Tags = b.Tags.String
+				}
+				return bs
+			}, fn); err != nil {
+				return err
+			}
 		}
+		return nil
+	})
+}
 
-		// Audit log
-		var als []AuditLogModel
-		if err := tx.NewSelect().Model(&als).Scan(ctx); err != nil {
-			return err
-		}
-		for _, a := range als {
-			backup.AuditLogEntries = append(backup.AuditLogEntries, model.AuditLogEntry{ID: a.ID, Timestamp: a.Timestamp, Username: a.Username, Action: a.Action, Details: a.Details})
-		}
+// streamModelRowsBun runs the select q through a cursor; see streamRowsBun.
+func streamModelRowsBun[M any](ctx context.Context, bdb *bun.DB, q *bun.SelectQuery, table string, convert func(M) any, fn func(table string, row any) error) error {
+	rows, err := q.Rows(ctx)
+	if err != nil {
+		return err
+	}
+	return streamRowsBun(ctx, bdb, rows, table, convert, fn)
+}
 
-		// Bootstrap sessions
-		var bss []BootstrapSessionModel
-		if err := tx.NewSelect().Model(&bss).Scan(ctx); err != nil {
+// streamRowsBun scans rows one at a time into M and passes the converted
+// value to fn. rows is closed before returning.
+func streamRowsBun[M any](ctx context.Context, bdb *bun.DB, rows *sql.Rows, table string, convert func(M) any, fn func(table string, row any) error) error {
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var m M
+		if err := bdb.ScanRow(ctx, rows, &m); err != nil {
 			return err
 		}
-		for _, b := range bss {
-			bs := model.BootstrapSession{ID: b.ID, Username: b.Username, Hostname: b.Hostname, TempPublicKey: b.TempPublicKey, CreatedAt: b.CreatedAt, ExpiresAt: b.ExpiresAt, Status: b.Status}
-			if b.Label.Valid {
-				bs.Label = b.Label.String
-			}
-			if b.Tags.Valid {
-				bs.Tags = b.Tags.String
-			}
-			backup.BootstrapSessions = append(backup.BootstrapSessions, bs)
+		if err := fn(table, convert(m)); err != nil {
+			return err
 		}
-
-		return nil
-	})
-	return backup, err
+	}
+	return rows.Err()
 }
 
 // ImportDataFromBackupBun performs a full wipe-and-replace using a Bun transaction.
 func ImportDataFromBackupBun(bdb *bun.DB, backup *model.BackupData) error {
 	ctx := context.Background()
 	return WithTx(ctx, bdb, func(ctx context.Context, tx bun.Tx) error {
-		// Wipe tables
+		// account_keys cascades from accounts and public_keys, so wiping
+		// either would also drop assignments a selective backup does not
+		// carry. Remember them and put back those that still apply.
+		var keptAssignments []model.AccountKey
+		if !backup.IncludesTable(model.BackupTableAccountKeys) && (backup.IncludesTable(model.BackupTableAccounts) || backup.IncludesTable(model.BackupTablePublicKeys)) {
+			var err error
+			if keptAssignments, err = accountKeysBun(ctx, tx); err != nil {
+				return err
+			}
+		}
+
+		// Wipe tables. A selective backup only replaces the tables it contains.
 		tables := []string{"account_keys", "bootstrap_sessions", "audit_log", "known_hosts", "system_keys", "public_keys", "accounts"}
 		for _, t := range tables {
+			if !backup.IncludesTable(t) {
+				continue
+			}
 			if _, err := ExecRaw(ctx, tx, fmt.Sprintf("DELETE FROM %s", t)); err != nil {
 				return err
 			}
@@ -597,25 +856,33 @@ func ImportDataFromBackupBun(bdb *bun.DB, backup *model.BackupData) error {
 
 		// Insert accounts
 		for _, acc := range backup.Accounts {
-			if _, err := ExecRaw(ctx, tx, "INSERT INTO accounts (id, username, hostname, label, tags, serial, is_active, is_dirty) VALUES (?, ?, ?, ?, ?, ?, ?, ?)", acc.ID, acc.Username, acc.Hostname, acc.Label, acc.Tags, acc.Serial, acc.IsActive, acc.IsDirty); err != nil {
+			meta, err := metadataColumn(acc.Metadata)
+			if err != nil {
+				return err
+			}
+			if _, err := ExecRaw(ctx, tx, "INSERT INTO accounts (id, username, hostname, label, tags, serial, is_active, is_dirty, remediation_policy, manage_system_key, deploy_mode, deployed_keys, metadata, os_family, deploy_enabled, account_group, deploy_known_hosts, excluded_global_keys, authorized_principals, disable_at, enable_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", acc.ID, acc.Username, acc.Hostname, acc.Label, acc.Tags, acc.Serial, acc.IsActive, acc.IsDirty, nullStringOf(acc.RemediationPolicy), backup.ManagesSystemKey(acc), nullStringOf(acc.DeployMode), nullStringOf(strings.Join(acc.DeployedKeys, "\n")), meta, nullStringOf(acc.OSFamily), backup.DeploysEnabled(acc), nullStringOf(acc.Group), acc.DeployKnownHosts, keyIDsColumn(acc.ExcludedGlobalKeys), nullStringOf(strings.Join(acc.AuthorizedPrincipals, ",")), nullTimeOf(acc.DisableAt), nullTimeOf(acc.EnableAt)); err != nil {
 				return MapDBError(err)
 			}
 		}
 		// Public keys
 		for _, pk := range backup.PublicKeys {
-			if _, err := ExecRaw(ctx, tx, "INSERT INTO public_keys (id, algorithm, key_data, comment, is_global) VALUES (?, ?, ?, ?, ?)", pk.ID, pk.Algorithm, pk.KeyData, pk.Comment, pk.IsGlobal); err != nil {
+			meta, err := metadataColumn(pk.Metadata)
+			if err != nil {
+				return err
+			}
+			if _, err := ExecRaw(ctx, tx, "INSERT INTO public_keys (id, algorithm, key_data, comment, is_global, metadata, is_ca, principals) VALUES (?, ?, ?, ?, ?, ?, ?, ?)", pk.ID, pk.Algorithm, pk.KeyData, pk.Comment, pk.IsGlobal, meta, pk.IsCA, nullStringOf(strings.Join(pk.Principals, ","))); err != nil {
 				return MapDBError(err)
 			}
 		}
 		// AccountKeys
 		for _, ak := range backup.AccountKeys {
-			if _, err := ExecRaw(ctx, tx, "INSERT INTO account_keys (key_id, account_id) VALUES (?, ?)", ak.KeyID, ak.AccountID); err != nil {
+			if _, err := ExecRaw(ctx, tx, "INSERT INTO account_keys (key_id, account_id, options) VALUES (?, ?, ?)", ak.KeyID, ak.AccountID, nullStringOf(ak.Options)); err != nil {
 				return MapDBError(err)
 			}
 		}
 		// SystemKeys
 		for _, sk := range backup.SystemKeys {
-			if _, err := ExecRaw(ctx, tx, "INSERT INTO system_keys (id, serial, public_key, private_key, is_active) VALUES (?, ?, ?, ?, ?)", sk.ID, sk.Serial, sk.PublicKey, sk.PrivateKey, sk.IsActive); err != nil {
+			if _, err := ExecRaw(ctx, tx, "INSERT INTO system_keys (id, serial, public_key, private_key, is_active, created_at) VALUES (?, ?, ?, ?, ?, ?)", sk.ID, sk.Serial, sk.PublicKey, sk.PrivateKey, sk.IsActive, nullTimeOf(sk.CreatedAt)); err != nil {
 				return MapDBError(err)
 			}
 		}
@@ -649,31 +916,155 @@ func ImportDataFromBackupBun(bdb *bun.DB, backup *model.BackupData) error {
 				return MapDBError(err)
 			}
 		}
+		if err := restoreAccountKeysBun(ctx, tx, keptAssignments); err != nil {
+			return err
+		}
+		// Rows were inserted with explicit ids; Postgres identity sequences do
+		// not advance on their own, so the next insert would collide.
+		if tx.Dialect().Name() == dialect.PG {
+			if err := resetIdentitySequencesBun(ctx, tx); err != nil {
+				return err
+			}
+		}
 		return nil
 	})
 }
 
-// IntegrateDataFromBackupBun performs a non-destructive restore using INSERT OR IGNORE semantics.
-func IntegrateDataFromBackupBun(bdb *bun.DB, backup *model.BackupData) error {
+// accountKeysBun returns every row of account_keys.
+func accountKeysBun(ctx context.Context, tx bun.Tx) ([]model.AccountKey, error) {
+	var rows []struct {
+		KeyID     int            `bun:"key_id"`
+		AccountID int            `bun:"account_id"`
+		Options   sql.NullString `bun:"options"`
+	}
+	if err := QueryRawInto(ctx, tx, &rows, "SELECT key_id, account_id, options FROM account_keys"); err != nil {
+		return nil, err
+	}
+	aks := make([]model.AccountKey, 0, len(rows))
+	for _, r := range rows {
+		aks = append(aks, model.AccountKey{KeyID: r.KeyID, AccountID: r.AccountID, Options: r.Options.String})
+	}
+	return aks, nil
+}
+
+// restoreAccountKeysBun re-inserts the assignments in aks whose account and
+// key both exist. Assignments already present are left alone.
+func restoreAccountKeysBun(ctx context.Context, tx bun.Tx, aks []model.AccountKey) error {
+	if len(aks) == 0 {
+		return nil
+	}
+	var accountIDs, keyIDs []int
+	if err := QueryRawInto(ctx, tx, &accountIDs, "SELECT id FROM accounts"); err != nil {
+		return err
+	}
+	if err := QueryRawInto(ctx, tx, &keyIDs, "SELECT id FROM public_keys"); err != nil {
+		return err
+	}
+	accounts := make(map[int]bool, len(accountIDs))
+	for _, id := range accountIDs {
+		accounts[id] = true
+	}
+	keys := make(map[int]bool, len(keyIDs))
+	for _, id := range keyIDs {
+		keys[id] = true
+	}
+	for _, ak := range aks {
+		if !accounts[ak.AccountID] || !keys[ak.KeyID] {
+			continue
+		}
+		if _, err := ExecRaw(ctx, tx, insertIgnoreSQL(tx.Dialect().Name(), "account_keys", "key_id, account_id, options", 3), ak.KeyID, ak.AccountID, nullStringOf(ak.Options)); err != nil {
+			return MapDBError(err)
+		}
+	}
+	return nil
+}
+
+// identityTables lists the tables whose integer id is generated by a
+// Postgres identity sequence.
+var identityTables = []string{"accounts", "public_keys", "system_keys", "audit_log"}
+
+// identitySequenceResetSQL returns the statement that moves table's id
+// sequence past the highest existing id. An empty table restarts at 1.
+func identitySequenceResetSQL(table string) string {
+	return fmt.Sprintf("SELECT setval(pg_get_serial_sequence('%[1]s', 'id'), COALESCE((SELECT MAX(id) FROM %[1]s), 0) + 1, false)", table)
+}
+
+// resetIdentitySequencesBun realigns Postgres identity sequences with the
+// ids present after rows were inserted with explicit ids.
+func resetIdentitySequencesBun(ctx context.Context, exec execRawProvider) error {
+	for _, table := range identityTables {
+		if _, err := ExecRaw(ctx, exec, identitySequenceResetSQL(table)); err != nil {
+			return fmt.Errorf("reset id sequence for %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// IntegrateDataFromBackupBun performs a non-destructive restore inside a
+// transaction. Rows conflicting with existing data are skipped rather than
+// overwritten; the returned summary counts imported and skipped rows per table.
+func IntegrateDataFromBackupBun(bdb *bun.DB, backup *model.BackupData) (model.RestoreSummary, error) {
 	ctx := context.Background()
-	return WithTx(ctx, bdb, func(ctx context.Context, tx bun.Tx) error {
+	var summary model.RestoreSummary
+	err := WithTx(ctx, bdb, func(ctx context.Context, tx bun.Tx) error {
+		insert := func(counts *model.RestoreTableCounts, table, columns string, args ...interface{}) error {
+			res, err := ExecRaw(ctx, tx, insertIgnoreSQL(tx.Dialect().Name(), table, columns, len(args)), args...)
+			if err != nil {
+				return MapDBError(err)
+			}
+			if n, _ := res.RowsAffected(); n > 0 {
+				counts.Imported++
+			} else {
+				counts.Skipped++
+			}
+			return nil
+		}
 		for _, acc := range backup.Accounts {
-			if _, err := ExecRaw(ctx, tx, "INSERT OR IGNORE INTO accounts (id, username, hostname, label, tags, serial, is_active, is_dirty) VALUES (?, ?, ?, ?, ?, ?, ?, ?)", acc.ID, acc.Username, acc.Hostname, acc.Label, acc.Tags, acc.Serial, acc.IsActive, acc.IsDirty); err != nil {
+			meta, err := metadataColumn(acc.Metadata)
+			if err != nil {
+				return err
+			}
+			if err := insert(&summary.Accounts, "accounts", "id, username, hostname, label, tags, serial, is_active, is_dirty, remediation_policy, manage_system_key, deploy_mode, deployed_keys, metadata, os_family, deploy_enabled, account_group, deploy_known_hosts, excluded_global_keys, authorized_principals, disable_at, enable_at", acc.ID, acc.Username, acc.Hostname, acc.Label, acc.Tags, acc.Serial, acc.IsActive, acc.IsDirty, nullStringOf(acc.RemediationPolicy), backup.ManagesSystemKey(acc), nullStringOf(acc.DeployMode), nullStringOf(strings.Join(acc.DeployedKeys, "\n")), meta, nullStringOf(acc.OSFamily), backup.DeploysEnabled(acc), nullStringOf(acc.Group), acc.DeployKnownHosts, keyIDsColumn(acc.ExcludedGlobalKeys), nullStringOf(strings.Join(acc.AuthorizedPrincipals, ",")), nullTimeOf(acc.DisableAt), nullTimeOf(acc.EnableAt)); err != nil {
 				return err
 			}
 		}
 		for _, pk := range backup.PublicKeys {
-			if _, err := ExecRaw(ctx, tx, "INSERT OR IGNORE INTO public_keys (id, algorithm, key_data, comment, is_global) VALUES (?, ?, ?, ?, ?)", pk.ID, pk.Algorithm, pk.KeyData, pk.Comment, pk.IsGlobal); err != nil {
+			meta, err := metadataColumn(pk.Metadata)
+			if err != nil {
+				return err
+			}
+			if err := insert(&summary.PublicKeys, "public_keys", "id, algorithm, key_data, comment, is_global, metadata, is_ca, principals", pk.ID, pk.Algorithm, pk.KeyData, pk.Comment, pk.IsGlobal, meta, pk.IsCA, nullStringOf(strings.Join(pk.Principals, ","))); err != nil {
 				return err
 			}
 		}
 		for _, ak := range backup.AccountKeys {
-			if _, err := ExecRaw(ctx, tx, "INSERT OR IGNORE INTO account_keys (key_id, account_id) VALUES (?, ?)", ak.KeyID, ak.AccountID); err != nil {
+			if err := insert(&summary.AccountKeys, "account_keys", "key_id, account_id, options", ak.KeyID, ak.AccountID, nullStringOf(ak.Options)); err != nil {
 				return err
 			}
 		}
+		if tx.Dialect().Name() == dialect.PG {
+			return resetIdentitySequencesBun(ctx, tx)
+		}
 		return nil
 	})
+	if err != nil {
+		return model.RestoreSummary{}, err
+	}
+	return summary, nil
+}
+
+// insertIgnoreSQL builds an INSERT that silently skips rows violating a
+// unique constraint, using the syntax of the given dialect.
+func insertIgnoreSQL(name dialect.Name, table, columns string, n int) string {
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+	switch name {
+	case dialect.PG:
+		return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT DO NOTHING", table, columns, placeholders)
+	case dialect.MySQL:
+		return fmt.Sprintf("INSERT IGNORE INTO %s (%s) VALUES (%s)", table, columns, placeholders)
+	default:
+		return fmt.Sprintf("INSERT OR IGNORE INTO %s (%s) VALUES (%s)", table, columns, placeholders)
+	}
 }
 
 // --- Public key helpers ---
@@ -707,9 +1098,28 @@ func GetPublicKeyByCommentBun(bdb *bun.DB, comment string) (*model.PublicKey, er
 	return &m, nil
 }
 
-// AddPublicKeyBun inserts a public key.
+// ensureKeyDataUniqueBun returns an error wrapping ErrDuplicate when the key
+// material is already stored, whatever comment it was stored under.
+func ensureKeyDataUniqueBun(ctx context.Context, q execRawProvider, keyData string) error {
+	var comments []string
+	if err := QueryRawInto(ctx, q, &comments, "SELECT comment FROM public_keys WHERE key_data = ? LIMIT 1", keyData); err != nil {
+		return err
+	}
+	if len(comments) > 0 {
+		return fmt.Errorf("%w: key material already stored as %q", ErrDuplicate, comments[0])
+	}
+	return nil
+}
+
+// AddPublicKeyBun inserts a public key. It returns ErrDuplicate when the
+// comment or the (normalized) key material already exists.
 func AddPublicKeyBun(bdb *bun.DB, algorithm, keyData, comment string, isGlobal bool, expiresAt time.Time) error {
 	ctx := context.Background()
+	algorithm, keyData = sshkey.NormalizeKey(algorithm, keyData)
+	comment = sshkey.NormalizeComment(comment)
+	if err := ensureKeyDataUniqueBun(ctx, bdb, keyData); err != nil {
+		return err
+	}
 	var exp interface{}
 	if !expiresAt.IsZero() {
 		exp = expiresAt
@@ -731,6 +1141,7 @@ func AddPublicKeyBun(bdb *bun.DB, algorithm, keyData, comment string, isGlobal b
 // AddPublicKeyAndGetModelBun inserts a public key if not exists and returns the model.
 // Returns (nil, nil) when duplicate.
 func AddPublicKeyAndGetModelBun(bdb *bun.DB, algorithm, keyData, comment string, isGlobal bool, expiresAt time.Time) (*model.PublicKey, error) {
+	comment = sshkey.NormalizeComment(comment)
 	// Check for existing
 	existing, err := GetPublicKeyByCommentBun(bdb, comment)
 	if err != nil {
@@ -740,6 +1151,10 @@ func AddPublicKeyAndGetModelBun(bdb *bun.DB, algorithm, keyData, comment string,
 		return nil, nil
 	}
 	ctx := context.Background()
+	algorithm, keyData = sshkey.NormalizeKey(algorithm, keyData)
+	if err := ensureKeyDataUniqueBun(ctx, bdb, keyData); err != nil {
+		return nil, err
+	}
 	var exp interface{}
 	if !expiresAt.IsZero() {
 		exp = expiresAt
@@ -822,6 +1237,25 @@ func GetGlobalPublicKeysBun(bdb *bun.DB) ([]model.PublicKey, error) {
 	return out, nil
 }
 
+// GetUnassignedPublicKeysBun returns non-global public keys that are not
+// assigned to any account.
+func GetUnassignedPublicKeysBun(bdb *bun.DB) ([]model.PublicKey, error) {
+	ctx := context.Background()
+	var pks []PublicKeyModel
+	if err := bdb.NewSelect().Model(&pks).
+		Where("is_global = ?", false).
+		Where("NOT EXISTS (SELECT 1 FROM account_keys ak WHERE ak.key_id = ?TableAlias.id)").
+		OrderExpr("comment").
+		Scan(ctx); err != nil {
+		return nil, err
+	}
+	out := make([]model.PublicKey, 0, len(pks))
+	for _, p := range pks {
+		out = append(out, publicKeyModelToModel(p))
+	}
+	return out, nil
+}
+
 // DeletePublicKeyBun deletes a public key by id.
 func DeletePublicKeyBun(bdb *bun.DB, id int) error {
 	ctx := context.Background()
@@ -903,6 +1337,20 @@ func GetKnownHostKeyBun(bdb *bun.DB, hostname string) (string, error) {
 	return kh.Key, nil
 }
 
+// GetAllKnownHostsBun returns every trusted host key, ordered by hostname.
+func GetAllKnownHostsBun(bdb *bun.DB) ([]model.KnownHost, error) {
+	ctx := context.Background()
+	var khs []KnownHostModel
+	if err := bdb.NewSelect().Model(&khs).OrderExpr("hostname ASC").Scan(ctx); err != nil {
+		return nil, MapDBError(err)
+	}
+	out := make([]model.KnownHost, 0, len(khs))
+	for _, kh := range khs {
+		out = append(out, model.KnownHost{Hostname: kh.Hostname, Key: kh.Key})
+	}
+	return out, nil
+}
+
 func AddKnownHostKeyBun(bdb *bun.DB, hostname, key string) error {
 	ctx := context.Background()
 	_, err := ExecRaw(ctx, bdb, "INSERT OR REPLACE INTO known_hosts (hostname, key) VALUES (?, ?)", hostname, key)
@@ -1000,6 +1448,248 @@ func ToggleAccountStatusBun(bdb *bun.DB, id int, enabled bool) error {
 	return err
 }
 
+// SetAccountScheduleBun sets the scheduled disable/enable times of an
+// account. A zero time clears the corresponding column.
+func SetAccountScheduleBun(bdb *bun.DB, id int, disableAt, enableAt time.Time) error {
+	ctx := context.Background()
+	nullable := func(t time.Time) interface{} {
+		if t.IsZero() {
+			return nil
+		}
+		return t.UTC()
+	}
+	if _, err := ExecRaw(ctx, bdb, "UPDATE accounts SET disable_at = ?, enable_at = ? WHERE id = ?", nullable(disableAt), nullable(enableAt), id); err != nil {
+		return MapDBError(err)
+	}
+	return nil
+}
+
+// SetAccountRemediationPolicyBun sets the drift remediation policy of an
+// account. An empty policy resets it to the default.
+func SetAccountRemediationPolicyBun(bdb *bun.DB, id int, policy string) error {
+	ctx := context.Background()
+	if _, err := ExecRaw(ctx, bdb, "UPDATE accounts SET remediation_policy = ? WHERE id = ?", nullStringOf(policy), id); err != nil {
+		return MapDBError(err)
+	}
+	return nil
+}
+
+// SetAccountDeployEnabledBun sets whether deploys may write to the account.
+func SetAccountDeployEnabledBun(bdb *bun.DB, id int, enabled bool) error {
+	ctx := context.Background()
+	if _, err := ExecRaw(ctx, bdb, "UPDATE accounts SET deploy_enabled = ? WHERE id = ?", enabled, id); err != nil {
+		return MapDBError(err)
+	}
+	return nil
+}
+
+// SetAccountManageSystemKeyBun sets whether the Keymaster system key is
+// rendered into the account's authorized_keys.
+func SetAccountManageSystemKeyBun(bdb *bun.DB, id int, manage bool) error {
+	ctx := context.Background()
+	if _, err := ExecRaw(ctx, bdb, "UPDATE accounts SET manage_system_key = ? WHERE id = ?", manage, id); err != nil {
+		return MapDBError(err)
+	}
+	return nil
+}
+
+// SetAccountDeployModeBun sets the deploy mode of an account. An empty mode
+// resets it to the default.
+func SetAccountDeployModeBun(bdb *bun.DB, id int, mode string) error {
+	ctx := context.Background()
+	if _, err := ExecRaw(ctx, bdb, "UPDATE accounts SET deploy_mode = ? WHERE id = ?", nullStringOf(mode), id); err != nil {
+		return MapDBError(err)
+	}
+	return nil
+}
+
+// SetAccountDeployedKeysBun records the key identities written by the last
+// deploy to an account.
+func SetAccountDeployedKeysBun(bdb *bun.DB, id int, keys []string) error {
+	ctx := context.Background()
+	if _, err := ExecRaw(ctx, bdb, "UPDATE accounts SET deployed_keys = ? WHERE id = ?", nullStringOf(strings.Join(keys, "\n")), id); err != nil {
+		return MapDBError(err)
+	}
+	return nil
+}
+
+// SetAccountLastAuditBun records the time of the last clean audit of an
+// account. A zero time clears it.
+func SetAccountLastAuditBun(bdb *bun.DB, id int, at time.Time) error {
+	ctx := context.Background()
+	var v interface{}
+	if !at.IsZero() {
+		v = at.UTC()
+	}
+	if _, err := ExecRaw(ctx, bdb, "UPDATE accounts SET last_audit_at = ? WHERE id = ?", v, id); err != nil {
+		return MapDBError(err)
+	}
+	return nil
+}
+
+// SetAccountDeployKnownHostsBun sets whether deploys also write known_hosts
+// to the account.
+func SetAccountDeployKnownHostsBun(bdb *bun.DB, id int, enabled bool) error {
+	ctx := context.Background()
+	if _, err := ExecRaw(ctx, bdb, "UPDATE accounts SET deploy_known_hosts = ? WHERE id = ?", enabled, id); err != nil {
+		return MapDBError(err)
+	}
+	return nil
+}
+
+// RecordOperationResultBun stores the outcome of one deploy or audit. A zero
+// RecordedAt is stamped with the current time.
+func RecordOperationResultBun(bdb *bun.DB, r model.OperationResult) error {
+	ctx := context.Background()
+	at := r.RecordedAt
+	if at.IsZero() {
+		at = time.Now()
+	}
+	m := OperationResultModel{
+		RecordedAt:    at.UTC(),
+		AccountID:     r.AccountID,
+		Operation:     r.Operation,
+		Success:       r.Success,
+		ErrorCategory: r.ErrorCategory,
+		DurationMs:    r.Duration.Milliseconds(),
+	}
+	if _, err := bdb.NewInsert().Model(&m).ExcludeColumn("id").Exec(ctx); err != nil {
+		return MapDBError(err)
+	}
+	return nil
+}
+
+// GetOperationResultsBun returns the operation results of an account recorded
+// at or after since, oldest first.
+func GetOperationResultsBun(bdb *bun.DB, accountID int, since time.Time) ([]model.OperationResult, error) {
+	ctx := context.Background()
+	var rs []OperationResultModel
+	err := bdb.NewSelect().Model(&rs).
+		Where("account_id = ?", accountID).
+		Where("recorded_at >= ?", since.UTC()).
+		OrderExpr("recorded_at ASC, id ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, MapDBError(err)
+	}
+	out := make([]model.OperationResult, 0, len(rs))
+	for _, r := range rs {
+		out = append(out, model.OperationResult{
+			ID:            r.ID,
+			RecordedAt:    r.RecordedAt,
+			AccountID:     r.AccountID,
+			Operation:     r.Operation,
+			Success:       r.Success,
+			ErrorCategory: r.ErrorCategory,
+			Duration:      time.Duration(r.DurationMs) * time.Millisecond,
+		})
+	}
+	return out, nil
+}
+
+// SetAccountAuthorizedPrincipalsBun replaces the principals deployed to an
+// account's authorized_principals.
+func SetAccountAuthorizedPrincipalsBun(bdb *bun.DB, id int, principals []string) error {
+	ctx := context.Background()
+	if _, err := ExecRaw(ctx, bdb, "UPDATE accounts SET authorized_principals = ? WHERE id = ?", nullStringOf(strings.Join(principals, ",")), id); err != nil {
+		return MapDBError(err)
+	}
+	return nil
+}
+
+// SetAccountExcludedGlobalKeysBun replaces the global keys excluded from an
+// account.
+func SetAccountExcludedGlobalKeysBun(bdb *bun.DB, id int, keyIDs []int) error {
+	ctx := context.Background()
+	if _, err := ExecRaw(ctx, bdb, "UPDATE accounts SET excluded_global_keys = ? WHERE id = ?", keyIDsColumn(keyIDs), id); err != nil {
+		return MapDBError(err)
+	}
+	return nil
+}
+
+// SetAccountGroupBun moves an account into group. An empty group removes it
+// from its group.
+func SetAccountGroupBun(bdb *bun.DB, id int, group string) error {
+	ctx := context.Background()
+	if _, err := ExecRaw(ctx, bdb, "UPDATE accounts SET account_group = ? WHERE id = ?", nullStringOf(group), id); err != nil {
+		return MapDBError(err)
+	}
+	return nil
+}
+
+// SetAccountOSFamilyBun sets the OS family of an account. An empty family
+// resets it to the default.
+func SetAccountOSFamilyBun(bdb *bun.DB, id int, family string) error {
+	ctx := context.Background()
+	if _, err := ExecRaw(ctx, bdb, "UPDATE accounts SET os_family = ? WHERE id = ?", nullStringOf(family), id); err != nil {
+		return MapDBError(err)
+	}
+	return nil
+}
+
+// SetAccountMetadataBun replaces the metadata of an account. Empty metadata
+// clears it.
+func SetAccountMetadataBun(bdb *bun.DB, id int, metadata map[string]string) error {
+	meta, err := metadataColumn(metadata)
+	if err != nil {
+		return err
+	}
+	if _, err := ExecRaw(context.Background(), bdb, "UPDATE accounts SET metadata = ? WHERE id = ?", meta, id); err != nil {
+		return MapDBError(err)
+	}
+	return nil
+}
+
+// SetPublicKeyMetadataBun replaces the metadata of a public key. Empty
+// metadata clears it.
+func SetPublicKeyMetadataBun(bdb *bun.DB, id int, metadata map[string]string) error {
+	meta, err := metadataColumn(metadata)
+	if err != nil {
+		return err
+	}
+	if _, err := ExecRaw(context.Background(), bdb, "UPDATE public_keys SET metadata = ? WHERE id = ?", meta, id); err != nil {
+		return MapDBError(err)
+	}
+	return nil
+}
+
+// SetPublicKeyCertAuthorityBun sets whether a public key is rendered as a
+// certificate authority and the principals it is restricted to, and marks
+// the affected accounts dirty.
+func SetPublicKeyCertAuthorityBun(bdb *bun.DB, id int, isCA bool, principals []string) error {
+	ctx := context.Background()
+	if !isCA {
+		principals = nil
+	}
+	if _, err := ExecRaw(ctx, bdb, "UPDATE public_keys SET is_ca = ?, principals = ? WHERE id = ?", isCA, nullStringOf(strings.Join(principals, ",")), id); err != nil {
+		return MapDBError(err)
+	}
+	pk, err := GetPublicKeyByIDBun(bdb, id)
+	if err != nil || pk == nil {
+		return err
+	}
+	return markAccountsDirtyForKey(ctx, bdb, id, pk.IsGlobal)
+}
+
+// UpdatePublicKeyBun replaces the algorithm and key data of a public key,
+// keeping its comment, assignments and settings, and marks the accounts
+// rendering it dirty.
+func UpdatePublicKeyBun(bdb *bun.DB, id int, algorithm, keyData string) error {
+	ctx := context.Background()
+	res, err := ExecRaw(ctx, bdb, "UPDATE public_keys SET algorithm = ?, key_data = ? WHERE id = ?", algorithm, keyData, id)
+	if err != nil {
+		return MapDBError(err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("public key %w: %d", ErrNotFound, id)
+	}
+	pk, err := GetPublicKeyByIDBun(bdb, id)
+	if err != nil || pk == nil {
+		return err
+	}
+	return markAccountsDirtyForKey(ctx, bdb, id, pk.IsGlobal)
+}
+
 func UpdateAccountLabelBun(bdb *bun.DB, id int, label string) error {
 	ctx := context.Background()
 	_, err := ExecRaw(ctx, bdb, "UPDATE accounts SET label = ? WHERE id = ?", label, id)
@@ -1085,6 +1775,55 @@ func GetSystemKeyBySerialBun(bdb *bun.DB, serial int) (*model.SystemKey, error)
 	return &m, nil
 }
 
+// GetAllSystemKeysBun returns every system key, active or retained, ordered
+// by serial.
+func GetAllSystemKeysBun(bdb *bun.DB) ([]model.SystemKey, error) {
+	ctx := context.Background()
+	var sks []SystemKeyModel
+	if err := bdb.NewSelect().Model(&sks).Order("serial ASC").Scan(ctx); err != nil {
+		return nil, err
+	}
+	out := make([]model.SystemKey, 0, len(sks))
+	for _, sk := range sks {
+		out = append(out, systemKeyModelToModel(sk))
+	}
+	return out, nil
+}
+
+// DeleteSystemKeyBun deletes the inactive system key with the given serial.
+// The active key is never deleted; ErrActiveSystemKey is returned instead.
+func DeleteSystemKeyBun(bdb *bun.DB, serial int) error {
+	ctx := context.Background()
+	sk, err := GetSystemKeyBySerialBun(bdb, serial)
+	if err != nil {
+		return err
+	}
+	if sk == nil {
+		return fmt.Errorf("system key with serial %d %w", serial, ErrNotFound)
+	}
+	if sk.IsActive {
+		return ErrActiveSystemKey
+	}
+	// Guard on is_active again in case a rotation happened in between.
+	res, err := ExecRaw(ctx, bdb, "DELETE FROM system_keys WHERE serial = ? AND is_active = ?", serial, false)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrActiveSystemKey
+	}
+	return nil
+}
+
+// nullTimeOf maps a zero time to SQL NULL.
+func nullTimeOf(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+func nullStringOf(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
 func HasSystemKeysBun(bdb *bun.DB) (bool, error) {
 	ctx := context.Background()
 	var count int
@@ -1106,8 +1845,20 @@ func CreateSystemKeyBun(bdb *bun.DB, publicKey, privateKey string) (int, error)
 		newSerial = int(max.Int64) + 1
 	}
 	// Insert new key (do not deactivate others)
-	if _, err := ExecRaw(ctx, bdb, "INSERT INTO system_keys(serial, public_key, private_key, is_active) VALUES(?, ?, ?, ?)", newSerial, publicKey, privateKey, true); err != nil {
+	if _, err := ExecRaw(ctx, bdb, "INSERT INTO system_keys(serial, public_key, private_key, is_active, created_at) VALUES(?, ?, ?, ?, ?)", newSerial, publicKey, privateKey, true, time.Now().UTC()); err != nil {
 		return 0, err
 	}
 	return newSerial, nil
 }
+
+// DeactivateSystemKeysBelowBun deactivates the active system keys with a
+// serial lower than serial, finalizing a staged rotation. It returns the
+// number of keys deactivated.
+func DeactivateSystemKeysBelowBun(bdb *bun.DB, serial int) (int, error) {
+	res, err := ExecRaw(context.Background(), bdb, "UPDATE system_keys SET is_active = ? WHERE serial < ? AND is_active = ?", false, serial, true)
+	if err != nil {
+		return 0, MapDBError(err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
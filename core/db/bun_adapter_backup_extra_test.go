@@ -65,10 +65,10 @@ func TestKnownHostAndImportIntegrateTimestamps(t *testing.T) {
 		}
 
 		// Integrate should be idempotent (no error) when called twice
-		if err := IntegrateDataFromBackupBun(bdb, backup); err != nil {
+		if _, err := IntegrateDataFromBackupBun(bdb, backup); err != nil {
 			t.Fatalf("IntegrateDataFromBackupBun first: %v", err)
 		}
-		if err := IntegrateDataFromBackupBun(bdb, backup); err != nil {
+		if _, err := IntegrateDataFromBackupBun(bdb, backup); err != nil {
 			t.Fatalf("IntegrateDataFromBackupBun second: %v", err)
 		}
 	})
@@ -126,7 +126,7 @@ func TestAddPublicKeyAndImportIntegrate(t *testing.T) {
 		}
 
 		// Integrate should be idempotent (no error)
-		if err := IntegrateDataFromBackupBun(bdb, backup); err != nil {
+		if _, err := IntegrateDataFromBackupBun(bdb, backup); err != nil {
 			t.Fatalf("IntegrateDataFromBackupBun: %v", err)
 		}
 	})
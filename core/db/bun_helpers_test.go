@@ -12,11 +12,11 @@ import (
 
 func TestBeginTx_WithTx_IsInitialized_GetAllAuditLogEntries(t *testing.T) {
 	// Preserve original store and restore at end
-	orig := store
-	defer func() { store = orig }()
+	orig := currentStore()
+	defer func() { setStore(orig) }()
 
 	// Ensure uninitialized state is reported when store is nil
-	store = nil
+	setStore(nil)
 	if IsInitialized() {
 		t.Fatal("expected IsInitialized to be false when store is nil")
 	}
@@ -0,0 +1,64 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package db
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+func TestSetPublicKeyCertAuthorityBun_RoundTrip(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		id, err := AddAccountBun(s.BunDB(), "u", "h", "", "")
+		if err != nil {
+			t.Fatalf("AddAccountBun: %v", err)
+		}
+		pk, err := AddPublicKeyAndGetModelBun(s.BunDB(), "ssh-ed25519", "AAAAca", "user-ca", false, time.Time{})
+		if err != nil || pk == nil {
+			t.Fatalf("AddPublicKeyAndGetModelBun: %v", err)
+		}
+		if err := AssignKeyToAccountBun(s.BunDB(), pk.ID, id); err != nil {
+			t.Fatalf("AssignKeyToAccountBun: %v", err)
+		}
+		if pk.IsCA {
+			t.Fatalf("expected a plain key, got %+v", pk)
+		}
+
+		principals := []string{"deploy", "ops"}
+		if err := SetPublicKeyCertAuthorityBun(s.BunDB(), pk.ID, true, principals); err != nil {
+			t.Fatalf("SetPublicKeyCertAuthorityBun: %v", err)
+		}
+		keys, err := GetKeysForAccountBun(s.BunDB(), id)
+		if err != nil || len(keys) != 1 || !keys[0].IsCA || !reflect.DeepEqual(keys[0].Principals, principals) {
+			t.Fatalf("CA mark not persisted: %+v %v", keys, err)
+		}
+		if acc, _ := s.GetAccount(id); !acc.IsDirty {
+			t.Fatal("expected the account holding the key to be marked dirty")
+		}
+
+		backup, err := s.ExportDataForBackup()
+		if err != nil {
+			t.Fatalf("ExportDataForBackup: %v", err)
+		}
+		if err := s.ImportDataFromBackup(&model.BackupData{SchemaVersion: backup.SchemaVersion}); err != nil {
+			t.Fatalf("wipe: %v", err)
+		}
+		if err := s.ImportDataFromBackup(backup); err != nil {
+			t.Fatalf("ImportDataFromBackup: %v", err)
+		}
+		if got, _ := GetPublicKeyByIDBun(s.BunDB(), pk.ID); got == nil || !got.IsCA || !reflect.DeepEqual(got.Principals, principals) {
+			t.Fatalf("CA mark lost in restore: %+v", got)
+		}
+
+		if err := SetPublicKeyCertAuthorityBun(s.BunDB(), pk.ID, false, principals); err != nil {
+			t.Fatalf("unset CA: %v", err)
+		}
+		if got, _ := GetPublicKeyByIDBun(s.BunDB(), pk.ID); got == nil || got.IsCA || got.Principals != nil {
+			t.Fatalf("expected a plain key again, got %+v", got)
+		}
+	})
+}
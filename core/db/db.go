@@ -49,21 +49,26 @@ var (
 
 // IsInitialized reports whether the package-level store has been set.
 func IsInitialized() bool {
-	return store != nil
+	return currentStore() != nil
 }
 
 // DefaultStore returns the package-level Store instance. This provides access
 // to the active database store for operations that need the full Store interface.
 // Returns nil if the store has not been initialized.
 func DefaultStore() Store {
-	return store
+	return currentStore()
 }
 
 // ResetStoreForTests closes and clears the package-level store.
 // This is intended for tests to ensure isolation between runs.
 func ResetStoreForTests() {
-	if store != nil {
-		if bunDB := store.BunDB(); bunDB != nil {
+	st := currentStore()
+	if _, ok := st.(*DryRunStore); ok {
+		ClearDefaultKeyManager()
+		ClearDefaultAuditWriter()
+	}
+	if st != nil {
+		if bunDB := st.BunDB(); bunDB != nil {
 			_ = bunDB.DB.Close()
 			// Force a GC and small sleep to help Windows release file locks
 			// from underlying drivers/cleaners before test TempDir cleanup.
@@ -77,13 +82,17 @@ func ResetStoreForTests() {
 			// Retry GC+sleep a few times to give the runtime time to finalize
 			// and for background cleanup to release file handles. Use an
 			// exponential-ish backoff total ~3s to be conservative on CI.
-			for i, d := 0, 200*time.Millisecond; i < 6; i, d = i+1, d*2 {
-				runtime.GC()
-				time.Sleep(d)
+			// Other platforms release handles on Close, so they skip the
+			// wait; it otherwise dominates the CLI test suite's runtime.
+			if runtime.GOOS == "windows" {
+				for i, d := 0, 200*time.Millisecond; i < 6; i, d = i+1, d*2 {
+					runtime.GC()
+					time.Sleep(d)
+				}
 			}
 		}
 	}
-	store = nil
+	setStore(nil)
 }
 
 // BunDB returns the underlying *bun.DB for the active Store, or nil if
@@ -91,17 +100,23 @@ func ResetStoreForTests() {
 // Store interface for most operations; this accessor is provided for code
 // that needs direct Bun access for advanced operations or diagnostics.
 func BunDB() *bun.DB {
-	if store == nil {
+	st := currentStore()
+	if st == nil {
 		return nil
 	}
-	return store.BunDB()
+	return st.BunDB()
 }
 
 // RunDBMaintenance performs engine-specific maintenance tasks for the given
 // database DSN. It is safe to call for SQLite/Postgres/MySQL. For SQLite this
 // will run PRAGMA optimize, VACUUM and WAL checkpoint. For Postgres it runs
 // VACUUM ANALYZE. For MySQL it runs OPTIMIZE TABLE for all tables.
-func RunDBMaintenance(dbType, dsn string) error {
+//
+// Statements run with ctx, so cancelling it interrupts the running statement
+// (e.g. a long VACUUM) instead of leaving it to finish in the background; the
+// returned error then wraps ctx.Err(). Without a deadline on ctx a two-minute
+// limit applies.
+func RunDBMaintenance(ctx context.Context, dbType, dsn string) (err error) {
 	driverName := dbType
 	if dbType == "postgres" {
 		driverName = "pgx"
@@ -112,9 +127,17 @@ func RunDBMaintenance(dbType, dsn string) error {
 	}
 	defer func() { _ = sqlDB.Close() }()
 
-	// Small timeout for maintenance operations to avoid blocking CI.
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
-	defer cancel()
+	// Small default timeout for maintenance operations to avoid blocking CI.
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, 2*time.Minute)
+		defer cancel()
+	}
+	defer func() {
+		if ctxErr := ctx.Err(); err != nil && ctxErr != nil && !errors.Is(err, ctxErr) {
+			err = fmt.Errorf("%w: %w", ctxErr, err)
+		}
+	}()
 
 	switch dbType {
 	case "sqlite":
@@ -254,17 +277,22 @@ func NewStoreFromDSN(dbType, dsn string) (Store, error) {
 // Centralizing construction makes it easier to apply consistent options
 // and to test Bun initialization in one place.
 func createBunDB(sqlDB *sql.DB, dbType string) *bun.DB {
+	var bdb *bun.DB
 	switch dbType {
 	case "sqlite":
-		return bun.NewDB(sqlDB, sqlitedialect.New())
+		bdb = bun.NewDB(sqlDB, sqlitedialect.New())
 	case "postgres":
-		return bun.NewDB(sqlDB, pgdialect.New())
+		bdb = bun.NewDB(sqlDB, pgdialect.New())
 	case "mysql":
-		return bun.NewDB(sqlDB, mysqldialect.New())
+		bdb = bun.NewDB(sqlDB, mysqldialect.New())
 	default:
 		// Fallback to SQLite dialect as a safe default; callers should validate dbType earlier.
-		return bun.NewDB(sqlDB, sqlitedialect.New())
+		bdb = bun.NewDB(sqlDB, sqlitedialect.New())
 	}
+	// m2m join tables must be registered before any model referencing them
+	// is used, otherwise Bun panics while resolving the relation.
+	bdb.RegisterModel((*PublicKeyToTagModel)(nil))
+	return bdb
 }
 
 // (old NewStore removed) Use NewStoreFromDSN to create stores from a DSN.
@@ -275,28 +303,15 @@ func RunMigrations(db *sql.DB, dbType string) error {
 	dbLogf("db: starting migrations for %s", dbType)
 	migrationsPath := fmt.Sprintf("migrations/%s", dbType)
 
-	entries, err := fs.ReadDir(embeddedMigrations, migrationsPath)
+	ups, err := embeddedUpMigrations(dbType)
 	if err != nil {
-		if errors.Is(err, fs.ErrNotExist) {
-			// No migrations embedded for this DB type.
-			dbLogf("db: applied migrations for %s in %s", dbType, time.Since(start))
-			return nil
-		}
-		return fmt.Errorf("failed to read embedded migrations (%s): %w", migrationsPath, err)
+		return err
 	}
-
-	// Collect .up.sql files and sort them
-	var ups []string
-	for _, e := range entries {
-		if e.IsDir() {
-			continue
-		}
-		name := e.Name()
-		if strings.HasSuffix(name, ".up.sql") {
-			ups = append(ups, name)
-		}
+	if len(ups) == 0 {
+		// No migrations embedded for this DB type.
+		dbLogf("db: applied migrations for %s in %s", dbType, time.Since(start))
+		return nil
 	}
-	sort.Strings(ups)
 
 	// Ensure schema_migrations table exists and is compatible with current schema
 	if err := ensureSchemaMigrationsTable(db, dbType); err != nil {
@@ -358,6 +373,74 @@ func RunMigrations(db *sql.DB, dbType string) error {
 	return nil
 }
 
+// embeddedUpMigrations returns the sorted .up.sql file names embedded for
+// dbType, or nil when there are none.
+func embeddedUpMigrations(dbType string) ([]string, error) {
+	migrationsPath := fmt.Sprintf("migrations/%s", dbType)
+	entries, err := fs.ReadDir(embeddedMigrations, migrationsPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read embedded migrations (%s): %w", migrationsPath, err)
+	}
+	var ups []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".up.sql") {
+			ups = append(ups, e.Name())
+		}
+	}
+	sort.Strings(ups)
+	return ups, nil
+}
+
+// MigrationStatus compares the migrations embedded for dbType with those
+// recorded in the active store's schema_migrations table. pending lists
+// embedded versions not applied yet; unknown lists applied versions this
+// build does not know, as left by a newer Keymaster.
+func MigrationStatus(dbType string) (pending, unknown []string, err error) {
+	bdb := BunDB()
+	if bdb == nil {
+		return nil, nil, fmt.Errorf("database not initialized")
+	}
+	ups, err := embeddedUpMigrations(dbType)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err := bdb.DB.Query("SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return nil, nil, fmt.Errorf("failed to read applied migrations: %w", err)
+		}
+		applied[version] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+
+	known := make(map[string]bool, len(ups))
+	for _, fname := range ups {
+		version := strings.TrimSuffix(fname, ".up.sql")
+		known[version] = true
+		if !applied[version] {
+			pending = append(pending, version)
+		}
+	}
+	for version := range applied {
+		if !known[version] {
+			unknown = append(unknown, version)
+		}
+	}
+	sort.Strings(unknown)
+	return pending, unknown, nil
+}
+
 // ensureSchemaMigrationsTable creates schema_migrations if missing and adds
 // the `applied_at` column when the table exists but is missing that column.
 func ensureSchemaMigrationsTable(db *sql.DB, dbType string) error {
@@ -440,7 +523,7 @@ func ensureSchemaMigrationsTable(db *sql.DB, dbType string) error {
 
 // GetAllAccounts retrieves all accounts from the database.
 func GetAllAccounts() ([]model.Account, error) {
-	return store.GetAllAccounts()
+	return currentStore().GetAllAccounts()
 }
 
 // AddAccount adds a new account to the database.
@@ -451,87 +534,118 @@ func GetAllAccounts() ([]model.Account, error) {
 // UpdateAccountSerial sets the system key serial for a given account ID.
 // This is typically called after a successful deployment.
 func UpdateAccountSerial(id, serial int) error {
-	return store.UpdateAccountSerial(id, serial)
+	return currentStore().UpdateAccountSerial(id, serial)
+}
+
+// GetAccount returns the account with the given ID, or nil if none exists.
+func GetAccount(id int) (*model.Account, error) {
+	st := currentStore()
+	if st == nil {
+		return nil, fmt.Errorf("store not initialized")
+	}
+	if bun := st.BunDB(); bun != nil {
+		return GetAccountByIDBun(bun, id)
+	}
+	return nil, fmt.Errorf("store does not support account lookup")
 }
 
 // ToggleAccountStatus flips the active status of an account.
 // ToggleAccountStatus flips the active status of an account (convenience wrapper).
 func ToggleAccountStatus(id int) error {
 	// Read current status
-	if store == nil {
+	st := currentStore()
+	if st == nil {
 		return fmt.Errorf("store not initialized")
 	}
-	if bun := store.BunDB(); bun != nil {
+	if bun := st.BunDB(); bun != nil {
 		acc, err := GetAccountByIDBun(bun, id)
 		if err != nil {
 			return err
 		}
 		if acc == nil {
-			return fmt.Errorf("account not found: %d", id)
+			return fmt.Errorf("account %w: %d", ErrNotFound, id)
 		}
-		return store.ToggleAccountStatus(id, !acc.IsActive)
+		return st.ToggleAccountStatus(id, !acc.IsActive)
 	}
 	// Fallback: ask store to toggle by setting true (best-effort)
-	return store.ToggleAccountStatus(id, true)
+	return st.ToggleAccountStatus(id, true)
 }
 
 // SetAccountActive sets the account active flag to the provided value.
 func SetAccountActive(id int, enabled bool) error {
-	if store == nil {
+	st := currentStore()
+	if st == nil {
 		return fmt.Errorf("store not initialized")
 	}
-	return store.ToggleAccountStatus(id, enabled)
+	return st.ToggleAccountStatus(id, enabled)
 }
 
 // UpdateAccountLabel updates the label for a given account.
 func UpdateAccountLabel(id int, label string) error {
-	return store.UpdateAccountLabel(id, label)
+	return currentStore().UpdateAccountLabel(id, label)
 }
 
 // UpdateAccountIsDirty sets or clears the is_dirty flag for the account.
 func UpdateAccountIsDirty(id int, dirty bool) error {
-	return store.UpdateAccountIsDirty(id, dirty)
+	return currentStore().UpdateAccountIsDirty(id, dirty)
 }
 
 // UpdateAccountHostname updates the hostname for a given account.
 func UpdateAccountHostname(id int, hostname string) error {
-	return store.UpdateAccountHostname(id, hostname)
+	return currentStore().UpdateAccountHostname(id, hostname)
 }
 
 // UpdateAccountTags updates the tags for a given account.
 func UpdateAccountTags(id int, tags string) error {
-	return store.UpdateAccountTags(id, tags)
+	return currentStore().UpdateAccountTags(id, tags)
+}
+
+// GetAccountsBelowSerial returns the accounts last deployed with a system key
+// serial lower than serial.
+func GetAccountsBelowSerial(serial int) ([]model.Account, error) {
+	return currentStore().GetAccountsBelowSerial(serial)
 }
 
 // GetAllActiveAccounts retrieves all active accounts from the database.
 func GetAllActiveAccounts() ([]model.Account, error) {
-	return store.GetAllActiveAccounts()
+	return currentStore().GetAllActiveAccounts()
 }
 
 // GetKnownHostKey retrieves the trusted public key for a given hostname.
 func GetKnownHostKey(hostname string) (string, error) {
-	return store.GetKnownHostKey(hostname)
+	return currentStore().GetKnownHostKey(hostname)
+}
+
+// GetAllKnownHosts returns every trusted host key, ordered by hostname.
+func GetAllKnownHosts() ([]model.KnownHost, error) {
+	return currentStore().GetAllKnownHosts()
 }
 
 // AddKnownHostKey adds a new trusted host key to the database.
 func AddKnownHostKey(hostname, key string) error {
-	return store.AddKnownHostKey(hostname, key)
+	return currentStore().AddKnownHostKey(hostname, key)
 }
 
 // CreateSystemKey adds a new system key to the database. It determines the correct serial automatically.
 func CreateSystemKey(publicKey, privateKey string) (int, error) {
-	return store.CreateSystemKey(publicKey, privateKey)
+	return currentStore().CreateSystemKey(publicKey, privateKey)
 }
 
 // RotateSystemKey deactivates all current system keys and adds a new one as active.
 // This should be performed within a transaction to ensure atomicity.
 func RotateSystemKey(publicKey, privateKey string) (int, error) {
-	return store.RotateSystemKey(publicKey, privateKey)
+	return currentStore().RotateSystemKey(publicKey, privateKey)
+}
+
+// DeactivateSystemKeysBelow deactivates the active system keys older than
+// serial, ending a staged rotation.
+func DeactivateSystemKeysBelow(serial int) (int, error) {
+	return currentStore().DeactivateSystemKeysBelow(serial)
 }
 
 // GetActiveSystemKey retrieves the currently active system key for deployments.
 func GetActiveSystemKey() (*model.SystemKey, error) {
-	return store.GetActiveSystemKey()
+	return currentStore().GetActiveSystemKey()
 }
 
 // SecretFromModelSystemKey converts a stored SystemKey model into a
@@ -558,12 +672,114 @@ func GetActiveSystemKeySecret() (security.Secret, *model.SystemKey, error) {
 
 // GetSystemKeyBySerial retrieves a system key by its serial number.
 func GetSystemKeyBySerial(serial int) (*model.SystemKey, error) {
-	return store.GetSystemKeyBySerial(serial)
+	return currentStore().GetSystemKeyBySerial(serial)
+}
+
+// GetAllSystemKeys returns every system key, including rotated ones kept for
+// hosts that have not been redeployed yet, ordered by serial.
+func GetAllSystemKeys() ([]model.SystemKey, error) {
+	return currentStore().GetAllSystemKeys()
+}
+
+// DeleteSystemKey deletes a retained system key by serial. The active key
+// cannot be deleted.
+func DeleteSystemKey(serial int) error {
+	return currentStore().DeleteSystemKey(serial)
 }
 
 // HasSystemKeys checks if any system keys exist in the database.
 func HasSystemKeys() (bool, error) {
-	return store.HasSystemKeys()
+	return currentStore().HasSystemKeys()
+}
+
+// SetAccountSchedule sets or clears the scheduled disable/enable times of an account.
+func SetAccountSchedule(id int, disableAt, enableAt time.Time) error {
+	return currentStore().SetAccountSchedule(id, disableAt, enableAt)
+}
+
+// SetAccountRemediationPolicy sets the drift remediation policy of an account.
+func SetAccountRemediationPolicy(id int, policy string) error {
+	return currentStore().SetAccountRemediationPolicy(id, policy)
+}
+
+// SetAccountDeployEnabled sets whether deploys may write to an account.
+func SetAccountDeployEnabled(id int, enabled bool) error {
+	return currentStore().SetAccountDeployEnabled(id, enabled)
+}
+
+// SetAccountManageSystemKey sets whether the system key is rendered for an account.
+func SetAccountManageSystemKey(id int, manage bool) error {
+	return currentStore().SetAccountManageSystemKey(id, manage)
+}
+
+// SetAccountDeployMode sets the deploy mode of an account.
+func SetAccountDeployMode(id int, mode string) error {
+	return currentStore().SetAccountDeployMode(id, mode)
+}
+
+// SetAccountLastAudit records the time of the last clean audit of an
+// account; a zero time clears it.
+func SetAccountLastAudit(id int, at time.Time) error {
+	return currentStore().SetAccountLastAudit(id, at)
+}
+
+// SetAccountOSFamily sets the remote OS family of an account.
+func SetAccountOSFamily(id int, family string) error {
+	return currentStore().SetAccountOSFamily(id, family)
+}
+
+// SetAccountDeployKnownHosts sets whether deploys also write a known_hosts
+// file to an account.
+func SetAccountDeployKnownHosts(id int, enabled bool) error {
+	return currentStore().SetAccountDeployKnownHosts(id, enabled)
+}
+
+// SetAccountExcludedGlobalKeys replaces the global keys not rendered for an
+// account.
+func SetAccountExcludedGlobalKeys(id int, keyIDs []int) error {
+	return currentStore().SetAccountExcludedGlobalKeys(id, keyIDs)
+}
+
+// SetAccountAuthorizedPrincipals replaces the principals deployed to an
+// account's authorized_principals.
+func SetAccountAuthorizedPrincipals(id int, principals []string) error {
+	return currentStore().SetAccountAuthorizedPrincipals(id, principals)
+}
+
+// SetAccountGroup moves an account into a group; an empty group removes it
+// from its group.
+func SetAccountGroup(id int, group string) error {
+	return currentStore().SetAccountGroup(id, group)
+}
+
+// SetAccountMetadata replaces the free-form metadata of an account.
+func SetAccountMetadata(id int, metadata map[string]string) error {
+	return currentStore().SetAccountMetadata(id, metadata)
+}
+
+// SetAccountDeployedKeys records the key identities written by the last deploy.
+func SetAccountDeployedKeys(id int, keys []string) error {
+	return currentStore().SetAccountDeployedKeys(id, keys)
+}
+
+// AcquireLock takes a named database-wide lock without waiting.
+func AcquireLock(name string, ttl time.Duration) (bool, error) {
+	return currentStore().AcquireLock(name, ttl)
+}
+
+// ReleaseLock releases a lock taken with AcquireLock.
+func ReleaseLock(name string) error {
+	return currentStore().ReleaseLock(name)
+}
+
+// RenewLock extends a held lock by ttl from now.
+func RenewLock(name string, ttl time.Duration) error {
+	return currentStore().RenewLock(name, ttl)
+}
+
+// GetUnassignedPublicKeys returns non-global public keys with no account assignments.
+func GetUnassignedPublicKeys() ([]model.PublicKey, error) {
+	return currentStore().GetUnassignedPublicKeys()
 }
 
 // Key-related operations are handled via the KeyManager interface (use
@@ -572,7 +788,24 @@ func HasSystemKeys() (bool, error) {
 
 // GetAllAuditLogEntries retrieves all entries from the audit log, most recent first.
 func GetAllAuditLogEntries() ([]model.AuditLogEntry, error) {
-	return store.GetAllAuditLogEntries()
+	return currentStore().GetAllAuditLogEntries()
+}
+
+// GetAuditLogForAccount retrieves the audit log entries referencing an
+// account, oldest first.
+func GetAuditLogForAccount(accountID int) ([]model.AuditLogEntry, error) {
+	return currentStore().GetAuditLogForAccount(accountID)
+}
+
+// RecordOperationResult stores the outcome of one deploy or audit.
+func RecordOperationResult(r model.OperationResult) error {
+	return currentStore().RecordOperationResult(r)
+}
+
+// GetOperationResults returns the operation results of an account recorded at
+// or after since, oldest first.
+func GetOperationResults(accountID int, since time.Time) ([]model.OperationResult, error) {
+	return currentStore().GetOperationResults(accountID, since)
 }
 
 // LogAction records an audit trail event.
@@ -581,50 +814,69 @@ func LogAction(action string, details string) error {
 	if w := DefaultAuditWriter(); w != nil {
 		return w.LogAction(action, details)
 	}
-	return store.LogAction(action, details)
+	return currentStore().LogAction(action, details)
+}
+
+// LogActionFields records an audit trail event with a human summary and
+// structured fields, stored together as JSON in the details column.
+func LogActionFields(action, summary string, fields model.AuditFields) error {
+	return LogAction(action, model.EncodeAuditDetails(summary, fields))
 }
 
 // SaveBootstrapSession saves a bootstrap session to the database.
 func SaveBootstrapSession(id, username, hostname, label, tags, tempPublicKey string, expiresAt time.Time, status string) error {
-	return store.SaveBootstrapSession(id, username, hostname, label, tags, tempPublicKey, expiresAt, status)
+	return currentStore().SaveBootstrapSession(id, username, hostname, label, tags, tempPublicKey, expiresAt, status)
 }
 
 // GetBootstrapSession retrieves a bootstrap session by ID.
 func GetBootstrapSession(id string) (*model.BootstrapSession, error) {
-	return store.GetBootstrapSession(id)
+	return currentStore().GetBootstrapSession(id)
 }
 
 // DeleteBootstrapSession removes a bootstrap session from the database.
 func DeleteBootstrapSession(id string) error {
-	return store.DeleteBootstrapSession(id)
+	return currentStore().DeleteBootstrapSession(id)
 }
 
 // UpdateBootstrapSessionStatus updates the status of a bootstrap session.
 func UpdateBootstrapSessionStatus(id string, status string) error {
-	return store.UpdateBootstrapSessionStatus(id, status)
+	return currentStore().UpdateBootstrapSessionStatus(id, status)
 }
 
 // GetExpiredBootstrapSessions returns all expired bootstrap sessions.
 func GetExpiredBootstrapSessions() ([]*model.BootstrapSession, error) {
-	return store.GetExpiredBootstrapSessions()
+	return currentStore().GetExpiredBootstrapSessions()
 }
 
 // GetOrphanedBootstrapSessions returns all orphaned bootstrap sessions.
 func GetOrphanedBootstrapSessions() ([]*model.BootstrapSession, error) {
-	return store.GetOrphanedBootstrapSessions()
+	return currentStore().GetOrphanedBootstrapSessions()
 }
 
 // ExportDataForBackup retrieves all data from the database for a backup.
 func ExportDataForBackup() (*model.BackupData, error) {
-	return store.ExportDataForBackup()
+	return currentStore().ExportDataForBackup()
+}
+
+// ExportTablesForBackup retrieves the data of the given tables for a
+// selective backup. An empty list exports every table.
+func ExportTablesForBackup(tables []string) (*model.BackupData, error) {
+	return currentStore().ExportTablesForBackup(tables)
+}
+
+// StreamBackup passes the rows of the given tables to fn one at a time. An
+// empty list streams every table.
+func StreamBackup(tables []string, fn func(table string, row any) error) error {
+	return currentStore().StreamBackup(tables, fn)
 }
 
 // ImportDataFromBackup restores the database from a backup data structure.
 func ImportDataFromBackup(backup *model.BackupData) error {
-	return store.ImportDataFromBackup(backup)
+	return currentStore().ImportDataFromBackup(backup)
 }
 
 // IntegrateDataFromBackup restores the database from a backup data structure in a non-destructive way.
-func IntegrateDataFromBackup(backup *model.BackupData) error {
-	return store.IntegrateDataFromBackup(backup)
+// The returned summary counts imported and skipped rows per table.
+func IntegrateDataFromBackup(backup *model.BackupData) (model.RestoreSummary, error) {
+	return currentStore().IntegrateDataFromBackup(backup)
 }
@@ -13,10 +13,10 @@ import (
 // Test BunDB() returns nil when package-level store is nil and non-nil when set.
 func TestBunDB_NilAndNonNil(t *testing.T) {
 	// Save/restore globals
-	prev := store
-	defer func() { store = prev }()
+	prev := currentStore()
+	defer func() { setStore(prev) }()
 
-	store = nil
+	setStore(nil)
 	if BunDB() != nil {
 		t.Fatalf("expected BunDB() to be nil when store is nil")
 	}
@@ -78,3 +78,33 @@ func TestRunMigrations_SQLite(t *testing.T) {
 		t.Fatalf("RunMigrations sqlite failed: %v", err)
 	}
 }
+
+func TestMigrationStatus(t *testing.T) {
+	if _, err := New("sqlite", ":memory:"); err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(ResetStoreForTests)
+
+	pending, unknown, err := MigrationStatus("sqlite")
+	if err != nil || len(pending) != 0 || len(unknown) != 0 {
+		t.Fatalf("expected a freshly migrated database to be current, got %v %v %v", pending, unknown, err)
+	}
+
+	bdb := BunDB()
+	if _, err := bdb.DB.Exec("INSERT INTO schema_migrations(version, applied_at) VALUES('999999_from_the_future', CURRENT_TIMESTAMP)"); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if _, err := bdb.DB.Exec("DELETE FROM schema_migrations WHERE version = '000001_create_initial_tables'"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	pending, unknown, err = MigrationStatus("sqlite")
+	if err != nil {
+		t.Fatalf("MigrationStatus: %v", err)
+	}
+	if len(pending) != 1 || pending[0] != "000001_create_initial_tables" {
+		t.Fatalf("expected the removed migration to be pending, got %v", pending)
+	}
+	if len(unknown) != 1 || unknown[0] != "999999_from_the_future" {
+		t.Fatalf("expected the unknown migration to be reported, got %v", unknown)
+	}
+}
@@ -4,6 +4,7 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"testing"
@@ -85,14 +86,49 @@ func TestPublicKey_AddDuplicateBehavior(t *testing.T) {
 	}
 
 	// AddPublicKey should return ErrDuplicate on the second insert
-	if err := km.AddPublicKey("ed25519", keyData, "another-comment", false, time.Time{}); err != nil {
+	otherKeyData := "AAAAB3NzaC1lZDI1NTE5AAAAIotherkeydata"
+	if err := km.AddPublicKey("ed25519", otherKeyData, "another-comment", false, time.Time{}); err != nil {
 		t.Fatalf("unexpected error on first AddPublicKey: %v", err)
 	}
-	if err := km.AddPublicKey("ed25519", keyData, "another-comment", false, time.Time{}); !errors.Is(err, ErrDuplicate) {
+	if err := km.AddPublicKey("ed25519", otherKeyData, "another-comment", false, time.Time{}); !errors.Is(err, ErrDuplicate) {
 		t.Fatalf("expected ErrDuplicate on duplicate AddPublicKey, got: %v", err)
 	}
 }
 
+func TestPublicKey_DuplicateKeyDataDifferentComment(t *testing.T) {
+	_ = newTestDB(t)
+
+	// A real key, so normalization canonicalizes the base64 form.
+	keyData := "AAAAC3NzaC1lZDI1NTE5AAAAIBVYCTA2Ju/Lm9J3Jts/IHFHqAeVCrw1RnfpTb9GXrRO"
+	km := DefaultKeyManager()
+	if err := km.AddPublicKey("ssh-ed25519", keyData, "alice@laptop", false, time.Time{}); err != nil {
+		t.Fatalf("AddPublicKey: %v", err)
+	}
+	if err := km.AddPublicKey("ssh-ed25519", " "+keyData+" ", "alice@desktop", false, time.Time{}); !errors.Is(err, ErrDuplicate) {
+		t.Fatalf("expected ErrDuplicate for same key data under new comment, got: %v", err)
+	}
+	if pk, err := km.AddPublicKeyAndGetModel("ssh-ed25519", keyData, "alice@phone", false, time.Time{}); !errors.Is(err, ErrDuplicate) || pk != nil {
+		t.Fatalf("expected ErrDuplicate from AddPublicKeyAndGetModel, got %v, %v", pk, err)
+	}
+	all, err := km.GetAllPublicKeys()
+	if err != nil || len(all) != 1 {
+		t.Fatalf("expected exactly one stored key, got %d (%v)", len(all), err)
+	}
+}
+
+func TestPublicKey_UniqueKeyDataIndex(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		ctx := context.Background()
+		if _, err := ExecRaw(ctx, s.BunDB(), "INSERT INTO public_keys (algorithm, key_data, comment) VALUES ('ssh-ed25519', 'AAAAsame', 'a')"); err != nil {
+			t.Fatalf("first insert: %v", err)
+		}
+		_, err := ExecRaw(ctx, s.BunDB(), "INSERT INTO public_keys (algorithm, key_data, comment) VALUES ('ssh-ed25519', 'AAAAsame', 'b')")
+		if !errors.Is(MapDBError(err), ErrDuplicate) {
+			t.Fatalf("expected unique index violation, got %v", err)
+		}
+	})
+}
+
 func TestAccount_AddDuplicateBehavior(t *testing.T) {
 	_ = newTestDB(t)
 
@@ -109,3 +145,26 @@ func TestAccount_AddDuplicateBehavior(t *testing.T) {
 		t.Fatalf("expected ErrDuplicate on duplicate AddAccount, got: %v", err)
 	}
 }
+
+func TestPublicKey_NormalizedOnStorage(t *testing.T) {
+	_ = newTestDB(t)
+
+	const data = "AAAAC3NzaC1lZDI1NTE5AAAAIBVYCTA2Ju/Lm9J3Jts/IHFHqAeVCrw1RnfpTb9GXrRO"
+	km := DefaultKeyManager()
+	pk, err := km.AddPublicKeyAndGetModel(" ssh-ed25519 ", " "+data+"\n", "  Alice   Laptop ", false, time.Time{})
+	if err != nil || pk == nil {
+		t.Fatalf("AddPublicKeyAndGetModel: %v, %v", pk, err)
+	}
+	stored, err := km.GetPublicKeyByComment("Alice Laptop")
+	if err != nil || stored == nil {
+		t.Fatalf("expected key stored under normalized comment: %v", err)
+	}
+	if stored.Algorithm != "ssh-ed25519" || stored.KeyData != data {
+		t.Fatalf("stored key not canonical: %q %q", stored.Algorithm, stored.KeyData)
+	}
+
+	// Same material with a differently cased comment is the same key.
+	if err := km.AddPublicKey("ssh-ed25519", data, "ALICE LAPTOP", false, time.Time{}); !errors.Is(err, ErrDuplicate) {
+		t.Fatalf("expected ErrDuplicate for comment-case variant, got %v", err)
+	}
+}
@@ -204,7 +204,7 @@ func TestAccountAndKeyWrappers(t *testing.T) {
 		if err := ImportDataFromBackup(b); err != nil {
 			t.Fatalf("ImportDataFromBackup: %v", err)
 		}
-		if err := IntegrateDataFromBackup(b); err != nil {
+		if _, err := IntegrateDataFromBackup(b); err != nil {
 			t.Fatalf("IntegrateDataFromBackup: %v", err)
 		}
 
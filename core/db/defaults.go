@@ -0,0 +1,129 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package db
+
+import (
+	"context"
+	"sync"
+)
+
+// defaultsMu guards the package-level store and the overrides installed with
+// the SetDefault* functions, so tests may swap fakes while other goroutines
+// resolve services.
+var defaultsMu sync.RWMutex
+
+// setDefault stores v in the override *dst under defaultsMu.
+func setDefault[T any](dst *T, v T) {
+	defaultsMu.Lock()
+	defer defaultsMu.Unlock()
+	*dst = v
+}
+
+// getDefault reads the override *src under defaultsMu.
+func getDefault[T any](src *T) T {
+	defaultsMu.RLock()
+	defer defaultsMu.RUnlock()
+	return *src
+}
+
+// currentStore returns the package-level store under defaultsMu.
+func currentStore() Store { return getDefault(&store) }
+
+// setStore replaces the package-level store under defaultsMu.
+func setStore(s Store) { setDefault(&store, s) }
+
+// overrideKey is the context key of a per-context override of service T.
+type overrideKey[T any] struct{}
+
+func withOverride[T any](ctx context.Context, v T) context.Context {
+	return context.WithValue(ctx, overrideKey[T]{}, v)
+}
+
+// fromContext returns the override of T carried by ctx, or fallback() when
+// there is none.
+func fromContext[T any](ctx context.Context, fallback func() T) T {
+	if ctx != nil {
+		if v, ok := ctx.Value(overrideKey[T]{}).(T); ok {
+			return v
+		}
+	}
+	return fallback()
+}
+
+// Per-context overrides take precedence over the package-level SetDefault*
+// overrides for code that resolves its services through the *FromContext
+// functions. Parallel tests use them to inject fakes without affecting each
+// other.
+
+// ContextWithAccountSearcher returns a copy of ctx that resolves s as its
+// AccountSearcher.
+func ContextWithAccountSearcher(ctx context.Context, s AccountSearcher) context.Context {
+	return withOverride(ctx, s)
+}
+
+// AccountSearcherFromContext returns the AccountSearcher of ctx, or
+// DefaultAccountSearcher().
+func AccountSearcherFromContext(ctx context.Context) AccountSearcher {
+	return fromContext(ctx, DefaultAccountSearcher)
+}
+
+// ContextWithAuditSearcher returns a copy of ctx that resolves s as its
+// AuditSearcher.
+func ContextWithAuditSearcher(ctx context.Context, s AuditSearcher) context.Context {
+	return withOverride(ctx, s)
+}
+
+// AuditSearcherFromContext returns the AuditSearcher of ctx, or
+// DefaultAuditSearcher().
+func AuditSearcherFromContext(ctx context.Context) AuditSearcher {
+	return fromContext(ctx, DefaultAuditSearcher)
+}
+
+// ContextWithKeySearcher returns a copy of ctx that resolves s as its
+// KeySearcher.
+func ContextWithKeySearcher(ctx context.Context, s KeySearcher) context.Context {
+	return withOverride(ctx, s)
+}
+
+// KeySearcherFromContext returns the KeySearcher of ctx, or
+// DefaultKeySearcher().
+func KeySearcherFromContext(ctx context.Context) KeySearcher {
+	return fromContext(ctx, DefaultKeySearcher)
+}
+
+// ContextWithAccountManager returns a copy of ctx that resolves m as its
+// AccountManager.
+func ContextWithAccountManager(ctx context.Context, m AccountManager) context.Context {
+	return withOverride(ctx, m)
+}
+
+// AccountManagerFromContext returns the AccountManager of ctx, or
+// DefaultAccountManager().
+func AccountManagerFromContext(ctx context.Context) AccountManager {
+	return fromContext(ctx, DefaultAccountManager)
+}
+
+// ContextWithKeyManager returns a copy of ctx that resolves m as its
+// KeyManager.
+func ContextWithKeyManager(ctx context.Context, m KeyManager) context.Context {
+	return withOverride(ctx, m)
+}
+
+// KeyManagerFromContext returns the KeyManager of ctx, or
+// DefaultKeyManager().
+func KeyManagerFromContext(ctx context.Context) KeyManager {
+	return fromContext(ctx, DefaultKeyManager)
+}
+
+// ContextWithAuditWriter returns a copy of ctx that resolves w as its
+// AuditWriter.
+func ContextWithAuditWriter(ctx context.Context, w AuditWriter) context.Context {
+	return withOverride(ctx, w)
+}
+
+// AuditWriterFromContext returns the AuditWriter of ctx, or
+// DefaultAuditWriter().
+func AuditWriterFromContext(ctx context.Context) AuditWriter {
+	return fromContext(ctx, DefaultAuditWriter)
+}
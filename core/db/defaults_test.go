@@ -0,0 +1,169 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package db
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// TestDefaults_ConcurrentOverrides swaps the package-level overrides while
+// other goroutines resolve services through them and through per-context
+// overrides. Run with -race.
+func TestDefaults_ConcurrentOverrides(t *testing.T) {
+	prevKM := getDefault(&defaultKeyManager)
+	prevAM := getDefault(&defaultAccountManager)
+	prevAW := getDefault(&defaultAuditWriter)
+	t.Cleanup(func() {
+		SetDefaultKeyManager(prevKM)
+		SetDefaultAccountManager(prevAM)
+		SetDefaultAuditWriter(prevAW)
+	})
+	SetDefaultKeyManager(&FakeKeyManager{})
+	SetDefaultAccountManager(&FakeAccountManager{})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 24)
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				SetDefaultKeyManager(&FakeKeyManager{NextKeyID: j})
+				SetDefaultAccountManager(&FakeAccountManager{NextID: j})
+				SetDefaultAuditWriter(&FakeAuditWriter{})
+				ClearDefaultAuditWriter()
+			}
+		}()
+		// Readers without a context override see one of the fakes set above.
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if got, ok := DefaultKeyManager().(*FakeKeyManager); !ok || got == nil {
+					errs <- fmt.Errorf("reader %d: got key manager %T", i, DefaultKeyManager())
+					return
+				}
+				if got, ok := DefaultAccountManager().(*FakeAccountManager); !ok || got == nil {
+					errs <- fmt.Errorf("reader %d: got account manager %T", i, DefaultAccountManager())
+					return
+				}
+			}
+		}()
+	}
+
+	// Each worker carries its own fakes in its context and must always see
+	// them, whatever the package-level overrides are at the time.
+	for i := 0; i < 8; i++ {
+		km := &FakeKeyManager{NextKeyID: 1000 + i}
+		am := &FakeAccountManager{NextID: 1000 + i}
+		aw := &FakeAuditWriter{}
+		ctx := ContextWithAuditWriter(ContextWithAccountManager(ContextWithKeyManager(context.Background(), km), am), aw)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if got := KeyManagerFromContext(ctx); got != km {
+					errs <- fmt.Errorf("worker %d: got key manager %+v", i, got)
+					return
+				}
+				if got := AccountManagerFromContext(ctx); got != am {
+					errs <- fmt.Errorf("worker %d: got account manager %+v", i, got)
+					return
+				}
+				if got := AuditWriterFromContext(ctx); got != aw {
+					errs <- fmt.Errorf("worker %d: got audit writer %+v", i, got)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestDefaults_ContextFallsBackToPackageOverride(t *testing.T) {
+	prev := getDefault(&defaultKeySearcher)
+	t.Cleanup(func() { SetDefaultKeySearcher(prev) })
+
+	ks := &FakeKeySearcher{}
+	SetDefaultKeySearcher(ks)
+	if got := KeySearcherFromContext(context.Background()); got != ks {
+		t.Fatalf("expected the package-level searcher without a context override, got %v", got)
+	}
+	own := &FakeKeySearcher{}
+	if got := KeySearcherFromContext(ContextWithKeySearcher(context.Background(), own)); got != own {
+		t.Fatalf("expected the context override, got %v", got)
+	}
+}
+
+// TestDefaults_ConcurrentStoreSwap enables dry-run mode and replaces the
+// package-level store while other goroutines resolve services from it. Run
+// with -race.
+func TestDefaults_ConcurrentStoreSwap(t *testing.T) {
+	prevStore := currentStore()
+	prevKM := getDefault(&defaultKeyManager)
+	prevAW := getDefault(&defaultAuditWriter)
+	t.Cleanup(func() {
+		setStore(prevStore)
+		SetDefaultKeyManager(prevKM)
+		SetDefaultAuditWriter(prevAW)
+	})
+	setStore(&fakeStore{})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				setStore(&fakeStore{})
+				d, err := EnableDryRun()
+				if err != nil {
+					errs <- fmt.Errorf("writer %d: EnableDryRun: %v", i, err)
+					return
+				}
+				if _, ok := d.Store.(*fakeStore); !ok {
+					errs <- fmt.Errorf("writer %d: dry-run store wraps %T", i, d.Store)
+					return
+				}
+			}
+		}()
+		// The store is only ever a fake or a dry-run wrapper around one.
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if !IsInitialized() {
+					errs <- fmt.Errorf("reader %d: store not initialized", i)
+					return
+				}
+				switch st := DefaultStore().(type) {
+				case *fakeStore:
+				case *DryRunStore:
+					if _, ok := st.Store.(*fakeStore); !ok {
+						errs <- fmt.Errorf("reader %d: dry-run store wraps %T", i, st.Store)
+						return
+					}
+				default:
+					errs <- fmt.Errorf("reader %d: got store %T", i, st)
+					return
+				}
+				if DefaultAccountSearcher() == nil || DefaultAccountManager() == nil {
+					errs <- fmt.Errorf("reader %d: no account services for an initialized store", i)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,363 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package db
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// DryRunChange is a write captured by a DryRunStore instead of being applied.
+type DryRunChange struct {
+	// Op is the name of the store method, e.g. "UpdateAccountTags".
+	Op string
+	// Args are the formatted arguments of the call.
+	Args string
+}
+
+// String renders the change as a method call.
+func (c DryRunChange) String() string {
+	return c.Op + "(" + c.Args + ")"
+}
+
+// DryRunStore wraps a Store to rehearse commands: reads are forwarded to the
+// real store while writes are recorded and reported as successful without
+// touching the database. Writes that create rows return zero IDs, and later
+// reads do not observe recorded writes.
+type DryRunStore struct {
+	Store
+
+	mu      sync.Mutex
+	changes []DryRunChange
+}
+
+// NewDryRunStore returns a DryRunStore forwarding reads to real.
+func NewDryRunStore(real Store) *DryRunStore {
+	return &DryRunStore{Store: real}
+}
+
+// Changes returns the writes recorded so far, in call order.
+func (d *DryRunStore) Changes() []DryRunChange {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]DryRunChange(nil), d.changes...)
+}
+
+// WriteSummary prints the recorded writes to w.
+func (d *DryRunStore) WriteSummary(w io.Writer) error {
+	changes := d.Changes()
+	if len(changes) == 0 {
+		_, err := fmt.Fprintln(w, "Dry run: no database changes.")
+		return err
+	}
+	noun := "changes were"
+	if len(changes) == 1 {
+		noun = "change was"
+	}
+	if _, err := fmt.Fprintf(w, "Dry run: %d database %s not applied:\n", len(changes), noun); err != nil {
+		return err
+	}
+	for _, c := range changes {
+		if _, err := fmt.Fprintf(w, "  %s\n", c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *DryRunStore) record(op string, args ...any) {
+	parts := make([]string, len(args))
+	for i, a := range args {
+		parts[i] = formatDryRunArg(a)
+	}
+	c := DryRunChange{Op: op, Args: strings.Join(parts, ", ")}
+	dbLogf("db: dry run: skipped %s", c)
+	d.mu.Lock()
+	d.changes = append(d.changes, c)
+	d.mu.Unlock()
+}
+
+func formatDryRunArg(a any) string {
+	switch v := a.(type) {
+	case string:
+		return fmt.Sprintf("%q", v)
+	case time.Time:
+		if v.IsZero() {
+			return "<none>"
+		}
+		return v.UTC().Format(time.RFC3339)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// redactedDryRunArg stands in for secrets such as private keys.
+const redactedDryRunArg = "<redacted>"
+
+func (d *DryRunStore) AddAccount(username, hostname, label, tags string) (int, error) {
+	d.record("AddAccount", username, hostname, label, tags)
+	return 0, nil
+}
+
+func (d *DryRunStore) DeleteAccount(id int) error {
+	d.record("DeleteAccount", id)
+	return nil
+}
+
+func (d *DryRunStore) UpdateAccountSerial(id, serial int) error {
+	d.record("UpdateAccountSerial", id, serial)
+	return nil
+}
+
+func (d *DryRunStore) ToggleAccountStatus(id int, enabled bool) error {
+	d.record("ToggleAccountStatus", id, enabled)
+	return nil
+}
+
+func (d *DryRunStore) UpdateAccountLabel(id int, label string) error {
+	d.record("UpdateAccountLabel", id, label)
+	return nil
+}
+
+func (d *DryRunStore) UpdateAccountHostname(id int, hostname string) error {
+	d.record("UpdateAccountHostname", id, hostname)
+	return nil
+}
+
+func (d *DryRunStore) UpdateAccountTags(id int, tags string) error {
+	d.record("UpdateAccountTags", id, tags)
+	return nil
+}
+
+func (d *DryRunStore) UpdateAccountIsDirty(id int, dirty bool) error {
+	d.record("UpdateAccountIsDirty", id, dirty)
+	return nil
+}
+
+func (d *DryRunStore) SetAccountSchedule(id int, disableAt, enableAt time.Time) error {
+	d.record("SetAccountSchedule", id, disableAt, enableAt)
+	return nil
+}
+
+func (d *DryRunStore) SetAccountRemediationPolicy(id int, policy string) error {
+	d.record("SetAccountRemediationPolicy", id, policy)
+	return nil
+}
+
+func (d *DryRunStore) SetAccountManageSystemKey(id int, manage bool) error {
+	d.record("SetAccountManageSystemKey", id, manage)
+	return nil
+}
+
+func (d *DryRunStore) SetAccountDeployEnabled(id int, enabled bool) error {
+	d.record("SetAccountDeployEnabled", id, enabled)
+	return nil
+}
+
+func (d *DryRunStore) SetAccountDeployMode(id int, mode string) error {
+	d.record("SetAccountDeployMode", id, mode)
+	return nil
+}
+
+func (d *DryRunStore) SetAccountDeployedKeys(id int, keys []string) error {
+	d.record("SetAccountDeployedKeys", id, keys)
+	return nil
+}
+
+func (d *DryRunStore) SetAccountMetadata(id int, metadata map[string]string) error {
+	d.record("SetAccountMetadata", id, metadata)
+	return nil
+}
+
+func (d *DryRunStore) SetAccountOSFamily(id int, family string) error {
+	d.record("SetAccountOSFamily", id, family)
+	return nil
+}
+
+func (d *DryRunStore) SetAccountDeployKnownHosts(id int, enabled bool) error {
+	d.record("SetAccountDeployKnownHosts", id, enabled)
+	return nil
+}
+
+func (d *DryRunStore) SetAccountExcludedGlobalKeys(id int, keyIDs []int) error {
+	d.record("SetAccountExcludedGlobalKeys", id, keyIDs)
+	return nil
+}
+
+func (d *DryRunStore) SetAccountAuthorizedPrincipals(id int, principals []string) error {
+	d.record("SetAccountAuthorizedPrincipals", id, principals)
+	return nil
+}
+
+func (d *DryRunStore) SetAccountGroup(id int, group string) error {
+	d.record("SetAccountGroup", id, group)
+	return nil
+}
+
+func (d *DryRunStore) SetAccountLastAudit(id int, at time.Time) error {
+	d.record("SetAccountLastAudit", id, at)
+	return nil
+}
+
+func (d *DryRunStore) AddKnownHostKey(hostname, key string) error {
+	d.record("AddKnownHostKey", hostname, key)
+	return nil
+}
+
+func (d *DryRunStore) CreateSystemKey(publicKey, privateKey string) (int, error) {
+	d.record("CreateSystemKey", publicKey, redactedDryRunArg)
+	return 0, nil
+}
+
+func (d *DryRunStore) RotateSystemKey(publicKey, privateKey string) (int, error) {
+	d.record("RotateSystemKey", publicKey, redactedDryRunArg)
+	return 0, nil
+}
+
+func (d *DryRunStore) DeleteSystemKey(serial int) error {
+	d.record("DeleteSystemKey", serial)
+	return nil
+}
+
+func (d *DryRunStore) DeactivateSystemKeysBelow(serial int) (int, error) {
+	d.record("DeactivateSystemKeysBelow", serial)
+	return 0, nil
+}
+
+// LogAction records the audit entry instead of writing it. It also makes the
+// DryRunStore usable as the package AuditWriter.
+func (d *DryRunStore) LogAction(action string, details string) error {
+	d.record("LogAction", action, details)
+	return nil
+}
+
+func (d *DryRunStore) RecordOperationResult(r model.OperationResult) error {
+	d.record("RecordOperationResult", r.AccountID, r.Operation, r.Success, r.ErrorCategory)
+	return nil
+}
+
+func (d *DryRunStore) SaveBootstrapSession(id, username, hostname, label, tags, tempPublicKey string, expiresAt time.Time, status string) error {
+	d.record("SaveBootstrapSession", id, username, hostname, label, tags, expiresAt, status)
+	return nil
+}
+
+func (d *DryRunStore) DeleteBootstrapSession(id string) error {
+	d.record("DeleteBootstrapSession", id)
+	return nil
+}
+
+func (d *DryRunStore) UpdateBootstrapSessionStatus(id string, status string) error {
+	d.record("UpdateBootstrapSessionStatus", id, status)
+	return nil
+}
+
+func (d *DryRunStore) ImportDataFromBackup(backup *model.BackupData) error {
+	d.record("ImportDataFromBackup", dryRunBackupSummary(backup))
+	return nil
+}
+
+func (d *DryRunStore) IntegrateDataFromBackup(backup *model.BackupData) (model.RestoreSummary, error) {
+	d.record("IntegrateDataFromBackup", dryRunBackupSummary(backup))
+	return model.RestoreSummary{}, nil
+}
+
+func dryRunBackupSummary(b *model.BackupData) string {
+	if b == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("%d accounts, %d public keys, %d assignments, %d system keys, %d known hosts",
+		len(b.Accounts), len(b.PublicKeys), len(b.AccountKeys), len(b.SystemKeys), len(b.KnownHosts))
+}
+
+// AcquireLock, RenewLock and ReleaseLock are forwarded: locks only coordinate instances
+// and are released by the command that took them.
+
+// dryRunKeyManager forwards KeyManager reads and records writes into a
+// DryRunStore. The Bun-backed KeyManager writes through the database handle
+// rather than the Store, so EnableDryRun installs this wrapper in its place.
+type dryRunKeyManager struct {
+	KeyManager
+	d *DryRunStore
+}
+
+func (m *dryRunKeyManager) AddPublicKey(algorithm, keyData, comment string, isGlobal bool, expiresAt time.Time) error {
+	m.d.record("AddPublicKey", algorithm, comment, isGlobal, expiresAt)
+	return nil
+}
+
+func (m *dryRunKeyManager) AddPublicKeyAndGetModel(algorithm, keyData, comment string, isGlobal bool, expiresAt time.Time) (*model.PublicKey, error) {
+	m.d.record("AddPublicKey", algorithm, comment, isGlobal, expiresAt)
+	return &model.PublicKey{Algorithm: algorithm, KeyData: keyData, Comment: comment, IsGlobal: isGlobal, ExpiresAt: expiresAt}, nil
+}
+
+func (m *dryRunKeyManager) DeletePublicKey(id int) error {
+	m.d.record("DeletePublicKey", id)
+	return nil
+}
+
+func (m *dryRunKeyManager) TogglePublicKeyGlobal(id int) error {
+	m.d.record("TogglePublicKeyGlobal", id)
+	return nil
+}
+
+func (m *dryRunKeyManager) SetPublicKeyExpiry(id int, expiresAt time.Time) error {
+	m.d.record("SetPublicKeyExpiry", id, expiresAt)
+	return nil
+}
+
+func (m *dryRunKeyManager) SetPublicKeyMetadata(id int, metadata map[string]string) error {
+	m.d.record("SetPublicKeyMetadata", id, metadata)
+	return nil
+}
+
+func (m *dryRunKeyManager) SetPublicKeyCertAuthority(id int, isCA bool, principals []string) error {
+	m.d.record("SetPublicKeyCertAuthority", id, isCA, principals)
+	return nil
+}
+
+func (m *dryRunKeyManager) UpdatePublicKey(id int, algorithm, keyData string) error {
+	m.d.record("UpdatePublicKey", id, algorithm)
+	return nil
+}
+
+func (m *dryRunKeyManager) AssignKeyToAccount(keyID, accountID int) error {
+	m.d.record("AssignKeyToAccount", keyID, accountID)
+	return nil
+}
+
+func (m *dryRunKeyManager) AssignKeyToAccountWithOptions(keyID, accountID int, options string) error {
+	m.d.record("AssignKeyToAccountWithOptions", keyID, accountID, options)
+	return nil
+}
+
+func (m *dryRunKeyManager) UnassignKeyFromAccount(keyID, accountID int) error {
+	m.d.record("UnassignKeyFromAccount", keyID, accountID)
+	return nil
+}
+
+// EnableDryRun wraps the package-level store in a DryRunStore and routes the
+// default KeyManager and AuditWriter through it, so the package helpers stop
+// writing to the database. Calling it again returns the active DryRunStore.
+func EnableDryRun() (*DryRunStore, error) {
+	// Swap the store and its overrides in one critical section so no
+	// goroutine sees the dry-run store with the writing KeyManager.
+	defaultsMu.Lock()
+	defer defaultsMu.Unlock()
+	if store == nil {
+		return nil, fmt.Errorf("store not initialized")
+	}
+	if d, ok := store.(*DryRunStore); ok {
+		return d, nil
+	}
+	d := NewDryRunStore(store)
+	store = d
+	defaultKeyManager = &dryRunKeyManager{KeyManager: &bunKeyManager{bStore: d.Store}, d: d}
+	defaultAuditWriter = d
+	return d, nil
+}
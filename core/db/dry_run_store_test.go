@@ -0,0 +1,129 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package db
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDryRunStore_ReadsPassThroughWritesRecorded(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		id, err := AddAccountBun(s.BunDB(), "deploy", "web-01", "web", "prod")
+		if err != nil {
+			t.Fatalf("AddAccountBun: %v", err)
+		}
+		d := NewDryRunStore(s)
+
+		accounts, err := d.GetAllAccounts()
+		if err != nil || len(accounts) != 1 || accounts[0].ID != id {
+			t.Fatalf("expected reads to reach the real store, got %+v, %v", accounts, err)
+		}
+
+		if err := d.UpdateAccountTags(id, "staging"); err != nil {
+			t.Fatalf("UpdateAccountTags: %v", err)
+		}
+		if newID, err := d.AddAccount("admin", "db-01", "", ""); err != nil || newID != 0 {
+			t.Fatalf("expected AddAccount to report id 0, got %d, %v", newID, err)
+		}
+		if _, err := d.CreateSystemKey("ssh-ed25519 AAAA", "PRIVATE KEY"); err != nil {
+			t.Fatalf("CreateSystemKey: %v", err)
+		}
+		if err := d.SetAccountLastAudit(id, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)); err != nil {
+			t.Fatalf("SetAccountLastAudit: %v", err)
+		}
+
+		accounts, _ = s.GetAllAccounts()
+		if len(accounts) != 1 || accounts[0].Tags != "prod" || !accounts[0].LastAuditAt.IsZero() {
+			t.Fatalf("expected the real store to be unchanged, got %+v", accounts)
+		}
+		if has, _ := s.HasSystemKeys(); has {
+			t.Fatal("expected no system key to be created")
+		}
+
+		changes := d.Changes()
+		want := []string{
+			`UpdateAccountTags(1, "staging")`,
+			`AddAccount("admin", "db-01", "", "")`,
+			`CreateSystemKey("ssh-ed25519 AAAA", "<redacted>")`,
+			`SetAccountLastAudit(1, 2026-01-02T03:04:05Z)`,
+		}
+		if len(changes) != len(want) {
+			t.Fatalf("expected %d changes, got %v", len(want), changes)
+		}
+		for i, w := range want {
+			if changes[i].String() != w {
+				t.Errorf("change %d = %s, want %s", i, changes[i], w)
+			}
+		}
+
+		var buf bytes.Buffer
+		if err := d.WriteSummary(&buf); err != nil {
+			t.Fatalf("WriteSummary: %v", err)
+		}
+		if !strings.HasPrefix(buf.String(), "Dry run: 4 database changes were not applied:\n") || strings.Contains(buf.String(), "PRIVATE KEY") {
+			t.Fatalf("unexpected summary:\n%s", buf.String())
+		}
+	})
+}
+
+func TestDryRunStore_EmptySummary(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewDryRunStore(nil).WriteSummary(&buf); err != nil {
+		t.Fatalf("WriteSummary: %v", err)
+	}
+	if buf.String() != "Dry run: no database changes.\n" {
+		t.Fatalf("unexpected summary %q", buf.String())
+	}
+}
+
+func TestEnableDryRun_RoutesPackageHelpers(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		id, err := AddAccountBun(s.BunDB(), "deploy", "web-01", "", "")
+		if err != nil {
+			t.Fatalf("AddAccountBun: %v", err)
+		}
+		before, _ := s.GetAllAuditLogEntries()
+
+		d, err := EnableDryRun()
+		if err != nil {
+			t.Fatalf("EnableDryRun: %v", err)
+		}
+		if again, _ := EnableDryRun(); again != d {
+			t.Fatal("expected EnableDryRun to return the active dry-run store")
+		}
+
+		if err := UpdateAccountTags(id, "web"); err != nil {
+			t.Fatalf("UpdateAccountTags: %v", err)
+		}
+		if err := LogAction("TEST", "dry run"); err != nil {
+			t.Fatalf("LogAction: %v", err)
+		}
+		km := DefaultKeyManager()
+		if err := km.AddPublicKey("ssh-ed25519", "AAAAC3NzaC1lZDI1NTE5AAAAIDryRun", "alice", false, time.Time{}); err != nil {
+			t.Fatalf("AddPublicKey: %v", err)
+		}
+		if err := km.AssignKeyToAccount(7, id); err != nil {
+			t.Fatalf("AssignKeyToAccount: %v", err)
+		}
+		if accounts, err := GetAllAccounts(); err != nil || len(accounts) != 1 {
+			t.Fatalf("expected reads to pass through, got %+v, %v", accounts, err)
+		}
+
+		if acc, _ := GetAccountByIDBun(s.BunDB(), id); acc.Tags != "" {
+			t.Fatalf("expected tags to stay unchanged, got %q", acc.Tags)
+		}
+		if keys, _ := GetAllPublicKeysBun(s.BunDB()); len(keys) != 0 {
+			t.Fatalf("expected no key to be added, got %+v", keys)
+		}
+		if after, _ := s.GetAllAuditLogEntries(); len(after) != len(before) {
+			t.Fatalf("expected no audit entries to be written, got %d, had %d", len(after), len(before))
+		}
+		if got := len(d.Changes()); got != 4 {
+			t.Fatalf("expected 4 recorded changes, got %v", d.Changes())
+		}
+	})
+}
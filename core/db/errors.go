@@ -6,25 +6,50 @@
 package db
 
 import (
+	"database/sql"
 	"errors"
+	"fmt"
 	"strings"
 )
 
 // ErrDuplicate is returned when attempting to insert a record that already exists.
 var ErrDuplicate = errors.New("duplicate record")
 
+// ErrNotFound is returned when the referenced record does not exist.
+var ErrNotFound = errors.New("not found")
+
+// ErrForeignKey is returned when a write references a record that does not
+// exist, or deletes one that is still referenced.
+var ErrForeignKey = errors.New("foreign key violation")
+
+// ErrActiveSystemKey is returned when attempting to delete the active system key.
+var ErrActiveSystemKey = errors.New("system key is active")
+
 // MapDBError inspects low-level driver errors and maps common constraint
-// violations to package-level sentinel errors (like ErrDuplicate). This is a
-// conservative, string-based mapping to avoid importing SQL driver packages
-// into this package file.
+// violations to package-level sentinel errors (ErrDuplicate, ErrForeignKey,
+// ErrNotFound) so callers can use errors.Is instead of matching driver
+// messages. The driver error stays in the chain. This is a conservative,
+// string-based mapping to avoid importing SQL driver packages into this
+// package file.
 func MapDBError(err error) error {
 	if err == nil {
 		return nil
 	}
+	if errors.Is(err, ErrDuplicate) || errors.Is(err, ErrForeignKey) || errors.Is(err, ErrNotFound) {
+		return err
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
 	le := strings.ToLower(err.Error())
+	// Checked before duplicates: Postgres foreign key violation (23503),
+	// MySQL 1451/1452, SQLite "FOREIGN KEY constraint failed".
+	if strings.Contains(le, "foreign key") || strings.Contains(le, "23503") || strings.Contains(le, "1451") || strings.Contains(le, "1452") {
+		return fmt.Errorf("%w: %w", ErrForeignKey, err)
+	}
 	// MySQL duplicate entry, Postgres unique violation (23505), SQLite unique constraint
 	if strings.Contains(le, "duplicate") || strings.Contains(le, "unique") || strings.Contains(le, "23505") || strings.Contains(le, "1062") {
-		return ErrDuplicate
+		return fmt.Errorf("%w: %w", ErrDuplicate, err)
 	}
 	return err
 }
@@ -4,7 +4,10 @@
 package db
 
 import (
+	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -44,3 +47,61 @@ func TestMapDBError_NonDuplicatePassthrough(t *testing.T) {
 		t.Fatalf("expected original error to be returned unchanged, got: %v", mapped)
 	}
 }
+
+// driverErrors are representative constraint messages of each supported
+// backend.
+var driverErrors = []struct {
+	backend string
+	msg     string
+	want    error
+}{
+	{"sqlite", "constraint failed: UNIQUE constraint failed: accounts.username, accounts.hostname (2067)", ErrDuplicate},
+	{"sqlite", "constraint failed: FOREIGN KEY constraint failed (787)", ErrForeignKey},
+	{"postgres", `ERROR: duplicate key value violates unique constraint "public_keys_comment_key" (SQLSTATE 23505)`, ErrDuplicate},
+	{"postgres", `ERROR: insert or update on table "account_keys" violates foreign key constraint "account_keys_key_id_fkey" (SQLSTATE 23503)`, ErrForeignKey},
+	{"mysql", "Error 1062 (23000): Duplicate entry 'alice-host1' for key 'accounts.username'", ErrDuplicate},
+	{"mysql", "Error 1452 (23000): Cannot add or update a child row: a foreign key constraint fails", ErrForeignKey},
+	{"mysql", "Error 1451 (23000): Cannot delete or update a parent row: a foreign key constraint fails", ErrForeignKey},
+}
+
+func TestMapDBError_DriverConstraintViolations(t *testing.T) {
+	for _, tc := range driverErrors {
+		driverErr := errors.New(tc.msg)
+		err := MapDBError(driverErr)
+		if !errors.Is(err, tc.want) {
+			t.Fatalf("%s: expected %v for %q, got %v", tc.backend, tc.want, tc.msg, err)
+		}
+		if !errors.Is(err, driverErr) {
+			t.Fatalf("%s: expected the driver error to stay in the chain, got %v", tc.backend, err)
+		}
+		if MapDBError(err) != err {
+			t.Fatalf("%s: expected an already mapped error to be returned as is", tc.backend)
+		}
+	}
+}
+
+func TestMapDBError_NoRowsIsNotFound(t *testing.T) {
+	err := MapDBError(fmt.Errorf("scan account: %w", sql.ErrNoRows))
+	if !errors.Is(err, ErrNotFound) || !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected ErrNotFound wrapping sql.ErrNoRows, got %v", err)
+	}
+}
+
+func TestSQLite_TypedErrors(t *testing.T) {
+	_ = newTestDB(t)
+	mgr := DefaultAccountManager()
+	if mgr == nil {
+		t.Fatalf("no account manager available")
+	}
+	if _, err := mgr.AddAccount("alice", "host1.example", "", ""); err != nil {
+		t.Fatalf("AddAccount: %v", err)
+	}
+	_, err := mgr.AddAccount("alice", "host1.example", "", "")
+	if !errors.Is(err, ErrDuplicate) || !strings.Contains(strings.ToLower(err.Error()), "unique") {
+		t.Fatalf("expected ErrDuplicate carrying the driver message, got %v", err)
+	}
+	err = ToggleAccountStatus(9999)
+	if !errors.Is(err, ErrNotFound) || err.Error() != "account not found: 9999" {
+		t.Fatalf("expected ErrNotFound for a missing account, got %v", err)
+	}
+}
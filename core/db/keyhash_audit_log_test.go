@@ -93,7 +93,7 @@ func TestMaybeMarkAccountDirtyTx_InsertsAuditAndMarksDirty(t *testing.T) {
 		if r.Action != "ACCOUNT_KEY_HASH_UPDATED" {
 			t.Fatalf("expected action ACCOUNT_KEY_HASH_UPDATED, got %s", r.Action)
 		}
-		if !strings.Contains(r.Details, "account:") || !strings.Contains(r.Details, "key_hash:") {
+		if !strings.Contains(r.Details, "account_id:") || !strings.Contains(r.Details, "key_hash:") {
 			t.Fatalf("unexpected audit details: %s", r.Details)
 		}
 		// Ensure the stored key_hash matches the one recorded in audit details
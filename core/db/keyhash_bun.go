@@ -34,7 +34,7 @@ func computeAccountKeyHashTx(ctx context.Context, q execRawProvider, accountID i
 
 	// Global keys
 	var gks []PublicKeyModel
-	if err := QueryRawInto(ctx, q, &gks, "SELECT id, algorithm, key_data, comment, expires_at, is_global FROM public_keys WHERE is_global = 1 ORDER BY comment"); err != nil {
+	if err := QueryRawInto(ctx, q, &gks, "SELECT id, algorithm, key_data, comment, expires_at, is_global, is_ca, principals FROM public_keys WHERE is_global = 1 ORDER BY comment"); err != nil {
 		return "", err
 	}
 	globals := make([]model.PublicKey, 0, len(gks))
@@ -44,7 +44,7 @@ func computeAccountKeyHashTx(ctx context.Context, q execRawProvider, accountID i
 
 	// Account keys
 	var aks []PublicKeyModel
-	if err := QueryRawInto(ctx, q, &aks, "SELECT p.id, p.algorithm, p.key_data, p.comment, p.expires_at, p.is_global FROM public_keys p JOIN account_keys ak ON ak.key_id = p.id WHERE ak.account_id = ? ORDER BY p.comment", accountID); err != nil {
+	if err := QueryRawInto(ctx, q, &aks, "SELECT p.id, p.algorithm, p.key_data, p.comment, p.expires_at, p.is_global, p.is_ca, p.principals, ak.options FROM public_keys p JOIN account_keys ak ON ak.key_id = p.id WHERE ak.account_id = ? ORDER BY p.comment", accountID); err != nil {
 		return "", err
 	}
 	accountKeys := make([]model.PublicKey, 0, len(aks))
@@ -84,17 +84,11 @@ func computeAccountKeyHashTx(ctx context.Context, q execRawProvider, accountID i
 		comment string
 	}
 	allMap := make(map[int]keyInfo)
-	formatKey := func(k model.PublicKey) string {
-		if k.Comment != "" {
-			return fmt.Sprintf("%s %s %s", k.Algorithm, k.KeyData, k.Comment)
-		}
-		return fmt.Sprintf("%s %s", k.Algorithm, k.KeyData)
-	}
 	for _, k := range globals {
-		allMap[k.ID] = keyInfo{id: k.ID, line: formatKey(k), comment: k.Comment}
+		allMap[k.ID] = keyInfo{id: k.ID, line: k.AuthorizedKeysLine(), comment: k.Comment}
 	}
 	for _, k := range accountKeys {
-		allMap[k.ID] = keyInfo{id: k.ID, line: formatKey(k), comment: k.Comment}
+		allMap[k.ID] = keyInfo{id: k.ID, line: k.AuthorizedKeysLine(), comment: k.Comment}
 	}
 	var sorted []keyInfo
 	for _, v := range allMap {
@@ -137,7 +131,7 @@ func MaybeMarkAccountDirtyTx(ctx context.Context, q execRawProvider, accountID i
 			return MapDBError(err)
 		}
 		// Record audit entry with the new fingerprint instead of storing/printing full authorized_keys
-		details := fmt.Sprintf("account:%d key_hash:%s", accountID, newHash)
+		details := fmt.Sprintf("%s key_hash:%s", model.AccountRef(accountID), newHash)
 		if _, err := ExecRaw(ctx, q, "INSERT INTO audit_log (username, action, details) VALUES (?, ?, ?)", "system", "ACCOUNT_KEY_HASH_UPDATED", details); err != nil {
 			return MapDBError(err)
 		}
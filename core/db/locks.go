@@ -0,0 +1,180 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"time"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+)
+
+// ErrLockNotHeld is returned by ReleaseLock when this store does not hold
+// the named lock.
+var ErrLockNotHeld = errors.New("lock not held")
+
+// AcquireLock takes the named database-wide lock without waiting and reports
+// whether it was acquired. Locks are not reentrant: a second acquire fails
+// while the first is held, even from the same store.
+//
+// On Postgres a session advisory lock is taken on a dedicated connection; it
+// is released by ReleaseLock or when the connection dies, so ttl is not used.
+// Other backends record the lock in the locks table, where it can be taken
+// over once ttl has passed without a release.
+func (s *BunStore) AcquireLock(name string, ttl time.Duration) (bool, error) {
+	if s.bun.Dialect().Name() == dialect.PG {
+		return s.acquireAdvisoryLock(name)
+	}
+	if ttl <= 0 {
+		return false, fmt.Errorf("lock %q: ttl must be positive", name)
+	}
+	return AcquireLockBun(s.bun, name, s.lockHolder(), ttl)
+}
+
+// ReleaseLock releases a lock taken with AcquireLock.
+func (s *BunStore) ReleaseLock(name string) error {
+	if s.bun.Dialect().Name() == dialect.PG {
+		return s.releaseAdvisoryLock(name)
+	}
+	return ReleaseLockBun(s.bun, name, s.lockHolder())
+}
+
+// RenewLock pushes the expiry of a lock taken with AcquireLock to ttl from
+// now, so long operations keep it. It returns ErrLockNotHeld when the lock
+// was released or taken over. Postgres advisory locks do not expire, so
+// there it only checks that the lock is held.
+func (s *BunStore) RenewLock(name string, ttl time.Duration) error {
+	if s.bun.Dialect().Name() == dialect.PG {
+		s.lockMu.Lock()
+		_, held := s.advisory[name]
+		s.lockMu.Unlock()
+		if !held {
+			return fmt.Errorf("renew lock %q: %w", name, ErrLockNotHeld)
+		}
+		return nil
+	}
+	if ttl <= 0 {
+		return fmt.Errorf("lock %q: ttl must be positive", name)
+	}
+	return RenewLockBun(s.bun, name, s.lockHolder(), ttl)
+}
+
+// lockHolder identifies this store in the locks table so only the holder
+// can release a lock.
+func (s *BunStore) lockHolder() string {
+	s.lockMu.Lock()
+	defer s.lockMu.Unlock()
+	if s.holder == "" {
+		host, _ := os.Hostname()
+		b := make([]byte, 4)
+		_, _ = rand.Read(b)
+		s.holder = fmt.Sprintf("%s:%d:%s", host, os.Getpid(), hex.EncodeToString(b))
+	}
+	return s.holder
+}
+
+// AcquireLockBun records holder as the owner of the named lock for ttl. An
+// expired lock is taken over.
+func AcquireLockBun(bdb *bun.DB, name, holder string, ttl time.Duration) (bool, error) {
+	ctx := context.Background()
+	acquired := false
+	err := WithTx(ctx, bdb, func(ctx context.Context, tx bun.Tx) error {
+		now := time.Now()
+		if _, err := ExecRaw(ctx, tx, "DELETE FROM locks WHERE name = ? AND expires_at < ?", name, now.UnixMilli()); err != nil {
+			return MapDBError(err)
+		}
+		res, err := ExecRaw(ctx, tx, insertIgnoreSQL(tx.Dialect().Name(), "locks", "name, holder, expires_at", 3), name, holder, now.Add(ttl).UnixMilli())
+		if err != nil {
+			return MapDBError(err)
+		}
+		n, _ := res.RowsAffected()
+		acquired = n > 0
+		return nil
+	})
+	return acquired, err
+}
+
+// RenewLockBun moves the expiry of the named lock to ttl from now if holder
+// still owns it.
+func RenewLockBun(bdb *bun.DB, name, holder string, ttl time.Duration) error {
+	res, err := ExecRaw(context.Background(), bdb, "UPDATE locks SET expires_at = ? WHERE name = ? AND holder = ?", time.Now().Add(ttl).UnixMilli(), name, holder)
+	if err != nil {
+		return MapDBError(err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("renew lock %q: %w", name, ErrLockNotHeld)
+	}
+	return nil
+}
+
+// ReleaseLockBun removes the named lock if holder owns it.
+func ReleaseLockBun(bdb *bun.DB, name, holder string) error {
+	res, err := ExecRaw(context.Background(), bdb, "DELETE FROM locks WHERE name = ? AND holder = ?", name, holder)
+	if err != nil {
+		return MapDBError(err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("release lock %q: %w", name, ErrLockNotHeld)
+	}
+	return nil
+}
+
+// advisoryLockKey maps a lock name to the bigint key of a Postgres advisory
+// lock.
+func advisoryLockKey(name string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+	return int64(h.Sum64())
+}
+
+func (s *BunStore) acquireAdvisoryLock(name string) (bool, error) {
+	s.lockMu.Lock()
+	defer s.lockMu.Unlock()
+	if _, held := s.advisory[name]; held {
+		return false, nil
+	}
+	ctx := context.Background()
+	// Advisory locks belong to the session, so the connection must stay
+	// checked out of the pool until the lock is released.
+	conn, err := s.bun.Conn(ctx)
+	if err != nil {
+		return false, MapDBError(err)
+	}
+	var ok bool
+	if err := conn.NewRaw("SELECT pg_try_advisory_lock(?)", advisoryLockKey(name)).Scan(ctx, &ok); err != nil {
+		_ = conn.Close()
+		return false, MapDBError(err)
+	}
+	if !ok {
+		_ = conn.Close()
+		return false, nil
+	}
+	if s.advisory == nil {
+		s.advisory = make(map[string]bun.Conn)
+	}
+	s.advisory[name] = conn
+	return true, nil
+}
+
+func (s *BunStore) releaseAdvisoryLock(name string) error {
+	s.lockMu.Lock()
+	conn, held := s.advisory[name]
+	delete(s.advisory, name)
+	s.lockMu.Unlock()
+	if !held {
+		return fmt.Errorf("release lock %q: %w", name, ErrLockNotHeld)
+	}
+	defer func() { _ = conn.Close() }()
+	if _, err := ExecRaw(context.Background(), conn, "SELECT pg_advisory_unlock(?)", advisoryLockKey(name)); err != nil {
+		return MapDBError(err)
+	}
+	return nil
+}
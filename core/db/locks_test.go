@@ -0,0 +1,79 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package db
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAcquireLock_SecondAcquireFailsWhileHeld(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		ok, err := s.AcquireLock("rotate-key", time.Minute)
+		if err != nil || !ok {
+			t.Fatalf("first acquire: ok=%v err=%v", ok, err)
+		}
+		ok, err = s.AcquireLock("rotate-key", time.Minute)
+		if err != nil || ok {
+			t.Fatalf("expected second acquire to fail while held, got ok=%v err=%v", ok, err)
+		}
+		// A different instance sharing the database is refused as well.
+		if ok, err := AcquireLockBun(s.BunDB(), "rotate-key", "other-instance", time.Minute); err != nil || ok {
+			t.Fatalf("expected other holder to be refused, got ok=%v err=%v", ok, err)
+		}
+		// Other lock names are independent.
+		if ok, err := s.AcquireLock("full-restore", time.Minute); err != nil || !ok {
+			t.Fatalf("expected independent lock, got ok=%v err=%v", ok, err)
+		}
+
+		if err := ReleaseLockBun(s.BunDB(), "rotate-key", "other-instance"); !errors.Is(err, ErrLockNotHeld) {
+			t.Fatalf("expected ErrLockNotHeld for a foreign release, got %v", err)
+		}
+		if err := s.ReleaseLock("rotate-key"); err != nil {
+			t.Fatalf("ReleaseLock: %v", err)
+		}
+		if ok, err := s.AcquireLock("rotate-key", time.Minute); err != nil || !ok {
+			t.Fatalf("expected acquire after release, got ok=%v err=%v", ok, err)
+		}
+	})
+}
+
+func TestAcquireLock_ExpiredLockIsTakenOver(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		if ok, err := AcquireLockBun(s.BunDB(), "rotate-key", "crashed-instance", time.Millisecond); err != nil || !ok {
+			t.Fatalf("acquire: ok=%v err=%v", ok, err)
+		}
+		time.Sleep(5 * time.Millisecond)
+		if ok, err := s.AcquireLock("rotate-key", time.Minute); err != nil || !ok {
+			t.Fatalf("expected expired lock to be taken over, got ok=%v err=%v", ok, err)
+		}
+		if _, err := s.AcquireLock("x", 0); err == nil {
+			t.Fatal("expected error for non-positive ttl")
+		}
+	})
+}
+
+func TestRenewLock_KeepsLockPastTTL(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		if ok, err := s.AcquireLock("full-restore", 20*time.Millisecond); err != nil || !ok {
+			t.Fatalf("acquire: ok=%v err=%v", ok, err)
+		}
+		if err := s.RenewLock("full-restore", time.Minute); err != nil {
+			t.Fatalf("RenewLock: %v", err)
+		}
+		time.Sleep(40 * time.Millisecond)
+		if ok, err := AcquireLockBun(s.BunDB(), "full-restore", "other-instance", time.Minute); err != nil || ok {
+			t.Fatalf("expected renewed lock to stay held, got ok=%v err=%v", ok, err)
+		}
+
+		// Once another instance took over an expired lock, renewing fails.
+		if ok, err := AcquireLockBun(s.BunDB(), "rotate-key", "crashed-instance", time.Millisecond); err != nil || !ok {
+			t.Fatalf("acquire: ok=%v err=%v", ok, err)
+		}
+		if err := s.RenewLock("rotate-key", time.Minute); !errors.Is(err, ErrLockNotHeld) {
+			t.Fatalf("expected ErrLockNotHeld for a lock held by another instance, got %v", err)
+		}
+	})
+}
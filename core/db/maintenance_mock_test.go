@@ -4,9 +4,11 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 )
@@ -31,7 +33,7 @@ func TestRunDBMaintenance_Sqlite_WithMock_Success(t *testing.T) {
 	rows := sqlmock.NewRows([]string{"integrity_check"}).AddRow("ok")
 	mock.ExpectQuery("PRAGMA integrity_check").WillReturnRows(rows)
 
-	if err := RunDBMaintenance("sqlite", "whatever"); err != nil {
+	if err := RunDBMaintenance(context.Background(), "sqlite", "whatever"); err != nil {
 		t.Fatalf("expected RunDBMaintenance success, got %v", err)
 	}
 
@@ -40,6 +42,32 @@ func TestRunDBMaintenance_Sqlite_WithMock_Success(t *testing.T) {
 	}
 }
 
+func TestRunDBMaintenance_Sqlite_CancelInterruptsVacuum(t *testing.T) {
+	dbMock, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer func() { _ = dbMock.Close() }()
+
+	orig := sqlOpenFunc
+	sqlOpenFunc = func(driverName, dsn string) (*sql.DB, error) { return dbMock, nil }
+	defer func() { sqlOpenFunc = orig }()
+
+	mock.ExpectExec("PRAGMA optimize").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("VACUUM").WillDelayFor(10 * time.Second).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = RunDBMaintenance(ctx, "sqlite", "whatever")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the VACUUM to be interrupted by the deadline, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("VACUUM was not interrupted, took %v", elapsed)
+	}
+}
+
 func TestRunDBMaintenance_Sqlite_WithMock_Failure(t *testing.T) {
 	dbMock, mock, err := sqlmock.New()
 	if err != nil {
@@ -54,7 +82,7 @@ func TestRunDBMaintenance_Sqlite_WithMock_Failure(t *testing.T) {
 	// Simulate PRAGMA optimize failing
 	mock.ExpectExec("PRAGMA optimize").WillReturnError(errors.New("optimize fail"))
 
-	if err := RunDBMaintenance("sqlite", "whatever"); err == nil {
+	if err := RunDBMaintenance(context.Background(), "sqlite", "whatever"); err == nil {
 		t.Fatalf("expected error when PRAGMA optimize fails")
 	}
 }
@@ -72,7 +100,7 @@ func TestRunDBMaintenance_Postgres_WithMock_Success(t *testing.T) {
 
 	mock.ExpectExec("VACUUM ANALYZE").WillReturnResult(sqlmock.NewResult(0, 0))
 
-	if err := RunDBMaintenance("postgres", "dsn"); err != nil {
+	if err := RunDBMaintenance(context.Background(), "postgres", "dsn"); err != nil {
 		t.Fatalf("expected postgres maintenance to succeed, got: %v", err)
 	}
 
@@ -97,7 +125,7 @@ func TestRunDBMaintenance_MySQL_WithMock_Success(t *testing.T) {
 	mock.ExpectQuery("SHOW TABLES").WillReturnRows(rows)
 	mock.ExpectExec("OPTIMIZE TABLE users").WillReturnResult(sqlmock.NewResult(0, 0))
 
-	if err := RunDBMaintenance("mysql", "dsn"); err != nil {
+	if err := RunDBMaintenance(context.Background(), "mysql", "dsn"); err != nil {
 		t.Fatalf("expected mysql maintenance to succeed, got: %v", err)
 	}
 
@@ -119,7 +147,7 @@ func TestRunDBMaintenance_Postgres_WithMock_Failure(t *testing.T) {
 
 	mock.ExpectExec("VACUUM ANALYZE").WillReturnError(errors.New("vacuum fail"))
 
-	if err := RunDBMaintenance("postgres", "dsn"); err == nil {
+	if err := RunDBMaintenance(context.Background(), "postgres", "dsn"); err == nil {
 		t.Fatalf("expected error when VACUUM ANALYZE fails")
 	}
 }
@@ -139,7 +167,7 @@ func TestRunDBMaintenance_MySQL_WithMock_Failure(t *testing.T) {
 	mock.ExpectQuery("SHOW TABLES").WillReturnRows(rows)
 	mock.ExpectExec("OPTIMIZE TABLE users").WillReturnError(errors.New("optimize fail"))
 
-	if err := RunDBMaintenance("mysql", "dsn"); err == nil {
+	if err := RunDBMaintenance(context.Background(), "mysql", "dsn"); err == nil {
 		t.Fatalf("expected error when OPTIMIZE TABLE fails")
 	}
 }
@@ -4,6 +4,7 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
@@ -14,14 +15,14 @@ import (
 
 func TestRunDBMaintenance_SqliteSuccess(t *testing.T) {
 	// in-memory sqlite should succeed
-	if err := RunDBMaintenance("sqlite", ":memory:"); err != nil {
+	if err := RunDBMaintenance(context.Background(), "sqlite", ":memory:"); err != nil {
 		t.Fatalf("expected sqlite maintenance to succeed, got: %v", err)
 	}
 }
 
 func TestRunDBMaintenance_UnknownDriver(t *testing.T) {
 	// an unknown driver name should cause an error (sql.Open fails)
-	if err := RunDBMaintenance("no-such-driver", "dsn"); err == nil {
+	if err := RunDBMaintenance(context.Background(), "no-such-driver", "dsn"); err == nil {
 		t.Fatalf("expected error for unknown driver")
 	}
 }
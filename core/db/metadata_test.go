@@ -0,0 +1,73 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package db
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+func TestMetadataBun_RoundTripThroughBackup(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		id, err := AddAccountBun(s.BunDB(), "u", "h", "", "")
+		if err != nil {
+			t.Fatalf("AddAccountBun: %v", err)
+		}
+		pk, err := AddPublicKeyAndGetModelBun(s.BunDB(), "ssh-ed25519", "AAAAmeta", "meta-key", false, time.Time{})
+		if err != nil || pk == nil {
+			t.Fatalf("AddPublicKeyAndGetModelBun: %v", err)
+		}
+		if acc, _ := s.GetAccount(id); acc.Metadata != nil {
+			t.Fatalf("expected no metadata on a new account, got %v", acc.Metadata)
+		}
+
+		accMeta := map[string]string{
+			"owner":  "alice@example.com",
+			"ticket": "https://tracker.example/browse?id=OPS-1&x=y",
+			"note":   "line1\nline2 'single' \"double\" ünïcode; DROP TABLE accounts;--",
+		}
+		keyMeta := map[string]string{"provisioned": "2026-01-02", "key=with=equals": "v"}
+		if err := s.SetAccountMetadata(id, accMeta); err != nil {
+			t.Fatalf("SetAccountMetadata: %v", err)
+		}
+		if err := SetPublicKeyMetadataBun(s.BunDB(), pk.ID, keyMeta); err != nil {
+			t.Fatalf("SetPublicKeyMetadataBun: %v", err)
+		}
+		acc, _ := s.GetAccount(id)
+		if !reflect.DeepEqual(acc.Metadata, accMeta) {
+			t.Fatalf("account metadata not persisted: %v", acc.Metadata)
+		}
+		if got, _ := GetPublicKeyByIDBun(s.BunDB(), pk.ID); got == nil || !reflect.DeepEqual(got.Metadata, keyMeta) {
+			t.Fatalf("key metadata not persisted: %+v", got)
+		}
+
+		backup, err := s.ExportDataForBackup()
+		if err != nil {
+			t.Fatalf("ExportDataForBackup: %v", err)
+		}
+		if err := s.ImportDataFromBackup(&model.BackupData{SchemaVersion: backup.SchemaVersion}); err != nil {
+			t.Fatalf("wipe: %v", err)
+		}
+		if err := s.ImportDataFromBackup(backup); err != nil {
+			t.Fatalf("ImportDataFromBackup: %v", err)
+		}
+		acc, _ = s.GetAccount(id)
+		if acc == nil || !reflect.DeepEqual(acc.Metadata, accMeta) {
+			t.Fatalf("account metadata lost in restore: %+v", acc)
+		}
+		if got, _ := GetPublicKeyByIDBun(s.BunDB(), pk.ID); got == nil || !reflect.DeepEqual(got.Metadata, keyMeta) {
+			t.Fatalf("key metadata lost in restore: %+v", got)
+		}
+
+		if err := s.SetAccountMetadata(id, nil); err != nil {
+			t.Fatalf("clear metadata: %v", err)
+		}
+		if acc, _ := s.GetAccount(id); acc.Metadata != nil {
+			t.Fatalf("expected metadata cleared, got %v", acc.Metadata)
+		}
+	})
+}
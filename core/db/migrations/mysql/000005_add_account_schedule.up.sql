@@ -0,0 +1,8 @@
+-- Copyright (c) 2026 Keymaster Team
+-- Keymaster - SSH key management system
+-- This source code is licensed under the MIT license found in the LICENSE file.
+
+-- Let the scheduler disable an account at disable_at and re-enable it at
+-- enable_at. NULL means nothing is scheduled.
+ALTER TABLE accounts ADD COLUMN disable_at DATETIME;
+ALTER TABLE accounts ADD COLUMN enable_at DATETIME;
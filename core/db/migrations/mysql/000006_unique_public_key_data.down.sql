@@ -0,0 +1 @@
+DROP INDEX idx_public_keys_key_data ON public_keys;
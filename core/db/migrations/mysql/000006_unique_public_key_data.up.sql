@@ -0,0 +1,21 @@
+-- Copyright (c) 2026 Keymaster Team
+-- Keymaster - SSH key management system
+-- This source code is licensed under the MIT license found in the LICENSE file.
+
+-- Store each public key only once. Existing duplicates are merged into the
+-- oldest row: it inherits their assignments and global flag before they are
+-- removed. Derived tables avoid MySQL's restriction on selecting from the
+-- table being modified.
+UPDATE public_keys p
+  JOIN (SELECT key_data FROM public_keys WHERE is_global = TRUE GROUP BY key_data) g ON g.key_data = p.key_data
+  SET p.is_global = TRUE;
+INSERT IGNORE INTO account_keys (account_id, key_id)
+  SELECT ak.account_id, keep.id
+  FROM account_keys ak
+  JOIN public_keys p ON p.id = ak.key_id
+  JOIN (SELECT MIN(id) AS id, key_data FROM public_keys GROUP BY key_data) keep ON keep.key_data = p.key_data;
+DELETE FROM account_keys WHERE key_id NOT IN (SELECT id FROM (SELECT MIN(id) AS id FROM public_keys GROUP BY key_data) keep);
+DELETE FROM public_keys WHERE id NOT IN (SELECT id FROM (SELECT MIN(id) AS id FROM public_keys GROUP BY key_data) keep);
+-- key_data is TEXT, so the index covers a prefix long enough to be unique
+-- for every supported key type.
+CREATE UNIQUE INDEX idx_public_keys_key_data ON public_keys (key_data(255));
@@ -0,0 +1,5 @@
+-- Copyright (c) 2026 Keymaster Team
+-- Keymaster - SSH key management system
+-- This source code is licensed under the MIT license found in the LICENSE file.
+
+ALTER TABLE system_keys DROP COLUMN created_at;
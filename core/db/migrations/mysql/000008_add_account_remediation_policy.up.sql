@@ -0,0 +1,7 @@
+-- Copyright (c) 2026 Keymaster Team
+-- Keymaster - SSH key management system
+-- This source code is licensed under the MIT license found in the LICENSE file.
+
+-- How remediating audits react to drift on an account: auto, alert or
+-- ignore. NULL is treated as alert.
+ALTER TABLE accounts ADD COLUMN remediation_policy VARCHAR(16);
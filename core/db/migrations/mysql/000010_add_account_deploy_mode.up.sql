@@ -0,0 +1,10 @@
+-- Copyright (c) 2026 Keymaster Team
+-- Keymaster - SSH key management system
+-- This source code is licensed under the MIT license found in the LICENSE file.
+
+-- How deploys write authorized_keys: replace or append-only. NULL is
+-- treated as replace.
+ALTER TABLE accounts ADD COLUMN deploy_mode VARCHAR(16);
+-- Newline-separated identities of the keys written by the last deploy, so
+-- append-only deploys only remove keys Keymaster put there.
+ALTER TABLE accounts ADD COLUMN deployed_keys TEXT;
@@ -0,0 +1,6 @@
+-- Copyright (c) 2026 Keymaster Team
+-- Keymaster - SSH key management system
+-- This source code is licensed under the MIT license found in the LICENSE file.
+
+ALTER TABLE public_keys DROP COLUMN metadata;
+ALTER TABLE accounts DROP COLUMN metadata;
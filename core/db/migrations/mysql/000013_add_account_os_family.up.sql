@@ -0,0 +1,8 @@
+-- Copyright (c) 2026 Keymaster Team
+-- Keymaster - SSH key management system
+-- This source code is licensed under the MIT license found in the LICENSE file.
+
+-- Remote OS family (posix, windows, windows-admin) deciding where
+-- authorized_keys lives and how permissions are handled. NULL is treated as
+-- posix.
+ALTER TABLE accounts ADD COLUMN os_family VARCHAR(16);
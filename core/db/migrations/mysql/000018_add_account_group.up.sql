@@ -0,0 +1,7 @@
+-- Copyright (c) 2026 Keymaster Team
+-- Keymaster - SSH key management system
+-- This source code is licensed under the MIT license found in the LICENSE file.
+
+-- Account group, the exact-membership alternative to tags used to select
+-- accounts for deploys and audits. NULL means the account is in no group.
+ALTER TABLE accounts ADD COLUMN account_group VARCHAR(255);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_public_keys_key_data;
@@ -0,0 +1,16 @@
+-- Copyright (c) 2026 Keymaster Team
+-- Keymaster - SSH key management system
+-- This source code is licensed under the MIT license found in the LICENSE file.
+
+-- Store each public key only once. Existing duplicates are merged into the
+-- oldest row: it inherits their assignments and global flag before they are
+-- removed.
+UPDATE public_keys SET is_global = TRUE
+  WHERE EXISTS (SELECT 1 FROM public_keys d WHERE d.key_data = public_keys.key_data AND d.is_global);
+INSERT INTO account_keys (account_id, key_id)
+  SELECT ak.account_id, (SELECT MIN(k.id) FROM public_keys k WHERE k.key_data = p.key_data)
+  FROM account_keys ak JOIN public_keys p ON p.id = ak.key_id
+  ON CONFLICT DO NOTHING;
+DELETE FROM account_keys WHERE key_id NOT IN (SELECT MIN(id) FROM public_keys GROUP BY key_data);
+DELETE FROM public_keys WHERE id NOT IN (SELECT MIN(id) FROM public_keys GROUP BY key_data);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_public_keys_key_data ON public_keys (key_data);
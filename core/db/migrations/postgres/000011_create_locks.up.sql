@@ -0,0 +1,13 @@
+-- Copyright (c) 2026 Keymaster Team
+-- Keymaster - SSH key management system
+-- This source code is licensed under the MIT license found in the LICENSE file.
+
+-- Named locks shared by every Keymaster instance using this database. A lock
+-- whose expires_at (unix milliseconds) has passed may be taken over.
+-- Postgres takes session advisory locks instead; the table keeps the schema
+-- identical across backends.
+CREATE TABLE IF NOT EXISTS locks (
+    name TEXT NOT NULL PRIMARY KEY,
+    holder TEXT NOT NULL,
+    expires_at BIGINT NOT NULL
+);
@@ -0,0 +1,7 @@
+-- Copyright (c) 2026 Keymaster Team
+-- Keymaster - SSH key management system
+-- This source code is licensed under the MIT license found in the LICENSE file.
+
+-- Time of the last audit that found the host clean. Cleared when an audit
+-- fails, so only clean hosts are skipped by audit --skip-recent.
+ALTER TABLE accounts ADD COLUMN last_audit_at TIMESTAMP WITH TIME ZONE;
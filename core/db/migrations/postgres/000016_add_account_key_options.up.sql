@@ -0,0 +1,7 @@
+-- Copyright (c) 2026 Keymaster Team
+-- Keymaster - SSH key management system
+-- This source code is licensed under the MIT license found in the LICENSE file.
+
+-- Per-assignment authorized_keys options (e.g. command="rsync ..."), rendered
+-- in front of the key for this account only. NULL means no options.
+ALTER TABLE account_keys ADD COLUMN options TEXT;
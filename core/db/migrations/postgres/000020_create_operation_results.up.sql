@@ -0,0 +1,16 @@
+-- Copyright (c) 2026 Keymaster Team
+-- Keymaster - SSH key management system
+-- This source code is licensed under the MIT license found in the LICENSE file.
+
+-- One row per deploy or audit of an account, kept for reliability trends.
+-- account_id carries no foreign key so history outlives deleted accounts.
+CREATE TABLE IF NOT EXISTS operation_results (
+    id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    account_id INTEGER NOT NULL,
+    operation TEXT NOT NULL,
+    success BOOLEAN NOT NULL,
+    error_category TEXT NOT NULL DEFAULT '',
+    duration_ms BIGINT NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_operation_results_account ON operation_results(account_id, recorded_at);
@@ -0,0 +1,7 @@
+-- Copyright (c) 2026 Keymaster Team
+-- Keymaster - SSH key management system
+-- This source code is licensed under the MIT license found in the LICENSE file.
+
+-- Comma-separated principals deployed to ~/.ssh/authorized_principals; NULL
+-- when the account does not manage that file.
+ALTER TABLE accounts ADD COLUMN authorized_principals TEXT;
@@ -0,0 +1,8 @@
+-- Copyright (c) 2026 Keymaster Team
+-- Keymaster - SSH key management system
+-- This source code is licensed under the MIT license found in the LICENSE file.
+
+-- Track when each system key was created. Keys that predate this column
+-- are stamped with the migration time.
+ALTER TABLE system_keys ADD COLUMN created_at DATETIME;
+UPDATE system_keys SET created_at = CURRENT_TIMESTAMP WHERE created_at IS NULL;
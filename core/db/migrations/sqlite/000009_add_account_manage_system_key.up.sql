@@ -0,0 +1,7 @@
+-- Copyright (c) 2026 Keymaster Team
+-- Keymaster - SSH key management system
+-- This source code is licensed under the MIT license found in the LICENSE file.
+
+-- Whether the Keymaster system key is rendered into the account's
+-- authorized_keys. Hosts co-managed by another tool turn it off.
+ALTER TABLE accounts ADD COLUMN manage_system_key BOOLEAN NOT NULL DEFAULT 1;
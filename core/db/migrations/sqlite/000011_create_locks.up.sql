@@ -0,0 +1,11 @@
+-- Copyright (c) 2026 Keymaster Team
+-- Keymaster - SSH key management system
+-- This source code is licensed under the MIT license found in the LICENSE file.
+
+-- Named locks shared by every Keymaster instance using this database. A lock
+-- whose expires_at (unix milliseconds) has passed may be taken over.
+CREATE TABLE IF NOT EXISTS locks (
+    name TEXT NOT NULL PRIMARY KEY,
+    holder TEXT NOT NULL,
+    expires_at INTEGER NOT NULL
+);
@@ -0,0 +1,8 @@
+-- Copyright (c) 2026 Keymaster Team
+-- Keymaster - SSH key management system
+-- This source code is licensed under the MIT license found in the LICENSE file.
+
+-- Free-form key/value annotations stored as a JSON object. NULL means no
+-- metadata.
+ALTER TABLE accounts ADD COLUMN metadata TEXT;
+ALTER TABLE public_keys ADD COLUMN metadata TEXT;
@@ -0,0 +1,9 @@
+-- Copyright (c) 2026 Keymaster Team
+-- Keymaster - SSH key management system
+-- This source code is licensed under the MIT license found in the LICENSE file.
+
+-- SSH certificate authority keys are rendered with the cert-authority
+-- option. principals holds the comma-separated principals="..." restriction;
+-- NULL means unrestricted.
+ALTER TABLE public_keys ADD COLUMN is_ca BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE public_keys ADD COLUMN principals TEXT;
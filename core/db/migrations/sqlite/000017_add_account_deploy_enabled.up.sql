@@ -0,0 +1,7 @@
+-- Copyright (c) 2026 Keymaster Team
+-- Keymaster - SSH key management system
+-- This source code is licensed under the MIT license found in the LICENSE file.
+
+-- Whether deploys may write to the account. Turning it off keeps the account
+-- in audits while excluding it from deploys.
+ALTER TABLE accounts ADD COLUMN deploy_enabled BOOLEAN NOT NULL DEFAULT 1;
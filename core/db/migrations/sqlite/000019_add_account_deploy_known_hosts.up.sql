@@ -0,0 +1,7 @@
+-- Copyright (c) 2026 Keymaster Team
+-- Keymaster - SSH key management system
+-- This source code is licensed under the MIT license found in the LICENSE file.
+
+-- Whether deploys also write a known_hosts file, rendered from the stored
+-- known host keys, so the host can trust onward SSH targets.
+ALTER TABLE accounts ADD COLUMN deploy_known_hosts BOOLEAN NOT NULL DEFAULT 0;
@@ -0,0 +1,7 @@
+-- Copyright (c) 2026 Keymaster Team
+-- Keymaster - SSH key management system
+-- This source code is licensed under the MIT license found in the LICENSE file.
+
+-- Comma-separated IDs of the global keys not rendered for the account; NULL
+-- when the account receives every global key.
+ALTER TABLE accounts ADD COLUMN excluded_global_keys TEXT;
@@ -4,6 +4,7 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"testing"
 
@@ -55,7 +56,84 @@ func TestRunMigrationsSqlite(t *testing.T) {
 
 func TestRunDBMaintenanceSqlite_Smoke(t *testing.T) {
 	dsn := "file:test_maint?mode=memory&cache=shared"
-	if err := RunDBMaintenance("sqlite", dsn); err != nil {
+	if err := RunDBMaintenance(context.Background(), "sqlite", dsn); err != nil {
 		t.Fatalf("RunDBMaintenance failed: %v", err)
 	}
 }
+
+func TestMigration_UniquePublicKeyData_MergesDuplicates(t *testing.T) {
+	dbConn, err := sql.Open("sqlite", "file:test_key_data_migration?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	defer func() { _ = dbConn.Close() }()
+	if err := RunMigrations(dbConn, "sqlite"); err != nil {
+		t.Fatalf("RunMigrations failed: %v", err)
+	}
+
+	// Rewind to the schema before 000006 and seed duplicate key material.
+	for _, stmt := range []string{
+		"PRAGMA foreign_keys = ON",
+		"DROP INDEX idx_public_keys_key_data",
+		"DELETE FROM schema_migrations WHERE version = '000006_unique_public_key_data'",
+		"INSERT INTO accounts (id, username, hostname) VALUES (1, 'a', 'h1'), (2, 'b', 'h2')",
+		"INSERT INTO public_keys (id, algorithm, key_data, comment, is_global) VALUES (1, 'ssh-ed25519', 'AAAAdup', 'first', 0), (2, 'ssh-ed25519', 'AAAAdup', 'second', 1), (3, 'ssh-ed25519', 'AAAAother', 'other', 0)",
+		"INSERT INTO account_keys (account_id, key_id) VALUES (1, 1), (2, 2)",
+	} {
+		if _, err := dbConn.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+
+	if err := RunMigrations(dbConn, "sqlite"); err != nil {
+		t.Fatalf("RunMigrations (000006) failed: %v", err)
+	}
+
+	var keys, global int
+	if err := dbConn.QueryRow("SELECT COUNT(*) FROM public_keys").Scan(&keys); err != nil || keys != 2 {
+		t.Fatalf("expected 2 keys after merge, got %d (%v)", keys, err)
+	}
+	if err := dbConn.QueryRow("SELECT is_global FROM public_keys WHERE id = 1").Scan(&global); err != nil || global != 1 {
+		t.Fatalf("expected kept key to inherit global flag, got %d (%v)", global, err)
+	}
+	var assigned int
+	if err := dbConn.QueryRow("SELECT COUNT(*) FROM account_keys WHERE key_id = 1").Scan(&assigned); err != nil || assigned != 2 {
+		t.Fatalf("expected both assignments on kept key, got %d (%v)", assigned, err)
+	}
+	if _, err := dbConn.Exec("INSERT INTO public_keys (algorithm, key_data, comment) VALUES ('ssh-ed25519', 'AAAAdup', 'third')"); err == nil {
+		t.Fatalf("expected unique index to reject duplicate key data")
+	}
+}
+
+func TestMigration_SystemKeyCreatedAt_DefaultsExistingRows(t *testing.T) {
+	dbConn, err := sql.Open("sqlite", "file:test_system_key_created_at?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	defer func() { _ = dbConn.Close() }()
+	if err := RunMigrations(dbConn, "sqlite"); err != nil {
+		t.Fatalf("RunMigrations failed: %v", err)
+	}
+
+	// Rewind to the schema before 000007 and seed a key without created_at.
+	for _, stmt := range []string{
+		"ALTER TABLE system_keys DROP COLUMN created_at",
+		"DELETE FROM schema_migrations WHERE version = '000007_add_system_key_created_at'",
+		"INSERT INTO system_keys (serial, public_key, private_key, is_active) VALUES (1, 'pub', 'priv', 1)",
+	} {
+		if _, err := dbConn.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+
+	if err := RunMigrations(dbConn, "sqlite"); err != nil {
+		t.Fatalf("RunMigrations (000007) failed: %v", err)
+	}
+	var createdAt sql.NullString
+	if err := dbConn.QueryRow("SELECT created_at FROM system_keys WHERE serial = 1").Scan(&createdAt); err != nil {
+		t.Fatalf("query created_at: %v", err)
+	}
+	if !createdAt.Valid || createdAt.String == "" {
+		t.Fatalf("expected existing key to get a created_at default, got %+v", createdAt)
+	}
+}
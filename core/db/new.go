@@ -8,6 +8,6 @@ func New(dbType, dsn string) (Store, error) {
 	if err != nil {
 		return nil, err
 	}
-	store = s
+	setStore(s)
 	return s, nil
 }
@@ -0,0 +1,58 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+func TestOperationResultsBun_WindowAndAccount(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		now := time.Now().UTC().Truncate(time.Second)
+		for _, r := range []model.OperationResult{
+			{RecordedAt: now.Add(-40 * 24 * time.Hour), AccountID: 1, Operation: model.OperationDeploy, ErrorCategory: "unreachable"},
+			{RecordedAt: now.Add(-2 * time.Hour), AccountID: 1, Operation: model.OperationAudit, Success: true, Duration: 1500 * time.Millisecond},
+			{RecordedAt: now.Add(-3 * time.Hour), AccountID: 1, Operation: model.OperationDeploy, ErrorCategory: "auth", Duration: time.Second},
+			{RecordedAt: now.Add(-time.Hour), AccountID: 2, Operation: model.OperationDeploy, Success: true},
+		} {
+			if err := s.RecordOperationResult(r); err != nil {
+				t.Fatalf("RecordOperationResult: %v", err)
+			}
+		}
+
+		got, err := s.GetOperationResults(1, now.Add(-30*24*time.Hour))
+		if err != nil {
+			t.Fatalf("GetOperationResults: %v", err)
+		}
+		if len(got) != 2 {
+			t.Fatalf("expected 2 results in the window, got %+v", got)
+		}
+		first, second := got[0], got[1]
+		if first.Operation != model.OperationDeploy || first.Success || first.ErrorCategory != "auth" || first.Duration != time.Second {
+			t.Fatalf("unexpected oldest result %+v", first)
+		}
+		if second.Operation != model.OperationAudit || !second.Success || second.Duration != 1500*time.Millisecond {
+			t.Fatalf("unexpected newest result %+v", second)
+		}
+		if !first.RecordedAt.Equal(now.Add(-3 * time.Hour)) {
+			t.Fatalf("timestamp not preserved: %v", first.RecordedAt)
+		}
+	})
+}
+
+func TestRecordOperationResultBun_StampsZeroTime(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		before := time.Now().Add(-time.Minute)
+		if err := s.RecordOperationResult(model.OperationResult{AccountID: 7, Operation: model.OperationAudit, Success: true}); err != nil {
+			t.Fatalf("RecordOperationResult: %v", err)
+		}
+		got, err := s.GetOperationResults(7, before)
+		if err != nil || len(got) != 1 {
+			t.Fatalf("expected the result to be stamped with the current time, got %+v, %v", got, err)
+		}
+	})
+}
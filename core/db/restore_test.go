@@ -4,10 +4,12 @@
 package db
 
 import (
+	"os"
 	"testing"
 	"time"
 
 	"github.com/toeirei/keymaster/core/model"
+	"github.com/uptrace/bun/dialect"
 )
 
 func TestIntegrateDataFromBackup_NonDestructive(t *testing.T) {
@@ -47,9 +49,17 @@ func TestIntegrateDataFromBackup_NonDestructive(t *testing.T) {
 		{ID: 1000, Algorithm: "ed25519", KeyData: "AAAAB3NzaC1lZDI1NTE5AAAAIkey2", Comment: "c2", IsGlobal: false},
 	}
 
-	if err := IntegrateDataFromBackup(bk); err != nil {
+	summary, err := IntegrateDataFromBackup(bk)
+	if err != nil {
 		t.Fatalf("IntegrateDataFromBackup failed: %v", err)
 	}
+	want := model.RestoreSummary{
+		Accounts:   model.RestoreTableCounts{Imported: 1, Skipped: 1},
+		PublicKeys: model.RestoreTableCounts{Imported: 1, Skipped: 1},
+	}
+	if summary != want {
+		t.Fatalf("unexpected summary: got %+v, want %+v", summary, want)
+	}
 
 	after, err := ExportDataForBackup()
 	if err != nil {
@@ -64,3 +74,264 @@ func TestIntegrateDataFromBackup_NonDestructive(t *testing.T) {
 		t.Fatalf("expected public keys to increase by 1: before=%d after=%d", len(before.PublicKeys), len(after.PublicKeys))
 	}
 }
+
+func TestIntegrateDataFromBackup_ReportsSkippedRows(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		existing := &model.BackupData{
+			Accounts:    []model.Account{{ID: 1, Username: "alice", Hostname: "a.example", IsActive: true}},
+			PublicKeys:  []model.PublicKey{{ID: 1, Algorithm: "ssh-ed25519", KeyData: "AAAAkey1", Comment: "k1"}},
+			AccountKeys: []model.AccountKey{{KeyID: 1, AccountID: 1}},
+		}
+		if err := s.ImportDataFromBackup(existing); err != nil {
+			t.Fatalf("ImportDataFromBackup: %v", err)
+		}
+
+		incoming := &model.BackupData{
+			Accounts: []model.Account{
+				{ID: 1, Username: "alice", Hostname: "a.example", IsActive: true},
+				{ID: 5, Username: "alice", Hostname: "a.example", IsActive: true}, // same user@host, new id
+				{ID: 2, Username: "bob", Hostname: "b.example", IsActive: true},
+			},
+			PublicKeys: []model.PublicKey{
+				{ID: 1, Algorithm: "ssh-ed25519", KeyData: "AAAAkey1", Comment: "k1"},
+				{ID: 2, Algorithm: "ssh-ed25519", KeyData: "AAAAkey2", Comment: "k2"},
+			},
+			AccountKeys: []model.AccountKey{{KeyID: 1, AccountID: 1}, {KeyID: 2, AccountID: 2}},
+		}
+		summary, err := s.IntegrateDataFromBackup(incoming)
+		if err != nil {
+			t.Fatalf("IntegrateDataFromBackup: %v", err)
+		}
+		want := model.RestoreSummary{
+			Accounts:    model.RestoreTableCounts{Imported: 1, Skipped: 2},
+			PublicKeys:  model.RestoreTableCounts{Imported: 1, Skipped: 1},
+			AccountKeys: model.RestoreTableCounts{Imported: 1, Skipped: 1},
+		}
+		if summary != want {
+			t.Fatalf("unexpected summary: got %+v, want %+v", summary, want)
+		}
+		if summary.Skipped() != 4 {
+			t.Fatalf("expected 4 skipped rows in total, got %d", summary.Skipped())
+		}
+	})
+}
+
+func TestInsertIgnoreSQL(t *testing.T) {
+	cases := map[dialect.Name]string{
+		dialect.SQLite: "INSERT OR IGNORE INTO t (a, b) VALUES (?, ?)",
+		dialect.PG:     "INSERT INTO t (a, b) VALUES (?, ?) ON CONFLICT DO NOTHING",
+		dialect.MySQL:  "INSERT IGNORE INTO t (a, b) VALUES (?, ?)",
+	}
+	for name, want := range cases {
+		if got := insertIgnoreSQL(name, "t", "a, b", 2); got != want {
+			t.Errorf("%s: got %q, want %q", name, got, want)
+		}
+	}
+}
+
+// assertRestoredIDsDoNotCollide restores a backup with explicit ids into st
+// and checks that a subsequently added account receives a fresh id.
+func assertRestoredIDsDoNotCollide(t *testing.T, st Store) {
+	t.Helper()
+	backup := &model.BackupData{
+		Accounts: []model.Account{
+			{ID: 7, Username: "restored1", Hostname: "r1.example", IsActive: true},
+			{ID: 42, Username: "restored2", Hostname: "r2.example", IsActive: true},
+		},
+	}
+	if err := st.ImportDataFromBackup(backup); err != nil {
+		t.Fatalf("ImportDataFromBackup failed: %v", err)
+	}
+	id, err := st.AddAccount("fresh", "fresh.example", "", "")
+	if err != nil {
+		t.Fatalf("AddAccount after restore failed: %v", err)
+	}
+	if id <= 42 {
+		t.Fatalf("expected new account id above restored ids, got %d", id)
+	}
+}
+
+func TestImportDataFromBackup_NewIDsDoNotCollide(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		assertRestoredIDsDoNotCollide(t, s)
+	})
+}
+
+// TestImportDataFromBackup_PostgresResetsSequences requires INTEGRATION_DB=postgres
+// and INTEGRATION_DSN; it is skipped otherwise.
+func TestImportDataFromBackup_PostgresResetsSequences(t *testing.T) {
+	if os.Getenv("INTEGRATION_DB") != "postgres" || os.Getenv("INTEGRATION_DSN") == "" {
+		t.Skip("postgres integration DB env not set; skipping")
+	}
+	st, err := NewStoreFromDSN("postgres", os.Getenv("INTEGRATION_DSN"))
+	if err != nil {
+		t.Fatalf("NewStoreFromDSN failed: %v", err)
+	}
+	assertRestoredIDsDoNotCollide(t, st)
+}
+
+func TestIdentitySequenceResetSQL(t *testing.T) {
+	got := identitySequenceResetSQL("accounts")
+	want := "SELECT setval(pg_get_serial_sequence('accounts', 'id'), COALESCE((SELECT MAX(id) FROM accounts), 0) + 1, false)"
+	if got != want {
+		t.Fatalf("identitySequenceResetSQL() = %q, want %q", got, want)
+	}
+}
+
+func TestExportTablesForBackup_SubsetRestoresWithoutClobbering(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		accID, err := s.AddAccount("deploy", "web-01", "", "")
+		if err != nil {
+			t.Fatalf("AddAccount: %v", err)
+		}
+		if err := AddPublicKeyBun(s.BunDB(), "ssh-ed25519", "AAAAsubset", "k1", false, time.Time{}); err != nil {
+			t.Fatalf("AddPublicKeyBun: %v", err)
+		}
+		if err := s.AddKnownHostKey("web-01", "ssh-ed25519 AAAAhost"); err != nil {
+			t.Fatalf("AddKnownHostKey: %v", err)
+		}
+		if err := s.LogAction("TEST", "before backup"); err != nil {
+			t.Fatalf("LogAction: %v", err)
+		}
+
+		tables := []string{model.BackupTableAccounts, model.BackupTablePublicKeys, model.BackupTableAccountKeys}
+		backup, err := s.ExportTablesForBackup(tables)
+		if err != nil {
+			t.Fatalf("ExportTablesForBackup: %v", err)
+		}
+		if len(backup.Accounts) != 1 || len(backup.PublicKeys) != 1 {
+			t.Fatalf("expected selected tables exported, got %d accounts, %d keys", len(backup.Accounts), len(backup.PublicKeys))
+		}
+		if len(backup.AuditLogEntries) != 0 || len(backup.KnownHosts) != 0 {
+			t.Fatalf("excluded tables were exported: %d audit, %d known hosts", len(backup.AuditLogEntries), len(backup.KnownHosts))
+		}
+
+		// Change data on both sides of the selection, then restore.
+		if err := s.UpdateAccountLabel(accID, "changed"); err != nil {
+			t.Fatalf("UpdateAccountLabel: %v", err)
+		}
+		if err := s.LogAction("TEST", "after backup"); err != nil {
+			t.Fatalf("LogAction: %v", err)
+		}
+		auditBefore, _ := s.GetAllAuditLogEntries()
+
+		if err := s.ImportDataFromBackup(backup); err != nil {
+			t.Fatalf("ImportDataFromBackup: %v", err)
+		}
+		acc, err := GetAccountByIDBun(s.BunDB(), accID)
+		if err != nil || acc == nil || acc.Label != "" {
+			t.Fatalf("expected selected table restored, got %+v (%v)", acc, err)
+		}
+		if host, _ := s.GetKnownHostKey("web-01"); host == "" {
+			t.Fatalf("known_hosts was clobbered by a selective restore")
+		}
+		if auditAfter, _ := s.GetAllAuditLogEntries(); len(auditAfter) < len(auditBefore) {
+			t.Fatalf("audit log was clobbered: %d entries before, %d after", len(auditBefore), len(auditAfter))
+		}
+
+		if _, err := s.IntegrateDataFromBackup(backup); err != nil {
+			t.Fatalf("IntegrateDataFromBackup: %v", err)
+		}
+		if host, _ := s.GetKnownHostKey("web-01"); host == "" {
+			t.Fatalf("known_hosts was clobbered by an integrating restore")
+		}
+	})
+}
+
+func TestExportTablesForBackup_UnknownTable(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		if _, err := s.ExportTablesForBackup([]string{"accounts", "nope"}); err == nil {
+			t.Fatalf("expected error for unknown table")
+		}
+	})
+}
+
+func TestImportDataFromBackup_PreservesAccountSchedule(t *testing.T) {
+	disableAt := time.Now().UTC().Add(24 * time.Hour).Truncate(time.Second)
+	enableAt := disableAt.Add(48 * time.Hour)
+	var backup *model.BackupData
+	WithTestStore(t, func(s *BunStore) {
+		id, err := s.AddAccount("contractor", "web-01", "", "")
+		if err != nil {
+			t.Fatalf("AddAccount: %v", err)
+		}
+		if err := s.SetAccountSchedule(id, disableAt, enableAt); err != nil {
+			t.Fatalf("SetAccountSchedule: %v", err)
+		}
+		if backup, err = s.ExportDataForBackup(); err != nil {
+			t.Fatalf("ExportDataForBackup: %v", err)
+		}
+	})
+
+	assertSchedule := func(t *testing.T, s *BunStore) {
+		t.Helper()
+		accounts, err := s.GetAllAccounts()
+		if err != nil || len(accounts) != 1 {
+			t.Fatalf("expected one restored account, got %d (%v)", len(accounts), err)
+		}
+		if !accounts[0].DisableAt.Equal(disableAt) || !accounts[0].EnableAt.Equal(enableAt) {
+			t.Fatalf("schedule lost: got disable=%v enable=%v, want disable=%v enable=%v",
+				accounts[0].DisableAt, accounts[0].EnableAt, disableAt, enableAt)
+		}
+	}
+	WithTestStore(t, func(s *BunStore) {
+		if err := s.ImportDataFromBackup(backup); err != nil {
+			t.Fatalf("ImportDataFromBackup: %v", err)
+		}
+		assertSchedule(t, s)
+	})
+	WithTestStore(t, func(s *BunStore) {
+		if _, err := s.IntegrateDataFromBackup(backup); err != nil {
+			t.Fatalf("IntegrateDataFromBackup: %v", err)
+		}
+		assertSchedule(t, s)
+	})
+}
+
+func TestImportDataFromBackup_AccountsOnlyKeepsAssignments(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		// SQLite only cascades deletes with foreign keys enabled, which is a
+		// per-connection setting; Postgres and MySQL always do.
+		s.BunDB().SetMaxOpenConns(1)
+		if _, err := s.BunDB().Exec("PRAGMA foreign_keys = ON"); err != nil {
+			t.Fatalf("enable foreign keys: %v", err)
+		}
+		accID, err := s.AddAccount("deploy", "web-01", "", "")
+		if err != nil {
+			t.Fatalf("AddAccount: %v", err)
+		}
+		pk, err := AddPublicKeyAndGetModelBun(s.BunDB(), "ssh-ed25519", "AAAAkeep", "k1", false, time.Time{})
+		if err != nil {
+			t.Fatalf("AddPublicKeyAndGetModelBun: %v", err)
+		}
+		if err := s.AssignKeyToAccount(pk.ID, accID); err != nil {
+			t.Fatalf("AssignKeyToAccount: %v", err)
+		}
+		backup, err := s.ExportTablesForBackup([]string{model.BackupTableAccounts})
+		if err != nil {
+			t.Fatalf("ExportTablesForBackup: %v", err)
+		}
+
+		// An account created after the backup disappears on restore, and
+		// so does its assignment.
+		lateID, err := s.AddAccount("late", "web-02", "", "")
+		if err != nil {
+			t.Fatalf("AddAccount: %v", err)
+		}
+		if err := s.AssignKeyToAccount(pk.ID, lateID); err != nil {
+			t.Fatalf("AssignKeyToAccount: %v", err)
+		}
+
+		if err := s.ImportDataFromBackup(backup); err != nil {
+			t.Fatalf("ImportDataFromBackup: %v", err)
+		}
+		keys, err := GetKeysForAccountBun(s.BunDB(), accID)
+		if err != nil || len(keys) != 1 || keys[0].ID != pk.ID {
+			t.Fatalf("expected assignment to survive an accounts-only restore, got %+v (%v)", keys, err)
+		}
+		accounts, err := GetAccountsForKeyBun(s.BunDB(), pk.ID)
+		if err != nil || len(accounts) != 1 || accounts[0].ID != accID {
+			t.Fatalf("expected only the restored account assigned, got %+v (%v)", accounts, err)
+		}
+	})
+}
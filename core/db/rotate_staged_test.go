@@ -0,0 +1,43 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package db
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStagedRotation_BothKeysActiveUntilDeactivated(t *testing.T) {
+	_ = newTestDB(t)
+	if _, err := CreateSystemKey("old-pub", "old-priv"); err != nil {
+		t.Fatalf("CreateSystemKey: %v", err)
+	}
+	staged, err := CreateSystemKey("new-pub", "new-priv")
+	if err != nil {
+		t.Fatalf("CreateSystemKey: %v", err)
+	}
+
+	active, err := GetActiveSystemKey()
+	if err != nil || active == nil || active.Serial != staged {
+		t.Fatalf("expected the staged key %d used for deploys, got %+v, %v", staged, active, err)
+	}
+	old, err := GetSystemKeyBySerial(staged - 1)
+	if err != nil || old == nil || !old.IsActive {
+		t.Fatalf("expected the previous key still active, got %+v, %v", old, err)
+	}
+	if err := DeleteSystemKey(old.Serial); !errors.Is(err, ErrActiveSystemKey) {
+		t.Fatalf("expected the previous key protected from deletion, got %v", err)
+	}
+
+	n, err := DeactivateSystemKeysBelow(staged)
+	if err != nil || n != 1 {
+		t.Fatalf("DeactivateSystemKeysBelow: %d, %v", n, err)
+	}
+	if old, _ = GetSystemKeyBySerial(staged - 1); old.IsActive {
+		t.Fatal("expected the previous key deactivated")
+	}
+	if active, _ = GetActiveSystemKey(); active.Serial != staged {
+		t.Fatalf("expected the staged key to stay active, got %+v", active)
+	}
+}
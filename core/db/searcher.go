@@ -6,9 +6,12 @@ package db
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/core/sshkey"
 	"github.com/uptrace/bun"
 )
 
@@ -44,13 +47,14 @@ func (s *BunAccountSearcher) SearchAccounts(q string) ([]model.Account, error) {
 // initialized; callers should handle nil by falling back to local filtering.
 func DefaultAccountSearcher() AccountSearcher {
 	// If a test or other code has injected a default searcher, prefer that.
-	if defaultSearcher != nil {
-		return defaultSearcher
+	if m := getDefault(&defaultSearcher); m != nil {
+		return m
 	}
-	if store == nil {
+	st := currentStore()
+	if st == nil {
 		return nil
 	}
-	return NewAccountSearcherFromStore(store)
+	return NewAccountSearcherFromStore(st)
 }
 
 // package-level override used primarily by tests to inject a fake searcher.
@@ -59,12 +63,12 @@ var defaultSearcher AccountSearcher
 // SetDefaultAccountSearcher sets a package-level AccountSearcher that will be
 // returned by DefaultAccountSearcher(). Useful for tests to inject a fake.
 func SetDefaultAccountSearcher(s AccountSearcher) {
-	defaultSearcher = s
+	setDefault(&defaultSearcher, s)
 }
 
 // ClearDefaultAccountSearcher clears any previously set package-level searcher.
 func ClearDefaultAccountSearcher() {
-	defaultSearcher = nil
+	setDefault(&defaultSearcher, nil)
 }
 
 // AuditSearcher defines a minimal interface for retrieving audit log entries.
@@ -97,13 +101,14 @@ func (s *BunAuditSearcher) GetAllAuditLogEntries() ([]model.AuditLogEntry, error
 // `store` if available. It returns nil when the package store is not
 // initialized; callers should handle nil by falling back to direct helpers.
 func DefaultAuditSearcher() AuditSearcher {
-	if defaultAuditSearcher != nil {
-		return defaultAuditSearcher
+	if m := getDefault(&defaultAuditSearcher); m != nil {
+		return m
 	}
-	if store == nil {
+	st := currentStore()
+	if st == nil {
 		return nil
 	}
-	return NewAuditSearcherFromStore(store)
+	return NewAuditSearcherFromStore(st)
 }
 
 // package-level override used primarily by tests to inject a fake audit searcher.
@@ -112,12 +117,12 @@ var defaultAuditSearcher AuditSearcher
 // SetDefaultAuditSearcher sets a package-level AuditSearcher that will be
 // returned by DefaultAuditSearcher(). Useful for tests to inject a fake.
 func SetDefaultAuditSearcher(s AuditSearcher) {
-	defaultAuditSearcher = s
+	setDefault(&defaultAuditSearcher, s)
 }
 
 // ClearDefaultAuditSearcher clears any previously set package-level audit searcher.
 func ClearDefaultAuditSearcher() {
-	defaultAuditSearcher = nil
+	setDefault(&defaultAuditSearcher, nil)
 }
 
 // KeySearcher defines a minimal interface for searching public keys.
@@ -150,13 +155,14 @@ func (s *BunKeySearcher) SearchPublicKeys(q string) ([]model.PublicKey, error) {
 // `store` if available. It returns nil when the package store is not
 // initialized; callers should handle nil by falling back to local filtering.
 func DefaultKeySearcher() KeySearcher {
-	if defaultKeySearcher != nil {
-		return defaultKeySearcher
+	if m := getDefault(&defaultKeySearcher); m != nil {
+		return m
 	}
-	if store == nil {
+	st := currentStore()
+	if st == nil {
 		return nil
 	}
-	return NewKeySearcherFromStore(store)
+	return NewKeySearcherFromStore(st)
 }
 
 // package-level override used primarily by tests to inject a fake key searcher.
@@ -165,12 +171,12 @@ var defaultKeySearcher KeySearcher
 // SetDefaultKeySearcher sets a package-level KeySearcher that will be
 // returned by DefaultKeySearcher(). Useful for tests to inject a fake.
 func SetDefaultKeySearcher(s KeySearcher) {
-	defaultKeySearcher = s
+	setDefault(&defaultKeySearcher, s)
 }
 
 // ClearDefaultKeySearcher clears any previously set package-level key searcher.
 func ClearDefaultKeySearcher() {
-	defaultKeySearcher = nil
+	setDefault(&defaultKeySearcher, nil)
 }
 
 // AccountManager defines a minimal interface for managing accounts (add/delete).
@@ -184,14 +190,15 @@ type AccountManager interface {
 // DefaultAccountManager returns an AccountManager backed by the package-level
 // `store` if available. Tests may inject a fake via SetDefaultAccountManager.
 func DefaultAccountManager() AccountManager {
-	if defaultAccountManager != nil {
-		return defaultAccountManager
+	if m := getDefault(&defaultAccountManager); m != nil {
+		return m
 	}
-	if store == nil {
+	st := currentStore()
+	if st == nil {
 		return nil
 	}
 	// Use the package store as the default AccountManager by delegating to it.
-	return &bunAccountManager{bStore: store}
+	return &bunAccountManager{bStore: st}
 }
 
 // bunAccountManager adapts the existing Store to the AccountManager interface.
@@ -231,7 +238,7 @@ func (b *bunAccountManager) FindByIdentifier(ctx context.Context, identifier str
 			return &aa, nil
 		}
 	}
-	return nil, fmt.Errorf("account not found: %s", identifier)
+	return nil, fmt.Errorf("account %w: %s", ErrNotFound, identifier)
 }
 
 // SetActive sets the account's active flag to the provided state. It will
@@ -242,7 +249,7 @@ func (b *bunAccountManager) SetActive(ctx context.Context, accountID int, active
 		return err
 	}
 	if acc == nil {
-		return fmt.Errorf("account not found: %d", accountID)
+		return fmt.Errorf("account %w: %d", ErrNotFound, accountID)
 	}
 	if acc.IsActive == active {
 		return nil
@@ -276,12 +283,12 @@ var defaultAccountManager AccountManager
 // SetDefaultAccountManager sets a package-level AccountManager that will be
 // returned by DefaultAccountManager(). Useful for tests to inject a fake.
 func SetDefaultAccountManager(m AccountManager) {
-	defaultAccountManager = m
+	setDefault(&defaultAccountManager, m)
 }
 
 // ClearDefaultAccountManager clears any previously set package-level account manager.
 func ClearDefaultAccountManager() {
-	defaultAccountManager = nil
+	setDefault(&defaultAccountManager, nil)
 }
 
 // KeyManager defines a minimal interface for managing public keys (add/delete,
@@ -295,10 +302,22 @@ type KeyManager interface {
 	// SetPublicKeyExpiry sets or clears the expires_at for a public key. A zero
 	// time value will clear the expiration (set NULL).
 	SetPublicKeyExpiry(id int, expiresAt time.Time) error
+	// SetPublicKeyMetadata replaces the free-form metadata of a public key.
+	SetPublicKeyMetadata(id int, metadata map[string]string) error
+	// SetPublicKeyCertAuthority marks a public key as an SSH certificate
+	// authority restricted to principals, or clears the mark.
+	SetPublicKeyCertAuthority(id int, isCA bool, principals []string) error
+	// UpdatePublicKey replaces the algorithm and key data of a public key,
+	// e.g. after it was rotated under the same comment, and marks the
+	// accounts rendering it dirty.
+	UpdatePublicKey(id int, algorithm, keyData string) error
 	GetAllPublicKeys() ([]model.PublicKey, error)
 	GetPublicKeyByComment(comment string) (*model.PublicKey, error)
 	GetGlobalPublicKeys() ([]model.PublicKey, error)
 	AssignKeyToAccount(keyID, accountID int) error
+	// AssignKeyToAccountWithOptions assigns a key with authorized_keys
+	// options (e.g. command="...") that are rendered for this account only.
+	AssignKeyToAccountWithOptions(keyID, accountID int, options string) error
 	UnassignKeyFromAccount(keyID, accountID int) error
 	GetKeysForAccount(accountID int) ([]model.PublicKey, error)
 	GetAccountsForKey(keyID int) ([]model.Account, error)
@@ -307,19 +326,23 @@ type KeyManager interface {
 // DefaultKeyManager returns a KeyManager backed by the package-level `store`.
 // Tests can inject a fake via SetDefaultKeyManager.
 func DefaultKeyManager() KeyManager {
-	if defaultKeyManager != nil {
-		return defaultKeyManager
+	if m := getDefault(&defaultKeyManager); m != nil {
+		return m
 	}
-	if store == nil {
+	st := currentStore()
+	if st == nil {
 		return nil
 	}
-	return &bunKeyManager{bStore: store}
+	return &bunKeyManager{bStore: st}
 }
 
 // bunKeyManager adapts the Store to KeyManager.
 type bunKeyManager struct{ bStore Store }
 
 func (b *bunKeyManager) AddPublicKey(algorithm, keyData, comment string, isGlobal bool, expiresAt time.Time) error {
+	if err := sshkey.CheckAlgorithmAllowed(algorithm); err != nil {
+		return err
+	}
 	err := AddPublicKeyBun(b.bStore.BunDB(), algorithm, keyData, comment, isGlobal, expiresAt)
 	if err == nil {
 		_ = b.bStore.LogAction("ADD_PUBLIC_KEY", fmt.Sprintf("comment: %s", comment))
@@ -328,6 +351,9 @@ func (b *bunKeyManager) AddPublicKey(algorithm, keyData, comment string, isGloba
 }
 
 func (b *bunKeyManager) AddPublicKeyAndGetModel(algorithm, keyData, comment string, isGlobal bool, expiresAt time.Time) (*model.PublicKey, error) {
+	if err := sshkey.CheckAlgorithmAllowed(algorithm); err != nil {
+		return nil, err
+	}
 	pk, err := AddPublicKeyAndGetModelBun(b.bStore.BunDB(), algorithm, keyData, comment, isGlobal, expiresAt)
 	if err == nil && pk != nil {
 		_ = b.bStore.LogAction("ADD_PUBLIC_KEY", fmt.Sprintf("comment: %s", comment))
@@ -363,6 +389,41 @@ func (b *bunKeyManager) SetPublicKeyExpiry(id int, expiresAt time.Time) error {
 	return err
 }
 
+func (b *bunKeyManager) SetPublicKeyMetadata(id int, metadata map[string]string) error {
+	err := SetPublicKeyMetadataBun(b.bStore.BunDB(), id, metadata)
+	if err == nil {
+		_ = b.bStore.LogAction("SET_KEY_METADATA", fmt.Sprintf("key_id: %d keys: %s", id, strings.Join(model.MetadataKeys(metadata), ",")))
+	}
+	return err
+}
+
+func (b *bunKeyManager) SetPublicKeyCertAuthority(id int, isCA bool, principals []string) error {
+	err := SetPublicKeyCertAuthorityBun(b.bStore.BunDB(), id, isCA, principals)
+	if err == nil {
+		_ = b.bStore.LogAction("SET_KEY_CERT_AUTHORITY", fmt.Sprintf("key_id: %d is_ca: %t principals: %s", id, isCA, strings.Join(principals, ",")))
+	}
+	return err
+}
+
+func (b *bunKeyManager) UpdatePublicKey(id int, algorithm, keyData string) error {
+	if err := sshkey.CheckAlgorithmAllowed(algorithm); err != nil {
+		return err
+	}
+	old, _ := GetPublicKeyByIDBun(b.bStore.BunDB(), id)
+	err := UpdatePublicKeyBun(b.bStore.BunDB(), id, algorithm, keyData)
+	if err == nil {
+		// Record both fingerprints so the rotation can be traced.
+		newFP, _, _ := sshkey.Fingerprints(keyData)
+		details := fmt.Sprintf("key_id: %d new: %s", id, newFP)
+		if old != nil {
+			oldFP, _, _ := sshkey.Fingerprints(old.KeyData)
+			details = fmt.Sprintf("comment: %s old: %s new: %s", old.Comment, oldFP, newFP)
+		}
+		_ = b.bStore.LogAction("UPDATE_PUBLIC_KEY", details)
+	}
+	return err
+}
+
 func (b *bunKeyManager) GetAllPublicKeys() ([]model.PublicKey, error) {
 	return GetAllPublicKeysBun(b.bStore.BunDB())
 }
@@ -375,18 +436,36 @@ func (b *bunKeyManager) GetGlobalPublicKeys() ([]model.PublicKey, error) {
 	return GetGlobalPublicKeysBun(b.bStore.BunDB())
 }
 func (b *bunKeyManager) AssignKeyToAccount(keyID, accountID int) error {
-	err := AssignKeyToAccountBun(b.bStore.BunDB(), keyID, accountID)
+	return b.AssignKeyToAccountWithOptions(keyID, accountID, "")
+}
+
+func (b *bunKeyManager) AssignKeyToAccountWithOptions(keyID, accountID int, options string) error {
+	if err := sshkey.ValidateKeyOptions(options); err != nil {
+		return err
+	}
+	pk, _ := GetPublicKeyByIDBun(b.bStore.BunDB(), keyID)
+	if pk != nil {
+		// Keys stored before the allow-list was configured stay in the
+		// database but may not gain new assignments.
+		if err := sshkey.CheckAlgorithmAllowed(pk.Algorithm); err != nil {
+			return err
+		}
+	}
+	err := AssignKeyToAccountWithOptionsBun(b.bStore.BunDB(), keyID, accountID, options)
 	if err == nil {
 		var keyComment, accUser, accHost string
-		if pk, _ := GetPublicKeyByIDBun(b.bStore.BunDB(), keyID); pk != nil {
+		if pk != nil {
 			keyComment = pk.Comment
 		}
 		if acc, _ := GetAccountByIDBun(b.bStore.BunDB(), accountID); acc != nil {
 			accUser = acc.Username
 			accHost = acc.Hostname
 		}
-		details := fmt.Sprintf("key: '%s' to account: %s@%s", keyComment, accUser, accHost)
-		_ = b.bStore.LogAction("ASSIGN_KEY", details)
+		summary := fmt.Sprintf("key: '%s' to account: %s@%s", keyComment, accUser, accHost)
+		if options != "" {
+			summary += fmt.Sprintf(" with options: %s", options)
+		}
+		_ = b.bStore.LogAction("ASSIGN_KEY", model.EncodeAuditDetails(summary, keyAssignmentFields(keyID, keyComment, accountID, accUser, accHost)))
 	}
 	return err
 }
@@ -400,14 +479,25 @@ func (b *bunKeyManager) UnassignKeyFromAccount(keyID, accountID int) error {
 		accUser = acc.Username
 		accHost = acc.Hostname
 	}
-	details := fmt.Sprintf("key: '%s' from account: %s@%s", keyComment, accUser, accHost)
+	summary := fmt.Sprintf("key: '%s' from account: %s@%s", keyComment, accUser, accHost)
 	err := UnassignKeyFromAccountBun(b.bStore.BunDB(), keyID, accountID)
 	if err == nil {
-		_ = b.bStore.LogAction("UNASSIGN_KEY", details)
+		_ = b.bStore.LogAction("UNASSIGN_KEY", model.EncodeAuditDetails(summary, keyAssignmentFields(keyID, keyComment, accountID, accUser, accHost)))
 	}
 	return err
 }
 
+// keyAssignmentFields returns the structured audit fields of a key
+// assignment change.
+func keyAssignmentFields(keyID int, keyComment string, accountID int, accUser, accHost string) model.AuditFields {
+	return model.AuditFields{
+		model.AuditFieldKeyID:     strconv.Itoa(keyID),
+		model.AuditFieldKey:       keyComment,
+		model.AuditFieldAccountID: strconv.Itoa(accountID),
+		model.AuditFieldAccount:   accUser + "@" + accHost,
+	}
+}
+
 func (b *bunKeyManager) GetKeysForAccount(accountID int) ([]model.PublicKey, error) {
 	return GetKeysForAccountBun(b.bStore.BunDB(), accountID)
 }
@@ -420,10 +510,10 @@ func (b *bunKeyManager) GetAccountsForKey(keyID int) ([]model.Account, error) {
 var defaultKeyManager KeyManager
 
 // SetDefaultKeyManager sets a package-level KeyManager for DefaultKeyManager().
-func SetDefaultKeyManager(m KeyManager) { defaultKeyManager = m }
+func SetDefaultKeyManager(m KeyManager) { setDefault(&defaultKeyManager, m) }
 
 // ClearDefaultKeyManager clears any previously set package-level key manager.
-func ClearDefaultKeyManager() { defaultKeyManager = nil }
+func ClearDefaultKeyManager() { setDefault(&defaultKeyManager, nil) }
 
 // AuditWriter defines a minimal interface for recording audit log events.
 type AuditWriter interface {
@@ -455,13 +545,14 @@ func (s *BunAuditWriter) LogAction(action string, details string) error {
 // `store` if available. It returns nil when the package store is not
 // initialized; callers should handle nil by falling back to direct helpers.
 func DefaultAuditWriter() AuditWriter {
-	if defaultAuditWriter != nil {
-		return defaultAuditWriter
+	if m := getDefault(&defaultAuditWriter); m != nil {
+		return m
 	}
-	if store == nil {
+	st := currentStore()
+	if st == nil {
 		return nil
 	}
-	return NewAuditWriterFromStore(store)
+	return NewAuditWriterFromStore(st)
 }
 
 // package-level override used primarily by tests to inject a fake audit writer.
@@ -470,10 +561,10 @@ var defaultAuditWriter AuditWriter
 // SetDefaultAuditWriter sets a package-level AuditWriter that will be
 // returned by DefaultAuditWriter(). Useful for tests to inject a fake.
 func SetDefaultAuditWriter(w AuditWriter) {
-	defaultAuditWriter = w
+	setDefault(&defaultAuditWriter, w)
 }
 
 // ClearDefaultAuditWriter clears any previously set package-level audit writer.
 func ClearDefaultAuditWriter() {
-	defaultAuditWriter = nil
+	setDefault(&defaultAuditWriter, nil)
 }
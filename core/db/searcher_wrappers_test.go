@@ -60,7 +60,10 @@ func (f *fakeKeyManager) GetPublicKeyByComment(comment string) (*model.PublicKey
 func (f *fakeKeyManager) GetGlobalPublicKeys() ([]model.PublicKey, error) {
 	return []model.PublicKey{{ID: 2}}, nil
 }
-func (f *fakeKeyManager) AssignKeyToAccount(keyID, accountID int) error     { return nil }
+func (f *fakeKeyManager) AssignKeyToAccount(keyID, accountID int) error { return nil }
+func (f *fakeKeyManager) AssignKeyToAccountWithOptions(keyID, accountID int, options string) error {
+	return nil
+}
 func (f *fakeKeyManager) UnassignKeyFromAccount(keyID, accountID int) error { return nil }
 func (f *fakeKeyManager) GetKeysForAccount(accountID int) ([]model.PublicKey, error) {
 	return []model.PublicKey{{ID: 3}}, nil
@@ -68,7 +71,12 @@ func (f *fakeKeyManager) GetKeysForAccount(accountID int) ([]model.PublicKey, er
 func (f *fakeKeyManager) GetAccountsForKey(keyID int) ([]model.Account, error) {
 	return []model.Account{{ID: 4}}, nil
 }
-func (f *fakeKeyManager) SetPublicKeyExpiry(id int, expiresAt time.Time) error { return nil }
+func (f *fakeKeyManager) SetPublicKeyExpiry(id int, expiresAt time.Time) error          { return nil }
+func (f *fakeKeyManager) SetPublicKeyMetadata(id int, metadata map[string]string) error { return nil }
+func (f *fakeKeyManager) SetPublicKeyCertAuthority(id int, isCA bool, principals []string) error {
+	return nil
+}
+func (f *fakeKeyManager) UpdatePublicKey(id int, algorithm, keyData string) error { return nil }
 
 func TestSearcherAndManagerWrappers_Injection(t *testing.T) {
 	// AccountSearcher
@@ -147,26 +155,55 @@ func TestSearcherAndManagerWrappers_Injection(t *testing.T) {
 // searchers/managers. Methods return zero values and do not require a real DB.
 type fakeStore struct{}
 
-func (f *fakeStore) GetAllAccounts() ([]model.Account, error)                       { return nil, nil }
-func (f *fakeStore) AddAccount(username, hostname, label, tags string) (int, error) { return 0, nil }
-func (f *fakeStore) DeleteAccount(id int) error                                     { return nil }
-func (f *fakeStore) UpdateAccountSerial(id, serial int) error                       { return nil }
-func (f *fakeStore) ToggleAccountStatus(id int, enabled bool) error                 { return nil }
-func (f *fakeStore) UpdateAccountLabel(id int, label string) error                  { return nil }
-func (f *fakeStore) UpdateAccountHostname(id int, hostname string) error            { return nil }
-func (f *fakeStore) UpdateAccountTags(id int, tags string) error                    { return nil }
-func (f *fakeStore) UpdateAccountIsDirty(id int, dirty bool) error                  { return nil }
-func (f *fakeStore) GetAllActiveAccounts() ([]model.Account, error)                 { return nil, nil }
-func (f *fakeStore) GetKnownHostKey(hostname string) (string, error)                { return "", nil }
-func (f *fakeStore) AddKnownHostKey(hostname, key string) error                     { return nil }
-func (f *fakeStore) CreateSystemKey(publicKey, privateKey string) (int, error)      { return 0, nil }
-func (f *fakeStore) RotateSystemKey(publicKey, privateKey string) (int, error)      { return 0, nil }
-func (f *fakeStore) GetActiveSystemKey() (*model.SystemKey, error)                  { return nil, nil }
-func (f *fakeStore) GetSystemKeyBySerial(serial int) (*model.SystemKey, error)      { return nil, nil }
-func (f *fakeStore) HasSystemKeys() (bool, error)                                   { return false, nil }
-func (f *fakeStore) SearchAccounts(query string) ([]model.Account, error)           { return nil, nil }
-func (f *fakeStore) GetAllAuditLogEntries() ([]model.AuditLogEntry, error)          { return nil, nil }
-func (f *fakeStore) LogAction(action string, details string) error                  { return nil }
+func (f *fakeStore) GetAllAccounts() ([]model.Account, error)                         { return nil, nil }
+func (f *fakeStore) AddAccount(username, hostname, label, tags string) (int, error)   { return 0, nil }
+func (f *fakeStore) DeleteAccount(id int) error                                       { return nil }
+func (f *fakeStore) UpdateAccountSerial(id, serial int) error                         { return nil }
+func (f *fakeStore) ToggleAccountStatus(id int, enabled bool) error                   { return nil }
+func (f *fakeStore) UpdateAccountLabel(id int, label string) error                    { return nil }
+func (f *fakeStore) UpdateAccountHostname(id int, hostname string) error              { return nil }
+func (f *fakeStore) UpdateAccountTags(id int, tags string) error                      { return nil }
+func (f *fakeStore) UpdateAccountIsDirty(id int, dirty bool) error                    { return nil }
+func (f *fakeStore) GetAllActiveAccounts() ([]model.Account, error)                   { return nil, nil }
+func (f *fakeStore) GetAccountsBelowSerial(serial int) ([]model.Account, error)       { return nil, nil }
+func (f *fakeStore) GetKnownHostKey(hostname string) (string, error)                  { return "", nil }
+func (f *fakeStore) AddKnownHostKey(hostname, key string) error                       { return nil }
+func (f *fakeStore) CreateSystemKey(publicKey, privateKey string) (int, error)        { return 0, nil }
+func (f *fakeStore) RotateSystemKey(publicKey, privateKey string) (int, error)        { return 0, nil }
+func (f *fakeStore) GetAllSystemKeys() ([]model.SystemKey, error)                     { return nil, nil }
+func (f *fakeStore) DeleteSystemKey(serial int) error                                 { return nil }
+func (f *fakeStore) DeactivateSystemKeysBelow(serial int) (int, error)                { return 0, nil }
+func (f *fakeStore) GetActiveSystemKey() (*model.SystemKey, error)                    { return nil, nil }
+func (f *fakeStore) GetSystemKeyBySerial(serial int) (*model.SystemKey, error)        { return nil, nil }
+func (f *fakeStore) HasSystemKeys() (bool, error)                                     { return false, nil }
+func (f *fakeStore) GetUnassignedPublicKeys() ([]model.PublicKey, error)              { return nil, nil }
+func (f *fakeStore) SetAccountSchedule(id int, disableAt, enableAt time.Time) error   { return nil }
+func (f *fakeStore) SetAccountRemediationPolicy(id int, policy string) error          { return nil }
+func (f *fakeStore) SetAccountManageSystemKey(id int, manage bool) error              { return nil }
+func (f *fakeStore) SetAccountDeployMode(id int, mode string) error                   { return nil }
+func (f *fakeStore) SetAccountDeployEnabled(id int, enabled bool) error               { return nil }
+func (f *fakeStore) SetAccountDeployedKeys(id int, keys []string) error               { return nil }
+func (f *fakeStore) SetAccountMetadata(id int, metadata map[string]string) error      { return nil }
+func (f *fakeStore) SetAccountOSFamily(id int, family string) error                   { return nil }
+func (f *fakeStore) SetAccountGroup(id int, group string) error                       { return nil }
+func (f *fakeStore) SetAccountExcludedGlobalKeys(id int, keyIDs []int) error          { return nil }
+func (f *fakeStore) SetAccountAuthorizedPrincipals(id int, principals []string) error { return nil }
+func (f *fakeStore) SetAccountDeployKnownHosts(id int, enabled bool) error            { return nil }
+func (f *fakeStore) GetAllKnownHosts() ([]model.KnownHost, error)                     { return nil, nil }
+func (f *fakeStore) SetAccountLastAudit(id int, at time.Time) error                   { return nil }
+func (f *fakeStore) RecordOperationResult(r model.OperationResult) error              { return nil }
+func (f *fakeStore) GetOperationResults(accountID int, since time.Time) ([]model.OperationResult, error) {
+	return nil, nil
+}
+func (f *fakeStore) AcquireLock(name string, ttl time.Duration) (bool, error) { return true, nil }
+func (f *fakeStore) ReleaseLock(name string) error                            { return nil }
+func (f *fakeStore) RenewLock(name string, ttl time.Duration) error           { return nil }
+func (f *fakeStore) SearchAccounts(query string) ([]model.Account, error)     { return nil, nil }
+func (f *fakeStore) GetAllAuditLogEntries() ([]model.AuditLogEntry, error)    { return nil, nil }
+func (f *fakeStore) GetAuditLogForAccount(accountID int) ([]model.AuditLogEntry, error) {
+	return nil, nil
+}
+func (f *fakeStore) LogAction(action string, details string) error { return nil }
 func (f *fakeStore) SaveBootstrapSession(id, username, hostname, label, tags, tempPublicKey string, expiresAt time.Time, status string) error {
 	return nil
 }
@@ -178,16 +215,24 @@ func (f *fakeStore) GetOrphanedBootstrapSessions() ([]*model.BootstrapSession, e
 	return nil, nil
 }
 func (f *fakeStore) ExportDataForBackup() (*model.BackupData, error) { return nil, nil }
-func (f *fakeStore) ImportDataFromBackup(*model.BackupData) error    { return nil }
-func (f *fakeStore) IntegrateDataFromBackup(*model.BackupData) error { return nil }
-func (f *fakeStore) BunDB() *bun.DB                                  { return nil }
+func (f *fakeStore) ExportTablesForBackup(tables []string) (*model.BackupData, error) {
+	return nil, nil
+}
+func (f *fakeStore) StreamBackup(tables []string, fn func(table string, row any) error) error {
+	return nil
+}
+func (f *fakeStore) ImportDataFromBackup(*model.BackupData) error { return nil }
+func (f *fakeStore) IntegrateDataFromBackup(*model.BackupData) (model.RestoreSummary, error) {
+	return model.RestoreSummary{}, nil
+}
+func (f *fakeStore) BunDB() *bun.DB { return nil }
 
 func TestDefaultWrappers_WithStore(t *testing.T) {
 	// Preserve original store and restore at the end.
-	orig := store
-	defer func() { store = orig }()
+	orig := currentStore()
+	defer func() { setStore(orig) }()
 
-	store = &fakeStore{}
+	setStore(&fakeStore{})
 
 	if DefaultAccountSearcher() == nil {
 		t.Fatal("expected DefaultAccountSearcher to return non-nil when store set")
@@ -23,15 +23,69 @@ type Store interface {
 	UpdateAccountHostname(id int, hostname string) error
 	UpdateAccountTags(id int, tags string) error
 	GetAllActiveAccounts() ([]model.Account, error)
+	// GetAccountsBelowSerial returns the accounts last deployed with a system
+	// key serial lower than serial, including never-deployed accounts.
+	GetAccountsBelowSerial(serial int) ([]model.Account, error)
 	// UpdateAccountIsDirty sets or clears the is_dirty flag for an account.
 	UpdateAccountIsDirty(id int, dirty bool) error
+	// SetAccountSchedule sets the scheduled disable/enable times of an
+	// account; a zero time clears the corresponding schedule.
+	SetAccountSchedule(id int, disableAt, enableAt time.Time) error
+	// SetAccountRemediationPolicy sets how remediating audits react to drift
+	// on an account; an empty policy restores the default.
+	SetAccountRemediationPolicy(id int, policy string) error
+	// SetAccountManageSystemKey sets whether the Keymaster system key is
+	// rendered into the account's authorized_keys.
+	SetAccountManageSystemKey(id int, manage bool) error
+	// SetAccountDeployEnabled sets whether deploys may write to the
+	// account; audits are unaffected.
+	SetAccountDeployEnabled(id int, enabled bool) error
+	// SetAccountDeployMode sets how deploys write an account's
+	// authorized_keys; an empty mode restores the default.
+	SetAccountDeployMode(id int, mode string) error
+	// SetAccountDeployedKeys records the key identities written by the last
+	// deploy to an account.
+	SetAccountDeployedKeys(id int, keys []string) error
+	// SetAccountMetadata replaces the free-form metadata of an account.
+	SetAccountMetadata(id int, metadata map[string]string) error
+	// SetAccountOSFamily sets the remote OS family of an account; an empty
+	// family restores the default.
+	SetAccountOSFamily(id int, family string) error
+	// SetAccountDeployKnownHosts sets whether deploys also write a
+	// known_hosts file to the account.
+	SetAccountDeployKnownHosts(id int, enabled bool) error
+	// SetAccountExcludedGlobalKeys replaces the IDs of the global keys not
+	// rendered for the account.
+	SetAccountExcludedGlobalKeys(id int, keyIDs []int) error
+	// SetAccountAuthorizedPrincipals replaces the principals deployed to
+	// the account's authorized_principals; nil stops managing the file.
+	SetAccountAuthorizedPrincipals(id int, principals []string) error
+	// SetAccountGroup moves an account into a group; an empty group removes
+	// it from its group.
+	SetAccountGroup(id int, group string) error
+	// SetAccountLastAudit records the time of the last clean audit of an
+	// account; a zero time clears it.
+	SetAccountLastAudit(id int, at time.Time) error
+
+	// Lock methods
+	// AcquireLock takes a named lock shared by every instance using the
+	// database, without waiting. It reports false when the lock is held.
+	AcquireLock(name string, ttl time.Duration) (bool, error)
+	// ReleaseLock releases a lock taken with AcquireLock.
+	ReleaseLock(name string) error
+	// RenewLock extends a held lock by ttl from now.
+	RenewLock(name string, ttl time.Duration) error
 
 	// Public Key methods
 	// Public Key methods have been moved to the KeyManager abstraction. Store
 	// implementations continue to provide Bun helpers in `bun_adapter.go`.
+	// GetUnassignedPublicKeys returns non-global keys assigned to no account.
+	GetUnassignedPublicKeys() ([]model.PublicKey, error)
 
 	// Host Key methods
 	GetKnownHostKey(hostname string) (string, error)
+	// GetAllKnownHosts returns every trusted host key, ordered by hostname.
+	GetAllKnownHosts() ([]model.KnownHost, error)
 	AddKnownHostKey(hostname, key string) error
 
 	// System Key methods
@@ -40,6 +94,11 @@ type Store interface {
 	GetActiveSystemKey() (*model.SystemKey, error)
 	GetSystemKeyBySerial(serial int) (*model.SystemKey, error)
 	HasSystemKeys() (bool, error)
+	GetAllSystemKeys() ([]model.SystemKey, error)
+	DeleteSystemKey(serial int) error
+	// DeactivateSystemKeysBelow deactivates the active system keys older
+	// than serial and returns how many were deactivated.
+	DeactivateSystemKeysBelow(serial int) (int, error)
 
 	// Assignment methods
 	// NOTE: key<->account assignment helpers have been moved behind the
@@ -52,8 +111,17 @@ type Store interface {
 
 	// Audit Log methods
 	GetAllAuditLogEntries() ([]model.AuditLogEntry, error)
+	// GetAuditLogForAccount returns the entries referencing an account, oldest first.
+	GetAuditLogForAccount(accountID int) ([]model.AuditLogEntry, error)
 	LogAction(action string, details string) error
 
+	// Operation result methods
+	// RecordOperationResult stores the outcome of one deploy or audit.
+	RecordOperationResult(r model.OperationResult) error
+	// GetOperationResults returns an account's results recorded at or after
+	// since, oldest first.
+	GetOperationResults(accountID int, since time.Time) ([]model.OperationResult, error)
+
 	// Bootstrap Session methods
 	SaveBootstrapSession(id, username, hostname, label, tags, tempPublicKey string, expiresAt time.Time, status string) error
 	GetBootstrapSession(id string) (*model.BootstrapSession, error)
@@ -64,8 +132,10 @@ type Store interface {
 
 	// Backup/Restore methods
 	ExportDataForBackup() (*model.BackupData, error)
+	ExportTablesForBackup(tables []string) (*model.BackupData, error)
+	StreamBackup(tables []string, fn func(table string, row any) error) error
 	ImportDataFromBackup(*model.BackupData) error
-	IntegrateDataFromBackup(*model.BackupData) error
+	IntegrateDataFromBackup(*model.BackupData) (model.RestoreSummary, error)
 
 	// BunDB exposes the underlying *bun.DB for advanced operations or diagnostics.
 	BunDB() *bun.DB
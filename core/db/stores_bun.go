@@ -5,6 +5,8 @@ package db
 
 import (
 	"fmt"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/toeirei/keymaster/core/model"
@@ -16,6 +18,11 @@ import (
 // helpers in this package.
 type BunStore struct {
 	bun *bun.DB
+
+	// lockMu guards holder and advisory, used by AcquireLock/ReleaseLock.
+	lockMu   sync.Mutex
+	holder   string
+	advisory map[string]bun.Conn
 }
 
 // BunDB returns the underlying *bun.DB for advanced callers.
@@ -31,14 +38,14 @@ func (s *BunStore) GetAccount(id int) (*model.Account, error) {
 func (s *BunStore) AddAccount(username, hostname, label, tags string) (int, error) {
 	id, err := AddAccountBun(s.bun, username, hostname, label, tags)
 	if err == nil {
-		_ = s.LogAction("ADD_ACCOUNT", fmt.Sprintf("account: %s@%s", username, hostname))
+		_ = s.LogAction("ADD_ACCOUNT", fmt.Sprintf("account: %s@%s, %s", username, hostname, model.AccountRef(id)))
 	}
 	return id, err
 }
 func (s *BunStore) DeleteAccount(id int) error {
-	details := fmt.Sprintf("id: %d", id)
+	details := model.AccountRef(id)
 	if acc, err2 := GetAccountByIDBun(s.bun, id); err2 == nil && acc != nil {
-		details = fmt.Sprintf("account: %s@%s", acc.Username, acc.Hostname)
+		details = fmt.Sprintf("account: %s@%s, %s", acc.Username, acc.Hostname, model.AccountRef(id))
 	}
 	err := DeleteAccountBun(s.bun, id)
 	if err == nil {
@@ -49,7 +56,10 @@ func (s *BunStore) DeleteAccount(id int) error {
 func (s *BunStore) AssignKeyToAccount(keyID, accountID int) error {
 	err := AssignKeyToAccountBun(s.bun, keyID, accountID)
 	if err == nil {
-		_ = s.LogAction("ASSIGN_KEY", fmt.Sprintf("keyID: %d, accountID: %d", keyID, accountID))
+		_ = s.LogActionFields("ASSIGN_KEY", fmt.Sprintf("key #%d to account #%d", keyID, accountID), model.AuditFields{
+			model.AuditFieldKeyID:     strconv.Itoa(keyID),
+			model.AuditFieldAccountID: strconv.Itoa(accountID),
+		})
 	}
 	return err
 }
@@ -62,10 +72,10 @@ func (s *BunStore) ToggleAccountStatus(id int, enabled bool) error {
 		return err
 	}
 	if acc == nil {
-		return fmt.Errorf("account not found: %d", id)
+		return fmt.Errorf("account %w: %d", ErrNotFound, id)
 	}
 	if err := ToggleAccountStatusBun(s.bun, id, enabled); err == nil {
-		_ = s.LogAction("TOGGLE_ACCOUNT_STATUS", fmt.Sprintf("account: %s@%s, new_status: %t", acc.Username, acc.Hostname, enabled))
+		_ = s.LogAction("TOGGLE_ACCOUNT_STATUS", fmt.Sprintf("account: %s@%s, %s, new_status: %t", acc.Username, acc.Hostname, model.AccountRef(id), enabled))
 		return nil
 	} else {
 		return err
@@ -74,7 +84,7 @@ func (s *BunStore) ToggleAccountStatus(id int, enabled bool) error {
 func (s *BunStore) UpdateAccountLabel(id int, label string) error {
 	err := UpdateAccountLabelBun(s.bun, id, label)
 	if err == nil {
-		_ = s.LogAction("UPDATE_ACCOUNT_LABEL", fmt.Sprintf("account_id: %d, new_label: '%s'", id, label))
+		_ = s.LogAction("UPDATE_ACCOUNT_LABEL", fmt.Sprintf("%s, new_label: '%s'", model.AccountRef(id), label))
 	}
 	return err
 }
@@ -84,19 +94,26 @@ func (s *BunStore) UpdateAccountHostname(id int, hostname string) error {
 func (s *BunStore) UpdateAccountTags(id int, tags string) error {
 	err := UpdateAccountTagsBun(s.bun, id, tags)
 	if err == nil {
-		_ = s.LogAction("UPDATE_ACCOUNT_TAGS", fmt.Sprintf("account_id: %d, new_tags: '%s'", id, tags))
+		_ = s.LogAction("UPDATE_ACCOUNT_TAGS", fmt.Sprintf("%s, new_tags: '%s'", model.AccountRef(id), tags))
 	}
 	return err
 }
 func (s *BunStore) UpdateAccountIsDirty(id int, dirty bool) error {
 	return UpdateAccountIsDirtyBun(s.bun, id, dirty)
 }
+func (s *BunStore) GetAccountsBelowSerial(serial int) ([]model.Account, error) {
+	return GetAccountsBelowSerialBun(s.bun, serial)
+}
+
 func (s *BunStore) GetAllActiveAccounts() ([]model.Account, error) {
 	return GetAllActiveAccountsBun(s.bun)
 }
 func (s *BunStore) GetKnownHostKey(hostname string) (string, error) {
 	return GetKnownHostKeyBun(s.bun, hostname)
 }
+func (s *BunStore) GetAllKnownHosts() ([]model.KnownHost, error) {
+	return GetAllKnownHostsBun(s.bun)
+}
 func (s *BunStore) AddKnownHostKey(hostname, key string) error {
 	err := AddKnownHostKeyBun(s.bun, hostname, key)
 	if err == nil {
@@ -107,14 +124,14 @@ func (s *BunStore) AddKnownHostKey(hostname, key string) error {
 func (s *BunStore) CreateSystemKey(publicKey, privateKey string) (int, error) {
 	newSerial, err := CreateSystemKeyBun(s.bun, publicKey, privateKey)
 	if err == nil {
-		_ = s.LogAction("CREATE_SYSTEM_KEY", fmt.Sprintf("serial: %d", newSerial))
+		_ = s.LogActionFields("CREATE_SYSTEM_KEY", fmt.Sprintf("serial: %d", newSerial), model.AuditFields{model.AuditFieldSerial: strconv.Itoa(newSerial)})
 	}
 	return newSerial, err
 }
 func (s *BunStore) RotateSystemKey(publicKey, privateKey string) (int, error) {
 	newSerial, err := RotateSystemKeyBun(s.bun, publicKey, privateKey)
 	if err == nil {
-		_ = s.LogAction("ROTATE_SYSTEM_KEY", fmt.Sprintf("new_serial: %d", newSerial))
+		_ = s.LogActionFields("ROTATE_SYSTEM_KEY", fmt.Sprintf("new_serial: %d", newSerial), model.AuditFields{model.AuditFieldSerial: strconv.Itoa(newSerial)})
 	}
 	return newSerial, err
 }
@@ -125,15 +142,95 @@ func (s *BunStore) GetSystemKeyBySerial(serial int) (*model.SystemKey, error) {
 	return GetSystemKeyBySerialBun(s.bun, serial)
 }
 func (s *BunStore) HasSystemKeys() (bool, error) { return HasSystemKeysBun(s.bun) }
+func (s *BunStore) GetAllSystemKeys() ([]model.SystemKey, error) {
+	return GetAllSystemKeysBun(s.bun)
+}
+func (s *BunStore) DeleteSystemKey(serial int) error { return DeleteSystemKeyBun(s.bun, serial) }
+func (s *BunStore) DeactivateSystemKeysBelow(serial int) (int, error) {
+	n, err := DeactivateSystemKeysBelowBun(s.bun, serial)
+	if err == nil && n > 0 {
+		_ = s.LogActionFields("FINALIZE_SYSTEM_KEY_ROTATION", fmt.Sprintf("serial: %d, deactivated: %d", serial, n), model.AuditFields{model.AuditFieldSerial: strconv.Itoa(serial)})
+	}
+	return n, err
+}
+func (s *BunStore) SetAccountSchedule(id int, disableAt, enableAt time.Time) error {
+	return SetAccountScheduleBun(s.bun, id, disableAt, enableAt)
+}
+
+func (s *BunStore) SetAccountRemediationPolicy(id int, policy string) error {
+	return SetAccountRemediationPolicyBun(s.bun, id, policy)
+}
+
+func (s *BunStore) SetAccountDeployEnabled(id int, enabled bool) error {
+	return SetAccountDeployEnabledBun(s.bun, id, enabled)
+}
+
+func (s *BunStore) SetAccountManageSystemKey(id int, manage bool) error {
+	return SetAccountManageSystemKeyBun(s.bun, id, manage)
+}
+
+func (s *BunStore) SetAccountLastAudit(id int, at time.Time) error {
+	return SetAccountLastAuditBun(s.bun, id, at)
+}
+
+func (s *BunStore) SetAccountOSFamily(id int, family string) error {
+	return SetAccountOSFamilyBun(s.bun, id, family)
+}
+
+func (s *BunStore) SetAccountDeployKnownHosts(id int, enabled bool) error {
+	return SetAccountDeployKnownHostsBun(s.bun, id, enabled)
+}
+
+func (s *BunStore) SetAccountExcludedGlobalKeys(id int, keyIDs []int) error {
+	return SetAccountExcludedGlobalKeysBun(s.bun, id, keyIDs)
+}
+
+func (s *BunStore) SetAccountAuthorizedPrincipals(id int, principals []string) error {
+	return SetAccountAuthorizedPrincipalsBun(s.bun, id, principals)
+}
+
+func (s *BunStore) SetAccountGroup(id int, group string) error {
+	return SetAccountGroupBun(s.bun, id, group)
+}
+
+func (s *BunStore) SetAccountMetadata(id int, metadata map[string]string) error {
+	return SetAccountMetadataBun(s.bun, id, metadata)
+}
+func (s *BunStore) SetAccountDeployMode(id int, mode string) error {
+	return SetAccountDeployModeBun(s.bun, id, mode)
+}
+
+func (s *BunStore) SetAccountDeployedKeys(id int, keys []string) error {
+	return SetAccountDeployedKeysBun(s.bun, id, keys)
+}
+
+func (s *BunStore) GetUnassignedPublicKeys() ([]model.PublicKey, error) {
+	return GetUnassignedPublicKeysBun(s.bun)
+}
 func (s *BunStore) SearchAccounts(query string) ([]model.Account, error) {
 	return NewBunAccountSearcher(s.bun).SearchAccounts(query)
 }
 func (s *BunStore) GetAllAuditLogEntries() ([]model.AuditLogEntry, error) {
 	return GetAllAuditLogEntriesBun(s.bun)
 }
+func (s *BunStore) GetAuditLogForAccount(accountID int) ([]model.AuditLogEntry, error) {
+	return GetAuditLogForAccountBun(s.bun, accountID)
+}
+func (s *BunStore) RecordOperationResult(r model.OperationResult) error {
+	return RecordOperationResultBun(s.bun, r)
+}
+func (s *BunStore) GetOperationResults(accountID int, since time.Time) ([]model.OperationResult, error) {
+	return GetOperationResultsBun(s.bun, accountID, since)
+}
 func (s *BunStore) LogAction(action string, details string) error {
 	return LogActionBun(s.bun, action, details)
 }
+
+// LogActionFields records an audit event with a human summary and
+// structured fields.
+func (s *BunStore) LogActionFields(action, summary string, fields model.AuditFields) error {
+	return LogActionBun(s.bun, action, model.EncodeAuditDetails(summary, fields))
+}
 func (s *BunStore) SaveBootstrapSession(id, username, hostname, label, tags, tempPublicKey string, expiresAt time.Time, status string) error {
 	return SaveBootstrapSessionBun(s.bun, id, username, hostname, label, tags, tempPublicKey, expiresAt, status)
 }
@@ -155,10 +252,16 @@ func (s *BunStore) GetOrphanedBootstrapSessions() ([]*model.BootstrapSession, er
 func (s *BunStore) ExportDataForBackup() (*model.BackupData, error) {
 	return ExportDataForBackupBun(s.bun)
 }
+func (s *BunStore) ExportTablesForBackup(tables []string) (*model.BackupData, error) {
+	return ExportTablesForBackupBun(s.bun, tables)
+}
+func (s *BunStore) StreamBackup(tables []string, fn func(table string, row any) error) error {
+	return StreamBackupBun(s.bun, tables, fn)
+}
 func (s *BunStore) ImportDataFromBackup(backup *model.BackupData) error {
 	return ImportDataFromBackupBun(s.bun, backup)
 }
-func (s *BunStore) IntegrateDataFromBackup(backup *model.BackupData) error {
+func (s *BunStore) IntegrateDataFromBackup(backup *model.BackupData) (model.RestoreSummary, error) {
 	return IntegrateDataFromBackupBun(s.bun, backup)
 }
 
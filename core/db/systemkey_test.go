@@ -4,7 +4,9 @@
 package db
 
 import (
+	"errors"
 	"testing"
+	"time"
 )
 
 func TestSystemKey_CreateRotateAndActive(t *testing.T) {
@@ -88,3 +90,81 @@ func TestSystemKey_CreateRotateAndActive(t *testing.T) {
 		t.Fatalf("expected to find system key for serial %d", s2)
 	}
 }
+
+func TestGetAllSystemKeys_ListsRetainedKeysWithOneActive(t *testing.T) {
+	_ = newTestDB(t)
+
+	keys, err := GetAllSystemKeys()
+	if err != nil {
+		t.Fatalf("GetAllSystemKeys error: %v", err)
+	}
+	if len(keys) != 0 {
+		t.Fatalf("expected no system keys initially, got %d", len(keys))
+	}
+
+	before := time.Now().Add(-time.Minute)
+	if _, err := CreateSystemKey("pub1", "priv1"); err != nil {
+		t.Fatalf("CreateSystemKey failed: %v", err)
+	}
+	for _, pub := range []string{"pub2", "pub3"} {
+		if _, err := RotateSystemKey(pub, "priv-"+pub); err != nil {
+			t.Fatalf("RotateSystemKey failed: %v", err)
+		}
+	}
+
+	keys, err = GetAllSystemKeys()
+	if err != nil {
+		t.Fatalf("GetAllSystemKeys error: %v", err)
+	}
+	if len(keys) != 3 {
+		t.Fatalf("expected 3 system keys, got %d", len(keys))
+	}
+	active := 0
+	for i, k := range keys {
+		if i > 0 && k.Serial <= keys[i-1].Serial {
+			t.Fatalf("expected keys ordered by serial, got %d after %d", k.Serial, keys[i-1].Serial)
+		}
+		if k.IsActive {
+			active++
+			if k.PublicKey != "pub3" {
+				t.Fatalf("expected latest key to be active, got %q", k.PublicKey)
+			}
+		}
+		if k.CreatedAt.Before(before) {
+			t.Fatalf("expected created_at to be recorded for serial %d, got %v", k.Serial, k.CreatedAt)
+		}
+	}
+	if active != 1 {
+		t.Fatalf("expected exactly one active key, got %d", active)
+	}
+}
+
+func TestDeleteSystemKey_NeverDeletesActiveKey(t *testing.T) {
+	_ = newTestDB(t)
+
+	if _, err := CreateSystemKey("pub1", "priv1"); err != nil {
+		t.Fatalf("CreateSystemKey failed: %v", err)
+	}
+	active, err := RotateSystemKey("pub2", "priv2")
+	if err != nil {
+		t.Fatalf("RotateSystemKey failed: %v", err)
+	}
+
+	if err := DeleteSystemKey(active); !errors.Is(err, ErrActiveSystemKey) {
+		t.Fatalf("expected ErrActiveSystemKey, got %v", err)
+	}
+	if err := DeleteSystemKey(1); err != nil {
+		t.Fatalf("DeleteSystemKey(1) failed: %v", err)
+	}
+	if err := DeleteSystemKey(1); err == nil {
+		t.Fatalf("expected error deleting a missing key")
+	}
+
+	keys, err := GetAllSystemKeys()
+	if err != nil {
+		t.Fatalf("GetAllSystemKeys error: %v", err)
+	}
+	if len(keys) != 1 || keys[0].Serial != active {
+		t.Fatalf("expected only the active key to remain, got %+v", keys)
+	}
+}
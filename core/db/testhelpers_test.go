@@ -13,13 +13,13 @@ func WithTestStore(t *testing.T, fn func(s *BunStore)) {
 	t.Helper()
 
 	// Save previous globals
-	prevStore := store
-	prevDefaultSearcher := defaultSearcher
-	prevDefaultAuditSearcher := defaultAuditSearcher
-	prevDefaultKeySearcher := defaultKeySearcher
-	prevDefaultAccountManager := defaultAccountManager
-	prevDefaultKeyManager := defaultKeyManager
-	prevDefaultAuditWriter := defaultAuditWriter
+	prevStore := currentStore()
+	prevDefaultSearcher := getDefault(&defaultSearcher)
+	prevDefaultAuditSearcher := getDefault(&defaultAuditSearcher)
+	prevDefaultKeySearcher := getDefault(&defaultKeySearcher)
+	prevDefaultAccountManager := getDefault(&defaultAccountManager)
+	prevDefaultKeyManager := getDefault(&defaultKeyManager)
+	prevDefaultAuditWriter := getDefault(&defaultAuditWriter)
 	prevAuditContext := getAuditContext()
 
 	// Initialize in-memory sqlite DB for this test
@@ -27,20 +27,20 @@ func WithTestStore(t *testing.T, fn func(s *BunStore)) {
 	if _, err := New("sqlite", dsn); err != nil {
 		t.Fatalf("db.New failed: %v", err)
 	}
-	s, ok := store.(*BunStore)
+	s, ok := currentStore().(*BunStore)
 	if !ok {
 		t.Fatalf("store is not *BunStore")
 	}
 
 	// Ensure restoration of globals after fn completes
 	defer func() {
-		store = prevStore
-		defaultSearcher = prevDefaultSearcher
-		defaultAuditSearcher = prevDefaultAuditSearcher
-		defaultKeySearcher = prevDefaultKeySearcher
-		defaultAccountManager = prevDefaultAccountManager
-		defaultKeyManager = prevDefaultKeyManager
-		defaultAuditWriter = prevDefaultAuditWriter
+		setStore(prevStore)
+		setDefault(&defaultSearcher, prevDefaultSearcher)
+		setDefault(&defaultAuditSearcher, prevDefaultAuditSearcher)
+		setDefault(&defaultKeySearcher, prevDefaultKeySearcher)
+		setDefault(&defaultAccountManager, prevDefaultAccountManager)
+		setDefault(&defaultKeyManager, prevDefaultKeyManager)
+		setDefault(&defaultAuditWriter, prevDefaultAuditWriter)
 		SetAuditContext(prevAuditContext.ClientImplementation, prevAuditContext.Referrer)
 	}()
 
@@ -51,8 +51,8 @@ func WithTestStore(t *testing.T, fn func(s *BunStore)) {
 // duration of fn and restores the previous writer afterwards.
 func WithAuditWriter(t *testing.T, w AuditWriter, fn func()) {
 	t.Helper()
-	prev := defaultAuditWriter
-	defaultAuditWriter = w
-	defer func() { defaultAuditWriter = prev }()
+	prev := getDefault(&defaultAuditWriter)
+	setDefault(&defaultAuditWriter, w)
+	defer func() { setDefault(&defaultAuditWriter, prev) }()
 	fn()
 }
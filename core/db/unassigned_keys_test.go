@@ -0,0 +1,42 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetUnassignedPublicKeysBun(t *testing.T) {
+	WithTestStore(t, func(s *BunStore) {
+		bdb := s.BunDB()
+
+		aid, err := AddAccountBun(bdb, "u", "h", "", "")
+		if err != nil {
+			t.Fatalf("AddAccountBun: %v", err)
+		}
+		assigned, err := AddPublicKeyAndGetModelBun(bdb, "ssh-ed25519", "A", "assigned", false, time.Time{})
+		if err != nil {
+			t.Fatalf("add assigned: %v", err)
+		}
+		if err := AssignKeyToAccountBun(bdb, assigned.ID, aid); err != nil {
+			t.Fatalf("assign: %v", err)
+		}
+		if _, err := AddPublicKeyAndGetModelBun(bdb, "ssh-ed25519", "G", "global", true, time.Time{}); err != nil {
+			t.Fatalf("add global: %v", err)
+		}
+		orphan, err := AddPublicKeyAndGetModelBun(bdb, "ssh-ed25519", "O", "orphan", false, time.Time{})
+		if err != nil {
+			t.Fatalf("add orphan: %v", err)
+		}
+
+		keys, err := s.GetUnassignedPublicKeys()
+		if err != nil {
+			t.Fatalf("GetUnassignedPublicKeys: %v", err)
+		}
+		if len(keys) != 1 || keys[0].ID != orphan.ID {
+			t.Fatalf("expected only orphan key, got %+v", keys)
+		}
+	})
+}
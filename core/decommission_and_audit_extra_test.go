@@ -63,13 +63,20 @@ func (f *fakeStoreAudit) AssignKeyToAccount(keyID, accountID int) error
 func (f *fakeStoreAudit) UpdateAccountIsDirty(id int, dirty bool) error             { return f.updateErr }
 func (f *fakeStoreAudit) CreateSystemKey(publicKey, privateKey string) (int, error) { return 0, nil }
 func (f *fakeStoreAudit) RotateSystemKey(publicKey, privateKey string) (int, error) { return 0, nil }
+func (f *fakeStoreAudit) GetAllSystemKeys() ([]model.SystemKey, error)              { return nil, nil }
+func (f *fakeStoreAudit) DeleteSystemKey(serial int) error                          { return nil }
 func (f *fakeStoreAudit) GetActiveSystemKey() (*model.SystemKey, error) {
 	return &model.SystemKey{Serial: 1, PublicKey: "k"}, nil
 }
 func (f *fakeStoreAudit) AddKnownHostKey(hostname, key string) error      { return nil }
 func (f *fakeStoreAudit) ExportDataForBackup() (*model.BackupData, error) { return nil, nil }
-func (f *fakeStoreAudit) ImportDataFromBackup(*model.BackupData) error    { return nil }
-func (f *fakeStoreAudit) IntegrateDataFromBackup(*model.BackupData) error { return nil }
+func (f *fakeStoreAudit) ExportTablesForBackup(tables []string) (*model.BackupData, error) {
+	return nil, nil
+}
+func (f *fakeStoreAudit) ImportDataFromBackup(*model.BackupData) error { return nil }
+func (f *fakeStoreAudit) IntegrateDataFromBackup(*model.BackupData) (model.RestoreSummary, error) {
+	return model.RestoreSummary{}, nil
+}
 
 // satisfy updated Store interface
 func (f *fakeStoreAudit) ToggleAccountStatus(id int, enabled bool) error      { return nil }
@@ -21,6 +21,11 @@ var (
 	defaultKeyImporter          KeyImporter
 	defaultAuditWriter          AuditWriter
 	defaultAccountManager       AccountManager
+	defaultAccountReader        AccountReader
+	defaultDeployedKeysRecorder DeployedKeysRecorder
+	defaultLastAuditRecorder    LastAuditRecorder
+	defaultKnownHostLister      KnownHostLister
+	defaultOperationRecorder    OperationResultRecorder
 	defaultDBInit               func(dbType, dsn string) error
 	defaultDBIsInitialized      func() bool
 )
@@ -90,6 +95,36 @@ func SetDefaultAccountManager(a AccountManager) {
 	db.SetDefaultAccountManager(a)
 }
 
+// DefaultAccountReader returns the package-level AccountReader if set.
+func DefaultAccountReader() AccountReader { return defaultAccountReader }
+
+// SetDefaultAccountReader sets the package-level AccountReader used by core helpers.
+func SetDefaultAccountReader(r AccountReader) { defaultAccountReader = r }
+
+// DefaultDeployedKeysRecorder returns the package-level DeployedKeysRecorder if set.
+func DefaultDeployedKeysRecorder() DeployedKeysRecorder { return defaultDeployedKeysRecorder }
+
+// SetDefaultDeployedKeysRecorder sets the package-level DeployedKeysRecorder used by core helpers.
+func SetDefaultDeployedKeysRecorder(r DeployedKeysRecorder) { defaultDeployedKeysRecorder = r }
+
+// DefaultKnownHostLister returns the package-level KnownHostLister if set.
+func DefaultKnownHostLister() KnownHostLister { return defaultKnownHostLister }
+
+// SetDefaultKnownHostLister sets the package-level KnownHostLister used by core helpers.
+func SetDefaultKnownHostLister(l KnownHostLister) { defaultKnownHostLister = l }
+
+// DefaultLastAuditRecorder returns the package-level LastAuditRecorder if set.
+func DefaultLastAuditRecorder() LastAuditRecorder { return defaultLastAuditRecorder }
+
+// SetDefaultLastAuditRecorder sets the package-level LastAuditRecorder used by core helpers.
+func SetDefaultLastAuditRecorder(r LastAuditRecorder) { defaultLastAuditRecorder = r }
+
+// DefaultOperationResultRecorder returns the package-level OperationResultRecorder if set.
+func DefaultOperationResultRecorder() OperationResultRecorder { return defaultOperationRecorder }
+
+// SetDefaultOperationResultRecorder sets the package-level OperationResultRecorder used by core helpers.
+func SetDefaultOperationResultRecorder(r OperationResultRecorder) { defaultOperationRecorder = r }
+
 // DefaultInitDB delegates DB initialization to the injected function if present.
 func DefaultInitDB(dbType, dsn string) error {
 	if defaultDBInit == nil {
@@ -14,11 +14,16 @@ import (
 
 // Compile-time interface checks
 var (
-	_ core.KeyLister            = (*coreKeyLister)(nil)        // coreKeyLister implements core.KeyLister
-	_ core.AccountSerialUpdater = (*accountSerialUpdater)(nil) // accountSerialUpdater implements core.AccountSerialUpdater
-	_ core.KeyImporter          = (*keyImporter)(nil)          // keyImporter implements core.KeyImporter
-	_ core.AccountManager       = (*coreAccountManager)(nil)   // coreAccountManager implements core.AccountManager
-	_ core.AuditWriter          = (*coreAuditWriter)(nil)      // coreAuditWriter implements core.AuditWriter
+	_ core.KeyLister               = (*coreKeyLister)(nil)           // coreKeyLister implements core.KeyLister
+	_ core.AccountSerialUpdater    = (*accountSerialUpdater)(nil)    // accountSerialUpdater implements core.AccountSerialUpdater
+	_ core.KeyImporter             = (*keyImporter)(nil)             // keyImporter implements core.KeyImporter
+	_ core.AccountManager          = (*coreAccountManager)(nil)      // coreAccountManager implements core.AccountManager
+	_ core.AuditWriter             = (*coreAuditWriter)(nil)         // coreAuditWriter implements core.AuditWriter
+	_ core.AccountReader           = (*coreAccountReader)(nil)       // coreAccountReader implements core.AccountReader
+	_ core.DeployedKeysRecorder    = (*deployedKeysRecorder)(nil)    // deployedKeysRecorder implements core.DeployedKeysRecorder
+	_ core.LastAuditRecorder       = (*lastAuditRecorder)(nil)       // lastAuditRecorder implements core.LastAuditRecorder
+	_ core.KnownHostLister         = (*knownHostLister)(nil)         // knownHostLister implements core.KnownHostLister
+	_ core.OperationResultRecorder = (*operationResultRecorder)(nil) // operationResultRecorder implements core.OperationResultRecorder
 )
 
 // Wire DB-backed adapters into core defaults for packages that import
@@ -37,6 +42,10 @@ func (coreKeyReader) GetAllPublicKeys() ([]model.PublicKey, error) {
 	return nil, fmt.Errorf("no key manager available")
 }
 
+type coreAccountReader struct{}
+
+func (coreAccountReader) GetAccount(id int) (*model.Account, error) { return db.GetAccount(id) }
+
 type coreKeyLister struct{}
 
 func (coreKeyLister) GetGlobalPublicKeys() ([]model.PublicKey, error) {
@@ -55,6 +64,42 @@ func (coreKeyLister) GetAllPublicKeys() ([]model.PublicKey, error) {
 	return coreKeyReader{}.GetAllPublicKeys()
 }
 
+type deployedKeysRecorder struct{}
+
+func (deployedKeysRecorder) SetAccountDeployedKeys(id int, keys []string) error {
+	if !db.IsInitialized() {
+		return fmt.Errorf("store not initialized")
+	}
+	return db.SetAccountDeployedKeys(id, keys)
+}
+
+type knownHostLister struct{}
+
+func (knownHostLister) GetAllKnownHosts() ([]model.KnownHost, error) {
+	if !db.IsInitialized() {
+		return nil, fmt.Errorf("store not initialized")
+	}
+	return db.GetAllKnownHosts()
+}
+
+type operationResultRecorder struct{}
+
+func (operationResultRecorder) RecordOperationResult(r model.OperationResult) error {
+	if !db.IsInitialized() {
+		return fmt.Errorf("store not initialized")
+	}
+	return db.RecordOperationResult(r)
+}
+
+type lastAuditRecorder struct{}
+
+func (lastAuditRecorder) SetAccountLastAudit(id int, at time.Time) error {
+	if !db.IsInitialized() {
+		return fmt.Errorf("store not initialized")
+	}
+	return db.SetAccountLastAudit(id, at)
+}
+
 type accountSerialUpdater struct{}
 
 func (accountSerialUpdater) UpdateAccountSerial(accountID int, serial int) error {
@@ -103,7 +148,12 @@ func (coreAccountManager) AddAccount(username, hostname, label, tags string) (in
 func InitializeDefaults() {
 	core.SetDefaultKeyReader(coreKeyReader{})
 	core.SetDefaultKeyLister(coreKeyLister{})
+	core.SetDefaultAccountReader(coreAccountReader{})
 	core.SetDefaultAccountSerialUpdater(accountSerialUpdater{})
+	core.SetDefaultDeployedKeysRecorder(deployedKeysRecorder{})
+	core.SetDefaultLastAuditRecorder(lastAuditRecorder{})
+	core.SetDefaultKnownHostLister(knownHostLister{})
+	core.SetDefaultOperationResultRecorder(operationResultRecorder{})
 	core.SetDefaultKeyImporter(keyImporter{})
 	core.SetDefaultAuditWriter(coreAuditWriter{})
 	core.SetDefaultAccountManager(coreAccountManager{})
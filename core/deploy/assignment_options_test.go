@@ -0,0 +1,69 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package deploy
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toeirei/keymaster/core/db"
+)
+
+func TestGenerateKeysContent_AssignmentOptions(t *testing.T) {
+	if _, err := db.New("sqlite", ":memory:"); err != nil {
+		t.Fatalf("db.New failed: %v", err)
+	}
+	if _, err := db.CreateSystemKey("sys-pub-opts", "sys-priv-opts"); err != nil {
+		t.Fatalf("CreateSystemKey failed: %v", err)
+	}
+	am := db.DefaultAccountManager()
+	backupID, err := am.AddAccount("backup", "nas.local", "", "")
+	if err != nil {
+		t.Fatalf("AddAccount failed: %v", err)
+	}
+	shellID, err := am.AddAccount("ops", "web.local", "", "")
+	if err != nil {
+		t.Fatalf("AddAccount failed: %v", err)
+	}
+	km := db.DefaultKeyManager()
+	pk, err := km.AddPublicKeyAndGetModel("ssh-ed25519", "AAAAC3NzaC1lZDI1NTE5AAAAIOptionsTestKey", "alice", false, time.Time{})
+	if err != nil {
+		t.Fatalf("AddPublicKeyAndGetModel failed: %v", err)
+	}
+	opts := `command="rsync --server --sender . /srv/backup",no-pty`
+	if err := km.AssignKeyToAccountWithOptions(pk.ID, backupID, opts); err != nil {
+		t.Fatalf("AssignKeyToAccountWithOptions failed: %v", err)
+	}
+	if err := km.AssignKeyToAccount(pk.ID, shellID); err != nil {
+		t.Fatalf("AssignKeyToAccount failed: %v", err)
+	}
+
+	line := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOptionsTestKey alice"
+	restricted, err := GenerateKeysContent(backupID)
+	if err != nil {
+		t.Fatalf("GenerateKeysContent failed: %v", err)
+	}
+	if !strings.Contains(restricted, "\n"+opts+" "+line+"\n") {
+		t.Fatalf("expected restricted key line on the backup account, got:\n%s", restricted)
+	}
+	plain, err := GenerateKeysContent(shellID)
+	if err != nil {
+		t.Fatalf("GenerateKeysContent failed: %v", err)
+	}
+	if !strings.Contains(plain, "\n"+line+"\n") || strings.Contains(plain, "rsync") {
+		t.Fatalf("expected an unrestricted key line on the shell account, got:\n%s", plain)
+	}
+
+	ciID, err := am.AddAccount("ci", "ci.local", "", "")
+	if err != nil {
+		t.Fatalf("AddAccount failed: %v", err)
+	}
+	if err := km.AssignKeyToAccountWithOptions(pk.ID, ciID, "no-pty extra"); err == nil {
+		t.Fatal("expected malformed options to be rejected")
+	}
+	if keys, _ := km.GetKeysForAccount(ciID); len(keys) != 0 {
+		t.Fatalf("expected no assignment after rejected options, got %+v", keys)
+	}
+}
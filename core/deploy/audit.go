@@ -49,6 +49,7 @@ func AuditAccountStrict(account model.Account) error {
 		return fmt.Errorf(i18n.T("audit.error_connection_failed"), account.Serial, err)
 	}
 	defer deployer.Close()
+	deployer.SetOSFamily(account.EffectiveOSFamily())
 	// Once the deployer is successfully created, the passphrase has been used.
 	// We must clear it from the global cache immediately so it's not accidentally
 	// reused by another operation that doesn't need it.
@@ -110,6 +111,7 @@ func AuditAccountSerial(account model.Account) error {
 		return fmt.Errorf(i18n.T("audit.error_connection_failed"), account.Serial, err)
 	}
 	defer deployer.Close()
+	deployer.SetOSFamily(account.EffectiveOSFamily())
 	// Once the deployer is successfully created, the passphrase has been used.
 	// We must clear it from the global cache immediately so it's not accidentally
 	// reused by another operation that doesn't need it.
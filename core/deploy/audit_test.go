@@ -4,6 +4,7 @@
 package deploy_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/toeirei/keymaster/core"
@@ -122,4 +123,58 @@ func TestAuditAccountStrict_Match(t *testing.T) {
 	}
 }
 
+func TestAuditAccountStrict_CoManagedWithoutSystemKey(t *testing.T) {
+	if _, err := db.New("sqlite", ":memory:"); err != nil {
+		t.Fatalf("db.New failed: %v", err)
+	}
+	i18n.Init("en")
+
+	mgr := db.DefaultAccountManager()
+	if mgr == nil {
+		t.Fatal("no account manager")
+	}
+	acctID, err := mgr.AddAccount("u4", "host4.local", "lbl", "")
+	if err != nil {
+		t.Fatalf("AddAccount failed: %v", err)
+	}
+	serial, err := db.CreateSystemKey("sys-pub-audit4", "sys-priv-audit4")
+	if err != nil {
+		t.Fatalf("CreateSystemKey failed: %v", err)
+	}
+	if err := db.UpdateAccountSerial(acctID, serial); err != nil {
+		t.Fatalf("UpdateAccountSerial failed: %v", err)
+	}
+	if err := db.SetAccountManageSystemKey(acctID, false); err != nil {
+		t.Fatalf("SetAccountManageSystemKey failed: %v", err)
+	}
+
+	// The co-managed host carries the serial header but no system key line.
+	remoteContent, err := core.GenerateKeysContent(acctID)
+	if err != nil {
+		t.Fatalf("GenerateKeysContent failed: %v", err)
+	}
+	if strings.Contains(remoteContent, "sys-pub-audit4") {
+		t.Fatalf("expected content without the system key, got %q", remoteContent)
+	}
+
+	origFactory := core.NewDeployerFactory
+	core.NewDeployerFactory = func(host, user string, privateKey security.Secret, passphrase []byte) (core.RemoteDeployer, error) {
+		return &fakeDeployer{content: []byte(remoteContent)}, nil
+	}
+	defer func() { core.NewDeployerFactory = origFactory }()
+
+	acct := model.Account{ID: acctID, Username: "u4", Hostname: "host4.local", Serial: serial}
+	if err := core.AuditAccountStrict(acct); err != nil {
+		t.Fatalf("expected co-managed account to audit clean, got: %v", err)
+	}
+
+	// Once Keymaster manages the system key again, the same host drifts.
+	if err := db.SetAccountManageSystemKey(acctID, true); err != nil {
+		t.Fatalf("SetAccountManageSystemKey failed: %v", err)
+	}
+	if err := core.AuditAccountStrict(acct); err == nil {
+		t.Fatal("expected drift when the system key is managed, but AuditAccountStrict returned nil")
+	}
+}
+
 // bytesFromString is provided by testhelpers_test.go
@@ -3,7 +3,10 @@
 // This source code is licensed under the MIT license found in the LICENSE file.
 package deploy
 
-import "github.com/toeirei/keymaster/core/db"
+import (
+	"github.com/toeirei/keymaster/core/db"
+	"github.com/toeirei/keymaster/core/model"
+)
 
 // package-level audit writer override for tests
 var auditWriter db.AuditWriter
@@ -29,3 +32,8 @@ func logAction(action, details string) error {
 	}
 	return nil
 }
+
+// logActionFields writes an audit entry with structured fields via logAction.
+func logActionFields(action, summary string, fields model.AuditFields) error {
+	return logAction(action, model.EncodeAuditDetails(summary, fields))
+}
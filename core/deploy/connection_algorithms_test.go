@@ -0,0 +1,55 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package deploy
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+
+	genssh "github.com/toeirei/keymaster/core/crypto/ssh"
+	"github.com/toeirei/keymaster/core/security"
+	"github.com/toeirei/keymaster/core/sshkey"
+)
+
+func TestDialSSH_AppliesConnectionAlgorithms(t *testing.T) {
+	algs := sshkey.ConnectionAlgorithms{
+		Ciphers:      []string{"aes256-gcm@openssh.com"},
+		MACs:         []string{"hmac-sha2-512-etm@openssh.com"},
+		KeyExchanges: []string{"diffie-hellman-group14-sha256"},
+	}
+	sshkey.SetConnectionAlgorithms(algs)
+	t.Cleanup(func() { sshkey.SetConnectionAlgorithms(sshkey.ConnectionAlgorithms{}) })
+
+	var seen []ssh.Config
+	origDial, origAgent := sshDial, sshAgentGetter
+	t.Cleanup(func() { sshDial, sshAgentGetter = origDial, origAgent })
+	sshAgentGetter = func() agent.Agent { return agent.NewKeyring() }
+	sshDial = func(network, addr string, cfg *ssh.ClientConfig) (sshClientIface, error) {
+		seen = append(seen, cfg.Config)
+		return nil, errors.New("stop before connecting")
+	}
+
+	_, priv, err := genssh.GenerateAndMarshalEd25519Key("test", "")
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	_, _ = GetRemoteHostKeyWithTimeout("probe.example.com", time.Second)
+	_, _ = NewDeployerWithConfig("deploy.example.com", "user", security.FromString(priv), nil, DefaultConnectionConfig(), false)
+	_, _ = newDeployerWithExpectedHostKey("expected.example.com", "user", security.FromString(priv), DefaultConnectionConfig(), "")
+
+	// Probe, system key, agent fallback and expected-host-key connections.
+	if len(seen) != 4 {
+		t.Fatalf("expected 4 dial attempts, got %d", len(seen))
+	}
+	for i, cfg := range seen {
+		if !reflect.DeepEqual(cfg.Ciphers, algs.Ciphers) || !reflect.DeepEqual(cfg.MACs, algs.MACs) || !reflect.DeepEqual(cfg.KeyExchanges, algs.KeyExchanges) {
+			t.Fatalf("dial %d: configured algorithms missing from client config: %+v", i, cfg)
+		}
+	}
+}
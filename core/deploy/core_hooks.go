@@ -5,6 +5,7 @@ package deploy
 
 import (
 	"errors"
+	"os"
 
 	"github.com/toeirei/keymaster/core"
 	"github.com/toeirei/keymaster/core/security"
@@ -35,10 +36,18 @@ func init() {
 		default:
 			sk = nil
 		}
+		var d *Deployer
+		var err error
 		if expectedHostKey != "" {
-			return NewBootstrapDeployerWithExpectedKey(hostname, username, sk, expectedHostKey)
+			d, err = NewBootstrapDeployerWithExpectedKey(hostname, username, sk, expectedHostKey)
+		} else {
+			d, err = NewBootstrapDeployer(hostname, username, sk)
 		}
-		return NewBootstrapDeployer(hostname, username, sk)
+		if err != nil {
+			return nil, err
+		}
+		// The adapter lets core run the bootstrap privilege preflight.
+		return &deployAdapter{inner: d}, nil
 	}
 
 	// Network helper passthroughs.
@@ -52,6 +61,8 @@ func init() {
 		return string(ssh.MarshalAuthorizedKey(pk)), nil
 	}
 
+	core.OpenShellFunc = OpenShell
+
 	core.IsPassphraseRequired = func(err error) bool {
 		return errors.Is(err, ErrPassphraseRequired)
 	}
@@ -62,5 +73,22 @@ type deployAdapter struct{ inner *Deployer }
 func (a *deployAdapter) DeployAuthorizedKeys(content string) error {
 	return a.inner.DeployAuthorizedKeys(content)
 }
+func (a *deployAdapter) DeployKnownHosts(content string) error {
+	return a.inner.DeployKnownHosts(content)
+}
+func (a *deployAdapter) DeployManagedFile(name, content string, mode os.FileMode) error {
+	return a.inner.DeployManagedFile(name, content, mode)
+}
+func (a *deployAdapter) GetManagedFile(name string) ([]byte, error) {
+	return a.inner.GetManagedFile(name)
+}
+func (a *deployAdapter) StatAuthorizedKeys() (file, dir core.RemoteFileStat, err error) {
+	return a.inner.StatAuthorizedKeys()
+}
 func (a *deployAdapter) GetAuthorizedKeys() ([]byte, error) { return a.inner.GetAuthorizedKeys() }
 func (a *deployAdapter) Close()                             { a.inner.Close() }
+func (a *deployAdapter) SetOSFamily(family string)          { a.inner.SetOSFamily(family) }
+func (a *deployAdapter) RunCommand(cmd string) (core.RemoteCommandResult, error) {
+	stdout, stderr, exitCode, err := a.inner.RunCommand(cmd)
+	return core.RemoteCommandResult{Stdout: stdout, Stderr: stderr, ExitCode: exitCode}, err
+}
@@ -9,10 +9,11 @@ package deploy // import "github.com/toeirei/keymaster/core/deploy"
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 
+	"github.com/toeirei/keymaster/core"
 	"github.com/toeirei/keymaster/core/db"
-	"github.com/toeirei/keymaster/core/logging"
 	"github.com/toeirei/keymaster/core/model"
 	"github.com/toeirei/keymaster/core/security"
 	"github.com/toeirei/keymaster/core/state"
@@ -88,14 +89,14 @@ func DecommissionAccount(account model.Account, systemKey security.Secret, optio
 
 	// Log the decommission attempt
 	auditAction := "DECOMMISSION_START"
-	auditDetails := fmt.Sprintf("Starting decommission of account %s (ID: %d)", account.String(), account.ID)
+	auditDetails := fmt.Sprintf("Starting decommission of account %s", account.String())
 	if options.DryRun {
 		auditAction = "DECOMMISSION_DRYRUN"
-		auditDetails = fmt.Sprintf("DRY RUN: Would decommission account %s (ID: %d)", account.String(), account.ID)
+		auditDetails = fmt.Sprintf("DRY RUN: Would decommission account %s", account.String())
 	}
-	if err := logAction(auditAction, auditDetails); err != nil {
+	if err := logActionFields(auditAction, auditDetails, decommissionAuditFields(account, nil)); err != nil {
 		// Log the error but continue - audit logging shouldn't block decommission
-		logging.Warnf("Failed to log audit entry: %v", err)
+		core.DefaultLogger().Warn("failed to write audit entry", "action", auditAction, "err", err)
 	}
 
 	if options.DryRun {
@@ -122,8 +123,8 @@ func DecommissionAccount(account model.Account, systemKey security.Secret, optio
 				result.SkipReason = fmt.Sprintf("remote cleanup failed and --force not specified: %v", err)
 
 				// Log the failure
-				_ = logAction("DECOMMISSION_FAILED",
-					fmt.Sprintf("Failed to decommission %s: %v", account.String(), err))
+				_ = logActionFields("DECOMMISSION_FAILED",
+					fmt.Sprintf("Failed to decommission %s: %v", account.String(), err), decommissionAuditFields(account, err))
 				return result
 			}
 			// With --force, we continue despite remote cleanup failure
@@ -134,31 +135,48 @@ func DecommissionAccount(account model.Account, systemKey security.Secret, optio
 	mgr := db.DefaultAccountManager()
 	if mgr == nil {
 		result.DatabaseDeleteError = fmt.Errorf("no account manager configured")
-		_ = logAction("DECOMMISSION_FAILED",
-			fmt.Sprintf("Failed to delete account %s from database: %v", account.String(), result.DatabaseDeleteError))
+		_ = logActionFields("DECOMMISSION_FAILED",
+			fmt.Sprintf("Failed to delete account %s from database: %v", account.String(), result.DatabaseDeleteError), decommissionAuditFields(account, result.DatabaseDeleteError))
 		return result
 	}
 	if err := mgr.DeleteAccount(account.ID); err != nil {
 		result.DatabaseDeleteError = err
-		_ = logAction("DECOMMISSION_FAILED",
-			fmt.Sprintf("Failed to delete account %s from database: %v", account.String(), err))
+		_ = logActionFields("DECOMMISSION_FAILED",
+			fmt.Sprintf("Failed to delete account %s from database: %v", account.String(), err), decommissionAuditFields(account, err))
 		return result
 	}
 	result.DatabaseDeleteDone = true
 
 	// Log successful decommission
-	details := fmt.Sprintf("Successfully decommissioned account %s (ID: %d)", account.String(), account.ID)
+	details := fmt.Sprintf("Successfully decommissioned account %s", account.String())
 	if result.RemoteCleanupError != nil {
 		details += fmt.Sprintf(" - Warning: remote cleanup failed: %v", result.RemoteCleanupError)
 	}
 	if result.BackupPath != "" {
 		details += fmt.Sprintf(" - Backup created: %s", result.BackupPath)
 	}
-	_ = logAction("DECOMMISSION_SUCCESS", details)
+	fields := decommissionAuditFields(account, result.RemoteCleanupError)
+	if result.BackupPath != "" {
+		fields["backup"] = result.BackupPath
+	}
+	_ = logActionFields("DECOMMISSION_SUCCESS", details, fields)
 
 	return result
 }
 
+// decommissionAuditFields returns the structured audit fields of a
+// decommission event; err is recorded when non-nil.
+func decommissionAuditFields(account model.Account, err error) model.AuditFields {
+	fields := model.AuditFields{
+		model.AuditFieldAccountID: strconv.Itoa(account.ID),
+		model.AuditFieldAccount:   account.String(),
+	}
+	if err != nil {
+		fields[model.AuditFieldError] = err.Error()
+	}
+	return fields
+}
+
 // cleanupRemoteAuthorizedKeys connects to the remote host and removes the authorized_keys file
 func cleanupRemoteAuthorizedKeys(account model.Account, systemKey security.Secret, keepFile bool, result *DecommissionResult) error {
 	// Get passphrase from cache and ensure it's wiped after use.
@@ -175,6 +193,7 @@ func cleanupRemoteAuthorizedKeys(account model.Account, systemKey security.Secre
 		return fmt.Errorf("failed to connect to %s@%s: %w", account.Username, account.Hostname, err)
 	}
 	defer deployer.Close()
+	deployer.SetOSFamily(account.EffectiveOSFamily())
 
 	if keepFile {
 		// Remove only Keymaster-managed content, preserve other keys
@@ -201,6 +220,7 @@ func cleanupRemoteAuthorizedKeysSelective(account model.Account, systemKey secur
 		return fmt.Errorf("failed to connect to %s@%s: %w", account.Username, account.Hostname, err)
 	}
 	defer deployer.Close()
+	deployer.SetOSFamily(account.EffectiveOSFamily())
 
 	if options.RemoveSystemKeyOnly {
 		// Remove only the system key, keep all user keys
@@ -219,7 +239,7 @@ func cleanupRemoteAuthorizedKeysSelective(account model.Account, systemKey secur
 
 // removeAuthorizedKeysFile completely removes the authorized_keys file
 func removeAuthorizedKeysFile(deployer *Deployer, result *DecommissionResult) error {
-	authorizedKeysPath := ".ssh/authorized_keys"
+	authorizedKeysPath := layoutFor(deployer.osFamily).path()
 
 	// Check if file exists
 	if _, err := deployer.sftp.Stat(authorizedKeysPath); err != nil {
@@ -246,7 +266,7 @@ func removeKeymasterContent(deployer *Deployer, result *DecommissionResult, acco
 
 // removeSelectiveKeymasterContent removes specific keys from the Keymaster-managed section
 func removeSelectiveKeymasterContent(deployer *Deployer, result *DecommissionResult, accountID int, excludeKeyIDs []int, removeSystemKey bool) error {
-	authorizedKeysPath := ".ssh/authorized_keys"
+	authorizedKeysPath := layoutFor(deployer.osFamily).path()
 
 	// Read current content
 	content, err := deployer.GetAuthorizedKeys()
@@ -284,14 +304,12 @@ func removeSelectiveKeymasterContent(deployer *Deployer, result *DecommissionRes
 		}
 	} else if len(excludeKeyIDs) > 0 || removeSystemKey {
 		// Regenerate Keymaster section without excluded keys and/or without system key
-		logging.Debugf("DEBUG decommission: removeSystemKey=%v, excludeKeyIDs=%v, accountID=%d", removeSystemKey, excludeKeyIDs, accountID)
+		core.DefaultLogger().Debug("regenerating keymaster section", "account_id", accountID, "remove_system_key", removeSystemKey, "exclude_key_ids", excludeKeyIDs)
 		keymasterContent, err := GenerateSelectiveKeysContent(accountID, 0, excludeKeyIDs, removeSystemKey)
 		if err != nil {
 			return fmt.Errorf("failed to generate selective keys content: %w", err)
 		}
-		logging.Debugf("DEBUG decommission: Generated keymaster content length=%d", len(keymasterContent))
-		logging.Debugf("DEBUG decommission: Keymaster content (truncated): %s", keymasterContent)
-		logging.Debugf("DEBUG decommission: Non-Keymaster content (truncated): %s", nonKeymasterContent)
+		core.DefaultLogger().Debug("generated selective keymaster section", "account_id", accountID, "keymaster_bytes", len(keymasterContent), "other_bytes", len(nonKeymasterContent))
 		// Check if content is empty, but don't trim (to preserve trailing newlines)
 		hasKeymasterContent := strings.TrimSpace(keymasterContent) != ""
 		hasNonKeymasterContent := strings.TrimSpace(nonKeymasterContent) != ""
@@ -306,7 +324,7 @@ func removeSelectiveKeymasterContent(deployer *Deployer, result *DecommissionRes
 			// No Keymaster keys remain, only non-Keymaster content
 			finalContent = nonKeymasterContent
 		}
-		logging.Debugf("DEBUG decommission: Final content (truncated): %s", finalContent)
+		core.DefaultLogger().Debug("final authorized_keys content prepared", "account_id", accountID, "bytes", len(finalContent))
 	} else {
 		// Remove entire Keymaster-managed section
 		finalContent = nonKeymasterContent
@@ -382,13 +400,13 @@ func BulkDecommissionAccounts(accounts []model.Account, systemKey security.Secre
 	results := make([]DecommissionResult, 0, len(accounts))
 
 	for i, account := range accounts {
-		logging.Infof("Decommissioning account %d/%d: %s", i+1, len(accounts), account.String())
+		core.DefaultLogger().Info("decommissioning account", "index", i+1, "total", len(accounts), "account", account.String())
 
 		result := DecommissionAccount(account, systemKey, options)
 		results = append(results, result)
 
 		// Print immediate result
-		logging.Infof("  → %s", result.String())
+		core.DefaultLogger().Info("decommission finished", "result", result.String())
 	}
 
 	return results
@@ -16,6 +16,7 @@ import (
 	"github.com/toeirei/keymaster/core/db"
 	"github.com/toeirei/keymaster/core/keys"
 	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/core/sshkey"
 )
 
 // SystemKeyRestrictions defines the SSH options applied to the Keymaster system key.
@@ -60,6 +61,15 @@ func GenerateKeysContentForSerial(accountID int, serial int) (string, error) {
 		return "", fmt.Errorf("could not retrieve keys for account ID %d: %w", accountID, err)
 	}
 
+	// An account that cannot be looked up gets the system key and every
+	// global key.
+	acc, _ := db.GetAccount(accountID)
+	globalKeys = keys.GlobalKeysForAccount(acc, globalKeys)
+	// Hosts co-managed by another tool keep their own system key line.
+	if acc != nil && !acc.ManageSystemKey {
+		return keys.BuildAuthorizedKeysContentWithoutSystemKey(systemKey, globalKeys, accountKeys)
+	}
+
 	// Delegate pure formatting/sorting/dedup to keys helper
 	return keys.BuildAuthorizedKeysContent(systemKey, globalKeys, accountKeys)
 }
@@ -107,6 +117,9 @@ func GenerateSelectiveKeysContent(accountID int, serial int, excludeKeyIDs []int
 	if err != nil {
 		return "", fmt.Errorf("could not retrieve global public keys: %w", err)
 	}
+	if acc, err := db.GetAccount(accountID); err == nil {
+		globalKeys = keys.GlobalKeysForAccount(acc, globalKeys)
+	}
 
 	// 3. Get keys specifically assigned to this account.
 	accountKeys, err := km.GetKeysForAccount(accountID)
@@ -128,18 +141,14 @@ func GenerateSelectiveKeysContent(accountID int, serial int, excludeKeyIDs []int
 		excludeSet[keyID] = true
 	}
 
-	formatKey := func(key model.PublicKey) string {
-		if key.Comment != "" {
-			return fmt.Sprintf("%s %s %s", key.Algorithm, key.KeyData, key.Comment)
-		}
-		return fmt.Sprintf("%s %s", key.Algorithm, key.KeyData)
-	}
-
 	// Filter expired keys first
 	filterExpired := func(keys []model.PublicKey) []model.PublicKey {
 		var out []model.PublicKey
 		now := time.Now().UTC()
 		for _, k := range keys {
+			if !sshkey.IsAlgorithmAllowed(k.Algorithm) {
+				continue
+			}
 			if k.ExpiresAt.IsZero() || k.ExpiresAt.After(now) {
 				out = append(out, k)
 			}
@@ -152,14 +161,14 @@ func GenerateSelectiveKeysContent(accountID int, serial int, excludeKeyIDs []int
 	// Add global keys (excluding those in excludeSet)
 	for _, key := range globalKeys {
 		if !excludeSet[key.ID] {
-			allUserKeysMap[key.ID] = keyInfo{id: key.ID, line: formatKey(key), comment: key.Comment}
+			allUserKeysMap[key.ID] = keyInfo{id: key.ID, line: key.AuthorizedKeysLine(), comment: key.Comment}
 		}
 	}
 
 	// Add account keys (excluding those in excludeSet)
 	for _, key := range accountKeys {
 		if !excludeSet[key.ID] {
-			allUserKeysMap[key.ID] = keyInfo{id: key.ID, line: formatKey(key), comment: key.Comment}
+			allUserKeysMap[key.ID] = keyInfo{id: key.ID, line: key.AuthorizedKeysLine(), comment: key.Comment}
 		}
 	}
 
@@ -0,0 +1,81 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package deploy
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+
+	"github.com/toeirei/keymaster/core"
+	genssh "github.com/toeirei/keymaster/core/crypto/ssh"
+	"github.com/toeirei/keymaster/core/db"
+	"github.com/toeirei/keymaster/core/security"
+)
+
+// dialPresentingTestHostKey fakes sshDial by running the host key callback
+// against a freshly generated host key and returning its error the way
+// ssh.Dial does.
+func dialPresentingTestHostKey(t *testing.T) {
+	t.Helper()
+	hostPub, _, err := genssh.GenerateAndMarshalEd25519Key("host", "")
+	if err != nil {
+		t.Fatalf("generate host key: %v", err)
+	}
+	pk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(hostPub))
+	if err != nil {
+		t.Fatalf("parse host key: %v", err)
+	}
+	origDial, origAgent := sshDial, sshAgentGetter
+	t.Cleanup(func() { sshDial, sshAgentGetter = origDial, origAgent })
+	sshAgentGetter = func() agent.Agent { return agent.NewKeyring() }
+	sshDial = func(network, addr string, cfg *ssh.ClientConfig) (sshClientIface, error) {
+		if err := cfg.HostKeyCallback(addr, &net.TCPAddr{}, pk); err != nil {
+			return nil, fmt.Errorf("ssh: handshake failed: %w", err)
+		}
+		return nil, errors.New("stop after host key check")
+	}
+}
+
+func TestNewDeployer_HostKeyChangedVsUnknown(t *testing.T) {
+	if _, err := db.New("sqlite", ":memory:"); err != nil {
+		t.Fatalf("db.New failed: %v", err)
+	}
+	dialPresentingTestHostKey(t)
+	_, priv, err := genssh.GenerateAndMarshalEd25519Key("test", "")
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	_, err = NewDeployerWithConfig("unknown.example.com", "user", security.FromString(priv), nil, DefaultConnectionConfig(), false)
+	if !errors.Is(err, core.ErrUnknownHostKey) {
+		t.Fatalf("expected ErrUnknownHostKey, got %v", err)
+	}
+	var changed *core.HostKeyChangedError
+	if errors.As(err, &changed) {
+		t.Fatalf("unknown host must not be reported as changed: %v", err)
+	}
+
+	otherPub, _, err := genssh.GenerateAndMarshalEd25519Key("other", "")
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	if err := db.AddKnownHostKey("changed.example.com:22", otherPub); err != nil {
+		t.Fatalf("AddKnownHostKey: %v", err)
+	}
+	_, err = NewDeployerWithConfig("changed.example.com", "user", security.FromString(priv), nil, DefaultConnectionConfig(), false)
+	if !errors.As(err, &changed) {
+		t.Fatalf("expected HostKeyChangedError, got %v", err)
+	}
+	if changed.Host != "changed.example.com:22" || changed.PresentedKey == "" {
+		t.Fatalf("unexpected changed error: %+v", changed)
+	}
+	if !IsHostKeyError(err) {
+		t.Fatalf("expected IsHostKeyError to recognize %v", err)
+	}
+}
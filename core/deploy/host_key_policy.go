@@ -0,0 +1,119 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package deploy
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/toeirei/keymaster/core"
+	"github.com/toeirei/keymaster/core/db"
+	"golang.org/x/crypto/ssh"
+)
+
+// HostKeyPolicy decides how deploy and audit connections treat host keys
+// that are not on record.
+type HostKeyPolicy string
+
+const (
+	// HostKeyPolicyStrict only accepts hosts trusted beforehand (e.g. with
+	// 'keymaster trust-host'). This is the default.
+	HostKeyPolicyStrict HostKeyPolicy = "strict"
+	// HostKeyPolicyTOFU trusts and saves the key of a host seen for the
+	// first time. Changed keys are still rejected.
+	HostKeyPolicyTOFU HostKeyPolicy = "tofu"
+	// HostKeyPolicyInsecure accepts any key, logging a warning for unknown
+	// and changed keys without saving them. Only for throwaway environments.
+	HostKeyPolicyInsecure HostKeyPolicy = "insecure"
+)
+
+// ParseHostKeyPolicy parses a configured policy name. Empty selects
+// HostKeyPolicyStrict.
+func ParseHostKeyPolicy(s string) (HostKeyPolicy, error) {
+	switch p := HostKeyPolicy(strings.ToLower(strings.TrimSpace(s))); p {
+	case "":
+		return HostKeyPolicyStrict, nil
+	case HostKeyPolicyStrict, HostKeyPolicyTOFU, HostKeyPolicyInsecure:
+		return p, nil
+	default:
+		return "", fmt.Errorf("invalid host key policy %q (use %s, %s or %s)", s, HostKeyPolicyStrict, HostKeyPolicyTOFU, HostKeyPolicyInsecure)
+	}
+}
+
+var (
+	hostKeyPolicyMu sync.RWMutex
+	hostKeyPolicy   = HostKeyPolicyStrict
+)
+
+// SetHostKeyPolicy sets the policy applied by deploy and audit connections.
+func SetHostKeyPolicy(p HostKeyPolicy) {
+	hostKeyPolicyMu.Lock()
+	defer hostKeyPolicyMu.Unlock()
+	hostKeyPolicy = p
+}
+
+// CurrentHostKeyPolicy returns the policy set by SetHostKeyPolicy.
+func CurrentHostKeyPolicy() HostKeyPolicy {
+	hostKeyPolicyMu.RLock()
+	defer hostKeyPolicyMu.RUnlock()
+	return hostKeyPolicy
+}
+
+// Known host accessors, replaceable in tests.
+var (
+	getKnownHostKey = db.GetKnownHostKey
+	addKnownHostKey = db.AddKnownHostKey
+)
+
+// verifyHostKey checks the key presented by hostname against the known host
+// keys under policy. It returns core.ErrUnknownHostKey for untrusted hosts
+// and a *core.HostKeyChangedError for hosts presenting a different key.
+func verifyHostKey(policy HostKeyPolicy, hostname string, key ssh.PublicKey) error {
+	// Always check canonical host:port first
+	canonical := CanonicalizeHostPort(hostname)
+
+	// The key is presented in the format "ssh-ed25519 AAA..."
+	presentedKey := string(ssh.MarshalAuthorizedKey(key))
+
+	knownKey, err := getKnownHostKey(canonical)
+	if err != nil {
+		return fmt.Errorf("failed to query known_hosts database: %w", err)
+	}
+	if knownKey == "" {
+		// Backward compatibility: try legacy host-only key (without port)
+		if hostOnly, _, err := net.SplitHostPort(canonical); err == nil {
+			legacyKey, lerr := getKnownHostKey(hostOnly)
+			if lerr != nil {
+				return fmt.Errorf("failed to query known_hosts database: %w", lerr)
+			}
+			knownKey = legacyKey
+		}
+	}
+
+	lg := core.DefaultLogger()
+	switch {
+	case knownKey == "":
+		switch policy {
+		case HostKeyPolicyTOFU:
+			if err := addKnownHostKey(canonical, presentedKey); err != nil {
+				return fmt.Errorf("failed to save host key on first use: %w", err)
+			}
+			lg.Warn("trusted new host key on first use", "host", canonical, "fingerprint", ssh.FingerprintSHA256(key))
+			return nil
+		case HostKeyPolicyInsecure:
+			lg.Warn("accepting unknown host key (insecure host key policy)", "host", canonical, "fingerprint", ssh.FingerprintSHA256(key))
+			return nil
+		}
+		return fmt.Errorf("%w for %s. run 'keymaster trust-host' to add it", core.ErrUnknownHostKey, canonical)
+	case knownKey != presentedKey:
+		if policy == HostKeyPolicyInsecure {
+			lg.Warn("accepting changed host key (insecure host key policy)", "host", canonical, "fingerprint", ssh.FingerprintSHA256(key))
+			return nil
+		}
+		return &core.HostKeyChangedError{Host: canonical, PresentedKey: presentedKey}
+	}
+	return nil // Host key is trusted.
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package deploy
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/toeirei/keymaster/core"
+	genssh "github.com/toeirei/keymaster/core/crypto/ssh"
+	"golang.org/x/crypto/ssh"
+)
+
+// fakeKnownHosts replaces the known host accessors with an in-memory map.
+func fakeKnownHosts(t *testing.T, known map[string]string) {
+	t.Helper()
+	origGet, origAdd := getKnownHostKey, addKnownHostKey
+	t.Cleanup(func() { getKnownHostKey, addKnownHostKey = origGet, origAdd })
+	getKnownHostKey = func(host string) (string, error) { return known[host], nil }
+	addKnownHostKey = func(host, key string) error {
+		known[host] = key
+		return nil
+	}
+}
+
+func testHostKey(t *testing.T) ssh.PublicKey {
+	t.Helper()
+	pub, _, err := genssh.GenerateAndMarshalEd25519Key("host", "")
+	if err != nil {
+		t.Fatalf("generate host key: %v", err)
+	}
+	pk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(pub))
+	if err != nil {
+		t.Fatalf("parse host key: %v", err)
+	}
+	return pk
+}
+
+func TestVerifyHostKey_Policies(t *testing.T) {
+	presented, other := testHostKey(t), testHostKey(t)
+	presentedLine := string(ssh.MarshalAuthorizedKey(presented))
+
+	type outcome int
+	const (
+		accepted outcome = iota
+		unknown
+		changed
+	)
+	cases := []struct {
+		policy                  HostKeyPolicy
+		known, unknown, changed outcome
+		savesUnknown            bool
+	}{
+		{policy: HostKeyPolicyStrict, known: accepted, unknown: unknown, changed: changed},
+		{policy: HostKeyPolicyTOFU, known: accepted, unknown: accepted, changed: changed, savesUnknown: true},
+		{policy: HostKeyPolicyInsecure, known: accepted, unknown: accepted, changed: accepted},
+	}
+	check := func(t *testing.T, err error, want outcome) {
+		t.Helper()
+		var hkc *core.HostKeyChangedError
+		switch want {
+		case accepted:
+			if err != nil {
+				t.Fatalf("expected the key to be accepted, got %v", err)
+			}
+		case unknown:
+			if !errors.Is(err, core.ErrUnknownHostKey) {
+				t.Fatalf("expected ErrUnknownHostKey, got %v", err)
+			}
+		case changed:
+			if !errors.As(err, &hkc) {
+				t.Fatalf("expected HostKeyChangedError, got %v", err)
+			}
+		}
+	}
+
+	for _, tc := range cases {
+		t.Run(string(tc.policy), func(t *testing.T) {
+			known := map[string]string{
+				"known.example.com:22":   presentedLine,
+				"legacy.example.com":     presentedLine,
+				"changed.example.com:22": string(ssh.MarshalAuthorizedKey(other)),
+			}
+			fakeKnownHosts(t, known)
+
+			check(t, verifyHostKey(tc.policy, "known.example.com", presented), tc.known)
+			check(t, verifyHostKey(tc.policy, "legacy.example.com:22", presented), tc.known)
+			check(t, verifyHostKey(tc.policy, "new.example.com", presented), tc.unknown)
+			check(t, verifyHostKey(tc.policy, "changed.example.com", presented), tc.changed)
+
+			if saved := known["new.example.com:22"] == presentedLine; saved != tc.savesUnknown {
+				t.Fatalf("unknown host key saved = %v, want %v", saved, tc.savesUnknown)
+			}
+			if known["changed.example.com:22"] == presentedLine {
+				t.Fatal("a changed host key must never be overwritten")
+			}
+			if tc.savesUnknown {
+				// Once trusted, the host is known.
+				check(t, verifyHostKey(HostKeyPolicyStrict, "new.example.com", presented), accepted)
+			}
+		})
+	}
+}
+
+func TestParseHostKeyPolicy(t *testing.T) {
+	for in, want := range map[string]HostKeyPolicy{"": HostKeyPolicyStrict, "strict": HostKeyPolicyStrict, " TOFU ": HostKeyPolicyTOFU, "insecure": HostKeyPolicyInsecure} {
+		if got, err := ParseHostKeyPolicy(in); err != nil || got != want {
+			t.Errorf("ParseHostKeyPolicy(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseHostKeyPolicy("yolo"); err == nil {
+		t.Error("expected an invalid policy to be rejected")
+	}
+}
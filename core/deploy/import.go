@@ -67,6 +67,7 @@ func ImportRemoteKeys(account model.Account) (importedKeys []model.PublicKey, sk
 		return nil, 0, warning, fmt.Errorf("connection failed: %w", err)
 	}
 	defer deployer.Close()
+	deployer.SetOSFamily(account.EffectiveOSFamily())
 
 	// 3. Get remote content.
 	content, err := deployer.GetAuthorizedKeys()
@@ -82,13 +83,15 @@ func ImportRemoteKeys(account model.Account) (importedKeys []model.PublicKey, sk
 			continue
 		}
 
-		alg, keyData, comment, parseErr := sshkey.Parse(line)
+		alg, keyData, comment, parseErr := sshkey.Normalize(line)
 		if parseErr != nil || comment == "" {
 			skippedCount++
 			continue
 		}
 
-		// Try to add the key. AddPublicKeyAndGetModel returns (nil, nil) for duplicates.
+		// Try to add the key. AddPublicKeyAndGetModel returns (nil, nil) for a
+		// duplicate comment and an error wrapping db.ErrDuplicate for duplicate
+		// key material; both are skipped.
 		// Imported keys are not global by default.
 		km := db.DefaultKeyManager()
 		if km == nil {
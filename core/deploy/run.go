@@ -79,6 +79,7 @@ func RunDeploymentForAccount(account model.Account, isTUI bool) error {
 		return fmt.Errorf(i18n.T("deploy.error_connection_failed"), err) // For CLI
 	}
 	defer deployer.Close()
+	deployer.SetOSFamily(account.EffectiveOSFamily())
 	// Once the deployer is successfully created, the passphrase has been used.
 	// We must clear it from the global cache immediately so it's not accidentally
 	// reused by another operation that doesn't need it.
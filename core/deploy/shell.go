@@ -0,0 +1,181 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package deploy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/toeirei/keymaster/core"
+	"github.com/toeirei/keymaster/core/sshkey"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/term"
+)
+
+// defaultShellTerm is requested for the PTY when $TERM is unset.
+const defaultShellTerm = "xterm-256color"
+
+// shellHop is one SSH connection on the way to a shell: the bastions of a
+// proxy jump chain and finally the account itself.
+type shellHop struct {
+	Addr   string
+	Config *ssh.ClientConfig
+}
+
+// shellHops builds the connections needed to reach target, bastions first.
+// Every hop authenticates with the system key, falling back to the ssh agent
+// when one is running, and verifies host keys under the configured host key
+// policy. Bastions without a user in the proxy jump use the account's user.
+func shellHops(target core.ShellTarget, passphrase []byte, config *ConnectionConfig) ([]shellHop, error) {
+	var auth []ssh.AuthMethod
+	if len(target.PrivateKey) != 0 {
+		signer, err := parseSystemKeySigner(target.PrivateKey, passphrase)
+		if err != nil {
+			if errors.Is(err, ErrPassphraseRequired) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("failed to parse system key: %w", err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if agentClient := sshAgentGetter(); agentClient != nil {
+		auth = append(auth, ssh.PublicKeysCallback(agentClient.Signers))
+	}
+	if len(auth) == 0 {
+		return nil, errors.New("no authentication method available (no system key and no ssh agent found)")
+	}
+
+	newConfig := func(user string) *ssh.ClientConfig {
+		cfg := &ssh.ClientConfig{
+			User:            user,
+			Auth:            auth,
+			HostKeyCallback: policyHostKeyCallback(),
+			Timeout:         config.ConnectionTimeout,
+		}
+		sshkey.ApplyConnectionAlgorithms(&cfg.Config)
+		return cfg
+	}
+
+	var hops []shellHop
+	if jump := strings.TrimSpace(target.ProxyJump); jump != "" {
+		for _, spec := range strings.Split(jump, ",") {
+			spec = strings.TrimSpace(spec)
+			if spec == "" {
+				continue
+			}
+			user := target.User
+			if u, host, ok := strings.Cut(spec, "@"); ok {
+				user, spec = u, host
+			}
+			hops = append(hops, shellHop{Addr: CanonicalizeHostPort(spec), Config: newConfig(user)})
+		}
+	}
+	return append(hops, shellHop{Addr: CanonicalizeHostPort(target.Host), Config: newConfig(target.User)}), nil
+}
+
+// dialShellHops connects to the last hop, tunnelling through the ones before
+// it. The returned close function closes every connection.
+func dialShellHops(hops []shellHop) (*ssh.Client, func(), error) {
+	var clients []*ssh.Client
+	closeAll := func() {
+		for i := len(clients) - 1; i >= 0; i-- {
+			_ = clients[i].Close()
+		}
+	}
+	for i, hop := range hops {
+		var client *ssh.Client
+		if i == 0 {
+			c, err := dialSSH(hop.Addr, "shell", hop.Config)
+			if err != nil {
+				return nil, nil, ClassifyConnectionError(hop.Addr, err)
+			}
+			var ok bool
+			if client, ok = c.(*ssh.Client); !ok {
+				_ = closeSSHClient(c)
+				return nil, nil, errors.New("unsupported ssh client type for interactive sessions")
+			}
+		} else {
+			conn, err := clients[i-1].Dial("tcp", hop.Addr)
+			if err != nil {
+				closeAll()
+				return nil, nil, fmt.Errorf("failed to reach %s through %s: %w", hop.Addr, hops[i-1].Addr, err)
+			}
+			c, chans, reqs, err := ssh.NewClientConn(conn, hop.Addr, hop.Config)
+			if err != nil {
+				_ = conn.Close()
+				closeAll()
+				return nil, nil, ClassifyConnectionError(hop.Addr, err)
+			}
+			client = ssh.NewClient(c, chans, reqs)
+		}
+		clients = append(clients, client)
+	}
+	return clients[len(clients)-1], closeAll, nil
+}
+
+// OpenShell starts an interactive login shell for target attached to stdio.
+// When stdin is a terminal it is switched to raw mode, a PTY of the same size
+// is requested and size changes are forwarded. A non-zero remote exit status
+// is returned as a *core.ShellExitError.
+func OpenShell(target core.ShellTarget, passphrase []byte, stdio core.ShellIO) error {
+	hops, err := shellHops(target, passphrase, DefaultConnectionConfig())
+	if err != nil {
+		return err
+	}
+	client, closeAll, err := dialShellHops(hops)
+	if err != nil {
+		return err
+	}
+	defer closeAll()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return fmt.Errorf("failed to open ssh session: %w", err)
+	}
+	defer func() { _ = session.Close() }()
+	session.Stdin = stdio.Stdin
+	session.Stdout = stdio.Stdout
+	session.Stderr = stdio.Stderr
+
+	if f, ok := stdio.Stdin.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		fd := int(f.Fd())
+		width, height, err := term.GetSize(fd)
+		if err != nil {
+			width, height = 80, 24
+		}
+		termType := os.Getenv("TERM")
+		if termType == "" {
+			termType = defaultShellTerm
+		}
+		modes := ssh.TerminalModes{
+			ssh.ECHO:          1,
+			ssh.TTY_OP_ISPEED: 14400,
+			ssh.TTY_OP_OSPEED: 14400,
+		}
+		if err := session.RequestPty(termType, height, width, modes); err != nil {
+			return fmt.Errorf("failed to allocate a pty: %w", err)
+		}
+		oldState, err := term.MakeRaw(fd)
+		if err != nil {
+			return fmt.Errorf("failed to put the terminal in raw mode: %w", err)
+		}
+		defer func() { _ = term.Restore(fd, oldState) }()
+		stop := watchTerminalSize(fd, func(width, height int) {
+			_ = session.WindowChange(height, width)
+		})
+		defer stop()
+	}
+
+	if err := session.Shell(); err != nil {
+		return fmt.Errorf("failed to start shell: %w", err)
+	}
+	err = session.Wait()
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		return &core.ShellExitError{Status: exitErr.ExitStatus()}
+	}
+	return err
+}
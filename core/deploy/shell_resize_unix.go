@@ -0,0 +1,39 @@
+//go:build !windows
+
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package deploy
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/term"
+)
+
+// watchTerminalSize calls onResize with the new size of the terminal fd
+// whenever SIGWINCH reports a change. The returned function stops watching.
+func watchTerminalSize(fd int, onResize func(width, height int)) func() {
+	sigs := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(sigs, syscall.SIGWINCH)
+	go func() {
+		for {
+			select {
+			case <-sigs:
+				if w, h, err := term.GetSize(fd); err == nil {
+					onResize(w, h)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(sigs)
+		close(done)
+	}
+}
@@ -0,0 +1,42 @@
+//go:build windows
+
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package deploy
+
+import (
+	"time"
+
+	"golang.org/x/term"
+)
+
+// terminalPollInterval is how often the console size is checked; Windows has
+// no SIGWINCH.
+const terminalPollInterval = 250 * time.Millisecond
+
+// watchTerminalSize polls the console fd and calls onResize whenever its
+// size changes. The returned function stops watching.
+func watchTerminalSize(fd int, onResize func(width, height int)) func() {
+	done := make(chan struct{})
+	go func() {
+		lastW, lastH, _ := term.GetSize(fd)
+		ticker := time.NewTicker(terminalPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				w, h, err := term.GetSize(fd)
+				if err != nil || (w == lastW && h == lastH) {
+					continue
+				}
+				lastW, lastH = w, h
+				onResize(w, h)
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
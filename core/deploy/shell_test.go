@@ -0,0 +1,124 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package deploy
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+
+	"github.com/toeirei/keymaster/core"
+	genssh "github.com/toeirei/keymaster/core/crypto/ssh"
+	"github.com/toeirei/keymaster/core/db"
+	"github.com/toeirei/keymaster/core/security"
+)
+
+func withShellAgent(t *testing.T, a agent.Agent) {
+	t.Helper()
+	orig := sshAgentGetter
+	t.Cleanup(func() { sshAgentGetter = orig })
+	sshAgentGetter = func() agent.Agent { return a }
+}
+
+func TestShellHops_DirectConnection(t *testing.T) {
+	if _, err := db.New("sqlite", ":memory:"); err != nil {
+		t.Fatalf("db.New failed: %v", err)
+	}
+	withShellAgent(t, nil)
+	_, priv, err := genssh.GenerateAndMarshalEd25519Key("test", "")
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	cfg := &ConnectionConfig{ConnectionTimeout: 7 * time.Second}
+
+	hops, err := shellHops(core.ShellTarget{Host: "web-01", User: "deploy", PrivateKey: security.FromString(priv)}, nil, cfg)
+	if err != nil {
+		t.Fatalf("shellHops: %v", err)
+	}
+	if len(hops) != 1 || hops[0].Addr != "web-01:22" {
+		t.Fatalf("expected a single hop to web-01:22, got %+v", hops)
+	}
+	c := hops[0].Config
+	if c.User != "deploy" || c.Timeout != 7*time.Second || len(c.Auth) != 1 {
+		t.Fatalf("unexpected client config %+v", c)
+	}
+
+	// Host keys are verified against the known hosts, like deploys.
+	hostPub, _, err := genssh.GenerateAndMarshalEd25519Key("host", "")
+	if err != nil {
+		t.Fatalf("generate host key: %v", err)
+	}
+	pk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(hostPub))
+	if err != nil {
+		t.Fatalf("parse host key: %v", err)
+	}
+	if err := c.HostKeyCallback("web-01:22", &net.TCPAddr{}, pk); !errors.Is(err, core.ErrUnknownHostKey) {
+		t.Fatalf("expected an unknown host key to be rejected, got %v", err)
+	}
+	if err := db.AddKnownHostKey("web-01:22", string(ssh.MarshalAuthorizedKey(pk))); err != nil {
+		t.Fatalf("AddKnownHostKey: %v", err)
+	}
+	if err := c.HostKeyCallback("web-01:22", &net.TCPAddr{}, pk); err != nil {
+		t.Fatalf("expected the known host key to be accepted, got %v", err)
+	}
+}
+
+func TestShellHops_ProxyJumpChain(t *testing.T) {
+	withShellAgent(t, agent.NewKeyring())
+	_, priv, err := genssh.GenerateAndMarshalEd25519Key("test", "")
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	target := core.ShellTarget{
+		Host:       "web-01:2200",
+		User:       "deploy",
+		ProxyJump:  "admin@bastion:2222, jump-02",
+		PrivateKey: security.FromString(priv),
+	}
+
+	hops, err := shellHops(target, nil, DefaultConnectionConfig())
+	if err != nil {
+		t.Fatalf("shellHops: %v", err)
+	}
+	want := []struct{ addr, user string }{
+		{"bastion:2222", "admin"},
+		{"jump-02:22", "deploy"},
+		{"web-01:2200", "deploy"},
+	}
+	if len(hops) != len(want) {
+		t.Fatalf("expected %d hops, got %+v", len(want), hops)
+	}
+	for i, w := range want {
+		if hops[i].Addr != w.addr || hops[i].Config.User != w.user {
+			t.Errorf("hop %d = %s as %s, want %s as %s", i, hops[i].Addr, hops[i].Config.User, w.addr, w.user)
+		}
+		// System key first, then the running agent.
+		if len(hops[i].Config.Auth) != 2 {
+			t.Errorf("hop %d: expected system key and agent auth, got %d methods", i, len(hops[i].Config.Auth))
+		}
+	}
+}
+
+func TestShellHops_Errors(t *testing.T) {
+	withShellAgent(t, nil)
+	if _, err := shellHops(core.ShellTarget{Host: "web-01", User: "deploy"}, nil, DefaultConnectionConfig()); err == nil {
+		t.Fatal("expected an error without system key or agent")
+	}
+
+	_, encrypted, err := genssh.GenerateAndMarshalEd25519Key("test", "secret")
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	_, err = shellHops(core.ShellTarget{Host: "web-01", User: "deploy", PrivateKey: security.FromString(encrypted)}, nil, DefaultConnectionConfig())
+	if !errors.Is(err, ErrPassphraseRequired) {
+		t.Fatalf("expected ErrPassphraseRequired, got %v", err)
+	}
+	if _, err := shellHops(core.ShellTarget{Host: "web-01", User: "deploy", PrivateKey: security.FromString(encrypted)}, []byte("secret"), DefaultConnectionConfig()); err != nil {
+		t.Fatalf("expected the passphrase to decrypt the key, got %v", err)
+	}
+}
@@ -11,6 +11,7 @@
 package deploy // import "github.com/toeirei/keymaster/core/deploy"
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
@@ -22,9 +23,11 @@ import (
 	"time"
 
 	"github.com/pkg/sftp"
+	"github.com/toeirei/keymaster/core"
 	"github.com/toeirei/keymaster/core/db"
-	"github.com/toeirei/keymaster/core/logging"
+	"github.com/toeirei/keymaster/core/model"
 	"github.com/toeirei/keymaster/core/security"
+	"github.com/toeirei/keymaster/core/sshkey"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -232,6 +235,8 @@ type Deployer struct {
 	client sshClientIface
 	sftp   sftpClient
 	config *ConnectionConfig
+	// osFamily selects the authorized_keys layout; empty means POSIX.
+	osFamily string
 }
 
 // NewDeployerFunc is a overridable factory used to create Deployers. Tests may
@@ -250,6 +255,27 @@ var sshDial = func(network, addr string, cfg *ssh.ClientConfig) (sshClientIface,
 	return ssh.Dial(network, addr, cfg)
 }
 
+// dialSSH applies the configured connection algorithms and the per-host
+// connection throttle, dials addr and logs the attempt and its outcome at
+// debug level. method names the authentication path for the log record.
+func dialSSH(addr, method string, cfg *ssh.ClientConfig) (sshClientIface, error) {
+	sshkey.ApplyConnectionAlgorithms(&cfg.Config)
+	waitForHostSlot(addr)
+	lg := core.DefaultLogger()
+	lg.Debug("ssh connection attempt", "host", addr, "user", cfg.User, "method", method)
+	start := time.Now()
+	client, err := sshDial("tcp", addr, cfg)
+	switch {
+	case err == nil:
+		lg.Debug("ssh connection established", "host", addr, "user", cfg.User, "method", method, "elapsed", time.Since(start))
+	case errors.Is(err, ErrHostKeySuccessfullyRetrieved):
+		lg.Debug("ssh host key retrieved", "host", addr, "elapsed", time.Since(start))
+	default:
+		lg.Debug("ssh connection failed", "host", addr, "user", cfg.User, "method", method, "elapsed", time.Since(start), "err", err)
+	}
+	return client, err
+}
+
 // newSftpClient is a package-level wrapper used to create an sftpRaw from an
 // existing *ssh.Client. By default it wraps the real *sftp.Client with
 // sftpRealAdapter. Tests may override this to return a mock sftpRaw.
@@ -314,6 +340,43 @@ func NewDeployerWithConfig(host, user string, privateKey security.Secret, passph
 	return newDeployerInternal(host, user, privateKey, passphrase, config, isBootstrap)
 }
 
+// parseSystemKeySigner parses a private key, decrypting it with passphrase
+// when it is encrypted. An encrypted key without a passphrase yields
+// ErrPassphraseRequired.
+func parseSystemKeySigner(privateKey security.Secret, passphrase []byte) (ssh.Signer, error) {
+	var signer ssh.Signer
+	// Parse private key without making an extra copy where possible.
+	err := privateKey.Use(func(b []byte) error {
+		var perr error
+		signer, perr = ssh.ParsePrivateKey(b)
+		return perr
+	})
+	var pme *ssh.PassphraseMissingError
+	if errors.As(err, &pme) {
+		if len(passphrase) == 0 {
+			return nil, ErrPassphraseRequired
+		}
+		err = privateKey.Use(func(b []byte) error {
+			var perr error
+			signer, perr = ssh.ParsePrivateKeyWithPassphrase(b, passphrase)
+			return perr
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return signer, nil
+}
+
+// policyHostKeyCallback verifies host keys against the known hosts under
+// the configured host key policy.
+func policyHostKeyCallback() ssh.HostKeyCallback {
+	policy := CurrentHostKeyPolicy()
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		return verifyHostKey(policy, hostname, key)
+	}
+}
+
 // newDeployerInternal is the internal implementation for creating deployers.
 func newDeployerInternal(host, user string, privateKey security.Secret, passphrase []byte, config *ConnectionConfig, isBootstrap bool) (*Deployer, error) {
 	// Define the host key callback based on bootstrap mode.
@@ -327,50 +390,14 @@ func newDeployerInternal(host, user string, privateKey security.Secret, passphra
 			// Save the host key for future connections
 			presentedKey := string(ssh.MarshalAuthorizedKey(key))
 			if err := db.AddKnownHostKey(canonical, presentedKey); err != nil {
-				logging.Warnf("failed to save known host key for %s: %v", canonical, err)
+				core.DefaultLogger().Warn("failed to save known host key", "host", canonical, "err", err)
 			}
 
 			return nil // Accept the key for bootstrap
 		}
 	} else {
-		// Normal mode: verify host keys
-		hostKeyCallback = func(hostname string, remote net.Addr, key ssh.PublicKey) error {
-			// Always check canonical host:port first
-			canonical := CanonicalizeHostPort(hostname)
-
-			// The key is presented in the format "ssh-ed25519 AAA..."
-			presentedKey := string(ssh.MarshalAuthorizedKey(key))
-
-			// Check if we have a trusted key for this canonical host:port in our database.
-			knownKey, err := db.GetKnownHostKey(canonical)
-			if err != nil {
-				return fmt.Errorf("failed to query known_hosts database: %w", err)
-			}
-
-			// If we don't have a key, this is the first connection.
-			if knownKey == "" {
-				// Backward compatibility: try legacy host-only key (without port)
-				if hostOnly, _, err := net.SplitHostPort(canonical); err == nil {
-					legacyKey, lerr := db.GetKnownHostKey(hostOnly)
-					if lerr != nil {
-						return fmt.Errorf("failed to query known_hosts database: %w", lerr)
-					}
-					if legacyKey != "" {
-						knownKey = legacyKey
-					}
-				}
-				if knownKey == "" {
-					return fmt.Errorf("unknown host key for %s. run 'keymaster trust-host' to add it", canonical)
-				}
-			}
-
-			// If the key exists, it must match exactly.
-			if knownKey != presentedKey {
-				return fmt.Errorf("!!! HOST KEY MISMATCH FOR %s !!!\nRemote key presented: %s\nThis could be a man-in-the-middle attack", canonical, presentedKey)
-			}
-
-			return nil // Host key is trusted.
-		}
+		// Normal mode: verify host keys under the configured policy.
+		hostKeyCallback = policyHostKeyCallback()
 	}
 
 	// Add port 22 if not specified.
@@ -380,29 +407,10 @@ func newDeployerInternal(host, user string, privateKey security.Secret, passphra
 	// If a private key is provided, use it exclusively. This is the standard path
 	// for deployment and auditing with a Keymaster system key.
 	if len(privateKey) != 0 {
-		var signer ssh.Signer
-		var err error
-
-		// Parse private key without making an extra copy where possible.
-		err = privateKey.Use(func(b []byte) error {
-			signer, err = ssh.ParsePrivateKey(b)
-			return err
-		})
-		if err != nil {
-			// Check if the error is because the key is encrypted.
-			var pme *ssh.PassphraseMissingError
-			if errors.As(err, &pme) {
-				// If it's encrypted and we have a passphrase, try to parse it again.
-				if len(passphrase) > 0 {
-					err = privateKey.Use(func(b []byte) error {
-						signer, err = ssh.ParsePrivateKeyWithPassphrase(b, passphrase)
-						return err
-					})
-				} else {
-					// Key is encrypted, but we have no passphrase. Signal to the caller.
-					return nil, ErrPassphraseRequired
-				}
-			}
+		signer, err := parseSystemKeySigner(privateKey, passphrase)
+		if errors.Is(err, ErrPassphraseRequired) {
+			// Key is encrypted, but we have no passphrase. Signal to the caller.
+			return nil, err
 		}
 
 		// If we have a valid signer at this point (either unencrypted or successfully decrypted).
@@ -413,7 +421,7 @@ func newDeployerInternal(host, user string, privateKey security.Secret, passphra
 				HostKeyCallback: hostKeyCallback,
 				Timeout:         config.ConnectionTimeout,
 			}
-			client, err = sshDial("tcp", addr, sshConfig)
+			client, err = dialSSH(addr, "system-key", sshConfig)
 			if err == nil {
 				// Success! We connected with the system key.
 				sftpClient, sftpErr := newSftpClient(client)
@@ -422,9 +430,13 @@ func newDeployerInternal(host, user string, privateKey security.Secret, passphra
 					return nil, fmt.Errorf("failed to create sftp client: %w", sftpErr)
 				}
 				return &Deployer{client: client, sftp: &sftpClientAdapter{client: sftpClient}, config: config}, nil
+			} else if IsHostKeyError(err) {
+				// The agent would be offered the same host key; fail now so the
+				// caller sees the host key error rather than an agent failure.
+				return nil, ClassifyConnectionError(host, err)
 			} else {
 				// Classify the error for better debugging (log it); we'll fall back to ssh-agent.
-				logging.Infof("system key connection attempt failed for %s: %v", host, err)
+				core.DefaultLogger().Info("system key connection attempt failed, falling back to ssh agent", "host", host, "err", err)
 			}
 			// If we provided a key and it failed, we will fall through to try the agent.
 		}
@@ -444,7 +456,7 @@ func newDeployerInternal(host, user string, privateKey security.Secret, passphra
 		Timeout:         config.ConnectionTimeout,
 	}
 
-	client, err := sshDial("tcp", addr, sshConfig)
+	client, err := dialSSH(addr, "ssh-agent", sshConfig)
 	if err != nil {
 		err = ClassifyConnectionError(host, err)
 		return nil, fmt.Errorf("connection with ssh agent failed: %w", err)
@@ -482,7 +494,7 @@ func newDeployerWithExpectedHostKey(host, user string, privateKey security.Secre
 
 		// Save the verified host key to database
 		if err := db.AddKnownHostKey(hostOnly, presentedKey); err != nil {
-			logging.Warnf("failed to save verified host key for %s: %v", hostOnly, err)
+			core.DefaultLogger().Warn("failed to save verified host key", "host", hostOnly, "err", err)
 		}
 
 		return nil
@@ -510,7 +522,7 @@ func newDeployerWithExpectedHostKey(host, user string, privateKey security.Secre
 	}
 
 	// Connect
-	client, err := sshDial("tcp", addr, sshConfig)
+	client, err := dialSSH(addr, "expected-host-key", sshConfig)
 	if err != nil {
 		err = ClassifyConnectionError(host, err)
 		return nil, err
@@ -530,25 +542,99 @@ func newDeployerWithExpectedHostKey(host, user string, privateKey security.Secre
 	}, nil
 }
 
+// SetOSFamily selects where authorized_keys lives on the remote host and how
+// permissions are handled (see model.OSFamilyPOSIX and friends).
+func (d *Deployer) SetOSFamily(family string) { d.osFamily = family }
+
+// authorizedKeysLayout describes where a host keeps authorized_keys, as seen
+// over SFTP.
+type authorizedKeysLayout struct {
+	dir  string
+	file string
+	// chmod is false on Windows, where access is governed by ACLs and SFTP
+	// chmod is unsupported or misleading.
+	chmod bool
+}
+
+func (l authorizedKeysLayout) path() string { return path.Join(l.dir, l.file) }
+
+// layoutFor returns the authorized_keys layout for an OS family. Windows
+// OpenSSH starts SFTP sessions in the user profile, so the per-user file is
+// reachable relative to it; administrators_authorized_keys is addressed by
+// its absolute SFTP path.
+func layoutFor(family string) authorizedKeysLayout {
+	switch family {
+	case model.OSFamilyWindows:
+		return authorizedKeysLayout{dir: ".ssh", file: "authorized_keys"}
+	case model.OSFamilyWindowsAdmin:
+		return authorizedKeysLayout{dir: "/C:/ProgramData/ssh", file: "administrators_authorized_keys"}
+	default:
+		return authorizedKeysLayout{dir: ".ssh", file: "authorized_keys", chmod: true}
+	}
+}
+
 // DeployAuthorizedKeys uploads the new authorized_keys content and moves it into place.
 // This function uses a pure-SFTP method to be compatible with restricted keys
 // (e.g., command="internal-sftp"). It uses a backup-and-rename strategy for
 // compatibility with SFTP servers that don't support atomic overwrites (e.g., on Windows).
 func (d *Deployer) DeployAuthorizedKeys(content string) error {
+	return d.uploadFile(layoutFor(d.osFamily), content, 0600)
+}
+
+// knownHostsLayout returns where a host keeps the account's known_hosts. It
+// lives in the user profile on every OS family, including for Windows
+// administrators.
+func knownHostsLayout(family string) authorizedKeysLayout {
+	return managedFileLayout(family, "known_hosts")
+}
+
+// managedFileLayout returns where a host keeps a managed file named name:
+// in the user's .ssh directory on every OS family.
+func managedFileLayout(family, name string) authorizedKeysLayout {
+	layout := authorizedKeysLayout{dir: ".ssh", file: name, chmod: true}
+	if family == model.OSFamilyWindows || family == model.OSFamilyWindowsAdmin {
+		layout.chmod = false
+	}
+	return layout
+}
+
+// DeployKnownHosts uploads known_hosts content for outbound SSH from the host
+// and moves it into place, the same way DeployAuthorizedKeys does.
+func (d *Deployer) DeployKnownHosts(content string) error {
+	return d.uploadFile(knownHostsLayout(d.osFamily), content, 0644)
+}
+
+// DeployManagedFile uploads content to ~/.ssh/<name> and moves it into place
+// the same way DeployAuthorizedKeys does.
+func (d *Deployer) DeployManagedFile(name, content string, mode os.FileMode) error {
+	return d.uploadFile(managedFileLayout(d.osFamily, name), content, mode)
+}
+
+// GetManagedFile reads ~/.ssh/<name>. A missing file yields an error
+// matching os.ErrNotExist.
+func (d *Deployer) GetManagedFile(name string) ([]byte, error) {
+	return ReadRemoteFile(d.sftp, managedFileLayout(d.osFamily, name).path())
+}
+
+// uploadFile writes content to the file described by layout, giving it mode
+// where the layout allows chmod.
+func (d *Deployer) uploadFile(layout authorizedKeysLayout, content string, mode os.FileMode) error {
 	// 1. Ensure .ssh directory exists with correct permissions.
-	const sshDir = ".ssh"
+	sshDir := layout.dir
 	if _, err := d.sftp.Stat(sshDir); err != nil {
 		// If the directory doesn't exist, create it.
 		if err := d.sftp.Mkdir(sshDir); err != nil {
-			return fmt.Errorf("failed to create .ssh directory: %w", err)
+			return fmt.Errorf("failed to create %s directory: %w", sshDir, err)
 		}
 	}
-	if err := d.sftp.Chmod(sshDir, 0700); err != nil {
-		return fmt.Errorf("failed to chmod .ssh directory: %w", err)
+	if layout.chmod {
+		if err := d.sftp.Chmod(sshDir, 0700); err != nil {
+			return fmt.Errorf("failed to chmod .ssh directory: %w", err)
+		}
 	}
 
 	// 2. Upload to a temporary file within the .ssh directory for atomic rename.
-	tmpPath := path.Join(sshDir, fmt.Sprintf("authorized_keys.keymaster.%d", time.Now().UnixNano()))
+	tmpPath := path.Join(sshDir, fmt.Sprintf("%s.keymaster.%d", layout.file, time.Now().UnixNano()))
 	f, err := d.sftp.Create(tmpPath)
 	if err != nil {
 		return fmt.Errorf("failed to create temporary file on remote: %w", err)
@@ -562,13 +648,15 @@ func (d *Deployer) DeployAuthorizedKeys(content string) error {
 	_ = f.Close()
 
 	// 3. Set permissions on the temporary file before moving.
-	if err := d.sftp.Chmod(tmpPath, 0600); err != nil {
-		_ = d.sftp.Remove(tmpPath)
-		return fmt.Errorf("failed to chmod temporary file: %w", err)
+	if layout.chmod {
+		if err := d.sftp.Chmod(tmpPath, mode); err != nil {
+			_ = d.sftp.Remove(tmpPath)
+			return fmt.Errorf("failed to chmod temporary file: %w", err)
+		}
 	}
 
 	// 4. Move the file into place using a backup-and-rename strategy.
-	finalPath := path.Join(sshDir, "authorized_keys")
+	finalPath := layout.path()
 	backupPath := finalPath + ".keymaster-bak"
 
 	// Step A: Remove any old backup file from a previous failed run.
@@ -584,7 +672,7 @@ func (d *Deployer) DeployAuthorizedKeys(content string) error {
 		_ = d.sftp.Rename(backupPath, finalPath)
 		// Clean up the temp file regardless.
 		_ = d.sftp.Remove(tmpPath)
-		return fmt.Errorf("failed to rename authorized_keys file into place: %w", err)
+		return fmt.Errorf("failed to rename %s file into place: %w", layout.file, err)
 	}
 
 	// Step D: Success. Clean up the backup file.
@@ -601,18 +689,97 @@ func (d *Deployer) Close() {
 	_ = closeSSHClient(d.client)
 }
 
+// runRemoteCommand executes cmd in a new session on the given SSH client and
+// returns its output and exit status. A non-zero exit is not an error; err is
+// only set when the command could not be run. Tests may override this hook.
+var runRemoteCommand = func(c sshClientIface, cmd string, timeout time.Duration) (stdout, stderr string, exitCode int, err error) {
+	client, ok := c.(*ssh.Client)
+	if !ok || client == nil {
+		return "", "", -1, fmt.Errorf("unsupported ssh client type for remote commands")
+	}
+	session, err := client.NewSession()
+	if err != nil {
+		return "", "", -1, fmt.Errorf("failed to open ssh session: %w", err)
+	}
+	defer func() { _ = session.Close() }()
+
+	var outBuf, errBuf bytes.Buffer
+	session.Stdout = &outBuf
+	session.Stderr = &errBuf
+	done := make(chan error, 1)
+	go func() { done <- session.Run(cmd) }()
+
+	var runErr error
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case runErr = <-done:
+		case <-timer.C:
+			_ = session.Close()
+			return "", "", -1, fmt.Errorf("command timed out after %s", timeout)
+		}
+	} else {
+		runErr = <-done
+	}
+
+	var exitErr *ssh.ExitError
+	if errors.As(runErr, &exitErr) {
+		return outBuf.String(), errBuf.String(), exitErr.ExitStatus(), nil
+	}
+	if runErr != nil {
+		return outBuf.String(), errBuf.String(), -1, runErr
+	}
+	return outBuf.String(), errBuf.String(), 0, nil
+}
+
+// RunCommand executes a command on the remote host over the deployer's
+// existing SSH connection, bounded by the configured CommandTimeout.
+func (d *Deployer) RunCommand(cmd string) (stdout, stderr string, exitCode int, err error) {
+	timeout := DefaultCommandTimeout
+	if d.config != nil {
+		timeout = d.config.CommandTimeout
+	}
+	return runRemoteCommand(d.client, cmd, timeout)
+}
+
 // GetAuthorizedKeys reads and returns the content of the remote authorized_keys file.
 func (d *Deployer) GetAuthorizedKeys() ([]byte, error) {
-	finalPath := ".ssh/authorized_keys"
-	f, err := d.sftp.Open(finalPath)
+	return ReadRemoteFile(d.sftp, layoutFor(d.osFamily).path())
+}
+
+// StatAuthorizedKeys returns the SFTP stat of the authorized_keys file and
+// of the directory holding it.
+func (d *Deployer) StatAuthorizedKeys() (file, dir core.RemoteFileStat, err error) {
+	layout := layoutFor(d.osFamily)
+	if dir, err = statRemoteFile(d.sftp, layout.dir); err != nil {
+		return file, dir, err
+	}
+	file, err = statRemoteFile(d.sftp, layout.path())
+	return file, dir, err
+}
+
+func statRemoteFile(client sftpClient, p string) (core.RemoteFileStat, error) {
+	fi, err := client.Stat(p)
+	if err != nil {
+		return core.RemoteFileStat{}, fmt.Errorf("failed to stat remote file %s: %w", p, err)
+	}
+	return core.RemoteFileStat{Path: p, Mode: fi.Mode(), Size: fi.Size(), ModTime: fi.ModTime()}, nil
+}
+
+// ReadRemoteFile reads a remote file over SFTP. Reads are capped at
+// sshkey.MaxAuthorizedKeysSize; larger files fail with an error wrapping
+// sshkey.ErrAuthorizedKeysTooLarge.
+func ReadRemoteFile(client sftpClient, path string) ([]byte, error) {
+	f, err := client.Open(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open remote file %s: %w", finalPath, err)
+		return nil, fmt.Errorf("failed to open remote file %s: %w", path, err)
 	}
 	defer func() { _ = f.Close() }()
 
-	content, err := io.ReadAll(f)
+	content, err := sshkey.ReadAuthorizedKeys(f)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read from remote file %s: %w", finalPath, err)
+		return nil, fmt.Errorf("failed to read from remote file %s: %w", path, err)
 	}
 	return content, nil
 }
@@ -666,6 +833,11 @@ func IsHostKeyError(err error) bool {
 		return false
 	}
 
+	var changed *core.HostKeyChangedError
+	if errors.As(err, &changed) || errors.Is(err, core.ErrUnknownHostKey) {
+		return true
+	}
+
 	errStr := err.Error()
 	return strings.Contains(errStr, "HOST KEY MISMATCH") ||
 		strings.Contains(errStr, "unknown host key") ||
@@ -716,7 +888,7 @@ func GetRemoteHostKeyWithTimeout(host string, timeout time.Duration) (ssh.Public
 	addr := CanonicalizeHostPort(host)
 
 	// We expect ssh.Dial to fail with our specific error.
-	_, err := sshDial("tcp", addr, config)
+	_, err := dialSSH(addr, "host-key-probe", config)
 	if err != nil {
 		// Check if it's our specific sentinel error.
 		if errors.Is(err, ErrHostKeySuccessfullyRetrieved) {
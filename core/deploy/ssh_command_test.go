@@ -0,0 +1,43 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package deploy
+
+import (
+	"testing"
+	"time"
+)
+
+type fakeSSHClient struct{}
+
+func (fakeSSHClient) Close() error { return nil }
+
+func TestDeployerRunCommand_UsesHookAndTimeout(t *testing.T) {
+	orig := runRemoteCommand
+	defer func() { runRemoteCommand = orig }()
+
+	var gotCmd string
+	var gotTimeout time.Duration
+	runRemoteCommand = func(c sshClientIface, cmd string, timeout time.Duration) (string, string, int, error) {
+		gotCmd, gotTimeout = cmd, timeout
+		return "out", "err", 3, nil
+	}
+
+	d := &Deployer{client: fakeSSHClient{}, config: &ConnectionConfig{CommandTimeout: 7 * time.Second}}
+	res, err := (&deployAdapter{inner: d}).RunCommand("sshd -t")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotCmd != "sshd -t" || gotTimeout != 7*time.Second {
+		t.Fatalf("hook called with %q/%s", gotCmd, gotTimeout)
+	}
+	if res.Stdout != "out" || res.Stderr != "err" || res.ExitCode != 3 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+}
+
+func TestRunRemoteCommand_UnsupportedClient(t *testing.T) {
+	if _, _, code, err := runRemoteCommand(fakeSSHClient{}, "true", time.Second); err == nil || code != -1 {
+		t.Fatalf("expected error for non-ssh client, got code=%d err=%v", code, err)
+	}
+}
@@ -4,10 +4,12 @@
 package deploy
 
 import (
+	"errors"
 	"net"
 	"os"
 	"testing"
 
+	"github.com/toeirei/keymaster/core"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -48,3 +50,34 @@ func TestGetRemoteHostKey_WithInjectedDial(t *testing.T) {
 		t.Fatalf("retrieved key does not match expected key")
 	}
 }
+
+// dialLogRecorder records the messages passed to the core logger.
+type dialLogRecorder struct{ msgs []string }
+
+func (r *dialLogRecorder) Debug(msg string, keyvals ...any) { r.msgs = append(r.msgs, msg) }
+func (r *dialLogRecorder) Info(msg string, keyvals ...any)  { r.msgs = append(r.msgs, msg) }
+func (r *dialLogRecorder) Warn(msg string, keyvals ...any)  { r.msgs = append(r.msgs, msg) }
+func (r *dialLogRecorder) Error(msg string, keyvals ...any) { r.msgs = append(r.msgs, msg) }
+
+// TestDialSSH_LogsAttemptAndOutcome verifies connection attempts and their
+// outcome are logged at debug level through the core logger.
+func TestDialSSH_LogsAttemptAndOutcome(t *testing.T) {
+	rec := &dialLogRecorder{}
+	prevLogger := core.DefaultLogger()
+	core.SetDefaultLogger(rec)
+	defer core.SetDefaultLogger(prevLogger)
+
+	orig := sshDial
+	defer func() { sshDial = orig }()
+	sshDial = func(network, addr string, config *ssh.ClientConfig) (sshClientIface, error) {
+		return nil, errors.New("connection refused")
+	}
+
+	if _, err := dialSSH("example.com:22", "system-key", &ssh.ClientConfig{User: "u"}); err == nil {
+		t.Fatalf("expected dial error")
+	}
+	want := []string{"ssh connection attempt", "ssh connection failed"}
+	if len(rec.msgs) != len(want) || rec.msgs[0] != want[0] || rec.msgs[1] != want[1] {
+		t.Fatalf("unexpected log messages: %v", rec.msgs)
+	}
+}
@@ -9,8 +9,12 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/core/sshkey"
 )
 
 func TestDefaultConnectionConfig(t *testing.T) {
@@ -461,3 +465,157 @@ func TestDeployAuthorizedKeys_ChmodFailAndRenameRecover(t *testing.T) {
 		t.Fatalf("expected DeployAuthorizedKeys to fail due to rename error")
 	}
 }
+
+func TestDeployAuthorizedKeys_WindowsSkipsChmod(t *testing.T) {
+	mockClient := newMockSftpClient()
+	d := &Deployer{sftp: mockClient}
+	d.SetOSFamily(model.OSFamilyWindows)
+
+	if err := d.DeployAuthorizedKeys("ssh-ed25519 AAAA win@key"); err != nil {
+		t.Fatalf("DeployAuthorizedKeys failed: %v", err)
+	}
+	if f, ok := mockClient.files[".ssh/authorized_keys"]; !ok || f.String() != "ssh-ed25519 AAAA win@key" {
+		t.Fatalf("authorized_keys not written to the user profile: %v", mockClient.actions)
+	}
+	for _, a := range mockClient.actions {
+		if strings.HasPrefix(a, "chmod:") {
+			t.Fatalf("unexpected chmod on a Windows host: %v", mockClient.actions)
+		}
+	}
+}
+
+func TestDeployAuthorizedKeys_WindowsAdmin(t *testing.T) {
+	mockClient := newMockSftpClient()
+	mockClient.perms["/C:/ProgramData/ssh"] = 0755 | os.ModeDir
+	d := &Deployer{sftp: mockClient}
+	d.SetOSFamily(model.OSFamilyWindowsAdmin)
+
+	if err := d.DeployAuthorizedKeys("ssh-ed25519 AAAA admin@key"); err != nil {
+		t.Fatalf("DeployAuthorizedKeys failed: %v", err)
+	}
+	const final = "/C:/ProgramData/ssh/administrators_authorized_keys"
+	if _, ok := mockClient.files[final]; !ok {
+		t.Fatalf("expected %s to be written, actions: %v", final, mockClient.actions)
+	}
+	for _, a := range mockClient.actions {
+		if strings.HasPrefix(a, "chmod:") || strings.HasPrefix(a, "mkdir:") {
+			t.Fatalf("unexpected %q on a Windows host", a)
+		}
+	}
+
+	data, err := d.GetAuthorizedKeys()
+	if err != nil || string(data) != "ssh-ed25519 AAAA admin@key" {
+		t.Fatalf("GetAuthorizedKeys read the wrong file: %q, %v", data, err)
+	}
+}
+
+func TestDeployKnownHosts_WritesNextToAuthorizedKeys(t *testing.T) {
+	mockClient := newMockSftpClient()
+	mockClient.perms[".ssh"] = 0700 | os.ModeDir
+	d := &Deployer{sftp: mockClient}
+
+	content := "# Keymaster Managed known_hosts\nweb-01 ssh-ed25519 AAAA\n"
+	if err := d.DeployKnownHosts(content); err != nil {
+		t.Fatalf("DeployKnownHosts failed: %v", err)
+	}
+	f, ok := mockClient.files[".ssh/known_hosts"]
+	if !ok || f.String() != content {
+		t.Fatalf("known_hosts not written, actions: %v", mockClient.actions)
+	}
+	if pm := mockClient.perms[".ssh/known_hosts"]; pm != 0644 {
+		t.Errorf("expected known_hosts to have mode 0644, got %v", pm)
+	}
+	if _, ok := mockClient.files[".ssh/authorized_keys"]; ok {
+		t.Fatal("DeployKnownHosts must not touch authorized_keys")
+	}
+}
+
+func TestDeployKnownHosts_WindowsAdminUsesProfile(t *testing.T) {
+	mockClient := newMockSftpClient()
+	d := &Deployer{sftp: mockClient}
+	d.SetOSFamily(model.OSFamilyWindowsAdmin)
+
+	if err := d.DeployKnownHosts("web-01 ssh-ed25519 AAAA\n"); err != nil {
+		t.Fatalf("DeployKnownHosts failed: %v", err)
+	}
+	if _, ok := mockClient.files[".ssh/known_hosts"]; !ok {
+		t.Fatalf("expected known_hosts in the user profile, actions: %v", mockClient.actions)
+	}
+	for _, a := range mockClient.actions {
+		if strings.HasPrefix(a, "chmod:") {
+			t.Fatalf("unexpected chmod on a Windows host: %v", mockClient.actions)
+		}
+	}
+}
+
+func TestDeployManagedFile_RoundTrip(t *testing.T) {
+	mockClient := newMockSftpClient()
+	mockClient.perms[".ssh"] = 0700 | os.ModeDir
+	d := &Deployer{sftp: mockClient}
+
+	if _, err := d.GetManagedFile("authorized_principals"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected a missing file to match os.ErrNotExist, got %v", err)
+	}
+	content := "# Keymaster Managed authorized_principals\ndeploy\n"
+	if err := d.DeployManagedFile("authorized_principals", content, 0600); err != nil {
+		t.Fatalf("DeployManagedFile failed: %v", err)
+	}
+	if pm := mockClient.perms[".ssh/authorized_principals"]; pm != 0600 {
+		t.Errorf("expected authorized_principals to have mode 0600, got %v", pm)
+	}
+	got, err := d.GetManagedFile("authorized_principals")
+	if err != nil || string(got) != content {
+		t.Fatalf("GetManagedFile = %q, %v; want %q", got, err, content)
+	}
+	if _, ok := mockClient.files[".ssh/authorized_keys"]; ok {
+		t.Fatal("DeployManagedFile must not touch authorized_keys")
+	}
+}
+
+func TestStatAuthorizedKeys(t *testing.T) {
+	mockClient := newMockSftpClient()
+	mockClient.perms[".ssh"] = 0700 | os.ModeDir
+	mockClient.files[".ssh/authorized_keys"] = &mockSftpFile{Buffer: bytes.NewBufferString("ssh-ed25519 AAAA one\n"), path: ".ssh/authorized_keys", parent: mockClient}
+	mockClient.perms[".ssh/authorized_keys"] = 0644
+	d := &Deployer{sftp: mockClient}
+
+	file, dir, err := d.StatAuthorizedKeys()
+	if err != nil {
+		t.Fatalf("StatAuthorizedKeys failed: %v", err)
+	}
+	if file.Path != ".ssh/authorized_keys" || file.Mode != 0644 {
+		t.Errorf("unexpected file stat: %+v", file)
+	}
+	if dir.Path != ".ssh" || dir.Mode != 0700|os.ModeDir {
+		t.Errorf("unexpected directory stat: %+v", dir)
+	}
+
+	delete(mockClient.files, ".ssh/authorized_keys")
+	delete(mockClient.perms, ".ssh/authorized_keys")
+	if _, _, err := d.StatAuthorizedKeys(); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected a missing file to match os.ErrNotExist, got %v", err)
+	}
+}
+
+func TestReadRemoteFile(t *testing.T) {
+	mockClient := newMockSftpClient()
+	put := func(path, content string) {
+		mockClient.files[path] = &mockSftpFile{Buffer: bytes.NewBufferString(content), path: path, parent: mockClient}
+	}
+	put("normal", "ssh-ed25519 AAAA one\n")
+	put("empty", "")
+	put("huge", strings.Repeat("x", sshkey.MaxAuthorizedKeysSize+1))
+
+	if got, err := ReadRemoteFile(mockClient, "normal"); err != nil || string(got) != "ssh-ed25519 AAAA one\n" {
+		t.Fatalf("normal file: got %q, %v", got, err)
+	}
+	if got, err := ReadRemoteFile(mockClient, "empty"); err != nil || len(got) != 0 {
+		t.Fatalf("empty file: got %q, %v", got, err)
+	}
+	if _, err := ReadRemoteFile(mockClient, "huge"); !errors.Is(err, sshkey.ErrAuthorizedKeysTooLarge) {
+		t.Fatalf("expected the size cap to be enforced, got %v", err)
+	}
+	if _, err := ReadRemoteFile(mockClient, "missing"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected a not-exist error, got %v", err)
+	}
+}
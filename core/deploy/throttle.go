@@ -0,0 +1,101 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package deploy
+
+import (
+	"sync"
+	"time"
+
+	"github.com/toeirei/keymaster/core"
+)
+
+// DefaultHostThrottleMaxWait caps how long a single connection waits for its
+// turn before proceeding anyway.
+const DefaultHostThrottleMaxWait = 30 * time.Second
+
+// hostThrottle spaces out connections to the same host. Each host has a
+// single-token bucket refilled every interval; connections to different
+// hosts never wait on each other. This is independent of any overall
+// concurrency limit applied by callers.
+type hostThrottle struct {
+	mu       sync.Mutex
+	interval time.Duration
+	maxWait  time.Duration
+	// next holds, per canonical host:port, the earliest time the next
+	// connection may start.
+	next map[string]time.Time
+
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+func newHostThrottle(interval, maxWait time.Duration) *hostThrottle {
+	if maxWait <= 0 {
+		maxWait = DefaultHostThrottleMaxWait
+	}
+	return &hostThrottle{
+		interval: interval,
+		maxWait:  maxWait,
+		next:     make(map[string]time.Time),
+		now:      time.Now,
+		sleep:    time.Sleep,
+	}
+}
+
+// wait blocks until a connection to host may start and returns the time
+// spent waiting. Waits longer than maxWait are truncated so operations are
+// delayed, never failed, by throttling.
+func (t *hostThrottle) wait(host string) time.Duration {
+	if t == nil || t.interval <= 0 {
+		return 0
+	}
+	key := CanonicalizeHostPort(host)
+
+	t.mu.Lock()
+	now := t.now()
+	start := now
+	if next, ok := t.next[key]; ok && next.After(now) {
+		start = next
+	}
+	if start.Sub(now) > t.maxWait {
+		start = now.Add(t.maxWait)
+	}
+	// Reserve the slot before releasing the lock so concurrent callers for
+	// the same host queue up behind each other.
+	t.next[key] = start.Add(t.interval)
+	t.mu.Unlock()
+
+	d := start.Sub(now)
+	if d > 0 {
+		core.DefaultLogger().Debug("throttling ssh connection", "host", key, "wait", d)
+		t.sleep(d)
+	}
+	return d
+}
+
+var (
+	connectionThrottleMu sync.RWMutex
+	connectionThrottle   *hostThrottle
+)
+
+// SetHostConnectionInterval configures the minimum interval between two
+// connections to the same host. A zero interval disables throttling. maxWait
+// caps how long a connection waits; zero selects DefaultHostThrottleMaxWait.
+func SetHostConnectionInterval(interval, maxWait time.Duration) {
+	connectionThrottleMu.Lock()
+	defer connectionThrottleMu.Unlock()
+	if interval <= 0 {
+		connectionThrottle = nil
+		return
+	}
+	connectionThrottle = newHostThrottle(interval, maxWait)
+}
+
+// waitForHostSlot applies the configured per-host connection throttle.
+func waitForHostSlot(host string) {
+	connectionThrottleMu.RLock()
+	t := connectionThrottle
+	connectionThrottleMu.RUnlock()
+	t.wait(host)
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package deploy
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock advances only when the throttle sleeps, so spacing is exact.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestThrottle(interval, maxWait time.Duration) (*hostThrottle, *fakeClock) {
+	clk := &fakeClock{now: time.Unix(1700000000, 0)}
+	th := newHostThrottle(interval, maxWait)
+	th.now = clk.Now
+	th.sleep = clk.Sleep
+	return th, clk
+}
+
+func TestHostThrottle_SpacesSameHost(t *testing.T) {
+	th, clk := newTestThrottle(2*time.Second, 0)
+
+	start := clk.Now()
+	if d := th.wait("host.example.com"); d != 0 {
+		t.Fatalf("first connection should not wait, waited %v", d)
+	}
+	first := clk.Now()
+	// "host.example.com" and "host.example.com:22" are the same canonical host.
+	th.wait("host.example.com:22")
+	second := clk.Now()
+
+	if got := second.Sub(first); got < 2*time.Second {
+		t.Fatalf("expected connections spaced by at least 2s, got %v", got)
+	}
+	if first != start {
+		t.Fatalf("first connection was delayed")
+	}
+}
+
+func TestHostThrottle_DifferentHostsNotThrottled(t *testing.T) {
+	th, clk := newTestThrottle(2*time.Second, 0)
+
+	start := clk.Now()
+	for _, h := range []string{"a.example.com", "b.example.com", "a.example.com:2222"} {
+		if d := th.wait(h); d != 0 {
+			t.Fatalf("connection to %s waited %v", h, d)
+		}
+	}
+	if !clk.Now().Equal(start) {
+		t.Fatalf("clock advanced for distinct hosts")
+	}
+}
+
+func TestHostThrottle_WaitIsCapped(t *testing.T) {
+	th, _ := newTestThrottle(time.Minute, 5*time.Second)
+
+	th.wait("h")
+	if d := th.wait("h"); d != 5*time.Second {
+		t.Fatalf("expected wait capped at 5s, got %v", d)
+	}
+}
+
+func TestHostThrottle_RealClock(t *testing.T) {
+	SetHostConnectionInterval(50*time.Millisecond, time.Second)
+	defer SetHostConnectionInterval(0, 0)
+
+	waitForHostSlot("same.example.com")
+	first := time.Now()
+	waitForHostSlot("other.example.com")
+	if time.Since(first) > 40*time.Millisecond {
+		t.Fatalf("different host was throttled")
+	}
+	waitForHostSlot("same.example.com")
+	if got := time.Since(first); got < 45*time.Millisecond {
+		t.Fatalf("expected same-host connections spaced by ~50ms, got %v", got)
+	}
+}
+
+func TestHostThrottle_DisabledByDefault(t *testing.T) {
+	SetHostConnectionInterval(0, 0)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		waitForHostSlot("h")
+	}
+	if time.Since(start) > 100*time.Millisecond {
+		t.Fatalf("throttle should be disabled")
+	}
+}
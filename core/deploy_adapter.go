@@ -19,6 +19,19 @@ func (b *builtinBootstrapDeployer) DeployAuthorizedKeys(content string) error {
 
 func (b *builtinBootstrapDeployer) Close() { b.d.Close() }
 
+func (b *builtinBootstrapDeployer) SetOSFamily(family string) {
+	if d, ok := b.d.(OSFamilyDeployer); ok {
+		d.SetOSFamily(family)
+	}
+}
+
+func (b *builtinBootstrapDeployer) RunCommand(cmd string) (RemoteCommandResult, error) {
+	if r, ok := b.d.(RemoteCommandRunner); ok {
+		return r.RunCommand(cmd)
+	}
+	return RemoteCommandResult{}, fmt.Errorf("deployer does not support remote commands")
+}
+
 // NewBootstrapDeployer creates a BootstrapDeployer via the registered hook.
 func NewBootstrapDeployer(hostname, username string, privateKey security.Secret, expectedHostKey string) (BootstrapDeployer, error) {
 	d, err := NewBootstrapDeployerFunc(hostname, username, privateKey, expectedHostKey)
@@ -34,6 +47,12 @@ func NewBootstrapDeployer(hostname, username string, privateKey security.Secret,
 type builtinDeployerManager struct{}
 
 func (builtinDeployerManager) DeployForAccount(account model.Account, keepFile bool) error {
+	// Two writers racing over SFTP can leave a corrupt authorized_keys.
+	unlock, err := lockAccountForDeploy(account)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	return RunDeploymentForAccount(account, keepFile)
 }
 
@@ -73,7 +92,55 @@ func (builtinDeployerManager) GetRemoteHostKey(host string) (string, error) {
 }
 
 func (builtinDeployerManager) FetchAuthorizedKeys(account model.Account) ([]byte, error) {
-	// Use NewDeployerFactory hook which handles agent/passphrase.
+	deployer, err := connectForFetch(account)
+	if err != nil {
+		return nil, err
+	}
+	defer deployer.Close()
+
+	content, err := deployer.GetAuthorizedKeys()
+	if err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// FetchManagedFile reads the managed file called name from the account's host.
+func (builtinDeployerManager) FetchManagedFile(account model.Account, name string) ([]byte, error) {
+	deployer, err := connectForFetch(account)
+	if err != nil {
+		return nil, err
+	}
+	defer deployer.Close()
+
+	mfd, ok := deployer.(ManagedFileDeployer)
+	if !ok {
+		return nil, fmt.Errorf("deployer does not support %s", name)
+	}
+	return mfd.GetManagedFile(name)
+}
+
+// StatAuthorizedKeys stats the account's authorized_keys file and its
+// directory.
+func (builtinDeployerManager) StatAuthorizedKeys(account model.Account) (file, dir RemoteFileStat, err error) {
+	deployer, err := connectForFetch(account)
+	if err != nil {
+		return file, dir, err
+	}
+	defer deployer.Close()
+
+	stater, ok := deployer.(AuthorizedKeysStater)
+	if !ok {
+		return file, dir, fmt.Errorf("deployer cannot stat remote files")
+	}
+	return stater.StatAuthorizedKeys()
+}
+
+// connectForFetch connects to the account's host with the system key of its
+// serial, or the active key for accounts never deployed, and configures the
+// deployer for the account's OS family.
+func connectForFetch(account model.Account) (RemoteDeployer, error) {
+	// The NewDeployerFactory hook handles agent/passphrase.
 	var privateKeySecret security.Secret
 	kr := DefaultKeyReader()
 	if kr == nil {
@@ -106,18 +173,16 @@ func (builtinDeployerManager) FetchAuthorizedKeys(account model.Account) ([]byte
 		}
 	}()
 
-	deployer, err := NewDeployerFactory(account.Hostname, account.Username, privateKeySecret, passphrase)
+	deployer, err := connectWithHostKeyPrompt(account.Hostname, account.Username, privateKeySecret, passphrase)
 	if err != nil {
 		return nil, err
 	}
-	defer deployer.Close()
 	state.PasswordCache.Clear()
-
-	content, err := deployer.GetAuthorizedKeys()
-	if err != nil {
+	if err := configureDeployer(deployer, account); err != nil {
+		deployer.Close()
 		return nil, err
 	}
-	return content, nil
+	return deployer, nil
 }
 
 func (builtinDeployerManager) ImportRemoteKeys(account model.Account) ([]model.PublicKey, int, string, error) {
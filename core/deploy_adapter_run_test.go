@@ -2,6 +2,7 @@ package core
 
 import (
 	"errors"
+	"strings"
 	"testing"
 
 	"github.com/toeirei/keymaster/core/model"
@@ -115,4 +116,101 @@ func TestRunDeploymentForAccount_DeployFails_ReturnsError(t *testing.T) {
 	if err := RunDeploymentForAccount(acct, false); err == nil {
 		t.Fatalf("expected error when deploy fails, got nil")
 	}
+	if upd.lastID != 0 {
+		t.Fatalf("a failed deploy must not update the serial, got id=%d serial=%d", upd.lastID, upd.lastSerial)
+	}
+}
+
+func TestRunDeploymentForAccount_RecordsActiveSerialOnFirstDeploy(t *testing.T) {
+	i18n.Init("en")
+	SetDefaultKeyReader(&fakeKR{})
+	SetDefaultKeyLister(&fakeKL{})
+
+	origFactory := NewDeployerFactory
+	defer func() { NewDeployerFactory = origFactory }()
+	NewDeployerFactory = func(host, user string, privateKey security.Secret, passphrase []byte) (RemoteDeployer, error) {
+		return &fakeRemoteRun{}, nil
+	}
+	upd := &recordingUpdater{}
+	origUpd := DefaultAccountSerialUpdater()
+	SetDefaultAccountSerialUpdater(upd)
+	defer SetDefaultAccountSerialUpdater(origUpd)
+
+	// A never-deployed account has serial 0 and must end up on the active key.
+	acct := model.Account{ID: 303, Username: "u4", Hostname: "h4"}
+	if err := RunDeploymentForAccount(acct, false); err != nil {
+		t.Fatalf("RunDeploymentForAccount failed: %v", err)
+	}
+	if upd.lastID != acct.ID || upd.lastSerial != 1 {
+		t.Fatalf("expected serial 1 recorded for account %d, got id=%d serial=%d", acct.ID, upd.lastID, upd.lastSerial)
+	}
+}
+
+func TestRunDeploymentForAccount_NoUpdaterFailsBeforeConnecting(t *testing.T) {
+	i18n.Init("en")
+	SetDefaultKeyReader(&fakeKR{})
+	SetDefaultKeyLister(&fakeKL{})
+
+	connected := false
+	origFactory := NewDeployerFactory
+	defer func() { NewDeployerFactory = origFactory }()
+	NewDeployerFactory = func(host, user string, privateKey security.Secret, passphrase []byte) (RemoteDeployer, error) {
+		connected = true
+		return &fakeRemoteRun{}, nil
+	}
+	origUpd := DefaultAccountSerialUpdater()
+	SetDefaultAccountSerialUpdater(nil)
+	defer SetDefaultAccountSerialUpdater(origUpd)
+
+	err := RunDeploymentForAccount(model.Account{ID: 304, Username: "u5", Hostname: "h5", Serial: 1}, false)
+	if err == nil || !strings.Contains(err.Error(), "serial") {
+		t.Fatalf("expected a serial updater error, got %v", err)
+	}
+	if connected {
+		t.Fatal("the host must not be touched when the serial cannot be recorded")
+	}
+}
+
+func TestRunDeploymentForAccount_LogsStructuredAudit(t *testing.T) {
+	i18n.Init("en")
+	SetDefaultKeyReader(&fakeKR{})
+	SetDefaultKeyLister(&fakeKL{})
+
+	deployErr := errors.New("remote failure")
+	origFactory := NewDeployerFactory
+	defer func() { NewDeployerFactory = origFactory }()
+	NewDeployerFactory = func(host, user string, privateKey security.Secret, passphrase []byte) (RemoteDeployer, error) {
+		return &fakeRemoteRun{deployErr: deployErr}, nil
+	}
+	origUpd := DefaultAccountSerialUpdater()
+	SetDefaultAccountSerialUpdater(&recordingUpdater{})
+	defer SetDefaultAccountSerialUpdater(origUpd)
+	aw := &spyAuditW{}
+	origAW := DefaultAuditWriter()
+	SetDefaultAuditWriter(aw)
+	defer SetDefaultAuditWriter(origAW)
+
+	acct := model.Account{ID: 302, Username: "u3", Hostname: "h3", Serial: 1}
+	_ = RunDeploymentForAccount(acct, false)
+	deployErr = nil
+	if err := RunDeploymentForAccount(acct, false); err != nil {
+		t.Fatalf("RunDeploymentForAccount failed: %v", err)
+	}
+
+	if len(aw.actions) != 2 {
+		t.Fatalf("expected two audit entries, got %v", aw.actions)
+	}
+	for i, want := range []string{"DEPLOY_FAILED", "DEPLOY_SUCCESS"} {
+		action, details, _ := strings.Cut(aw.actions[i], ":")
+		summary, fields := model.DecodeAuditDetails(details)
+		if action != want || !strings.Contains(summary, "u3@h3") {
+			t.Fatalf("unexpected entry %d: %s %q", i, action, summary)
+		}
+		if fields[model.AuditFieldAccountID] != "302" || fields[model.AuditFieldAccount] != "u3@h3" || fields[model.AuditFieldSerial] == "" {
+			t.Fatalf("unexpected fields for %s: %v", action, fields)
+		}
+		if (action == "DEPLOY_FAILED") != (fields[model.AuditFieldError] != "") {
+			t.Fatalf("error field mismatch for %s: %v", action, fields)
+		}
+	}
 }
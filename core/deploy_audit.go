@@ -41,12 +41,16 @@ func AuditAccountStrict(account model.Account) error {
 		}
 	}()
 
-	deployer, err := NewDeployerFactory(account.Hostname, account.Username, SystemKeyToSecret(connectKey), passphrase)
+	deployer, err := connectWithHostKeyPrompt(account.Hostname, account.Username, SystemKeyToSecret(connectKey), passphrase)
 	if err != nil {
+		DefaultLogger().Error("audit connection failed", "account", account.String(), "err", err)
 		return fmt.Errorf(i18n.T("audit.error_connection_failed"), account.Serial, err)
 	}
 	defer deployer.Close()
 	state.PasswordCache.Clear()
+	if err := configureDeployer(deployer, account); err != nil {
+		return errors.New(i18n.T("audit.error_read_remote_file", err))
+	}
 
 	remoteContentBytes, err := deployer.GetAuthorizedKeys()
 	if err != nil {
@@ -58,12 +62,50 @@ func AuditAccountStrict(account model.Account) error {
 		return errors.New(i18n.T("audit.error_generate_expected", err))
 	}
 
-	normalize := func(s string) string {
-		s = strings.ReplaceAll(s, "\r\n", "\n")
-		s = strings.TrimSpace(s)
-		return s
+	if account.EffectiveDeployMode() == model.DeployModeAppendOnly {
+		if d := AnalyzeAppendOnlyDrift(expectedContent, string(remoteContentBytes), account.DeployedKeys); d.HasKeyDrift() {
+			DefaultLogger().Warn("audit drift detected", "account", account.String(), "mode", "strict", "deploy_mode", model.DeployModeAppendOnly)
+			return errors.New(i18n.T("audit.error_drift_detected"))
+		}
+	} else {
+		normalize := func(s string) string {
+			s = strings.ReplaceAll(s, "\r\n", "\n")
+			s = strings.TrimSpace(s)
+			return s
+		}
+		if normalize(string(remoteContentBytes)) != normalize(expectedContent) {
+			DefaultLogger().Warn("audit drift detected", "account", account.String(), "mode", "strict")
+			return errors.New(i18n.T("audit.error_drift_detected"))
+		}
+	}
+
+	if err := auditManagedFiles(deployer, account); err != nil {
+		return err
 	}
-	if normalize(string(remoteContentBytes)) != normalize(expectedContent) {
+	DefaultLogger().Debug("audit passed", "account", account.String(), "mode", "strict")
+	return nil
+}
+
+// auditManagedFiles compares the managed files account uses with the copies
+// on the host behind deployer, returning the drift error on a mismatch.
+func auditManagedFiles(deployer RemoteDeployer, account model.Account) error {
+	files, err := renderManagedFiles(account)
+	if err != nil {
+		return errors.New(i18n.T("audit.error_generate_expected", err))
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	mfd, ok := deployer.(ManagedFileDeployer)
+	if !ok {
+		return fmt.Errorf("deployer does not support %s", files[0].Name)
+	}
+	name, err := managedFileDrift(files, mfd.GetManagedFile)
+	if err != nil {
+		return err
+	}
+	if name != "" {
+		DefaultLogger().Warn("audit drift detected", "account", account.String(), "mode", "strict", "file", name)
 		return errors.New(i18n.T("audit.error_drift_detected"))
 	}
 	return nil
@@ -96,12 +138,16 @@ func AuditAccountSerial(account model.Account) error {
 		}
 	}()
 
-	deployer, err := NewDeployerFactory(account.Hostname, account.Username, SystemKeyToSecret(connectKey), passphrase)
+	deployer, err := connectWithHostKeyPrompt(account.Hostname, account.Username, SystemKeyToSecret(connectKey), passphrase)
 	if err != nil {
+		DefaultLogger().Error("audit connection failed", "account", account.String(), "err", err)
 		return fmt.Errorf(i18n.T("audit.error_connection_failed"), account.Serial, err)
 	}
 	defer deployer.Close()
 	state.PasswordCache.Clear()
+	if err := configureDeployer(deployer, account); err != nil {
+		return errors.New(i18n.T("audit.error_read_remote_file", err))
+	}
 
 	remoteContentBytes, err := deployer.GetAuthorizedKeys()
 	if err != nil {
@@ -126,8 +172,10 @@ func AuditAccountSerial(account model.Account) error {
 	}
 	serial, err := sshkey.ParseSerial(header)
 	if err != nil || serial != account.Serial {
+		DefaultLogger().Warn("audit drift detected", "account", account.String(), "mode", "serial", "remote_serial", serial, "expected_serial", account.Serial)
 		return errors.New(i18n.T("audit.error_drift_detected"))
 	}
+	DefaultLogger().Debug("audit passed", "account", account.String(), "mode", "serial")
 	return nil
 }
 
@@ -0,0 +1,77 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// DeployCheckpoint records the accounts a fleet deploy completed, so an
+// interrupted rollout can be resumed without deploying them again. Accounts
+// that failed are not recorded and are retried on resume.
+type DeployCheckpoint struct {
+	Completed []int     `json:"completed"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// IsCompleted reports whether the account with the given ID was completed.
+func (c *DeployCheckpoint) IsCompleted(id int) bool {
+	return c != nil && slices.Contains(c.Completed, id)
+}
+
+// Remaining returns the accounts not completed in c, in their original order.
+func (c *DeployCheckpoint) Remaining(accounts []model.Account) []model.Account {
+	var out []model.Account
+	for _, acc := range accounts {
+		if !c.IsCompleted(acc.ID) {
+			out = append(out, acc)
+		}
+	}
+	return out
+}
+
+// ReadDeployCheckpoint reads a checkpoint written by WriteDeployCheckpoint.
+func ReadDeployCheckpoint(path string) (*DeployCheckpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read deploy checkpoint: %w", err)
+	}
+	var c DeployCheckpoint
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parse deploy checkpoint %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// WriteDeployCheckpoint writes c to path. The file is replaced atomically so
+// a deploy killed mid-write leaves the previous checkpoint intact.
+func WriteDeployCheckpoint(path string, c *DeployCheckpoint) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode deploy checkpoint: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("write deploy checkpoint: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write deploy checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write deploy checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("write deploy checkpoint: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// hookDM is canaryDM calling onDeploy after each deploy attempt.
+type hookDM struct {
+	canaryDM
+	onDeploy func(id int)
+}
+
+func (d *hookDM) DeployForAccount(account model.Account, keepFile bool) error {
+	err := d.canaryDM.DeployForAccount(account, keepFile)
+	d.onDeploy(account.ID)
+	return err
+}
+
+func TestDeployCheckpoint_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	want := &DeployCheckpoint{Completed: []int{3, 1}, UpdatedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}
+	if err := WriteDeployCheckpoint(path, want); err != nil {
+		t.Fatalf("WriteDeployCheckpoint: %v", err)
+	}
+	got, err := ReadDeployCheckpoint(path)
+	if err != nil {
+		t.Fatalf("ReadDeployCheckpoint: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("round trip mismatch: got %+v, want %+v", got, want)
+	}
+	if !got.IsCompleted(3) || got.IsCompleted(2) {
+		t.Fatalf("unexpected IsCompleted results for %+v", got)
+	}
+
+	if _, err := ReadDeployCheckpoint(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatal("expected error for a missing checkpoint")
+	}
+	bad := filepath.Join(t.TempDir(), "bad.json")
+	_ = os.WriteFile(bad, []byte("not json"), 0o600)
+	if _, err := ReadDeployCheckpoint(bad); err == nil {
+		t.Fatal("expected error for a malformed checkpoint")
+	}
+}
+
+func TestDeployAccountsWithOptions_CheckpointAndResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	st := &simpleFakeStore{accounts: canaryAccounts()}
+
+	// The first run is interrupted while account 3 fails.
+	ctx, cancel := context.WithCancel(context.Background())
+	hook := &hookDM{canaryDM: canaryDM{deployErr: map[int]error{3: errors.New("connection refused")}}, onDeploy: func(id int) {
+		if id == 3 {
+			cancel()
+		}
+	}}
+	_, err := DeployAccountsWithOptions(ctx, st, hook, nil, DeployRunOptions{CheckpointPath: path}, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected interrupted run, got %v", err)
+	}
+	cp, err := ReadDeployCheckpoint(path)
+	if err != nil {
+		t.Fatalf("ReadDeployCheckpoint: %v", err)
+	}
+	if !reflect.DeepEqual(cp.Completed, []int{1, 2}) {
+		t.Fatalf("expected accounts 1 and 2 completed, got %v", cp.Completed)
+	}
+
+	// Resuming skips the completed accounts and keeps extending the checkpoint.
+	dm := &canaryDM{}
+	res, err := DeployAccountsWithOptions(context.Background(), st, dm, nil, DeployRunOptions{Resume: cp, CheckpointPath: path}, nil)
+	if err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if !reflect.DeepEqual(dm.deployed, []int{3, 4}) || len(res) != 2 {
+		t.Fatalf("expected only accounts 3 and 4 deployed, got %v", dm.deployed)
+	}
+	cp, _ = ReadDeployCheckpoint(path)
+	if !reflect.DeepEqual(cp.Completed, []int{1, 2, 3, 4}) {
+		t.Fatalf("expected all accounts completed, got %v", cp.Completed)
+	}
+
+	// A checkpoint that cannot be written stops the rollout.
+	dm = &canaryDM{}
+	_, err = DeployAccountsWithOptions(context.Background(), st, dm, nil,
+		DeployRunOptions{CheckpointPath: filepath.Join(t.TempDir(), "missing", "checkpoint.json")}, nil)
+	if err == nil || len(dm.deployed) != 1 {
+		t.Fatalf("expected the rollout to stop after an unwritable checkpoint, got %v (deployed %v)", err, dm.deployed)
+	}
+}
+
+func TestDeployAccountsWithOptions_Pause(t *testing.T) {
+	st := &simpleFakeStore{accounts: canaryAccounts()}
+	dm := &canaryDM{}
+	start := time.Now()
+	if _, err := DeployAccountsWithOptions(context.Background(), st, dm, nil, DeployRunOptions{Pause: 20 * time.Millisecond}, nil); err != nil {
+		t.Fatalf("deploy: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Fatalf("expected three pauses between four accounts, took %s", elapsed)
+	}
+
+	// Cancelling during a pause stops before the next account.
+	ctx, cancel := context.WithCancel(context.Background())
+	hook := &hookDM{onDeploy: func(int) { cancel() }}
+	_, err := DeployAccountsWithOptions(ctx, st, hook, nil, DeployRunOptions{Pause: time.Hour}, nil)
+	if !errors.Is(err, context.Canceled) || len(hook.deployed) != 1 {
+		t.Fatalf("expected one deploy before cancellation, got %v (deployed %v)", err, hook.deployed)
+	}
+}
@@ -6,9 +6,9 @@ package core
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 
-	"github.com/toeirei/keymaster/core/logging"
 	"github.com/toeirei/keymaster/core/model"
 	"github.com/toeirei/keymaster/core/security"
 	"github.com/toeirei/keymaster/core/state"
@@ -26,13 +26,13 @@ func DecommissionAccount(account model.Account, systemKey security.Secret, optio
 	}
 
 	auditAction := "DECOMMISSION_START"
-	auditDetails := fmt.Sprintf("Starting decommission of account %s (ID: %d)", account.String(), account.ID)
+	auditDetails := fmt.Sprintf("Starting decommission of account %s", account.String())
 	if options.DryRun {
 		auditAction = "DECOMMISSION_DRYRUN"
-		auditDetails = fmt.Sprintf("DRY RUN: Would decommission account %s (ID: %d)", account.String(), account.ID)
+		auditDetails = fmt.Sprintf("DRY RUN: Would decommission account %s", account.String())
 	}
 	if w := DefaultAuditWriter(); w != nil {
-		_ = w.LogAction(auditAction, auditDetails)
+		_ = LogAuditFields(w, auditAction, auditDetails, decommissionAuditFields(account, nil))
 	}
 
 	if options.DryRun {
@@ -55,7 +55,7 @@ func DecommissionAccount(account model.Account, systemKey security.Secret, optio
 				result.Skipped = true
 				result.SkipReason = fmt.Sprintf("remote cleanup failed and --force not specified: %v", err)
 				if w := DefaultAuditWriter(); w != nil {
-					_ = w.LogAction("DECOMMISSION_FAILED", fmt.Sprintf("Failed to decommission %s: %v", account.String(), err))
+					_ = LogAuditFields(w, "DECOMMISSION_FAILED", fmt.Sprintf("Failed to decommission %s: %v", account.String(), err), decommissionAuditFields(account, err))
 				}
 				return result
 			}
@@ -66,20 +66,20 @@ func DecommissionAccount(account model.Account, systemKey security.Secret, optio
 	if mgr == nil {
 		result.DatabaseDeleteError = fmt.Errorf("no account manager configured")
 		if w := DefaultAuditWriter(); w != nil {
-			_ = w.LogAction("DECOMMISSION_FAILED", fmt.Sprintf("Failed to delete account %s from database: %v", account.String(), result.DatabaseDeleteError))
+			_ = LogAuditFields(w, "DECOMMISSION_FAILED", fmt.Sprintf("Failed to delete account %s from database: %v", account.String(), result.DatabaseDeleteError), decommissionAuditFields(account, result.DatabaseDeleteError))
 		}
 		return result
 	}
 	if err := mgr.DeleteAccount(account.ID); err != nil {
 		result.DatabaseDeleteError = err
 		if w := DefaultAuditWriter(); w != nil {
-			_ = w.LogAction("DECOMMISSION_FAILED", fmt.Sprintf("Failed to delete account %s from database: %v", account.String(), err))
+			_ = LogAuditFields(w, "DECOMMISSION_FAILED", fmt.Sprintf("Failed to delete account %s from database: %v", account.String(), err), decommissionAuditFields(account, err))
 		}
 		return result
 	}
 	result.DatabaseDeleteDone = true
 
-	details := fmt.Sprintf("Successfully decommissioned account %s (ID: %d)", account.String(), account.ID)
+	details := fmt.Sprintf("Successfully decommissioned account %s", account.String())
 	if result.RemoteCleanupError != nil {
 		details += fmt.Sprintf(" - Warning: remote cleanup failed: %v", result.RemoteCleanupError)
 	}
@@ -87,23 +87,40 @@ func DecommissionAccount(account model.Account, systemKey security.Secret, optio
 		details += fmt.Sprintf(" - Backup created: %s", result.BackupPath)
 	}
 	if w := DefaultAuditWriter(); w != nil {
-		_ = w.LogAction("DECOMMISSION_SUCCESS", details)
+		fields := decommissionAuditFields(account, result.RemoteCleanupError)
+		if result.BackupPath != "" {
+			fields["backup"] = result.BackupPath
+		}
+		_ = LogAuditFields(w, "DECOMMISSION_SUCCESS", details, fields)
 	}
 
 	return result
 }
 
+// decommissionAuditFields returns the structured audit fields of a
+// decommission event; err is recorded when non-nil.
+func decommissionAuditFields(account model.Account, err error) model.AuditFields {
+	fields := model.AuditFields{
+		model.AuditFieldAccountID: strconv.Itoa(account.ID),
+		model.AuditFieldAccount:   account.String(),
+	}
+	if err != nil {
+		fields[model.AuditFieldError] = err.Error()
+	}
+	return fields
+}
+
 // BulkDecommissionAccounts decommissions multiple accounts with progress reporting
 func BulkDecommissionAccounts(accounts []model.Account, systemKey security.Secret, options DecommissionOptions) []DecommissionResult {
 	results := make([]DecommissionResult, 0, len(accounts))
 
 	for i, account := range accounts {
-		logging.Infof("Decommissioning account %d/%d: %s", i+1, len(accounts), account.String())
+		DefaultLogger().Info("decommissioning account", "index", i+1, "total", len(accounts), "account", account.String())
 
 		result := DecommissionAccount(account, systemKey, options)
 		results = append(results, result)
 
-		logging.Infof("  → %s", result.AccountString)
+		DefaultLogger().Info("decommission finished", "account", result.AccountString)
 	}
 
 	return results
@@ -123,6 +140,9 @@ func cleanupRemoteAuthorizedKeys(account model.Account, systemKey security.Secre
 		return fmt.Errorf("failed to connect to %s@%s: %w", account.Username, account.Hostname, err)
 	}
 	defer deployer.Close()
+	if err := configureDeployer(deployer, account); err != nil {
+		return err
+	}
 
 	if keepFile {
 		return removeKeymasterContent(deployer, result, account.ID)
@@ -150,6 +170,9 @@ func cleanupRemoteAuthorizedKeysSelective(account model.Account, systemKey secur
 		return fmt.Errorf("failed to connect to %s@%s: %w", account.Username, account.Hostname, err)
 	}
 	defer deployer.Close()
+	if err := configureDeployer(deployer, account); err != nil {
+		return err
+	}
 
 	if len(options.SelectiveKeys) > 0 {
 		return removeSelectiveKeymasterContent(deployer, result, account.ID, options.SelectiveKeys, true)
@@ -6,10 +6,11 @@ package core
 import (
 	"context"
 	"fmt"
+	"time"
 )
 
 // DeployDirtyAccounts fetches all active accounts from the store, selects
-// accounts marked `IsDirty`, deploys to each using the provided DeployerManager,
+// accounts marked `IsDirty` that have deploys enabled, deploys to each using the provided DeployerManager,
 // and clears the `is_dirty` flag for accounts that deployed successfully.
 // It returns the per-account DeployResult slice and an error if fetching
 // accounts failed.
@@ -19,11 +20,14 @@ func DeployDirtyAccounts(ctx context.Context, st Store, dm DeployerManager, rep
 		return nil, fmt.Errorf("get accounts: %w", err)
 	}
 
-	dirty := DirtyAccounts(accounts)
+	dirty := DirtyAccounts(DeployableAccounts(accounts))
 	results := make([]DeployResult, 0, len(dirty))
 	for _, acc := range dirty {
+		start := time.Now()
 		err := dm.DeployForAccount(acc, false)
-		results = append(results, DeployResult{Account: acc, Error: err})
+		res := DeployResult{Account: acc, Error: err, Duration: time.Since(start)}
+		recordDeployResult(res)
+		results = append(results, res)
 		if err == nil {
 			// Best-effort: clear is_dirty; log/store error ignored for now
 			_ = st.UpdateAccountIsDirty(acc.ID, false)
@@ -35,11 +35,18 @@ func (f *fakeStoreForDirty) DeleteAccount(accountID int) error
 func (f *fakeStoreForDirty) AssignKeyToAccount(keyID, accountID int) error             { return nil }
 func (f *fakeStoreForDirty) CreateSystemKey(publicKey, privateKey string) (int, error) { return 0, nil }
 func (f *fakeStoreForDirty) RotateSystemKey(publicKey, privateKey string) (int, error) { return 0, nil }
+func (f *fakeStoreForDirty) GetAllSystemKeys() ([]model.SystemKey, error)              { return nil, nil }
+func (f *fakeStoreForDirty) DeleteSystemKey(serial int) error                          { return nil }
 func (f *fakeStoreForDirty) GetActiveSystemKey() (*model.SystemKey, error)             { return nil, nil }
 func (f *fakeStoreForDirty) AddKnownHostKey(hostname, key string) error                { return nil }
 func (f *fakeStoreForDirty) ExportDataForBackup() (*model.BackupData, error)           { return nil, nil }
-func (f *fakeStoreForDirty) ImportDataFromBackup(*model.BackupData) error              { return nil }
-func (f *fakeStoreForDirty) IntegrateDataFromBackup(*model.BackupData) error           { return nil }
+func (f *fakeStoreForDirty) ExportTablesForBackup(tables []string) (*model.BackupData, error) {
+	return nil, nil
+}
+func (f *fakeStoreForDirty) ImportDataFromBackup(*model.BackupData) error { return nil }
+func (f *fakeStoreForDirty) IntegrateDataFromBackup(*model.BackupData) (model.RestoreSummary, error) {
+	return model.RestoreSummary{}, nil
+}
 
 // satisfy updated Store interface
 func (f *fakeStoreForDirty) ToggleAccountStatus(id int, enabled bool) error      { return nil }
@@ -71,7 +78,7 @@ func (f *fakeDMForDirty) ImportRemoteKeys(account model.Account) ([]model.Public
 func (f *fakeDMForDirty) IsPassphraseRequired(err error) bool { return false }
 
 func TestDeployDirtyAccounts_ClearsOnSuccess(t *testing.T) {
-	st := &fakeStoreForDirty{accounts: []model.Account{{ID: 1, IsDirty: false}, {ID: 2, IsDirty: true}, {ID: 3, IsDirty: true}}}
+	st := &fakeStoreForDirty{accounts: []model.Account{{ID: 1, IsActive: true, DeployEnabled: true, IsDirty: false}, {ID: 2, IsActive: true, DeployEnabled: true, IsDirty: true}, {ID: 3, IsActive: true, DeployEnabled: true, IsDirty: true}}}
 	dm := &fakeDMForDirty{}
 
 	res, err := DeployDirtyAccounts(context.Background(), st, dm, nil)
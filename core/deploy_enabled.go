@@ -0,0 +1,45 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"fmt"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// SetDeployEnabled includes or excludes account id from deploys. A
+// deploy-disabled account stays active, so it is still audited and keeps its
+// dirty flag until deploys are enabled again.
+func SetDeployEnabled(st DeployEnabledStore, id int, enabled bool) error {
+	accounts, err := st.GetAllAccounts()
+	if err != nil {
+		return fmt.Errorf("failed to load accounts: %w", err)
+	}
+	for _, acc := range accounts {
+		if acc.ID != id {
+			continue
+		}
+		if acc.DeployEnabled == enabled {
+			return nil
+		}
+		if err := st.SetAccountDeployEnabled(id, enabled); err != nil {
+			return fmt.Errorf("failed to save deploy setting: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("account not found: %d", id)
+}
+
+// DeployableAccounts returns the accounts deploys may write to: active
+// accounts with deploys enabled.
+func DeployableAccounts(accounts []model.Account) []model.Account {
+	out := make([]model.Account, 0, len(accounts))
+	for _, acc := range accounts {
+		if acc.Deployable() {
+			out = append(out, acc)
+		}
+	}
+	return out
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+type deployEnabledStore struct {
+	accounts []model.Account
+	enabled  map[int]bool
+}
+
+func (s *deployEnabledStore) GetAllAccounts() ([]model.Account, error) { return s.accounts, nil }
+func (s *deployEnabledStore) SetAccountDeployEnabled(id int, enabled bool) error {
+	s.enabled[id] = enabled
+	return nil
+}
+
+func TestSetDeployEnabled(t *testing.T) {
+	st := &deployEnabledStore{accounts: []model.Account{{ID: 1, DeployEnabled: true}}, enabled: map[int]bool{}}
+	if err := SetDeployEnabled(st, 1, true); err != nil || len(st.enabled) != 0 {
+		t.Fatalf("expected an unchanged setting to be a no-op, got %v %v", err, st.enabled)
+	}
+	if err := SetDeployEnabled(st, 1, false); err != nil || st.enabled[1] {
+		t.Fatalf("expected deploys to be disabled, got %v %v", err, st.enabled)
+	}
+	if err := SetDeployEnabled(st, 9, false); err == nil {
+		t.Fatal("expected an unknown account to be rejected")
+	}
+}
+
+// pausedAccounts returns canaryAccounts with deploys disabled for account 2.
+func pausedAccounts() []model.Account {
+	accounts := canaryAccounts()
+	accounts[1].DeployEnabled = false
+	accounts[1].IsDirty = true
+	accounts[2].IsDirty = true
+	return accounts
+}
+
+func TestDeployAccounts_SkipsDeployDisabled(t *testing.T) {
+	dm := &canaryDM{}
+	res, err := DeployAccountsWithOptions(context.Background(), &simpleFakeStore{accounts: pausedAccounts()}, dm, nil, DeployRunOptions{}, nil)
+	if err != nil {
+		t.Fatalf("deploy: %v", err)
+	}
+	if !reflect.DeepEqual(dm.deployed, []int{1, 3, 4}) || len(res) != 3 {
+		t.Fatalf("expected account 2 to be skipped, got %v", dm.deployed)
+	}
+
+	identifier := "deploy@web-01"
+	_, err = DeployAccounts(context.Background(), &simpleFakeStore{accounts: pausedAccounts()}, dm, &identifier, nil)
+	if err == nil || !strings.Contains(err.Error(), "deploys are disabled") {
+		t.Fatalf("expected a direct deploy to be refused, got %v", err)
+	}
+}
+
+func TestDeployDirtyAccounts_SkipsDeployDisabled(t *testing.T) {
+	st := &simpleFakeStore{accounts: pausedAccounts()}
+	dm := &canaryDM{}
+	if _, err := DeployDirtyAccounts(context.Background(), st, dm, nil); err != nil {
+		t.Fatalf("DeployDirtyAccounts: %v", err)
+	}
+	if !reflect.DeepEqual(dm.deployed, []int{3}) {
+		t.Fatalf("expected only the dirty deployable account, got %v", dm.deployed)
+	}
+	if _, touched := st.updates[2]; touched {
+		t.Fatal("a deploy-disabled account must stay dirty")
+	}
+}
+
+func TestRunCanaryDeploy_SkipsDeployDisabled(t *testing.T) {
+	dm := &canaryDM{}
+	res, err := RunCanaryDeploy(context.Background(), &simpleFakeStore{accounts: pausedAccounts()}, dm, CanaryOptions{Canaries: 1, Tag: "web", AuditMode: "serial"}, nil)
+	if err != nil {
+		t.Fatalf("RunCanaryDeploy: %v", err)
+	}
+	if len(res.Canaries)+len(res.Rest) != 2 || !reflect.DeepEqual(dm.deployed, []int{1, 3}) {
+		t.Fatalf("expected only the deployable web accounts, got %v", dm.deployed)
+	}
+}
+
+func TestAuditAccounts_IncludesDeployDisabled(t *testing.T) {
+	dm := &canaryDM{auditErr: map[int]error{2: errors.New("drift")}}
+	res, err := AuditAccounts(context.Background(), &simpleFakeStore{accounts: pausedAccounts()}, dm, "serial", nil)
+	if err != nil {
+		t.Fatalf("AuditAccounts: %v", err)
+	}
+	if len(res) != 4 || res[1].Account.ID != 2 || res[1].Error == nil {
+		t.Fatalf("expected the deploy-disabled account to be audited, got %+v", res)
+	}
+}
+
+func TestApplyRemediationPolicies_DeployDisabledAlerts(t *testing.T) {
+	dm := &canaryDM{}
+	r := driftedResult(1, model.RemediationAuto)
+	r.Account.DeployEnabled = false
+
+	adjusted, outcomes := ApplyRemediationPolicies(dm, []AuditResult{r})
+	if len(dm.deployed) != 0 {
+		t.Fatalf("expected no redeploy, got %v", dm.deployed)
+	}
+	if adjusted[0].Error == nil || outcomes[0].Policy != model.RemediationAlert {
+		t.Fatalf("expected the drift to be alerted on, got %+v %+v", adjusted[0], outcomes[0])
+	}
+}
@@ -63,6 +63,9 @@ func ImportRemoteKeys(account model.Account) (importedKeys []model.PublicKey, sk
 		return nil, 0, warning, fmt.Errorf("connection failed: %w", err)
 	}
 	defer deployer.Close()
+	if err := configureDeployer(deployer, account); err != nil {
+		return nil, 0, warning, err
+	}
 
 	content, err := deployer.GetAuthorizedKeys()
 	if err != nil {
@@ -76,7 +79,7 @@ func ImportRemoteKeys(account model.Account) (importedKeys []model.PublicKey, sk
 			continue
 		}
 
-		alg, keyData, comment, parseErr := sshkey.Parse(line)
+		alg, keyData, comment, parseErr := sshkey.Normalize(line)
 		if parseErr != nil || comment == "" {
 			skippedCount++
 			continue
@@ -0,0 +1,67 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// ResolveKeysByComment returns the stored keys with the given comments, in
+// the given order. Every comment must match a key.
+func ResolveKeysByComment(kl KeyLister, comments []string) ([]model.PublicKey, error) {
+	all, err := kl.GetAllPublicKeys()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load keys: %w", err)
+	}
+	byComment := make(map[string]model.PublicKey, len(all))
+	for _, k := range all {
+		byComment[k.Comment] = k
+	}
+	var out []model.PublicKey
+	var missing []string
+	for _, c := range comments {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		k, ok := byComment[c]
+		if !ok {
+			missing = append(missing, c)
+			continue
+		}
+		out = append(out, k)
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("no key with comment: %s", strings.Join(missing, ", "))
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no keys given")
+	}
+	return out, nil
+}
+
+// DeployAccountWithKeys deploys authorized_keys to account rendered from only
+// keys and the system key, without changing the account's key assignments.
+// The account is marked dirty afterwards so the next deploy restores its
+// assigned keys.
+func DeployAccountWithKeys(st AccountDirtyMarker, account model.Account, keys []model.PublicKey) error {
+	unlock, err := lockAccountForDeploy(account)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	err = runDeploymentForAccount(account, false, func() (string, error) {
+		return GenerateKeysContentWithKeys(account.ID, keys)
+	})
+	if err != nil {
+		return err
+	}
+	if err := st.UpdateAccountIsDirty(account.ID, true); err != nil {
+		return fmt.Errorf("mark account dirty after one-off deploy: %w", err)
+	}
+	return nil
+}
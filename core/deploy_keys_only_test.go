@@ -0,0 +1,106 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/core/security"
+	"github.com/toeirei/keymaster/ui/i18n"
+)
+
+// keysOnlyKL stores a global key, an assigned key and an unassigned key.
+type keysOnlyKL struct{ fakeKL }
+
+func (*keysOnlyKL) GetGlobalPublicKeys() ([]model.PublicKey, error) {
+	return []model.PublicKey{{ID: 1, Algorithm: "ssh-ed25519", KeyData: "GLOBAL", Comment: "ops", IsGlobal: true}}, nil
+}
+func (*keysOnlyKL) GetKeysForAccount(accountID int) ([]model.PublicKey, error) {
+	return []model.PublicKey{{ID: 2, Algorithm: "ssh-ed25519", KeyData: "ALICE", Comment: "alice"}}, nil
+}
+func (k *keysOnlyKL) GetAllPublicKeys() ([]model.PublicKey, error) {
+	global, _ := k.GetGlobalPublicKeys()
+	assigned, _ := k.GetKeysForAccount(0)
+	return append(append(global, assigned...),
+		model.PublicKey{ID: 3, Algorithm: "ssh-ed25519", KeyData: "BOB", Comment: "bob"},
+		model.PublicKey{ID: 4, Algorithm: "ssh-ed25519", KeyData: "CAROL", Comment: "carol"}), nil
+}
+
+func TestResolveKeysByComment(t *testing.T) {
+	keys, err := ResolveKeysByComment(&keysOnlyKL{}, []string{"carol", " bob "})
+	if err != nil {
+		t.Fatalf("ResolveKeysByComment: %v", err)
+	}
+	if len(keys) != 2 || keys[0].ID != 4 || keys[1].ID != 3 {
+		t.Fatalf("expected carol and bob in the given order, got %+v", keys)
+	}
+	if _, err := ResolveKeysByComment(&keysOnlyKL{}, []string{"bob", "mallory"}); err == nil || !strings.Contains(err.Error(), "mallory") {
+		t.Fatalf("expected error naming the unknown comment, got %v", err)
+	}
+	if _, err := ResolveKeysByComment(&keysOnlyKL{}, []string{" "}); err == nil {
+		t.Fatal("expected error for an empty key list")
+	}
+}
+
+func TestGenerateKeysContentWithKeys_OnlyOverrideAndSystemKey(t *testing.T) {
+	SetDefaultKeyReader(&fakeKR{})
+	SetDefaultKeyLister(&keysOnlyKL{})
+	withAccountReader(t, mapAccountReader{})
+
+	keys, err := ResolveKeysByComment(&keysOnlyKL{}, []string{"bob"})
+	if err != nil {
+		t.Fatalf("ResolveKeysByComment: %v", err)
+	}
+	content, err := GenerateKeysContentWithKeys(7, keys)
+	if err != nil {
+		t.Fatalf("GenerateKeysContentWithKeys: %v", err)
+	}
+	if !strings.Contains(content, "sys-pub") || !strings.Contains(content, "ssh-ed25519 BOB bob") {
+		t.Fatalf("expected system key and bob, got %q", content)
+	}
+	for _, unwanted := range []string{"GLOBAL", "ALICE", "CAROL"} {
+		if strings.Contains(content, unwanted) {
+			t.Fatalf("expected only the override keys, found %s in %q", unwanted, content)
+		}
+	}
+}
+
+func TestDeployAccountWithKeys_DeploysOverrideAndMarksDirty(t *testing.T) {
+	i18n.Init("en")
+	SetDefaultKeyReader(&fakeKR{})
+	SetDefaultKeyLister(&keysOnlyKL{})
+	withAccountReader(t, mapAccountReader{})
+
+	remote := &captureRemote{}
+	origFactory := NewDeployerFactory
+	defer func() { NewDeployerFactory = origFactory }()
+	NewDeployerFactory = func(host, user string, privateKey security.Secret, passphrase []byte) (RemoteDeployer, error) {
+		return remote, nil
+	}
+	upd := &recordingUpdater{}
+	origUpd := DefaultAccountSerialUpdater()
+	SetDefaultAccountSerialUpdater(upd)
+	defer SetDefaultAccountSerialUpdater(origUpd)
+
+	acct := model.Account{ID: 7, Username: "svc", Hostname: "test-01", Serial: 1}
+	st := &dirtyStore{accounts: []model.Account{acct}, dirty: map[int]bool{}}
+	keys := []model.PublicKey{{ID: 3, Algorithm: "ssh-ed25519", KeyData: "BOB", Comment: "bob"}}
+	if err := DeployAccountWithKeys(st, acct, keys); err != nil {
+		t.Fatalf("DeployAccountWithKeys: %v", err)
+	}
+	if !strings.Contains(remote.deployed, "sys-pub") || !strings.Contains(remote.deployed, "BOB") {
+		t.Fatalf("expected system key and bob deployed, got %q", remote.deployed)
+	}
+	if strings.Contains(remote.deployed, "ALICE") || strings.Contains(remote.deployed, "GLOBAL") {
+		t.Fatalf("expected assigned and global keys left out, got %q", remote.deployed)
+	}
+	if upd.lastID != 7 || upd.lastSerial != 1 {
+		t.Fatalf("expected serial recorded for account 7, got %+v", upd)
+	}
+	if !st.dirty[7] {
+		t.Fatal("expected account marked dirty so the next deploy restores its keys")
+	}
+}
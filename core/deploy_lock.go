@@ -0,0 +1,88 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// AccountLockedError is returned when another deployment to the same account
+// holds the deploy lock and the lock policy gave up waiting for it.
+type AccountLockedError struct {
+	Account model.Account
+	// Waited is how long the deployment waited; zero for fail-fast.
+	Waited time.Duration
+}
+
+func (e *AccountLockedError) Error() string {
+	if e.Waited == 0 {
+		return fmt.Sprintf("deployment to %s already in progress", e.Account.String())
+	}
+	return fmt.Sprintf("deployment to %s still in progress after waiting %s", e.Account.String(), e.Waited)
+}
+
+// accountLocks serializes authorized_keys writes per account id within this
+// process. Each account has a one-slot channel so waits can time out.
+type accountLocks struct {
+	mu    sync.Mutex
+	slots map[int]chan struct{}
+}
+
+func newAccountLocks() *accountLocks {
+	return &accountLocks{slots: make(map[int]chan struct{})}
+}
+
+func (l *accountLocks) slot(id int) chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ch, ok := l.slots[id]
+	if !ok {
+		ch = make(chan struct{}, 1)
+		l.slots[id] = ch
+	}
+	return ch
+}
+
+// acquire takes the lock for account. With failFast a held lock is an
+// immediate error; otherwise it waits up to timeout, or indefinitely when
+// timeout is zero. The returned func releases the lock.
+func (l *accountLocks) acquire(account model.Account, timeout time.Duration, failFast bool) (func(), error) {
+	ch := l.slot(account.ID)
+	release := func() { <-ch }
+	select {
+	case ch <- struct{}{}:
+		return release, nil
+	default:
+	}
+	if failFast {
+		return nil, &AccountLockedError{Account: account}
+	}
+
+	DefaultLogger().Debug("waiting for deploy lock", "account", account.String())
+	if timeout <= 0 {
+		ch <- struct{}{}
+		return release, nil
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case ch <- struct{}{}:
+		return release, nil
+	case <-timer.C:
+		return nil, &AccountLockedError{Account: account, Waited: timeout}
+	}
+}
+
+var deployLocks = newAccountLocks()
+
+// lockAccountForDeploy takes the per-account deploy lock according to
+// DefaultDeployOptions.
+func lockAccountForDeploy(account model.Account) (func(), error) {
+	opts := DefaultDeployOptions()
+	return deployLocks.acquire(account, opts.LockTimeout, opts.LockFailFast)
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/core/security"
+	"github.com/toeirei/keymaster/ui/i18n"
+)
+
+// overlapDeployer records how many writes to the host were in flight at once.
+type overlapDeployer struct {
+	inFlight, maxInFlight, writes *int32
+}
+
+func (d *overlapDeployer) DeployAuthorizedKeys(content string) error {
+	n := atomic.AddInt32(d.inFlight, 1)
+	for {
+		m := atomic.LoadInt32(d.maxInFlight)
+		if n <= m || atomic.CompareAndSwapInt32(d.maxInFlight, m, n) {
+			break
+		}
+	}
+	time.Sleep(30 * time.Millisecond)
+	atomic.AddInt32(d.writes, 1)
+	atomic.AddInt32(d.inFlight, -1)
+	return nil
+}
+func (d *overlapDeployer) GetAuthorizedKeys() ([]byte, error) { return nil, nil }
+func (d *overlapDeployer) Close()                             {}
+
+func TestDeployForAccount_SerializesConcurrentWrites(t *testing.T) {
+	i18n.Init("en")
+	prevKR, prevKL, prevUpd := DefaultKeyReader(), DefaultKeyLister(), DefaultAccountSerialUpdater()
+	SetDefaultKeyReader(&fakeKR{})
+	SetDefaultKeyLister(&fakeKL{})
+	SetDefaultAccountSerialUpdater(&recordingUpdater{})
+	defer func() {
+		SetDefaultKeyReader(prevKR)
+		SetDefaultKeyLister(prevKL)
+		SetDefaultAccountSerialUpdater(prevUpd)
+	}()
+
+	var inFlight, maxInFlight, writes int32
+	orig := NewDeployerFactory
+	NewDeployerFactory = func(host, user string, privateKey security.Secret, passphrase []byte) (RemoteDeployer, error) {
+		return &overlapDeployer{inFlight: &inFlight, maxInFlight: &maxInFlight, writes: &writes}, nil
+	}
+	defer func() { NewDeployerFactory = orig }()
+
+	acct := model.Account{ID: 1, Username: "deployuser", Hostname: "example.test", Serial: 1}
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- builtinDeployerManager{}.DeployForAccount(acct, false)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("DeployForAccount failed: %v", err)
+		}
+	}
+	if writes != 2 {
+		t.Fatalf("expected 2 writes, got %d", writes)
+	}
+	if maxInFlight != 1 {
+		t.Fatalf("expected serialized writes, saw %d concurrent writers", maxInFlight)
+	}
+}
+
+func TestAccountLocks_ContentionPolicy(t *testing.T) {
+	locks := newAccountLocks()
+	acc := model.Account{ID: 7, Username: "app", Hostname: "web-01"}
+	release, err := locks.acquire(acc, 0, false)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	var le *AccountLockedError
+	if _, err := locks.acquire(acc, 0, true); !errors.As(err, &le) || le.Waited != 0 {
+		t.Fatalf("expected fail-fast AccountLockedError, got %v", err)
+	}
+	if _, err := locks.acquire(acc, 20*time.Millisecond, false); !errors.As(err, &le) || le.Waited != 20*time.Millisecond {
+		t.Fatalf("expected AccountLockedError after timeout, got %v", err)
+	}
+	// Other accounts are not affected.
+	other, err := locks.acquire(model.Account{ID: 8}, 0, true)
+	if err != nil {
+		t.Fatalf("expected lock for another account, got %v", err)
+	}
+	other()
+
+	// A waiting deployment proceeds once the holder releases.
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		release()
+	}()
+	again, err := locks.acquire(acc, time.Second, false)
+	if err != nil {
+		t.Fatalf("expected lock after release, got %v", err)
+	}
+	again()
+}
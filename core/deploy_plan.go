@@ -0,0 +1,237 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// ErrDeployPlanStale is returned by VerifyDeployPlan and ExecutePlan when the
+// live state no longer matches the plan.
+var ErrDeployPlanStale = errors.New("state changed since the plan was built")
+
+// DeployPlan describes what a deploy would write, built by BuildDeployPlan
+// before anything is executed so UIs can present it for review.
+type DeployPlan struct {
+	CreatedAt time.Time `json:"created_at"`
+	// SystemKeySerial is the serial of the active system key the deploy
+	// applies.
+	SystemKeySerial int               `json:"system_key_serial"`
+	Entries         []DeployPlanEntry `json:"entries"`
+}
+
+// DeployPlanEntry is the plan for one account.
+type DeployPlanEntry struct {
+	Account model.Account `json:"account"`
+	// ContentHash is the HashAuthorizedKeysContent of the rendered content.
+	ContentHash string `json:"content_hash"`
+	// Keys lists the identities of the rendered keys, as recorded in
+	// model.Account.DeployedKeys after a deploy.
+	Keys []string `json:"keys"`
+	// SerialChanged is true when the account was last deployed with another
+	// system key serial, or never.
+	SerialChanged bool `json:"serial_changed"`
+	// KeysChanged is true when the rendered keys differ from those written
+	// by the last deploy.
+	KeysChanged bool `json:"keys_changed"`
+	// Err is set when the content could not be rendered. It is not
+	// serialized; WriteDeployPlan refuses plans holding one.
+	Err error `json:"-"`
+}
+
+// Changed reports whether deploying the entry would change the host. Dirty
+// accounts and entries that failed to render count as changed.
+func (e DeployPlanEntry) Changed() bool {
+	return e.SerialChanged || e.KeysChanged || e.Account.IsDirty || e.Err != nil
+}
+
+// OnlyChanged returns a copy of p without the entries that would not change
+// their host.
+func (p *DeployPlan) OnlyChanged() *DeployPlan {
+	out := &DeployPlan{CreatedAt: p.CreatedAt, SystemKeySerial: p.SystemKeySerial}
+	for _, e := range p.Entries {
+		if e.Changed() {
+			out.Entries = append(out.Entries, e)
+		}
+	}
+	return out
+}
+
+// BuildDeployPlan renders the authorized_keys content of each target and
+// compares it with what was last deployed, without contacting any host. Nil
+// targets plans every deployable account of st.
+func BuildDeployPlan(st Store, targets []model.Account) (*DeployPlan, error) {
+	if targets == nil {
+		accounts, err := st.GetAllActiveAccounts()
+		if err != nil {
+			return nil, fmt.Errorf("get accounts: %w", err)
+		}
+		targets = DeployableAccounts(accounts)
+	}
+	active, err := st.GetActiveSystemKey()
+	if err != nil {
+		return nil, fmt.Errorf("get active system key: %w", err)
+	}
+	if active == nil {
+		return nil, errors.New("no active system key found. please generate one first")
+	}
+
+	plan := &DeployPlan{CreatedAt: time.Now().UTC(), SystemKeySerial: active.Serial}
+	for _, acc := range targets {
+		entry := DeployPlanEntry{Account: acc, SerialChanged: acc.Serial != active.Serial}
+		content, err := GenerateKeysContentForSerial(acc.ID, active.Serial)
+		if err != nil {
+			entry.Err = err
+		} else {
+			entry.ContentHash = HashAuthorizedKeysContent([]byte(content))
+			entry.Keys = DeployedKeyIdentities(content)
+			entry.KeysChanged = !sameKeyIdentities(entry.Keys, acc.DeployedKeys)
+		}
+		plan.Entries = append(plan.Entries, entry)
+	}
+	return plan, nil
+}
+
+// sameKeyIdentities compares two identity lists ignoring order.
+func sameKeyIdentities(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
+
+// WriteDeployPlan writes plan to path as JSON for offline review. Plans with
+// entries that failed to render cannot be approved and are refused.
+func WriteDeployPlan(path string, plan *DeployPlan) error {
+	for _, e := range plan.Entries {
+		if e.Err != nil {
+			return fmt.Errorf("cannot write plan: %s: %w", e.Account.String(), e.Err)
+		}
+	}
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode deploy plan: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("write deploy plan: %w", err)
+	}
+	return nil
+}
+
+// ReadDeployPlan reads a plan written by WriteDeployPlan.
+func ReadDeployPlan(path string) (*DeployPlan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read deploy plan: %w", err)
+	}
+	var plan DeployPlan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("parse deploy plan %s: %w", path, err)
+	}
+	return &plan, nil
+}
+
+// VerifyDeployPlan checks that plan still describes the live state of st: the
+// active system key is unchanged and every planned account still exists, is
+// deployable and renders to the planned hash. Any difference is reported as
+// ErrDeployPlanStale, so an approved plan is never executed against state
+// nobody reviewed. On success it returns the plan rebuilt from the live
+// accounts, which is what ExecutePlan should be given.
+func VerifyDeployPlan(st Store, plan *DeployPlan) (*DeployPlan, error) {
+	accounts, err := st.GetAllActiveAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("get accounts: %w", err)
+	}
+	byID := make(map[int]model.Account, len(accounts))
+	for _, acc := range accounts {
+		byID[acc.ID] = acc
+	}
+	targets := make([]model.Account, 0, len(plan.Entries))
+	var problems []string
+	for _, e := range plan.Entries {
+		acc, ok := byID[e.Account.ID]
+		switch {
+		case !ok:
+			problems = append(problems, e.Account.String()+": account removed or deactivated")
+		case !acc.DeployEnabled:
+			problems = append(problems, acc.String()+": deploys disabled")
+		default:
+			targets = append(targets, acc)
+		}
+	}
+	live, err := BuildDeployPlan(st, targets)
+	if err != nil {
+		return nil, err
+	}
+	if live.SystemKeySerial != plan.SystemKeySerial {
+		problems = append(problems, fmt.Sprintf("active system key serial is %d, plan has %d", live.SystemKeySerial, plan.SystemKeySerial))
+	}
+	planned := make(map[int]string, len(plan.Entries))
+	for _, e := range plan.Entries {
+		planned[e.Account.ID] = e.ContentHash
+	}
+	for _, e := range live.Entries {
+		switch {
+		case e.Err != nil:
+			problems = append(problems, fmt.Sprintf("%s: %v", e.Account.String(), e.Err))
+		case e.ContentHash != planned[e.Account.ID]:
+			problems = append(problems, e.Account.String()+": authorized_keys differs")
+		}
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrDeployPlanStale, strings.Join(problems, "; "))
+	}
+	return live, nil
+}
+
+// ExecutePlan deploys the entries of plan in order through dm, honouring
+// opts.StopOnError and opts.Pause. Each account's content is rendered again
+// first; when it no longer matches the plan, for example after a key
+// assignment or a system key rotation, the account fails with
+// ErrDeployPlanStale instead of deploying something that was not reviewed.
+func ExecutePlan(ctx context.Context, dm DeployerManager, plan *DeployPlan, opts DeployRunOptions) ([]DeployResult, error) {
+	targets := make([]model.Account, 0, len(plan.Entries))
+	hashes := make(map[int]string, len(plan.Entries))
+	for _, e := range plan.Entries {
+		targets = append(targets, e.Account)
+		hashes[e.Account.ID] = e.ContentHash
+	}
+
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := deployTargetsCtx(ctx, planDeployerManager{dm, hashes}, targets, opts.Pause, func(r DeployResult) {
+		if r.Error != nil && opts.StopOnError {
+			cancel()
+		}
+	})
+	return results, parent.Err()
+}
+
+// planDeployerManager deploys an account only while its rendered content
+// still matches the planned hash.
+type planDeployerManager struct {
+	DeployerManager
+	hashes map[int]string
+}
+
+func (m planDeployerManager) DeployForAccount(account model.Account, keepFile bool) error {
+	content, err := GenerateKeysContent(account.ID)
+	if err != nil {
+		return err
+	}
+	if HashAuthorizedKeysContent([]byte(content)) != m.hashes[account.ID] {
+		return fmt.Errorf("%w: %s", ErrDeployPlanStale, account.String())
+	}
+	return m.DeployerManager.DeployForAccount(account, keepFile)
+}
@@ -0,0 +1,237 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// planKL assigns keys per account ID.
+type planKL struct {
+	fakeKL
+	keys map[int][]model.PublicKey
+}
+
+func (k *planKL) GetKeysForAccount(accountID int) ([]model.PublicKey, error) {
+	return k.keys[accountID], nil
+}
+
+func planKey(id int, data string) model.PublicKey {
+	return model.PublicKey{ID: id, Algorithm: "ssh-ed25519", KeyData: data, Comment: data}
+}
+
+// planFixture returns accounts covering each plan outcome: 1 is up to date,
+// 2 has a newly assigned key, 3 was never deployed and 4 is up to date but
+// dirty.
+func planFixture(t *testing.T) (*planKL, []model.Account) {
+	t.Helper()
+	origKR := DefaultKeyReader()
+	t.Cleanup(func() { SetDefaultKeyReader(origKR) })
+	SetDefaultKeyReader(&fakeKR{})
+	kl := &planKL{keys: map[int][]model.PublicKey{
+		1: {planKey(10, "ALICE")},
+		2: {planKey(10, "ALICE")},
+		3: {planKey(11, "BOB")},
+		4: {planKey(11, "BOB")},
+	}}
+	SetDefaultKeyLister(kl)
+	t.Cleanup(func() { SetDefaultKeyLister(&fakeKL{}) })
+	withAccountReader(t, mapAccountReader{})
+
+	deployedKeys := func(id int) []string {
+		content, err := GenerateKeysContent(id)
+		if err != nil {
+			t.Fatalf("GenerateKeysContent: %v", err)
+		}
+		return DeployedKeyIdentities(content)
+	}
+	accounts := []model.Account{
+		{ID: 1, Username: "app", Hostname: "web-01", Serial: 1, DeployedKeys: deployedKeys(1), IsActive: true, DeployEnabled: true},
+		{ID: 2, Username: "app", Hostname: "web-02", Serial: 1, DeployedKeys: deployedKeys(2), IsActive: true, DeployEnabled: true},
+		{ID: 3, Username: "app", Hostname: "web-03", IsActive: true, DeployEnabled: true},
+		{ID: 4, Username: "app", Hostname: "db-01", Serial: 1, DeployedKeys: deployedKeys(4), IsDirty: true, IsActive: true, DeployEnabled: true},
+	}
+	kl.keys[2] = append(kl.keys[2], planKey(12, "CAROL"))
+	return kl, accounts
+}
+
+func TestBuildDeployPlan_IdentifiesNoOpAndChangedAccounts(t *testing.T) {
+	_, accounts := planFixture(t)
+
+	plan, err := BuildDeployPlan(&simpleFakeStore{accounts: accounts}, nil)
+	if err != nil {
+		t.Fatalf("BuildDeployPlan: %v", err)
+	}
+	if plan.SystemKeySerial != 1 || len(plan.Entries) != 4 {
+		t.Fatalf("expected 4 entries at serial 1, got %+v", plan)
+	}
+	want := []struct{ serial, keys, changed bool }{
+		{false, false, false},
+		{false, true, true},
+		{true, true, true},
+		{false, false, true},
+	}
+	for i, e := range plan.Entries {
+		if e.Err != nil || e.ContentHash == "" {
+			t.Fatalf("entry %d: expected rendered content, got err=%v hash=%q", i, e.Err, e.ContentHash)
+		}
+		if e.SerialChanged != want[i].serial || e.KeysChanged != want[i].keys || e.Changed() != want[i].changed {
+			t.Fatalf("entry %d (%s): got serial=%v keys=%v changed=%v, want %+v",
+				i, e.Account.String(), e.SerialChanged, e.KeysChanged, e.Changed(), want[i])
+		}
+	}
+	if plan.Entries[0].ContentHash == plan.Entries[1].ContentHash {
+		t.Fatal("expected a different hash for the account with an extra key")
+	}
+
+	var ids []int
+	for _, e := range plan.OnlyChanged().Entries {
+		ids = append(ids, e.Account.ID)
+	}
+	if !reflect.DeepEqual(ids, []int{2, 3, 4}) {
+		t.Fatalf("expected accounts 2, 3 and 4 to change, got %v", ids)
+	}
+}
+
+func TestBuildDeployPlan_OtherSystemKeySerialIsChanged(t *testing.T) {
+	_, accounts := planFixture(t)
+	accounts[0].Serial = 2 // last deployed with another system key
+
+	plan, err := BuildDeployPlan(&simpleFakeStore{}, accounts[:1])
+	if err != nil {
+		t.Fatalf("BuildDeployPlan: %v", err)
+	}
+	if e := plan.Entries[0]; !e.SerialChanged || !e.Changed() {
+		t.Fatalf("expected a serial change, got %+v", e)
+	}
+}
+
+func TestExecutePlan_DeploysPlannedAccountsAndRejectsStaleOnes(t *testing.T) {
+	kl, accounts := planFixture(t)
+	plan, err := BuildDeployPlan(&simpleFakeStore{accounts: accounts}, nil)
+	if err != nil {
+		t.Fatalf("BuildDeployPlan: %v", err)
+	}
+	plan = plan.OnlyChanged()
+
+	// Another key is assigned to account 3 after the plan was reviewed.
+	kl.keys[3] = append(kl.keys[3], planKey(13, "MALLORY"))
+
+	dm := &canaryDM{}
+	results, err := ExecutePlan(context.Background(), dm, plan, DeployRunOptions{})
+	if err != nil {
+		t.Fatalf("ExecutePlan: %v", err)
+	}
+	if !reflect.DeepEqual(dm.deployed, []int{2, 4}) {
+		t.Fatalf("expected only entries still matching the plan deployed, got %v", dm.deployed)
+	}
+	if len(results) != 3 || !errors.Is(results[1].Error, ErrDeployPlanStale) {
+		t.Fatalf("expected account 3 to fail as stale, got %+v", results)
+	}
+}
+
+// rotatedKR reports serial 2 as the active system key.
+type rotatedKR struct{ fakeKR }
+
+func (*rotatedKR) GetActiveSystemKey() (*model.SystemKey, error) {
+	return &model.SystemKey{Serial: 2, PublicKey: "sys-pub-2", IsActive: true}, nil
+}
+
+// rotatedStore is simpleFakeStore after a system key rotation.
+type rotatedStore struct{ simpleFakeStore }
+
+func (*rotatedStore) GetActiveSystemKey() (*model.SystemKey, error) {
+	return &model.SystemKey{Serial: 2, PublicKey: "sys-pub-2", IsActive: true}, nil
+}
+
+func TestDeployPlanFile_ApprovedPlanIsExecuted(t *testing.T) {
+	_, accounts := planFixture(t)
+	st := &simpleFakeStore{accounts: accounts}
+	plan, err := BuildDeployPlan(st, nil)
+	if err != nil {
+		t.Fatalf("BuildDeployPlan: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "plan.json")
+	if err := WriteDeployPlan(path, plan.OnlyChanged()); err != nil {
+		t.Fatalf("WriteDeployPlan: %v", err)
+	}
+
+	approved, err := ReadDeployPlan(path)
+	if err != nil {
+		t.Fatalf("ReadDeployPlan: %v", err)
+	}
+	if approved.SystemKeySerial != 1 || len(approved.Entries) != 3 || approved.Entries[0].ContentHash != plan.Entries[1].ContentHash {
+		t.Fatalf("expected the changed entries to round-trip, got %+v", approved)
+	}
+	live, err := VerifyDeployPlan(st, approved)
+	if err != nil {
+		t.Fatalf("VerifyDeployPlan: %v", err)
+	}
+	dm := &canaryDM{}
+	results, err := ExecutePlan(context.Background(), dm, live, DeployRunOptions{})
+	if err != nil {
+		t.Fatalf("ExecutePlan: %v", err)
+	}
+	if !reflect.DeepEqual(dm.deployed, []int{2, 3, 4}) || len(results) != 3 {
+		t.Fatalf("expected the approved accounts deployed, got %v", dm.deployed)
+	}
+}
+
+func TestVerifyDeployPlan_AbortsWhenStateChanged(t *testing.T) {
+	cases := []struct {
+		name   string
+		change func(kl *planKL, st *simpleFakeStore) Store
+		want   string
+	}{
+		{"key assigned after approval", func(kl *planKL, st *simpleFakeStore) Store {
+			kl.keys[3] = append(kl.keys[3], planKey(13, "MALLORY"))
+			return st
+		}, "app@web-03: authorized_keys differs"},
+		{"account deactivated", func(kl *planKL, st *simpleFakeStore) Store {
+			st.accounts = st.accounts[:2]
+			return st
+		}, "app@web-03: account removed or deactivated"},
+		{"deploys disabled", func(kl *planKL, st *simpleFakeStore) Store {
+			st.accounts[1].DeployEnabled = false
+			return st
+		}, "app@web-02: deploys disabled"},
+		{"system key rotated", func(kl *planKL, st *simpleFakeStore) Store {
+			SetDefaultKeyReader(&rotatedKR{})
+			return &rotatedStore{simpleFakeStore: *st}
+		}, "active system key serial is 2, plan has 1"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			kl, accounts := planFixture(t)
+			st := &simpleFakeStore{accounts: accounts}
+			plan, err := BuildDeployPlan(st, nil)
+			if err != nil {
+				t.Fatalf("BuildDeployPlan: %v", err)
+			}
+			plan = plan.OnlyChanged()
+
+			live, err := VerifyDeployPlan(tc.change(kl, st), plan)
+			if !errors.Is(err, ErrDeployPlanStale) || live != nil {
+				t.Fatalf("expected ErrDeployPlanStale, got %v", err)
+			}
+			if !strings.Contains(err.Error(), "state changed") || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected %q in %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestWriteDeployPlan_RefusesRenderErrors(t *testing.T) {
+	plan := &DeployPlan{Entries: []DeployPlanEntry{{Account: model.Account{Username: "app", Hostname: "web-01"}, Err: errors.New("no key lister")}}}
+	if err := WriteDeployPlan(filepath.Join(t.TempDir(), "plan.json"), plan); err == nil {
+		t.Fatal("expected a plan with render errors to be refused")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,7 +17,17 @@ import (
 )
 
 // RunDeploymentForAccount handles the deployment logic for a single account.
+// After authorized_keys was written it records the active system key's serial
+// on the account; a deploy that fails or is rolled back leaves it unchanged.
 func RunDeploymentForAccount(account model.Account, isTUI bool) error {
+	return runDeploymentForAccount(account, isTUI, func() (string, error) {
+		return GenerateKeysContent(account.ID)
+	})
+}
+
+// runDeploymentForAccount is RunDeploymentForAccount deploying the content
+// returned by render.
+func runDeploymentForAccount(account model.Account, isTUI bool, render func() (string, error)) error {
 	var connectKey *model.SystemKey
 	var err error
 
@@ -24,6 +35,13 @@ func RunDeploymentForAccount(account model.Account, isTUI bool) error {
 	if kr == nil {
 		return errors.New(i18n.T("deploy.error_no_bootstrap_key"))
 	}
+	// Every successful write must be followed by a serial update, or serial
+	// audits and the next deploy's connect key would be wrong. Check before
+	// touching the host.
+	updater := DefaultAccountSerialUpdater()
+	if updater == nil {
+		return errors.New("cannot record the deployed serial: no account serial updater configured")
+	}
 	if account.Serial == 0 {
 		connectKey, err = kr.GetActiveSystemKey()
 		if err != nil {
@@ -48,7 +66,20 @@ func RunDeploymentForAccount(account model.Account, isTUI bool) error {
 		}
 	}
 
-	content, err := GenerateKeysContent(account.ID)
+	lg := DefaultLogger()
+	lg.Debug("deploying authorized_keys", "account", account.String(), "connect_serial", connectKey.Serial)
+
+	content, err := render()
+	if err != nil {
+		return err
+	}
+	var knownHosts string
+	if account.DeployKnownHosts {
+		if knownHosts, err = renderKnownHostsForDeploy(); err != nil {
+			return err
+		}
+	}
+	files, err := renderManagedFiles(account)
 	if err != nil {
 		return err
 	}
@@ -63,8 +94,9 @@ func RunDeploymentForAccount(account model.Account, isTUI bool) error {
 			passphrase[i] = 0
 		}
 	}()
-	deployer, err := NewDeployerFactory(account.Hostname, account.Username, SystemKeyToSecret(connectKey), passphrase)
+	deployer, err := connectWithHostKeyPrompt(account.Hostname, account.Username, SystemKeyToSecret(connectKey), passphrase)
 	if err != nil {
+		lg.Error("deploy connection failed", "account", account.String(), "err", err)
 		if isTUI {
 			return fmt.Errorf(i18n.T("deploy.error_connection_failed_tui"), account.String(), err)
 		}
@@ -72,20 +104,112 @@ func RunDeploymentForAccount(account model.Account, isTUI bool) error {
 	}
 	defer deployer.Close()
 	state.PasswordCache.Clear()
+	if err := configureDeployer(deployer, account); err != nil {
+		return fmt.Errorf(i18n.T("deploy.error_deployment_failed"), err)
+	}
+	khd, canDeployKnownHosts := deployer.(KnownHostsDeployer)
+	if account.DeployKnownHosts && !canDeployKnownHosts {
+		return fmt.Errorf(i18n.T("deploy.error_deployment_failed"), errors.New("deployer does not support known_hosts"))
+	}
+	mfd, canDeployManagedFiles := deployer.(ManagedFileDeployer)
+	if len(files) > 0 && !canDeployManagedFiles {
+		return fmt.Errorf(i18n.T("deploy.error_deployment_failed"), fmt.Errorf("deployer does not support %s", files[0].Name))
+	}
+
+	// Keep the current file around so a failing post-deploy command can be
+	// rolled back.
+	opts := DefaultDeployOptions().ForOSFamily(account.EffectiveOSFamily())
+	var previous []byte
+	hadPrevious := false
+	appendOnly := account.EffectiveDeployMode() == model.DeployModeAppendOnly
+	if appendOnly || (opts.PostDeployCommand != "" && opts.RollbackOnPostDeployFailure) {
+		prev, perr := deployer.GetAuthorizedKeys()
+		if perr == nil {
+			previous, hadPrevious = prev, true
+		} else if appendOnly {
+			// Without the current file foreign keys would be overwritten.
+			return fmt.Errorf(i18n.T("deploy.error_deployment_failed"), fmt.Errorf("read authorized_keys for append-only deploy: %w", perr))
+		}
+	}
+	deployedKeys := DeployedKeyIdentities(content)
+	if appendOnly {
+		content = MergeAppendOnly(string(previous), content, account.DeployedKeys)
+	}
 
 	if err := deployer.DeployAuthorizedKeys(content); err != nil {
+		lg.Error("writing authorized_keys failed", "account", account.String(), "err", err)
+		logDeployAudit(account, activeKey.Serial, err)
 		return fmt.Errorf(i18n.T("deploy.error_deployment_failed"), err)
 	}
 
-	updater := DefaultAccountSerialUpdater()
-	if updater == nil {
-		return errors.New(i18n.T("deploy.error_get_active_key_for_serial"))
+	postErr := runPostDeployCommand(deployer, opts, previous, hadPrevious)
+	if postErr != nil {
+		lg.Warn("post-deploy command failed", "account", account.String(), "command", opts.PostDeployCommand, "err", postErr)
+	}
+	var pde *PostDeployError
+	if errors.As(postErr, &pde) && pde.RolledBack {
+		// The previous content is back in place; the serial must not advance.
+		logDeployAudit(account, activeKey.Serial, postErr)
+		return postErr
+	}
+
+	// authorized_keys is in place, so failed known_hosts or managed file
+	// writes are reported without holding back the serial.
+	var knownHostsErr error
+	if account.DeployKnownHosts {
+		if err := khd.DeployKnownHosts(knownHosts); err != nil {
+			lg.Warn("writing known_hosts failed", "account", account.String(), "err", err)
+			knownHostsErr = fmt.Errorf("write known_hosts: %w", err)
+		}
 	}
+	var managedFilesErr error
+	if len(files) > 0 {
+		managedFilesErr = deployManagedFiles(mfd, account, files)
+	}
+
 	for i := 0; i < 5; i++ {
 		if err = updater.UpdateAccountSerial(account.ID, activeKey.Serial); err == nil || !strings.Contains(err.Error(), "database is locked") {
 			break
 		}
 		time.Sleep(time.Duration(50+rand.Intn(100)) * time.Millisecond)
 	}
-	return err
+	if err != nil {
+		lg.Error("updating account serial failed", "account", account.String(), "serial", activeKey.Serial, "err", err)
+		return err
+	}
+	if rec := DefaultDeployedKeysRecorder(); rec != nil {
+		if err := rec.SetAccountDeployedKeys(account.ID, deployedKeys); err != nil {
+			// Append-only deploys rely on the record to remove revoked keys.
+			if appendOnly {
+				return fmt.Errorf("record deployed keys: %w", err)
+			}
+			lg.Warn("recording deployed keys failed", "account", account.String(), "err", err)
+		}
+	}
+	lg.Info("deployed authorized_keys", "account", account.String(), "serial", activeKey.Serial)
+	logDeployAudit(account, activeKey.Serial, nil)
+	if knownHostsErr != nil || managedFilesErr != nil {
+		return errors.Join(postErr, knownHostsErr, managedFilesErr)
+	}
+	return postErr
+}
+
+// logDeployAudit records the outcome of a deployment as DEPLOY_SUCCESS or,
+// when err is non-nil, DEPLOY_FAILED.
+func logDeployAudit(account model.Account, serial int, err error) {
+	w := DefaultAuditWriter()
+	if w == nil {
+		return
+	}
+	fields := model.AuditFields{
+		model.AuditFieldAccountID: strconv.Itoa(account.ID),
+		model.AuditFieldAccount:   account.String(),
+		model.AuditFieldSerial:    strconv.Itoa(serial),
+	}
+	if err != nil {
+		fields[model.AuditFieldError] = err.Error()
+		_ = LogAuditFields(w, "DEPLOY_FAILED", fmt.Sprintf("deploy to %s failed: %v", account.String(), err), fields)
+		return
+	}
+	_ = LogAuditFields(w, "DEPLOY_SUCCESS", fmt.Sprintf("deployed authorized_keys to %s", account.String()), fields)
 }
@@ -0,0 +1,50 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDeployAccountsWithOptions_StopOnError(t *testing.T) {
+	st := &simpleFakeStore{accounts: canaryAccounts()}
+	dm := &canaryDM{deployErr: map[int]error{2: errors.New("connection refused")}}
+
+	res, err := DeployAccountsWithOptions(context.Background(), st, dm, nil, DeployRunOptions{StopOnError: true}, nil)
+	if err != nil {
+		t.Fatalf("stopping on error should not be reported as an error: %v", err)
+	}
+	if !reflect.DeepEqual(dm.deployed, []int{1, 2}) {
+		t.Fatalf("expected deploys to halt after account 2, got %v", dm.deployed)
+	}
+	if len(res) != 2 || res[1].Account.ID != 2 || res[1].Error == nil {
+		t.Fatalf("expected partial results ending in the failure, got %+v", res)
+	}
+}
+
+func TestDeployAccountsWithOptions_ContinueOnError(t *testing.T) {
+	st := &simpleFakeStore{accounts: canaryAccounts()}
+	dm := &canaryDM{deployErr: map[int]error{2: errors.New("connection refused")}}
+
+	res, err := DeployAccountsWithOptions(context.Background(), st, dm, nil, DeployRunOptions{}, nil)
+	if err != nil {
+		t.Fatalf("deploy: %v", err)
+	}
+	if !reflect.DeepEqual(dm.deployed, []int{1, 2, 3, 4}) || len(res) != 4 {
+		t.Fatalf("expected every account to be attempted, got %v", dm.deployed)
+	}
+}
+
+func TestDeployAccountsWithOptions_CancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	dm := &canaryDM{}
+	res, err := DeployAccountsWithOptions(ctx, &simpleFakeStore{accounts: canaryAccounts()}, dm, nil, DeployRunOptions{}, nil)
+	if !errors.Is(err, context.Canceled) || len(res) != 0 || len(dm.deployed) != 0 {
+		t.Fatalf("expected nothing deployed and context.Canceled, got %v (deployed %v)", err, dm.deployed)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"strings"
+
+	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/core/sshkey"
+)
+
+// AnalyzeDrift compares the expected authorized_keys content with the content
+// found on a host and lists the keys added to or removed from the host. Keys
+// are matched on their algorithm and key material, so a changed comment or
+// reordered file is not reported. Comments and blank lines are ignored; lines
+// that do not parse as keys are compared verbatim.
+func AnalyzeDrift(expected, remote string) model.DriftAnalysis {
+	want := authorizedKeyLines(expected)
+	have := authorizedKeyLines(remote)
+
+	d := model.DriftAnalysis{Expected: expected, Remote: remote}
+	for _, k := range have.order {
+		if _, ok := want.lines[k]; !ok {
+			d.Added = append(d.Added, have.lines[k])
+		}
+	}
+	for _, k := range want.order {
+		if _, ok := have.lines[k]; !ok {
+			d.Removed = append(d.Removed, want.lines[k])
+		}
+	}
+	return d
+}
+
+// keyLineSet indexes authorized_keys lines by key identity, keeping the
+// first line seen for each identity in file order.
+type keyLineSet struct {
+	order []string
+	lines map[string]string
+}
+
+func authorizedKeyLines(content string) keyLineSet {
+	set := keyLineSet{lines: make(map[string]string)}
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		id := keyLineIdentity(line)
+		if _, ok := set.lines[id]; ok {
+			continue
+		}
+		set.order = append(set.order, id)
+		set.lines[id] = line
+	}
+	return set
+}
+
+// keyLineIdentity returns "algorithm key-data" for a parsable key line so
+// comments and spacing do not matter, and the line itself otherwise.
+func keyLineIdentity(line string) string {
+	if alg, data, _, err := sshkey.Parse(line); err == nil {
+		alg, data = sshkey.NormalizeKey(alg, data)
+		return alg + " " + data
+	}
+	return line
+}
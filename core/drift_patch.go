@@ -0,0 +1,77 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// DriftPatch renders the drift of acc as a unified diff from the expected
+// authorized_keys content to the content found on the host, so lines only
+// on the host are additions.
+func DriftPatch(acc model.Account, d model.DriftAnalysis) (string, error) {
+	name := fmt.Sprintf("%s@%s", acc.Username, acc.Hostname)
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        patchLines(d.Expected),
+		B:        patchLines(d.Remote),
+		FromFile: "expected/" + name,
+		ToFile:   "remote/" + name,
+		Context:  3,
+	})
+}
+
+// patchLines splits content into newline-terminated lines with LF endings,
+// so a missing final newline does not show up as a change.
+func patchLines(s string) []string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	if s == "" {
+		return nil
+	}
+	if !strings.HasSuffix(s, "\n") {
+		s += "\n"
+	}
+	lines := strings.SplitAfter(s, "\n")
+	return lines[:len(lines)-1]
+}
+
+// DriftPatchFileName is the file name WriteDriftPatches uses for acc.
+func DriftPatchFileName(acc model.Account) string {
+	return fmt.Sprintf("%s@%s.diff", acc.Username, acc.Hostname)
+}
+
+// WriteDriftPatches writes a user@host.diff patch into dir for every result
+// that carries drift, creating dir if needed, and returns the paths written.
+// Results without drift, such as serial audits, produce no file.
+func WriteDriftPatches(dir string, results []AuditResult) ([]string, error) {
+	var paths []string
+	for _, r := range results {
+		if r.Drift == nil {
+			continue
+		}
+		patch, err := DriftPatch(r.Account, *r.Drift)
+		if err != nil {
+			return paths, fmt.Errorf("render drift patch for %s: %w", r.Account.String(), err)
+		}
+		if patch == "" {
+			continue
+		}
+		if len(paths) == 0 {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return nil, fmt.Errorf("create diff output directory: %w", err)
+			}
+		}
+		path := filepath.Join(dir, DriftPatchFileName(r.Account))
+		if err := os.WriteFile(path, []byte(patch), 0o644); err != nil {
+			return paths, fmt.Errorf("write drift patch: %w", err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
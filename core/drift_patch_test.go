@@ -0,0 +1,68 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestDriftPatch_AddedAndRemovedKey(t *testing.T) {
+	key := func(seed byte, comment string) string {
+		return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(craftedKey(t, seed)))) + " " + comment
+	}
+	alice, bob, mallory := key(1, "alice"), key(2, "bob"), key(3, "mallory")
+	expected := "# Keymaster Managed Keys (Serial: 2)\n" + alice + "\n" + bob + "\n"
+	remote := "# Keymaster Managed Keys (Serial: 2)\n" + alice + "\n" + mallory + "\n"
+	acc := model.Account{ID: 1, Username: "deploy", Hostname: "web-01"}
+
+	d := AnalyzeDrift(expected, remote)
+	patch, err := DriftPatch(acc, d)
+	if err != nil {
+		t.Fatalf("DriftPatch: %v", err)
+	}
+	want := "--- expected/deploy@web-01\n" +
+		"+++ remote/deploy@web-01\n" +
+		"@@ -1,3 +1,3 @@\n" +
+		" # Keymaster Managed Keys (Serial: 2)\n" +
+		" " + alice + "\n" +
+		"-" + bob + "\n" +
+		"+" + mallory + "\n"
+	if patch != want {
+		t.Fatalf("unexpected patch:\n%s\nwant:\n%s", patch, want)
+	}
+
+	dir := filepath.Join(t.TempDir(), "diffs")
+	clean := AuditResult{Account: model.Account{ID: 2, Username: "deploy", Hostname: "web-02"}}
+	failed := AuditResult{Account: model.Account{ID: 3, Username: "deploy", Hostname: "web-03"}, Error: errors.New("connection refused")}
+	drifted := AuditResult{Account: acc, Error: errors.New("drift"), Drift: &d}
+	paths, err := WriteDriftPatches(dir, []AuditResult{clean, failed, drifted})
+	if err != nil {
+		t.Fatalf("WriteDriftPatches: %v", err)
+	}
+	if len(paths) != 1 || filepath.Base(paths[0]) != "deploy@web-01.diff" {
+		t.Fatalf("expected one patch for the drifted host, got %v", paths)
+	}
+	data, err := os.ReadFile(paths[0])
+	if err != nil || string(data) != want {
+		t.Fatalf("unexpected patch file content %q: %v", data, err)
+	}
+}
+
+func TestWriteDriftPatches_NoDriftCreatesNothing(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "diffs")
+	paths, err := WriteDriftPatches(dir, []AuditResult{{Account: model.Account{ID: 1}}})
+	if err != nil || len(paths) != 0 {
+		t.Fatalf("expected no patches, got %v %v", paths, err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("expected no directory to be created, got %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+const (
+	driftKeyA = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+	driftKeyB = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBB"
+	driftKeyC = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAICCCCCCCCCCCCCCCCCCCCCCCCCCCCCCCCCCCCCCCCCCC"
+)
+
+func TestAnalyzeDrift(t *testing.T) {
+	header := "# Keymaster Managed Keys (Serial: 1)\n"
+	tests := []struct {
+		name     string
+		expected string
+		remote   string
+		added    []string
+		removed  []string
+	}{
+		{
+			name:     "identical",
+			expected: header + driftKeyA + " alice\n" + driftKeyB + " bob\n",
+			remote:   header + driftKeyA + " alice\n" + driftKeyB + " bob\n",
+		},
+		{
+			name:     "reordered, comment changed and CRLF",
+			expected: header + driftKeyA + " alice\n" + driftKeyB + " bob\n",
+			remote:   header + driftKeyB + " bob@laptop\r\n\r\n" + driftKeyA + " alice\r\n",
+		},
+		{
+			name:     "key added on host",
+			expected: header + driftKeyA + " alice\n",
+			remote:   header + driftKeyA + " alice\n" + driftKeyC + " mallory\n",
+			added:    []string{driftKeyC + " mallory"},
+		},
+		{
+			name:     "key removed from host",
+			expected: header + driftKeyA + " alice\n" + driftKeyB + " bob\n",
+			remote:   header + driftKeyA + " alice\n",
+			removed:  []string{driftKeyB + " bob"},
+		},
+		{
+			name:     "key replaced and options kept",
+			expected: header + `from="10.0.0.1" ` + driftKeyA + " alice\n",
+			remote:   "# edited by hand\n" + driftKeyB + " bob\n",
+			added:    []string{driftKeyB + " bob"},
+			removed:  []string{`from="10.0.0.1" ` + driftKeyA + " alice"},
+		},
+		{
+			name:     "unparseable lines compared verbatim",
+			expected: header + driftKeyA + " alice\n",
+			remote:   header + driftKeyA + " alice\ngarbage line\n",
+			added:    []string{"garbage line"},
+		},
+		{
+			name:     "empty remote",
+			expected: header + driftKeyA + " alice\n",
+			remote:   "",
+			removed:  []string{driftKeyA + " alice"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AnalyzeDrift(tt.expected, tt.remote)
+			if !reflect.DeepEqual(got.Added, tt.added) {
+				t.Errorf("added: expected %q, got %q", tt.added, got.Added)
+			}
+			if !reflect.DeepEqual(got.Removed, tt.removed) {
+				t.Errorf("removed: expected %q, got %q", tt.removed, got.Removed)
+			}
+			if got.HasKeyDrift() != (len(tt.added)+len(tt.removed) > 0) {
+				t.Errorf("HasKeyDrift mismatch for %+v", got)
+			}
+		})
+	}
+}
@@ -12,14 +12,14 @@ package core
 import (
 	"bufio"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/klauspost/compress/zstd"
 	"github.com/toeirei/keymaster/config"
 	"github.com/toeirei/keymaster/core/bootstrap"
 	"github.com/toeirei/keymaster/ui/i18n"
@@ -34,6 +34,10 @@ type DeployResult struct {
 	Account model.Account
 	// Error is non-nil when the deployment failed for this account.
 	Error error
+	// PostDeploy holds the output of the post-deploy command when it failed.
+	PostDeploy *RemoteCommandResult
+	// Duration is the wall time spent deploying to the host.
+	Duration time.Duration
 }
 
 // AuditResult represents the result of auditing a single account.
@@ -42,6 +46,11 @@ type AuditResult struct {
 	Account model.Account
 	// Error is non-nil when the audit detected an error or failed.
 	Error error
+	// Drift lists the keys added to or removed from the host when a strict
+	// audit found the content differs. It is nil otherwise.
+	Drift *model.DriftAnalysis
+	// Duration is the wall time spent auditing the host.
+	Duration time.Duration
 }
 
 // DecommissionSummary aggregates counts from a decommission operation.
@@ -54,6 +63,64 @@ type DecommissionSummary struct {
 	Skipped int
 }
 
+// DeployRunOptions controls optional deploy behavior used by
+// DeployAccountsWithOptions.
+type DeployRunOptions struct {
+	// StopOnError stops the deployment after the first account that fails;
+	// the remaining accounts are not attempted.
+	StopOnError bool
+	// Resume skips the accounts completed in this checkpoint.
+	Resume *DeployCheckpoint
+	// CheckpointPath, when set, is rewritten after every successful account
+	// with the accounts completed so far, including those from Resume. The
+	// deployment stops if the checkpoint cannot be written.
+	CheckpointPath string
+	// Pause is waited between two accounts to spread a large rollout out.
+	Pause time.Duration
+	// AccountGroup, when set, deploys only the members of this account
+	// group (see model.Account.Group). It does not apply to a deploy to a
+	// single account.
+	AccountGroup string
+}
+
+// AuditOptions controls optional audit behavior used by AuditAccountsWithOptions.
+type AuditOptions struct {
+	// FailFast stops the audit after the first account that fails.
+	FailFast bool
+	// Groups, when set, audits the accounts carrying each tag as one
+	// partition, in the given order, with the hosts of a partition audited
+	// concurrently. Accounts carrying none of the tags form a last partition.
+	// With FailFast the audit stops after the partition holding a failure.
+	Groups []string
+	// SkipRecent, when positive, skips accounts audited clean within that
+	// window (see model.Account.LastAuditAt). Accounts whose last audit
+	// failed are always audited.
+	SkipRecent time.Duration
+	// OnlyReachable pings every host's SSH port first (see PingAccounts) and
+	// reports hosts that do not answer as ErrHostUnreachable instead of
+	// waiting for an SSH connection to time out.
+	OnlyReachable bool
+	// PingTimeout bounds the OnlyReachable check per host; zero means
+	// DefaultPingTimeout.
+	PingTimeout time.Duration
+	// AccountGroup, when set, audits only the members of this account group
+	// (see model.Account.Group).
+	AccountGroup string
+}
+
+// Exit codes returned by AuditExitCode.
+const (
+	AuditExitOK    = 0
+	AuditExitDrift = 1
+)
+
+// BackupOptions controls which data `BackupWithOptions` exports.
+type BackupOptions struct {
+	// Tables limits the backup to the named tables (see model.BackupTables).
+	// Empty exports every table.
+	Tables []string
+}
+
 // RestoreOptions controls restore behavior used by `Restore`.
 type RestoreOptions struct {
 	// Full indicates whether to perform a full restore (true) or an
@@ -67,6 +134,9 @@ type DBMaintenanceOptions struct {
 	SkipIntegrity bool
 	// Timeout bounds the maintenance operation.
 	Timeout time.Duration
+	// Analyze additionally refreshes planner statistics and reports
+	// per-table row counts and sizes.
+	Analyze bool
 }
 
 // ParallelResult reports the name and optional error returned by a
@@ -85,6 +155,14 @@ func InitializeServices(ctx context.Context, cfg *config.Config) (Store, error)
 // DeployAccounts orchestrates deployment for either a single target identifier
 // or all active accounts. Uses the provided Store and DeployerManager.
 func DeployAccounts(ctx context.Context, st Store, dm DeployerManager, identifier *string, rep Reporter) ([]DeployResult, error) {
+	return DeployAccountsWithOptions(ctx, st, dm, identifier, DeployRunOptions{}, rep)
+}
+
+// DeployAccountsWithOptions is DeployAccounts with additional controls. With
+// opts.StopOnError the deployment is cancelled after the first failing
+// account and the results collected so far are returned. Cancelling ctx also
+// stops between accounts; ctx.Err() is returned with the partial results.
+func DeployAccountsWithOptions(ctx context.Context, st Store, dm DeployerManager, identifier *string, opts DeployRunOptions, rep Reporter) ([]DeployResult, error) {
 	accounts, err := st.GetAllActiveAccounts()
 	if err != nil {
 		return nil, fmt.Errorf("get accounts: %w", err)
@@ -98,6 +176,9 @@ func DeployAccounts(ctx context.Context, st Store, dm DeployerManager, identifie
 		norm := strings.ToLower(*identifier)
 		for _, acc := range accounts {
 			if strings.ToLower(fmt.Sprintf("%s@%s", acc.Username, acc.Hostname)) == norm {
+				if !acc.DeployEnabled {
+					return nil, fmt.Errorf("deploys are disabled for account %s", acc.String())
+				}
 				targets = append(targets, acc)
 				found = true
 				break
@@ -107,69 +188,315 @@ func DeployAccounts(ctx context.Context, st Store, dm DeployerManager, identifie
 			return nil, fmt.Errorf("account not found: %s", *identifier)
 		}
 	} else {
-		targets = accounts
+		targets = DeployableAccounts(accounts)
+	}
+
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	lg := DefaultLogger()
+	if skipped := len(accounts) - len(targets); skipped > 0 && (identifier == nil || *identifier == "") {
+		lg.Info("skipping accounts with deploys disabled", "accounts", skipped)
+	}
+	if identifier == nil || *identifier == "" {
+		if targets, err = selectAccountGroup(targets, opts.AccountGroup); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Resume != nil {
+		remaining := opts.Resume.Remaining(targets)
+		lg.Info("resuming deployment from checkpoint", "completed", len(targets)-len(remaining), "remaining", len(remaining))
+		targets = remaining
+	}
+	lg.Debug("starting deployment", "accounts", len(targets), "stop_on_error", opts.StopOnError)
+
+	checkpoint := &DeployCheckpoint{}
+	if opts.Resume != nil {
+		checkpoint.Completed = slices.Clone(opts.Resume.Completed)
+	}
+	var checkpointErr error
+	results := deployTargetsCtx(ctx, dm, targets, opts.Pause, func(r DeployResult) {
+		if r.Error == nil && opts.CheckpointPath != "" {
+			checkpoint.Completed = append(checkpoint.Completed, r.Account.ID)
+			checkpoint.UpdatedAt = time.Now().UTC()
+			if err := WriteDeployCheckpoint(opts.CheckpointPath, checkpoint); err != nil {
+				lg.Error("stopping deployment: checkpoint not written", "err", err)
+				checkpointErr = err
+				cancel()
+				return
+			}
+		}
+		if r.Error != nil && opts.StopOnError {
+			lg.Info("stopping deployment at first failure", "account", r.Account.String())
+			cancel()
+		}
+	})
+	if checkpointErr != nil {
+		return results, checkpointErr
+	}
+	if err := parent.Err(); err != nil {
+		return results, err
 	}
+	return results, nil
+}
 
+// deployTargets deploys to each account in order and collects the results.
+func deployTargets(dm DeployerManager, targets []model.Account) []DeployResult {
+	return deployTargetsCtx(context.Background(), dm, targets, 0, nil)
+}
+
+// deployTargetsCtx is deployTargets that stops before the next account once
+// ctx is done and waits pause between two accounts. onResult, if set, sees
+// each result as soon as it is known.
+func deployTargetsCtx(ctx context.Context, dm DeployerManager, targets []model.Account, pause time.Duration, onResult func(DeployResult)) []DeployResult {
+	lg := DefaultLogger()
 	results := make([]DeployResult, 0, len(targets))
-	for _, acc := range targets {
+	for i, acc := range targets {
+		if i > 0 && pause > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(pause):
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		start := time.Now()
 		err := dm.DeployForAccount(acc, false)
-		results = append(results, DeployResult{Account: acc, Error: err})
+		elapsed := time.Since(start)
+		if err != nil {
+			lg.Error("deployment failed", "account", acc.String(), "err", err)
+		} else {
+			lg.Debug("deployment succeeded", "account", acc.String())
+		}
+		res := DeployResult{Account: acc, Error: err, Duration: elapsed}
+		var pde *PostDeployError
+		if errors.As(err, &pde) {
+			res.PostDeploy = &pde.Result
+		}
+		recordDeployResult(res)
+		results = append(results, res)
+		if onResult != nil {
+			onResult(res)
+		}
 	}
-	return results, nil
+	return results
 }
 
 // AuditAccounts runs audit across active accounts using DeployerManager audit helpers.
 func AuditAccounts(ctx context.Context, st Store, dm DeployerManager, mode string, rep Reporter) ([]AuditResult, error) {
+	return AuditAccountsWithOptions(ctx, st, dm, mode, AuditOptions{}, rep)
+}
+
+// AuditAccountsWithOptions is AuditAccounts with additional controls. With
+// opts.FailFast the audit stops after the first failing account and returns
+// the results collected so far. Cancelling ctx also stops the audit between
+// accounts; ctx.Err() is returned alongside the partial results in that case.
+// With opts.Groups the audit runs group by group, see auditGroupsParallel.
+func AuditAccountsWithOptions(ctx context.Context, st Store, dm DeployerManager, mode string, opts AuditOptions, rep Reporter) ([]AuditResult, error) {
 	accounts, err := st.GetAllActiveAccounts()
 	if err != nil {
 		return nil, fmt.Errorf("get accounts: %w", err)
 	}
+	if accounts, err = selectAccountGroup(accounts, opts.AccountGroup); err != nil {
+		return nil, err
+	}
+	if opts.SkipRecent > 0 {
+		var skipped int
+		accounts, skipped = skipRecentlyAudited(accounts, opts.SkipRecent, time.Now())
+		if skipped > 0 {
+			DefaultLogger().Info("skipping recently audited hosts", "skipped", skipped, "window", opts.SkipRecent)
+		}
+	}
+
+	audit := func(acc model.Account) (AuditResult, error) { return auditAccount(st, dm, acc, mode) }
+	if opts.OnlyReachable {
+		audit = skipUnreachable(ctx, dm, accounts, opts.PingTimeout, audit)
+	}
+
+	if len(opts.Groups) > 0 {
+		return auditGroupsParallel(ctx, accounts, opts, audit)
+	}
 
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	lg := DefaultLogger()
+	lg.Debug("starting audit", "accounts", len(accounts), "mode", mode, "fail_fast", opts.FailFast)
 	results := make([]AuditResult, 0, len(accounts))
 	for _, acc := range accounts {
-		var aerr error
-		switch strings.ToLower(strings.TrimSpace(mode)) {
-		case "serial":
-			aerr = dm.AuditSerial(acc)
-		case "strict", "":
-			// Strict mode: fetch remote authorized_keys and compare deterministic hash
-			if acc.Serial == 0 {
-				aerr = fmt.Errorf("%s", i18n.T("audit.error_not_deployed"))
-				break
-			}
-			remote, ferr := dm.FetchAuthorizedKeys(acc)
-			if ferr != nil {
-				aerr = fmt.Errorf("%s", i18n.T("audit.error_read_remote_file", ferr))
-				break
-			}
-			expected, gerr := GenerateKeysContent(acc.ID)
-			if gerr != nil {
-				aerr = fmt.Errorf("%s", i18n.T("audit.error_generate_expected", gerr))
-				break
-			}
-			remoteHash := HashAuthorizedKeysContent(remote)
-			expectedHash := HashAuthorizedKeysContent([]byte(expected))
-			if remoteHash != expectedHash {
+		if ctx.Err() != nil {
+			break
+		}
+		res, merr := audit(acc)
+		if merr != nil {
+			return nil, merr
+		}
+		aerr := res.Error
+		if aerr != nil {
+			lg.Warn("audit failed", "account", acc.String(), "err", aerr)
+		} else {
+			lg.Debug("audit passed", "account", acc.String())
+		}
+		results = append(results, res)
+		if aerr != nil && opts.FailFast {
+			lg.Info("stopping audit at first failure", "account", acc.String())
+			cancel()
+		}
+	}
+	if err := parent.Err(); err != nil {
+		return results, err
+	}
+	return results, nil
+}
+
+// skipRecentlyAudited drops the accounts audited clean less than window
+// before now and returns the rest with the number dropped.
+func skipRecentlyAudited(accounts []model.Account, window time.Duration, now time.Time) ([]model.Account, int) {
+	kept := make([]model.Account, 0, len(accounts))
+	for _, acc := range accounts {
+		if !acc.LastAuditAt.IsZero() && now.Sub(acc.LastAuditAt) < window {
+			continue
+		}
+		kept = append(kept, acc)
+	}
+	return kept, len(accounts) - len(kept)
+}
+
+// recordAuditOutcome stores the time of a clean audit, or clears it after a
+// failed one so the account is not skipped by AuditOptions.SkipRecent.
+func recordAuditOutcome(res AuditResult, at time.Time) {
+	rec := DefaultLastAuditRecorder()
+	if rec == nil {
+		return
+	}
+	if res.Error != nil {
+		if res.Account.LastAuditAt.IsZero() {
+			return
+		}
+		at = time.Time{}
+	}
+	if err := rec.SetAccountLastAudit(res.Account.ID, at); err != nil {
+		DefaultLogger().Warn("recording audit time failed", "account", res.Account.String(), "err", err)
+	}
+}
+
+// auditAccount audits a single account in the given mode. The audit outcome
+// is reported in the result; the returned error is only set for an invalid
+// mode. A strict mismatch marks the account dirty and carries the drift.
+// The outcome is recorded through the default LastAuditRecorder and
+// OperationResultRecorder.
+func auditAccount(st Store, dm DeployerManager, acc model.Account, mode string) (AuditResult, error) {
+	start := time.Now()
+	var aerr error
+	var drift *model.DriftAnalysis
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	switch mode {
+	case "serial":
+		aerr = dm.AuditSerial(acc)
+	case "strict", "":
+		// Strict mode: fetch remote authorized_keys and compare deterministic hash
+		if acc.Serial == 0 {
+			aerr = fmt.Errorf("%s", i18n.T("audit.error_not_deployed"))
+			break
+		}
+		remote, ferr := dm.FetchAuthorizedKeys(acc)
+		if ferr != nil {
+			aerr = fmt.Errorf("%s", i18n.T("audit.error_read_remote_file", ferr))
+			break
+		}
+		expected, gerr := GenerateKeysContent(acc.ID)
+		if gerr != nil {
+			aerr = fmt.Errorf("%s", i18n.T("audit.error_generate_expected", gerr))
+			break
+		}
+		if acc.EffectiveDeployMode() == model.DeployModeAppendOnly {
+			// Keys added by other parties are not drift on shared accounts.
+			if analysis := AnalyzeAppendOnlyDrift(expected, string(remote), acc.DeployedKeys); analysis.HasKeyDrift() {
 				aerr = fmt.Errorf("%s", i18n.T("audit.error_drift_detected"))
-				// Record an audit event for detected drift (host change). Do not
-				// write audit entries for matches — auditing is meant for host changes,
-				// not verbose debug logging.
-				if aw := DefaultAuditWriter(); aw != nil {
-					_ = aw.LogAction("AUDIT_HASH_MISMATCH", fmt.Sprintf("account:%d stored:%s computed:%s", acc.ID, expectedHash, remoteHash))
-				}
-				// Mark the account dirty so other systems know the host state changed.
+				drift = &analysis
 				if err := st.UpdateAccountIsDirty(acc.ID, true); err != nil {
 					if aw := DefaultAuditWriter(); aw != nil {
-						_ = aw.LogAction("AUDIT_HASH_MARK_DIRTY_FAILED", fmt.Sprintf("account:%d err:%v", acc.ID, err))
+						_ = aw.LogAction("AUDIT_HASH_MARK_DIRTY_FAILED", fmt.Sprintf("%s err:%v", model.AccountRef(acc.ID), err))
 					}
 				}
 			}
-		default:
-			return nil, fmt.Errorf("invalid audit mode: %s", mode)
+			break
 		}
-		results = append(results, AuditResult{Account: acc, Error: aerr})
+		remoteHash := HashAuthorizedKeysContent(remote)
+		expectedHash := HashAuthorizedKeysContent([]byte(expected))
+		if remoteHash != expectedHash {
+			aerr = fmt.Errorf("%s", i18n.T("audit.error_drift_detected"))
+			analysis := AnalyzeDrift(expected, string(remote))
+			drift = &analysis
+			// Record an audit event for detected drift (host change). Do not
+			// write audit entries for matches — auditing is meant for host changes,
+			// not verbose debug logging.
+			if aw := DefaultAuditWriter(); aw != nil {
+				_ = aw.LogAction("AUDIT_HASH_MISMATCH", fmt.Sprintf("%s stored:%s computed:%s", model.AccountRef(acc.ID), expectedHash, remoteHash))
+			}
+			// Mark the account dirty so other systems know the host state changed.
+			if err := st.UpdateAccountIsDirty(acc.ID, true); err != nil {
+				if aw := DefaultAuditWriter(); aw != nil {
+					_ = aw.LogAction("AUDIT_HASH_MARK_DIRTY_FAILED", fmt.Sprintf("%s err:%v", model.AccountRef(acc.ID), err))
+				}
+			}
+		}
+	default:
+		return AuditResult{}, fmt.Errorf("invalid audit mode: %s", mode)
 	}
-	return results, nil
+	if aerr == nil && mode != "serial" {
+		aerr = auditManagedFilesVia(st, dm, acc)
+	}
+	res := AuditResult{Account: acc, Error: aerr, Drift: drift, Duration: time.Since(start)}
+	recordAuditOutcome(res, start)
+	recordAuditResult(res)
+	return res, nil
+}
+
+// auditManagedFilesVia compares the managed files acc uses with the host's
+// copies read through dm. A mismatch is logged as AUDIT_MANAGED_FILE_MISMATCH,
+// marks the account dirty and returns the drift error.
+func auditManagedFilesVia(st Store, dm DeployerManager, acc model.Account) error {
+	files, err := renderManagedFiles(acc)
+	if err != nil {
+		return fmt.Errorf("%s", i18n.T("audit.error_generate_expected", err))
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	fetcher, ok := dm.(ManagedFileFetcher)
+	if !ok {
+		return fmt.Errorf("deployer manager cannot read %s", files[0].Name)
+	}
+	name, err := managedFileDrift(files, func(name string) ([]byte, error) {
+		return fetcher.FetchManagedFile(acc, name)
+	})
+	if err != nil || name == "" {
+		return err
+	}
+	if aw := DefaultAuditWriter(); aw != nil {
+		_ = aw.LogAction("AUDIT_MANAGED_FILE_MISMATCH", fmt.Sprintf("%s file:%s", model.AccountRef(acc.ID), name))
+	}
+	if err := st.UpdateAccountIsDirty(acc.ID, true); err != nil {
+		if aw := DefaultAuditWriter(); aw != nil {
+			_ = aw.LogAction("AUDIT_HASH_MARK_DIRTY_FAILED", fmt.Sprintf("%s err:%v", model.AccountRef(acc.ID), err))
+		}
+	}
+	return fmt.Errorf("%s", i18n.T("audit.error_drift_detected"))
+}
+
+// AuditExitCode maps audit results to a process exit code: AuditExitOK when
+// every account passed and AuditExitDrift when at least one account failed.
+func AuditExitCode(results []AuditResult) int {
+	for _, r := range results {
+		if r.Error != nil {
+			return AuditExitDrift
+		}
+	}
+	return AuditExitOK
 }
 
 // TrustHost fetches a host key and optionally saves it in the store.
@@ -189,43 +516,174 @@ func TrustHost(ctx context.Context, canonicalHost string, hf HostFetcher, st Sto
 // ImportAuthorizedKeys parses an authorized_keys stream and imports found keys
 // via the provided KeyManager.
 func ImportAuthorizedKeys(ctx context.Context, r io.Reader, km KeyManager, rep Reporter) (imported int, skipped int, err error) {
+	res, err := importAuthorizedKeys(ctx, r, km, rep, nil, ImportOptions{})
+	return res.Imported, res.Skipped, err
+}
+
+// ImportOptions controls how an import treats keys whose comment is already
+// stored.
+type ImportOptions struct {
+	// Replace updates the algorithm and key data of the stored key with the
+	// same comment instead of skipping the line, for keys rotated under a
+	// stable comment. Accounts rendering the key are marked dirty.
+	Replace bool
+}
+
+// ImportResult counts the outcome of an import.
+type ImportResult struct {
+	Imported int
+	// Replaced counts stored keys whose key data was updated (see
+	// ImportOptions.Replace).
+	Replaced int
+	Skipped  int
+}
+
+// ImportAuthorizedKeysWithOptions is ImportAuthorizedKeys honouring opts.
+func ImportAuthorizedKeysWithOptions(ctx context.Context, r io.Reader, km KeyManager, rep Reporter, opts ImportOptions) (ImportResult, error) {
+	return importAuthorizedKeys(ctx, r, km, rep, nil, opts)
+}
+
+// importAuthorizedKeys implements ImportAuthorizedKeysWithOptions. Lines for
+// which isSystemKey reports true are skipped without being offered to km.
+func importAuthorizedKeys(ctx context.Context, r io.Reader, km KeyManager, rep Reporter, isSystemKey func(line, alg, keyData string) bool, opts ImportOptions) (res ImportResult, err error) {
 	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" || strings.HasPrefix(line, "#") {
 			continue
 		}
-		alg, keyData, comment, perr := sshkey.Parse(line)
+		alg, keyData, comment, perr := sshkey.Normalize(line)
 		if perr != nil {
-			skipped++
+			res.Skipped++
 			if rep != nil {
 				rep.Reportf("Skipping invalid key line\n")
 			}
 			continue
 		}
-		if comment == "" {
-			skipped++
+		if isSystemKey != nil && isSystemKey(line, alg, keyData) {
+			res.Skipped++
 			if rep != nil {
-				rep.Reportf("Skipping key with empty comment\n")
+				rep.Reportf("Skipping Keymaster system key\n")
 			}
 			continue
 		}
-		if err := km.AddPublicKey(alg, keyData, comment, false, time.Time{}); err != nil {
-			skipped++
-			if rep != nil {
-				rep.Reportf("Skipping duplicate key (comment exists): %s\n", comment)
-			}
-			continue
+		outcome := importKey(km, rep, alg, keyData, comment, opts)
+		res.count(outcome)
+		if outcome != importSkipped {
+			markImportedCertAuthority(km, rep, line, comment)
 		}
-		imported++
+	}
+	if sErr := scanner.Err(); sErr != nil {
+		return res, sErr
+	}
+	return res, nil
+}
+
+// importOutcome is what importKey did with a key.
+type importOutcome int
+
+const (
+	importSkipped importOutcome = iota
+	importAdded
+	importReplaced
+)
+
+func (r *ImportResult) count(o importOutcome) {
+	switch o {
+	case importAdded:
+		r.Imported++
+	case importReplaced:
+		r.Replaced++
+	default:
+		r.Skipped++
+	}
+}
+
+// importKey adds a single normalized key through km. Keys without a comment,
+// with a disallowed algorithm or that km rejects (usually a duplicate
+// comment) are skipped; with opts.Replace a duplicate comment updates the
+// stored key instead.
+func importKey(km KeyManager, rep Reporter, alg, keyData, comment string, opts ImportOptions) importOutcome {
+	if comment == "" {
 		if rep != nil {
-			rep.Reportf("Imported key: %s\n", comment)
+			rep.Reportf("Skipping key with empty comment\n")
 		}
+		return importSkipped
 	}
-	if sErr := scanner.Err(); sErr != nil {
-		return imported, skipped, sErr
+	if err := sshkey.CheckAlgorithmAllowed(alg); err != nil {
+		if rep != nil {
+			rep.Reportf("Skipping key %s: %v\n", comment, err)
+		}
+		return importSkipped
+	}
+	if err := km.AddPublicKey(alg, keyData, comment, false, time.Time{}); err != nil {
+		if opts.Replace {
+			return replaceKey(km, rep, alg, keyData, comment)
+		}
+		if rep != nil {
+			rep.Reportf("Skipping duplicate key (comment exists): %s\n", comment)
+		}
+		return importSkipped
+	}
+	if rep != nil {
+		rep.Reportf("Imported key: %s\n", comment)
+	}
+	return importAdded
+}
+
+// replaceKey updates the stored key with comment to alg and keyData. Keys
+// that are unchanged, or whose new key data is stored under another comment,
+// are skipped.
+func replaceKey(km KeyManager, rep Reporter, alg, keyData, comment string) importOutcome {
+	existing, err := km.GetPublicKeyByComment(comment)
+	if err != nil || existing == nil {
+		if rep != nil {
+			rep.Reportf("Skipping duplicate key (key data stored under another comment): %s\n", comment)
+		}
+		return importSkipped
+	}
+	if existing.Algorithm == alg && existing.KeyData == keyData {
+		if rep != nil {
+			rep.Reportf("Skipping unchanged key: %s\n", comment)
+		}
+		return importSkipped
+	}
+	if err := km.UpdatePublicKey(existing.ID, alg, keyData); err != nil {
+		if rep != nil {
+			rep.Reportf("Skipping key %s: %v\n", comment, err)
+		}
+		return importSkipped
+	}
+	if rep != nil {
+		rep.Reportf("Replaced key data of %s\n", comment)
+	}
+	return importReplaced
+}
+
+// markImportedCertAuthority carries the cert-authority option of an imported
+// authorized_keys line over to the stored key. Failures are reported; the
+// key stays imported as a plain key.
+func markImportedCertAuthority(km KeyManager, rep Reporter, line, comment string) {
+	if !strings.Contains(line, "cert-authority") {
+		return
+	}
+	isCA, principals, err := sshkey.ParseCertAuthority(line)
+	if err == nil && !isCA {
+		return
+	}
+	var key *model.PublicKey
+	if err == nil {
+		key, err = km.GetPublicKeyByComment(comment)
+	}
+	if err == nil && key == nil {
+		err = fmt.Errorf("key not found after import")
+	}
+	if err == nil {
+		err = km.SetPublicKeyCertAuthority(key.ID, true, principals)
+	}
+	if err != nil && rep != nil {
+		rep.Reportf("Imported %s as a plain key, marking it as a certificate authority failed: %v\n", comment, err)
 	}
-	return imported, skipped, nil
 }
 
 // Backup exports the DB into BackupData using the Store.
@@ -233,53 +691,48 @@ func Backup(ctx context.Context, st Store) (*model.BackupData, error) {
 	return st.ExportDataForBackup()
 }
 
-// WriteBackup writes compressed JSON backup data to writer.
-func WriteBackup(ctx context.Context, data *model.BackupData, w io.Writer) error {
-	zw, err := zstd.NewWriter(w)
-	if err != nil {
-		return fmt.Errorf("create zstd writer: %w", err)
+// BackupWithOptions exports the tables selected by opts. Restoring such a
+// selective backup only touches the tables it contains.
+func BackupWithOptions(ctx context.Context, st Store, opts BackupOptions) (*model.BackupData, error) {
+	if len(opts.Tables) == 0 {
+		return Backup(ctx, st)
 	}
-	defer func() { _ = zw.Close() }()
-	enc := json.NewEncoder(zw)
-	enc.SetIndent("", "  ")
-	if err := enc.Encode(data); err != nil {
-		return fmt.Errorf("encode backup: %w", err)
+	if err := model.ValidateBackupTables(opts.Tables); err != nil {
+		return nil, err
 	}
-	return nil
+	return st.ExportTablesForBackup(opts.Tables)
 }
 
-// Restore reads a zstd-compressed JSON backup and imports it via the Store.
-// RecoverFromCrash performs recovery tasks after an unexpected process exit.
-func Restore(ctx context.Context, r io.Reader, opts RestoreOptions, st Store) error {
-	zr, err := zstd.NewReader(r)
+// WriteBackup writes compressed JSON backup data to writer.
+func WriteBackup(ctx context.Context, data *model.BackupData, w io.Writer) error {
+	return WriteBackupWithOptions(ctx, data, w, WriteBackupOptions{})
+}
+
+// Restore reads a backup (zstd-compressed or plain JSON) and imports it via
+// the Store. For an integrating (non-full) restore the returned summary reports the rows
+// skipped because they conflicted with existing data; a full restore returns
+// a nil summary.
+func Restore(ctx context.Context, r io.Reader, opts RestoreOptions, st Store) (*model.RestoreSummary, error) {
+	data, err := ReadBackup(r)
 	if err != nil {
-		return fmt.Errorf("create zstd reader: %w", err)
-	}
-	defer zr.Close()
-	var data model.BackupData
-	if err := json.NewDecoder(zr).Decode(&data); err != nil {
-		return fmt.Errorf("decode backup: %w", err)
+		return nil, err
 	}
 	if opts.Full {
-		return st.ImportDataFromBackup(&data)
+		return nil, withGlobalLock(st, LockFullRestore, func() error {
+			return st.ImportDataFromBackup(data)
+		})
+	}
+	summary, err := st.IntegrateDataFromBackup(data)
+	if err != nil {
+		return nil, err
 	}
-	return st.IntegrateDataFromBackup(&data)
+	return &summary, nil
 }
 
 // Migrate performs a backup from source store and imports into a newly created target store.
 func Migrate(ctx context.Context, factory StoreFactory, st Store, targetType, targetDsn string) error {
-	data, err := st.ExportDataForBackup()
-	if err != nil {
-		return fmt.Errorf("export backup: %w", err)
-	}
-	targetStore, err := factory.NewStoreFromDSN(targetType, targetDsn)
-	if err != nil {
-		return fmt.Errorf("init target store: %w", err)
-	}
-	if err := targetStore.ImportDataFromBackup(data); err != nil {
-		return fmt.Errorf("import to target: %w", err)
-	}
-	return nil
+	_, err := MigrateWithOptions(ctx, factory, st, targetType, targetDsn, MigrateOptions{})
+	return err
 }
 
 // DecommissionAccounts runs decommission using DeployerManager and returns a summary.
@@ -323,13 +776,51 @@ func DecommissionAccounts(ctx context.Context, targets []model.Account, opts int
 	return summary, nil
 }
 
-// RunDBMaintenance delegates to DBMaintainer.
+// RunDBMaintenance delegates to DBMaintainer. The work stops when ctx is
+// cancelled or opts.Timeout elapses; the error then wraps ctx.Err().
 func RunDBMaintenance(ctx context.Context, maint DBMaintainer, dbType, dsn string, opts DBMaintenanceOptions) error {
-	return maint.RunDBMaintenance(dbType, dsn)
+	ctx, cancel := maintenanceContext(ctx, opts)
+	defer cancel()
+	return maint.RunDBMaintenance(ctx, dbType, dsn)
+}
+
+// RunDBMaintenanceWithStats runs maintenance and, with opts.Analyze, returns
+// the table statistics reported afterwards. maint must implement DBAnalyzer
+// for analysis. opts.Timeout bounds maintenance and analysis together.
+func RunDBMaintenanceWithStats(ctx context.Context, maint DBMaintainer, dbType, dsn string, opts DBMaintenanceOptions) ([]model.TableStats, error) {
+	ctx, cancel := maintenanceContext(ctx, opts)
+	defer cancel()
+	if err := maint.RunDBMaintenance(ctx, dbType, dsn); err != nil {
+		return nil, err
+	}
+	if !opts.Analyze {
+		return nil, nil
+	}
+	analyzer, ok := maint.(DBAnalyzer)
+	if !ok {
+		return nil, fmt.Errorf("database analysis is not supported by this maintainer")
+	}
+	stats, err := analyzer.AnalyzeDatabase(ctx, dbType, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("analyze database: %w", err)
+	}
+	return stats, nil
+}
+
+// maintenanceContext derives the context for a maintenance run, bounded by
+// opts.Timeout when set.
+func maintenanceContext(ctx context.Context, opts DBMaintenanceOptions) (context.Context, context.CancelFunc) {
+	if opts.Timeout > 0 {
+		return context.WithTimeout(ctx, opts.Timeout)
+	}
+	return context.WithCancel(ctx)
 }
 
 // ExportSSHConfig builds an SSH config text for active accounts.
-func ExportSSHConfig(ctx context.Context, st Store) (string, error) {
+func ExportSSHConfig(ctx context.Context, st Store, opts SSHConfigOptions) (string, error) {
+	if err := opts.Validate(); err != nil {
+		return "", err
+	}
 	accounts, err := st.GetAllActiveAccounts()
 	if err != nil {
 		return "", fmt.Errorf("get accounts: %w", err)
@@ -339,17 +830,12 @@ func ExportSSHConfig(ctx context.Context, st Store) (string, error) {
 	}
 	var b strings.Builder
 	b.WriteString("# SSH config generated by Keymaster\n")
-	fmt.Fprintf(&b, "# date: %s\n\n", time.Now().Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "# date: %s\n", time.Now().Format("2006-01-02 15:04:05"))
+	writeSSHConfigGuidance(&b, opts)
+	b.WriteString("\n")
+	aliases := sshConfigAliases(accounts, opts.DedupeAliases)
 	for _, account := range accounts {
-		hostAlias := account.Label
-		if hostAlias == "" {
-			hostAlias = fmt.Sprintf("%s-%s", account.Username, strings.ReplaceAll(account.Hostname, ".", "-"))
-		}
-		fmt.Fprintf(&b, "# %s\n", account.String())
-		fmt.Fprintf(&b, "Host %s\n", hostAlias)
-		fmt.Fprintf(&b, "    HostName %s\n", account.Hostname)
-		fmt.Fprintf(&b, "    User %s\n", account.Username)
-		b.WriteString("\n")
+		writeSSHConfigHostBlock(&b, account, aliases[account.ID], opts)
 	}
 	return b.String(), nil
 }
@@ -408,6 +894,10 @@ func RunDeployCmd(ctx context.Context, st Store, dm DeployerManager, identifier
 	return DeployAccounts(ctx, st, dm, identifier, rep)
 }
 
+func RunDeployWithOptionsCmd(ctx context.Context, st Store, dm DeployerManager, identifier *string, opts DeployRunOptions, rep Reporter) ([]DeployResult, error) {
+	return DeployAccountsWithOptions(ctx, st, dm, identifier, opts, rep)
+}
+
 // RunDeployForAccount calls DeployerManager for a single account deployment.
 func RunDeployForAccount(ctx context.Context, dm DeployerManager, account model.Account, rep Reporter) error {
 	return dm.DeployForAccount(account, false)
@@ -418,13 +908,23 @@ func RunRotateKeyCmd(ctx context.Context, kg KeyGenerator, st Store, passphrase
 	if err != nil {
 		return 0, fmt.Errorf("generate key: %w", err)
 	}
-	return st.RotateSystemKey(pub, priv)
+	var serial int
+	err = withGlobalLock(st, LockRotateKey, func() error {
+		var rerr error
+		serial, rerr = st.RotateSystemKey(pub, priv)
+		return rerr
+	})
+	return serial, err
 }
 
 func RunAuditCmd(ctx context.Context, st Store, dm DeployerManager, mode string, rep Reporter) ([]AuditResult, error) {
 	return AuditAccounts(ctx, st, dm, mode, rep)
 }
 
+func RunAuditWithOptionsCmd(ctx context.Context, st Store, dm DeployerManager, mode string, opts AuditOptions, rep Reporter) ([]AuditResult, error) {
+	return AuditAccountsWithOptions(ctx, st, dm, mode, opts, rep)
+}
+
 // RunAuditForAccount runs audit for a single account via the DeployerManager.
 func RunAuditForAccount(ctx context.Context, dm DeployerManager, account model.Account, mode string, rep Reporter) error {
 	switch strings.ToLower(strings.TrimSpace(mode)) {
@@ -441,15 +941,61 @@ func RunImportCmd(ctx context.Context, r io.Reader, km KeyManager, rep Reporter)
 	return ImportAuthorizedKeys(ctx, r, km, rep)
 }
 
+// RunImportWithOptionsCmd imports an authorized_keys stream honouring opts.
+func RunImportWithOptionsCmd(ctx context.Context, r io.Reader, km KeyManager, rep Reporter, opts ImportOptions) (ImportResult, error) {
+	return ImportAuthorizedKeysWithOptions(ctx, r, km, rep, opts)
+}
+
 // RunImportRemoteCmd fetches authorized_keys from remote via DeployerManager
-// and imports via the provided KeyManager, reporting via Reporter.
+// and imports via the provided KeyManager, reporting via Reporter. The
+// Keymaster system key is never imported: it is recognised by its restriction
+// options or by matching a system key known to DefaultKeyReader.
 func RunImportRemoteCmd(ctx context.Context, account model.Account, dm DeployerManager, km KeyManager, rep Reporter) (imported int, skipped int, warning string, err error) {
 	content, ferr := dm.FetchAuthorizedKeys(account)
 	if ferr != nil {
 		return 0, 0, "", fmt.Errorf("fetch remote authorized_keys: %w", ferr)
 	}
-	imported, skipped, ierr := ImportAuthorizedKeys(ctx, strings.NewReader(string(content)), km, rep)
-	return imported, skipped, "", ierr
+	known, warning := knownSystemKeyMaterial(account)
+	isSystemKey := func(line, alg, keyData string) bool {
+		return strings.HasPrefix(line, SystemKeyRestrictions) || known[alg+" "+keyData]
+	}
+	res, ierr := importAuthorizedKeys(ctx, strings.NewReader(string(content)), km, rep, isSystemKey, ImportOptions{})
+	return res.Imported, res.Skipped, warning, ierr
+}
+
+// knownSystemKeyMaterial returns the normalized "alg data" of the system keys
+// that may appear on account's host: the active key and the key of the
+// account's recorded serial. A warning is returned when they cannot be read;
+// only restricted system key lines are recognised then, as they are when no
+// KeyReader is configured.
+func knownSystemKeyMaterial(account model.Account) (map[string]bool, string) {
+	known := make(map[string]bool)
+	kr := DefaultKeyReader()
+	if kr == nil {
+		return known, ""
+	}
+	var keys []*model.SystemKey
+	active, err := kr.GetActiveSystemKey()
+	if err != nil {
+		return known, fmt.Sprintf("Warning: could not read active system key: %v", err)
+	}
+	keys = append(keys, active)
+	if account.Serial != 0 {
+		sk, err := kr.GetSystemKeyBySerial(account.Serial)
+		if err != nil {
+			return known, fmt.Sprintf("Warning: could not read system key %d: %v", account.Serial, err)
+		}
+		keys = append(keys, sk)
+	}
+	for _, sk := range keys {
+		if sk == nil {
+			continue
+		}
+		if alg, data, _, perr := sshkey.Normalize(sk.PublicKey); perr == nil {
+			known[alg+" "+data] = true
+		}
+	}
+	return known, ""
 }
 
 func RunTrustHostCmd(ctx context.Context, canonicalHost string, dm DeployerManager, st Store, save bool) (string, error) {
@@ -465,8 +1011,12 @@ func RunTrustHostCmd(ctx context.Context, canonicalHost string, dm DeployerManag
 	return key, nil
 }
 
-func RunExportSSHConfigCmd(ctx context.Context, st Store) (string, error) {
-	return ExportSSHConfig(ctx, st)
+func RunExportSSHConfigCmd(ctx context.Context, st Store, opts SSHConfigOptions) (string, error) {
+	return ExportSSHConfig(ctx, st, opts)
+}
+
+func RunExportSSHConfigSplitCmd(ctx context.Context, st Store, opts SSHConfigOptions) (*SSHConfigSplit, error) {
+	return ExportSSHConfigSplit(ctx, st, opts)
 }
 
 func RunDBMaintainCmd(ctx context.Context, maint DBMaintainer, dbType, dsn string, opts DBMaintenanceOptions) error {
@@ -477,11 +1027,23 @@ func RunBackupCmd(ctx context.Context, st Store) (*model.BackupData, error) {
 	return Backup(ctx, st)
 }
 
+func RunBackupWithOptionsCmd(ctx context.Context, st Store, opts BackupOptions) (*model.BackupData, error) {
+	return BackupWithOptions(ctx, st, opts)
+}
+
 func RunWriteBackupCmd(ctx context.Context, data *model.BackupData, w io.Writer) error {
 	return WriteBackup(ctx, data, w)
 }
 
-func RunRestoreCmd(ctx context.Context, r io.Reader, opts RestoreOptions, st Store) error {
+func RunWriteBackupWithOptionsCmd(ctx context.Context, data *model.BackupData, w io.Writer, opts WriteBackupOptions) error {
+	return WriteBackupWithOptions(ctx, data, w, opts)
+}
+
+func RunWriteBackupStreamCmd(ctx context.Context, st Store, opts BackupOptions, w io.Writer, wopts WriteBackupOptions) error {
+	return WriteBackupStream(ctx, st, opts, w, wopts)
+}
+
+func RunRestoreCmd(ctx context.Context, r io.Reader, opts RestoreOptions, st Store) (*model.RestoreSummary, error) {
 	return Restore(ctx, r, opts, st)
 }
 
@@ -489,6 +1051,10 @@ func RunMigrateCmd(ctx context.Context, factory StoreFactory, st Store, targetTy
 	return Migrate(ctx, factory, st, targetType, targetDsn)
 }
 
+func RunMigrateWithOptionsCmd(ctx context.Context, factory StoreFactory, st Store, targetType, targetDsn string, opts MigrateOptions) ([]TableDigest, error) {
+	return MigrateWithOptions(ctx, factory, st, targetType, targetDsn, opts)
+}
+
 func RunDecommissionCmd(ctx context.Context, targets []model.Account, opts interface{}, dm DeployerManager, st Store, a AuditWriter) (DecommissionSummary, error) {
 	return DecommissionAccounts(ctx, targets, opts, dm, st, a)
 }
@@ -503,6 +1069,9 @@ func RecoverFromCrash() error {
 }
 
 func StartSessionReaper() {
+	registerAccountScheduleTask.Do(func() {
+		bootstrap.RegisterReaperTask(runAccountSchedules)
+	})
 	bootstrap.StartSessionReaper()
 }
 
@@ -651,6 +1220,33 @@ func CreateAccount(am AccountManager, username, hostname, label, tags string) (i
 	return id, nil
 }
 
+// ModifyTagsForAccount adds and removes individual tags on an account without
+// replacing the whole tags string. It returns the resulting tags.
+func ModifyTagsForAccount(st Store, id int, add, remove []string) (string, error) {
+	allAccounts, err := st.GetAllAccounts()
+	if err != nil {
+		return "", fmt.Errorf("failed to load accounts: %w", err)
+	}
+	var account *model.Account
+	for i, acc := range allAccounts {
+		if acc.ID == id {
+			account = &allAccounts[i]
+			break
+		}
+	}
+	if account == nil {
+		return "", fmt.Errorf("account not found: %d", id)
+	}
+	tags := ModifyAccountTags(account.Tags, add, remove)
+	if tags == account.Tags {
+		return tags, nil
+	}
+	if err := st.UpdateAccountTags(id, tags); err != nil {
+		return "", fmt.Errorf("failed to update tags: %w", err)
+	}
+	return tags, nil
+}
+
 // UpdateAccount updates hostname, label, or tags for an existing account.
 func UpdateAccount(st Store, id int, hostname, label, tags *string) error {
 	// Check if account exists
@@ -668,6 +1264,11 @@ func UpdateAccount(st Store, id int, hostname, label, tags *string) error {
 	if !accountExists {
 		return fmt.Errorf("account not found: %d", id)
 	}
+	if label != nil {
+		if err := CheckLabelAvailable(allAccounts, id, *label); err != nil {
+			return err
+		}
+	}
 
 	// Update fields if provided
 	updated := false
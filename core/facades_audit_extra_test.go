@@ -29,13 +29,20 @@ func (s *failingDirtyStore) UpdateAccountIsDirty(id int, dirty bool) error {
 }
 func (s *failingDirtyStore) CreateSystemKey(publicKey, privateKey string) (int, error) { return 0, nil }
 func (s *failingDirtyStore) RotateSystemKey(publicKey, privateKey string) (int, error) { return 0, nil }
+func (s *failingDirtyStore) GetAllSystemKeys() ([]model.SystemKey, error)              { return nil, nil }
+func (s *failingDirtyStore) DeleteSystemKey(serial int) error                          { return nil }
 func (s *failingDirtyStore) GetActiveSystemKey() (*model.SystemKey, error) {
 	return &model.SystemKey{Serial: 1, PublicKey: "sys"}, nil
 }
 func (s *failingDirtyStore) AddKnownHostKey(hostname, key string) error      { return nil }
 func (s *failingDirtyStore) ExportDataForBackup() (*model.BackupData, error) { return nil, nil }
-func (s *failingDirtyStore) ImportDataFromBackup(*model.BackupData) error    { return nil }
-func (s *failingDirtyStore) IntegrateDataFromBackup(*model.BackupData) error { return nil }
+func (s *failingDirtyStore) ExportTablesForBackup(tables []string) (*model.BackupData, error) {
+	return nil, nil
+}
+func (s *failingDirtyStore) ImportDataFromBackup(*model.BackupData) error { return nil }
+func (s *failingDirtyStore) IntegrateDataFromBackup(*model.BackupData) (model.RestoreSummary, error) {
+	return model.RestoreSummary{}, nil
+}
 
 // satisfy updated Store interface
 func (s *failingDirtyStore) ToggleAccountStatus(id int, enabled bool) error      { return nil }
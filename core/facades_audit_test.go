@@ -34,13 +34,20 @@ func (s *simpleFakeStore) UpdateAccountIsDirty(id int, dirty bool) error {
 }
 func (s *simpleFakeStore) CreateSystemKey(publicKey, privateKey string) (int, error) { return 0, nil }
 func (s *simpleFakeStore) RotateSystemKey(publicKey, privateKey string) (int, error) { return 0, nil }
+func (s *simpleFakeStore) GetAllSystemKeys() ([]model.SystemKey, error)              { return nil, nil }
+func (s *simpleFakeStore) DeleteSystemKey(serial int) error                          { return nil }
 func (s *simpleFakeStore) GetActiveSystemKey() (*model.SystemKey, error) {
 	return &model.SystemKey{Serial: 1, PublicKey: "sys-pub", PrivateKey: "sys-priv", IsActive: true}, nil
 }
 func (s *simpleFakeStore) AddKnownHostKey(hostname, key string) error      { return nil }
 func (s *simpleFakeStore) ExportDataForBackup() (*model.BackupData, error) { return nil, nil }
-func (s *simpleFakeStore) ImportDataFromBackup(*model.BackupData) error    { return nil }
-func (s *simpleFakeStore) IntegrateDataFromBackup(*model.BackupData) error { return nil }
+func (s *simpleFakeStore) ExportTablesForBackup(tables []string) (*model.BackupData, error) {
+	return nil, nil
+}
+func (s *simpleFakeStore) ImportDataFromBackup(*model.BackupData) error { return nil }
+func (s *simpleFakeStore) IntegrateDataFromBackup(*model.BackupData) (model.RestoreSummary, error) {
+	return model.RestoreSummary{}, nil
+}
 
 // satisfy updated Store interface
 func (s *simpleFakeStore) ToggleAccountStatus(id int, enabled bool) error      { return nil }
@@ -132,7 +139,7 @@ func (s *simpleDM) IsPassphraseRequired(err error) bool { return false }
 func TestAuditAccounts_StrictMatch_NoDirty(t *testing.T) {
 	i18n.Init("en")
 	// prepare account
-	acct := model.Account{ID: 1, Username: "u", Hostname: "h", Serial: 1, IsActive: true}
+	acct := model.Account{ID: 1, Username: "u", Hostname: "h", Serial: 1, IsActive: true, DeployEnabled: true}
 
 	store := &simpleFakeStore{accounts: []model.Account{acct}}
 	dm := &fakeDeployerManager{}
@@ -158,6 +165,9 @@ func TestAuditAccounts_StrictMatch_NoDirty(t *testing.T) {
 	if res[0].Error != nil {
 		t.Fatalf("expected no audit error, got %v", res[0].Error)
 	}
+	if res[0].Drift != nil {
+		t.Fatalf("expected no drift analysis for a match, got %+v", res[0].Drift)
+	}
 	if len(aw.actions) != 0 {
 		t.Fatalf("expected no audit actions logged, got %v", aw.actions)
 	}
@@ -168,7 +178,7 @@ func TestAuditAccounts_StrictMatch_NoDirty(t *testing.T) {
 
 func TestAuditAccounts_StrictMismatch_LogsAndMarksDirty(t *testing.T) {
 	i18n.Init("en")
-	acct := model.Account{ID: 2, Username: "u2", Hostname: "h2", Serial: 1, IsActive: true}
+	acct := model.Account{ID: 2, Username: "u2", Hostname: "h2", Serial: 1, IsActive: true, DeployEnabled: true}
 	store := &simpleFakeStore{accounts: []model.Account{acct}}
 	dm := &fakeDeployerManager{content: []byte("mismatched content")}
 
@@ -187,6 +197,9 @@ func TestAuditAccounts_StrictMismatch_LogsAndMarksDirty(t *testing.T) {
 	if res[0].Error == nil {
 		t.Fatalf("expected audit error for mismatch, got nil")
 	}
+	if res[0].Drift == nil || len(res[0].Drift.Added) != 1 || res[0].Drift.Added[0] != "mismatched content" {
+		t.Fatalf("expected drift analysis listing the unexpected line, got %+v", res[0].Drift)
+	}
 	found := false
 	for _, a := range aw.actions {
 		if strings.HasPrefix(a, "AUDIT_HASH_MISMATCH") {
@@ -203,8 +216,8 @@ func TestAuditAccounts_StrictMismatch_LogsAndMarksDirty(t *testing.T) {
 }
 
 func TestDeployDirtyAccounts_ClearsDirtyOnSuccess(t *testing.T) {
-	acct1 := model.Account{ID: 10, Username: "a", Hostname: "h", IsActive: true, IsDirty: true}
-	acct2 := model.Account{ID: 11, Username: "b", Hostname: "h2", IsActive: true, IsDirty: true}
+	acct1 := model.Account{ID: 10, Username: "a", Hostname: "h", IsActive: true, DeployEnabled: true, IsDirty: true}
+	acct2 := model.Account{ID: 11, Username: "b", Hostname: "h2", IsActive: true, DeployEnabled: true, IsDirty: true}
 	store := &simpleFakeStore{accounts: []model.Account{acct1, acct2}}
 
 	simple := &simpleDM{}
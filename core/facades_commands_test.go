@@ -98,6 +98,8 @@ func (s *simpleStore) AssignKeyToAccount(keyID, accountID int) error
 func (s *simpleStore) UpdateAccountIsDirty(id int, dirty bool) error                  { return nil }
 func (s *simpleStore) CreateSystemKey(publicKey, privateKey string) (int, error)      { return 0, nil }
 func (s *simpleStore) RotateSystemKey(publicKey, privateKey string) (int, error)      { return 0, nil }
+func (s *simpleStore) GetAllSystemKeys() ([]model.SystemKey, error)                   { return nil, nil }
+func (s *simpleStore) DeleteSystemKey(serial int) error                               { return nil }
 func (s *simpleStore) GetActiveSystemKey() (*model.SystemKey, error) {
 	return &model.SystemKey{Serial: 1, PublicKey: "p", PrivateKey: "priv", IsActive: true}, nil
 }
@@ -109,8 +111,13 @@ func (s *simpleStore) AddKnownHostKey(hostname, key string) error {
 	return nil
 }
 func (s *simpleStore) ExportDataForBackup() (*model.BackupData, error) { return nil, nil }
-func (s *simpleStore) ImportDataFromBackup(*model.BackupData) error    { return nil }
-func (s *simpleStore) IntegrateDataFromBackup(*model.BackupData) error { return nil }
+func (s *simpleStore) ExportTablesForBackup(tables []string) (*model.BackupData, error) {
+	return nil, nil
+}
+func (s *simpleStore) ImportDataFromBackup(*model.BackupData) error { return nil }
+func (s *simpleStore) IntegrateDataFromBackup(*model.BackupData) (model.RestoreSummary, error) {
+	return model.RestoreSummary{}, nil
+}
 
 // satisfy updated Store interface
 func (s *simpleStore) ToggleAccountStatus(id int, enabled bool) error      { return nil }
@@ -120,8 +127,8 @@ func (s *simpleStore) UpdateAccountTags(id int, tags string) error         { ret
 
 func TestDeployAccounts_AllAndIdentifier(t *testing.T) {
 	i18n.Init("en")
-	acct1 := model.Account{ID: 1, Username: "alice", Hostname: "a.example.com", Label: ""}
-	acct2 := model.Account{ID: 2, Username: "bob", Hostname: "b.example.com", Label: "team"}
+	acct1 := model.Account{ID: 1, Username: "alice", Hostname: "a.example.com", Label: "", IsActive: true, DeployEnabled: true}
+	acct2 := model.Account{ID: 2, Username: "bob", Hostname: "b.example.com", Label: "team", IsActive: true, DeployEnabled: true}
 	st := &simpleStore{accounts: []model.Account{acct1, acct2}}
 	dm := &callCountingDM{}
 
@@ -14,6 +14,8 @@ type fakeStoreForDecom struct {
 	ferr error
 }
 
+func (f *fakeStoreForDecom) GetAllSystemKeys() ([]model.SystemKey, error)  { return nil, nil }
+func (f *fakeStoreForDecom) DeleteSystemKey(serial int) error              { return nil }
 func (f *fakeStoreForDecom) GetActiveSystemKey() (*model.SystemKey, error) { return f.sys, f.ferr }
 
 // other Store methods (stubs) to satisfy the interface
@@ -31,8 +33,13 @@ func (f *fakeStoreForDecom) CreateSystemKey(publicKey, privateKey string) (int,
 func (f *fakeStoreForDecom) RotateSystemKey(publicKey, privateKey string) (int, error) { return 0, nil }
 func (f *fakeStoreForDecom) AddKnownHostKey(hostname, key string) error                { return nil }
 func (f *fakeStoreForDecom) ExportDataForBackup() (*model.BackupData, error)           { return nil, nil }
-func (f *fakeStoreForDecom) ImportDataFromBackup(*model.BackupData) error              { return nil }
-func (f *fakeStoreForDecom) IntegrateDataFromBackup(*model.BackupData) error           { return nil }
+func (f *fakeStoreForDecom) ExportTablesForBackup(tables []string) (*model.BackupData, error) {
+	return nil, nil
+}
+func (f *fakeStoreForDecom) ImportDataFromBackup(*model.BackupData) error { return nil }
+func (f *fakeStoreForDecom) IntegrateDataFromBackup(*model.BackupData) (model.RestoreSummary, error) {
+	return model.RestoreSummary{}, nil
+}
 
 // satisfy updated Store interface
 func (f *fakeStoreForDecom) ToggleAccountStatus(id int, enabled bool) error      { return nil }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"os"
 	"strings"
 	"testing"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/toeirei/keymaster/core/model"
 	"github.com/toeirei/keymaster/core/security"
+	"github.com/toeirei/keymaster/core/sshkey"
 )
 
 // --- fakes -----------------------------------------------------------------
@@ -39,15 +41,23 @@ func (f *fStore) AssignKeyToAccount(keyID, accountID int) error
 func (f *fStore) UpdateAccountIsDirty(id int, dirty bool) error                  { return nil }
 func (f *fStore) CreateSystemKey(publicKey, privateKey string) (int, error)      { return 0, nil }
 func (f *fStore) RotateSystemKey(publicKey, privateKey string) (int, error)      { return 0, nil }
+func (f *fStore) GetAllSystemKeys() ([]model.SystemKey, error)                   { return nil, nil }
+func (f *fStore) DeleteSystemKey(serial int) error                               { return nil }
 func (f *fStore) GetActiveSystemKey() (*model.SystemKey, error)                  { return f.activeSK, nil }
 func (f *fStore) AddKnownHostKey(hostname, key string) error {
 	f.lastKnownHost = hostname
 	f.lastKnownKey = key
 	return nil
 }
-func (f *fStore) ExportDataForBackup() (*model.BackupData, error)   { return f.gotExport, nil }
-func (f *fStore) ImportDataFromBackup(d *model.BackupData) error    { f.gotExport = d; return nil }
-func (f *fStore) IntegrateDataFromBackup(d *model.BackupData) error { f.gotExport = d; return nil }
+func (f *fStore) ExportDataForBackup() (*model.BackupData, error) { return f.gotExport, nil }
+func (f *fStore) ExportTablesForBackup(tables []string) (*model.BackupData, error) {
+	return f.gotExport, nil
+}
+func (f *fStore) ImportDataFromBackup(d *model.BackupData) error { f.gotExport = d; return nil }
+func (f *fStore) IntegrateDataFromBackup(d *model.BackupData) (model.RestoreSummary, error) {
+	f.gotExport = d
+	return model.RestoreSummary{}, nil
+}
 
 // satisfy updated Store interface
 func (f *fStore) ToggleAccountStatus(id int, enabled bool) error      { return nil }
@@ -79,7 +89,7 @@ func (f fFactory) NewStoreFromDSN(dbType, dsn string) (Store, error) {
 
 type fMaint struct{ gotType, gotDsn string }
 
-func (m *fMaint) RunDBMaintenance(dbType, dsn string) error {
+func (m *fMaint) RunDBMaintenance(ctx context.Context, dbType, dsn string) error {
 	m.gotType = dbType
 	m.gotDsn = dsn
 	return nil
@@ -103,7 +113,10 @@ func (k *fKM) AddPublicKey(alg string, keyData string, comment string, managed b
 	k.added = append(k.added, comment)
 	return nil
 }
-func (k *fKM) AssignKeyToAccount(keyID, accountID int) error     { return nil }
+func (k *fKM) AssignKeyToAccount(keyID, accountID int) error { return nil }
+func (k *fKM) AssignKeyToAccountWithOptions(keyID, accountID int, options string) error {
+	return nil
+}
 func (k *fKM) UnassignKeyFromAccount(keyID, accountID int) error { return nil }
 func (k *fKM) AddPublicKeyAndGetModel(algorithm, keyData, comment string, isGlobal bool, expiresAt time.Time) (*model.PublicKey, error) {
 	if comment == "dup" {
@@ -112,14 +125,17 @@ func (k *fKM) AddPublicKeyAndGetModel(algorithm, keyData, comment string, isGlob
 	k.added = append(k.added, comment)
 	return &model.PublicKey{Algorithm: algorithm, KeyData: keyData, Comment: comment}, nil
 }
-func (k *fKM) DeletePublicKey(id int) error                                   { return nil }
-func (k *fKM) GetAccountsForKey(keyID int) ([]model.Account, error)           { return nil, nil }
-func (k *fKM) GetAllPublicKeys() ([]model.PublicKey, error)                   { return nil, nil }
-func (k *fKM) GetGlobalPublicKeys() ([]model.PublicKey, error)                { return nil, nil }
-func (k *fKM) GetPublicKeyByComment(comment string) (*model.PublicKey, error) { return nil, nil }
-func (k *fKM) GetKeysForAccount(accountID int) ([]model.PublicKey, error)     { return nil, nil }
-func (k *fKM) SetPublicKeyExpiry(id int, expiresAt time.Time) error           { return nil }
-func (k *fKM) TogglePublicKeyGlobal(id int) error                             { return nil }
+func (k *fKM) DeletePublicKey(id int) error                                           { return nil }
+func (k *fKM) GetAccountsForKey(keyID int) ([]model.Account, error)                   { return nil, nil }
+func (k *fKM) GetAllPublicKeys() ([]model.PublicKey, error)                           { return nil, nil }
+func (k *fKM) GetGlobalPublicKeys() ([]model.PublicKey, error)                        { return nil, nil }
+func (k *fKM) GetPublicKeyByComment(comment string) (*model.PublicKey, error)         { return nil, nil }
+func (k *fKM) GetKeysForAccount(accountID int) ([]model.PublicKey, error)             { return nil, nil }
+func (k *fKM) SetPublicKeyExpiry(id int, expiresAt time.Time) error                   { return nil }
+func (k *fKM) SetPublicKeyMetadata(id int, metadata map[string]string) error          { return nil }
+func (k *fKM) SetPublicKeyCertAuthority(id int, isCA bool, principals []string) error { return nil }
+func (k *fKM) TogglePublicKeyGlobal(id int) error                                     { return nil }
+func (k *fKM) UpdatePublicKey(id int, algorithm, keyData string) error                { return nil }
 
 type fDM struct{ deployed []model.Account }
 
@@ -191,7 +207,7 @@ func TestRunRotateKeyCmd(t *testing.T) {
 }
 
 func TestRunDeployCmd(t *testing.T) {
-	a1 := model.Account{ID: 1, Username: "u", Hostname: "h", IsActive: true}
+	a1 := model.Account{ID: 1, Username: "u", Hostname: "h", IsActive: true, DeployEnabled: true}
 	st := &fStore{accounts: []model.Account{a1}}
 	dm := &fDM{}
 	res, err := RunDeployCmd(context.TODO(), st, dm, nil, nil)
@@ -222,6 +238,47 @@ func TestImportAuthorizedKeys_RunImportCmd(t *testing.T) {
 	}
 }
 
+func TestImportAuthorizedKeys_RejectsDisallowedAlgorithm(t *testing.T) {
+	sshkey.SetAllowedAlgorithms([]string{"ssh-ed25519"})
+	t.Cleanup(func() { sshkey.SetAllowedAlgorithms(nil) })
+
+	input := "ssh-rsa AAAAB3 legacy@rsa\nssh-ed25519 AAAAC3 ok@ed\n"
+	km := &fKM{}
+	imported, skipped, err := ImportAuthorizedKeys(context.TODO(), strings.NewReader(input), km, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if imported != 1 || skipped != 1 {
+		t.Fatalf("expected 1 imported and 1 skipped, got %d/%d", imported, skipped)
+	}
+	if len(km.added) != 1 || km.added[0] != "ok@ed" {
+		t.Fatalf("unexpected keys added: %v", km.added)
+	}
+}
+
+func TestImportAuthorizedKeys_NormalizesComment(t *testing.T) {
+	input := "  ssh-ed25519   AAAAC3   ops   team  \n"
+	km := &fKM{}
+	if _, _, err := ImportAuthorizedKeys(context.TODO(), strings.NewReader(input), km, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(km.added) != 1 || km.added[0] != "ops team" {
+		t.Fatalf("expected normalized comment, got %q", km.added)
+	}
+}
+
+func TestBackupWithOptions_Tables(t *testing.T) {
+	data := &model.BackupData{SchemaVersion: 1, Tables: []string{model.BackupTableAccounts}}
+	st := &fStore{gotExport: data}
+	got, err := RunBackupWithOptionsCmd(context.TODO(), st, BackupOptions{Tables: []string{model.BackupTableAccounts}})
+	if err != nil || got != data {
+		t.Fatalf("expected selective export, got %v, %v", got, err)
+	}
+	if _, err := BackupWithOptions(context.TODO(), st, BackupOptions{Tables: []string{"nope"}}); err == nil {
+		t.Fatalf("expected error for unknown table")
+	}
+}
+
 func TestWriteAndRestoreBackup_Migrate(t *testing.T) {
 	data := &model.BackupData{SchemaVersion: 1}
 	var buf bytes.Buffer
@@ -229,12 +286,20 @@ func TestWriteAndRestoreBackup_Migrate(t *testing.T) {
 		t.Fatalf("write backup: %v", err)
 	}
 	st2 := &fStore{}
-	if err := RunRestoreCmd(context.TODO(), &buf, RestoreOptions{Full: true}, st2); err != nil {
-		t.Fatalf("restore: %v", err)
+	if summary, err := RunRestoreCmd(context.TODO(), &buf, RestoreOptions{Full: true}, st2); err != nil || summary != nil {
+		t.Fatalf("full restore: expected no summary, got %v, %v", summary, err)
 	}
 	if st2.gotExport == nil {
 		t.Fatalf("store did not receive backup")
 	}
+	buf.Reset()
+	if err := RunWriteBackupCmd(context.TODO(), data, &buf); err != nil {
+		t.Fatalf("write backup: %v", err)
+	}
+	summary, err := RunRestoreCmd(context.TODO(), &buf, RestoreOptions{}, &fStore{})
+	if err != nil || summary == nil {
+		t.Fatalf("integrating restore: expected summary, got %v, %v", summary, err)
+	}
 	src := &fStore{gotExport: data}
 	tgt := &fStore{}
 	fac := fFactory{target: tgt}
@@ -256,6 +321,72 @@ func TestRunDBMaintainCmd(t *testing.T) {
 	}
 }
 
+type fAnalyzer struct{ fMaint }
+
+func (a *fAnalyzer) AnalyzeDatabase(ctx context.Context, dbType, dsn string) ([]model.TableStats, error) {
+	return []model.TableStats{{Table: "accounts", Rows: 2}}, nil
+}
+
+func TestRunDBMaintenanceWithStats(t *testing.T) {
+	stats, err := RunDBMaintenanceWithStats(context.TODO(), &fMaint{}, "sqlite", "x", DBMaintenanceOptions{})
+	if err != nil || stats != nil {
+		t.Fatalf("expected no stats without Analyze, got %v, %v", stats, err)
+	}
+	if _, err := RunDBMaintenanceWithStats(context.TODO(), &fMaint{}, "sqlite", "x", DBMaintenanceOptions{Analyze: true}); err == nil {
+		t.Fatalf("expected error for maintainer without analysis support")
+	}
+	a := &fAnalyzer{}
+	stats, err = RunDBMaintenanceWithStats(context.TODO(), a, "sqlite", "x", DBMaintenanceOptions{Analyze: true})
+	if err != nil || len(stats) != 1 || stats[0].Rows != 2 {
+		t.Fatalf("unexpected stats: %v, %v", stats, err)
+	}
+	if a.gotType != "sqlite" {
+		t.Fatalf("expected maintenance to run before analysis")
+	}
+}
+
+// slowMaint simulates a long VACUUM that only stops when ctx is cancelled.
+type slowMaint struct{ started chan struct{} }
+
+func (m *slowMaint) RunDBMaintenance(ctx context.Context, dbType, dsn string) error {
+	close(m.started)
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("vacuum interrupted: %w", ctx.Err())
+	case <-time.After(10 * time.Second):
+		return nil
+	}
+}
+
+func TestRunDBMaintenance_CancelInterruptsWork(t *testing.T) {
+	m := &slowMaint{started: make(chan struct{})}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- RunDBMaintenance(ctx, m, "sqlite", "x", DBMaintenanceOptions{}) }()
+	<-m.started
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("maintenance kept running after cancellation")
+	}
+}
+
+func TestRunDBMaintenanceWithStats_TimeoutInterruptsWork(t *testing.T) {
+	m := &slowMaint{started: make(chan struct{})}
+	start := time.Now()
+	_, err := RunDBMaintenanceWithStats(context.Background(), m, "sqlite", "x", DBMaintenanceOptions{Timeout: 20 * time.Millisecond})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("maintenance was not stopped by the timeout, took %v", elapsed)
+	}
+}
+
 func TestRunDecommissionCmd_Single(t *testing.T) {
 	acc := model.Account{ID: 5, Username: "u", Hostname: "h"}
 	st := &fStore{activeSK: &model.SystemKey{PrivateKey: "pkey"}}
@@ -291,7 +422,7 @@ func TestInitializeServices_NoopAndWrappers(t *testing.T) {
 
 	// RunExportSSHConfigCmd
 	st2 := &fStore{accounts: []model.Account{{ID: 1, Username: "u", Hostname: "h", Label: "lbl"}}}
-	cfg, err := RunExportSSHConfigCmd(context.TODO(), st2)
+	cfg, err := RunExportSSHConfigCmd(context.TODO(), st2, SSHConfigOptions{})
 	if err != nil {
 		t.Fatalf("export ssh config err: %v", err)
 	}
@@ -29,7 +29,10 @@ func (f *fmKeyManager) AddPublicKey(algorithm, keyData, comment string, isGlobal
 	f.added = append(f.added, comment)
 	return nil
 }
-func (f *fmKeyManager) AssignKeyToAccount(keyID, accountID int) error     { return nil }
+func (f *fmKeyManager) AssignKeyToAccount(keyID, accountID int) error { return nil }
+func (f *fmKeyManager) AssignKeyToAccountWithOptions(keyID, accountID int, options string) error {
+	return nil
+}
 func (f *fmKeyManager) UnassignKeyFromAccount(keyID, accountID int) error { return nil }
 
 func (f *fmKeyManager) AddPublicKeyAndGetModel(algorithm, keyData, comment string, isGlobal bool, expiresAt time.Time) (*model.PublicKey, error) {
@@ -50,8 +53,13 @@ func (f *fmKeyManager) GetKeysForAccount(accountID int) ([]model.PublicKey, erro
 func (f *fmKeyManager) GetPublicKeyByComment(comment string) (*model.PublicKey, error) {
 	return nil, nil
 }
-func (f *fmKeyManager) SetPublicKeyExpiry(id int, expiresAt time.Time) error { return nil }
-func (f *fmKeyManager) TogglePublicKeyGlobal(id int) error                   { return nil }
+func (f *fmKeyManager) SetPublicKeyExpiry(id int, expiresAt time.Time) error          { return nil }
+func (f *fmKeyManager) SetPublicKeyMetadata(id int, metadata map[string]string) error { return nil }
+func (f *fmKeyManager) SetPublicKeyCertAuthority(id int, isCA bool, principals []string) error {
+	return nil
+}
+func (f *fmKeyManager) UpdatePublicKey(id int, algorithm, keyData string) error { return nil }
+func (f *fmKeyManager) TogglePublicKeyGlobal(id int) error                      { return nil }
 
 // Assign/Unassign provided above
 
@@ -74,7 +82,7 @@ func TestImportAuthorizedKeys_Basic(t *testing.T) {
 func TestExportSSHConfig_And_FindAccount(t *testing.T) {
 	// empty
 	stEmpty := &simpleStore{accounts: []model.Account{}}
-	out, err := ExportSSHConfig(context.TODO(), stEmpty)
+	out, err := ExportSSHConfig(context.TODO(), stEmpty, SSHConfigOptions{})
 	if err != nil {
 		t.Fatalf("ExportSSHConfig error: %v", err)
 	}
@@ -86,7 +94,7 @@ func TestExportSSHConfig_And_FindAccount(t *testing.T) {
 	a1 := model.Account{ID: 1, Username: "alice", Hostname: "a.example.com", Label: ""}
 	a2 := model.Account{ID: 2, Username: "bob", Hostname: "b.example.com", Label: "team"}
 	st := &simpleStore{accounts: []model.Account{a1, a2}}
-	out2, err := ExportSSHConfig(context.TODO(), st)
+	out2, err := ExportSSHConfig(context.TODO(), st, SSHConfigOptions{})
 	if err != nil {
 		t.Fatalf("ExportSSHConfig error: %v", err)
 	}
@@ -146,7 +154,7 @@ func TestWriteBackup_Compresses(t *testing.T) {
 }
 
 // DeployerManager that returns authorized_keys content
-type dmForImport struct{}
+type dmForImport struct{ content []byte }
 
 func (d *dmForImport) DeployForAccount(account model.Account, keepFile bool) error { return nil }
 func (d *dmForImport) AuditSerial(account model.Account) error                     { return nil }
@@ -161,6 +169,9 @@ func (d *dmForImport) CanonicalizeHostPort(host string) string           { retur
 func (d *dmForImport) ParseHostPort(host string) (string, string, error) { return host, "22", nil }
 func (d *dmForImport) GetRemoteHostKey(host string) (string, error)      { return "hk", nil }
 func (d *dmForImport) FetchAuthorizedKeys(account model.Account) ([]byte, error) {
+	if d.content != nil {
+		return d.content, nil
+	}
 	return []byte("ssh-ed25519 AAAA key1\nssh-ed25519 BBBB key2\n"), nil
 }
 func (d *dmForImport) ImportRemoteKeys(account model.Account) ([]model.PublicKey, int, string, error) {
@@ -179,3 +190,56 @@ func TestRunImportRemoteCmd_Success(t *testing.T) {
 		t.Fatalf("unexpected import result: imp=%d skip=%d warn=%q", imp, skip, warn)
 	}
 }
+
+// systemKeyReader serves a single active system key.
+type systemKeyReader struct{ pub string }
+
+func (r *systemKeyReader) GetActiveSystemKey() (*model.SystemKey, error) {
+	return &model.SystemKey{Serial: 3, PublicKey: r.pub, IsActive: true}, nil
+}
+func (r *systemKeyReader) GetSystemKeyBySerial(serial int) (*model.SystemKey, error) {
+	return &model.SystemKey{Serial: serial, PublicKey: r.pub}, nil
+}
+func (r *systemKeyReader) GetAllPublicKeys() ([]model.PublicKey, error) { return nil, nil }
+
+func TestRunImportRemoteCmd_SkipsSystemKey(t *testing.T) {
+	orig := DefaultKeyReader()
+	SetDefaultKeyReader(nil)
+	defer SetDefaultKeyReader(orig)
+
+	content := "# Keymaster Managed Keys (Serial: 3)\n" +
+		SystemKeyRestrictions + " ssh-ed25519 SYSKEY keymaster-system-key\n" +
+		"\n# User Keys\n" +
+		"ssh-ed25519 AAAA alice@laptop\n" +
+		"ssh-ed25519 BBBB bob@desktop\n"
+	dm := &dmForImport{content: []byte(content)}
+	km := &fmKeyManager{}
+	imp, skip, warn, err := RunImportRemoteCmd(context.TODO(), model.Account{ID: 1, Serial: 3}, dm, km, nil)
+	if err != nil {
+		t.Fatalf("RunImportRemoteCmd error: %v", err)
+	}
+	if imp != 2 || skip != 1 || warn != "" {
+		t.Fatalf("unexpected import result: imp=%d skip=%d warn=%q", imp, skip, warn)
+	}
+	if strings.Join(km.added, ",") != "alice@laptop,bob@desktop" {
+		t.Fatalf("expected only user keys to be imported, got %v", km.added)
+	}
+}
+
+func TestRunImportRemoteCmd_SkipsUnrestrictedKnownSystemKey(t *testing.T) {
+	orig := DefaultKeyReader()
+	SetDefaultKeyReader(&systemKeyReader{pub: "ssh-ed25519 SYSKEY keymaster-system-key"})
+	defer SetDefaultKeyReader(orig)
+
+	// A system key copied by hand without its restrictions is still recognised.
+	content := "ssh-ed25519 SYSKEY copied-by-hand\nssh-ed25519 AAAA alice@laptop\n"
+	dm := &dmForImport{content: []byte(content)}
+	km := &fmKeyManager{}
+	imp, skip, _, err := RunImportRemoteCmd(context.TODO(), model.Account{ID: 1}, dm, km, nil)
+	if err != nil {
+		t.Fatalf("RunImportRemoteCmd error: %v", err)
+	}
+	if imp != 1 || skip != 1 || len(km.added) != 1 || km.added[0] != "alice@laptop" {
+		t.Fatalf("unexpected import result: imp=%d skip=%d added=%v", imp, skip, km.added)
+	}
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/toeirei/keymaster/core/keys"
 	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/core/sshkey"
 )
 
 // SystemKeyRestrictions defines the SSH options applied to the Keymaster system key.
@@ -59,9 +60,59 @@ func GenerateKeysContentForSerial(accountID int, serial int) (string, error) {
 		return "", fmt.Errorf("could not retrieve keys for account ID %d: %w", accountID, err)
 	}
 
+	account := lookupRenderedAccount(accountID)
+	globalKeys = keys.GlobalKeysForAccount(account, globalKeys)
+	if account != nil && !account.ManageSystemKey {
+		return keys.BuildAuthorizedKeysContentWithoutSystemKey(systemKey, globalKeys, accountKeys)
+	}
 	return keys.BuildAuthorizedKeysContent(systemKey, globalKeys, accountKeys)
 }
 
+// GenerateKeysContentWithKeys renders the authorized_keys content for
+// accountID from only the given keys and the active system key, ignoring
+// global keys and the account's assignments. It is used for one-off test
+// deploys; the account's ManageSystemKey setting still applies.
+func GenerateKeysContentWithKeys(accountID int, override []model.PublicKey) (string, error) {
+	kr := DefaultKeyReader()
+	if kr == nil {
+		return "", fmt.Errorf("no KeyReader available")
+	}
+	systemKey, err := kr.GetActiveSystemKey()
+	if err != nil {
+		return "", fmt.Errorf("could not retrieve active system key: %w", err)
+	}
+	if systemKey == nil {
+		return "", fmt.Errorf("no active system key found. please generate one first")
+	}
+	if !accountManagesSystemKey(accountID) {
+		return keys.BuildAuthorizedKeysContentWithoutSystemKey(systemKey, nil, override)
+	}
+	return keys.BuildAuthorizedKeysContent(systemKey, nil, override)
+}
+
+// accountManagesSystemKey reports whether the system key is rendered for
+// accountID. When the account cannot be looked up the key is kept, so a
+// lookup failure never locks Keymaster out of a host.
+func accountManagesSystemKey(accountID int) bool {
+	acc := lookupRenderedAccount(accountID)
+	return acc == nil || acc.ManageSystemKey
+}
+
+// lookupRenderedAccount returns the account whose authorized_keys is being
+// rendered, or nil when it cannot be looked up; callers then fall back to
+// rendering the system key and every global key.
+func lookupRenderedAccount(accountID int) *model.Account {
+	ar := DefaultAccountReader()
+	if ar == nil {
+		return nil
+	}
+	acc, err := ar.GetAccount(accountID)
+	if err != nil {
+		return nil
+	}
+	return acc
+}
+
 // GenerateSelectiveKeysContent constructs authorized_keys content excluding specific keys.
 func GenerateSelectiveKeysContent(accountID int, serial int, excludeKeyIDs []int, removeSystemKey bool) (string, error) {
 	var content strings.Builder
@@ -107,6 +158,7 @@ func GenerateSelectiveKeysContent(accountID int, serial int, excludeKeyIDs []int
 	if err != nil {
 		return "", fmt.Errorf("could not retrieve global public keys: %w", err)
 	}
+	globalKeys = keys.GlobalKeysForAccount(lookupRenderedAccount(accountID), globalKeys)
 
 	accountKeys, err := kl.GetKeysForAccount(accountID)
 	if err != nil {
@@ -124,17 +176,13 @@ func GenerateSelectiveKeysContent(accountID int, serial int, excludeKeyIDs []int
 		excludeSet[keyID] = true
 	}
 
-	formatKey := func(key model.PublicKey) string {
-		if key.Comment != "" {
-			return fmt.Sprintf("%s %s %s", key.Algorithm, key.KeyData, key.Comment)
-		}
-		return fmt.Sprintf("%s %s", key.Algorithm, key.KeyData)
-	}
-
 	filterExpired := func(keys []model.PublicKey) []model.PublicKey {
 		var out []model.PublicKey
 		now := time.Now().UTC()
 		for _, k := range keys {
+			if !sshkey.IsAlgorithmAllowed(k.Algorithm) {
+				continue
+			}
 			if k.ExpiresAt.IsZero() || k.ExpiresAt.After(now) {
 				out = append(out, k)
 			}
@@ -146,12 +194,12 @@ func GenerateSelectiveKeysContent(accountID int, serial int, excludeKeyIDs []int
 
 	for _, key := range globalKeys {
 		if !excludeSet[key.ID] {
-			allUserKeysMap[key.ID] = keyInfo{id: key.ID, line: formatKey(key), comment: key.Comment}
+			allUserKeysMap[key.ID] = keyInfo{id: key.ID, line: key.AuthorizedKeysLine(), comment: key.Comment}
 		}
 	}
 	for _, key := range accountKeys {
 		if !excludeSet[key.ID] {
-			allUserKeysMap[key.ID] = keyInfo{id: key.ID, line: formatKey(key), comment: key.Comment}
+			allUserKeysMap[key.ID] = keyInfo{id: key.ID, line: key.AuthorizedKeysLine(), comment: key.Comment}
 		}
 	}
 
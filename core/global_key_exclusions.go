@@ -0,0 +1,58 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// ModifyGlobalKeyExclusions excludes the global keys in exclude from account
+// id and renders the keys in include there again. Only global keys can be
+// excluded. The account is marked dirty when its exclusions change, and the
+// resulting exclusions are returned in ascending order.
+func ModifyGlobalKeyExclusions(st GlobalKeyExclusionStore, kl KeyLister, id int, exclude, include []int) ([]int, error) {
+	accounts, err := st.GetAllAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load accounts: %w", err)
+	}
+	idx := slices.IndexFunc(accounts, func(a model.Account) bool { return a.ID == id })
+	if idx < 0 {
+		return nil, fmt.Errorf("account not found: %d", id)
+	}
+	current := accounts[idx].ExcludedGlobalKeys
+
+	if len(exclude) > 0 {
+		globalKeys, err := kl.GetGlobalPublicKeys()
+		if err != nil {
+			return nil, fmt.Errorf("could not retrieve global public keys: %w", err)
+		}
+		for _, keyID := range exclude {
+			if !slices.ContainsFunc(globalKeys, func(k model.PublicKey) bool { return k.ID == keyID }) {
+				return nil, fmt.Errorf("key %d is not a global key", keyID)
+			}
+		}
+	}
+
+	var next []int
+	for _, keyID := range append(slices.Clone(current), exclude...) {
+		if !slices.Contains(include, keyID) && !slices.Contains(next, keyID) {
+			next = append(next, keyID)
+		}
+	}
+	slices.Sort(next)
+	sortedCurrent := slices.Sorted(slices.Values(current))
+	if slices.Equal(next, sortedCurrent) {
+		return next, nil
+	}
+	if err := st.SetAccountExcludedGlobalKeys(id, next); err != nil {
+		return nil, fmt.Errorf("failed to save global key exclusions: %w", err)
+	}
+	if err := st.UpdateAccountIsDirty(id, true); err != nil {
+		return nil, fmt.Errorf("failed to mark account dirty: %w", err)
+	}
+	return next, nil
+}
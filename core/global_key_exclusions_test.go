@@ -0,0 +1,121 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// globalKeyLister serves a fixed set of global keys and per-account
+// assignments.
+type globalKeyLister struct {
+	global   []model.PublicKey
+	assigned map[int][]model.PublicKey
+}
+
+func (l *globalKeyLister) GetGlobalPublicKeys() ([]model.PublicKey, error) { return l.global, nil }
+func (l *globalKeyLister) GetKeysForAccount(accountID int) ([]model.PublicKey, error) {
+	return l.assigned[accountID], nil
+}
+func (l *globalKeyLister) GetAllPublicKeys() ([]model.PublicKey, error) { return l.global, nil }
+
+type globalKeyExclusionStore struct {
+	accounts []model.Account
+	saved    map[int][]int
+	dirty    []int
+}
+
+func (s *globalKeyExclusionStore) GetAllAccounts() ([]model.Account, error) { return s.accounts, nil }
+func (s *globalKeyExclusionStore) SetAccountExcludedGlobalKeys(id int, keyIDs []int) error {
+	s.saved[id] = keyIDs
+	return nil
+}
+func (s *globalKeyExclusionStore) UpdateAccountIsDirty(id int, dirty bool) error {
+	s.dirty = append(s.dirty, id)
+	return nil
+}
+
+func TestGenerateKeysContent_ExcludedGlobalKey(t *testing.T) {
+	admin := model.PublicKey{ID: 1, Algorithm: "ssh-ed25519", KeyData: "AAAAadmin", Comment: "admin", IsGlobal: true}
+	ops := model.PublicKey{ID: 2, Algorithm: "ssh-ed25519", KeyData: "AAAAops", Comment: "ops", IsGlobal: true}
+	origKR, origKL := DefaultKeyReader(), DefaultKeyLister()
+	t.Cleanup(func() { SetDefaultKeyReader(origKR); SetDefaultKeyLister(origKL) })
+	SetDefaultKeyReader(&fakeKR{})
+	SetDefaultKeyLister(&globalKeyLister{
+		global:   []model.PublicKey{admin, ops},
+		assigned: map[int][]model.PublicKey{3: {ops}},
+	})
+	withAccountReader(t, mapAccountReader{
+		1: {ID: 1, ManageSystemKey: true, ExcludedGlobalKeys: []int{2}},
+		2: {ID: 2, ManageSystemKey: true},
+		3: {ID: 3, ManageSystemKey: true, ExcludedGlobalKeys: []int{2}},
+	})
+
+	dmz, err := GenerateKeysContent(1)
+	if err != nil {
+		t.Fatalf("GenerateKeysContent(1): %v", err)
+	}
+	if !strings.Contains(dmz, "AAAAadmin admin") || strings.Contains(dmz, "AAAAops") {
+		t.Fatalf("expected only the admin key on the excluding account, got %q", dmz)
+	}
+	other, err := GenerateKeysContent(2)
+	if err != nil {
+		t.Fatalf("GenerateKeysContent(2): %v", err)
+	}
+	if !strings.Contains(other, "AAAAadmin admin") || !strings.Contains(other, "AAAAops ops") {
+		t.Fatalf("expected both global keys on other accounts, got %q", other)
+	}
+	// A direct assignment still renders an excluded global key.
+	if assigned, err := GenerateKeysContent(3); err != nil || !strings.Contains(assigned, "AAAAops ops") {
+		t.Fatalf("expected the directly assigned key, got %q (%v)", assigned, err)
+	}
+
+	selective, err := GenerateSelectiveKeysContent(1, 0, nil, false)
+	if err != nil {
+		t.Fatalf("GenerateSelectiveKeysContent: %v", err)
+	}
+	if strings.Contains(selective, "AAAAops") {
+		t.Fatalf("expected the excluded key to stay out of selective content, got %q", selective)
+	}
+}
+
+func TestModifyGlobalKeyExclusions(t *testing.T) {
+	kl := &globalKeyLister{global: []model.PublicKey{{ID: 1, IsGlobal: true}, {ID: 2, IsGlobal: true}}}
+	st := &globalKeyExclusionStore{
+		accounts: []model.Account{{ID: 7, ExcludedGlobalKeys: []int{2}}},
+		saved:    map[int][]int{},
+	}
+
+	got, err := ModifyGlobalKeyExclusions(st, kl, 7, []int{1}, nil)
+	if err != nil {
+		t.Fatalf("ModifyGlobalKeyExclusions: %v", err)
+	}
+	if !slices.Equal(got, []int{1, 2}) || !slices.Equal(st.saved[7], []int{1, 2}) {
+		t.Fatalf("expected keys 1 and 2 excluded, got %v (saved %v)", got, st.saved)
+	}
+	if !slices.Equal(st.dirty, []int{7}) {
+		t.Fatalf("expected the account to be marked dirty, got %v", st.dirty)
+	}
+
+	// Re-including the only excluded key clears the list; repeating an
+	// existing exclusion changes nothing.
+	st.dirty = nil
+	if got, err := ModifyGlobalKeyExclusions(st, kl, 7, nil, []int{2}); err != nil || len(got) != 0 {
+		t.Fatalf("expected no exclusions left, got %v (%v)", got, err)
+	}
+	if got, err := ModifyGlobalKeyExclusions(st, kl, 7, []int{2}, nil); err != nil || !slices.Equal(got, []int{2}) || len(st.dirty) != 1 {
+		t.Fatalf("expected an unchanged exclusion to be a no-op, got %v, dirty %v (%v)", got, st.dirty, err)
+	}
+
+	if _, err := ModifyGlobalKeyExclusions(st, kl, 7, []int{9}, nil); err == nil {
+		t.Fatal("expected an error when excluding a key that is not global")
+	}
+	if _, err := ModifyGlobalKeyExclusions(st, kl, 99, []int{1}, nil); err == nil {
+		t.Fatal("expected an error for an unknown account")
+	}
+}
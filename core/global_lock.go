@@ -0,0 +1,88 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"fmt"
+	"time"
+)
+
+// Names of the database-wide locks taken by operations that must not run on
+// two Keymaster instances at once.
+const (
+	LockRotateKey   = "rotate-key"
+	LockFullRestore = "full-restore"
+)
+
+// GlobalLockTTL bounds how long a crashed instance can block the others on
+// backends whose locks expire. Stores implementing LockRenewer have the lock
+// renewed while the operation runs, so operations may take longer.
+const GlobalLockTTL = 10 * time.Minute
+
+// globalLockRenewInterval is how often a held global lock is renewed. It
+// leaves room for two missed renewals before the lock expires.
+var globalLockRenewInterval = GlobalLockTTL / 3
+
+// LockHeldError is returned when another instance holds a global lock.
+type LockHeldError struct {
+	Name string
+}
+
+func (e *LockHeldError) Error() string {
+	return fmt.Sprintf("another Keymaster instance holds the %q lock; try again later", e.Name)
+}
+
+// withGlobalLock runs fn while holding the named lock, renewing it when the
+// store implements LockRenewer. Stores that do not implement Locker run fn
+// unlocked.
+func withGlobalLock(st any, name string, fn func() error) error {
+	l, ok := st.(Locker)
+	if !ok {
+		return fn()
+	}
+	acquired, err := l.AcquireLock(name, GlobalLockTTL)
+	if err != nil {
+		return fmt.Errorf("acquire %s lock: %w", name, err)
+	}
+	if !acquired {
+		return &LockHeldError{Name: name}
+	}
+	defer func() {
+		if err := l.ReleaseLock(name); err != nil {
+			DefaultLogger().Warn("releasing lock failed", "lock", name, "err", err)
+		}
+	}()
+	if r, ok := st.(LockRenewer); ok {
+		stop := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			renewGlobalLock(r, name, stop)
+		}()
+		// Stop renewing before the deferred release runs.
+		defer func() {
+			close(stop)
+			<-done
+		}()
+	}
+	return fn()
+}
+
+// renewGlobalLock renews the named lock every globalLockRenewInterval until
+// stop is closed. A failed renewal is logged: the lock may have expired and
+// been taken by another instance.
+func renewGlobalLock(r LockRenewer, name string, stop <-chan struct{}) {
+	ticker := time.NewTicker(globalLockRenewInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := r.RenewLock(name, GlobalLockTTL); err != nil {
+				DefaultLogger().Warn("renewing lock failed", "lock", name, "err", err)
+			}
+		}
+	}
+}
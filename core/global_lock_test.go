@@ -0,0 +1,113 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// lockingStore is an fStore whose locks can be pre-held by another instance.
+type lockingStore struct {
+	*fStore
+	held     map[string]bool
+	rotated  bool
+	released []string
+}
+
+func (s *lockingStore) AcquireLock(name string, ttl time.Duration) (bool, error) {
+	if s.held[name] {
+		return false, nil
+	}
+	s.held[name] = true
+	return true, nil
+}
+
+func (s *lockingStore) ReleaseLock(name string) error {
+	delete(s.held, name)
+	s.released = append(s.released, name)
+	return nil
+}
+
+// renewingStore is a lockingStore that counts lock renewals.
+type renewingStore struct {
+	*lockingStore
+	renewals atomic.Int32
+}
+
+func (s *renewingStore) RenewLock(name string, ttl time.Duration) error {
+	s.renewals.Add(1)
+	return nil
+}
+
+func (s *lockingStore) RotateSystemKey(publicKey, privateKey string) (int, error) {
+	s.rotated = true
+	return 3, nil
+}
+
+func TestRunRotateKeyCmd_RefusesWhileLockHeld(t *testing.T) {
+	st := &lockingStore{fStore: &fStore{}, held: map[string]bool{LockRotateKey: true}}
+	_, err := RunRotateKeyCmd(context.TODO(), &fKG{pub: "pub", priv: "priv"}, st, "")
+	var lhe *LockHeldError
+	if !errors.As(err, &lhe) || lhe.Name != LockRotateKey {
+		t.Fatalf("expected LockHeldError, got %v", err)
+	}
+	if st.rotated {
+		t.Fatal("rotation must not run while another instance holds the lock")
+	}
+
+	delete(st.held, LockRotateKey)
+	serial, err := RunRotateKeyCmd(context.TODO(), &fKG{pub: "pub", priv: "priv"}, st, "")
+	if err != nil || serial != 3 || !st.rotated {
+		t.Fatalf("expected rotation to run, got serial=%d err=%v", serial, err)
+	}
+	if len(st.held) != 0 || len(st.released) != 1 {
+		t.Fatalf("expected the lock to be released, held=%v released=%v", st.held, st.released)
+	}
+}
+
+func TestRestore_FullTakesLock(t *testing.T) {
+	st := &lockingStore{fStore: &fStore{}, held: map[string]bool{LockFullRestore: true}}
+	backup := `{"schema_version":1}`
+	if _, err := Restore(context.TODO(), strings.NewReader(backup), RestoreOptions{Full: true}, st); err == nil {
+		t.Fatal("expected full restore to be refused while the lock is held")
+	}
+	if st.gotExport != nil {
+		t.Fatal("backup must not be imported while the lock is held")
+	}
+	// Integrating restores are non-destructive and do not lock.
+	if _, err := Restore(context.TODO(), strings.NewReader(backup), RestoreOptions{}, st); err != nil {
+		t.Fatalf("integrating restore: %v", err)
+	}
+}
+
+func TestWithGlobalLock_RenewsWhileRunning(t *testing.T) {
+	prev := globalLockRenewInterval
+	globalLockRenewInterval = time.Millisecond
+	t.Cleanup(func() { globalLockRenewInterval = prev })
+
+	st := &renewingStore{lockingStore: &lockingStore{fStore: &fStore{}, held: map[string]bool{}}}
+	err := withGlobalLock(st, LockFullRestore, func() error {
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("withGlobalLock: %v", err)
+	}
+	if st.renewals.Load() == 0 {
+		t.Fatal("expected the lock to be renewed while the operation ran")
+	}
+	after := st.renewals.Load()
+	time.Sleep(5 * time.Millisecond)
+	if st.renewals.Load() != after {
+		t.Fatal("expected renewals to stop once the lock was released")
+	}
+	if len(st.released) != 1 {
+		t.Fatalf("expected the lock to be released, got %v", st.released)
+	}
+}
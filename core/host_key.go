@@ -0,0 +1,90 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/toeirei/keymaster/core/db"
+	"github.com/toeirei/keymaster/core/security"
+)
+
+// ErrUnknownHostKey is returned when a host has no trusted key on record.
+var ErrUnknownHostKey = errors.New("unknown host key")
+
+// HostKeyChangedError is returned when a host presents a key that differs
+// from the trusted key on record.
+type HostKeyChangedError struct {
+	// Host is the canonical host:port the key was presented for.
+	Host string
+	// PresentedKey is the key offered by the server in authorized_keys format.
+	PresentedKey string
+}
+
+func (e *HostKeyChangedError) Error() string {
+	return fmt.Sprintf("!!! HOST KEY MISMATCH FOR %s !!!\nRemote key presented: %s\nThis could be a man-in-the-middle attack", e.Host, strings.TrimSpace(e.PresentedKey))
+}
+
+// HostKeyChangePrompt asks the operator whether the changed key presented by
+// host should replace the stored one. It returns true to accept.
+type HostKeyChangePrompt func(host, presentedKey string) bool
+
+var (
+	hostKeyPromptMu sync.Mutex
+	hostKeyPrompt   HostKeyChangePrompt
+)
+
+// SetHostKeyChangePrompt installs the prompt consulted when a host key has
+// changed during deploy or audit. nil, the default, makes host key changes
+// fail, which is the only safe behaviour for non-interactive runs.
+func SetHostKeyChangePrompt(p HostKeyChangePrompt) {
+	hostKeyPromptMu.Lock()
+	defer hostKeyPromptMu.Unlock()
+	hostKeyPrompt = p
+}
+
+// Known host accessors, replaceable in tests.
+var (
+	loadKnownHostKey = db.GetKnownHostKey
+	saveKnownHostKey = db.AddKnownHostKey
+)
+
+// connectWithHostKeyPrompt creates a deployer through NewDeployerFactory. If
+// the host key changed and a prompt is installed, the operator is asked to
+// accept the new key; on acceptance it is stored and the connection retried
+// once. Prompts are serialized so parallel runs ask at most once per key.
+func connectWithHostKeyPrompt(host, user string, privateKey security.Secret, passphrase []byte) (RemoteDeployer, error) {
+	deployer, err := NewDeployerFactory(host, user, privateKey, passphrase)
+	var changed *HostKeyChangedError
+	if err == nil || !errors.As(err, &changed) {
+		return deployer, err
+	}
+
+	hostKeyPromptMu.Lock()
+	prompt := hostKeyPrompt
+	if prompt == nil {
+		hostKeyPromptMu.Unlock()
+		return nil, err
+	}
+	// Another connection may already have accepted this key while we waited.
+	known, lerr := loadKnownHostKey(changed.Host)
+	accepted := lerr == nil && strings.TrimSpace(known) == strings.TrimSpace(changed.PresentedKey)
+	if !accepted {
+		if !prompt(changed.Host, changed.PresentedKey) {
+			hostKeyPromptMu.Unlock()
+			return nil, err
+		}
+		if serr := saveKnownHostKey(changed.Host, changed.PresentedKey); serr != nil {
+			hostKeyPromptMu.Unlock()
+			return nil, fmt.Errorf("save known host key: %w", serr)
+		}
+		DefaultLogger().Warn("replaced changed host key", "host", changed.Host)
+	}
+	hostKeyPromptMu.Unlock()
+
+	return NewDeployerFactory(host, user, privateKey, passphrase)
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"strings"
+
+	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/core/sshkey"
+)
+
+// ValidateRetrustPatterns checks that every auto-retrust pattern is a valid
+// glob.
+func ValidateRetrustPatterns(patterns []string) error {
+	for _, p := range patterns {
+		if strings.TrimSpace(p) == "" {
+			return fmt.Errorf("empty auto-retrust pattern")
+		}
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid auto-retrust pattern %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// HostMatchesRetrustPatterns reports whether host, a canonical host:port,
+// matches one of patterns. Patterns are globs matched against the host name
+// and against host:port, e.g. "web-*.example.com" or "10.0.1.*:2222".
+func HostMatchesRetrustPatterns(host string, patterns []string) bool {
+	name := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		name = h
+	}
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+		if ok, _ := path.Match(p, host); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// AutoRetrustPrompt returns a HostKeyChangePrompt that accepts the changed
+// key of every host matching patterns and records the replacement as
+// HOST_KEY_AUTO_RETRUST. Other hosts are passed to fallback, or rejected when
+// fallback is nil.
+func AutoRetrustPrompt(patterns []string, fallback HostKeyChangePrompt) HostKeyChangePrompt {
+	return func(host, presentedKey string) bool {
+		if !HostMatchesRetrustPatterns(host, patterns) {
+			if fallback == nil {
+				DefaultLogger().Warn("host key changed on a host not allowed for auto-retrust", "host", host)
+				return false
+			}
+			return fallback(host, presentedKey)
+		}
+		oldKey, _ := loadKnownHostKey(host)
+		oldFP, newFP := hostKeyFingerprint(oldKey), hostKeyFingerprint(presentedKey)
+		DefaultLogger().Warn("auto-retrusting changed host key", "host", host, "old_fingerprint", oldFP, "new_fingerprint", newFP)
+		if w := DefaultAuditWriter(); w != nil {
+			_ = LogAuditFields(w, "HOST_KEY_AUTO_RETRUST", fmt.Sprintf("replaced changed host key for %s", host), model.AuditFields{
+				"host":            host,
+				"old_fingerprint": oldFP,
+				"new_fingerprint": newFP,
+			})
+		}
+		return true
+	}
+}
+
+// hostKeyFingerprint returns the SHA256 fingerprint of a stored host key
+// ("type base64"), or "<unknown>" when it cannot be parsed.
+func hostKeyFingerprint(key string) string {
+	fields := strings.Fields(key)
+	if len(fields) < 2 {
+		return "<unknown>"
+	}
+	fp, _, err := sshkey.Fingerprints(fields[1])
+	if err != nil {
+		return "<unknown>"
+	}
+	return fp
+}
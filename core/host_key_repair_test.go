@@ -0,0 +1,109 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+func TestHostMatchesRetrustPatterns(t *testing.T) {
+	patterns := []string{"web-*.example.com", "10.0.1.*:2222"}
+	cases := map[string]bool{
+		"web-01.example.com:22": true,
+		"web-01.example.com":    true,
+		"db-01.example.com:22":  false,
+		"10.0.1.7:2222":         true,
+		"10.0.1.7:22":           false,
+		"web-01.example.org:22": false,
+	}
+	for host, want := range cases {
+		if got := HostMatchesRetrustPatterns(host, patterns); got != want {
+			t.Errorf("HostMatchesRetrustPatterns(%q) = %v, want %v", host, got, want)
+		}
+	}
+	if HostMatchesRetrustPatterns("web-01.example.com:22", nil) {
+		t.Error("expected no host to match an empty allow-list")
+	}
+}
+
+func TestValidateRetrustPatterns(t *testing.T) {
+	if err := ValidateRetrustPatterns([]string{"web-*", "10.0.1.?"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := ValidateRetrustPatterns([]string{"web-["}); err == nil {
+		t.Fatal("expected a malformed glob to be rejected")
+	}
+	if err := ValidateRetrustPatterns([]string{" "}); err == nil {
+		t.Fatal("expected an empty pattern to be rejected")
+	}
+}
+
+func TestAutoRetrustPrompt_AllowListedHostIsRepaired(t *testing.T) {
+	calls, known := hostKeyFixture(t)
+	aw := &spyAuditWriter{}
+	origAW := DefaultAuditWriter()
+	SetDefaultAuditWriter(aw)
+	t.Cleanup(func() { SetDefaultAuditWriter(origAW) })
+	SetHostKeyChangePrompt(AutoRetrustPrompt([]string{"h"}, func(host, presentedKey string) bool {
+		t.Errorf("fallback prompt must not be asked for an allow-listed host")
+		return false
+	}))
+
+	if err := AuditAccountSerial(model.Account{ID: 1, Username: "u", Hostname: "h", Serial: 3}); err != nil {
+		t.Fatalf("expected the audit to pass after the repair, got %v", err)
+	}
+	if *calls != 2 {
+		t.Fatalf("expected the connection to be retried once, got %d attempts", *calls)
+	}
+	if known["h:22"] != changedHostKey {
+		t.Fatalf("expected the new host key to be stored, got %q", known["h:22"])
+	}
+	if len(aw.actions) != 1 || !strings.HasPrefix(aw.actions[0], "HOST_KEY_AUTO_RETRUST:") || !strings.Contains(aw.actions[0], "h:22") {
+		t.Fatalf("expected the repair to be logged, got %v", aw.actions)
+	}
+}
+
+func TestAutoRetrustPrompt_OtherHostsAreNotRepaired(t *testing.T) {
+	calls, known := hostKeyFixture(t)
+	aw := &spyAuditWriter{}
+	origAW := DefaultAuditWriter()
+	SetDefaultAuditWriter(aw)
+	t.Cleanup(func() { SetDefaultAuditWriter(origAW) })
+	SetHostKeyChangePrompt(AutoRetrustPrompt([]string{"web-*"}, nil))
+
+	err := AuditAccountSerial(model.Account{ID: 1, Username: "u", Hostname: "h", Serial: 3})
+	var changed *HostKeyChangedError
+	if !errors.As(err, &changed) {
+		t.Fatalf("expected HostKeyChangedError, got %v", err)
+	}
+	if *calls != 1 {
+		t.Fatalf("expected a single connection attempt, got %d", *calls)
+	}
+	if known["h:22"] != "ssh-ed25519 AAAAold" {
+		t.Fatalf("stored host key must not change, got %q", known["h:22"])
+	}
+	if len(aw.actions) != 0 {
+		t.Fatalf("expected nothing to be logged, got %v", aw.actions)
+	}
+}
+
+func TestAutoRetrustPrompt_FallsBackForOtherHosts(t *testing.T) {
+	_, known := hostKeyFixture(t)
+	var asked []string
+	SetHostKeyChangePrompt(AutoRetrustPrompt([]string{"web-*"}, func(host, presentedKey string) bool {
+		asked = append(asked, host)
+		return true
+	}))
+
+	if err := AuditAccountSerial(model.Account{ID: 1, Username: "u", Hostname: "h", Serial: 3}); err != nil {
+		t.Fatalf("expected the operator's acceptance to repair the host, got %v", err)
+	}
+	if len(asked) != 1 || asked[0] != "h:22" || known["h:22"] != changedHostKey {
+		t.Fatalf("expected the fallback prompt to decide, asked %v, stored %q", asked, known["h:22"])
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/core/security"
+	"github.com/toeirei/keymaster/ui/i18n"
+)
+
+const changedHostKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIHostKeyChanged\n"
+
+// hostKeyFixture makes the first connection fail with a changed host key and
+// later ones succeed once the presented key is stored. It returns the number
+// of factory calls and the stored known hosts.
+func hostKeyFixture(t *testing.T) (*int, map[string]string) {
+	t.Helper()
+	i18n.Init("en")
+	calls := 0
+	known := map[string]string{"h:22": "ssh-ed25519 AAAAold"}
+
+	origFactory, origLoad, origSave := NewDeployerFactory, loadKnownHostKey, saveKnownHostKey
+	origReader := DefaultKeyReader()
+	t.Cleanup(func() {
+		NewDeployerFactory, loadKnownHostKey, saveKnownHostKey = origFactory, origLoad, origSave
+		SetDefaultKeyReader(origReader)
+		SetHostKeyChangePrompt(nil)
+	})
+
+	loadKnownHostKey = func(host string) (string, error) { return known[host], nil }
+	saveKnownHostKey = func(host, key string) error { known[host] = key; return nil }
+	NewDeployerFactory = func(host, user string, privateKey security.Secret, passphrase []byte) (RemoteDeployer, error) {
+		calls++
+		if known["h:22"] != changedHostKey {
+			return nil, &HostKeyChangedError{Host: "h:22", PresentedKey: changedHostKey}
+		}
+		return &fakeRemoteDeployer{getContent: []byte("# Keymaster Managed Keys (Serial: 3)\n")}, nil
+	}
+	SetDefaultKeyReader(&fakeKeyReaderForAudit{})
+	return &calls, known
+}
+
+func TestAuditAccountSerial_HostKeyChanged_NonInteractiveFails(t *testing.T) {
+	calls, known := hostKeyFixture(t)
+	SetHostKeyChangePrompt(nil)
+
+	err := AuditAccountSerial(model.Account{ID: 1, Username: "u", Hostname: "h", Serial: 3})
+	var changed *HostKeyChangedError
+	if !errors.As(err, &changed) {
+		t.Fatalf("expected HostKeyChangedError, got %v", err)
+	}
+	if *calls != 1 {
+		t.Fatalf("expected a single connection attempt, got %d", *calls)
+	}
+	if known["h:22"] != "ssh-ed25519 AAAAold" {
+		t.Fatalf("stored host key must not change, got %q", known["h:22"])
+	}
+}
+
+func TestAuditAccountSerial_HostKeyChanged_AcceptAndRetry(t *testing.T) {
+	calls, known := hostKeyFixture(t)
+	var prompted []string
+	SetHostKeyChangePrompt(func(host, presentedKey string) bool {
+		prompted = append(prompted, host)
+		if presentedKey != changedHostKey {
+			t.Errorf("unexpected presented key %q", presentedKey)
+		}
+		return true
+	})
+
+	if err := AuditAccountSerial(model.Account{ID: 1, Username: "u", Hostname: "h", Serial: 3}); err != nil {
+		t.Fatalf("expected audit to pass after accepting the new key, got %v", err)
+	}
+	if len(prompted) != 1 || prompted[0] != "h:22" {
+		t.Fatalf("expected one prompt for h:22, got %v", prompted)
+	}
+	if *calls != 2 {
+		t.Fatalf("expected connection to be retried once, got %d attempts", *calls)
+	}
+	if known["h:22"] != changedHostKey {
+		t.Fatalf("expected new host key to be stored, got %q", known["h:22"])
+	}
+}
+
+func TestConnectWithHostKeyPrompt_Declined(t *testing.T) {
+	calls, known := hostKeyFixture(t)
+	SetHostKeyChangePrompt(func(host, presentedKey string) bool { return false })
+
+	if _, err := connectWithHostKeyPrompt("h", "u", nil, nil); err == nil {
+		t.Fatal("expected error when the new host key is declined")
+	}
+	if *calls != 1 || known["h:22"] != "ssh-ed25519 AAAAold" {
+		t.Fatalf("declined prompt must not retry or store: calls=%d key=%q", *calls, known["h:22"])
+	}
+}
+
+func TestConnectWithHostKeyPrompt_UnknownHostNotPrompted(t *testing.T) {
+	_, _ = hostKeyFixture(t)
+	NewDeployerFactory = func(host, user string, privateKey security.Secret, passphrase []byte) (RemoteDeployer, error) {
+		return nil, ErrUnknownHostKey
+	}
+	SetHostKeyChangePrompt(func(host, presentedKey string) bool {
+		t.Fatal("unknown host keys must not trigger the change prompt")
+		return true
+	})
+
+	if _, err := connectWithHostKeyPrompt("h", "u", nil, nil); !errors.Is(err, ErrUnknownHostKey) {
+		t.Fatalf("expected ErrUnknownHostKey, got %v", err)
+	}
+}
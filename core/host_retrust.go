@@ -0,0 +1,145 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// HostKeyStatus classifies a host's presented key against the stored one.
+type HostKeyStatus string
+
+const (
+	// HostKeyUnchanged: the host presented the key already trusted.
+	HostKeyUnchanged HostKeyStatus = "unchanged"
+	// HostKeyChanged: the host presented a different key than the trusted one.
+	HostKeyChanged HostKeyStatus = "changed"
+	// HostKeyNew: no key was stored for the host yet.
+	HostKeyNew HostKeyStatus = "new"
+	// HostKeyFailed: the host key could not be fetched or compared.
+	HostKeyFailed HostKeyStatus = "failed"
+)
+
+// DefaultHostKeyConcurrency is the number of hosts CheckHostKeys contacts at
+// the same time when no concurrency is given.
+const DefaultHostKeyConcurrency = 16
+
+// HostKeyFetcher is the deployer surface used to fetch host keys.
+type HostKeyFetcher interface {
+	CanonicalizeHostPort(host string) string
+	GetRemoteHostKey(host string) (string, error)
+}
+
+// KnownHostStore reads and replaces trusted host keys.
+type KnownHostStore interface {
+	GetKnownHostKey(hostname string) (string, error)
+	AddKnownHostKey(hostname, key string) error
+}
+
+// HostKeyCheck is the outcome of fetching one host's key.
+type HostKeyCheck struct {
+	// Host is the canonical host:port the key belongs to.
+	Host string
+	// Accounts lists the selected accounts on Host.
+	Accounts  []model.Account
+	StoredKey string
+	RemoteKey string
+	Status    HostKeyStatus
+	Err       error
+}
+
+// NeedsTrust reports whether RetrustHostKeys would store the remote key.
+func (c HostKeyCheck) NeedsTrust() bool {
+	return c.Status == HostKeyChanged || c.Status == HostKeyNew
+}
+
+// CheckHostKeys fetches the current key of every distinct host among
+// accounts and compares it with the trusted one, without storing anything.
+// Accounts sharing a host are checked once. Results follow the order in
+// which hosts first appear; hosts not contacted because ctx was cancelled
+// are marked failed with ctx.Err().
+func CheckHostKeys(ctx context.Context, dm HostKeyFetcher, st KnownHostStore, accounts []model.Account, concurrency int) []HostKeyCheck {
+	if concurrency <= 0 {
+		concurrency = DefaultHostKeyConcurrency
+	}
+
+	var checks []HostKeyCheck
+	index := make(map[string]int)
+	for _, acc := range accounts {
+		host := dm.CanonicalizeHostPort(acc.Hostname)
+		if i, ok := index[host]; ok {
+			checks[i].Accounts = append(checks[i].Accounts, acc)
+			continue
+		}
+		index[host] = len(checks)
+		checks = append(checks, HostKeyCheck{Host: host, Accounts: []model.Account{acc}})
+	}
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range checks {
+		if err := ctx.Err(); err != nil {
+			checks[i].Status, checks[i].Err = HostKeyFailed, err
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			checks[i].Status, checks[i].Err = HostKeyFailed, ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func(c *HostKeyCheck) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			checkHostKey(dm, st, c)
+		}(&checks[i])
+	}
+	wg.Wait()
+	return checks
+}
+
+func checkHostKey(dm HostKeyFetcher, st KnownHostStore, c *HostKeyCheck) {
+	stored, err := st.GetKnownHostKey(c.Host)
+	if err != nil {
+		c.Status, c.Err = HostKeyFailed, fmt.Errorf("read known host key: %w", err)
+		return
+	}
+	c.StoredKey = stored
+	remote, err := dm.GetRemoteHostKey(c.Host)
+	if err != nil {
+		c.Status, c.Err = HostKeyFailed, fmt.Errorf("fetch remote host key: %w", err)
+		return
+	}
+	c.RemoteKey = remote
+	switch {
+	case strings.TrimSpace(stored) == "":
+		c.Status = HostKeyNew
+	case strings.TrimSpace(stored) == strings.TrimSpace(remote):
+		c.Status = HostKeyUnchanged
+	default:
+		c.Status = HostKeyChanged
+	}
+}
+
+// RetrustHostKeys stores the remote key of every check that needs trust and
+// returns how many keys were written. It stops at the first store error.
+func RetrustHostKeys(st KnownHostStore, checks []HostKeyCheck) (int, error) {
+	n := 0
+	for _, c := range checks {
+		if !c.NeedsTrust() {
+			continue
+		}
+		if err := st.AddKnownHostKey(c.Host, c.RemoteKey); err != nil {
+			return n, fmt.Errorf("save known host key for %s: %w", c.Host, err)
+		}
+		n++
+	}
+	return n, nil
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+	"golang.org/x/crypto/ssh"
+)
+
+// fakeFleet serves remote host keys and records known host writes. Hosts
+// are checked concurrently, so mu guards the maps.
+type fakeFleet struct {
+	mu      sync.Mutex
+	remote  map[string]string
+	known   map[string]string
+	fetched map[string]int
+}
+
+func (f *fakeFleet) CanonicalizeHostPort(host string) string {
+	if strings.Contains(host, ":") {
+		return host
+	}
+	return host + ":22"
+}
+
+func (f *fakeFleet) GetRemoteHostKey(host string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fetched[host]++
+	key, ok := f.remote[host]
+	if !ok {
+		return "", errors.New("connection refused")
+	}
+	return key, nil
+}
+
+func (f *fakeFleet) GetKnownHostKey(hostname string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.known[hostname], nil
+}
+
+func (f *fakeFleet) AddKnownHostKey(hostname, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.known[hostname] = key
+	return nil
+}
+
+func TestCheckAndRetrustHostKeys(t *testing.T) {
+	key := func(seed byte) string {
+		return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(craftedKey(t, seed))))
+	}
+	fleet := &fakeFleet{
+		remote: map[string]string{
+			"web-01:22": key(1),
+			"web-02:22": key(2),
+			"db-01:22":  key(3),
+			"new-01:22": key(4),
+		},
+		known: map[string]string{
+			"web-01:22":  key(11),
+			"web-02:22":  key(2),
+			"db-01:22":   key(13),
+			"down-01:22": key(14),
+		},
+		fetched: map[string]int{},
+	}
+	accounts := []model.Account{
+		{ID: 1, Username: "app", Hostname: "web-01"},
+		{ID: 2, Username: "app", Hostname: "web-02"},
+		{ID: 3, Username: "postgres", Hostname: "db-01"},
+		{ID: 4, Username: "backup", Hostname: "db-01"},
+		{ID: 5, Username: "app", Hostname: "new-01"},
+		{ID: 6, Username: "app", Hostname: "down-01"},
+	}
+
+	checks := CheckHostKeys(context.Background(), fleet, fleet, accounts, 2)
+	want := []struct {
+		host     string
+		status   HostKeyStatus
+		accounts int
+	}{
+		{"web-01:22", HostKeyChanged, 1},
+		{"web-02:22", HostKeyUnchanged, 1},
+		{"db-01:22", HostKeyChanged, 2},
+		{"new-01:22", HostKeyNew, 1},
+		{"down-01:22", HostKeyFailed, 1},
+	}
+	if len(checks) != len(want) {
+		t.Fatalf("expected %d hosts, got %+v", len(want), checks)
+	}
+	for i, w := range want {
+		c := checks[i]
+		if c.Host != w.host || c.Status != w.status || len(c.Accounts) != w.accounts {
+			t.Fatalf("check %d: expected %s %s with %d account(s), got %+v", i, w.host, w.status, w.accounts, c)
+		}
+	}
+	if fleet.fetched["db-01:22"] != 1 {
+		t.Fatalf("expected a shared host to be contacted once, got %d", fleet.fetched["db-01:22"])
+	}
+	if checks[4].Err == nil {
+		t.Fatal("expected the unreachable host to carry its error")
+	}
+	if fleet.known["web-01:22"] != key(11) {
+		t.Fatal("checking must not store anything")
+	}
+
+	n, err := RetrustHostKeys(fleet, checks)
+	if err != nil || n != 3 {
+		t.Fatalf("expected 3 keys saved, got %d %v", n, err)
+	}
+	for host, k := range map[string]string{"web-01:22": key(1), "web-02:22": key(2), "db-01:22": key(3), "new-01:22": key(4), "down-01:22": key(14)} {
+		if fleet.known[host] != k {
+			t.Fatalf("unexpected known key for %s after retrust", host)
+		}
+	}
+}
+
+func TestCheckHostKeys_Cancelled(t *testing.T) {
+	fleet := &fakeFleet{known: map[string]string{}, fetched: map[string]int{}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	checks := CheckHostKeys(ctx, fleet, fleet, []model.Account{{ID: 1, Hostname: "a"}, {ID: 2, Hostname: "b"}}, 1)
+	for _, c := range checks {
+		if c.Status != HostKeyFailed || c.Err == nil {
+			t.Fatalf("expected cancelled hosts to fail, got %+v", c)
+		}
+	}
+}
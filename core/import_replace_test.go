@@ -0,0 +1,70 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/db"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestImportReplace_UpdatesKeyDataAndDirtiesAccounts(t *testing.T) {
+	if _, err := db.New("sqlite", ":memory:"); err != nil {
+		t.Fatalf("db.New failed: %v", err)
+	}
+	km := db.DefaultKeyManager()
+	line := func(seed byte) string {
+		return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(craftedKey(t, seed)))) + " alice@laptop"
+	}
+
+	res, err := ImportAuthorizedKeysWithOptions(context.Background(), strings.NewReader(line(1)), km, nil, ImportOptions{})
+	if err != nil || res.Imported != 1 {
+		t.Fatalf("initial import: %+v, %v", res, err)
+	}
+	key, err := km.GetPublicKeyByComment("alice@laptop")
+	if err != nil || key == nil {
+		t.Fatalf("GetPublicKeyByComment: %v", err)
+	}
+	acctID, err := db.DefaultAccountManager().AddAccount("deploy", "web-01", "", "")
+	if err != nil {
+		t.Fatalf("AddAccount: %v", err)
+	}
+	if err := km.AssignKeyToAccount(key.ID, acctID); err != nil {
+		t.Fatalf("AssignKeyToAccount: %v", err)
+	}
+	if err := db.UpdateAccountIsDirty(acctID, false); err != nil {
+		t.Fatalf("UpdateAccountIsDirty: %v", err)
+	}
+
+	// Without --replace the rotated key is skipped as a duplicate comment.
+	res, err = ImportAuthorizedKeysWithOptions(context.Background(), strings.NewReader(line(2)), km, nil, ImportOptions{})
+	if err != nil || res.Skipped != 1 || res.Replaced != 0 {
+		t.Fatalf("import without replace: %+v, %v", res, err)
+	}
+	if got, _ := km.GetPublicKeyByComment("alice@laptop"); got.KeyData != key.KeyData {
+		t.Fatal("key data changed without --replace")
+	}
+
+	res, err = ImportAuthorizedKeysWithOptions(context.Background(), strings.NewReader(line(2)), km, nil, ImportOptions{Replace: true})
+	if err != nil || res.Replaced != 1 || res.Imported != 0 {
+		t.Fatalf("import with replace: %+v, %v", res, err)
+	}
+	got, _ := km.GetPublicKeyByComment("alice@laptop")
+	if got.ID != key.ID || got.KeyData != strings.Fields(line(2))[1] {
+		t.Fatalf("expected key %d updated in place, got %+v", key.ID, got)
+	}
+	acct, err := db.GetAccount(acctID)
+	if err != nil || acct == nil || !acct.IsDirty {
+		t.Fatalf("expected the assigned account to be marked dirty, got %+v, %v", acct, err)
+	}
+
+	// Re-importing the same key with --replace changes nothing.
+	res, err = ImportAuthorizedKeysWithOptions(context.Background(), strings.NewReader(line(2)), km, nil, ImportOptions{Replace: true})
+	if err != nil || res.Skipped != 1 || res.Replaced != 0 {
+		t.Fatalf("unchanged re-import: %+v, %v", res, err)
+	}
+}
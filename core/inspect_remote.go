@@ -0,0 +1,114 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/core/sshkey"
+)
+
+// RemoteFileStat is the SFTP stat of a file or directory on a host.
+type RemoteFileStat struct {
+	// Path is the path as seen over SFTP.
+	Path    string
+	Mode    os.FileMode
+	Size    int64
+	ModTime time.Time
+}
+
+// AuthorizedKeysStater is implemented by RemoteDeployers that can stat the
+// authorized_keys file and its directory.
+type AuthorizedKeysStater interface {
+	StatAuthorizedKeys() (file, dir RemoteFileStat, err error)
+}
+
+// AuthorizedKeysStatFetcher is implemented by DeployerManagers that can stat
+// an account's authorized_keys file and its directory.
+type AuthorizedKeysStatFetcher interface {
+	StatAuthorizedKeys(account model.Account) (file, dir RemoteFileStat, err error)
+}
+
+// RemoteInspection is the live state of an account's authorized_keys as
+// reported by InspectRemote.
+type RemoteInspection struct {
+	Account model.Account
+	// Content is the raw remote authorized_keys.
+	Content []byte
+	// Hash is the HashAuthorizedKeysContent of Content.
+	Hash string
+	// Serial is the serial of the Keymaster header, 0 when HasHeader is
+	// false.
+	Serial    int
+	HasHeader bool
+	// ExpectedHash is the hash of the content Keymaster would deploy now.
+	ExpectedHash string
+	// Matches reports whether the remote file passes a strict audit of the
+	// account's deploy mode.
+	Matches bool
+	// File and Dir are the stats of authorized_keys and its directory; nil
+	// when StatErr is set.
+	File, Dir *RemoteFileStat
+	StatErr   error
+}
+
+// InspectRemote reads account's authorized_keys through dm and reports its
+// hash, Keymaster serial header, permissions and whether it matches the
+// expected content. It changes nothing on the host or in the store. Errors
+// fetching the file or rendering the expected content are returned; a failed
+// stat is reported in StatErr.
+func InspectRemote(dm DeployerManager, account model.Account) (RemoteInspection, error) {
+	ins := RemoteInspection{Account: account}
+	content, err := dm.FetchAuthorizedKeys(account)
+	if err != nil {
+		return ins, fmt.Errorf("fetch authorized_keys: %w", err)
+	}
+	ins.Content = content
+	ins.Hash = HashAuthorizedKeysContent(content)
+	ins.Serial, ins.HasHeader = remoteSerialHeader(content)
+
+	expected, err := GenerateKeysContent(account.ID)
+	if err != nil {
+		return ins, fmt.Errorf("generate expected content: %w", err)
+	}
+	ins.ExpectedHash = HashAuthorizedKeysContent([]byte(expected))
+	if account.EffectiveDeployMode() == model.DeployModeAppendOnly {
+		ins.Matches = !AnalyzeAppendOnlyDrift(expected, string(content), account.DeployedKeys).HasKeyDrift()
+	} else {
+		ins.Matches = ins.Hash == ins.ExpectedHash
+	}
+
+	stater, ok := dm.(AuthorizedKeysStatFetcher)
+	if !ok {
+		ins.StatErr = fmt.Errorf("deployer manager cannot stat remote files")
+		return ins, nil
+	}
+	file, dir, err := stater.StatAuthorizedKeys(account)
+	if err != nil {
+		ins.StatErr = err
+		return ins, nil
+	}
+	ins.File, ins.Dir = &file, &dir
+	return ins, nil
+}
+
+// remoteSerialHeader parses the Keymaster header from the first non-empty
+// line of content.
+func remoteSerialHeader(content []byte) (int, bool) {
+	for _, line := range strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		serial, err := sshkey.ParseSerial(line)
+		if err != nil {
+			return 0, false
+		}
+		return serial, true
+	}
+	return 0, false
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// statDeployerManager is a fakeDeployerManager that also stats
+// authorized_keys.
+type statDeployerManager struct {
+	fakeDeployerManager
+	file, dir RemoteFileStat
+	serr      error
+}
+
+func (s *statDeployerManager) StatAuthorizedKeys(account model.Account) (RemoteFileStat, RemoteFileStat, error) {
+	return s.file, s.dir, s.serr
+}
+
+func TestInspectRemote_ReportsFields(t *testing.T) {
+	SetDefaultKeyReader(&fakeKR{})
+	SetDefaultKeyLister(&fakeKL{})
+	acct := model.Account{ID: 7, Username: "u", Hostname: "h", Serial: 1}
+	expected, err := GenerateKeysContent(acct.ID)
+	if err != nil {
+		t.Fatalf("GenerateKeysContent: %v", err)
+	}
+	if !strings.HasPrefix(expected, "# Keymaster Managed Keys (Serial: 1)\n") {
+		t.Fatalf("unexpected fixture header: %q", expected)
+	}
+	mod := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	dm := &statDeployerManager{
+		fakeDeployerManager: fakeDeployerManager{content: []byte(expected)},
+		file:                RemoteFileStat{Path: ".ssh/authorized_keys", Mode: 0600, Size: int64(len(expected)), ModTime: mod},
+		dir:                 RemoteFileStat{Path: ".ssh", Mode: 0700 | os.ModeDir},
+	}
+
+	ins, err := InspectRemote(dm, acct)
+	if err != nil {
+		t.Fatalf("InspectRemote: %v", err)
+	}
+	if !ins.HasHeader || ins.Serial != 1 {
+		t.Errorf("expected serial header 1, got %d (header %v)", ins.Serial, ins.HasHeader)
+	}
+	if ins.Hash != HashAuthorizedKeysContent([]byte(expected)) || ins.Hash != ins.ExpectedHash || !ins.Matches {
+		t.Errorf("expected matching hashes, got %s vs %s (matches %v)", ins.Hash, ins.ExpectedHash, ins.Matches)
+	}
+	if string(ins.Content) != expected {
+		t.Errorf("expected the raw content, got %q", ins.Content)
+	}
+	if ins.StatErr != nil || ins.File == nil || ins.File.Mode != 0600 || !ins.File.ModTime.Equal(mod) || ins.Dir == nil || ins.Dir.Mode.Perm() != 0700 {
+		t.Fatalf("unexpected stats: file %+v, dir %+v, err %v", ins.File, ins.Dir, ins.StatErr)
+	}
+
+	dm.content = append(dm.content, "ssh-ed25519 AAAAextra added-by-hand\n"...)
+	dm.serr = errors.New("permission denied")
+	ins, err = InspectRemote(dm, acct)
+	if err != nil {
+		t.Fatalf("InspectRemote: %v", err)
+	}
+	if ins.Matches || ins.Hash == ins.ExpectedHash || ins.Serial != 1 {
+		t.Errorf("expected drift with the header intact, got %+v", ins)
+	}
+	if ins.StatErr == nil || ins.File != nil {
+		t.Errorf("expected the stat failure to be reported, got %v", ins.StatErr)
+	}
+}
+
+func TestInspectRemote_NoHeaderAndFetchError(t *testing.T) {
+	SetDefaultKeyReader(&fakeKR{})
+	SetDefaultKeyLister(&fakeKL{})
+	acct := model.Account{ID: 1, Username: "u", Hostname: "h"}
+
+	ins, err := InspectRemote(&fakeDeployerManager{content: []byte("ssh-ed25519 AAAA manual\n")}, acct)
+	if err != nil {
+		t.Fatalf("InspectRemote: %v", err)
+	}
+	if ins.HasHeader || ins.Serial != 0 || ins.Matches {
+		t.Errorf("expected no header and no match, got %+v", ins)
+	}
+	if ins.StatErr == nil {
+		t.Error("expected a StatErr for a manager without stat support")
+	}
+
+	if _, err := InspectRemote(&fakeDeployerManager{ferr: errors.New("connection refused")}, acct); err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Fatalf("expected the fetch error, got %v", err)
+	}
+}
@@ -40,14 +40,17 @@ type Store interface {
 	CreateSystemKey(publicKey, privateKey string) (int, error)
 	RotateSystemKey(publicKey, privateKey string) (int, error)
 	GetActiveSystemKey() (*model.SystemKey, error)
+	GetAllSystemKeys() ([]model.SystemKey, error)
+	DeleteSystemKey(serial int) error
 
 	// Host keys
 	AddKnownHostKey(hostname, key string) error
 
 	// Backup helpers
 	ExportDataForBackup() (*model.BackupData, error)
+	ExportTablesForBackup(tables []string) (*model.BackupData, error)
 	ImportDataFromBackup(*model.BackupData) error
-	IntegrateDataFromBackup(*model.BackupData) error
+	IntegrateDataFromBackup(*model.BackupData) (model.RestoreSummary, error)
 }
 
 // Deployer defines the minimal remote deployment operations.
@@ -78,10 +81,14 @@ type KeyManager interface {
 	DeletePublicKey(id int) error
 	TogglePublicKeyGlobal(id int) error
 	SetPublicKeyExpiry(id int, expiresAt time.Time) error
+	SetPublicKeyMetadata(id int, metadata map[string]string) error
+	SetPublicKeyCertAuthority(id int, isCA bool, principals []string) error
+	UpdatePublicKey(id int, algorithm, keyData string) error
 	GetAllPublicKeys() ([]model.PublicKey, error)
 	GetPublicKeyByComment(comment string) (*model.PublicKey, error)
 	GetGlobalPublicKeys() ([]model.PublicKey, error)
 	AssignKeyToAccount(keyID, accountID int) error
+	AssignKeyToAccountWithOptions(keyID, accountID int, options string) error
 	UnassignKeyFromAccount(keyID, accountID int) error
 	GetKeysForAccount(accountID int) ([]model.PublicKey, error)
 	GetAccountsForKey(keyID int) ([]model.Account, error)
@@ -133,9 +140,16 @@ type DecommissionResult struct {
 	SkipReason string
 }
 
-// DBMaintainer runs engine-specific maintenance operations.
+// DBMaintainer runs engine-specific maintenance operations. Implementations
+// stop the work when ctx is cancelled and return an error wrapping ctx.Err().
 type DBMaintainer interface {
-	RunDBMaintenance(dbType, dsn string) error
+	RunDBMaintenance(ctx context.Context, dbType, dsn string) error
+}
+
+// DBAnalyzer is implemented by DBMaintainers that can report per-table row
+// counts and sizes.
+type DBAnalyzer interface {
+	AnalyzeDatabase(ctx context.Context, dbType, dsn string) ([]model.TableStats, error)
 }
 
 // DecommissionOptions configures how a decommission should behave. This is a
@@ -161,6 +175,15 @@ type Reporter interface {
 	Reportf(format string, args ...any)
 }
 
+// Logger emits leveled diagnostic records. keyvals are alternating key/value
+// pairs attached to the record as structured fields.
+type Logger interface {
+	Debug(msg string, keyvals ...any)
+	Info(msg string, keyvals ...any)
+	Warn(msg string, keyvals ...any)
+	Error(msg string, keyvals ...any)
+}
+
 // BackupStore provides streaming helpers for backup/restore operations.
 type BackupStore interface {
 	WriteBackup(ctx context.Context, w io.Writer, data *model.BackupData) error
@@ -54,3 +54,182 @@ type AccountManager interface {
 	AddAccount(username, hostname, label, tags string) (int, error)
 	DeleteAccount(id int) error
 }
+
+// UnassignedKeyLister lists public keys that are neither global nor assigned
+// to any account.
+type UnassignedKeyLister interface {
+	GetUnassignedPublicKeys() ([]model.PublicKey, error)
+}
+
+// AccountScheduler is the store surface used to apply scheduled account
+// activation changes.
+type AccountScheduler interface {
+	GetAllAccounts() ([]model.Account, error)
+	ToggleAccountStatus(id int, enabled bool) error
+	SetAccountSchedule(id int, disableAt, enableAt time.Time) error
+}
+
+// AccountReader looks up a single account by ID. It returns nil without an
+// error when no such account exists.
+type AccountReader interface {
+	GetAccount(id int) (*model.Account, error)
+}
+
+// SystemKeyPolicyStore is the store surface used to change whether an
+// account's authorized_keys carries the Keymaster system key.
+type SystemKeyPolicyStore interface {
+	GetAllAccounts() ([]model.Account, error)
+	SetAccountManageSystemKey(id int, manage bool) error
+	UpdateAccountIsDirty(id int, dirty bool) error
+}
+
+// GlobalKeyExclusionStore is the store surface used to exclude global keys
+// from an account.
+type GlobalKeyExclusionStore interface {
+	GetAllAccounts() ([]model.Account, error)
+	SetAccountExcludedGlobalKeys(id int, keyIDs []int) error
+	UpdateAccountIsDirty(id int, dirty bool) error
+}
+
+// AuthorizedPrincipalsStore is the store surface used to change the
+// principals deployed to an account.
+type AuthorizedPrincipalsStore interface {
+	GetAllAccounts() ([]model.Account, error)
+	SetAccountAuthorizedPrincipals(id int, principals []string) error
+	UpdateAccountIsDirty(id int, dirty bool) error
+}
+
+// StaleSerialReader is the store surface used to find accounts not yet
+// deployed with the active system key.
+type StaleSerialReader interface {
+	GetActiveSystemKey() (*model.SystemKey, error)
+	GetAccountsBelowSerial(serial int) ([]model.Account, error)
+}
+
+// AccountDirtyMarker is the store surface used to flag every account for
+// redeployment after a change that affects all of them.
+type AccountDirtyMarker interface {
+	GetAllAccounts() ([]model.Account, error)
+	UpdateAccountIsDirty(id int, dirty bool) error
+}
+
+// DeployModeStore is the store surface used to change an account's deploy
+// mode.
+type DeployModeStore interface {
+	GetAllAccounts() ([]model.Account, error)
+	SetAccountDeployMode(id int, mode string) error
+}
+
+// DeployEnabledStore is the store surface used to include or exclude an
+// account from deploys.
+type DeployEnabledStore interface {
+	GetAllAccounts() ([]model.Account, error)
+	SetAccountDeployEnabled(id int, enabled bool) error
+}
+
+// DeployKnownHostsStore is the store surface used to turn known_hosts
+// deployment on or off for an account.
+type DeployKnownHostsStore interface {
+	GetAllAccounts() ([]model.Account, error)
+	SetAccountDeployKnownHosts(id int, enabled bool) error
+}
+
+// KnownHostLister lists the stored known host keys.
+type KnownHostLister interface {
+	GetAllKnownHosts() ([]model.KnownHost, error)
+}
+
+// OSFamilyStore is the store surface used to change an account's remote OS
+// family.
+type OSFamilyStore interface {
+	GetAllAccounts() ([]model.Account, error)
+	SetAccountOSFamily(id int, family string) error
+}
+
+// AccountGroupStore is the store surface used to move accounts between
+// groups.
+type AccountGroupStore interface {
+	GetAllAccounts() ([]model.Account, error)
+	SetAccountGroup(id int, group string) error
+}
+
+// DeployedKeysRecorder records which keys a deploy wrote to an account.
+type DeployedKeysRecorder interface {
+	SetAccountDeployedKeys(id int, keys []string) error
+}
+
+// LastAuditRecorder records when an account was last audited clean.
+type LastAuditRecorder interface {
+	SetAccountLastAudit(id int, at time.Time) error
+}
+
+// OperationResultRecorder stores the outcome of deploys and audits.
+type OperationResultRecorder interface {
+	RecordOperationResult(r model.OperationResult) error
+}
+
+// OperationResultReader reads the recorded deploy and audit outcomes of an
+// account.
+type OperationResultReader interface {
+	GetOperationResults(accountID int, since time.Time) ([]model.OperationResult, error)
+}
+
+// RemediationPolicyStore is the store surface used to change an account's
+// drift remediation policy.
+type RemediationPolicyStore interface {
+	GetAllAccounts() ([]model.Account, error)
+	SetAccountRemediationPolicy(id int, policy string) error
+}
+
+// Locker takes named locks shared by every Keymaster instance using the same
+// database.
+type Locker interface {
+	AcquireLock(name string, ttl time.Duration) (bool, error)
+	ReleaseLock(name string) error
+}
+
+// LockRenewer extends a lock taken with Locker.AcquireLock, so operations
+// running longer than the lock's ttl keep it.
+type LockRenewer interface {
+	RenewLock(name string, ttl time.Duration) error
+}
+
+// SystemKeyDeactivator deactivates the system keys superseded by a staged
+// rotation.
+type SystemKeyDeactivator interface {
+	DeactivateSystemKeysBelow(serial int) (int, error)
+}
+
+// BackupStreamer passes backup rows to fn one at a time instead of loading
+// whole tables into memory. Rows arrive table by table in
+// model.BackupTables order and are model values such as model.Account.
+type BackupStreamer interface {
+	StreamBackup(tables []string, fn func(table string, row any) error) error
+}
+
+// BackupExporter exports selected tables as backup data.
+type BackupExporter interface {
+	ExportTablesForBackup(tables []string) (*model.BackupData, error)
+}
+
+// AccountMetadataStore is the store surface used to annotate accounts.
+type AccountMetadataStore interface {
+	GetAccount(id int) (*model.Account, error)
+	SetAccountMetadata(id int, metadata map[string]string) error
+}
+
+// AccountTagsStore is the store surface used to replace account tags.
+type AccountTagsStore interface {
+	GetAllAccounts() ([]model.Account, error)
+	UpdateAccountTags(accountID int, tags string) error
+}
+
+// AuditLogReader reads the whole audit log, newest entry first.
+type AuditLogReader interface {
+	GetAllAuditLogEntries() ([]model.AuditLogEntry, error)
+}
+
+// AccountHistoryReader reads the audit trail of a single account.
+type AccountHistoryReader interface {
+	GetAuditLogForAccount(accountID int) ([]model.AuditLogEntry, error)
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/core/sshkey"
+)
+
+// CompromisedFingerprints maps normalized fingerprints (see
+// sshkey.NormalizeFingerprint) to the label given in the list, if any.
+type CompromisedFingerprints map[string]string
+
+// ParseCompromisedFingerprints reads a list of known-compromised key
+// fingerprints, one per line. Anything after the fingerprint is kept as its
+// label; blank lines and lines starting with '#' are ignored. Both SHA256
+// ("SHA256:...") and MD5 ("aa:bb:..." or "MD5:aa:bb:...") fingerprints are
+// accepted.
+func ParseCompromisedFingerprints(r io.Reader) (CompromisedFingerprints, error) {
+	out := make(CompromisedFingerprints)
+	sc := bufio.NewScanner(r)
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		fp, err := sshkey.NormalizeFingerprint(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		out[fp] = strings.Join(fields[1:], " ")
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read fingerprint list: %w", err)
+	}
+	return out, nil
+}
+
+// CompromisedKeyMatch is a stored key whose fingerprint appears in a list of
+// known-compromised fingerprints.
+type CompromisedKeyMatch struct {
+	Key model.PublicKey
+	// Fingerprint is the matching fingerprint as listed.
+	Fingerprint string
+	// Label is the text following the fingerprint in the list.
+	Label string
+	// Accounts the key is assigned to. Global keys are deployed to every
+	// account regardless.
+	Accounts []model.Account
+}
+
+// FindCompromisedKeys fingerprints every stored public key and returns those
+// found in list, ordered like km.GetAllPublicKeys. Keys whose data cannot be
+// parsed are skipped. The check is purely local.
+func FindCompromisedKeys(km KeyManager, list CompromisedFingerprints) ([]CompromisedKeyMatch, error) {
+	keys, err := km.GetAllPublicKeys()
+	if err != nil {
+		return nil, fmt.Errorf("get public keys: %w", err)
+	}
+	var matches []CompromisedKeyMatch
+	for _, k := range keys {
+		sha, md5, err := sshkey.Fingerprints(k.KeyData)
+		if err != nil {
+			continue
+		}
+		for _, fp := range []string{sha, md5} {
+			label, ok := list[fp]
+			if !ok {
+				continue
+			}
+			accounts, err := km.GetAccountsForKey(k.ID)
+			if err != nil {
+				return nil, fmt.Errorf("get accounts for key %d: %w", k.ID, err)
+			}
+			matches = append(matches, CompromisedKeyMatch{Key: k, Fingerprint: fp, Label: label, Accounts: accounts})
+			break
+		}
+	}
+	return matches, nil
+}
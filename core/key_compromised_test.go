@@ -0,0 +1,77 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+	"golang.org/x/crypto/ssh"
+)
+
+// craftedKey derives a deterministic ed25519 public key from seed.
+func craftedKey(t *testing.T, seed byte) ssh.PublicKey {
+	t.Helper()
+	priv := ed25519.NewKeyFromSeed([]byte(strings.Repeat(string(rune(seed)), ed25519.SeedSize)))
+	pub, err := ssh.NewPublicKey(priv.Public())
+	if err != nil {
+		t.Fatalf("NewPublicKey: %v", err)
+	}
+	return pub
+}
+
+func TestParseCompromisedFingerprints(t *testing.T) {
+	list, err := ParseCompromisedFingerprints(strings.NewReader(`
+# leaked keys
+SHA256:abcDEF0123+/xyz=  leaked in incident 42
+D4:1D:8C:D9:8F:00:B2:04:E9:80:09:98:EC:F8:42:7E debian-weak
+`))
+	if err != nil {
+		t.Fatalf("ParseCompromisedFingerprints: %v", err)
+	}
+	if label, ok := list["SHA256:abcDEF0123+/xyz"]; !ok || label != "leaked in incident 42" {
+		t.Fatalf("expected padded SHA256 fingerprint to be normalized, got %v", list)
+	}
+	if label, ok := list["MD5:d4:1d:8c:d9:8f:00:b2:04:e9:80:09:98:ec:f8:42:7e"]; !ok || label != "debian-weak" {
+		t.Fatalf("expected MD5 fingerprint to be normalized, got %v", list)
+	}
+
+	if _, err := ParseCompromisedFingerprints(strings.NewReader("SHA256:ok\nnot-a-fingerprint\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("expected an error for line 2, got %v", err)
+	}
+}
+
+func TestFindCompromisedKeys(t *testing.T) {
+	bad, good, legacy := craftedKey(t, 1), craftedKey(t, 2), craftedKey(t, 3)
+	km := &assignKM{assigned: map[int]bool{4: true}}
+	km.Results = []model.PublicKey{
+		{ID: 1, Algorithm: bad.Type(), KeyData: base64.StdEncoding.EncodeToString(bad.Marshal()), Comment: "bad"},
+		{ID: 2, Algorithm: good.Type(), KeyData: base64.StdEncoding.EncodeToString(good.Marshal()), Comment: "good"},
+		{ID: 3, Algorithm: "ssh-ed25519", KeyData: "not-base64", Comment: "broken"},
+		{ID: 4, Algorithm: legacy.Type(), KeyData: base64.StdEncoding.EncodeToString(legacy.Marshal()), Comment: "legacy"},
+	}
+
+	list, err := ParseCompromisedFingerprints(strings.NewReader(
+		ssh.FingerprintSHA256(bad) + " leaked\n" + strings.ToUpper(ssh.FingerprintLegacyMD5(legacy)) + "\n"))
+	if err != nil {
+		t.Fatalf("ParseCompromisedFingerprints: %v", err)
+	}
+
+	matches, err := FindCompromisedKeys(km, list)
+	if err != nil {
+		t.Fatalf("FindCompromisedKeys: %v", err)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("expected 2 matches, got %+v", matches)
+	}
+	if m := matches[0]; m.Key.ID != 1 || m.Fingerprint != ssh.FingerprintSHA256(bad) || m.Label != "leaked" {
+		t.Fatalf("unexpected SHA256 match: %+v", m)
+	}
+	if m := matches[1]; m.Key.ID != 4 || !strings.HasPrefix(m.Fingerprint, "MD5:") || len(m.Accounts) != 1 || m.Accounts[0].ID != 4 {
+		t.Fatalf("unexpected MD5 match: %+v", m)
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// FindPublicKeyByIdentifier locates a key by its comment or, when no comment
+// matches, by its numeric ID.
+func FindPublicKeyByIdentifier(identifier string, keys []model.PublicKey) (*model.PublicKey, error) {
+	for i := range keys {
+		if keys[i].Comment == identifier {
+			return &keys[i], nil
+		}
+	}
+	if id, err := strconv.Atoi(identifier); err == nil {
+		for i := range keys {
+			if keys[i].ID == id {
+				return &keys[i], nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no key found with comment or id: %s", identifier)
+}
+
+// WriteKeyCatalog writes keys to w as an authorized_keys file, one line per
+// key. CA keys keep their cert-authority options so the file imports back
+// as the same catalog; account assignments are not included.
+func WriteKeyCatalog(w io.Writer, keys []model.PublicKey) error {
+	for _, k := range keys {
+		k.AssignmentOptions = ""
+		if _, err := fmt.Fprintln(w, k.AuthorizedKeysLine()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+func exportTestKeys() []model.PublicKey {
+	return []model.PublicKey{
+		{ID: 1, Algorithm: "ssh-ed25519", KeyData: "AAAAalice", Comment: "alice@laptop"},
+		{ID: 2, Algorithm: "ssh-rsa", KeyData: "AAAAbob", Comment: "1", IsGlobal: true},
+		{ID: 3, Algorithm: "ssh-ed25519", KeyData: "AAAAca", Comment: "user-ca", IsCA: true, Principals: []string{"deploy"}},
+		{ID: 4, Algorithm: "ssh-ed25519", KeyData: "AAAAanon", AssignmentOptions: `command="true"`},
+	}
+}
+
+func TestFindPublicKeyByIdentifier(t *testing.T) {
+	keys := exportTestKeys()
+	if k, err := FindPublicKeyByIdentifier("alice@laptop", keys); err != nil || k.ID != 1 {
+		t.Fatalf("expected lookup by comment, got %+v, %v", k, err)
+	}
+	// A comment wins over an ID of the same spelling.
+	if k, err := FindPublicKeyByIdentifier("1", keys); err != nil || k.ID != 2 {
+		t.Fatalf("expected the key commented 1, got %+v, %v", k, err)
+	}
+	if k, err := FindPublicKeyByIdentifier("3", keys); err != nil || k.Comment != "user-ca" {
+		t.Fatalf("expected lookup by id, got %+v, %v", k, err)
+	}
+	if _, err := FindPublicKeyByIdentifier("missing", keys); err == nil {
+		t.Fatal("expected an error for an unknown key")
+	}
+}
+
+func TestWriteKeyCatalog(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteKeyCatalog(&buf, exportTestKeys()[:1]); err != nil {
+		t.Fatalf("WriteKeyCatalog: %v", err)
+	}
+	if got := buf.String(); got != "ssh-ed25519 AAAAalice alice@laptop\n" {
+		t.Fatalf("unexpected single key export %q", got)
+	}
+
+	buf.Reset()
+	if err := WriteKeyCatalog(&buf, exportTestKeys()); err != nil {
+		t.Fatalf("WriteKeyCatalog: %v", err)
+	}
+	want := "ssh-ed25519 AAAAalice alice@laptop\n" +
+		"ssh-rsa AAAAbob 1\n" +
+		`cert-authority,principals="deploy" ssh-ed25519 AAAAca user-ca` + "\n" +
+		"ssh-ed25519 AAAAanon\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("unexpected catalog:\n%s\nwant:\n%s", got, want)
+	}
+}
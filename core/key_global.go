@@ -0,0 +1,45 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import "fmt"
+
+// SetKeyGlobal makes key id global (deployed to every account) or removes
+// its global status. Global keys end up in every authorized_keys file, so a
+// change marks all accounts dirty. It reports whether the status changed;
+// asking for the current status is not an error.
+func SetKeyGlobal(st AccountDirtyMarker, km KeyManager, id int, global bool) (bool, error) {
+	keys, err := km.GetAllPublicKeys()
+	if err != nil {
+		return false, fmt.Errorf("failed to load keys: %w", err)
+	}
+	found := false
+	for _, k := range keys {
+		if k.ID != id {
+			continue
+		}
+		found = true
+		if k.IsGlobal == global {
+			return false, nil
+		}
+		break
+	}
+	if !found {
+		return false, fmt.Errorf("key not found: %d", id)
+	}
+
+	if err := km.TogglePublicKeyGlobal(id); err != nil {
+		return false, fmt.Errorf("failed to update key: %w", err)
+	}
+	accounts, err := st.GetAllAccounts()
+	if err != nil {
+		return true, fmt.Errorf("failed to load accounts: %w", err)
+	}
+	for _, acc := range accounts {
+		if err := st.UpdateAccountIsDirty(acc.ID, true); err != nil {
+			return true, fmt.Errorf("failed to mark account %d dirty: %w", acc.ID, err)
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/testutil"
+)
+
+// globalKM flips IsGlobal on its keys like the real store does.
+type globalKM struct {
+	testutil.FakeKeyManager
+	toggled int
+}
+
+func (g *globalKM) TogglePublicKeyGlobal(id int) error {
+	g.toggled++
+	for i := range g.Results {
+		if g.Results[i].ID == id {
+			g.Results[i].IsGlobal = !g.Results[i].IsGlobal
+		}
+	}
+	return nil
+}
+
+func (g *globalKM) GetGlobalPublicKeys() ([]model.PublicKey, error) {
+	var out []model.PublicKey
+	for _, k := range g.Results {
+		if k.IsGlobal {
+			out = append(out, k)
+		}
+	}
+	return out, nil
+}
+
+type dirtyStore struct {
+	accounts []model.Account
+	dirty    map[int]bool
+}
+
+func (d *dirtyStore) GetAllAccounts() ([]model.Account, error) { return d.accounts, nil }
+func (d *dirtyStore) UpdateAccountIsDirty(id int, dirty bool) error {
+	d.dirty[id] = dirty
+	return nil
+}
+
+func TestSetKeyGlobal_MarksAccountsDirty(t *testing.T) {
+	km := &globalKM{}
+	km.Results = []model.PublicKey{{ID: 3, Algorithm: "ssh-ed25519", KeyData: "AAA", Comment: "ops"}}
+	st := &dirtyStore{accounts: []model.Account{{ID: 1}, {ID: 2, IsActive: false}}, dirty: map[int]bool{}}
+
+	changed, err := SetKeyGlobal(st, km, 3, true)
+	if err != nil || !changed {
+		t.Fatalf("SetKeyGlobal = %v, %v; want changed", changed, err)
+	}
+	globals, _ := km.GetGlobalPublicKeys()
+	if len(globals) != 1 || globals[0].ID != 3 {
+		t.Fatalf("expected key 3 among global keys, got %+v", globals)
+	}
+	if !st.dirty[1] || !st.dirty[2] {
+		t.Fatalf("expected every account to be marked dirty, got %v", st.dirty)
+	}
+
+	// Setting the current status again is a no-op.
+	st.dirty = map[int]bool{}
+	changed, err = SetKeyGlobal(st, km, 3, true)
+	if err != nil || changed || km.toggled != 1 || len(st.dirty) != 0 {
+		t.Fatalf("expected no-op, got changed=%v err=%v toggled=%d dirty=%v", changed, err, km.toggled, st.dirty)
+	}
+
+	if _, err := SetKeyGlobal(st, km, 99, true); err == nil {
+		t.Fatal("expected error for unknown key")
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/core/sshkey"
+)
+
+// Severities of key lint findings, most severe first.
+const (
+	LintSeverityError   = "error"
+	LintSeverityWarning = "warning"
+	LintSeverityInfo    = "info"
+)
+
+// Key lint rules.
+const (
+	LintRuleInvalidKey     = "invalid-key"
+	LintRuleDSAKey         = "dsa-key"
+	LintRuleWeakRSA        = "weak-rsa"
+	LintRuleMissingComment = "missing-comment"
+	LintRuleDuplicateKey   = "duplicate-key"
+	LintRuleUnassigned     = "unassigned"
+)
+
+// DefaultMinRSABits is the smallest RSA modulus LintKeys accepts by default.
+const DefaultMinRSABits = 2048
+
+// KeyLintOptions controls LintKeys.
+type KeyLintOptions struct {
+	// MinRSABits flags RSA keys with a smaller modulus. Zero selects
+	// DefaultMinRSABits.
+	MinRSABits int
+}
+
+// KeyLintFinding is one issue found on a stored public key.
+type KeyLintFinding struct {
+	KeyID    int    `json:"key_id"`
+	Comment  string `json:"comment"`
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// LintKeys checks every stored public key for weak algorithms and sizes,
+// missing comments, duplicated key material and missing assignments.
+// Findings are ordered by severity, then key ID.
+func LintKeys(km KeyManager, unassigned UnassignedKeyLister, opts KeyLintOptions) ([]KeyLintFinding, error) {
+	minRSA := opts.MinRSABits
+	if minRSA <= 0 {
+		minRSA = DefaultMinRSABits
+	}
+	keys, err := km.GetAllPublicKeys()
+	if err != nil {
+		return nil, fmt.Errorf("get public keys: %w", err)
+	}
+	orphans, err := unassigned.GetUnassignedPublicKeys()
+	if err != nil {
+		return nil, fmt.Errorf("get unassigned keys: %w", err)
+	}
+
+	var findings []KeyLintFinding
+	add := func(k model.PublicKey, rule, severity, format string, args ...any) {
+		findings = append(findings, KeyLintFinding{KeyID: k.ID, Comment: k.Comment, Rule: rule, Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	byMaterial := make(map[string][]int)
+	for _, k := range keys {
+		alg, data := sshkey.NormalizeKey(k.Algorithm, k.KeyData)
+		byMaterial[alg+" "+data] = append(byMaterial[alg+" "+data], k.ID)
+
+		keyType, bits, err := sshkey.KeyBits(k.KeyData)
+		switch {
+		case err != nil:
+			add(k, LintRuleInvalidKey, LintSeverityError, "key data cannot be parsed: %v", err)
+		case keyType == "ssh-dss":
+			add(k, LintRuleDSAKey, LintSeverityError, "DSA keys are insecure and rejected by current OpenSSH")
+		case keyType == "ssh-rsa" && bits < minRSA:
+			add(k, LintRuleWeakRSA, LintSeverityError, "RSA key has %d bits, below the minimum of %d", bits, minRSA)
+		}
+		if strings.TrimSpace(k.Comment) == "" {
+			add(k, LintRuleMissingComment, LintSeverityWarning, "key has no comment identifying its owner")
+		}
+	}
+	for _, k := range keys {
+		alg, data := sshkey.NormalizeKey(k.Algorithm, k.KeyData)
+		ids := byMaterial[alg+" "+data]
+		if len(ids) < 2 {
+			continue
+		}
+		others := make([]string, 0, len(ids)-1)
+		for _, id := range ids {
+			if id != k.ID {
+				others = append(others, strconv.Itoa(id))
+			}
+		}
+		add(k, LintRuleDuplicateKey, LintSeverityWarning, "same key material as key %s", strings.Join(others, ", "))
+	}
+	for _, k := range orphans {
+		if !k.IsGlobal {
+			add(k, LintRuleUnassigned, LintSeverityInfo, "key is not global and not assigned to any account")
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if ri, rj := lintSeverityRank(findings[i].Severity), lintSeverityRank(findings[j].Severity); ri != rj {
+			return ri < rj
+		}
+		return findings[i].KeyID < findings[j].KeyID
+	})
+	return findings, nil
+}
+
+func lintSeverityRank(severity string) int {
+	switch severity {
+	case LintSeverityError:
+		return 0
+	case LintSeverityWarning:
+		return 1
+	default:
+		return 2
+	}
+}
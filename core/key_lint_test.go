@@ -0,0 +1,104 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/testutil"
+	"golang.org/x/crypto/ssh"
+)
+
+// rsaKeyData generates an RSA public key of the given size as base64 data.
+func rsaKeyData(t *testing.T, bits int) string {
+	t.Helper()
+	priv, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	pub, err := ssh.NewPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatalf("NewPublicKey: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(pub.Marshal())
+}
+
+// dsaKeyData builds a structurally valid ssh-dss key blob; generating real
+// DSA parameters would be slow and the linter only inspects the type.
+func dsaKeyData() string {
+	p := new(big.Int).Lsh(big.NewInt(1), 1023)
+	p.Add(p, big.NewInt(1))
+	q := new(big.Int).Lsh(big.NewInt(1), 159)
+	q.Add(q, big.NewInt(1))
+	blob := ssh.Marshal(struct {
+		Name       string
+		P, Q, G, Y *big.Int
+	}{ssh.KeyAlgoDSA, p, q, big.NewInt(2), big.NewInt(3)})
+	return base64.StdEncoding.EncodeToString(blob)
+}
+
+func TestLintKeys(t *testing.T) {
+	good := craftedKey(t, 1)
+	goodData := base64.StdEncoding.EncodeToString(good.Marshal())
+	km := &testutil.FakeKeyManager{Results: []model.PublicKey{
+		{ID: 1, Algorithm: good.Type(), KeyData: goodData, Comment: "alice", IsGlobal: true},
+		{ID: 2, Algorithm: "ssh-rsa", KeyData: rsaKeyData(t, 1024), Comment: "weak-rsa"},
+		{ID: 3, Algorithm: "ssh-dss", KeyData: dsaKeyData(), Comment: "legacy-dsa"},
+		{ID: 4, Algorithm: good.Type(), KeyData: goodData, Comment: "  "},
+		{ID: 5, Algorithm: "ssh-ed25519", KeyData: "not-base64", Comment: "broken"},
+		{ID: 6, Algorithm: "ssh-rsa", KeyData: rsaKeyData(t, 2048), Comment: "ok-rsa"},
+	}}
+	lister := &fakeUnassignedLister{keys: []model.PublicKey{km.Results[5]}}
+
+	findings, err := LintKeys(km, lister, KeyLintOptions{})
+	if err != nil {
+		t.Fatalf("LintKeys: %v", err)
+	}
+	type hit struct {
+		id       int
+		rule     string
+		severity string
+	}
+	want := []hit{
+		{2, LintRuleWeakRSA, LintSeverityError},
+		{3, LintRuleDSAKey, LintSeverityError},
+		{5, LintRuleInvalidKey, LintSeverityError},
+		{1, LintRuleDuplicateKey, LintSeverityWarning},
+		{4, LintRuleMissingComment, LintSeverityWarning},
+		{4, LintRuleDuplicateKey, LintSeverityWarning},
+		{6, LintRuleUnassigned, LintSeverityInfo},
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("expected %d findings, got %+v", len(want), findings)
+	}
+	for i, w := range want {
+		f := findings[i]
+		if f.KeyID != w.id || f.Rule != w.rule || f.Severity != w.severity {
+			t.Fatalf("finding %d: expected %+v, got %+v", i, w, f)
+		}
+	}
+	if findings[3].Message != "same key material as key 4" {
+		t.Fatalf("unexpected duplicate message: %q", findings[3].Message)
+	}
+
+	// A higher threshold also flags the 2048-bit key.
+	findings, err = LintKeys(km, lister, KeyLintOptions{MinRSABits: 3072})
+	if err != nil {
+		t.Fatalf("LintKeys: %v", err)
+	}
+	weak := 0
+	for _, f := range findings {
+		if f.Rule == LintRuleWeakRSA {
+			weak++
+		}
+	}
+	if weak != 2 {
+		t.Fatalf("expected 2 weak RSA findings at 3072 bits, got %+v", findings)
+	}
+}
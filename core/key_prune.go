@@ -0,0 +1,44 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// PruneUnassignedKeys finds public keys with no account assignments that are
+// not global. When apply is false it only reports them; otherwise each key is
+// deleted through km. It returns the keys that were (or would be) pruned and
+// stops at the first delete error.
+func PruneUnassignedKeys(ctx context.Context, lister UnassignedKeyLister, km KeyManager, apply bool, rep Reporter) ([]model.PublicKey, error) {
+	candidates, err := lister.GetUnassignedPublicKeys()
+	if err != nil {
+		return nil, fmt.Errorf("get unassigned keys: %w", err)
+	}
+	pruned := make([]model.PublicKey, 0, len(candidates))
+	for _, k := range candidates {
+		// Defensive: never prune global keys even if a store returns them.
+		if k.IsGlobal {
+			continue
+		}
+		if !apply {
+			pruned = append(pruned, k)
+			if rep != nil {
+				rep.Reportf("Would prune key %d: %s\n", k.ID, k.Comment)
+			}
+			continue
+		}
+		if err := km.DeletePublicKey(k.ID); err != nil {
+			return pruned, fmt.Errorf("delete key %d (%s): %w", k.ID, k.Comment, err)
+		}
+		pruned = append(pruned, k)
+		if rep != nil {
+			rep.Reportf("Pruned key %d: %s\n", k.ID, k.Comment)
+		}
+	}
+	return pruned, nil
+}
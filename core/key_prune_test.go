@@ -0,0 +1,88 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+type fakeUnassignedLister struct {
+	keys []model.PublicKey
+	err  error
+}
+
+func (f *fakeUnassignedLister) GetUnassignedPublicKeys() ([]model.PublicKey, error) {
+	return f.keys, f.err
+}
+
+type pruneKeyManager struct {
+	fmKeyManager
+	deleted []int
+	failID  int
+}
+
+func (p *pruneKeyManager) DeletePublicKey(id int) error {
+	if id == p.failID {
+		return errors.New("boom")
+	}
+	p.deleted = append(p.deleted, id)
+	return nil
+}
+
+type bufReporter struct{ lines []string }
+
+func (b *bufReporter) Reportf(format string, args ...any) {
+	b.lines = append(b.lines, fmt.Sprintf(format, args...))
+}
+
+func TestPruneUnassignedKeys_DryRunDeletesNothing(t *testing.T) {
+	lister := &fakeUnassignedLister{keys: []model.PublicKey{{ID: 1, Comment: "old"}, {ID: 2, Comment: "test"}}}
+	km := &pruneKeyManager{}
+	rep := &bufReporter{}
+
+	pruned, err := PruneUnassignedKeys(context.TODO(), lister, km, false, rep)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pruned) != 2 || len(km.deleted) != 0 {
+		t.Fatalf("expected 2 candidates and no deletes, got %d / %v", len(pruned), km.deleted)
+	}
+	if len(rep.lines) != 2 {
+		t.Fatalf("expected a report line per key, got %v", rep.lines)
+	}
+}
+
+func TestPruneUnassignedKeys_ApplySkipsGlobal(t *testing.T) {
+	lister := &fakeUnassignedLister{keys: []model.PublicKey{{ID: 1, Comment: "old"}, {ID: 2, Comment: "g", IsGlobal: true}}}
+	km := &pruneKeyManager{}
+
+	pruned, err := PruneUnassignedKeys(context.TODO(), lister, km, true, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pruned) != 1 || len(km.deleted) != 1 || km.deleted[0] != 1 {
+		t.Fatalf("expected only key 1 pruned, got pruned=%v deleted=%v", pruned, km.deleted)
+	}
+}
+
+func TestPruneUnassignedKeys_Errors(t *testing.T) {
+	if _, err := PruneUnassignedKeys(context.TODO(), &fakeUnassignedLister{err: errors.New("db")}, &pruneKeyManager{}, true, nil); err == nil {
+		t.Fatalf("expected lister error")
+	}
+
+	lister := &fakeUnassignedLister{keys: []model.PublicKey{{ID: 1}, {ID: 2}, {ID: 3}}}
+	km := &pruneKeyManager{failID: 2}
+	pruned, err := PruneUnassignedKeys(context.TODO(), lister, km, true, nil)
+	if err == nil {
+		t.Fatalf("expected delete error")
+	}
+	if len(pruned) != 1 || len(km.deleted) != 1 {
+		t.Fatalf("expected to stop after first failure, got pruned=%v deleted=%v", pruned, km.deleted)
+	}
+}
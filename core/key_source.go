@@ -0,0 +1,223 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/toeirei/keymaster/core/sshkey"
+)
+
+// ParsedKey is a public key returned by a KeySource.
+type ParsedKey struct {
+	Algorithm string
+	KeyData   string
+	Comment   string
+}
+
+// KeySource fetches the authoritative public keys of an identifier (a user
+// name, a group, a path, ...) from an external system such as LDAP, an
+// identity provider or an internal API.
+//
+// Out-of-tree providers implement KeySource and register it under a name,
+// usually from an init function:
+//
+//	func init() {
+//		if err := core.RegisterKeySource("ldap", &ldapSource{}); err != nil {
+//			panic(err)
+//		}
+//	}
+//
+// after which `keymaster import --source ldap:alice` calls FetchKeys("alice").
+// Keys without a comment are skipped on import, so providers should fill in
+// a comment identifying the owner.
+type KeySource interface {
+	FetchKeys(identifier string) ([]ParsedKey, error)
+}
+
+// Names of the built-in key sources.
+const (
+	KeySourceFile   = "file"
+	KeySourceGitHub = "github"
+)
+
+var (
+	keySourcesMu sync.RWMutex
+	keySources   = map[string]KeySource{
+		KeySourceFile:   fileKeySource{},
+		KeySourceGitHub: githubKeySource{},
+	}
+)
+
+var keySourceNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// RegisterKeySource makes src available under name. Names are lowercase
+// letters, digits, '-' and '_'; registering a name twice is an error.
+func RegisterKeySource(name string, src KeySource) error {
+	if !keySourceNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid key source name %q", name)
+	}
+	if src == nil {
+		return fmt.Errorf("key source %q is nil", name)
+	}
+	keySourcesMu.Lock()
+	defer keySourcesMu.Unlock()
+	if _, ok := keySources[name]; ok {
+		return fmt.Errorf("key source %q is already registered", name)
+	}
+	keySources[name] = src
+	return nil
+}
+
+// UnregisterKeySource removes the key source registered under name, if any.
+func UnregisterKeySource(name string) {
+	keySourcesMu.Lock()
+	defer keySourcesMu.Unlock()
+	delete(keySources, name)
+}
+
+// KeySourceNames returns the names of the registered key sources, sorted.
+func KeySourceNames() []string {
+	keySourcesMu.RLock()
+	defer keySourcesMu.RUnlock()
+	names := make([]string, 0, len(keySources))
+	for name := range keySources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FetchKeysFromSource resolves a "<source>:<identifier>" spec, e.g.
+// "github:alice" or "file:/etc/keys/alice.pub", and fetches its keys from
+// the registered source.
+func FetchKeysFromSource(spec string) ([]ParsedKey, error) {
+	name, identifier, ok := strings.Cut(spec, ":")
+	name = strings.ToLower(strings.TrimSpace(name))
+	identifier = strings.TrimSpace(identifier)
+	if !ok || name == "" || identifier == "" {
+		return nil, fmt.Errorf("invalid key source %q (want <source>:<identifier>)", spec)
+	}
+	keySourcesMu.RLock()
+	src, found := keySources[name]
+	keySourcesMu.RUnlock()
+	if !found {
+		return nil, fmt.Errorf("unknown key source %q (available: %s)", name, strings.Join(KeySourceNames(), ", "))
+	}
+	keys, err := src.FetchKeys(identifier)
+	if err != nil {
+		return nil, fmt.Errorf("fetch keys from %s: %w", name, err)
+	}
+	return keys, nil
+}
+
+// ImportFromKeySource fetches the keys of spec (see FetchKeysFromSource) and
+// imports them through km with the same rules as ImportAuthorizedKeys.
+func ImportFromKeySource(ctx context.Context, spec string, km KeyManager, rep Reporter) (imported int, skipped int, err error) {
+	res, err := ImportFromKeySourceWithOptions(ctx, spec, km, rep, ImportOptions{})
+	return res.Imported, res.Skipped, err
+}
+
+// ImportFromKeySourceWithOptions is ImportFromKeySource honouring opts.
+func ImportFromKeySourceWithOptions(ctx context.Context, spec string, km KeyManager, rep Reporter, opts ImportOptions) (res ImportResult, err error) {
+	keys, err := FetchKeysFromSource(spec)
+	if err != nil {
+		return res, err
+	}
+	for _, k := range keys {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		alg, keyData := sshkey.NormalizeKey(k.Algorithm, k.KeyData)
+		res.count(importKey(km, rep, alg, keyData, sshkey.NormalizeComment(k.Comment), opts))
+	}
+	return res, nil
+}
+
+// parseKeyLines parses authorized_keys formatted content, skipping blank
+// lines and comments. Invalid lines are reported with their line number.
+func parseKeyLines(content []byte) ([]ParsedKey, error) {
+	var keys []ParsedKey
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		alg, keyData, comment, err := sshkey.Normalize(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		keys = append(keys, ParsedKey{Algorithm: alg, KeyData: keyData, Comment: comment})
+	}
+	return keys, scanner.Err()
+}
+
+// fileKeySource reads keys from a local authorized_keys formatted file; the
+// identifier is its path.
+type fileKeySource struct{}
+
+func (fileKeySource) FetchKeys(path string) ([]ParsedKey, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	content, err := sshkey.ReadAuthorizedKeys(f)
+	if err != nil {
+		return nil, err
+	}
+	return parseKeyLines(content)
+}
+
+// githubKeysBaseURL is the server queried by the github key source. Tests
+// point it at a local server.
+var githubKeysBaseURL = "https://github.com"
+
+// githubKeySource fetches the public keys a GitHub user publishes at
+// https://github.com/<user>.keys. GitHub omits key comments, so keys are
+// labelled "<user>@github".
+type githubKeySource struct{}
+
+func (githubKeySource) FetchKeys(user string) ([]ParsedKey, error) {
+	if strings.ContainsAny(user, "/?#") {
+		return nil, fmt.Errorf("invalid GitHub user %q", user)
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(githubKeysBaseURL + "/" + url.PathEscape(user) + ".keys")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("GitHub returned %s for user %q", resp.Status, user)
+	}
+	content, err := sshkey.ReadAuthorizedKeys(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := parseKeyLines(content)
+	if err != nil {
+		return nil, err
+	}
+	for i := range keys {
+		if keys[i].Comment == "" {
+			keys[i].Comment = user + "@github"
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeKeySource returns fixed keys per identifier and records the lookups.
+type fakeKeySource struct {
+	keys      map[string][]ParsedKey
+	requested []string
+}
+
+func (f *fakeKeySource) FetchKeys(identifier string) ([]ParsedKey, error) {
+	f.requested = append(f.requested, identifier)
+	keys, ok := f.keys[identifier]
+	if !ok {
+		return nil, errors.New("no such user")
+	}
+	return keys, nil
+}
+
+func registerFakeKeySource(t *testing.T, name string, src KeySource) {
+	t.Helper()
+	if err := RegisterKeySource(name, src); err != nil {
+		t.Fatalf("register %s: %v", name, err)
+	}
+	t.Cleanup(func() { UnregisterKeySource(name) })
+}
+
+func TestImportFromKeySource_FakeSource(t *testing.T) {
+	src := &fakeKeySource{keys: map[string][]ParsedKey{
+		"alice": {
+			{Algorithm: "ssh-ed25519", KeyData: "AAAAC3", Comment: "  alice  laptop "},
+			{Algorithm: "ssh-ed25519", KeyData: "AAAAC4", Comment: "dup"},
+			{Algorithm: "ssh-ed25519", KeyData: "AAAAC5"},
+		},
+	}}
+	registerFakeKeySource(t, "ldap", src)
+
+	km := &fKM{}
+	imported, skipped, err := ImportFromKeySource(context.TODO(), "LDAP:alice", km, nil)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if imported != 1 || skipped != 2 {
+		t.Fatalf("expected 1 imported and 2 skipped, got %d/%d", imported, skipped)
+	}
+	if !reflect.DeepEqual(km.added, []string{"alice laptop"}) || !reflect.DeepEqual(src.requested, []string{"alice"}) {
+		t.Fatalf("unexpected import: added %v, requested %v", km.added, src.requested)
+	}
+
+	if _, _, err := ImportFromKeySource(context.TODO(), "ldap:bob", km, nil); err == nil || !strings.Contains(err.Error(), "no such user") {
+		t.Fatalf("expected the source error to be returned, got %v", err)
+	}
+}
+
+func TestFetchKeysFromSource_InvalidSpec(t *testing.T) {
+	for _, spec := range []string{"", "alice", "ldap:", ":alice"} {
+		if _, err := FetchKeysFromSource(spec); err == nil || !strings.Contains(err.Error(), "invalid key source") {
+			t.Errorf("FetchKeysFromSource(%q): expected an invalid spec error, got %v", spec, err)
+		}
+	}
+	if _, err := FetchKeysFromSource("okta:alice"); err == nil || !strings.Contains(err.Error(), "available: file, github") {
+		t.Fatalf("expected unknown source error listing the sources, got %v", err)
+	}
+}
+
+func TestRegisterKeySource_Validation(t *testing.T) {
+	if err := RegisterKeySource("file", &fakeKeySource{}); err == nil {
+		t.Fatal("expected registering a built-in name twice to fail")
+	}
+	if err := RegisterKeySource("Bad Name", &fakeKeySource{}); err == nil {
+		t.Fatal("expected an invalid name to be rejected")
+	}
+	if err := RegisterKeySource("nil-source", nil); err == nil {
+		t.Fatal("expected a nil source to be rejected")
+	}
+	if names := KeySourceNames(); !reflect.DeepEqual(names, []string{"file", "github"}) {
+		t.Fatalf("unexpected registered sources: %v", names)
+	}
+}
+
+func TestFileKeySource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alice.pub")
+	content := "# alice's keys\n\nssh-ed25519 AAAAC3 alice@laptop\nfrom=\"10.0.0.0/8\" ssh-ed25519 AAAAC4 alice@ci\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := FetchKeysFromSource("file:" + path)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	want := []ParsedKey{
+		{Algorithm: "ssh-ed25519", KeyData: "AAAAC3", Comment: "alice@laptop"},
+		{Algorithm: "ssh-ed25519", KeyData: "AAAAC4", Comment: "alice@ci"},
+	}
+	if !reflect.DeepEqual(keys, want) {
+		t.Fatalf("expected %v, got %v", want, keys)
+	}
+
+	if err := os.WriteFile(path, []byte("ssh-ed25519 AAAAC3 ok\nnot a key\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FetchKeysFromSource("file:" + path); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("expected an error naming line 2, got %v", err)
+	}
+}
+
+func TestGitHubKeySource(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/alice.keys" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("ssh-ed25519 AAAAC3\nssh-ed25519 AAAAC4 named\n"))
+	}))
+	defer srv.Close()
+	orig := githubKeysBaseURL
+	githubKeysBaseURL = srv.URL
+	t.Cleanup(func() { githubKeysBaseURL = orig })
+
+	keys, err := FetchKeysFromSource("github:alice")
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if len(keys) != 2 || keys[0].Comment != "alice@github" || keys[1].Comment != "named" {
+		t.Fatalf("unexpected keys: %+v", keys)
+	}
+
+	if _, err := FetchKeysFromSource("github:bob"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("expected a 404 error, got %v", err)
+	}
+	if _, err := FetchKeysFromSource("github:../alice"); err == nil {
+		t.Fatal("expected a user containing a slash to be rejected")
+	}
+}
@@ -10,13 +10,40 @@ import (
 	"time"
 
 	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/core/sshkey"
 )
 
 // BuildAuthorizedKeysContent constructs the authorized_keys content given the
-// system key and lists of global and account-specific public keys. This
-// function is pure and deterministic; callers must provide keys fetched from
-// their data stores.
+// system key and lists of global and account-specific public keys. Expired
+// keys and keys whose algorithm is excluded by sshkey.SetAllowedAlgorithms
+// are left out. Callers must provide keys fetched from their data stores.
 func BuildAuthorizedKeysContent(systemKey *model.SystemKey, globalKeys, accountKeys []model.PublicKey) (string, error) {
+	return buildAuthorizedKeysContent(systemKey, true, globalKeys, accountKeys)
+}
+
+// BuildAuthorizedKeysContentWithoutSystemKey is BuildAuthorizedKeysContent
+// for hosts whose system key line is owned by another tool. The serial
+// header is kept so serial audits keep working.
+func BuildAuthorizedKeysContentWithoutSystemKey(systemKey *model.SystemKey, globalKeys, accountKeys []model.PublicKey) (string, error) {
+	return buildAuthorizedKeysContent(systemKey, false, globalKeys, accountKeys)
+}
+
+// GlobalKeysForAccount returns the global keys rendered for account, leaving
+// out the ones it excludes. A nil account receives every global key.
+func GlobalKeysForAccount(account *model.Account, globalKeys []model.PublicKey) []model.PublicKey {
+	if account == nil || len(account.ExcludedGlobalKeys) == 0 {
+		return globalKeys
+	}
+	out := make([]model.PublicKey, 0, len(globalKeys))
+	for _, k := range globalKeys {
+		if !account.ExcludesGlobalKey(k.ID) {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+func buildAuthorizedKeysContent(systemKey *model.SystemKey, includeSystemKey bool, globalKeys, accountKeys []model.PublicKey) (string, error) {
 	var sb strings.Builder
 
 	if systemKey == nil {
@@ -24,15 +51,21 @@ func BuildAuthorizedKeysContent(systemKey *model.SystemKey, globalKeys, accountK
 	}
 
 	// Header and restricted system key
-	fmt.Fprintf(&sb, "# Keymaster Managed Keys (Serial: %d)\n", systemKey.Serial)
-	restrictedSystemKey := fmt.Sprintf("%s %s", "command=\"internal-sftp\",no-port-forwarding,no-x11-forwarding,no-agent-forwarding,no-pty", systemKey.PublicKey)
-	sb.WriteString(restrictedSystemKey)
+	fmt.Fprintf(&sb, "# Keymaster Managed Keys (Serial: %d)", systemKey.Serial)
+	if includeSystemKey {
+		restrictedSystemKey := fmt.Sprintf("%s %s", "command=\"internal-sftp\",no-port-forwarding,no-x11-forwarding,no-agent-forwarding,no-pty", systemKey.PublicKey)
+		sb.WriteString("\n")
+		sb.WriteString(restrictedSystemKey)
+	}
 
-	// Helper to filter expired keys
+	// Helper to filter expired and disallowed keys
 	filterExpired := func(keys []model.PublicKey) []model.PublicKey {
 		var out []model.PublicKey
 		now := time.Now().UTC()
 		for _, k := range keys {
+			if !sshkey.IsAlgorithmAllowed(k.Algorithm) {
+				continue
+			}
 			if k.ExpiresAt.IsZero() || k.ExpiresAt.After(now) {
 				out = append(out, k)
 			}
@@ -51,18 +84,11 @@ func BuildAuthorizedKeysContent(systemKey *model.SystemKey, globalKeys, accountK
 	}
 	allMap := make(map[int]keyInfo)
 
-	formatKey := func(k model.PublicKey) string {
-		if k.Comment != "" {
-			return fmt.Sprintf("%s %s %s", k.Algorithm, k.KeyData, k.Comment)
-		}
-		return fmt.Sprintf("%s %s", k.Algorithm, k.KeyData)
-	}
-
 	for _, k := range globalKeys {
-		allMap[k.ID] = keyInfo{id: k.ID, line: formatKey(k), comment: k.Comment}
+		allMap[k.ID] = keyInfo{id: k.ID, line: k.AuthorizedKeysLine(), comment: k.Comment}
 	}
 	for _, k := range accountKeys {
-		allMap[k.ID] = keyInfo{id: k.ID, line: formatKey(k), comment: k.Comment}
+		allMap[k.ID] = keyInfo{id: k.ID, line: k.AuthorizedKeysLine(), comment: k.Comment}
 	}
 
 	var sorted []keyInfo
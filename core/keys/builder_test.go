@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/core/sshkey"
 )
 
 func TestBuildAuthorizedKeysContent_NoSystemKey(t *testing.T) {
@@ -60,6 +61,27 @@ func TestBuildAuthorizedKeysContent_BasicAndExpiryDedupSort(t *testing.T) {
 	}
 }
 
+func TestBuildAuthorizedKeysContentWithoutSystemKey(t *testing.T) {
+	sys := &model.SystemKey{Serial: 4, PublicKey: "ssh-ed25519 SYSKEY"}
+	user := model.PublicKey{ID: 1, Algorithm: "ssh-ed25519", KeyData: "UDATA", Comment: "alice"}
+
+	with, err := BuildAuthorizedKeysContent(sys, nil, []model.PublicKey{user})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	without, err := BuildAuthorizedKeysContentWithoutSystemKey(sys, nil, []model.PublicKey{user})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(with, "SYSKEY") || strings.Contains(without, "SYSKEY") {
+		t.Fatalf("system key should only be rendered when managed:\nwith: %q\nwithout: %q", with, without)
+	}
+	want := "# Keymaster Managed Keys (Serial: 4)\n\n# User Keys\nssh-ed25519 UDATA alice\n"
+	if without != want {
+		t.Fatalf("unexpected content without system key:\n got: %q\nwant: %q", without, want)
+	}
+}
+
 func TestSSHKeyTypeToVerifyCommand(t *testing.T) {
 	cases := map[string]string{
 		"ssh-rsa":             "ssh-keygen -lf /etc/ssh/ssh_host_rsa_key.pub",
@@ -75,3 +97,57 @@ func TestSSHKeyTypeToVerifyCommand(t *testing.T) {
 		}
 	}
 }
+
+func TestBuildAuthorizedKeysContent_SkipsDisallowedAlgorithms(t *testing.T) {
+	sshkey.SetAllowedAlgorithms([]string{"ssh-ed25519"})
+	t.Cleanup(func() { sshkey.SetAllowedAlgorithms(nil) })
+
+	sys := &model.SystemKey{Serial: 1, PublicKey: "SYSKEY"}
+	allowed := model.PublicKey{ID: 1, Algorithm: "ssh-ed25519", KeyData: "EDDATA", Comment: "ed"}
+	grandfathered := model.PublicKey{ID: 2, Algorithm: "ssh-rsa", KeyData: "RSADATA", Comment: "legacy"}
+
+	out, err := BuildAuthorizedKeysContent(sys, []model.PublicKey{grandfathered}, []model.PublicKey{allowed})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(out, "RSADATA") {
+		t.Fatalf("disallowed key rendered: %q", out)
+	}
+	if !strings.Contains(out, "EDDATA") {
+		t.Fatalf("allowed key missing: %q", out)
+	}
+}
+
+func TestBuildAuthorizedKeysContent_RendersCertAuthority(t *testing.T) {
+	sys := &model.SystemKey{Serial: 1, PublicKey: "SYSKEY"}
+	ca := model.PublicKey{ID: 1, Algorithm: "ssh-ed25519", KeyData: "CADATA", Comment: "user-ca", IsCA: true, Principals: []string{"deploy", "ops"}}
+	open := model.PublicKey{ID: 2, Algorithm: "ssh-ed25519", KeyData: "OPENCA", Comment: "open-ca", IsCA: true}
+
+	out, err := BuildAuthorizedKeysContent(sys, []model.PublicKey{ca}, []model.PublicKey{open})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, "\ncert-authority,principals=\"deploy,ops\" ssh-ed25519 CADATA user-ca\n") {
+		t.Fatalf("restricted CA line missing: %q", out)
+	}
+	if !strings.Contains(out, "\ncert-authority ssh-ed25519 OPENCA open-ca\n") {
+		t.Fatalf("unrestricted CA line missing: %q", out)
+	}
+}
+
+func TestBuildAuthorizedKeysContent_RendersAssignmentOptions(t *testing.T) {
+	sys := &model.SystemKey{Serial: 1, PublicKey: "SYSKEY"}
+	restricted := model.PublicKey{ID: 1, Algorithm: "ssh-ed25519", KeyData: "RSYNC", Comment: "backup", AssignmentOptions: `command="rsync --server",no-pty`}
+	ca := model.PublicKey{ID: 2, Algorithm: "ssh-ed25519", KeyData: "CADATA", Comment: "user-ca", IsCA: true, AssignmentOptions: `from="10.0.0.0/8"`}
+
+	out, err := BuildAuthorizedKeysContent(sys, nil, []model.PublicKey{restricted, ca})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, "\ncommand=\"rsync --server\",no-pty ssh-ed25519 RSYNC backup\n") {
+		t.Fatalf("restricted key line missing: %q", out)
+	}
+	if !strings.Contains(out, "\ncert-authority,from=\"10.0.0.0/8\" ssh-ed25519 CADATA user-ca\n") {
+		t.Fatalf("CA line with assignment options missing: %q", out)
+	}
+}
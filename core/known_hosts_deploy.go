@@ -0,0 +1,93 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// KnownHostsDeployer is implemented by RemoteDeployers that can write a
+// known_hosts file next to authorized_keys.
+type KnownHostsDeployer interface {
+	DeployKnownHosts(content string) error
+}
+
+// SetDeployKnownHosts turns known_hosts deployment on or off for account id.
+// The file is written by the account's next deploy.
+func SetDeployKnownHosts(st DeployKnownHostsStore, id int, enabled bool) error {
+	accounts, err := st.GetAllAccounts()
+	if err != nil {
+		return fmt.Errorf("failed to load accounts: %w", err)
+	}
+	for _, acc := range accounts {
+		if acc.ID != id {
+			continue
+		}
+		if acc.DeployKnownHosts == enabled {
+			return nil
+		}
+		if err := st.SetAccountDeployKnownHosts(id, enabled); err != nil {
+			return fmt.Errorf("failed to save known_hosts setting: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("account not found: %d", id)
+}
+
+// RenderKnownHosts renders stored known host entries as a known_hosts file.
+// Hosts on port 22 are written bare and others as [host]:port, which is how
+// ssh looks them up. Entries are ordered by host; entries without a key are
+// skipped.
+func RenderKnownHosts(entries []model.KnownHost) string {
+	lines := make([]string, 0, len(entries))
+	for _, e := range entries {
+		key := strings.TrimSpace(e.Key)
+		if key == "" {
+			continue
+		}
+		lines = append(lines, knownHostsPattern(e.Hostname)+" "+key)
+	}
+	sort.Strings(lines)
+
+	var b strings.Builder
+	b.WriteString("# Keymaster Managed known_hosts\n")
+	for _, line := range lines {
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// knownHostsPattern returns the known_hosts host pattern for a stored,
+// canonical host:port.
+func knownHostsPattern(hostport string) string {
+	host, port, err := net.SplitHostPort(hostport)
+	if err != nil {
+		return hostport
+	}
+	if port == "" || port == "22" {
+		return host
+	}
+	return "[" + host + "]:" + port
+}
+
+// renderKnownHostsForDeploy renders the known_hosts file deployed to accounts
+// with DeployKnownHosts set.
+func renderKnownHostsForDeploy() (string, error) {
+	l := DefaultKnownHostLister()
+	if l == nil {
+		return "", errors.New("cannot render known_hosts: no known host lister configured")
+	}
+	entries, err := l.GetAllKnownHosts()
+	if err != nil {
+		return "", fmt.Errorf("load known hosts: %w", err)
+	}
+	return RenderKnownHosts(entries), nil
+}
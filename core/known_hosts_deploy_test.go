@@ -0,0 +1,141 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// knownHostsDeployer is a cmdDeployer that also accepts known_hosts writes.
+type knownHostsDeployer struct {
+	cmdDeployer
+	knownHosts []string
+	err        error
+}
+
+func (k *knownHostsDeployer) DeployKnownHosts(content string) error {
+	k.knownHosts = append(k.knownHosts, content)
+	return k.err
+}
+
+type fakeKnownHostLister []model.KnownHost
+
+func (l fakeKnownHostLister) GetAllKnownHosts() ([]model.KnownHost, error) { return l, nil }
+
+func withKnownHosts(t *testing.T, entries ...model.KnownHost) {
+	t.Helper()
+	orig := DefaultKnownHostLister()
+	SetDefaultKnownHostLister(fakeKnownHostLister(entries))
+	t.Cleanup(func() { SetDefaultKnownHostLister(orig) })
+}
+
+func TestRenderKnownHosts(t *testing.T) {
+	got := RenderKnownHosts([]model.KnownHost{
+		{Hostname: "web-02:2222", Key: "ssh-ed25519 BBBB\n"},
+		{Hostname: "web-01:22", Key: "ssh-ed25519 AAAA\n"},
+		{Hostname: "[2001:db8::1]:22", Key: "ssh-rsa CCCC"},
+		{Hostname: "gone:22", Key: ""},
+	})
+	want := "# Keymaster Managed known_hosts\n" +
+		"2001:db8::1 ssh-rsa CCCC\n" +
+		"[web-02]:2222 ssh-ed25519 BBBB\n" +
+		"web-01 ssh-ed25519 AAAA\n"
+	if got != want {
+		t.Fatalf("RenderKnownHosts =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRunDeploymentForAccount_DeploysKnownHosts(t *testing.T) {
+	fake := &knownHostsDeployer{}
+	acct, serial := setupPostDeployTest(t, fake, DeployOptions{})
+	withKnownHosts(t, model.KnownHost{Hostname: "db-01:22", Key: "ssh-ed25519 AAAA\n"})
+	acct.DeployKnownHosts = true
+
+	if err := RunDeploymentForAccount(acct, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fake.content) != 1 {
+		t.Fatalf("expected authorized_keys to be written once, got %d", len(fake.content))
+	}
+	if len(fake.knownHosts) != 1 || !strings.Contains(fake.knownHosts[0], "db-01 ssh-ed25519 AAAA\n") {
+		t.Fatalf("expected the rendered known_hosts to be written, got %q", fake.knownHosts)
+	}
+	if got := accountSerial(t, acct.ID); got != serial {
+		t.Fatalf("expected serial %d, got %d", serial, got)
+	}
+}
+
+func TestRunDeploymentForAccount_KnownHostsOffByDefault(t *testing.T) {
+	fake := &knownHostsDeployer{}
+	acct, _ := setupPostDeployTest(t, fake, DeployOptions{})
+	withKnownHosts(t, model.KnownHost{Hostname: "db-01:22", Key: "ssh-ed25519 AAAA"})
+
+	if err := RunDeploymentForAccount(acct, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fake.knownHosts) != 0 {
+		t.Fatalf("expected no known_hosts write, got %q", fake.knownHosts)
+	}
+}
+
+func TestRunDeploymentForAccount_KnownHostsWriteFailureKeepsSerial(t *testing.T) {
+	fake := &knownHostsDeployer{err: errors.New("permission denied")}
+	acct, serial := setupPostDeployTest(t, fake, DeployOptions{})
+	withKnownHosts(t)
+	acct.DeployKnownHosts = true
+
+	err := RunDeploymentForAccount(acct, false)
+	if err == nil || !strings.Contains(err.Error(), "write known_hosts: permission denied") {
+		t.Fatalf("expected the known_hosts failure to be reported, got %v", err)
+	}
+	if got := accountSerial(t, acct.ID); got != serial {
+		t.Fatalf("expected serial %d to be recorded after authorized_keys was written, got %d", serial, got)
+	}
+}
+
+func TestRunDeploymentForAccount_KnownHostsUnsupportedDeployer(t *testing.T) {
+	fake := &cmdDeployer{}
+	acct, _ := setupPostDeployTest(t, fake, DeployOptions{})
+	withKnownHosts(t)
+	acct.DeployKnownHosts = true
+
+	if err := RunDeploymentForAccount(acct, false); err == nil {
+		t.Fatal("expected an error for a deployer without known_hosts support")
+	}
+	if len(fake.content) != 0 {
+		t.Fatal("expected nothing to be written")
+	}
+}
+
+func TestSetDeployKnownHosts(t *testing.T) {
+	st := &deployKnownHostsStore{accounts: []model.Account{{ID: 1}}}
+	if err := SetDeployKnownHosts(st, 1, true); err != nil {
+		t.Fatalf("SetDeployKnownHosts: %v", err)
+	}
+	if !st.accounts[0].DeployKnownHosts {
+		t.Fatal("expected known_hosts deployment to be enabled")
+	}
+	if err := SetDeployKnownHosts(st, 2, true); err == nil {
+		t.Fatal("expected an unknown account to be rejected")
+	}
+}
+
+type deployKnownHostsStore struct {
+	accounts []model.Account
+}
+
+func (s *deployKnownHostsStore) GetAllAccounts() ([]model.Account, error) { return s.accounts, nil }
+
+func (s *deployKnownHostsStore) SetAccountDeployKnownHosts(id int, enabled bool) error {
+	for i := range s.accounts {
+		if s.accounts[i].ID == id {
+			s.accounts[i].DeployKnownHosts = enabled
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"os"
+
+	clog "github.com/charmbracelet/log"
+	"github.com/toeirei/keymaster/core/logging"
+)
+
+// charmLogger is the default Logger. It writes through logging.L so level
+// and output changes made there (e.g. by --verbose) apply immediately.
+type charmLogger struct{}
+
+func (charmLogger) Debug(msg string, keyvals ...any) { logging.L.Debug(msg, keyvals...) }
+func (charmLogger) Info(msg string, keyvals ...any)  { logging.L.Info(msg, keyvals...) }
+func (charmLogger) Warn(msg string, keyvals ...any)  { logging.L.Warn(msg, keyvals...) }
+func (charmLogger) Error(msg string, keyvals ...any) { logging.L.Error(msg, keyvals...) }
+
+// nopLogger discards every record.
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...any) {}
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Warn(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
+
+var defaultLogger Logger = charmLogger{}
+
+// DefaultLogger returns the package-level Logger used by core operations.
+func DefaultLogger() Logger { return defaultLogger }
+
+// SetDefaultLogger sets the package-level Logger. A nil logger discards all
+// records.
+func SetDefaultLogger(l Logger) {
+	if l == nil {
+		l = nopLogger{}
+	}
+	defaultLogger = l
+}
+
+// ConfigureLogger rebuilds the process logger for the given output format
+// ("text" or "json") and verbosity, writing to stderr. The result backs both
+// DefaultLogger and the charmbracelet package-level logger so every record
+// shares the same format.
+func ConfigureLogger(format string, verbose bool) error {
+	l, err := logging.New(os.Stderr, format, verbose)
+	if err != nil {
+		return err
+	}
+	logging.L = l
+	clog.SetDefault(l)
+	return nil
+}
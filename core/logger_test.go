@@ -0,0 +1,131 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+type logRecord struct {
+	level  string
+	msg    string
+	fields map[string]any
+}
+
+// recordingLogger captures emitted records for assertions.
+type recordingLogger struct {
+	mu      sync.Mutex
+	records []logRecord
+}
+
+func (l *recordingLogger) add(level, msg string, keyvals []any) {
+	fields := make(map[string]any)
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		if k, ok := keyvals[i].(string); ok {
+			fields[k] = keyvals[i+1]
+		}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records = append(l.records, logRecord{level: level, msg: msg, fields: fields})
+}
+
+func (l *recordingLogger) Debug(msg string, keyvals ...any) { l.add("debug", msg, keyvals) }
+func (l *recordingLogger) Info(msg string, keyvals ...any)  { l.add("info", msg, keyvals) }
+func (l *recordingLogger) Warn(msg string, keyvals ...any)  { l.add("warn", msg, keyvals) }
+func (l *recordingLogger) Error(msg string, keyvals ...any) { l.add("error", msg, keyvals) }
+
+func (l *recordingLogger) find(level, msg string) *logRecord {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := range l.records {
+		if l.records[i].level == level && l.records[i].msg == msg {
+			return &l.records[i]
+		}
+	}
+	return nil
+}
+
+// failingDeployDM fails deployments for the account with ID failID.
+type failingDeployDM struct {
+	fakeDM
+	failID int
+}
+
+func (f *failingDeployDM) DeployForAccount(account model.Account, keepFile bool) error {
+	if account.ID == f.failID {
+		return errors.New("boom")
+	}
+	return nil
+}
+
+func withRecordingLogger(t *testing.T) *recordingLogger {
+	t.Helper()
+	rec := &recordingLogger{}
+	prev := DefaultLogger()
+	SetDefaultLogger(rec)
+	t.Cleanup(func() { SetDefaultLogger(prev) })
+	return rec
+}
+
+func TestDeployAccounts_EmitsLogRecords(t *testing.T) {
+	rec := withRecordingLogger(t)
+
+	ok := model.Account{ID: 1, Username: "a", Hostname: "h1", IsActive: true, DeployEnabled: true}
+	bad := model.Account{ID: 2, Username: "b", Hostname: "h2", IsActive: true, DeployEnabled: true}
+	st := &simpleFakeStore{accounts: []model.Account{ok, bad}}
+
+	if _, err := DeployAccounts(context.TODO(), st, &failingDeployDM{failID: 2}, nil, nil); err != nil {
+		t.Fatalf("DeployAccounts: %v", err)
+	}
+
+	start := rec.find("debug", "starting deployment")
+	if start == nil || start.fields["accounts"] != 2 {
+		t.Fatalf("missing start record: %+v", rec.records)
+	}
+	if r := rec.find("debug", "deployment succeeded"); r == nil || r.fields["account"] != ok.String() {
+		t.Fatalf("missing success record: %+v", rec.records)
+	}
+	failed := rec.find("error", "deployment failed")
+	if failed == nil || failed.fields["account"] != bad.String() {
+		t.Fatalf("missing failure record: %+v", rec.records)
+	}
+	if err, _ := failed.fields["err"].(error); err == nil || err.Error() != "boom" {
+		t.Fatalf("expected err field on failure record, got %+v", failed.fields)
+	}
+}
+
+func TestAuditAccounts_EmitsLogRecords(t *testing.T) {
+	rec := withRecordingLogger(t)
+
+	acct := model.Account{ID: 7, Username: "u", Hostname: "h", Serial: 1, IsActive: true, DeployEnabled: true}
+	st := &simpleFakeStore{accounts: []model.Account{acct}}
+
+	if _, err := AuditAccounts(context.TODO(), st, &serialDM{}, "serial", nil); err != nil {
+		t.Fatalf("AuditAccounts: %v", err)
+	}
+	if r := rec.find("debug", "starting audit"); r == nil || r.fields["mode"] != "serial" {
+		t.Fatalf("missing start record: %+v", rec.records)
+	}
+	if rec.find("debug", "audit passed") == nil {
+		t.Fatalf("missing audit passed record: %+v", rec.records)
+	}
+}
+
+func TestSetDefaultLogger_NilDiscards(t *testing.T) {
+	prev := DefaultLogger()
+	defer SetDefaultLogger(prev)
+
+	SetDefaultLogger(nil)
+	if DefaultLogger() == nil {
+		t.Fatalf("expected non-nil logger after SetDefaultLogger(nil)")
+	}
+	// Must not panic.
+	DefaultLogger().Error("ignored", "k", "v")
+}
@@ -9,15 +9,46 @@ package logging
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"time"
 
 	clog "github.com/charmbracelet/log"
 )
 
+// Supported log output formats.
+const (
+	// FormatText is the human-readable console format.
+	FormatText = "text"
+	// FormatJSON emits one JSON object per record with "time", "level" and
+	// "msg" keys followed by the record's fields.
+	FormatJSON = "json"
+)
+
 // L is the package-level logger. Callers should use the helper functions
 // below for compatibility with existing calls.
 var L = clog.New(os.Stderr)
 
+// New builds a logger writing to w in the given format. An empty format
+// selects FormatText. verbose lowers the level from Info to Debug.
+func New(w io.Writer, format string, verbose bool) (*clog.Logger, error) {
+	l := clog.New(w)
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", FormatText:
+	case FormatJSON:
+		l.SetFormatter(clog.JSONFormatter)
+		l.SetReportTimestamp(true)
+		l.SetTimeFormat(time.RFC3339Nano)
+	default:
+		return nil, fmt.Errorf("unknown log format %q (use %s or %s)", format, FormatText, FormatJSON)
+	}
+	if verbose {
+		l.SetLevel(clog.DebugLevel)
+	}
+	return l, nil
+}
+
 // Debugf logs a debug-level formatted message.
 func Debugf(format string, v ...interface{}) {
 	L.Debug(fmt.Sprintf(format, v...))
@@ -5,8 +5,10 @@ package logging
 
 import (
 	"bytes"
+	"encoding/json"
 	"strings"
 	"testing"
+	"time"
 
 	clog "github.com/charmbracelet/log"
 )
@@ -40,3 +42,65 @@ func TestLoggingHelpers_WriteToBuffer(t *testing.T) {
 		t.Fatalf("missing error output; got: %s", out)
 	}
 }
+
+// TestNew_JSONFormat verifies each level is emitted as one JSON object per
+// line carrying the timestamp, level, message and structured fields.
+func TestNew_JSONFormat(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New(&buf, FormatJSON, true)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	l.Debug("dbg msg", "host", "h1")
+	l.Info("info msg", "host", "h2")
+	l.Warn("warn msg", "host", "h3")
+	l.Error("error msg", "host", "h4")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []struct{ level, msg, host string }{
+		{"debug", "dbg msg", "h1"},
+		{"info", "info msg", "h2"},
+		{"warn", "warn msg", "h3"},
+		{"error", "error msg", "h4"},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d lines, got %d: %q", len(want), len(lines), buf.String())
+	}
+	for i, line := range lines {
+		var rec map[string]any
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("line %d is not valid JSON: %v (%q)", i, err, line)
+		}
+		if rec["level"] != want[i].level || rec["msg"] != want[i].msg || rec["host"] != want[i].host {
+			t.Fatalf("line %d: unexpected record %v", i, rec)
+		}
+		ts, _ := rec["time"].(string)
+		if _, err := time.Parse(time.RFC3339Nano, ts); err != nil {
+			t.Fatalf("line %d: bad timestamp %q: %v", i, ts, err)
+		}
+	}
+}
+
+func TestNew_TextFormatAndLevels(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New(&buf, "", false)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	l.Debug("hidden")
+	l.Info("shown", "k", "v")
+	out := buf.String()
+	if strings.Contains(out, "hidden") {
+		t.Fatalf("debug record emitted without verbose: %q", out)
+	}
+	if !strings.Contains(out, "shown") || strings.HasPrefix(strings.TrimSpace(out), "{") {
+		t.Fatalf("expected text output, got %q", out)
+	}
+}
+
+func TestNew_UnknownFormat(t *testing.T) {
+	if _, err := New(&bytes.Buffer{}, "xml", false); err == nil {
+		t.Fatalf("expected error for unknown format")
+	}
+}
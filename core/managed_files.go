@@ -0,0 +1,181 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/core/sshkey"
+)
+
+// ManagedFile is a file in the account's ~/.ssh, besides authorized_keys,
+// that deploys write and strict audits compare. Whether an account manages
+// the file, and its content, come from Render.
+type ManagedFile struct {
+	// Name is the file name under ~/.ssh, e.g. "authorized_principals".
+	Name string
+	// Mode is applied on hosts whose OS family supports chmod.
+	Mode os.FileMode
+	// Render returns the file content for account. ok is false when the
+	// account does not manage the file; it is then neither written nor
+	// audited.
+	Render func(account model.Account) (content string, ok bool, err error)
+}
+
+// ManagedFileDeployer is implemented by RemoteDeployers that can write and
+// read managed files next to authorized_keys. GetManagedFile reports a
+// missing file with an error matching os.ErrNotExist.
+type ManagedFileDeployer interface {
+	DeployManagedFile(name, content string, mode os.FileMode) error
+	GetManagedFile(name string) ([]byte, error)
+}
+
+// ManagedFileFetcher is implemented by DeployerManagers that can read a
+// managed file from an account's host.
+type ManagedFileFetcher interface {
+	FetchManagedFile(account model.Account, name string) ([]byte, error)
+}
+
+// AuthorizedPrincipalsFile renders the account's AuthorizedPrincipals as
+// ~/.ssh/authorized_principals. sshd only reads it when AuthorizedPrincipalsFile
+// points there, e.g. "AuthorizedPrincipalsFile .ssh/authorized_principals".
+var AuthorizedPrincipalsFile = ManagedFile{
+	Name: "authorized_principals",
+	Mode: 0600,
+	Render: func(account model.Account) (string, bool, error) {
+		if len(account.AuthorizedPrincipals) == 0 {
+			return "", false, nil
+		}
+		return RenderAuthorizedPrincipals(account.AuthorizedPrincipals), true, nil
+	},
+}
+
+// managedFiles lists the files handled besides authorized_keys, in the order
+// they are written.
+var managedFiles = []ManagedFile{AuthorizedPrincipalsFile}
+
+// ManagedFiles returns the registered managed files.
+func ManagedFiles() []ManagedFile {
+	return append([]ManagedFile(nil), managedFiles...)
+}
+
+// RegisterManagedFile adds f to the files deployed and audited, replacing a
+// registered file of the same name.
+func RegisterManagedFile(f ManagedFile) {
+	for i := range managedFiles {
+		if managedFiles[i].Name == f.Name {
+			managedFiles[i] = f
+			return
+		}
+	}
+	managedFiles = append(managedFiles, f)
+}
+
+// RenderAuthorizedPrincipals renders principals as an authorized_principals
+// file, one principal per line.
+func RenderAuthorizedPrincipals(principals []string) string {
+	var b strings.Builder
+	b.WriteString("# Keymaster Managed authorized_principals\n")
+	for _, p := range principals {
+		b.WriteString(p)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// SetAuthorizedPrincipals replaces the principals deployed to account id's
+// authorized_principals. Duplicates are dropped; an empty list stops
+// managing the file, leaving the host's copy in place. A change marks the
+// account dirty.
+func SetAuthorizedPrincipals(st AuthorizedPrincipalsStore, id int, principals []string) ([]string, error) {
+	if err := sshkey.ValidatePrincipals(principals); err != nil {
+		return nil, err
+	}
+	var unique []string
+	for _, p := range principals {
+		if !slices.Contains(unique, p) {
+			unique = append(unique, p)
+		}
+	}
+	accounts, err := st.GetAllAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load accounts: %w", err)
+	}
+	for _, acc := range accounts {
+		if acc.ID != id {
+			continue
+		}
+		if slices.Equal(acc.AuthorizedPrincipals, unique) {
+			return unique, nil
+		}
+		if err := st.SetAccountAuthorizedPrincipals(id, unique); err != nil {
+			return nil, fmt.Errorf("failed to save principals: %w", err)
+		}
+		if err := st.UpdateAccountIsDirty(id, true); err != nil {
+			return nil, fmt.Errorf("failed to mark account dirty: %w", err)
+		}
+		return unique, nil
+	}
+	return nil, fmt.Errorf("account not found: %d", id)
+}
+
+// renderedManagedFile is a managed file with the content rendered for one
+// account.
+type renderedManagedFile struct {
+	ManagedFile
+	content string
+}
+
+// renderManagedFiles renders the managed files account uses.
+func renderManagedFiles(account model.Account) ([]renderedManagedFile, error) {
+	var files []renderedManagedFile
+	for _, f := range managedFiles {
+		content, ok, err := f.Render(account)
+		if err != nil {
+			return nil, fmt.Errorf("render %s: %w", f.Name, err)
+		}
+		if ok {
+			files = append(files, renderedManagedFile{ManagedFile: f, content: content})
+		}
+	}
+	return files, nil
+}
+
+// deployManagedFiles writes files through d. Every file is attempted; the
+// failures are returned joined.
+func deployManagedFiles(d ManagedFileDeployer, account model.Account, files []renderedManagedFile) error {
+	var errs []error
+	for _, f := range files {
+		if err := d.DeployManagedFile(f.Name, f.content, f.Mode); err != nil {
+			DefaultLogger().Warn("writing managed file failed", "account", account.String(), "file", f.Name, "err", err)
+			errs = append(errs, fmt.Errorf("write %s: %w", f.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// managedFileDrift compares files with the host's copies returned by read,
+// using the normalization of HashAuthorizedKeysContent. It returns the name
+// of the first file that differs or is missing on the host, or "" when all
+// match.
+func managedFileDrift(files []renderedManagedFile, read func(name string) ([]byte, error)) (string, error) {
+	for _, f := range files {
+		remote, err := read(f.Name)
+		if errors.Is(err, os.ErrNotExist) {
+			return f.Name, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("could not read remote %s: %w", f.Name, err)
+		}
+		if HashAuthorizedKeysContent(remote) != HashAuthorizedKeysContent([]byte(f.content)) {
+			return f.Name, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,288 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/core/security"
+	"github.com/toeirei/keymaster/ui/i18n"
+)
+
+// managedFileDeployer is a cmdDeployer that also stores managed files.
+type managedFileDeployer struct {
+	cmdDeployer
+	files map[string]string
+	modes map[string]os.FileMode
+	err   error
+}
+
+func (m *managedFileDeployer) DeployManagedFile(name, content string, mode os.FileMode) error {
+	if m.err != nil {
+		return m.err
+	}
+	if m.files == nil {
+		m.files, m.modes = map[string]string{}, map[string]os.FileMode{}
+	}
+	m.files[name], m.modes[name] = content, mode
+	return nil
+}
+
+func (m *managedFileDeployer) GetManagedFile(name string) ([]byte, error) {
+	content, ok := m.files[name]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return []byte(content), nil
+}
+
+// managedFileRemote serves authorized_keys and managed files for audits.
+type managedFileRemote struct {
+	fakeRemote
+	files map[string]string
+}
+
+func (m *managedFileRemote) DeployManagedFile(name, content string, mode os.FileMode) error {
+	return nil
+}
+
+func (m *managedFileRemote) GetManagedFile(name string) ([]byte, error) {
+	content, ok := m.files[name]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return []byte(content), nil
+}
+
+// managedFileDM is a fakeDeployerManager that also serves managed files.
+type managedFileDM struct {
+	fakeDeployerManager
+	files map[string]string
+}
+
+func (m *managedFileDM) FetchManagedFile(account model.Account, name string) ([]byte, error) {
+	content, ok := m.files[name]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return []byte(content), nil
+}
+
+func TestRenderAuthorizedPrincipals(t *testing.T) {
+	got := RenderAuthorizedPrincipals([]string{"deploy", "ops-oncall"})
+	want := "# Keymaster Managed authorized_principals\ndeploy\nops-oncall\n"
+	if got != want {
+		t.Fatalf("RenderAuthorizedPrincipals = %q, want %q", got, want)
+	}
+}
+
+func TestRunDeploymentForAccount_DeploysAuthorizedPrincipals(t *testing.T) {
+	fake := &managedFileDeployer{}
+	acct, serial := setupPostDeployTest(t, fake, DeployOptions{})
+	acct.AuthorizedPrincipals = []string{"deploy", "ops-oncall"}
+
+	if err := RunDeploymentForAccount(acct, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fake.content) != 1 {
+		t.Fatalf("expected authorized_keys to be written once, got %d", len(fake.content))
+	}
+	if got := fake.files["authorized_principals"]; got != RenderAuthorizedPrincipals(acct.AuthorizedPrincipals) {
+		t.Fatalf("expected the rendered principals to be written, got %q", got)
+	}
+	if mode := fake.modes["authorized_principals"]; mode != 0600 {
+		t.Fatalf("expected mode 0600, got %v", mode)
+	}
+	if got := accountSerial(t, acct.ID); got != serial {
+		t.Fatalf("expected serial %d, got %d", serial, got)
+	}
+}
+
+func TestRunDeploymentForAccount_NoPrincipalsLeavesFileAlone(t *testing.T) {
+	fake := &managedFileDeployer{}
+	acct, _ := setupPostDeployTest(t, fake, DeployOptions{})
+
+	if err := RunDeploymentForAccount(acct, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fake.files) != 0 {
+		t.Fatalf("expected no managed file writes, got %v", fake.files)
+	}
+}
+
+func TestRunDeploymentForAccount_ManagedFileWriteFailureKeepsSerial(t *testing.T) {
+	fake := &managedFileDeployer{err: errors.New("permission denied")}
+	acct, serial := setupPostDeployTest(t, fake, DeployOptions{})
+	acct.AuthorizedPrincipals = []string{"deploy"}
+
+	err := RunDeploymentForAccount(acct, false)
+	if err == nil || !strings.Contains(err.Error(), "write authorized_principals: permission denied") {
+		t.Fatalf("expected the authorized_principals failure to be reported, got %v", err)
+	}
+	if got := accountSerial(t, acct.ID); got != serial {
+		t.Fatalf("expected serial %d to be recorded after authorized_keys was written, got %d", serial, got)
+	}
+}
+
+func TestRunDeploymentForAccount_ManagedFileUnsupportedDeployer(t *testing.T) {
+	fake := &cmdDeployer{}
+	acct, _ := setupPostDeployTest(t, fake, DeployOptions{})
+	acct.AuthorizedPrincipals = []string{"deploy"}
+
+	if err := RunDeploymentForAccount(acct, false); err == nil {
+		t.Fatal("expected an error for a deployer without managed file support")
+	}
+	if len(fake.content) != 0 {
+		t.Fatal("expected nothing to be written")
+	}
+}
+
+func TestRunDeploymentForAccount_RegisteredManagedFile(t *testing.T) {
+	orig := managedFiles
+	t.Cleanup(func() { managedFiles = orig })
+	RegisterManagedFile(ManagedFile{
+		Name: "sudoers-keymaster",
+		Mode: 0440,
+		Render: func(account model.Account) (string, bool, error) {
+			return account.Username + " ALL=(ALL) NOPASSWD: ALL\n", true, nil
+		},
+	})
+	fake := &managedFileDeployer{}
+	acct, _ := setupPostDeployTest(t, fake, DeployOptions{})
+	acct.AuthorizedPrincipals = []string{"deploy"}
+
+	if err := RunDeploymentForAccount(acct, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := fake.files["sudoers-keymaster"]; got != "deployuser ALL=(ALL) NOPASSWD: ALL\n" || fake.modes["sudoers-keymaster"] != 0440 {
+		t.Fatalf("expected the registered file to be written, got %q (%v)", got, fake.modes["sudoers-keymaster"])
+	}
+	if _, ok := fake.files["authorized_principals"]; !ok {
+		t.Fatal("expected authorized_principals next to the registered file")
+	}
+}
+
+func TestAuditAccountStrict_ManagedFiles(t *testing.T) {
+	i18n.Init("en")
+	SetDefaultKeyReader(&fakeKR{})
+	SetDefaultKeyLister(&fakeKL{})
+	acct := model.Account{ID: 102, Username: "u", Hostname: "h", Serial: 1, AuthorizedPrincipals: []string{"deploy"}}
+	expected, err := GenerateKeysContent(acct.ID)
+	if err != nil {
+		t.Fatalf("GenerateKeysContent: %v", err)
+	}
+
+	remote := &managedFileRemote{fakeRemote: fakeRemote{content: []byte(expected)}}
+	orig := NewDeployerFactory
+	t.Cleanup(func() { NewDeployerFactory = orig })
+	NewDeployerFactory = func(host, user string, privateKey security.Secret, passphrase []byte) (RemoteDeployer, error) {
+		return remote, nil
+	}
+
+	drift := i18n.T("audit.error_drift_detected")
+	if err := AuditAccountStrict(acct); err == nil || err.Error() != drift {
+		t.Fatalf("expected a missing authorized_principals to be drift, got %v", err)
+	}
+	remote.files = map[string]string{"authorized_principals": RenderAuthorizedPrincipals([]string{"deploy"})}
+	if err := AuditAccountStrict(acct); err != nil {
+		t.Fatalf("expected matching files to pass, got %v", err)
+	}
+	remote.files["authorized_principals"] += "root\n"
+	if err := AuditAccountStrict(acct); err == nil || err.Error() != drift {
+		t.Fatalf("expected a changed authorized_principals to be drift, got %v", err)
+	}
+}
+
+func TestAuditAccounts_ManagedFileMismatchMarksDirty(t *testing.T) {
+	i18n.Init("en")
+	SetDefaultKeyReader(&fakeKR{})
+	SetDefaultKeyLister(&fakeKL{})
+	acct := model.Account{ID: 3, Username: "u3", Hostname: "h3", Serial: 1, IsActive: true, DeployEnabled: true, AuthorizedPrincipals: []string{"deploy"}}
+	expected, err := GenerateKeysContent(acct.ID)
+	if err != nil {
+		t.Fatalf("GenerateKeysContent: %v", err)
+	}
+	dm := &managedFileDM{
+		fakeDeployerManager: fakeDeployerManager{content: []byte(expected)},
+		files:               map[string]string{"authorized_principals": RenderAuthorizedPrincipals(acct.AuthorizedPrincipals)},
+	}
+
+	store := &simpleFakeStore{accounts: []model.Account{acct}}
+	aw := &spyAuditWriter{}
+	SetDefaultAuditWriter(aw)
+	res, err := AuditAccounts(context.TODO(), store, dm, "strict", nil)
+	if err != nil || len(res) != 1 || res[0].Error != nil {
+		t.Fatalf("expected matching files to pass, got %+v (%v)", res, err)
+	}
+
+	dm.files["authorized_principals"] = "# edited on the host\nroot\n"
+	res, err = AuditAccounts(context.TODO(), store, dm, "strict", nil)
+	if err != nil || len(res) != 1 || res[0].Error == nil {
+		t.Fatalf("expected drift in authorized_principals, got %+v (%v)", res, err)
+	}
+	if !store.updates[acct.ID] {
+		t.Fatalf("expected the account to be marked dirty, got %v", store.updates)
+	}
+	if len(aw.actions) != 1 || !strings.HasPrefix(aw.actions[0], "AUDIT_MANAGED_FILE_MISMATCH:") || !strings.Contains(aw.actions[0], "file:authorized_principals") {
+		t.Fatalf("expected a managed file mismatch to be logged, got %v", aw.actions)
+	}
+
+	// Serial audits only check the authorized_keys header.
+	if res, err := AuditAccounts(context.TODO(), store, dm, "serial", nil); err != nil || res[0].Error != nil {
+		t.Fatalf("expected serial audits to skip managed files, got %+v (%v)", res, err)
+	}
+}
+
+type authorizedPrincipalsStore struct {
+	accounts []model.Account
+	dirty    []int
+}
+
+func (s *authorizedPrincipalsStore) GetAllAccounts() ([]model.Account, error) { return s.accounts, nil }
+func (s *authorizedPrincipalsStore) SetAccountAuthorizedPrincipals(id int, principals []string) error {
+	for i := range s.accounts {
+		if s.accounts[i].ID == id {
+			s.accounts[i].AuthorizedPrincipals = principals
+		}
+	}
+	return nil
+}
+func (s *authorizedPrincipalsStore) UpdateAccountIsDirty(id int, dirty bool) error {
+	s.dirty = append(s.dirty, id)
+	return nil
+}
+
+func TestSetAuthorizedPrincipals(t *testing.T) {
+	st := &authorizedPrincipalsStore{accounts: []model.Account{{ID: 1}}}
+
+	got, err := SetAuthorizedPrincipals(st, 1, []string{"deploy", "ops", "deploy"})
+	if err != nil {
+		t.Fatalf("SetAuthorizedPrincipals: %v", err)
+	}
+	if !slices.Equal(got, []string{"deploy", "ops"}) || !slices.Equal(st.accounts[0].AuthorizedPrincipals, got) {
+		t.Fatalf("expected duplicates to be dropped, got %v (stored %v)", got, st.accounts[0].AuthorizedPrincipals)
+	}
+	if !slices.Equal(st.dirty, []int{1}) {
+		t.Fatalf("expected the account to be marked dirty, got %v", st.dirty)
+	}
+	if _, err := SetAuthorizedPrincipals(st, 1, []string{"deploy", "ops"}); err != nil || len(st.dirty) != 1 {
+		t.Fatalf("expected an unchanged list to be a no-op, got dirty %v (%v)", st.dirty, err)
+	}
+	if got, err := SetAuthorizedPrincipals(st, 1, nil); err != nil || len(got) != 0 || len(st.accounts[0].AuthorizedPrincipals) != 0 {
+		t.Fatalf("expected the principals to be cleared, got %v (%v)", got, err)
+	}
+
+	if _, err := SetAuthorizedPrincipals(st, 1, []string{"two words"}); err == nil {
+		t.Fatal("expected an invalid principal to be rejected")
+	}
+	if _, err := SetAuthorizedPrincipals(st, 2, []string{"deploy"}); err == nil {
+		t.Fatal("expected an unknown account to be rejected")
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"fmt"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// SetAccountMetadata applies "key=value" assignments to the metadata of
+// account id and returns the resulting metadata. An empty value removes
+// the key.
+func SetAccountMetadata(st AccountMetadataStore, id int, assignments []string) (map[string]string, error) {
+	if len(assignments) == 0 {
+		return nil, fmt.Errorf("no metadata given (use key=value)")
+	}
+	acc, err := st.GetAccount(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load account: %w", err)
+	}
+	if acc == nil {
+		return nil, fmt.Errorf("account not found: %d", id)
+	}
+	metadata, err := model.ApplyMetadataAssignments(acc.Metadata, assignments)
+	if err != nil {
+		return nil, err
+	}
+	if err := st.SetAccountMetadata(id, metadata); err != nil {
+		return nil, fmt.Errorf("failed to save metadata: %w", err)
+	}
+	return metadata, nil
+}
+
+// SetKeyMetadata applies "key=value" assignments to the metadata of public
+// key id and returns the resulting metadata. An empty value removes the
+// key.
+func SetKeyMetadata(km KeyManager, id int, assignments []string) (map[string]string, error) {
+	if len(assignments) == 0 {
+		return nil, fmt.Errorf("no metadata given (use key=value)")
+	}
+	keys, err := km.GetAllPublicKeys()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load keys: %w", err)
+	}
+	for _, k := range keys {
+		if k.ID != id {
+			continue
+		}
+		metadata, err := model.ApplyMetadataAssignments(k.Metadata, assignments)
+		if err != nil {
+			return nil, err
+		}
+		if err := km.SetPublicKeyMetadata(id, metadata); err != nil {
+			return nil, fmt.Errorf("failed to save metadata: %w", err)
+		}
+		return metadata, nil
+	}
+	return nil, fmt.Errorf("key not found: %d", id)
+}
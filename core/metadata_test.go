@@ -0,0 +1,65 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/testutil"
+)
+
+type metaStore struct {
+	acc   *model.Account
+	saved map[string]string
+}
+
+func (m *metaStore) GetAccount(id int) (*model.Account, error) {
+	if m.acc == nil || m.acc.ID != id {
+		return nil, fmt.Errorf("account not found: %d", id)
+	}
+	return m.acc, nil
+}
+
+func (m *metaStore) SetAccountMetadata(id int, metadata map[string]string) error {
+	m.saved = metadata
+	return nil
+}
+
+func TestSetAccountMetadata(t *testing.T) {
+	st := &metaStore{acc: &model.Account{ID: 4, Metadata: map[string]string{"owner": "bob", "ticket": "OPS-1"}}}
+	got, err := SetAccountMetadata(st, 4, []string{"owner=alice@example.com", "ticket="})
+	if err != nil {
+		t.Fatalf("SetAccountMetadata: %v", err)
+	}
+	want := map[string]string{"owner": "alice@example.com"}
+	if !reflect.DeepEqual(got, want) || !reflect.DeepEqual(st.saved, want) {
+		t.Fatalf("unexpected metadata: returned %v, saved %v", got, st.saved)
+	}
+	if _, err := SetAccountMetadata(st, 5, []string{"a=b"}); err == nil {
+		t.Fatal("expected error for unknown account")
+	}
+	if _, err := SetAccountMetadata(st, 4, []string{"nokey"}); err == nil {
+		t.Fatal("expected error for malformed assignment")
+	}
+	if _, err := SetAccountMetadata(st, 4, nil); err == nil {
+		t.Fatal("expected error without assignments")
+	}
+}
+
+func TestSetKeyMetadata(t *testing.T) {
+	km := &testutil.FakeKeyManager{Results: []model.PublicKey{{ID: 9, Comment: "k"}}}
+	got, err := SetKeyMetadata(km, 9, []string{"owner=ops"})
+	if err != nil {
+		t.Fatalf("SetKeyMetadata: %v", err)
+	}
+	if got["owner"] != "ops" || km.Results[0].Metadata["owner"] != "ops" {
+		t.Fatalf("metadata not saved: %v, %+v", got, km.Results[0])
+	}
+	if _, err := SetKeyMetadata(km, 10, []string{"owner=ops"}); err == nil {
+		t.Fatal("expected error for unknown key")
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// MigrateOptions controls MigrateWithOptions.
+type MigrateOptions struct {
+	// Verify re-exports source and target after the import and compares
+	// per-table row counts and checksums.
+	Verify bool
+}
+
+// TableDigest summarizes the rows of one table: their count and a checksum
+// that does not depend on row order or on how a backend formats timestamps.
+type TableDigest struct {
+	Table    string
+	Rows     int
+	Checksum string
+}
+
+// MigrationMismatch is a table whose source and target digests differ.
+type MigrationMismatch struct {
+	Table  string
+	Source TableDigest
+	Target TableDigest
+}
+
+// MigrationVerifyError is returned when a verified migration found tables
+// that differ between source and target.
+type MigrationVerifyError struct {
+	Mismatches []MigrationMismatch
+}
+
+func (e *MigrationVerifyError) Error() string {
+	parts := make([]string, 0, len(e.Mismatches))
+	for _, m := range e.Mismatches {
+		if m.Source.Rows != m.Target.Rows {
+			parts = append(parts, fmt.Sprintf("%s: %d rows in source, %d in target", m.Table, m.Source.Rows, m.Target.Rows))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s: checksum mismatch", m.Table))
+		}
+	}
+	return "migration verification failed: " + strings.Join(parts, "; ")
+}
+
+// MigrateWithOptions performs Migrate and, with opts.Verify, checks that the
+// target holds the same data as the source. The returned digests describe
+// the source tables; they are nil without verification.
+func MigrateWithOptions(ctx context.Context, factory StoreFactory, st Store, targetType, targetDsn string, opts MigrateOptions) ([]TableDigest, error) {
+	data, err := st.ExportDataForBackup()
+	if err != nil {
+		return nil, fmt.Errorf("export backup: %w", err)
+	}
+	targetStore, err := factory.NewStoreFromDSN(targetType, targetDsn)
+	if err != nil {
+		return nil, fmt.Errorf("init target store: %w", err)
+	}
+	if err := targetStore.ImportDataFromBackup(data); err != nil {
+		return nil, fmt.Errorf("import to target: %w", err)
+	}
+	if !opts.Verify {
+		return nil, nil
+	}
+
+	source, err := st.ExportDataForBackup()
+	if err != nil {
+		return nil, fmt.Errorf("re-export source: %w", err)
+	}
+	target, err := targetStore.ExportDataForBackup()
+	if err != nil {
+		return nil, fmt.Errorf("export target: %w", err)
+	}
+	sourceDigests := DigestBackup(source)
+	if mismatches := CompareBackupDigests(sourceDigests, DigestBackup(target)); len(mismatches) > 0 {
+		return sourceDigests, &MigrationVerifyError{Mismatches: mismatches}
+	}
+	return sourceDigests, nil
+}
+
+// CompareBackupDigests returns the tables whose digests differ. Both slices
+// must come from DigestBackup.
+func CompareBackupDigests(source, target []TableDigest) []MigrationMismatch {
+	byTable := make(map[string]TableDigest, len(target))
+	for _, d := range target {
+		byTable[d.Table] = d
+	}
+	var out []MigrationMismatch
+	for _, s := range source {
+		t := byTable[s.Table]
+		if s.Rows != t.Rows || s.Checksum != t.Checksum {
+			out = append(out, MigrationMismatch{Table: s.Table, Source: s, Target: t})
+		}
+	}
+	return out
+}
+
+// DigestBackup computes a TableDigest for every table of a backup, in backup
+// table order.
+func DigestBackup(data *model.BackupData) []TableDigest {
+	if data == nil {
+		data = &model.BackupData{}
+	}
+	var accounts, publicKeys, accountKeys, systemKeys, knownHosts, auditLog, sessions []string
+	for _, a := range data.Accounts {
+		// Settings older backups predate are digested as a restore fills them in.
+		accounts = append(accounts, digestFields(a.ID, a.Username, a.Hostname, a.Label, a.Tags, a.Serial, a.IsActive, a.IsDirty,
+			digestTime(a.DisableAt), digestTime(a.EnableAt), a.RemediationPolicy, data.ManagesSystemKey(a), a.DeployMode, strings.Join(a.DeployedKeys, "\n"), digestMetadata(a.Metadata), a.OSFamily, a.Group,
+			data.DeploysEnabled(a), a.DeployKnownHosts, a.ExcludedGlobalKeys, strings.Join(a.AuthorizedPrincipals, ",")))
+	}
+	for _, k := range data.PublicKeys {
+		publicKeys = append(publicKeys, digestFields(k.ID, k.Algorithm, k.KeyData, k.Comment, k.IsGlobal, digestTime(k.ExpiresAt), digestMetadata(k.Metadata), k.IsCA, strings.Join(k.Principals, ",")))
+	}
+	for _, ak := range data.AccountKeys {
+		accountKeys = append(accountKeys, digestFields(ak.KeyID, ak.AccountID, ak.Options))
+	}
+	for _, sk := range data.SystemKeys {
+		systemKeys = append(systemKeys, digestFields(sk.ID, sk.Serial, sk.PublicKey, sk.PrivateKey, sk.IsActive, digestTime(sk.CreatedAt)))
+	}
+	for _, kh := range data.KnownHosts {
+		knownHosts = append(knownHosts, digestFields(kh.Hostname, kh.Key))
+	}
+	for _, e := range data.AuditLogEntries {
+		auditLog = append(auditLog, digestFields(e.ID, digestTimestamp(e.Timestamp), e.Username, e.Action, e.Details))
+	}
+	for _, bs := range data.BootstrapSessions {
+		sessions = append(sessions, digestFields(bs.ID, bs.Username, bs.Hostname, bs.Label, bs.Tags, bs.TempPublicKey,
+			digestTime(bs.CreatedAt), digestTime(bs.ExpiresAt), bs.Status))
+	}
+	return []TableDigest{
+		digestTable("accounts", accounts),
+		digestTable("public_keys", publicKeys),
+		digestTable("account_keys", accountKeys),
+		digestTable("system_keys", systemKeys),
+		digestTable("known_hosts", knownHosts),
+		digestTable("audit_log", auditLog),
+		digestTable("bootstrap_sessions", sessions),
+	}
+}
+
+// digestTable hashes the encoded rows of a table in sorted order so the
+// checksum does not depend on the order a backend returned them in.
+func digestTable(table string, rows []string) TableDigest {
+	sort.Strings(rows)
+	h := sha256.New()
+	for _, r := range rows {
+		h.Write([]byte(r))
+		h.Write([]byte{'\n'})
+	}
+	return TableDigest{Table: table, Rows: len(rows), Checksum: hex.EncodeToString(h.Sum(nil))}
+}
+
+// digestFields encodes row values unambiguously as quoted strings.
+func digestFields(values ...any) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Quote(fmt.Sprint(v))
+	}
+	return strings.Join(parts, ",")
+}
+
+// digestMetadata encodes metadata with sorted keys.
+func digestMetadata(m map[string]string) string {
+	s, _ := model.MarshalMetadata(m)
+	return s
+}
+
+// digestTime renders t in UTC at second precision, the coarsest precision
+// of the supported backends. The zero time renders empty.
+func digestTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Truncate(time.Second).Format(time.RFC3339)
+}
+
+// digestTimestamp normalizes an audit log timestamp string, which backends
+// format differently. Unparseable values are kept as they are.
+func digestTimestamp(s string) string {
+	if t, ok := ParseAuditTimestamp(s); ok {
+		return digestTime(t)
+	}
+	return s
+}
+
+// ParseAuditTimestamp parses an audit log timestamp in any of the formats
+// the supported database backends return.
+func ParseAuditTimestamp(s string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999-07:00", "2006-01-02 15:04:05.999999999", "2006-01-02T15:04:05.999999999"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// lossyStore drops the last account on import, like an incomplete migration.
+type lossyStore struct{ *fStore }
+
+func (s *lossyStore) ImportDataFromBackup(d *model.BackupData) error {
+	cp := *d
+	cp.Accounts = d.Accounts[:len(d.Accounts)-1]
+	return s.fStore.ImportDataFromBackup(&cp)
+}
+
+func migrateVerifyBackup() *model.BackupData {
+	return &model.BackupData{
+		SchemaVersion: model.BackupSchemaVersion,
+		Accounts: []model.Account{
+			{ID: 1, Username: "app", Hostname: "web-01", IsActive: true, ManageSystemKey: true},
+			{ID: 2, Username: "app", Hostname: "web-02", IsActive: true, ManageSystemKey: true},
+		},
+		PublicKeys:  []model.PublicKey{{ID: 1, Algorithm: "ssh-ed25519", KeyData: "AAAA", Comment: "alice"}},
+		AccountKeys: []model.AccountKey{{KeyID: 1, AccountID: 1}},
+	}
+}
+
+func TestMigrateWithOptions_VerifyCatchesIncompleteImport(t *testing.T) {
+	src := &fStore{gotExport: migrateVerifyBackup()}
+	tgt := &lossyStore{&fStore{}}
+	_, err := MigrateWithOptions(context.TODO(), lossyFactory{tgt}, src, "sqlite", "dsn", MigrateOptions{Verify: true})
+	var verr *MigrationVerifyError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected MigrationVerifyError, got %v", err)
+	}
+	if len(verr.Mismatches) != 1 || verr.Mismatches[0].Table != "accounts" {
+		t.Fatalf("expected only accounts to differ, got %+v", verr.Mismatches)
+	}
+	if m := verr.Mismatches[0]; m.Source.Rows != 2 || m.Target.Rows != 1 {
+		t.Fatalf("unexpected row counts %+v", m)
+	}
+
+	// Without --verify the incomplete import goes unnoticed.
+	if _, err := MigrateWithOptions(context.TODO(), lossyFactory{tgt}, src, "sqlite", "dsn", MigrateOptions{}); err != nil {
+		t.Fatalf("unverified migrate: %v", err)
+	}
+}
+
+// lossyFactory hands out a lossyStore as migration target.
+type lossyFactory struct{ target *lossyStore }
+
+func (f lossyFactory) NewStoreFromDSN(dbType, dsn string) (Store, error) { return f.target, nil }
+
+func TestMigrateWithOptions_VerifyPasses(t *testing.T) {
+	src := &fStore{gotExport: migrateVerifyBackup()}
+	digests, err := MigrateWithOptions(context.TODO(), fFactory{target: &fStore{}}, src, "sqlite", "dsn", MigrateOptions{Verify: true})
+	if err != nil {
+		t.Fatalf("verified migrate: %v", err)
+	}
+	if len(digests) != 7 || digests[0].Table != "accounts" || digests[0].Rows != 2 {
+		t.Fatalf("unexpected digests %+v", digests)
+	}
+}
+
+func TestDigestBackup_IgnoresOrderAndTimestampFormat(t *testing.T) {
+	ts := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	a := &model.BackupData{
+		Accounts:        []model.Account{{ID: 1, Username: "a"}, {ID: 2, Username: "b", DisableAt: ts}},
+		AuditLogEntries: []model.AuditLogEntry{{ID: 1, Timestamp: "2026-03-04 05:06:07", Action: "X"}},
+	}
+	b := &model.BackupData{
+		Accounts:        []model.Account{{ID: 2, Username: "b", DisableAt: ts.In(time.FixedZone("CET", 3600)).Add(400 * time.Millisecond)}, {ID: 1, Username: "a"}},
+		AuditLogEntries: []model.AuditLogEntry{{ID: 1, Timestamp: "2026-03-04T05:06:07Z", Action: "X"}},
+	}
+	if m := CompareBackupDigests(DigestBackup(a), DigestBackup(b)); len(m) != 0 {
+		t.Fatalf("expected equal digests, got %+v", m)
+	}
+	b.Accounts[1].Label = "changed"
+	if m := CompareBackupDigests(DigestBackup(a), DigestBackup(b)); len(m) != 1 || m[0].Source.Rows != m[0].Target.Rows {
+		t.Fatalf("expected a checksum-only mismatch, got %+v", m)
+	}
+}
+
+func TestMigrateWithOptions_VerifySQLiteRoundTrip(t *testing.T) {
+	src, err := NewStoreFromDSN("sqlite", "file:"+t.Name()+"_src?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("source store: %v", err)
+	}
+	id, err := src.AddAccount("app", "web-01", "web", "env:prod")
+	if err != nil {
+		t.Fatalf("AddAccount: %v", err)
+	}
+	if _, err := src.CreateSystemKey("sys-pub", "sys-priv"); err != nil {
+		t.Fatalf("CreateSystemKey: %v", err)
+	}
+	if err := src.AddKnownHostKey("web-01", "ssh-ed25519 HOSTKEY"); err != nil {
+		t.Fatalf("AddKnownHostKey: %v", err)
+	}
+	if err := src.ToggleAccountStatus(id, false); err != nil {
+		t.Fatalf("ToggleAccountStatus: %v", err)
+	}
+
+	fac := dsnFactory{}
+	if _, err := MigrateWithOptions(context.TODO(), fac, src, "sqlite", "file:"+t.Name()+"_dst?mode=memory&cache=shared", MigrateOptions{Verify: true}); err != nil {
+		t.Fatalf("verified sqlite migration: %v", err)
+	}
+}
+
+// dsnFactory opens real stores for migration targets.
+type dsnFactory struct{}
+
+func (dsnFactory) NewStoreFromDSN(dbType, dsn string) (Store, error) {
+	return NewStoreFromDSN(dbType, dsn)
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Well-known [AuditFields] names.
+const (
+	AuditFieldAccountID = "account_id"
+	AuditFieldAccount   = "account" // user@host
+	AuditFieldKeyID     = "key_id"
+	AuditFieldKey       = "key" // key comment
+	AuditFieldSerial    = "serial"
+	AuditFieldError     = "error"
+)
+
+// [AuditFields] holds the structured fields of an audit log entry.
+type AuditFields map[string]string
+
+// auditDetailsJSON is the layout of structured audit details.
+type auditDetailsJSON struct {
+	Summary string      `json:"summary"`
+	Fields  AuditFields `json:"fields"`
+}
+
+// [EncodeAuditDetails] encodes a human summary and structured fields for the
+// details column of the audit log. Without fields the summary is stored as
+// is, the format used for all entries before structured fields existed.
+func EncodeAuditDetails(summary string, fields AuditFields) string {
+	if len(fields) == 0 {
+		return summary
+	}
+	b, err := json.Marshal(auditDetailsJSON{Summary: summary, Fields: fields})
+	if err != nil {
+		return summary
+	}
+	return string(b)
+}
+
+// [DecodeAuditDetails] splits details written by [EncodeAuditDetails] into
+// summary and fields. Plain text details yield themselves and nil fields.
+func DecodeAuditDetails(details string) (string, AuditFields) {
+	if !strings.HasPrefix(details, `{"summary":`) {
+		return details, nil
+	}
+	var d auditDetailsJSON
+	if err := json.Unmarshal([]byte(details), &d); err != nil || len(d.Fields) == 0 {
+		return details, nil
+	}
+	return d.Summary, d.Fields
+}
+
+// [AuditLogEntry.Summary] returns the human readable part of the details.
+func (e AuditLogEntry) Summary() string {
+	s, _ := DecodeAuditDetails(e.Details)
+	return s
+}
+
+// [AuditLogEntry.Fields] returns the structured fields of the details, nil
+// for plain text entries.
+func (e AuditLogEntry) Fields() AuditFields {
+	_, f := DecodeAuditDetails(e.Details)
+	return f
+}
+
+// auditAccountRef matches the account reference in audit log details. Besides
+// the current "account_id:N" it accepts the "account:N" and "account_id: N"
+// forms written by older releases.
+var auditAccountRef = regexp.MustCompile(`\baccount(?:_id)?: ?(\d+)\b`)
+
+// [AccountRef] returns the account reference recorded in audit log details of
+// events concerning the given account, e.g. "account_id:7".
+func AccountRef(id int) string {
+	return fmt.Sprintf("account_id:%d", id)
+}
+
+// [AuditAccountIDs] returns the account IDs referenced by audit log details,
+// in order of appearance. A structured account_id field comes first.
+func AuditAccountIDs(details string) []int {
+	summary, fields := DecodeAuditDetails(details)
+	var ids []int
+	if id, err := strconv.Atoi(fields[AuditFieldAccountID]); err == nil {
+		ids = append(ids, id)
+	}
+	for _, m := range auditAccountRef.FindAllStringSubmatch(summary, -1) {
+		if id, err := strconv.Atoi(m[1]); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// [AuditReferencesAccount] reports whether audit log details reference the
+// given account.
+func AuditReferencesAccount(details string, id int) bool {
+	for _, ref := range AuditAccountIDs(details) {
+		if ref == id {
+			return true
+		}
+	}
+	return false
+}
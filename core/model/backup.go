@@ -3,11 +3,66 @@
 // This source code is licensed under the MIT license found in the LICENSE file.
 package model
 
+import (
+	"fmt"
+	"strings"
+)
+
+// Table names accepted for selective backups.
+const (
+	BackupTableAccounts          = "accounts"
+	BackupTablePublicKeys        = "public_keys"
+	BackupTableAccountKeys       = "account_keys"
+	BackupTableSystemKeys        = "system_keys"
+	BackupTableKnownHosts        = "known_hosts"
+	BackupTableAuditLog          = "audit_log"
+	BackupTableBootstrapSessions = "bootstrap_sessions"
+)
+
+// BackupTables lists every table included in a complete backup.
+var BackupTables = []string{
+	BackupTableAccounts,
+	BackupTablePublicKeys,
+	BackupTableAccountKeys,
+	BackupTableSystemKeys,
+	BackupTableKnownHosts,
+	BackupTableAuditLog,
+	BackupTableBootstrapSessions,
+}
+
+// ValidateBackupTables returns an error naming the first entry of tables
+// that is not a known backup table.
+func ValidateBackupTables(tables []string) error {
+	for _, t := range tables {
+		known := false
+		for _, k := range BackupTables {
+			if t == k {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown backup table %q (valid: %s)", t, strings.Join(BackupTables, ", "))
+		}
+	}
+	return nil
+}
+
+// BackupSchemaVersion is the SchemaVersion written by current exports.
+// Version 2 added Account.ManageSystemKey; accounts restored from older
+// backups keep managing the system key. Version 3 added
+// Account.DeployEnabled; accounts restored from older backups have deploys
+// enabled.
+const BackupSchemaVersion = 3
+
 // BackupData is a container for all data to be exported for a backup.
 // It holds slices of all the core models in Keymaster.
 type BackupData struct {
 	// SchemaVersion helps in handling migrations during restore.
 	SchemaVersion int `json:"schema_version"`
+	// Tables lists the tables contained in a selective backup. It is empty
+	// for a complete backup.
+	Tables []string `json:"tables,omitempty"`
 
 	// Data from each table.
 	Accounts          []Account          `json:"accounts"`
@@ -19,10 +74,39 @@ type BackupData struct {
 	BootstrapSessions []BootstrapSession `json:"bootstrap_sessions"`
 }
 
+// IncludesTable reports whether the backup carries data for table. Complete
+// backups include every table.
+func (b *BackupData) IncludesTable(table string) bool {
+	if len(b.Tables) == 0 {
+		return true
+	}
+	for _, t := range b.Tables {
+		if t == table {
+			return true
+		}
+	}
+	return false
+}
+
+// ManagesSystemKey reports whether acc, taken from this backup, should have
+// the Keymaster system key rendered. Backups written before
+// Account.ManageSystemKey existed always do.
+func (b *BackupData) ManagesSystemKey(acc Account) bool {
+	return b.SchemaVersion < 2 || acc.ManageSystemKey
+}
+
+// DeploysEnabled reports whether acc, taken from this backup, should have
+// deploys enabled. Backups written before Account.DeployEnabled existed
+// always do.
+func (b *BackupData) DeploysEnabled(acc Account) bool {
+	return b.SchemaVersion < 3 || acc.DeployEnabled
+}
+
 // AccountKey represents the many-to-many relationship between accounts and public keys.
 type AccountKey struct {
-	KeyID     int `json:"key_id"`
-	AccountID int `json:"account_id"`
+	KeyID     int    `json:"key_id"`
+	AccountID int    `json:"account_id"`
+	Options   string `json:"options,omitempty"`
 }
 
 // KnownHost represents a trusted host's public key.
@@ -30,3 +114,23 @@ type KnownHost struct {
 	Hostname string `json:"hostname"`
 	Key      string `json:"key"`
 }
+
+// RestoreTableCounts records how many backup rows of one table were imported
+// and how many were skipped because a conflicting row already existed.
+type RestoreTableCounts struct {
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"`
+}
+
+// RestoreSummary reports the outcome of a non-destructive (integrating)
+// restore per table.
+type RestoreSummary struct {
+	Accounts    RestoreTableCounts `json:"accounts"`
+	PublicKeys  RestoreTableCounts `json:"public_keys"`
+	AccountKeys RestoreTableCounts `json:"account_keys"`
+}
+
+// Skipped returns the total number of rows skipped across all tables.
+func (s RestoreSummary) Skipped() int {
+	return s.Accounts.Skipped + s.PublicKeys.Skipped + s.AccountKeys.Skipped
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// [MarshalMetadata] encodes metadata as a JSON object for storage. Keys are
+// written in sorted order; empty metadata encodes as "".
+func MarshalMetadata(m map[string]string) (string, error) {
+	if len(m) == 0 {
+		return "", nil
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return "", fmt.Errorf("encode metadata: %w", err)
+	}
+	return string(b), nil
+}
+
+// [UnmarshalMetadata] decodes metadata written by [MarshalMetadata]. An
+// empty string yields nil.
+func UnmarshalMetadata(s string) (map[string]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var m map[string]string
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		return nil, fmt.Errorf("decode metadata: %w", err)
+	}
+	if len(m) == 0 {
+		return nil, nil
+	}
+	return m, nil
+}
+
+// [ParseMetadataAssignment] splits a "key=value" argument at the first '='.
+// The key is trimmed and must not be empty; the value is kept verbatim and
+// may itself contain '='. An empty value means the key is to be removed.
+func ParseMetadataAssignment(s string) (key, value string, err error) {
+	key, value, ok := strings.Cut(s, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return "", "", fmt.Errorf("invalid metadata %q (use key=value)", s)
+	}
+	return key, value, nil
+}
+
+// [ApplyMetadataAssignments] returns a copy of m updated with the given
+// "key=value" assignments. An assignment with an empty value removes the
+// key. The result is nil when no keys remain.
+func ApplyMetadataAssignments(m map[string]string, assignments []string) (map[string]string, error) {
+	out := make(map[string]string, len(m)+len(assignments))
+	for k, v := range m {
+		out[k] = v
+	}
+	for _, a := range assignments {
+		k, v, err := ParseMetadataAssignment(a)
+		if err != nil {
+			return nil, err
+		}
+		if v == "" {
+			delete(out, k)
+			continue
+		}
+		out[k] = v
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+	return out, nil
+}
+
+// [MetadataKeys] returns the keys of m in sorted order.
+func MetadataKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -9,6 +9,7 @@ package model // import "github.com/toeirei/keymaster/core/model"
 
 import (
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -25,9 +26,167 @@ type Account struct {
 	Serial int
 	// IsActive determines if the account is included in bulk operations like 'deploy' and 'audit'.
 	IsActive bool
+	// DeployEnabled excludes an active account from deploys while keeping it
+	// in audits, e.g. during manual maintenance on the host. It is true for
+	// new accounts.
+	DeployEnabled bool
 	// IsDirty marks the account as having local changes that are not yet committed.
 	// This is used by the UI/CLI to surface accounts needing attention.
 	IsDirty bool
+	// DisableAt, when non-zero, is the time at which the account is
+	// automatically disabled by the scheduler.
+	DisableAt time.Time
+	// EnableAt, when non-zero, is the time at which the account is
+	// automatically re-enabled by the scheduler.
+	EnableAt time.Time
+	// RemediationPolicy decides how a remediating audit reacts to drift on
+	// this account (see RemediationAuto and friends). Empty means alert.
+	RemediationPolicy string
+	// ManageSystemKey controls whether the Keymaster system key is rendered
+	// into the account's authorized_keys. It is true for new accounts; turn
+	// it off for hosts where another tool owns the system key line.
+	ManageSystemKey bool
+	// DeployMode selects how deploys write authorized_keys (see
+	// DeployModeReplace and DeployModeAppendOnly). Empty means replace.
+	DeployMode string
+	// DeployedKeys lists the identities ("algorithm key-data") of the keys
+	// written by the last deploy. Append-only deploys only remove keys
+	// listed here.
+	DeployedKeys []string
+	// Metadata holds free-form annotations such as an owner or ticket URL.
+	// Unlike Tags it is not used for selection.
+	Metadata map[string]string
+	// OSFamily tells deploys where the host keeps authorized_keys and how
+	// permissions are handled (see OSFamilyPOSIX and friends). Empty means
+	// POSIX.
+	OSFamily string
+	// DeployKnownHosts makes deploys also write ~/.ssh/known_hosts, rendered
+	// from the stored known host keys, for hosts that SSH onward, such as
+	// jump boxes. It is false for new accounts.
+	DeployKnownHosts bool
+	// ExcludedGlobalKeys lists the IDs of global keys that are not rendered
+	// for this account, e.g. to keep a key off a DMZ host. A key that is
+	// also assigned to the account directly is still rendered.
+	ExcludedGlobalKeys []int
+	// AuthorizedPrincipals are the certificate principals deploys write to
+	// ~/.ssh/authorized_principals. Empty means the file is not managed.
+	AuthorizedPrincipals []string
+	// Group is the account group, the exact-membership alternative to Tags:
+	// an account is in at most one group, and selecting a group targets
+	// exactly its members. Empty means no group.
+	Group string
+	// LastAuditAt is the time of the last audit that found the host clean.
+	// It is zero when the host was never audited clean or the last audit
+	// failed.
+	LastAuditAt time.Time
+}
+
+// [Account.ExcludesGlobalKey] reports whether the global key keyID is
+// excluded from the account.
+func (a Account) ExcludesGlobalKey(keyID int) bool {
+	for _, id := range a.ExcludedGlobalKeys {
+		if id == keyID {
+			return true
+		}
+	}
+	return false
+}
+
+// [Account.Deployable] reports whether deploys may write to the account: it
+// must be active and have deploys enabled.
+func (a Account) Deployable() bool {
+	return a.IsActive && a.DeployEnabled
+}
+
+// OS families for [Account.OSFamily].
+const (
+	// OSFamilyPOSIX hosts keep keys in ~/.ssh/authorized_keys with mode 0600.
+	OSFamilyPOSIX = "posix"
+	// OSFamilyWindows hosts run Windows OpenSSH and keep keys in the user
+	// profile; access is controlled by ACLs rather than file modes.
+	OSFamilyWindows = "windows"
+	// OSFamilyWindowsAdmin is for Windows accounts in the Administrators
+	// group, whose keys sshd reads from administrators_authorized_keys.
+	OSFamilyWindowsAdmin = "windows-admin"
+)
+
+// [IsValidOSFamily] reports whether f is a known OS family.
+func IsValidOSFamily(f string) bool {
+	return f == OSFamilyPOSIX || f == OSFamilyWindows || f == OSFamilyWindowsAdmin
+}
+
+// [Account.EffectiveOSFamily] returns the account's OS family, defaulting to
+// OSFamilyPOSIX when unset or unknown.
+func (a Account) EffectiveOSFamily() string {
+	if IsValidOSFamily(a.OSFamily) {
+		return a.OSFamily
+	}
+	return OSFamilyPOSIX
+}
+
+// [IsWindowsOSFamily] reports whether f is one of the Windows families.
+func IsWindowsOSFamily(f string) bool {
+	return f == OSFamilyWindows || f == OSFamilyWindowsAdmin
+}
+
+// [Account.AuthorizedKeysPath] returns where the account's host reads
+// authorized_keys from, in the host's own path syntax.
+func (a Account) AuthorizedKeysPath() string {
+	switch a.EffectiveOSFamily() {
+	case OSFamilyWindows:
+		return `C:\Users\` + a.Username + `\.ssh\authorized_keys`
+	case OSFamilyWindowsAdmin:
+		return `C:\ProgramData\ssh\administrators_authorized_keys`
+	default:
+		return "~/.ssh/authorized_keys"
+	}
+}
+
+// Deploy modes for [Account.DeployMode].
+const (
+	// DeployModeReplace overwrites authorized_keys with the rendered content.
+	DeployModeReplace = "replace"
+	// DeployModeAppendOnly keeps keys Keymaster did not deploy, for shared
+	// accounts whose keys are managed by several parties.
+	DeployModeAppendOnly = "append-only"
+)
+
+// [IsValidDeployMode] reports whether m is a known deploy mode.
+func IsValidDeployMode(m string) bool {
+	return m == DeployModeReplace || m == DeployModeAppendOnly
+}
+
+// [Account.EffectiveDeployMode] returns the account's deploy mode,
+// defaulting to DeployModeReplace when unset or unknown.
+func (a Account) EffectiveDeployMode() string {
+	if IsValidDeployMode(a.DeployMode) {
+		return a.DeployMode
+	}
+	return DeployModeReplace
+}
+
+// Remediation policies for [Account.RemediationPolicy].
+const (
+	// RemediationAuto redeploys the expected authorized_keys on drift.
+	RemediationAuto = "auto"
+	// RemediationAlert reports drift without changing the host.
+	RemediationAlert = "alert"
+	// RemediationIgnore suppresses drift for hosts with expected local changes.
+	RemediationIgnore = "ignore"
+)
+
+// [IsValidRemediationPolicy] reports whether p is a known remediation policy.
+func IsValidRemediationPolicy(p string) bool {
+	return p == RemediationAuto || p == RemediationAlert || p == RemediationIgnore
+}
+
+// [Account.EffectiveRemediationPolicy] returns the account's remediation
+// policy, defaulting to RemediationAlert when unset or unknown.
+func (a Account) EffectiveRemediationPolicy() string {
+	if IsValidRemediationPolicy(a.RemediationPolicy) {
+		return a.RemediationPolicy
+	}
+	return RemediationAlert
 }
 
 // [Account.String] returns a user-friendly representation of the account.
@@ -50,6 +209,19 @@ type PublicKey struct {
 	IsGlobal bool
 	// ExpiresAt is the optional expiration time for this public key. A zero value means no expiration.
 	ExpiresAt time.Time
+	// Metadata holds free-form annotations such as an owner or ticket URL.
+	Metadata map[string]string
+	// IsCA marks the key as an SSH certificate authority: it is rendered
+	// with the cert-authority option, so the host accepts user certificates
+	// signed by it rather than the key itself.
+	IsCA bool
+	// Principals, for CA keys, restricts the certificate principals accepted
+	// for the account. Empty accepts a certificate listing the account name.
+	Principals []string
+	// AssignmentOptions holds the authorized_keys options of the key's
+	// assignment to one account (e.g. command="..."). It is only set on keys
+	// read for a specific account.
+	AssignmentOptions string
 }
 
 // [PublicKey.String] returns the full public key line suitable for an authorized_keys file.
@@ -57,6 +229,40 @@ func (k PublicKey) String() string {
 	return fmt.Sprintf("%s %s %s", k.Algorithm, k.KeyData, k.Comment)
 }
 
+// [PublicKey.AuthorizedKeysLine] renders the key as an authorized_keys line,
+// prefixed with the cert-authority options for CA keys and the assignment
+// options. The comment is left out when empty.
+func (k PublicKey) AuthorizedKeysLine() string {
+	line := k.Algorithm + " " + k.KeyData
+	if k.Comment != "" {
+		line += " " + k.Comment
+	}
+	var opts []string
+	if ca := CertAuthorityOptions(k.IsCA, k.Principals); ca != "" {
+		opts = append(opts, ca)
+	}
+	if k.AssignmentOptions != "" {
+		opts = append(opts, k.AssignmentOptions)
+	}
+	if len(opts) > 0 {
+		line = strings.Join(opts, ",") + " " + line
+	}
+	return line
+}
+
+// CertAuthorityOptions returns the authorized_keys options of a CA key:
+// "cert-authority", followed by a principals="..." restriction when
+// principals are given. It returns "" when isCA is false.
+func CertAuthorityOptions(isCA bool, principals []string) string {
+	if !isCA {
+		return ""
+	}
+	if len(principals) == 0 {
+		return "cert-authority"
+	}
+	return `cert-authority,principals="` + strings.Join(principals, ",") + `"`
+}
+
 // [Tag] links a [Link.TagMatcher] from a [Link].
 type Tag struct {
 	// [PK]
@@ -97,6 +303,8 @@ type SystemKey struct {
 	PrivateKey string // The private part of the key in PEM format.
 	// IsActive indicates if this is the current key for new deployments. Only one key can be active.
 	IsActive bool
+	// CreatedAt is when the key was generated; zero when unknown.
+	CreatedAt time.Time
 }
 
 // [AuditLogEntry] represents a single event in the audit log.
@@ -108,6 +316,42 @@ type AuditLogEntry struct {
 	Details   string // A free-text description of the event.
 }
 
+// Operations recorded in [OperationResult].
+const (
+	OperationDeploy = "deploy"
+	OperationAudit  = "audit"
+)
+
+// [OperationResult] is the structured outcome of one deploy or audit of an
+// account, kept for reliability trends alongside the free-text audit log.
+type OperationResult struct {
+	ID         int       // The primary key for the result.
+	RecordedAt time.Time // When the operation finished.
+	AccountID  int       // The account the operation ran against.
+	Operation  string    // OperationDeploy or OperationAudit.
+	Success    bool      // Whether the operation succeeded.
+	// ErrorCategory classifies a failure (e.g. "unreachable", "auth",
+	// "drift"); empty on success.
+	ErrorCategory string
+	Duration      time.Duration // How long the operation took.
+}
+
+// [DriftAnalysis] describes how a host's authorized_keys differs from the
+// content Keymaster expects to be deployed.
+type DriftAnalysis struct {
+	// Added holds key lines present on the host that Keymaster did not deploy.
+	Added []string `json:"added,omitempty"`
+	// Removed holds expected key lines that are missing from the host.
+	Removed []string `json:"removed,omitempty"`
+	// Expected is the authorized_keys content a deploy would write.
+	Expected string `json:"-"`
+	// Remote is the authorized_keys content found on the host.
+	Remote string `json:"-"`
+}
+
+// [DriftAnalysis.HasKeyDrift] reports whether any key was added or removed.
+func (d DriftAnalysis) HasKeyDrift() bool { return len(d.Added) > 0 || len(d.Removed) > 0 }
+
 // [BootstrapSession] represents an ongoing bootstrap operation for a new host.
 // Sessions track temporary keys and pending account information during the bootstrap workflow.
 type BootstrapSession struct {
@@ -121,3 +365,14 @@ type BootstrapSession struct {
 	ExpiresAt     time.Time // When the session expires.
 	Status        string    // Current status (active, committing, completed, failed, orphaned).
 }
+
+// [TableStats] reports the size of one database table as gathered by
+// db-maintain --analyze. Byte sizes are -1 when the backend cannot report
+// them.
+type TableStats struct {
+	Table      string // Table name.
+	Rows       int64  // Exact row count.
+	TableBytes int64  // On-disk size of the table data.
+	IndexBytes int64  // On-disk size of all indexes on the table.
+	Indexes    int    // Number of indexes on the table.
+}
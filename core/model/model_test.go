@@ -3,7 +3,10 @@
 // This source code is licensed under the MIT license found in the LICENSE file.
 package model
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+)
 
 func TestAccountString(t *testing.T) {
 	a := Account{Username: "deploy", Hostname: "web-01"}
@@ -17,6 +20,25 @@ func TestAccountString(t *testing.T) {
 	}
 }
 
+func TestAccount_AuthorizedKeysPath(t *testing.T) {
+	cases := map[string]string{
+		"":                   "~/.ssh/authorized_keys",
+		OSFamilyPOSIX:        "~/.ssh/authorized_keys",
+		"beos":               "~/.ssh/authorized_keys",
+		OSFamilyWindows:      `C:\Users\alice\.ssh\authorized_keys`,
+		OSFamilyWindowsAdmin: `C:\ProgramData\ssh\administrators_authorized_keys`,
+	}
+	for family, want := range cases {
+		a := Account{Username: "alice", OSFamily: family}
+		if got := a.AuthorizedKeysPath(); got != want {
+			t.Errorf("AuthorizedKeysPath(%q) = %q, want %q", family, got, want)
+		}
+	}
+	if got := (Account{OSFamily: "beos"}).EffectiveOSFamily(); got != OSFamilyPOSIX {
+		t.Errorf("expected unknown family to fall back to posix, got %q", got)
+	}
+}
+
 func TestPublicKeyString(t *testing.T) {
 	k := PublicKey{Algorithm: "ssh-ed25519", KeyData: "AAAAB3NzaC1lZDI1NTE5", Comment: "me@example.com"}
 	want := "ssh-ed25519 AAAAB3NzaC1lZDI1NTE5 me@example.com"
@@ -24,3 +46,112 @@ func TestPublicKeyString(t *testing.T) {
 		t.Errorf("unexpected PublicKey.String(): got %q want %q", got, want)
 	}
 }
+
+func TestBackupData_IncludesTable(t *testing.T) {
+	full := &BackupData{}
+	if !full.IncludesTable(BackupTableAuditLog) {
+		t.Fatalf("complete backup must include every table")
+	}
+	partial := &BackupData{Tables: []string{BackupTableAccounts}}
+	if !partial.IncludesTable(BackupTableAccounts) || partial.IncludesTable(BackupTableAuditLog) {
+		t.Fatalf("unexpected IncludesTable result for %v", partial.Tables)
+	}
+	if err := ValidateBackupTables([]string{BackupTableAccounts, "bogus"}); err == nil {
+		t.Fatalf("expected error for unknown table")
+	}
+	if err := ValidateBackupTables(BackupTables); err != nil {
+		t.Fatalf("ValidateBackupTables(BackupTables): %v", err)
+	}
+}
+
+func TestMetadata_RoundTripAndAssignments(t *testing.T) {
+	m := map[string]string{
+		"owner":  "alice@example.com",
+		"ticket": "https://tracker.example/browse?id=OPS-1&x=y",
+		"note":   "line1\nline2 \"quoted\" ünïcode = ok",
+	}
+	encoded, err := MarshalMetadata(m)
+	if err != nil {
+		t.Fatalf("MarshalMetadata: %v", err)
+	}
+	decoded, err := UnmarshalMetadata(encoded)
+	if err != nil || !reflect.DeepEqual(decoded, m) {
+		t.Fatalf("round trip mismatch: %v, %v", decoded, err)
+	}
+	if s, _ := MarshalMetadata(nil); s != "" {
+		t.Fatalf("expected empty encoding for nil metadata, got %q", s)
+	}
+	if got, err := UnmarshalMetadata(""); got != nil || err != nil {
+		t.Fatalf("expected nil for empty column, got %v, %v", got, err)
+	}
+	if _, err := UnmarshalMetadata("not json"); err == nil {
+		t.Fatal("expected error for invalid metadata")
+	}
+
+	updated, err := ApplyMetadataAssignments(m, []string{"ticket=", "url=https://x.example/?a=b", " team =ops"})
+	if err != nil {
+		t.Fatalf("ApplyMetadataAssignments: %v", err)
+	}
+	if _, ok := updated["ticket"]; ok || updated["url"] != "https://x.example/?a=b" || updated["team"] != "ops" {
+		t.Fatalf("unexpected metadata after assignments: %v", updated)
+	}
+	if _, ok := m["url"]; ok {
+		t.Fatal("ApplyMetadataAssignments modified its input")
+	}
+	if cleared, _ := ApplyMetadataAssignments(map[string]string{"a": "1"}, []string{"a="}); cleared != nil {
+		t.Fatalf("expected nil after removing the last key, got %v", cleared)
+	}
+	for _, bad := range []string{"novalue", "=value", "  =x"} {
+		if _, err := ApplyMetadataAssignments(nil, []string{bad}); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+	if keys := MetadataKeys(m); !reflect.DeepEqual(keys, []string{"note", "owner", "ticket"}) {
+		t.Fatalf("unexpected key order: %v", keys)
+	}
+}
+
+func TestAuditAccountIDs(t *testing.T) {
+	cases := map[string][]int{
+		"account: alice@web, " + AccountRef(7):    {7},
+		"account:12 alice@web":                    {12},
+		"account_id: 3, new_label: 'x'":           {3},
+		"key: 'k' to account: a@b, account_id:4":  {4},
+		"Starting decommission (ID: 5)":           nil,
+		"myaccount_id:9 account_id:10x":           nil,
+		"account_id:1 account_id:2 key_hash:abcd": {1, 2},
+	}
+	for details, want := range cases {
+		if got := AuditAccountIDs(details); !reflect.DeepEqual(got, want) {
+			t.Errorf("AuditAccountIDs(%q) = %v, want %v", details, got, want)
+		}
+	}
+	if !AuditReferencesAccount("account_id:1", 1) || AuditReferencesAccount("account_id:12", 1) {
+		t.Fatal("AuditReferencesAccount must match whole IDs only")
+	}
+}
+
+func TestAuditDetails_RoundTrip(t *testing.T) {
+	fields := AuditFields{AuditFieldAccountID: "7", AuditFieldKey: `ops "key"`}
+	details := EncodeAuditDetails("key: 'ops' to account: app@web", fields)
+	summary, got := DecodeAuditDetails(details)
+	if summary != "key: 'ops' to account: app@web" || !reflect.DeepEqual(got, fields) {
+		t.Fatalf("round trip: %q %v", summary, got)
+	}
+	entry := AuditLogEntry{Details: details}
+	if entry.Summary() != summary || entry.Fields()[AuditFieldAccountID] != "7" {
+		t.Fatalf("entry accessors: %q %v", entry.Summary(), entry.Fields())
+	}
+	if !AuditReferencesAccount(details, 7) || AuditReferencesAccount(details, 1) {
+		t.Fatalf("expected structured account_id to be matched exactly: %s", details)
+	}
+
+	for _, plain := range []string{"account: app@web, new_status: true", `{"not":"ours"}`, ""} {
+		if s, f := DecodeAuditDetails(plain); s != plain || f != nil {
+			t.Fatalf("plain details %q decoded as %q %v", plain, s, f)
+		}
+	}
+	if EncodeAuditDetails("just text", nil) != "just text" {
+		t.Fatal("details without fields must stay plain text")
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// Error categories recorded with failed operation results.
+const (
+	OperationErrorUnreachable = "unreachable"
+	OperationErrorAuth        = "auth"
+	OperationErrorHostKey     = "host_key"
+	OperationErrorDrift       = "drift"
+	OperationErrorPostDeploy  = "post_deploy"
+	OperationErrorOther       = "error"
+)
+
+// classifyOperationError maps a deploy or audit error to an error category.
+// Connection failures reach core wrapped in free text, so they are matched
+// on the same phrases the deploy package classifies them by.
+func classifyOperationError(err error) string {
+	if err == nil {
+		return ""
+	}
+	if errors.Is(err, ErrHostUnreachable) {
+		return OperationErrorUnreachable
+	}
+	var pde *PostDeployError
+	if errors.As(err, &pde) {
+		return OperationErrorPostDeploy
+	}
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "host key"):
+		return OperationErrorHostKey
+	case strings.Contains(msg, "unable to authenticate"),
+		strings.Contains(msg, "authentication failed"):
+		return OperationErrorAuth
+	case strings.Contains(msg, "timed out"),
+		strings.Contains(msg, "i/o timeout"),
+		strings.Contains(msg, "connection refused"),
+		strings.Contains(msg, "no route to host"),
+		strings.Contains(msg, "no such host"),
+		strings.Contains(msg, "unreachable"):
+		return OperationErrorUnreachable
+	}
+	return OperationErrorOther
+}
+
+// recordDeployResult stores a deploy result through the default
+// OperationResultRecorder.
+func recordDeployResult(res DeployResult) {
+	recordOperationResult(model.OperationDeploy, res.Account, res.Error, classifyOperationError(res.Error), res.Duration)
+}
+
+// recordAuditResult stores an audit result through the default
+// OperationResultRecorder. Audits that found drift are categorised as such.
+func recordAuditResult(res AuditResult) {
+	category := classifyOperationError(res.Error)
+	if res.Error != nil && res.Drift != nil {
+		category = OperationErrorDrift
+	}
+	recordOperationResult(model.OperationAudit, res.Account, res.Error, category, res.Duration)
+}
+
+func recordOperationResult(op string, acc model.Account, err error, category string, d time.Duration) {
+	rec := DefaultOperationResultRecorder()
+	if rec == nil {
+		return
+	}
+	r := model.OperationResult{
+		RecordedAt:    time.Now(),
+		AccountID:     acc.ID,
+		Operation:     op,
+		Success:       err == nil,
+		ErrorCategory: category,
+		Duration:      d,
+	}
+	if rerr := rec.RecordOperationResult(r); rerr != nil {
+		DefaultLogger().Warn("recording operation result failed", "account", acc.String(), "operation", op, "err", rerr)
+	}
+}
+
+// ReliabilityStats aggregates a set of operation results.
+type ReliabilityStats struct {
+	Total     int
+	Succeeded int
+	// Failures counts failed results by error category.
+	Failures map[string]int
+	// AverageDuration is the mean duration of all results.
+	AverageDuration time.Duration
+	// LastFailure is when the most recent failure was recorded; zero when
+	// nothing failed.
+	LastFailure time.Time
+}
+
+// Failed returns the number of failed results.
+func (s ReliabilityStats) Failed() int { return s.Total - s.Succeeded }
+
+// SuccessRate returns the fraction of successful results, or 0 when there
+// are none.
+func (s ReliabilityStats) SuccessRate() float64 {
+	if s.Total == 0 {
+		return 0
+	}
+	return float64(s.Succeeded) / float64(s.Total)
+}
+
+func (s *ReliabilityStats) add(r model.OperationResult, total *time.Duration) {
+	s.Total++
+	*total += r.Duration
+	s.AverageDuration = *total / time.Duration(s.Total)
+	if r.Success {
+		s.Succeeded++
+		return
+	}
+	if s.Failures == nil {
+		s.Failures = make(map[string]int)
+	}
+	category := r.ErrorCategory
+	if category == "" {
+		category = OperationErrorOther
+	}
+	s.Failures[category]++
+	if r.RecordedAt.After(s.LastFailure) {
+		s.LastFailure = r.RecordedAt
+	}
+}
+
+// ReliabilityReport summarizes the operation results of one account since a
+// point in time.
+type ReliabilityReport struct {
+	AccountID int
+	Since     time.Time
+	Overall   ReliabilityStats
+	// ByOperation holds the stats per operation (model.OperationDeploy,
+	// model.OperationAudit); operations without results are absent.
+	ByOperation map[string]ReliabilityStats
+}
+
+// SummarizeReliability aggregates results into a ReliabilityReport.
+func SummarizeReliability(accountID int, since time.Time, results []model.OperationResult) ReliabilityReport {
+	rep := ReliabilityReport{AccountID: accountID, Since: since, ByOperation: make(map[string]ReliabilityStats)}
+	var overall time.Duration
+	perOp := make(map[string]*time.Duration)
+	for _, r := range results {
+		rep.Overall.add(r, &overall)
+		total, ok := perOp[r.Operation]
+		if !ok {
+			total = new(time.Duration)
+			perOp[r.Operation] = total
+		}
+		s := rep.ByOperation[r.Operation]
+		s.add(r, total)
+		rep.ByOperation[r.Operation] = s
+	}
+	return rep
+}
+
+// AccountReliability summarizes the deploy and audit results recorded for an
+// account during the window before now.
+func AccountReliability(r OperationResultReader, accountID int, window time.Duration, now time.Time) (ReliabilityReport, error) {
+	if window <= 0 {
+		return ReliabilityReport{}, fmt.Errorf("window must be positive, got %s", window)
+	}
+	since := now.Add(-window)
+	results, err := r.GetOperationResults(accountID, since)
+	if err != nil {
+		return ReliabilityReport{}, fmt.Errorf("failed to read operation results: %w", err)
+	}
+	return SummarizeReliability(accountID, since, results), nil
+}
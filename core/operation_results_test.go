@@ -0,0 +1,153 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// memOperationResults records and serves operation results in memory.
+type memOperationResults struct {
+	results []model.OperationResult
+}
+
+func (m *memOperationResults) RecordOperationResult(r model.OperationResult) error {
+	m.results = append(m.results, r)
+	return nil
+}
+
+func (m *memOperationResults) GetOperationResults(accountID int, since time.Time) ([]model.OperationResult, error) {
+	var out []model.OperationResult
+	for _, r := range m.results {
+		if r.AccountID == accountID && !r.RecordedAt.Before(since) {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func withOperationRecorder(t *testing.T) *memOperationResults {
+	t.Helper()
+	m := &memOperationResults{}
+	orig := DefaultOperationResultRecorder()
+	SetDefaultOperationResultRecorder(m)
+	t.Cleanup(func() { SetDefaultOperationResultRecorder(orig) })
+	return m
+}
+
+func TestClassifyOperationError(t *testing.T) {
+	cases := []struct {
+		err  error
+		want string
+	}{
+		{nil, ""},
+		{fmt.Errorf("%w: web-01:22 timeout", ErrHostUnreachable), OperationErrorUnreachable},
+		{errors.New("connection to web-01 refused (SSH daemon may not be running or wrong port): dial tcp: connection refused"), OperationErrorUnreachable},
+		{errors.New("authentication failed for web-01 (check SSH keys or credentials): ssh: unable to authenticate"), OperationErrorAuth},
+		{errors.New("host key verification failed for web-01"), OperationErrorHostKey},
+		{&PostDeployError{Err: errors.New("exit 1")}, OperationErrorPostDeploy},
+		{errors.New("serial mismatch"), OperationErrorOther},
+	}
+	for _, c := range cases {
+		if got := classifyOperationError(c.err); got != c.want {
+			t.Errorf("classifyOperationError(%v) = %q, want %q", c.err, got, c.want)
+		}
+	}
+}
+
+func TestAccountReliability_AggregatesWindow(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	m := &memOperationResults{}
+	add := func(age time.Duration, accountID int, op string, ok bool, category string, d time.Duration) {
+		m.results = append(m.results, model.OperationResult{
+			RecordedAt: now.Add(-age), AccountID: accountID, Operation: op,
+			Success: ok, ErrorCategory: category, Duration: d,
+		})
+	}
+	add(40*24*time.Hour, 1, model.OperationDeploy, false, OperationErrorUnreachable, time.Second) // outside the window
+	add(20*24*time.Hour, 1, model.OperationDeploy, true, "", 2*time.Second)
+	add(10*24*time.Hour, 1, model.OperationDeploy, false, OperationErrorUnreachable, 4*time.Second)
+	add(5*24*time.Hour, 1, model.OperationAudit, false, OperationErrorDrift, time.Second)
+	add(24*time.Hour, 1, model.OperationAudit, true, "", time.Second)
+	add(time.Hour, 2, model.OperationDeploy, false, OperationErrorAuth, time.Second) // other account
+
+	rep, err := AccountReliability(m, 1, 30*24*time.Hour, now)
+	if err != nil {
+		t.Fatalf("AccountReliability: %v", err)
+	}
+	if !rep.Since.Equal(now.Add(-30 * 24 * time.Hour)) {
+		t.Fatalf("unexpected window start %v", rep.Since)
+	}
+	o := rep.Overall
+	if o.Total != 4 || o.Succeeded != 2 || o.Failed() != 2 || o.SuccessRate() != 0.5 {
+		t.Fatalf("unexpected overall stats %+v", o)
+	}
+	if o.Failures[OperationErrorUnreachable] != 1 || o.Failures[OperationErrorDrift] != 1 {
+		t.Fatalf("unexpected failure categories %v", o.Failures)
+	}
+	if o.AverageDuration != 2*time.Second {
+		t.Fatalf("expected average 2s, got %s", o.AverageDuration)
+	}
+	if !o.LastFailure.Equal(now.Add(-5 * 24 * time.Hour)) {
+		t.Fatalf("unexpected last failure %v", o.LastFailure)
+	}
+	deploy := rep.ByOperation[model.OperationDeploy]
+	if deploy.Total != 2 || deploy.Succeeded != 1 || deploy.AverageDuration != 3*time.Second {
+		t.Fatalf("unexpected deploy stats %+v", deploy)
+	}
+	audit := rep.ByOperation[model.OperationAudit]
+	if audit.Total != 2 || audit.Succeeded != 1 || audit.Failures[OperationErrorDrift] != 1 {
+		t.Fatalf("unexpected audit stats %+v", audit)
+	}
+}
+
+func TestAccountReliability_RejectsNonPositiveWindow(t *testing.T) {
+	if _, err := AccountReliability(&memOperationResults{}, 1, 0, time.Now()); err == nil {
+		t.Fatal("expected a zero window to be rejected")
+	}
+}
+
+func TestDeployAndAudit_RecordOperationResults(t *testing.T) {
+	m := withOperationRecorder(t)
+	st := &simpleFakeStore{accounts: canaryAccounts()[:2]}
+	dm := &canaryDM{
+		deployErr: map[int]error{2: errors.New("dial tcp web-01:22: i/o timeout")},
+		auditErr:  map[int]error{1: errors.New("serial mismatch")},
+	}
+
+	deployTargets(dm, st.accounts)
+	if _, err := AuditAccounts(context.Background(), st, dm, "serial", nil); err != nil {
+		t.Fatalf("AuditAccounts: %v", err)
+	}
+
+	want := []struct {
+		account  int
+		op       string
+		success  bool
+		category string
+	}{
+		{1, model.OperationDeploy, true, ""},
+		{2, model.OperationDeploy, false, OperationErrorUnreachable},
+		{1, model.OperationAudit, false, OperationErrorOther},
+		{2, model.OperationAudit, true, ""},
+	}
+	if len(m.results) != len(want) {
+		t.Fatalf("expected %d recorded results, got %+v", len(want), m.results)
+	}
+	for i, w := range want {
+		r := m.results[i]
+		if r.AccountID != w.account || r.Operation != w.op || r.Success != w.success || r.ErrorCategory != w.category {
+			t.Errorf("result %d = %+v, want %+v", i, r, w)
+		}
+		if r.RecordedAt.IsZero() {
+			t.Errorf("result %d has no timestamp", i)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"fmt"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// OSFamilyDeployer is implemented by RemoteDeployers that can adapt the
+// authorized_keys location and permission handling to the remote OS family.
+type OSFamilyDeployer interface {
+	SetOSFamily(family string)
+}
+
+// SetOSFamily validates family and stores it for account id. An empty family
+// restores the default (posix).
+func SetOSFamily(st OSFamilyStore, id int, family string) error {
+	if family != "" && !model.IsValidOSFamily(family) {
+		return fmt.Errorf("invalid OS family %q (use %s, %s or %s)", family, model.OSFamilyPOSIX, model.OSFamilyWindows, model.OSFamilyWindowsAdmin)
+	}
+	accounts, err := st.GetAllAccounts()
+	if err != nil {
+		return fmt.Errorf("failed to load accounts: %w", err)
+	}
+	for _, acc := range accounts {
+		if acc.ID == id {
+			if err := st.SetAccountOSFamily(id, family); err != nil {
+				return fmt.Errorf("failed to save OS family: %w", err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("account not found: %d", id)
+}
+
+// configureDeployer points deployer at the authorized_keys layout of the
+// account's host. Deployers that cannot be configured keep the POSIX layout;
+// they are rejected for Windows accounts rather than writing to the wrong
+// place.
+func configureDeployer(deployer RemoteDeployer, account model.Account) error {
+	family := account.EffectiveOSFamily()
+	if d, ok := deployer.(OSFamilyDeployer); ok {
+		d.SetOSFamily(family)
+		return nil
+	}
+	if family != model.OSFamilyPOSIX {
+		return fmt.Errorf("deployer does not support %s hosts", family)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/core/security"
+	"github.com/toeirei/keymaster/ui/i18n"
+)
+
+// familyRemote records the OS family it was configured for and the commands
+// it ran.
+type familyRemote struct {
+	captureRemote
+	family   string
+	commands []string
+}
+
+func (f *familyRemote) SetOSFamily(family string) { f.family = family }
+
+func (f *familyRemote) RunCommand(cmd string) (RemoteCommandResult, error) {
+	f.commands = append(f.commands, cmd)
+	return RemoteCommandResult{}, nil
+}
+
+type osFamilyStore struct {
+	accounts []model.Account
+	family   map[int]string
+}
+
+func (s *osFamilyStore) GetAllAccounts() ([]model.Account, error) { return s.accounts, nil }
+func (s *osFamilyStore) SetAccountOSFamily(id int, family string) error {
+	s.family[id] = family
+	return nil
+}
+
+func TestSetOSFamily(t *testing.T) {
+	st := &osFamilyStore{accounts: []model.Account{{ID: 3}}, family: map[int]string{}}
+	if err := SetOSFamily(st, 3, model.OSFamilyWindows); err != nil || st.family[3] != model.OSFamilyWindows {
+		t.Fatalf("expected windows to be stored, got %v %v", st.family, err)
+	}
+	if err := SetOSFamily(st, 3, "beos"); err == nil {
+		t.Fatal("expected an unknown family to be rejected")
+	}
+	if err := SetOSFamily(st, 4, model.OSFamilyPOSIX); err == nil {
+		t.Fatal("expected an unknown account to be rejected")
+	}
+}
+
+func TestDeployOptions_ForOSFamily(t *testing.T) {
+	opts := DeployOptions{PostDeployCommand: "sshd -t", PostDeployCommandWindows: "Get-Service sshd"}
+	if got := opts.ForOSFamily(model.OSFamilyPOSIX).PostDeployCommand; got != "sshd -t" {
+		t.Fatalf("posix: got %q", got)
+	}
+	if got := opts.ForOSFamily(model.OSFamilyWindowsAdmin).PostDeployCommand; got != "Get-Service sshd" {
+		t.Fatalf("windows-admin: got %q", got)
+	}
+	if got := (DeployOptions{PostDeployCommand: "sshd -t"}).ForOSFamily(model.OSFamilyWindows).PostDeployCommand; got != "" {
+		t.Fatalf("expected the POSIX command to be skipped on Windows, got %q", got)
+	}
+}
+
+func TestRunDeploymentForAccount_Windows(t *testing.T) {
+	i18n.Init("en")
+	SetDefaultKeyReader(&fakeKR{})
+	SetDefaultKeyLister(&aliceKL{})
+	origUpd := DefaultAccountSerialUpdater()
+	SetDefaultAccountSerialUpdater(&recordingUpdater{})
+	defer SetDefaultAccountSerialUpdater(origUpd)
+	origOpts := DefaultDeployOptions()
+	SetDefaultDeployOptions(DeployOptions{PostDeployCommand: "sshd -t", PostDeployCommandWindows: "Restart-Service sshd"})
+	defer SetDefaultDeployOptions(origOpts)
+	origFactory := NewDeployerFactory
+	defer func() { NewDeployerFactory = origFactory }()
+
+	remote := &familyRemote{}
+	NewDeployerFactory = func(host, user string, privateKey security.Secret, passphrase []byte) (RemoteDeployer, error) {
+		return remote, nil
+	}
+	acct := model.Account{ID: 9, Username: "alice", Hostname: "win-01", Serial: 1, OSFamily: model.OSFamilyWindows}
+	if err := RunDeploymentForAccount(acct, false); err != nil {
+		t.Fatalf("RunDeploymentForAccount: %v", err)
+	}
+	if remote.family != model.OSFamilyWindows || !strings.Contains(remote.deployed, "ALICE") {
+		t.Fatalf("expected a windows deploy, got family %q content %q", remote.family, remote.deployed)
+	}
+	if len(remote.commands) != 1 || remote.commands[0] != "Restart-Service sshd" {
+		t.Fatalf("expected the Windows post-deploy command, got %v", remote.commands)
+	}
+
+	// A deployer that cannot be pointed at the Windows layout must not
+	// silently write the POSIX path.
+	plain := &captureRemote{}
+	NewDeployerFactory = func(host, user string, privateKey security.Secret, passphrase []byte) (RemoteDeployer, error) {
+		return plain, nil
+	}
+	if err := RunDeploymentForAccount(acct, false); err == nil || plain.deployed != "" {
+		t.Fatalf("expected an unsupported deployer to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// PingStatus classifies the outcome of a TCP reachability check.
+type PingStatus string
+
+const (
+	// PingReachable: the SSH port accepted a TCP connection.
+	PingReachable PingStatus = "reachable"
+	// PingUnreachable: the connection was refused or could not be routed,
+	// or the host name did not resolve.
+	PingUnreachable PingStatus = "unreachable"
+	// PingTimeout: nothing answered within the timeout.
+	PingTimeout PingStatus = "timeout"
+)
+
+// ErrHostUnreachable marks audit results of hosts skipped because their SSH
+// port did not answer (see AuditOptions.OnlyReachable).
+var ErrHostUnreachable = errors.New("unreachable")
+
+// Defaults used by PingAccounts for zero PingOptions fields.
+const (
+	DefaultPingTimeout     = 3 * time.Second
+	DefaultPingConcurrency = 16
+)
+
+// PingOptions controls PingAccounts.
+type PingOptions struct {
+	// Timeout bounds each TCP dial.
+	Timeout time.Duration
+	// Concurrency is the number of hosts dialled at the same time.
+	Concurrency int
+}
+
+// PingResult is the reachability of one account's SSH port.
+type PingResult struct {
+	Account model.Account
+	// Address is the dialled host:port.
+	Address string
+	Status  PingStatus
+	// Latency is the time until the connection was accepted or failed.
+	Latency time.Duration
+	Err     error
+}
+
+// HostPortCanonicalizer resolves an account hostname to the host:port dialled
+// for SSH, adding the default port when none is given.
+type HostPortCanonicalizer interface {
+	CanonicalizeHostPort(host string) string
+}
+
+// pingDial is the dialler used by PingAccounts. Tests replace it.
+var pingDial = net.DialTimeout
+
+// PingAccounts dials the SSH port of every account over plain TCP, without
+// authenticating or reading anything, and classifies the outcome. At most
+// opts.Concurrency hosts are dialled at once. Results keep the order of
+// accounts; accounts not dialled because ctx was cancelled carry ctx.Err().
+func PingAccounts(ctx context.Context, hp HostPortCanonicalizer, accounts []model.Account, opts PingOptions) []PingResult {
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultPingTimeout
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultPingConcurrency
+	}
+
+	results := make([]PingResult, len(accounts))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, acc := range accounts {
+		results[i] = PingResult{Account: acc, Address: hp.CanonicalizeHostPort(acc.Hostname)}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Status, results[i].Err = PingUnreachable, ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func(r *PingResult) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			start := time.Now()
+			conn, err := pingDial("tcp", r.Address, timeout)
+			r.Latency = time.Since(start)
+			r.Status, r.Err = classifyPing(err)
+			if conn != nil {
+				_ = conn.Close()
+			}
+		}(&results[i])
+	}
+	wg.Wait()
+	return results
+}
+
+func classifyPing(err error) (PingStatus, error) {
+	if err == nil {
+		return PingReachable, nil
+	}
+	var nerr net.Error
+	if errors.As(err, &nerr) && nerr.Timeout() {
+		return PingTimeout, err
+	}
+	return PingUnreachable, err
+}
+
+// skipUnreachable pings accounts and returns audit wrapped so hosts that did
+// not answer get an ErrHostUnreachable result without an SSH attempt.
+func skipUnreachable(ctx context.Context, hp HostPortCanonicalizer, accounts []model.Account, timeout time.Duration, audit func(model.Account) (AuditResult, error)) func(model.Account) (AuditResult, error) {
+	down := make(map[int]PingResult)
+	for _, r := range PingAccounts(ctx, hp, accounts, PingOptions{Timeout: timeout}) {
+		if r.Status != PingReachable {
+			down[r.Account.ID] = r
+		}
+	}
+	if len(down) > 0 {
+		DefaultLogger().Info("skipping unreachable hosts", "unreachable", len(down), "accounts", len(accounts))
+	}
+	return func(acc model.Account) (AuditResult, error) {
+		r, ok := down[acc.ID]
+		if !ok {
+			return audit(acc)
+		}
+		res := AuditResult{
+			Account:  acc,
+			Error:    fmt.Errorf("%w: %s %s: %v", ErrHostUnreachable, r.Address, r.Status, r.Err),
+			Duration: r.Latency,
+		}
+		recordAuditResult(res)
+		return res, nil
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// defaultPortHP adds port 22 to hosts given without a port.
+type defaultPortHP struct{}
+
+func (defaultPortHP) CanonicalizeHostPort(host string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+	return net.JoinHostPort(host, "22")
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestPingAccounts_Classification(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer func() { _ = ln.Close() }()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+
+	// Grab a free port and close it again, so nothing listens there.
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	closedAddr := closed.Addr().String()
+	_ = closed.Close()
+
+	accounts := []model.Account{
+		{ID: 1, Username: "app", Hostname: ln.Addr().String()},
+		{ID: 2, Username: "app", Hostname: closedAddr},
+		{ID: 3, Username: "app", Hostname: "blackhole.invalid"},
+	}
+	// Only the black hole is faked; the other two use real dials.
+	orig := pingDial
+	pingDial = func(network, addr string, timeout time.Duration) (net.Conn, error) {
+		if strings.HasPrefix(addr, "blackhole.invalid") {
+			return nil, &net.OpError{Op: "dial", Net: network, Err: timeoutError{}}
+		}
+		return orig(network, addr, timeout)
+	}
+	t.Cleanup(func() { pingDial = orig })
+
+	results := PingAccounts(context.Background(), defaultPortHP{}, accounts, PingOptions{Timeout: 2 * time.Second})
+	want := []PingStatus{PingReachable, PingUnreachable, PingTimeout}
+	for i, r := range results {
+		if r.Account.ID != accounts[i].ID || r.Status != want[i] {
+			t.Fatalf("result %d: expected %s for account %d, got %+v", i, want[i], accounts[i].ID, r)
+		}
+	}
+	if results[0].Err != nil || results[1].Err == nil {
+		t.Fatalf("unexpected errors: %v / %v", results[0].Err, results[1].Err)
+	}
+	if results[2].Address != "blackhole.invalid:22" {
+		t.Fatalf("expected the default SSH port, got %s", results[2].Address)
+	}
+}
+
+func TestPingAccounts_BoundedConcurrency(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	orig := pingDial
+	pingDial = func(network, addr string, timeout time.Duration) (net.Conn, error) {
+		n := inFlight.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		inFlight.Add(-1)
+		return nil, &net.OpError{Op: "dial", Net: network, Err: timeoutError{}}
+	}
+	t.Cleanup(func() { pingDial = orig })
+
+	accounts := make([]model.Account, 10)
+	for i := range accounts {
+		accounts[i] = model.Account{ID: i + 1, Hostname: "host"}
+	}
+	results := PingAccounts(context.Background(), defaultPortHP{}, accounts, PingOptions{Concurrency: 3})
+	if len(results) != len(accounts) {
+		t.Fatalf("expected %d results, got %d", len(accounts), len(results))
+	}
+	if p := peak.Load(); p > 3 || p < 2 {
+		t.Fatalf("expected up to 3 parallel dials, peak was %d", p)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// DeployOptions holds settings applied to every deployment run through core.
+type DeployOptions struct {
+	// PostDeployCommand is executed on the remote host after a successful
+	// authorized_keys write. Empty disables the post-deploy step.
+	PostDeployCommand string
+	// PostDeployCommandWindows replaces PostDeployCommand for accounts on
+	// Windows hosts. Empty skips the post-deploy step there.
+	PostDeployCommandWindows string
+	// RollbackOnPostDeployFailure restores the previous authorized_keys when
+	// the post-deploy command fails.
+	RollbackOnPostDeployFailure bool
+	// LockTimeout caps how long a deployment waits for another deployment to
+	// the same account to finish. Zero waits until it is done.
+	LockTimeout time.Duration
+	// LockFailFast fails a deployment immediately when another deployment to
+	// the same account is in progress.
+	LockFailFast bool
+}
+
+var defaultDeployOptions DeployOptions
+
+// DefaultDeployOptions returns the package-level DeployOptions.
+func DefaultDeployOptions() DeployOptions { return defaultDeployOptions }
+
+// SetDefaultDeployOptions sets the package-level DeployOptions used by deployments.
+func SetDefaultDeployOptions(o DeployOptions) { defaultDeployOptions = o }
+
+// ForOSFamily returns o with PostDeployCommand set to the command for hosts
+// of the given OS family.
+func (o DeployOptions) ForOSFamily(family string) DeployOptions {
+	if model.IsWindowsOSFamily(family) {
+		o.PostDeployCommand = o.PostDeployCommandWindows
+	}
+	return o
+}
+
+// RemoteCommandResult captures the outcome of a command run on a remote host.
+type RemoteCommandResult struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+// RemoteCommandRunner is implemented by RemoteDeployers that can execute a
+// command over their existing connection.
+type RemoteCommandRunner interface {
+	RunCommand(cmd string) (RemoteCommandResult, error)
+}
+
+// PostDeployError is returned when the post-deploy command could not be run
+// or exited non-zero. The new authorized_keys content was written; RolledBack
+// reports whether the previous content was restored afterwards.
+type PostDeployError struct {
+	Command string
+	Result  RemoteCommandResult
+	// Err is set when the command could not be executed at all.
+	Err error
+	// RolledBack is true when the previous authorized_keys was restored.
+	RolledBack bool
+	// RollbackErr is set when a rollback was attempted but failed.
+	RollbackErr error
+}
+
+func (e *PostDeployError) Error() string {
+	var b strings.Builder
+	if e.Err != nil {
+		fmt.Fprintf(&b, "post-deploy command %q failed: %v", e.Command, e.Err)
+	} else {
+		fmt.Fprintf(&b, "post-deploy command %q exited with status %d", e.Command, e.Result.ExitCode)
+		if stderr := strings.TrimSpace(e.Result.Stderr); stderr != "" {
+			fmt.Fprintf(&b, ": %s", stderr)
+		}
+	}
+	switch {
+	case e.RollbackErr != nil:
+		fmt.Fprintf(&b, " (rollback failed: %v)", e.RollbackErr)
+	case e.RolledBack:
+		b.WriteString(" (previous authorized_keys restored)")
+	}
+	return b.String()
+}
+
+func (e *PostDeployError) Unwrap() error { return e.Err }
+
+// runPostDeployCommand executes opts.PostDeployCommand over deployer. When the
+// command fails and rollback is enabled, previous is written back; rollback is
+// skipped when there was no previous file, since an empty authorized_keys
+// would lock Keymaster out of the host.
+func runPostDeployCommand(deployer RemoteDeployer, opts DeployOptions, previous []byte, hadPrevious bool) error {
+	if opts.PostDeployCommand == "" {
+		return nil
+	}
+	perr := &PostDeployError{Command: opts.PostDeployCommand}
+	runner, ok := deployer.(RemoteCommandRunner)
+	if !ok {
+		perr.Err = fmt.Errorf("deployer does not support remote commands")
+	} else {
+		res, err := runner.RunCommand(opts.PostDeployCommand)
+		perr.Result = res
+		perr.Err = err
+		if err == nil && res.ExitCode == 0 {
+			return nil
+		}
+	}
+	if opts.RollbackOnPostDeployFailure && hadPrevious {
+		if err := deployer.DeployAuthorizedKeys(string(previous)); err != nil {
+			perr.RollbackErr = err
+		} else {
+			perr.RolledBack = true
+		}
+	}
+	return perr
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/db"
+	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/core/security"
+	"github.com/toeirei/keymaster/ui/i18n"
+)
+
+// cmdDeployer is a fake connection that records writes and answers commands.
+type cmdDeployer struct {
+	content []string
+	ran     []string
+	result  RemoteCommandResult
+	runErr  error
+}
+
+func (c *cmdDeployer) DeployAuthorizedKeys(content string) error {
+	c.content = append(c.content, content)
+	return nil
+}
+func (c *cmdDeployer) GetAuthorizedKeys() ([]byte, error) { return []byte("previous-content\n"), nil }
+func (c *cmdDeployer) Close()                             {}
+func (c *cmdDeployer) RunCommand(cmd string) (RemoteCommandResult, error) {
+	c.ran = append(c.ran, cmd)
+	return c.result, c.runErr
+}
+
+// setupPostDeployTest creates an account with an active system key and
+// installs fake as the deployer. It returns the account and active serial.
+func setupPostDeployTest(t *testing.T, fake RemoteDeployer, opts DeployOptions) (model.Account, int) {
+	t.Helper()
+	if _, err := db.New("sqlite", ":memory:"); err != nil {
+		t.Fatalf("db.New failed: %v", err)
+	}
+	i18n.Init("en")
+	// Other tests swap these defaults; make sure they hit the fresh DB.
+	SetDefaultKeyReader(testKeyReader{})
+	SetDefaultKeyLister(testKeyLister{})
+	SetDefaultAccountSerialUpdater(testAccountSerialUpdater{})
+	serial, err := db.CreateSystemKey("sys-pub-test", "sys-priv-test")
+	if err != nil {
+		t.Fatalf("CreateSystemKey failed: %v", err)
+	}
+	acctID, err := db.DefaultAccountManager().AddAccount("deployuser", "post.test", "", "")
+	if err != nil {
+		t.Fatalf("AddAccount failed: %v", err)
+	}
+
+	orig := NewDeployerFactory
+	NewDeployerFactory = func(host, user string, privateKey security.Secret, passphrase []byte) (RemoteDeployer, error) {
+		return fake, nil
+	}
+	origOpts := DefaultDeployOptions()
+	SetDefaultDeployOptions(opts)
+	t.Cleanup(func() {
+		NewDeployerFactory = orig
+		SetDefaultDeployOptions(origOpts)
+	})
+	return model.Account{ID: acctID, Username: "deployuser", Hostname: "post.test", IsActive: true, DeployEnabled: true}, serial
+}
+
+func accountSerial(t *testing.T, id int) int {
+	t.Helper()
+	accts, err := db.GetAllAccounts()
+	if err != nil {
+		t.Fatalf("GetAllAccounts: %v", err)
+	}
+	for _, a := range accts {
+		if a.ID == id {
+			return a.Serial
+		}
+	}
+	t.Fatalf("account %d not found", id)
+	return 0
+}
+
+func TestRunDeploymentForAccount_PostDeploySuccess(t *testing.T) {
+	fake := &cmdDeployer{}
+	acct, serial := setupPostDeployTest(t, fake, DeployOptions{PostDeployCommand: "sshd -t"})
+
+	if err := RunDeploymentForAccount(acct, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fake.ran) != 1 || fake.ran[0] != "sshd -t" {
+		t.Fatalf("expected post-deploy command to run once, got %v", fake.ran)
+	}
+	if got := accountSerial(t, acct.ID); got != serial {
+		t.Fatalf("expected serial %d, got %d", serial, got)
+	}
+}
+
+func TestRunDeploymentForAccount_PostDeployFailureReported(t *testing.T) {
+	fake := &cmdDeployer{result: RemoteCommandResult{ExitCode: 1, Stderr: "sshd: bad config"}}
+	acct, serial := setupPostDeployTest(t, fake, DeployOptions{PostDeployCommand: "sshd -t"})
+
+	results, err := DeployAccounts(context.TODO(), &simpleStore{accounts: []model.Account{acct}}, builtinDeployerManager{}, nil, nil)
+	if err != nil {
+		t.Fatalf("DeployAccounts: %v", err)
+	}
+	if len(results) != 1 || results[0].Error == nil {
+		t.Fatalf("expected failed deploy result, got %+v", results)
+	}
+	r := results[0]
+	if r.PostDeploy == nil || r.PostDeploy.ExitCode != 1 || r.PostDeploy.Stderr != "sshd: bad config" {
+		t.Fatalf("expected post-deploy output in result, got %+v", r.PostDeploy)
+	}
+	if !strings.Contains(r.Error.Error(), "exited with status 1") {
+		t.Fatalf("unexpected error message: %v", r.Error)
+	}
+	// Without rollback the new content stays in place, so the serial advances.
+	if len(fake.content) != 1 {
+		t.Fatalf("expected a single write without rollback, got %d", len(fake.content))
+	}
+	if got := accountSerial(t, acct.ID); got != serial {
+		t.Fatalf("expected serial %d without rollback, got %d", serial, got)
+	}
+}
+
+func TestRunDeploymentForAccount_PostDeployFailureRollsBack(t *testing.T) {
+	fake := &cmdDeployer{result: RemoteCommandResult{ExitCode: 2}}
+	acct, _ := setupPostDeployTest(t, fake, DeployOptions{PostDeployCommand: "systemctl reload sshd", RollbackOnPostDeployFailure: true})
+
+	err := RunDeploymentForAccount(acct, false)
+	var pde *PostDeployError
+	if !errors.As(err, &pde) {
+		t.Fatalf("expected PostDeployError, got %v", err)
+	}
+	if !pde.RolledBack {
+		t.Fatalf("expected rollback, got %+v", pde)
+	}
+	if len(fake.content) != 2 || fake.content[1] != "previous-content\n" {
+		t.Fatalf("expected previous content to be restored, got %q", fake.content)
+	}
+	if got := accountSerial(t, acct.ID); got != 0 {
+		t.Fatalf("serial must not advance after rollback, got %d", got)
+	}
+}
+
+func TestRunDeploymentForAccount_PostDeployUnsupportedDeployer(t *testing.T) {
+	acct, _ := setupPostDeployTest(t, &fakeDeployer{}, DeployOptions{PostDeployCommand: "true"})
+
+	err := RunDeploymentForAccount(acct, false)
+	var pde *PostDeployError
+	if !errors.As(err, &pde) || pde.Err == nil {
+		t.Fatalf("expected PostDeployError with transport error, got %v", err)
+	}
+}
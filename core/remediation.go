@@ -0,0 +1,109 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"fmt"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// RemediationOutcome records what ApplyRemediationPolicies did for one
+// drifted account.
+type RemediationOutcome struct {
+	Account model.Account
+	// Policy is the effective policy that was applied.
+	Policy string
+	// Err is set when an automatic remediation failed.
+	Err error
+}
+
+// SetRemediationPolicy validates policy and stores it for account id. An
+// empty policy restores the default (alert).
+func SetRemediationPolicy(st RemediationPolicyStore, id int, policy string) error {
+	if policy != "" && !model.IsValidRemediationPolicy(policy) {
+		return fmt.Errorf("invalid remediation policy %q (use %s, %s or %s)", policy, model.RemediationAuto, model.RemediationAlert, model.RemediationIgnore)
+	}
+	accounts, err := st.GetAllAccounts()
+	if err != nil {
+		return fmt.Errorf("failed to load accounts: %w", err)
+	}
+	found := false
+	for _, acc := range accounts {
+		if acc.ID == id {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("account not found: %d", id)
+	}
+	if err := st.SetAccountRemediationPolicy(id, policy); err != nil {
+		return fmt.Errorf("failed to save remediation policy: %w", err)
+	}
+	return nil
+}
+
+// RemediateAccount restores the expected authorized_keys on a drifted host
+// by redeploying it.
+func RemediateAccount(dm DeployerManager, account model.Account) error {
+	if err := dm.DeployForAccount(account, false); err != nil {
+		return fmt.Errorf("remediate %s: %w", account.String(), err)
+	}
+	if aw := DefaultAuditWriter(); aw != nil {
+		_ = aw.LogAction("DRIFT_REMEDIATED", fmt.Sprintf("%s %s", model.AccountRef(account.ID), account.String()))
+	}
+	DefaultLogger().Info("remediated drift", "account", account.String())
+	return nil
+}
+
+// ApplyRemediationPolicies applies each drifted account's remediation policy
+// to the results of a strict audit and returns the adjusted results:
+//   - auto redeploys the host; its drift no longer fails the run when the
+//     redeploy succeeded. Accounts with deploys disabled are alerted on
+//     instead.
+//   - alert keeps the failure and writes an AUDIT_DRIFT_ALERT audit entry.
+//   - ignore drops the drift from the results.
+//
+// Results that failed for other reasons, such as unreachable hosts, are
+// returned unchanged.
+func ApplyRemediationPolicies(dm DeployerManager, results []AuditResult) ([]AuditResult, []RemediationOutcome) {
+	lg := DefaultLogger()
+	adjusted := make([]AuditResult, 0, len(results))
+	var outcomes []RemediationOutcome
+	for _, r := range results {
+		if r.Error == nil || r.Drift == nil {
+			adjusted = append(adjusted, r)
+			continue
+		}
+		policy := r.Account.EffectiveRemediationPolicy()
+		if policy == model.RemediationAuto && !r.Account.DeployEnabled {
+			// Deploys are paused for this account; report the drift instead.
+			lg.Info("deploys disabled; not remediating drift", "account", r.Account.String())
+			policy = model.RemediationAlert
+		}
+		outcome := RemediationOutcome{Account: r.Account, Policy: policy}
+		switch policy {
+		case model.RemediationAuto:
+			if err := RemediateAccount(dm, r.Account); err != nil {
+				outcome.Err = err
+				lg.Error("drift remediation failed", "account", r.Account.String(), "err", err)
+			} else {
+				r.Error = nil
+			}
+		case model.RemediationIgnore:
+			lg.Debug("ignoring drift", "account", r.Account.String())
+			r.Error = nil
+			r.Drift = nil
+		default:
+			lg.Warn("drift detected", "account", r.Account.String(), "added", len(r.Drift.Added), "removed", len(r.Drift.Removed))
+			if aw := DefaultAuditWriter(); aw != nil {
+				_ = aw.LogAction("AUDIT_DRIFT_ALERT", fmt.Sprintf("%s %s", model.AccountRef(r.Account.ID), r.Account.String()))
+			}
+		}
+		adjusted = append(adjusted, r)
+		outcomes = append(outcomes, outcome)
+	}
+	return adjusted, outcomes
+}
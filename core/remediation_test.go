@@ -0,0 +1,114 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+func driftedResult(id int, policy string) AuditResult {
+	return AuditResult{
+		Account: model.Account{ID: id, Username: "app", Hostname: "host", RemediationPolicy: policy, DeployEnabled: true},
+		Error:   errors.New("drift detected"),
+		Drift:   &model.DriftAnalysis{Added: []string{"ssh-ed25519 AAAA intruder"}},
+	}
+}
+
+func TestApplyRemediationPolicies(t *testing.T) {
+	aw := &spyAuditWriter{}
+	orig := DefaultAuditWriter()
+	SetDefaultAuditWriter(aw)
+	defer SetDefaultAuditWriter(orig)
+
+	dm := &canaryDM{}
+	unreachable := AuditResult{Account: model.Account{ID: 5, RemediationPolicy: model.RemediationAuto}, Error: errors.New("connection refused")}
+	results := []AuditResult{
+		driftedResult(1, model.RemediationAuto),
+		driftedResult(2, model.RemediationAlert),
+		driftedResult(3, model.RemediationIgnore),
+		driftedResult(4, ""),
+		unreachable,
+	}
+
+	adjusted, outcomes := ApplyRemediationPolicies(dm, results)
+
+	if !reflect.DeepEqual(dm.deployed, []int{1}) {
+		t.Fatalf("expected only the auto account to be redeployed, got %v", dm.deployed)
+	}
+	if adjusted[0].Error != nil {
+		t.Fatalf("auto: expected remediated drift to pass, got %v", adjusted[0].Error)
+	}
+	if adjusted[1].Error == nil || adjusted[3].Error == nil {
+		t.Fatal("alert and default policy must keep the drift failure")
+	}
+	if adjusted[2].Error != nil || adjusted[2].Drift != nil {
+		t.Fatalf("ignore: expected drift to be suppressed, got %+v", adjusted[2])
+	}
+	if adjusted[4].Error == nil {
+		t.Fatal("non-drift failures must be left untouched")
+	}
+	if AuditExitCode(adjusted) != AuditExitDrift {
+		t.Fatal("expected alerted drift to keep the drift exit code")
+	}
+
+	var policies []string
+	for _, o := range outcomes {
+		policies = append(policies, o.Policy)
+	}
+	if !reflect.DeepEqual(policies, []string{"auto", "alert", "ignore", "alert"}) {
+		t.Fatalf("unexpected outcomes %v", policies)
+	}
+
+	joined := strings.Join(aw.actions, "\n")
+	if !strings.Contains(joined, "DRIFT_REMEDIATED:account_id:1") ||
+		!strings.Contains(joined, "AUDIT_DRIFT_ALERT:account_id:2") ||
+		!strings.Contains(joined, "AUDIT_DRIFT_ALERT:account_id:4") ||
+		strings.Contains(joined, "account_id:3") {
+		t.Fatalf("unexpected audit entries:\n%s", joined)
+	}
+}
+
+func TestApplyRemediationPolicies_AutoFailureKeepsDrift(t *testing.T) {
+	dm := &canaryDM{deployErr: map[int]error{1: errors.New("permission denied")}}
+	adjusted, outcomes := ApplyRemediationPolicies(dm, []AuditResult{driftedResult(1, model.RemediationAuto)})
+	if adjusted[0].Error == nil || len(outcomes) != 1 || outcomes[0].Err == nil {
+		t.Fatalf("expected failed remediation to keep the drift, got %+v %+v", adjusted, outcomes)
+	}
+}
+
+// remediationStore records policy updates.
+type remediationStore struct {
+	accounts []model.Account
+	set      map[int]string
+}
+
+func (s *remediationStore) GetAllAccounts() ([]model.Account, error) { return s.accounts, nil }
+func (s *remediationStore) SetAccountRemediationPolicy(id int, policy string) error {
+	if s.set == nil {
+		s.set = make(map[int]string)
+	}
+	s.set[id] = policy
+	return nil
+}
+
+func TestSetRemediationPolicy(t *testing.T) {
+	st := &remediationStore{accounts: []model.Account{{ID: 1}}}
+	if err := SetRemediationPolicy(st, 1, model.RemediationIgnore); err != nil {
+		t.Fatalf("SetRemediationPolicy: %v", err)
+	}
+	if st.set[1] != model.RemediationIgnore {
+		t.Fatalf("policy not stored: %v", st.set)
+	}
+	if err := SetRemediationPolicy(st, 1, "sometimes"); err == nil {
+		t.Fatal("expected error for invalid policy")
+	}
+	if err := SetRemediationPolicy(st, 2, model.RemediationAuto); err == nil {
+		t.Fatal("expected error for unknown account")
+	}
+}
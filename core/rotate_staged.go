@@ -0,0 +1,128 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// RunStageRotateKeyCmd generates a new system key and stores it as the active
+// key for deploys without deactivating the previous ones, so hosts not yet
+// migrated stay reachable with their old key. FinalizeSystemKeyRotation ends
+// the staged rotation.
+func RunStageRotateKeyCmd(ctx context.Context, kg KeyGenerator, st Store, passphrase string) (int, error) {
+	pub, priv, err := kg.GenerateAndMarshalEd25519Key("keymaster-system-key", passphrase)
+	if err != nil {
+		return 0, fmt.Errorf("generate key: %w", err)
+	}
+	var serial int
+	err = withGlobalLock(st, LockRotateKey, func() error {
+		var cerr error
+		serial, cerr = st.CreateSystemKey(pub, priv)
+		return cerr
+	})
+	return serial, err
+}
+
+// RolloutIncompleteError is returned by FinalizeSystemKeyRotation when some
+// deployable hosts are not confirmed to use the newest system key. Nothing is
+// deactivated in that case.
+type RolloutIncompleteError struct {
+	Serial int
+	// Pending lists the accounts not yet deployed with Serial, or whose
+	// serial audit failed, with the reason.
+	Pending []RolloutPending
+}
+
+// RolloutPending is a host blocking FinalizeSystemKeyRotation.
+type RolloutPending struct {
+	Account model.Account
+	Reason  string
+}
+
+func (e *RolloutIncompleteError) Error() string {
+	names := make([]string, 0, len(e.Pending))
+	for _, p := range e.Pending {
+		names = append(names, p.Account.String())
+	}
+	return fmt.Sprintf("%d host(s) not confirmed on system key serial %d: %s; no system keys were deactivated", len(e.Pending), e.Serial, strings.Join(names, ", "))
+}
+
+// FinalizeResult reports the outcome of FinalizeSystemKeyRotation.
+type FinalizeResult struct {
+	// Serial is the serial of the newest active key, which stays active.
+	Serial int
+	// Deactivated lists the superseded serials that were deactivated.
+	Deactivated []int
+}
+
+// FinalizeSystemKeyRotation ends a staged rotation started with
+// RunStageRotateKeyCmd. Every deployable account must have been deployed with
+// the newest active key and pass a serial audit through dm; only then are the
+// older active keys deactivated. Otherwise a *RolloutIncompleteError lists
+// the hosts still to migrate.
+func FinalizeSystemKeyRotation(ctx context.Context, st Store, dm DeployerManager) (FinalizeResult, error) {
+	var res FinalizeResult
+	active, err := st.GetActiveSystemKey()
+	if err != nil {
+		return res, fmt.Errorf("get active system key: %w", err)
+	}
+	if active == nil {
+		return res, errors.New("no active system key found")
+	}
+	res.Serial = active.Serial
+	keys, err := st.GetAllSystemKeys()
+	if err != nil {
+		return res, fmt.Errorf("get system keys: %w", err)
+	}
+	var superseded []int
+	for _, k := range keys {
+		if k.IsActive && k.Serial < active.Serial {
+			superseded = append(superseded, k.Serial)
+		}
+	}
+	if len(superseded) == 0 {
+		return res, nil
+	}
+	deactivator, ok := st.(SystemKeyDeactivator)
+	if !ok {
+		return res, errors.New("store does not support deactivating system keys")
+	}
+
+	accounts, err := st.GetAllActiveAccounts()
+	if err != nil {
+		return res, fmt.Errorf("get accounts: %w", err)
+	}
+	var pending []RolloutPending
+	for _, acc := range DeployableAccounts(accounts) {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		if acc.Serial != active.Serial {
+			pending = append(pending, RolloutPending{Account: acc, Reason: fmt.Sprintf("deployed with serial %d", acc.Serial)})
+			continue
+		}
+		if err := dm.AuditSerial(acc); err != nil {
+			pending = append(pending, RolloutPending{Account: acc, Reason: fmt.Sprintf("serial audit failed: %v", err)})
+		}
+	}
+	if len(pending) > 0 {
+		return res, &RolloutIncompleteError{Serial: active.Serial, Pending: pending}
+	}
+
+	err = withGlobalLock(st, LockRotateKey, func() error {
+		_, derr := deactivator.DeactivateSystemKeysBelow(active.Serial)
+		return derr
+	})
+	if err != nil {
+		return res, fmt.Errorf("deactivate superseded system keys: %w", err)
+	}
+	res.Deactivated = superseded
+	return res, nil
+}
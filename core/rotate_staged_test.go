@@ -0,0 +1,143 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// stagedKeyStore keeps system keys in memory the way the database does:
+// CreateSystemKey adds an active key without deactivating the others and the
+// newest active key is the one used for deploys.
+type stagedKeyStore struct {
+	simpleFakeStore
+	keys []model.SystemKey
+}
+
+func (s *stagedKeyStore) CreateSystemKey(publicKey, privateKey string) (int, error) {
+	serial := len(s.keys) + 1
+	s.keys = append(s.keys, model.SystemKey{Serial: serial, PublicKey: publicKey, PrivateKey: privateKey, IsActive: true})
+	return serial, nil
+}
+
+func (s *stagedKeyStore) GetAllSystemKeys() ([]model.SystemKey, error) { return s.keys, nil }
+
+func (s *stagedKeyStore) GetActiveSystemKey() (*model.SystemKey, error) {
+	for i := len(s.keys) - 1; i >= 0; i-- {
+		if s.keys[i].IsActive {
+			k := s.keys[i]
+			return &k, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *stagedKeyStore) DeactivateSystemKeysBelow(serial int) (int, error) {
+	n := 0
+	for i := range s.keys {
+		if s.keys[i].IsActive && s.keys[i].Serial < serial {
+			s.keys[i].IsActive = false
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *stagedKeyStore) active() []int {
+	var out []int
+	for _, k := range s.keys {
+		if k.IsActive {
+			out = append(out, k.Serial)
+		}
+	}
+	return out
+}
+
+// failingSerialDM fails the serial audit of the listed account IDs.
+type failingSerialDM struct {
+	fakeDeployerManager
+	fail map[int]bool
+}
+
+func (d *failingSerialDM) AuditSerial(account model.Account) error {
+	if d.fail[account.ID] {
+		return errors.New("serial mismatch")
+	}
+	return nil
+}
+
+func stagedFixture(t *testing.T) *stagedKeyStore {
+	t.Helper()
+	st := &stagedKeyStore{keys: []model.SystemKey{{Serial: 1, PublicKey: "old-pub", PrivateKey: "old-priv", IsActive: true}}}
+	serial, err := RunStageRotateKeyCmd(context.Background(), &fKG{pub: "new-pub", priv: "new-priv"}, st, "")
+	if err != nil || serial != 2 {
+		t.Fatalf("RunStageRotateKeyCmd: serial %d, err %v", serial, err)
+	}
+	return st
+}
+
+func TestStageRotateKey_BothKeysUsable(t *testing.T) {
+	st := stagedFixture(t)
+	if !reflect.DeepEqual(st.active(), []int{1, 2}) {
+		t.Fatalf("expected the old and the staged key active, got %v", st.active())
+	}
+	active, err := st.GetActiveSystemKey()
+	if err != nil || active.Serial != 2 || active.PublicKey != "new-pub" {
+		t.Fatalf("expected the staged key used for deploys, got %+v, %v", active, err)
+	}
+}
+
+func TestFinalizeRotation_DeactivatesSupersededKeys(t *testing.T) {
+	st := stagedFixture(t)
+	st.accounts = []model.Account{
+		{ID: 1, Username: "app", Hostname: "web-01", Serial: 2, IsActive: true, DeployEnabled: true},
+		{ID: 2, Username: "app", Hostname: "web-02", Serial: 1, IsActive: true, DeployEnabled: false},
+	}
+
+	res, err := FinalizeSystemKeyRotation(context.Background(), st, &failingSerialDM{})
+	if err != nil {
+		t.Fatalf("FinalizeSystemKeyRotation: %v", err)
+	}
+	if res.Serial != 2 || !reflect.DeepEqual(res.Deactivated, []int{1}) {
+		t.Fatalf("expected serial 1 deactivated, got %+v", res)
+	}
+	if !reflect.DeepEqual(st.active(), []int{2}) {
+		t.Fatalf("expected only the new key active, got %v", st.active())
+	}
+
+	res, err = FinalizeSystemKeyRotation(context.Background(), st, &failingSerialDM{})
+	if err != nil || len(res.Deactivated) != 0 {
+		t.Fatalf("expected nothing left to finalize, got %+v, %v", res, err)
+	}
+}
+
+func TestFinalizeRotation_RefusesIncompleteRollout(t *testing.T) {
+	st := stagedFixture(t)
+	st.accounts = []model.Account{
+		{ID: 1, Username: "app", Hostname: "web-01", Serial: 2, IsActive: true, DeployEnabled: true},
+		{ID: 2, Username: "app", Hostname: "web-02", Serial: 1, IsActive: true, DeployEnabled: true},
+		{ID: 3, Username: "app", Hostname: "web-03", Serial: 2, IsActive: true, DeployEnabled: true},
+	}
+
+	_, err := FinalizeSystemKeyRotation(context.Background(), st, &failingSerialDM{fail: map[int]bool{3: true}})
+	var incomplete *RolloutIncompleteError
+	if !errors.As(err, &incomplete) {
+		t.Fatalf("expected RolloutIncompleteError, got %v", err)
+	}
+	var pending []int
+	for _, p := range incomplete.Pending {
+		pending = append(pending, p.Account.ID)
+	}
+	if !reflect.DeepEqual(pending, []int{2, 3}) {
+		t.Fatalf("expected the host on the old serial and the failed audit pending, got %+v", incomplete.Pending)
+	}
+	if !reflect.DeepEqual(st.active(), []int{1, 2}) {
+		t.Fatalf("expected no key deactivated, got %v", st.active())
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TOTP parameters as used by common authenticator apps (RFC 6238 defaults).
+const (
+	TOTPDigits = 6
+	TOTPPeriod = 30 * time.Second
+	// TOTPSkew is the number of periods before and after the current one
+	// whose codes are still accepted, to tolerate clock drift and typing.
+	TOTPSkew = 1
+)
+
+// ErrInvalidTOTPCode is returned by VerifyTOTP for a wrong or expired code.
+var ErrInvalidTOTPCode = errors.New("invalid or expired TOTP code")
+
+// ParseTOTPSecret decodes a base32 TOTP secret as shown by authenticator
+// apps. Case, spaces and padding are ignored.
+func ParseTOTPSecret(s string) (Secret, error) {
+	s = strings.ToUpper(strings.Join(strings.Fields(s), ""))
+	s = strings.TrimRight(s, "=")
+	if s == "" {
+		return nil, errors.New("empty TOTP secret")
+	}
+	raw, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("TOTP secret is not valid base32: %w", err)
+	}
+	if len(raw) < 10 {
+		return nil, fmt.Errorf("TOTP secret is too short (%d bytes, need at least 10)", len(raw))
+	}
+	return Secret(raw), nil
+}
+
+// TOTPCode returns the code for secret in the period containing t.
+func TOTPCode(secret Secret, t time.Time) string {
+	return hotp(secret, uint64(t.Unix())/uint64(TOTPPeriod/time.Second))
+}
+
+// VerifyTOTP checks code against secret at time now, accepting the codes of
+// TOTPSkew periods around now. It returns ErrInvalidTOTPCode on mismatch.
+func VerifyTOTP(secret Secret, code string, now time.Time) error {
+	code = strings.TrimSpace(code)
+	if len(code) != TOTPDigits {
+		return ErrInvalidTOTPCode
+	}
+	counter := uint64(now.Unix()) / uint64(TOTPPeriod/time.Second)
+	ok := 0
+	for d := -TOTPSkew; d <= TOTPSkew; d++ {
+		c := int64(counter) + int64(d)
+		if c < 0 {
+			continue
+		}
+		// Compare every candidate so timing does not reveal which matched.
+		ok |= subtle.ConstantTimeCompare([]byte(hotp(secret, uint64(c))), []byte(code))
+	}
+	if ok != 1 {
+		return ErrInvalidTOTPCode
+	}
+	return nil
+}
+
+// hotp computes the RFC 4226 HMAC-SHA1 one-time password for counter.
+func hotp(secret Secret, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < TOTPDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", TOTPDigits, value%mod)
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package security
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA1 seed of the RFC 6238 test vectors,
+// "12345678901234567890", in base32 and lower case with spaces.
+const rfc6238Secret = "gezd gnbv gy3t qojq gezd gnbv gy3t qojq"
+
+func TestTOTPCode_RFC6238Vectors(t *testing.T) {
+	secret, err := ParseTOTPSecret(rfc6238Secret)
+	if err != nil {
+		t.Fatalf("ParseTOTPSecret: %v", err)
+	}
+	// The RFC lists 8-digit codes; 6-digit codes are their last 6 digits.
+	for unix, want := range map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	} {
+		if got := TOTPCode(secret, time.Unix(unix, 0)); got != want {
+			t.Errorf("TOTPCode at %d = %s, want %s", unix, got, want)
+		}
+	}
+}
+
+func TestVerifyTOTP_FixedClock(t *testing.T) {
+	secret, err := ParseTOTPSecret(rfc6238Secret)
+	if err != nil {
+		t.Fatalf("ParseTOTPSecret: %v", err)
+	}
+	now := time.Unix(1111111111, 0)
+	code := TOTPCode(secret, now)
+
+	if err := VerifyTOTP(secret, code, now); err != nil {
+		t.Fatalf("valid code rejected: %v", err)
+	}
+	if err := VerifyTOTP(secret, " "+code+"\n", now); err != nil {
+		t.Fatalf("surrounding whitespace should be ignored: %v", err)
+	}
+	// One period of drift either way is tolerated.
+	if err := VerifyTOTP(secret, code, now.Add(TOTPPeriod)); err != nil {
+		t.Fatalf("code from the previous period rejected: %v", err)
+	}
+	if err := VerifyTOTP(secret, TOTPCode(secret, now.Add(TOTPPeriod)), now); err != nil {
+		t.Fatalf("code from the next period rejected: %v", err)
+	}
+
+	invalid := "000000"
+	if invalid == code {
+		invalid = "111111"
+	}
+	for name, tc := range map[string]struct {
+		code string
+		at   time.Time
+	}{
+		"invalid":   {invalid, now},
+		"expired":   {code, now.Add(2 * TOTPPeriod)},
+		"too short": {code[:5], now},
+		"empty":     {"", now},
+	} {
+		if err := VerifyTOTP(secret, tc.code, tc.at); !errors.Is(err, ErrInvalidTOTPCode) {
+			t.Errorf("%s: expected ErrInvalidTOTPCode, got %v", name, err)
+		}
+	}
+}
+
+func TestParseTOTPSecret_Invalid(t *testing.T) {
+	for _, s := range []string{"", "   ", "not base32!", "GEZDGNBV"} {
+		if _, err := ParseTOTPSecret(s); err == nil {
+			t.Errorf("ParseTOTPSecret(%q): expected an error", s)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/core/security"
+	"github.com/toeirei/keymaster/core/state"
+	"github.com/toeirei/keymaster/ui/i18n"
+)
+
+// ShellTarget is what is needed to open an interactive session on an account.
+type ShellTarget struct {
+	// Host is the canonical host:port of the account.
+	Host string
+	User string
+	// ProxyJump is the bastion chain from the account's proxyjump tag, in
+	// ssh's ProxyJump syntax ([user@]host[:port], comma separated); empty
+	// for a direct connection.
+	ProxyJump string
+	// PrivateKey is the system key the account was last deployed with, or
+	// the active one for accounts never deployed.
+	PrivateKey security.Secret
+}
+
+// ShellIO is the terminal an interactive session is attached to.
+type ShellIO struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// ShellExitError reports a remote shell that exited with a non-zero status.
+type ShellExitError struct {
+	Status int
+}
+
+func (e *ShellExitError) Error() string {
+	return fmt.Sprintf("remote shell exited with status %d", e.Status)
+}
+
+// OpenShellFunc opens an interactive shell on target attached to stdio. The
+// deploy package registers the SSH implementation at init time.
+var OpenShellFunc = func(target ShellTarget, passphrase []byte, stdio ShellIO) error {
+	return errors.New("no shell support configured")
+}
+
+// ShellTargetForAccount resolves the connection details of account: its
+// canonical address, the proxyjump tag and the system key deploys connect
+// with.
+func ShellTargetForAccount(kr KeyReader, account model.Account) (ShellTarget, error) {
+	if kr == nil {
+		return ShellTarget{}, errors.New("no key reader configured")
+	}
+	var sk *model.SystemKey
+	var err error
+	if account.Serial == 0 {
+		if sk, err = kr.GetActiveSystemKey(); err != nil {
+			return ShellTarget{}, fmt.Errorf(i18n.T("deploy.error_get_bootstrap_key"), err)
+		}
+		if sk == nil {
+			return ShellTarget{}, errors.New(i18n.T("deploy.error_no_bootstrap_key"))
+		}
+	} else {
+		if sk, err = kr.GetSystemKeyBySerial(account.Serial); err != nil {
+			return ShellTarget{}, fmt.Errorf(i18n.T("deploy.error_get_serial_key"), account.Serial, err)
+		}
+		if sk == nil {
+			return ShellTarget{}, fmt.Errorf(i18n.T("deploy.error_no_serial_key"), account.Serial)
+		}
+	}
+	return ShellTarget{
+		Host:       CanonicalizeHostPort(account.Hostname),
+		User:       account.Username,
+		ProxyJump:  accountProxyJump(account),
+		PrivateKey: SystemKeyToSecret(sk),
+	}, nil
+}
+
+// OpenShell starts an interactive shell on account with its system key,
+// attached to stdio, and records it in the audit log. It returns once the remote shell exits; a non-zero
+// exit status is reported as a *ShellExitError.
+func OpenShell(kr KeyReader, account model.Account, stdio ShellIO) error {
+	target, err := ShellTargetForAccount(kr, account)
+	if err != nil {
+		return err
+	}
+	passphrase := state.PasswordCache.Get()
+	defer func() {
+		for i := range passphrase {
+			passphrase[i] = 0
+		}
+	}()
+	DefaultLogger().Debug("opening shell", "account", account.String(), "proxy_jump", target.ProxyJump)
+	if aw := DefaultAuditWriter(); aw != nil {
+		_ = aw.LogAction("OPEN_SHELL", fmt.Sprintf("account: %s, %s", account.String(), model.AccountRef(account.ID)))
+	}
+	return OpenShellFunc(target, passphrase, stdio)
+}
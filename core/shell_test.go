@@ -0,0 +1,73 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// serialKeyReader serves system keys whose private key names their serial.
+type serialKeyReader struct {
+	active int
+}
+
+func (r serialKeyReader) GetAllPublicKeys() ([]model.PublicKey, error) { return nil, nil }
+
+func (r serialKeyReader) GetActiveSystemKey() (*model.SystemKey, error) {
+	if r.active == 0 {
+		return nil, nil
+	}
+	return r.GetSystemKeyBySerial(r.active)
+}
+
+func (r serialKeyReader) GetSystemKeyBySerial(serial int) (*model.SystemKey, error) {
+	return &model.SystemKey{Serial: serial, PrivateKey: "key-" + strings.Repeat("x", serial)}, nil
+}
+
+func TestShellTargetForAccount(t *testing.T) {
+	kr := serialKeyReader{active: 3}
+	acc := model.Account{ID: 1, Username: "deploy", Hostname: "web-01:2222", Tags: "web, proxyjump:admin@bastion", Serial: 2}
+
+	target, err := ShellTargetForAccount(kr, acc)
+	if err != nil {
+		t.Fatalf("ShellTargetForAccount: %v", err)
+	}
+	if target.Host != "web-01:2222" || target.User != "deploy" || target.ProxyJump != "admin@bastion" {
+		t.Fatalf("unexpected target %+v", target)
+	}
+	if string(target.PrivateKey) != "key-xx" {
+		t.Fatalf("expected the key of serial 2 the account was deployed with, got %q", target.PrivateKey)
+	}
+
+	acc.Serial = 0
+	if target, _ = ShellTargetForAccount(kr, acc); string(target.PrivateKey) != "key-xxx" {
+		t.Fatalf("expected the active key for an undeployed account, got %q", target.PrivateKey)
+	}
+	if _, err := ShellTargetForAccount(serialKeyReader{}, acc); err == nil {
+		t.Fatal("expected an error without an active system key")
+	}
+}
+
+func TestOpenShell_PassesTargetToHook(t *testing.T) {
+	orig := OpenShellFunc
+	t.Cleanup(func() { OpenShellFunc = orig })
+	var got ShellTarget
+	OpenShellFunc = func(target ShellTarget, passphrase []byte, stdio ShellIO) error {
+		got = target
+		return &ShellExitError{Status: 3}
+	}
+
+	err := OpenShell(serialKeyReader{active: 1}, model.Account{Username: "deploy", Hostname: "web-01"}, ShellIO{})
+	var exitErr *ShellExitError
+	if !errors.As(err, &exitErr) || exitErr.Status != 3 {
+		t.Fatalf("expected the remote exit status to be returned, got %v", err)
+	}
+	if got.User != "deploy" || !strings.HasPrefix(got.Host, "web-01") || string(got.PrivateKey) != "key-x" {
+		t.Fatalf("unexpected target %+v", got)
+	}
+}
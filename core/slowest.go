@@ -0,0 +1,35 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"sort"
+	"time"
+)
+
+// SlowestDeployResults returns up to n deploy results ordered by descending
+// Duration. Results with equal durations keep their original order. The
+// input slice is not modified.
+func SlowestDeployResults(results []DeployResult, n int) []DeployResult {
+	return slowestBy(results, n, func(r DeployResult) time.Duration { return r.Duration })
+}
+
+// SlowestAuditResults returns up to n audit results ordered by descending
+// Duration, like SlowestDeployResults.
+func SlowestAuditResults(results []AuditResult, n int) []AuditResult {
+	return slowestBy(results, n, func(r AuditResult) time.Duration { return r.Duration })
+}
+
+func slowestBy[T any](items []T, n int, duration func(T) time.Duration) []T {
+	if n <= 0 || len(items) == 0 {
+		return nil
+	}
+	sorted := make([]T, len(items))
+	copy(sorted, items)
+	sort.SliceStable(sorted, func(i, j int) bool { return duration(sorted[i]) > duration(sorted[j]) })
+	if n < len(sorted) {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// slowDM sleeps for a per-account delay in deploys and serial audits.
+type slowDM struct {
+	fakeDeployerManager
+	delay map[int]time.Duration
+}
+
+func (d *slowDM) DeployForAccount(account model.Account, keepFile bool) error {
+	time.Sleep(d.delay[account.ID])
+	return nil
+}
+
+func (d *slowDM) AuditSerial(account model.Account) error {
+	time.Sleep(d.delay[account.ID])
+	return nil
+}
+
+func TestDeployAndAuditResults_CarryDuration(t *testing.T) {
+	accounts := []model.Account{{ID: 1, Username: "app", Hostname: "web-01", IsActive: true, DeployEnabled: true}}
+	st := &simpleFakeStore{accounts: accounts}
+	dm := &slowDM{delay: map[int]time.Duration{1: 5 * time.Millisecond}}
+
+	deployed, err := DeployAccounts(context.Background(), st, dm, nil, nil)
+	if err != nil {
+		t.Fatalf("DeployAccounts: %v", err)
+	}
+	if len(deployed) != 1 || deployed[0].Duration < 5*time.Millisecond {
+		t.Fatalf("expected deploy duration of at least 5ms, got %+v", deployed)
+	}
+
+	audited, err := AuditAccounts(context.Background(), st, dm, "serial", nil)
+	if err != nil {
+		t.Fatalf("AuditAccounts: %v", err)
+	}
+	if len(audited) != 1 || audited[0].Duration < 5*time.Millisecond {
+		t.Fatalf("expected audit duration of at least 5ms, got %+v", audited)
+	}
+}
+
+func TestSlowestDeployResults(t *testing.T) {
+	results := []DeployResult{
+		{Account: model.Account{ID: 1}, Duration: 2 * time.Second},
+		{Account: model.Account{ID: 2}, Duration: 5 * time.Second},
+		{Account: model.Account{ID: 3}, Duration: 1 * time.Second},
+		{Account: model.Account{ID: 4}, Duration: 5 * time.Second},
+	}
+	got := SlowestDeployResults(results, 3)
+	want := []int{2, 4, 1}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d results, got %d", len(want), len(got))
+	}
+	for i, id := range want {
+		if got[i].Account.ID != id {
+			t.Fatalf("position %d: expected account %d, got %d", i, id, got[i].Account.ID)
+		}
+	}
+	if results[0].Account.ID != 1 {
+		t.Fatal("input slice must not be reordered")
+	}
+	if len(SlowestDeployResults(results, 10)) != 4 {
+		t.Fatal("expected all results when n exceeds the count")
+	}
+	if SlowestDeployResults(results, 0) != nil {
+		t.Fatal("expected nil for n=0")
+	}
+}
+
+func TestSlowestAuditResults(t *testing.T) {
+	results := []AuditResult{
+		{Account: model.Account{ID: 1}, Duration: time.Millisecond},
+		{Account: model.Account{ID: 2}, Duration: time.Second},
+	}
+	got := SlowestAuditResults(results, 1)
+	if len(got) != 1 || got[0].Account.ID != 2 {
+		t.Fatalf("expected account 2 as the slowest, got %+v", got)
+	}
+}
@@ -0,0 +1,295 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// Supported output formats for the SSH client config export.
+const (
+	// SSHConfigFormatFlat emits a single config containing every host.
+	SSHConfigFormatFlat = "flat"
+	// SSHConfigFormatInclude emits an Include stub plus one file per tag.
+	SSHConfigFormatInclude = "include"
+)
+
+// SSHConfigIncludeDir is the name of the directory next to the stub file that
+// holds the per-tag config files referenced by the Include stub.
+const SSHConfigIncludeDir = "keymaster.d"
+
+// untaggedSSHConfigFile is the file name used for accounts without tags.
+const untaggedSSHConfigFile = "untagged.conf"
+
+// SSHConfigProxyJumpTag is the account tag key whose value is emitted as the
+// ProxyJump directive (e.g. "proxyjump:bastion.example.com").
+const SSHConfigProxyJumpTag = "proxyjump"
+
+var sshConfigFileNameSanitizer = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// validStrictHostKeyChecking lists the values accepted by ssh_config(5).
+var validStrictHostKeyChecking = map[string]bool{
+	"yes": true, "no": true, "ask": true, "accept-new": true, "off": true,
+}
+
+// SSHConfigOptions controls the optional directives emitted for each Host.
+type SSHConfigOptions struct {
+	// IdentityFile is emitted as the IdentityFile of every Host when set.
+	// ssh expands tokens such as %r and %h, so per-host paths are possible.
+	IdentityFile string
+	// StrictHostKeyChecking is emitted for every Host when set. Valid values
+	// are those accepted by ssh_config(5): yes, no, ask, accept-new and off.
+	StrictHostKeyChecking string
+	// DedupeAliases gives every Host a distinct alias: an alias already used
+	// by an earlier account gets the first free "-2", "-3", ... suffix.
+	// Without it, ssh silently uses the first of several identical aliases.
+	DedupeAliases bool
+	// IncludeDir is the directory the Include stub points at. ssh resolves a
+	// relative user Include against ~/.ssh, not against the stub, so callers
+	// writing the stub elsewhere must pass an absolute path. Defaults to
+	// SSHConfigIncludeDir.
+	IncludeDir string
+}
+
+// Validate reports an error for option values ssh would reject.
+func (o SSHConfigOptions) Validate() error {
+	if o.StrictHostKeyChecking != "" && !validStrictHostKeyChecking[o.StrictHostKeyChecking] {
+		return fmt.Errorf("invalid StrictHostKeyChecking value %q (use yes, no, ask, accept-new or off)", o.StrictHostKeyChecking)
+	}
+	if strings.ContainsAny(o.IdentityFile, "\r\n") {
+		return fmt.Errorf("invalid IdentityFile path %q", o.IdentityFile)
+	}
+	if strings.ContainsAny(o.IncludeDir, "\r\n\"") {
+		return fmt.Errorf("invalid Include directory %q", o.IncludeDir)
+	}
+	return nil
+}
+
+// SSHConfigSplit is the result of an Include-style SSH config export.
+type SSHConfigSplit struct {
+	// Stub is the content of the main config file. It only contains an
+	// Include directive pointing at the per-tag files.
+	Stub string
+	// Files maps a file name (relative to SSHConfigIncludeDir) to its content.
+	Files map[string]string
+}
+
+// FileNames returns the per-tag file names in sorted order.
+func (s *SSHConfigSplit) FileNames() []string {
+	names := make([]string, 0, len(s.Files))
+	for name := range s.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ExportSSHConfigSplit builds an Include stub and one SSH config file per tag
+// for active accounts. Accounts carrying several tags appear in each of their
+// tag files; accounts without tags (ignoring the proxyjump tag) are written
+// to "untagged.conf". A nil
+// result is returned when there are no active accounts.
+func ExportSSHConfigSplit(ctx context.Context, st Store, opts SSHConfigOptions) (*SSHConfigSplit, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	accounts, err := st.GetAllActiveAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("get accounts: %w", err)
+	}
+	if len(accounts) == 0 {
+		return nil, nil
+	}
+
+	aliases := sshConfigAliases(accounts, opts.DedupeAliases)
+	now := time.Now().Format("2006-01-02 15:04:05")
+	split := &SSHConfigSplit{Files: make(map[string]string)}
+	for tag, tagged := range sshConfigTagGroups(accounts) {
+		name := sshConfigFileNameForTag(tag)
+		var b strings.Builder
+		if existing, ok := split.Files[name]; ok {
+			// Two tags sanitized to the same file name; append to it.
+			b.WriteString(existing)
+		} else {
+			fmt.Fprintf(&b, "# SSH config generated by Keymaster (tag: %s)\n", tag)
+			fmt.Fprintf(&b, "# date: %s\n\n", now)
+		}
+		for _, account := range tagged {
+			writeSSHConfigHostBlock(&b, account, aliases[account.ID], opts)
+		}
+		split.Files[name] = b.String()
+	}
+
+	var stub strings.Builder
+	stub.WriteString("# SSH config generated by Keymaster\n")
+	fmt.Fprintf(&stub, "# date: %s\n", now)
+	writeSSHConfigGuidance(&stub, opts)
+	stub.WriteString("\n")
+	includeDir := opts.IncludeDir
+	if includeDir == "" {
+		includeDir = SSHConfigIncludeDir
+	}
+	includePattern := filepath.ToSlash(includeDir) + "/*.conf"
+	if strings.ContainsAny(includePattern, " \t") {
+		includePattern = `"` + includePattern + `"`
+	}
+	fmt.Fprintf(&stub, "Include %s\n", includePattern)
+	split.Stub = stub.String()
+	return split, nil
+}
+
+// sshConfigTagGroups groups accounts like BuildAccountsByTag but ignores the
+// proxyjump tag, which configures the Host block rather than grouping it.
+func sshConfigTagGroups(accounts []model.Account) map[string][]model.Account {
+	m := make(map[string][]model.Account)
+	for _, acc := range accounts {
+		grouped := false
+		for _, t := range strings.Split(acc.Tags, ",") {
+			tag := strings.TrimSpace(t)
+			if tag == "" {
+				continue
+			}
+			if key, _, ok := strings.Cut(tag, ":"); ok && strings.EqualFold(strings.TrimSpace(key), SSHConfigProxyJumpTag) {
+				continue
+			}
+			m[tag] = append(m[tag], acc)
+			grouped = true
+		}
+		if !grouped {
+			m[untaggedLabel] = append(m[untaggedLabel], acc)
+		}
+	}
+	return m
+}
+
+// sshConfigFileNameForTag converts a tag into a safe config file name.
+func sshConfigFileNameForTag(tag string) string {
+	if tag == untaggedLabel {
+		return untaggedSSHConfigFile
+	}
+	name := strings.Trim(sshConfigFileNameSanitizer.ReplaceAllString(strings.ToLower(tag), "-"), "-.")
+	if name == "" {
+		return untaggedSSHConfigFile
+	}
+	return name + ".conf"
+}
+
+// writeSSHConfigGuidance writes a short comment recommending host key
+// checking when the export does not set StrictHostKeyChecking itself.
+func writeSSHConfigGuidance(w io.Writer, opts SSHConfigOptions) {
+	if opts.StrictHostKeyChecking != "" {
+		return
+	}
+	_, _ = fmt.Fprint(w, "# StrictHostKeyChecking is not set; ssh falls back to your global setting.\n")
+	_, _ = fmt.Fprint(w, "# Consider 'accept-new' (trust on first use) or 'yes' with a managed known_hosts.\n")
+}
+
+// sshConfigAlias returns the Host alias of account: its label, or an alias
+// of the form "user-host-example-com" for accounts without one.
+func sshConfigAlias(account model.Account) string {
+	if account.Label != "" {
+		return account.Label
+	}
+	return fmt.Sprintf("%s-%s", account.Username, strings.ReplaceAll(account.Hostname, ".", "-"))
+}
+
+// sshConfigAliases maps account IDs to their Host aliases. With dedupe, an
+// alias already taken by an earlier account, compared case-insensitively as
+// ssh does, gets the first "-N" suffix that is neither taken nor the alias of
+// another account.
+func sshConfigAliases(accounts []model.Account, dedupe bool) map[int]string {
+	aliases := make(map[int]string, len(accounts))
+	reserved := make(map[string]bool, len(accounts))
+	for _, acc := range accounts {
+		reserved[strings.ToLower(sshConfigAlias(acc))] = true
+	}
+	taken := make(map[string]bool, len(accounts))
+	for _, acc := range accounts {
+		alias := sshConfigAlias(acc)
+		if dedupe && taken[strings.ToLower(alias)] {
+			for n := 2; ; n++ {
+				candidate := fmt.Sprintf("%s-%d", alias, n)
+				if key := strings.ToLower(candidate); !taken[key] && !reserved[key] {
+					alias = candidate
+					break
+				}
+			}
+		}
+		taken[strings.ToLower(alias)] = true
+		aliases[acc.ID] = alias
+	}
+	return aliases
+}
+
+// DuplicateSSHConfigAliases returns, in sorted order, the Host aliases that
+// more than one of accounts would be exported under without DedupeAliases.
+func DuplicateSSHConfigAliases(accounts []model.Account) []string {
+	first := make(map[string]string)
+	seen := make(map[string]int)
+	for _, acc := range accounts {
+		alias := sshConfigAlias(acc)
+		key := strings.ToLower(alias)
+		if _, ok := first[key]; !ok {
+			first[key] = alias
+		}
+		seen[key]++
+	}
+	var dups []string
+	for key, n := range seen {
+		if n > 1 {
+			dups = append(dups, first[key])
+		}
+	}
+	sort.Strings(dups)
+	return dups
+}
+
+// writeSSHConfigHostBlock writes the Host block for a single account under
+// the given alias.
+func writeSSHConfigHostBlock(w io.Writer, account model.Account, hostAlias string, opts SSHConfigOptions) {
+	_, _ = fmt.Fprintf(w, "# %s\n", account.String())
+	_, _ = fmt.Fprintf(w, "Host %s\n", hostAlias)
+	_, _ = fmt.Fprintf(w, "    HostName %s\n", account.Hostname)
+	_, _ = fmt.Fprintf(w, "    User %s\n", account.Username)
+	if opts.IdentityFile != "" {
+		_, _ = fmt.Fprintf(w, "    IdentityFile %s\n", quoteSSHConfigValue(opts.IdentityFile))
+		_, _ = fmt.Fprint(w, "    IdentitiesOnly yes\n")
+	}
+	if jump := accountProxyJump(account); jump != "" {
+		_, _ = fmt.Fprintf(w, "    ProxyJump %s\n", jump)
+	}
+	if opts.StrictHostKeyChecking != "" {
+		_, _ = fmt.Fprintf(w, "    StrictHostKeyChecking %s\n", opts.StrictHostKeyChecking)
+	}
+	_, _ = fmt.Fprint(w, "\n")
+}
+
+// accountProxyJump returns the bastion configured through the account's
+// "proxyjump:<host>" tag, or an empty string when none is set.
+func accountProxyJump(account model.Account) string {
+	for _, t := range strings.Split(account.Tags, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(t), ":")
+		if ok && strings.EqualFold(strings.TrimSpace(key), SSHConfigProxyJumpTag) {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// quoteSSHConfigValue wraps values containing whitespace in double quotes.
+func quoteSSHConfigValue(v string) string {
+	if strings.ContainsAny(v, " \t") {
+		return `"` + v + `"`
+	}
+	return v
+}
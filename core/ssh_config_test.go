@@ -0,0 +1,239 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+func TestExportSSHConfigSplit_Empty(t *testing.T) {
+	split, err := ExportSSHConfigSplit(context.TODO(), &simpleStore{accounts: []model.Account{}}, SSHConfigOptions{})
+	if err != nil {
+		t.Fatalf("ExportSSHConfigSplit error: %v", err)
+	}
+	if split != nil {
+		t.Fatalf("expected nil result for no accounts, got %+v", split)
+	}
+}
+
+func TestExportSSHConfigSplit_GroupsByTag(t *testing.T) {
+	st := &simpleStore{accounts: []model.Account{
+		{ID: 1, Username: "deploy", Hostname: "web1.prod.example.com", Label: "prod-web", Tags: "env:prod"},
+		{ID: 2, Username: "deploy", Hostname: "web1.stage.example.com", Label: "stage-web", Tags: "env:stage"},
+		{ID: 3, Username: "admin", Hostname: "db.example.com", Tags: "env:prod, role:db"},
+		{ID: 4, Username: "root", Hostname: "misc.example.com"},
+	}}
+	split, err := ExportSSHConfigSplit(context.TODO(), st, SSHConfigOptions{})
+	if err != nil {
+		t.Fatalf("ExportSSHConfigSplit error: %v", err)
+	}
+
+	if !strings.Contains(split.Stub, "Include keymaster.d/*.conf") {
+		t.Fatalf("stub does not reference include dir: %q", split.Stub)
+	}
+	if strings.Contains(split.Stub, "Host ") {
+		t.Fatalf("stub must not contain host entries: %q", split.Stub)
+	}
+
+	want := []string{"env-prod.conf", "env-stage.conf", "role-db.conf", "untagged.conf"}
+	got := split.FileNames()
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected files: got %v want %v", got, want)
+	}
+
+	cases := map[string]struct{ in, out []string }{
+		"env-prod.conf":  {in: []string{"Host prod-web", "Host admin-db-example-com"}, out: []string{"Host stage-web", "Host root-misc-example-com"}},
+		"env-stage.conf": {in: []string{"Host stage-web"}, out: []string{"Host prod-web"}},
+		"role-db.conf":   {in: []string{"Host admin-db-example-com", "User admin"}, out: []string{"Host prod-web"}},
+		"untagged.conf":  {in: []string{"Host root-misc-example-com", "HostName misc.example.com"}, out: []string{"Host prod-web"}},
+	}
+	for name, c := range cases {
+		content := split.Files[name]
+		for _, s := range c.in {
+			if !strings.Contains(content, s) {
+				t.Errorf("%s: expected %q in %q", name, s, content)
+			}
+		}
+		for _, s := range c.out {
+			if strings.Contains(content, s) {
+				t.Errorf("%s: did not expect %q in %q", name, s, content)
+			}
+		}
+	}
+}
+
+func TestExportSSHConfigSplit_IncludeDir(t *testing.T) {
+	st := &simpleStore{accounts: []model.Account{{ID: 1, Username: "deploy", Hostname: "web1.example.com"}}}
+	cases := map[string]string{
+		"/home/alice/configs/keymaster.d":    "Include /home/alice/configs/keymaster.d/*.conf\n",
+		"/home/alice/my configs/keymaster.d": "Include \"/home/alice/my configs/keymaster.d/*.conf\"\n",
+	}
+	for dir, want := range cases {
+		split, err := ExportSSHConfigSplit(context.TODO(), st, SSHConfigOptions{IncludeDir: dir})
+		if err != nil {
+			t.Fatalf("ExportSSHConfigSplit(%q) error: %v", dir, err)
+		}
+		if !strings.Contains(split.Stub, want) {
+			t.Errorf("IncludeDir %q: expected %q in stub %q", dir, want, split.Stub)
+		}
+	}
+}
+
+func TestSSHConfigFileNameForTag(t *testing.T) {
+	cases := map[string]string{
+		"env:prod":     "env-prod.conf",
+		"Team A":       "team-a.conf",
+		"../../etc":    "etc.conf",
+		untaggedLabel:  "untagged.conf",
+		":::":          "untagged.conf",
+		"eu-west-1.az": "eu-west-1.az.conf",
+	}
+	for tag, want := range cases {
+		if got := sshConfigFileNameForTag(tag); got != want {
+			t.Errorf("sshConfigFileNameForTag(%q) = %q, want %q", tag, got, want)
+		}
+	}
+}
+
+func TestExportSSHConfig_IdentityFileAndProxyJump(t *testing.T) {
+	st := &simpleStore{accounts: []model.Account{
+		{ID: 1, Username: "deploy", Hostname: "app.internal", Label: "app", Tags: "env:prod,proxyjump:jump@bastion.example.com"},
+		{ID: 2, Username: "deploy", Hostname: "edge.example.com", Label: "edge", Tags: "env:prod"},
+	}}
+	opts := SSHConfigOptions{IdentityFile: "~/.ssh/keymaster id", StrictHostKeyChecking: "accept-new"}
+	out, err := ExportSSHConfig(context.TODO(), st, opts)
+	if err != nil {
+		t.Fatalf("ExportSSHConfig error: %v", err)
+	}
+
+	blocks := strings.Split(out, "\nHost ")
+	if len(blocks) != 3 {
+		t.Fatalf("expected 2 host blocks, got %d: %q", len(blocks)-1, out)
+	}
+	app, edge := blocks[1], blocks[2]
+	if !strings.HasPrefix(app, "app\n") || !strings.HasPrefix(edge, "edge\n") {
+		t.Fatalf("unexpected block order: %q", out)
+	}
+	for _, block := range []string{app, edge} {
+		if !strings.Contains(block, `IdentityFile "~/.ssh/keymaster id"`) || !strings.Contains(block, "IdentitiesOnly yes") {
+			t.Errorf("expected quoted IdentityFile in block %q", block)
+		}
+		if !strings.Contains(block, "StrictHostKeyChecking accept-new") {
+			t.Errorf("expected StrictHostKeyChecking in block %q", block)
+		}
+	}
+	if !strings.Contains(app, "ProxyJump jump@bastion.example.com") {
+		t.Errorf("expected ProxyJump for proxied account: %q", app)
+	}
+	if strings.Contains(edge, "ProxyJump") {
+		t.Errorf("did not expect ProxyJump for direct account: %q", edge)
+	}
+	if strings.Contains(out, "Consider 'accept-new'") {
+		t.Errorf("guidance comment should be omitted when StrictHostKeyChecking is set")
+	}
+}
+
+func TestExportSSHConfig_DefaultsOmitDirectives(t *testing.T) {
+	st := &simpleStore{accounts: []model.Account{{ID: 1, Username: "u", Hostname: "h.example.com"}}}
+	out, err := ExportSSHConfig(context.TODO(), st, SSHConfigOptions{})
+	if err != nil {
+		t.Fatalf("ExportSSHConfig error: %v", err)
+	}
+	for _, s := range []string{"IdentityFile", "ProxyJump", "    StrictHostKeyChecking"} {
+		if strings.Contains(out, s) {
+			t.Errorf("did not expect %q in %q", s, out)
+		}
+	}
+	if !strings.Contains(out, "# StrictHostKeyChecking is not set") {
+		t.Errorf("expected StrictHostKeyChecking guidance in %q", out)
+	}
+}
+
+func TestExportSSHConfig_InvalidStrictHostKeyChecking(t *testing.T) {
+	st := &simpleStore{accounts: []model.Account{{ID: 1, Username: "u", Hostname: "h"}}}
+	if _, err := ExportSSHConfig(context.TODO(), st, SSHConfigOptions{StrictHostKeyChecking: "maybe"}); err == nil {
+		t.Fatalf("expected error for invalid StrictHostKeyChecking")
+	}
+}
+
+func TestExportSSHConfigSplit_IgnoresProxyJumpTag(t *testing.T) {
+	st := &simpleStore{accounts: []model.Account{
+		{ID: 1, Username: "deploy", Hostname: "app.internal", Label: "app", Tags: "proxyjump:bastion"},
+	}}
+	split, err := ExportSSHConfigSplit(context.TODO(), st, SSHConfigOptions{})
+	if err != nil {
+		t.Fatalf("ExportSSHConfigSplit error: %v", err)
+	}
+	if got := split.FileNames(); len(got) != 1 || got[0] != "untagged.conf" {
+		t.Fatalf("expected only untagged.conf, got %v", got)
+	}
+	if !strings.Contains(split.Files["untagged.conf"], "ProxyJump bastion") {
+		t.Fatalf("expected ProxyJump in untagged file: %q", split.Files["untagged.conf"])
+	}
+}
+
+// sshConfigHostAliases returns the Host aliases in out, in order.
+func sshConfigHostAliases(out string) []string {
+	var aliases []string
+	for _, line := range strings.Split(out, "\n") {
+		if alias, ok := strings.CutPrefix(line, "Host "); ok {
+			aliases = append(aliases, alias)
+		}
+	}
+	return aliases
+}
+
+func TestExportSSHConfig_DedupeAliases(t *testing.T) {
+	accounts := []model.Account{
+		{ID: 1, Username: "deploy", Hostname: "web1.example.com", Label: "web", Tags: "env:prod"},
+		{ID: 2, Username: "deploy", Hostname: "web2.example.com", Label: "WEB", Tags: "env:prod"},
+		{ID: 3, Username: "deploy", Hostname: "web3.example.com", Label: "web", Tags: "env:stage"},
+		{ID: 4, Username: "deploy", Hostname: "web4.example.com", Label: "web-2"},
+	}
+	st := &simpleStore{accounts: accounts}
+
+	if dups := DuplicateSSHConfigAliases(accounts); len(dups) != 1 || dups[0] != "web" {
+		t.Fatalf("expected duplicate alias web, got %v", dups)
+	}
+	out, err := ExportSSHConfig(context.TODO(), st, SSHConfigOptions{})
+	if err != nil {
+		t.Fatalf("ExportSSHConfig error: %v", err)
+	}
+	if got := sshConfigHostAliases(out); strings.Join(got, " ") != "web WEB web web-2" {
+		t.Fatalf("expected labels unchanged without dedupe, got %v", got)
+	}
+
+	// Later accounts are suffixed, skipping web-2 which account 4 owns.
+	out, err = ExportSSHConfig(context.TODO(), st, SSHConfigOptions{DedupeAliases: true})
+	if err != nil {
+		t.Fatalf("ExportSSHConfig error: %v", err)
+	}
+	if got := sshConfigHostAliases(out); strings.Join(got, " ") != "web WEB-3 web-4 web-2" {
+		t.Fatalf("unexpected deduplicated aliases: %v", got)
+	}
+
+	// The include format uses the same aliases in every tag file.
+	split, err := ExportSSHConfigSplit(context.TODO(), st, SSHConfigOptions{DedupeAliases: true})
+	if err != nil {
+		t.Fatalf("ExportSSHConfigSplit error: %v", err)
+	}
+	var all []string
+	for _, name := range split.FileNames() {
+		all = append(all, sshConfigHostAliases(split.Files[name])...)
+	}
+	if strings.Join(all, " ") != "web WEB-3 web-4 web-2" {
+		t.Fatalf("unexpected aliases across include files: %v", all)
+	}
+
+	// Generated user-host aliases are deduplicated the same way.
+	twins := []model.Account{{ID: 1, Username: "app", Hostname: "db.example.com"}, {ID: 2, Username: "app", Hostname: "db-example.com"}}
+	out, _ = ExportSSHConfig(context.TODO(), &simpleStore{accounts: twins}, SSHConfigOptions{DedupeAliases: true})
+	if got := sshConfigHostAliases(out); strings.Join(got, " ") != "app-db-example-com app-db-example-com-2" {
+		t.Fatalf("unexpected generated aliases: %v", got)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package sshkey
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ParseCertAuthority reports whether an authorized_keys line carries the
+// cert-authority option and returns the principals of its principals="..."
+// option, if any.
+func ParseCertAuthority(line string) (isCA bool, principals []string, err error) {
+	_, _, options, _, err := ssh.ParseAuthorizedKey([]byte(strings.TrimSpace(line)))
+	if err != nil {
+		return false, nil, fmt.Errorf("invalid authorized_keys line: %w", err)
+	}
+	for _, opt := range options {
+		name, value, hasValue := strings.Cut(opt, "=")
+		switch strings.ToLower(name) {
+		case "cert-authority":
+			isCA = true
+		case "principals":
+			if !hasValue {
+				return false, nil, fmt.Errorf("principals option without a value")
+			}
+			principals = splitPrincipals(strings.Trim(value, `"`))
+		}
+	}
+	if !isCA {
+		principals = nil
+	}
+	return isCA, principals, nil
+}
+
+// ValidatePrincipals checks that principals can be rendered into a
+// principals="..." option: names must be non-empty and free of whitespace,
+// commas and quotes.
+func ValidatePrincipals(principals []string) error {
+	for _, p := range principals {
+		if p == "" || strings.ContainsAny(p, " \t\r\n,\"") {
+			return fmt.Errorf("invalid principal %q", p)
+		}
+	}
+	return nil
+}
+
+func splitPrincipals(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
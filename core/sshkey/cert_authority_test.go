@@ -0,0 +1,67 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package sshkey
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"reflect"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestParseCertAuthority_RoundTrip(t *testing.T) {
+	pub, err := ssh.NewPublicKey(ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize)).Public())
+	if err != nil {
+		t.Fatalf("NewPublicKey: %v", err)
+	}
+	ca := model.PublicKey{
+		Algorithm:  pub.Type(),
+		KeyData:    base64.StdEncoding.EncodeToString(pub.Marshal()),
+		Comment:    "corp user CA",
+		IsCA:       true,
+		Principals: []string{"deploy", "ops"},
+	}
+	line := ca.AuthorizedKeysLine()
+	if want := `cert-authority,principals="deploy,ops" ` + ca.Algorithm + " " + ca.KeyData + " corp user CA"; line != want {
+		t.Fatalf("unexpected line:\n got %s\nwant %s", line, want)
+	}
+
+	isCA, principals, err := ParseCertAuthority(line)
+	if err != nil {
+		t.Fatalf("ParseCertAuthority: %v", err)
+	}
+	if !isCA || !reflect.DeepEqual(principals, ca.Principals) {
+		t.Fatalf("expected CA with principals %v, got %v %v", ca.Principals, isCA, principals)
+	}
+	alg, keyData, comment, err := Normalize(line)
+	if err != nil || alg != ca.Algorithm || keyData != ca.KeyData || comment != ca.Comment {
+		t.Fatalf("unexpected key parts: %s %s %q %v", alg, keyData, comment, err)
+	}
+
+	ca.Principals = nil
+	if isCA, principals, err = ParseCertAuthority(ca.AuthorizedKeysLine()); err != nil || !isCA || principals != nil {
+		t.Fatalf("expected an unrestricted CA, got %v %v %v", isCA, principals, err)
+	}
+	ca.IsCA = false
+	if isCA, _, err = ParseCertAuthority(ca.AuthorizedKeysLine()); err != nil || isCA {
+		t.Fatalf("expected a plain key, got %v %v", isCA, err)
+	}
+	if _, _, err := ParseCertAuthority("cert-authority not-a-key"); err == nil {
+		t.Fatal("expected an error for an invalid line")
+	}
+}
+
+func TestValidatePrincipals(t *testing.T) {
+	if err := ValidatePrincipals([]string{"deploy", "ops-team"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, bad := range []string{"", "a b", "a,b", `a"b`} {
+		if err := ValidatePrincipals([]string{bad}); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package sshkey
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ConnectionAlgorithms lists the ciphers, MACs and key exchange algorithms
+// offered on outgoing SSH connections, in order of preference. An empty list
+// keeps the golang.org/x/crypto/ssh defaults for that category.
+type ConnectionAlgorithms struct {
+	Ciphers      []string
+	MACs         []string
+	KeyExchanges []string
+}
+
+// Validate rejects names not implemented by golang.org/x/crypto/ssh. Legacy
+// algorithms the package implements but does not enable by default, such as
+// diffie-hellman-group1-sha1, are accepted; see Insecure.
+func (a ConnectionAlgorithms) Validate() error {
+	supported, insecure := ssh.SupportedAlgorithms(), ssh.InsecureAlgorithms()
+	for _, c := range []struct {
+		name            string
+		configured      []string
+		known, fallback []string
+	}{
+		{"cipher", a.Ciphers, supported.Ciphers, insecure.Ciphers},
+		{"MAC", a.MACs, supported.MACs, insecure.MACs},
+		{"key exchange algorithm", a.KeyExchanges, supported.KeyExchanges, insecure.KeyExchanges},
+	} {
+		for _, name := range c.configured {
+			if !slices.Contains(c.known, name) && !slices.Contains(c.fallback, name) {
+				return fmt.Errorf("unsupported %s %q (supported: %s)", c.name, name, strings.Join(c.known, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// Insecure returns the configured names that golang.org/x/crypto/ssh flags
+// as having known security issues.
+func (a ConnectionAlgorithms) Insecure() []string {
+	insecure := ssh.InsecureAlgorithms()
+	var out []string
+	for _, c := range []struct{ configured, insecure []string }{
+		{a.Ciphers, insecure.Ciphers},
+		{a.MACs, insecure.MACs},
+		{a.KeyExchanges, insecure.KeyExchanges},
+	} {
+		for _, name := range c.configured {
+			if slices.Contains(c.insecure, name) {
+				out = append(out, name)
+			}
+		}
+	}
+	return out
+}
+
+var (
+	connectionAlgorithmsMu sync.RWMutex
+	connectionAlgorithms   ConnectionAlgorithms
+)
+
+// SetConnectionAlgorithms sets the algorithms applied by
+// ApplyConnectionAlgorithms. Callers validate a first.
+func SetConnectionAlgorithms(a ConnectionAlgorithms) {
+	connectionAlgorithmsMu.Lock()
+	defer connectionAlgorithmsMu.Unlock()
+	connectionAlgorithms = ConnectionAlgorithms{
+		Ciphers:      slices.Clone(a.Ciphers),
+		MACs:         slices.Clone(a.MACs),
+		KeyExchanges: slices.Clone(a.KeyExchanges),
+	}
+}
+
+// ApplyConnectionAlgorithms copies the configured algorithms into cfg,
+// leaving categories without configured algorithms at their defaults.
+func ApplyConnectionAlgorithms(cfg *ssh.Config) {
+	connectionAlgorithmsMu.RLock()
+	defer connectionAlgorithmsMu.RUnlock()
+	if len(connectionAlgorithms.Ciphers) > 0 {
+		cfg.Ciphers = slices.Clone(connectionAlgorithms.Ciphers)
+	}
+	if len(connectionAlgorithms.MACs) > 0 {
+		cfg.MACs = slices.Clone(connectionAlgorithms.MACs)
+	}
+	if len(connectionAlgorithms.KeyExchanges) > 0 {
+		cfg.KeyExchanges = slices.Clone(connectionAlgorithms.KeyExchanges)
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package sshkey
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestConnectionAlgorithms_Validate(t *testing.T) {
+	ok := ConnectionAlgorithms{
+		Ciphers:      []string{"aes256-gcm@openssh.com", "aes128-cbc"},
+		MACs:         []string{"hmac-sha2-256-etm@openssh.com"},
+		KeyExchanges: []string{"curve25519-sha256", "diffie-hellman-group1-sha1"},
+	}
+	if err := ok.Validate(); err != nil {
+		t.Fatalf("expected supported and legacy algorithms to validate, got %v", err)
+	}
+	if got := ok.Insecure(); !reflect.DeepEqual(got, []string{"aes128-cbc", "diffie-hellman-group1-sha1"}) {
+		t.Fatalf("unexpected insecure algorithms %v", got)
+	}
+
+	for _, bad := range []ConnectionAlgorithms{
+		{Ciphers: []string{"blowfish-cbc"}},
+		{MACs: []string{"hmac-md5"}},
+		{KeyExchanges: []string{"aes256-gcm@openssh.com"}},
+	} {
+		if err := bad.Validate(); err == nil || !strings.Contains(err.Error(), "unsupported") {
+			t.Fatalf("expected %+v to be rejected, got %v", bad, err)
+		}
+	}
+}
+
+func TestApplyConnectionAlgorithms(t *testing.T) {
+	t.Cleanup(func() { SetConnectionAlgorithms(ConnectionAlgorithms{}) })
+
+	var cfg ssh.Config
+	ApplyConnectionAlgorithms(&cfg)
+	if cfg.Ciphers != nil || cfg.MACs != nil || cfg.KeyExchanges != nil {
+		t.Fatalf("expected Go defaults without configuration, got %+v", cfg)
+	}
+
+	SetConnectionAlgorithms(ConnectionAlgorithms{Ciphers: []string{"aes256-ctr"}, KeyExchanges: []string{"ecdh-sha2-nistp384"}})
+	ApplyConnectionAlgorithms(&cfg)
+	if !reflect.DeepEqual(cfg.Ciphers, []string{"aes256-ctr"}) || !reflect.DeepEqual(cfg.KeyExchanges, []string{"ecdh-sha2-nistp384"}) || cfg.MACs != nil {
+		t.Fatalf("unexpected applied config %+v", cfg)
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package sshkey
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Fingerprints returns the SHA256 fingerprint ("SHA256:...", as printed by
+// ssh-keygen -l) and the legacy MD5 fingerprint ("MD5:aa:bb:...") of the
+// base64 key data of a public key.
+func Fingerprints(keyData string) (sha256, md5 string, err error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(keyData))
+	if err != nil {
+		return "", "", fmt.Errorf("invalid key data: %w", err)
+	}
+	pub, err := ssh.ParsePublicKey(raw)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid public key: %w", err)
+	}
+	return ssh.FingerprintSHA256(pub), "MD5:" + ssh.FingerprintLegacyMD5(pub), nil
+}
+
+// NormalizeFingerprint returns fp in the form produced by Fingerprints so
+// fingerprints from other tools compare equal: SHA256 fingerprints lose their
+// base64 padding, MD5 fingerprints are lowercased and colon-separated and
+// gain the "MD5:" prefix when it is missing.
+func NormalizeFingerprint(fp string) (string, error) {
+	fp = strings.TrimSpace(fp)
+	if rest, ok := cutPrefixFold(fp, "SHA256:"); ok {
+		rest = strings.TrimRight(rest, "=")
+		if _, err := base64.RawStdEncoding.DecodeString(rest); err != nil || rest == "" {
+			return "", fmt.Errorf("invalid SHA256 fingerprint %q", fp)
+		}
+		return "SHA256:" + rest, nil
+	}
+
+	hexDigits := strings.ToLower(strings.ReplaceAll(fp, ":", ""))
+	if rest, ok := cutPrefixFold(fp, "MD5:"); ok {
+		hexDigits = strings.ToLower(strings.ReplaceAll(rest, ":", ""))
+	}
+	if len(hexDigits) != 32 || strings.Trim(hexDigits, "0123456789abcdef") != "" {
+		return "", fmt.Errorf("invalid fingerprint %q: expected SHA256:<base64> or an MD5 hex digest", fp)
+	}
+	pairs := make([]string, 0, 16)
+	for i := 0; i < len(hexDigits); i += 2 {
+		pairs = append(pairs, hexDigits[i:i+2])
+	}
+	return "MD5:" + strings.Join(pairs, ":"), nil
+}
+
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix) {
+		return s[len(prefix):], true
+	}
+	return s, false
+}
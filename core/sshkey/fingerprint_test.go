@@ -0,0 +1,50 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package sshkey
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestFingerprints(t *testing.T) {
+	priv := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	pub, err := ssh.NewPublicKey(priv.Public())
+	if err != nil {
+		t.Fatalf("NewPublicKey: %v", err)
+	}
+	sha, md5, err := Fingerprints(base64.StdEncoding.EncodeToString(pub.Marshal()))
+	if err != nil {
+		t.Fatalf("Fingerprints: %v", err)
+	}
+	if sha != ssh.FingerprintSHA256(pub) || md5 != "MD5:"+ssh.FingerprintLegacyMD5(pub) {
+		t.Fatalf("unexpected fingerprints %q %q", sha, md5)
+	}
+	if _, _, err := Fingerprints("!!!"); err == nil {
+		t.Fatal("expected an error for invalid key data")
+	}
+}
+
+func TestNormalizeFingerprint(t *testing.T) {
+	cases := map[string]string{
+		"SHA256:abc+/def=":                 "SHA256:abc+/def",
+		"sha256:abc":                       "SHA256:abc",
+		"D41D8CD98F00B204E9800998ECF8427E": "MD5:d4:1d:8c:d9:8f:00:b2:04:e9:80:09:98:ec:f8:42:7e",
+		"md5:d4:1d:8c:d9:8f:00:b2:04:e9:80:09:98:ec:f8:42:7e": "MD5:d4:1d:8c:d9:8f:00:b2:04:e9:80:09:98:ec:f8:42:7e",
+	}
+	for in, want := range cases {
+		got, err := NormalizeFingerprint(in)
+		if err != nil || got != want {
+			t.Errorf("NormalizeFingerprint(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "SHA256:", "SHA256:***", "d4:1d", "zz1d8cd98f00b204e9800998ecf8427e"} {
+		if _, err := NormalizeFingerprint(in); err == nil {
+			t.Errorf("NormalizeFingerprint(%q): expected an error", in)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package sshkey
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ValidateKeyOptions checks that opts is a comma-separated authorized_keys
+// option list such as `command="rsync --server",no-pty` that OpenSSH parses
+// back unchanged. An empty list is valid.
+func ValidateKeyOptions(opts string) error {
+	if opts == "" {
+		return nil
+	}
+	if strings.ContainsAny(opts, " \t\r\n") && !quotedWhitespaceOnly(opts) {
+		return fmt.Errorf("invalid key options %q: whitespace outside quotes", opts)
+	}
+	pub, err := ssh.NewPublicKey(ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize)).Public())
+	if err != nil {
+		return err
+	}
+	line := opts + " " + pub.Type() + " " + base64.StdEncoding.EncodeToString(pub.Marshal())
+	_, _, parsed, _, err := ssh.ParseAuthorizedKey([]byte(line))
+	if err != nil || strings.Join(parsed, ",") != opts {
+		return fmt.Errorf("invalid key options %q", opts)
+	}
+	return nil
+}
+
+// quotedWhitespaceOnly reports whether every space, tab or line break in s
+// sits inside a double-quoted value. Line breaks are never accepted.
+func quotedWhitespaceOnly(s string) bool {
+	inQuote := false
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && inQuote:
+			i++
+		case c == '"':
+			inQuote = !inQuote
+		case c == '\r' || c == '\n':
+			return false
+		case (c == ' ' || c == '\t') && !inQuote:
+			return false
+		}
+	}
+	return !inQuote
+}
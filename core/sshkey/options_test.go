@@ -0,0 +1,31 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package sshkey
+
+import "testing"
+
+func TestValidateKeyOptions(t *testing.T) {
+	valid := []string{
+		"",
+		"no-pty",
+		`command="rsync --server --sender -logDtpre.iLsfxC . /srv/backup",no-pty,no-port-forwarding`,
+		`from="10.0.0.0/8",restrict`,
+	}
+	for _, opts := range valid {
+		if err := ValidateKeyOptions(opts); err != nil {
+			t.Errorf("expected %q to be valid, got %v", opts, err)
+		}
+	}
+	invalid := []string{
+		"no-pty no-x11-forwarding",
+		`command="unterminated`,
+		"no-pty,\nssh-ed25519 AAAA injected",
+		`command="ls"trailing junk`,
+	}
+	for _, opts := range invalid {
+		if err := ValidateKeyOptions(opts); err == nil {
+			t.Errorf("expected %q to be rejected", opts)
+		}
+	}
+}
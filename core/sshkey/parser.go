@@ -8,6 +8,7 @@
 package sshkey // import "github.com/toeirei/keymaster/core/sshkey"
 
 import (
+	"encoding/base64"
 	"fmt"
 	"regexp"
 	"strconv"
@@ -53,6 +54,43 @@ func Parse(rawKey string) (algorithm, keyData, comment string, err error) {
 	return
 }
 
+// NormalizeKey returns the canonical algorithm and base64 key data for a
+// public key so the same key material always compares equal. Key data that
+// does not decode to a valid SSH public key is returned trimmed but otherwise
+// unchanged.
+func NormalizeKey(algorithm, keyData string) (string, string) {
+	algorithm = strings.TrimSpace(algorithm)
+	keyData = strings.TrimSpace(keyData)
+	raw, err := base64.StdEncoding.DecodeString(keyData)
+	if err != nil {
+		return algorithm, keyData
+	}
+	pub, err := ssh.ParsePublicKey(raw)
+	if err != nil {
+		return algorithm, keyData
+	}
+	return pub.Type(), base64.StdEncoding.EncodeToString(pub.Marshal())
+}
+
+// NormalizeComment collapses runs of whitespace in a key comment to single
+// spaces and trims it.
+func NormalizeComment(comment string) string {
+	return strings.Join(strings.Fields(comment), " ")
+}
+
+// Normalize parses an authorized_keys line like Parse and returns its parts in
+// the canonical form used for storage: key data is re-encoded by NormalizeKey
+// and the comment is cleaned by NormalizeComment. Lines differing only in
+// whitespace therefore yield identical results.
+func Normalize(line string) (algorithm, keyData, comment string, err error) {
+	algorithm, keyData, comment, err = Parse(line)
+	if err != nil {
+		return "", "", "", err
+	}
+	algorithm, keyData = NormalizeKey(algorithm, keyData)
+	return algorithm, keyData, NormalizeComment(comment), nil
+}
+
 // ParseSerial extracts the Keymaster serial number from the header comment line
 // of a Keymaster-managed authorized_keys file.
 func ParseSerial(line string) (int, error) {
@@ -4,6 +4,7 @@
 package sshkey
 
 import (
+	"encoding/base64"
 	"os"
 	"path/filepath"
 	"testing"
@@ -97,3 +98,46 @@ func TestCheckHostKeyAlgorithm_FromTestKeys(t *testing.T) {
 		}
 	}
 }
+
+func TestNormalizeKey(t *testing.T) {
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte("ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIBVYCTA2Ju/Lm9J3Jts/IHFHqAeVCrw1RnfpTb9GXrRO test"))
+	if err != nil {
+		t.Fatalf("ParseAuthorizedKey: %v", err)
+	}
+	canonical := base64.StdEncoding.EncodeToString(pub.Marshal())
+
+	alg, data := NormalizeKey(" ssh-ed25519 ", " "+canonical+"\n")
+	if alg != "ssh-ed25519" || data != canonical {
+		t.Fatalf("NormalizeKey() = %q, %q", alg, data)
+	}
+	// Undecodable key data is only trimmed.
+	if alg, data := NormalizeKey("ssh-rsa", " not-base64 "); alg != "ssh-rsa" || data != "not-base64" {
+		t.Fatalf("NormalizeKey(invalid) = %q, %q", alg, data)
+	}
+}
+
+func TestNormalize_WhitespaceVariantsAreIdentical(t *testing.T) {
+	const data = "AAAAC3NzaC1lZDI1NTE5AAAAIBVYCTA2Ju/Lm9J3Jts/IHFHqAeVCrw1RnfpTb9GXrRO"
+	lines := []string{
+		"ssh-ed25519 " + data + " alice@laptop",
+		"  ssh-ed25519\t" + data + "   alice@laptop  ",
+		`no-pty ssh-ed25519 ` + data + ` alice@laptop`,
+	}
+	for _, line := range lines {
+		alg, keyData, comment, err := Normalize(line)
+		if err != nil {
+			t.Fatalf("Normalize(%q): %v", line, err)
+		}
+		if alg != "ssh-ed25519" || keyData != data || comment != "alice@laptop" {
+			t.Fatalf("Normalize(%q) = %q, %q, %q", line, alg, keyData, comment)
+		}
+	}
+
+	_, _, comment, err := Normalize("ssh-ed25519 " + data + " Alice   Work\tLaptop")
+	if err != nil || comment != "Alice Work Laptop" {
+		t.Fatalf("expected collapsed comment, got %q (%v)", comment, err)
+	}
+	if _, _, _, err := Normalize("garbage"); err == nil {
+		t.Fatalf("expected error for invalid line")
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package sshkey
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrAlgorithmNotAllowed is returned when a public key uses an algorithm
+// excluded by the configured allow-list.
+var ErrAlgorithmNotAllowed = errors.New("key algorithm not allowed")
+
+var (
+	allowedAlgorithmsMu sync.RWMutex
+	allowedAlgorithms   map[string]bool
+)
+
+// SetAllowedAlgorithms restricts the key algorithms Keymaster accepts and
+// deploys (e.g. "ssh-ed25519", "ecdsa-sha2-nistp256"). An empty list allows
+// every algorithm.
+func SetAllowedAlgorithms(algorithms []string) {
+	allowedAlgorithmsMu.Lock()
+	defer allowedAlgorithmsMu.Unlock()
+	allowedAlgorithms = nil
+	for _, a := range algorithms {
+		a = strings.TrimSpace(a)
+		if a == "" {
+			continue
+		}
+		if allowedAlgorithms == nil {
+			allowedAlgorithms = make(map[string]bool)
+		}
+		allowedAlgorithms[a] = true
+	}
+}
+
+// AllowedAlgorithms returns the configured allow-list in sorted order, or nil
+// when every algorithm is allowed.
+func AllowedAlgorithms() []string {
+	allowedAlgorithmsMu.RLock()
+	defer allowedAlgorithmsMu.RUnlock()
+	if allowedAlgorithms == nil {
+		return nil
+	}
+	out := make([]string, 0, len(allowedAlgorithms))
+	for a := range allowedAlgorithms {
+		out = append(out, a)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// IsAlgorithmAllowed reports whether algorithm passes the allow-list.
+func IsAlgorithmAllowed(algorithm string) bool {
+	allowedAlgorithmsMu.RLock()
+	defer allowedAlgorithmsMu.RUnlock()
+	return allowedAlgorithms == nil || allowedAlgorithms[algorithm]
+}
+
+// CheckAlgorithmAllowed returns an error wrapping ErrAlgorithmNotAllowed when
+// algorithm is excluded by the allow-list.
+func CheckAlgorithmAllowed(algorithm string) error {
+	if IsAlgorithmAllowed(algorithm) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s (allowed: %s)", ErrAlgorithmNotAllowed, algorithm, strings.Join(AllowedAlgorithms(), ", "))
+}
@@ -0,0 +1,31 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package sshkey
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAllowedAlgorithms(t *testing.T) {
+	t.Cleanup(func() { SetAllowedAlgorithms(nil) })
+
+	SetAllowedAlgorithms(nil)
+	if !IsAlgorithmAllowed("ssh-rsa") || CheckAlgorithmAllowed("ssh-rsa") != nil {
+		t.Fatalf("empty allow-list must allow every algorithm")
+	}
+
+	SetAllowedAlgorithms([]string{" ssh-ed25519 ", "ecdsa-sha2-nistp256", ""})
+	if got := AllowedAlgorithms(); strings.Join(got, ",") != "ecdsa-sha2-nistp256,ssh-ed25519" {
+		t.Fatalf("AllowedAlgorithms() = %v", got)
+	}
+	if !IsAlgorithmAllowed("ssh-ed25519") {
+		t.Fatalf("expected ssh-ed25519 to be allowed")
+	}
+	err := CheckAlgorithmAllowed("ssh-rsa")
+	if !errors.Is(err, ErrAlgorithmNotAllowed) || !strings.Contains(err.Error(), "ssh-rsa") {
+		t.Fatalf("expected ErrAlgorithmNotAllowed naming ssh-rsa, got %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package sshkey
+
+import (
+	"fmt"
+	"io"
+)
+
+// MaxAuthorizedKeysSize caps how much of an authorized_keys file is read. It
+// is far above any real file (tens of thousands of keys) but keeps a
+// runaway or hostile remote file from exhausting memory.
+const MaxAuthorizedKeysSize = 16 << 20
+
+// ErrAuthorizedKeysTooLarge is returned by ReadAuthorizedKeys when the input
+// exceeds MaxAuthorizedKeysSize.
+var ErrAuthorizedKeysTooLarge = fmt.Errorf("authorized_keys exceeds %d bytes", MaxAuthorizedKeysSize)
+
+// ReadAuthorizedKeys reads all of r, failing with ErrAuthorizedKeysTooLarge
+// instead of reading past MaxAuthorizedKeysSize.
+func ReadAuthorizedKeys(r io.Reader) ([]byte, error) {
+	return readCapped(r, MaxAuthorizedKeysSize)
+}
+
+func readCapped(r io.Reader, limit int64) ([]byte, error) {
+	content, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > limit {
+		return nil, ErrAuthorizedKeysTooLarge
+	}
+	return content, nil
+}
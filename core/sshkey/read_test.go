@@ -0,0 +1,32 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package sshkey
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReadAuthorizedKeys(t *testing.T) {
+	content := "ssh-ed25519 AAAA one\nssh-ed25519 BBBB two\n"
+	got, err := ReadAuthorizedKeys(strings.NewReader(content))
+	if err != nil || string(got) != content {
+		t.Fatalf("ReadAuthorizedKeys = %q, %v", got, err)
+	}
+
+	got, err = ReadAuthorizedKeys(strings.NewReader(""))
+	if err != nil || len(got) != 0 {
+		t.Fatalf("expected empty content, got %q, %v", got, err)
+	}
+}
+
+func TestReadCapped_Limit(t *testing.T) {
+	if got, err := readCapped(strings.NewReader("12345678"), 8); err != nil || string(got) != "12345678" {
+		t.Fatalf("expected content at the limit to be read, got %q, %v", got, err)
+	}
+	if _, err := readCapped(strings.NewReader("123456789"), 8); !errors.Is(err, ErrAuthorizedKeysTooLarge) {
+		t.Fatalf("expected ErrAuthorizedKeysTooLarge, got %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package sshkey
+
+import (
+	"crypto/dsa" //nolint:staticcheck // needed to measure legacy DSA keys
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// KeyBits returns the key type and size in bits of base64 public key data:
+// the modulus size for RSA and DSA, the curve size for ECDSA and 256 for
+// Ed25519. Security key (sk-*) and other types report 0 bits.
+func KeyBits(keyData string) (keyType string, bits int, err error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(keyData))
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid key data: %w", err)
+	}
+	pub, err := ssh.ParsePublicKey(raw)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid public key: %w", err)
+	}
+	cpk, ok := pub.(ssh.CryptoPublicKey)
+	if !ok {
+		return pub.Type(), 0, nil
+	}
+	switch k := cpk.CryptoPublicKey().(type) {
+	case *rsa.PublicKey:
+		return pub.Type(), k.N.BitLen(), nil
+	case *dsa.PublicKey:
+		return pub.Type(), k.P.BitLen(), nil
+	case *ecdsa.PublicKey:
+		return pub.Type(), k.Curve.Params().BitSize, nil
+	case ed25519.PublicKey:
+		return pub.Type(), 256, nil
+	}
+	return pub.Type(), 0, nil
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// StaleSerialReport lists the active accounts not yet deployed with the
+// active system key, as recorded in the database. Unlike a serial audit it
+// does not contact any host.
+type StaleSerialReport struct {
+	ActiveSerial int
+	// Stale accounts were last deployed with an older system key.
+	Stale []model.Account
+	// NeverDeployed accounts have no recorded serial yet.
+	NeverDeployed []model.Account
+}
+
+// Total returns the number of accounts still waiting for a deploy.
+func (r StaleSerialReport) Total() int {
+	return len(r.Stale) + len(r.NeverDeployed)
+}
+
+// FindStaleSerials classifies the active accounts whose recorded serial is
+// below the active system key's serial. Inactive accounts are skipped as
+// deploys do not touch them.
+func FindStaleSerials(st StaleSerialReader) (StaleSerialReport, error) {
+	var r StaleSerialReport
+	sk, err := st.GetActiveSystemKey()
+	if err != nil {
+		return r, fmt.Errorf("get active system key: %w", err)
+	}
+	if sk == nil {
+		return r, errors.New("no active system key")
+	}
+	r.ActiveSerial = sk.Serial
+	accounts, err := st.GetAccountsBelowSerial(sk.Serial)
+	if err != nil {
+		return r, fmt.Errorf("get accounts: %w", err)
+	}
+	for _, acc := range accounts {
+		switch {
+		case !acc.IsActive:
+		case acc.Serial == 0:
+			r.NeverDeployed = append(r.NeverDeployed, acc)
+		default:
+			r.Stale = append(r.Stale, acc)
+		}
+	}
+	return r, nil
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+)
+
+type staleSerialStore struct {
+	active   *model.SystemKey
+	accounts []model.Account
+}
+
+func (s staleSerialStore) GetActiveSystemKey() (*model.SystemKey, error) { return s.active, nil }
+func (s staleSerialStore) GetAccountsBelowSerial(serial int) ([]model.Account, error) {
+	var out []model.Account
+	for _, a := range s.accounts {
+		if a.Serial < serial {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+func TestFindStaleSerials(t *testing.T) {
+	st := staleSerialStore{
+		active: &model.SystemKey{Serial: 3, IsActive: true},
+		accounts: []model.Account{
+			{ID: 1, Serial: 3, IsActive: true},
+			{ID: 2, Serial: 2, IsActive: true},
+			{ID: 3, Serial: 1, IsActive: true},
+			{ID: 4, Serial: 0, IsActive: true},
+			{ID: 5, Serial: 1, IsActive: false},
+		},
+	}
+	r, err := FindStaleSerials(st)
+	if err != nil {
+		t.Fatalf("FindStaleSerials: %v", err)
+	}
+	if r.ActiveSerial != 3 || r.Total() != 3 {
+		t.Fatalf("unexpected report %+v", r)
+	}
+	if len(r.Stale) != 2 || r.Stale[0].ID != 2 || r.Stale[1].ID != 3 {
+		t.Fatalf("expected accounts 2 and 3 to be stale, got %+v", r.Stale)
+	}
+	if len(r.NeverDeployed) != 1 || r.NeverDeployed[0].ID != 4 {
+		t.Fatalf("expected account 4 to be never deployed, got %+v", r.NeverDeployed)
+	}
+
+	if _, err := FindStaleSerials(staleSerialStore{}); err == nil {
+		t.Fatal("expected an error without an active system key")
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import "fmt"
+
+// SetManageSystemKey sets whether the Keymaster system key is rendered into
+// account id's authorized_keys. Changing the setting marks the account dirty
+// because the next deploy writes different content.
+func SetManageSystemKey(st SystemKeyPolicyStore, id int, manage bool) error {
+	accounts, err := st.GetAllAccounts()
+	if err != nil {
+		return fmt.Errorf("failed to load accounts: %w", err)
+	}
+	for _, acc := range accounts {
+		if acc.ID != id {
+			continue
+		}
+		if acc.ManageSystemKey == manage {
+			return nil
+		}
+		if err := st.SetAccountManageSystemKey(id, manage); err != nil {
+			return fmt.Errorf("failed to save system key setting: %w", err)
+		}
+		if err := st.UpdateAccountIsDirty(id, true); err != nil {
+			return fmt.Errorf("failed to mark account dirty: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("account not found: %d", id)
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/ui/i18n"
+)
+
+type mapAccountReader map[int]model.Account
+
+func (m mapAccountReader) GetAccount(id int) (*model.Account, error) {
+	acc, ok := m[id]
+	if !ok {
+		return nil, nil
+	}
+	return &acc, nil
+}
+
+type systemKeyPolicyStore struct {
+	accounts []model.Account
+	manage   map[int]bool
+	dirty    []int
+}
+
+func (s *systemKeyPolicyStore) GetAllAccounts() ([]model.Account, error) { return s.accounts, nil }
+func (s *systemKeyPolicyStore) SetAccountManageSystemKey(id int, manage bool) error {
+	s.manage[id] = manage
+	return nil
+}
+func (s *systemKeyPolicyStore) UpdateAccountIsDirty(id int, dirty bool) error {
+	s.dirty = append(s.dirty, id)
+	return nil
+}
+
+func withAccountReader(t *testing.T, r AccountReader) {
+	t.Helper()
+	orig := DefaultAccountReader()
+	SetDefaultAccountReader(r)
+	t.Cleanup(func() { SetDefaultAccountReader(orig) })
+}
+
+func TestGenerateKeysContent_RespectsManageSystemKey(t *testing.T) {
+	SetDefaultKeyReader(&fakeKR{})
+	SetDefaultKeyLister(&fakeKL{})
+	withAccountReader(t, mapAccountReader{
+		1: {ID: 1, ManageSystemKey: true},
+		2: {ID: 2, ManageSystemKey: false},
+	})
+
+	managed, err := GenerateKeysContent(1)
+	if err != nil {
+		t.Fatalf("GenerateKeysContent(1): %v", err)
+	}
+	if !strings.Contains(managed, "sys-pub") {
+		t.Fatalf("expected system key in managed content, got %q", managed)
+	}
+	unmanaged, err := GenerateKeysContent(2)
+	if err != nil {
+		t.Fatalf("GenerateKeysContent(2): %v", err)
+	}
+	if strings.Contains(unmanaged, "sys-pub") || !strings.Contains(unmanaged, "(Serial: 1)") {
+		t.Fatalf("expected serial header without system key, got %q", unmanaged)
+	}
+
+	// Unknown accounts keep the system key so Keymaster never locks itself out.
+	unknown, err := GenerateKeysContent(3)
+	if err != nil || !strings.Contains(unknown, "sys-pub") {
+		t.Fatalf("expected system key for unknown account, got %q (%v)", unknown, err)
+	}
+}
+
+func TestAuditAccounts_StrictHashFollowsManageSystemKey(t *testing.T) {
+	i18n.Init("en")
+	SetDefaultKeyReader(&fakeKR{})
+	SetDefaultKeyLister(&fakeKL{})
+	SetDefaultAuditWriter(&spyAuditWriter{})
+	acct := model.Account{ID: 5, Username: "u", Hostname: "h", Serial: 1, IsActive: true}
+
+	withAccountReader(t, mapAccountReader{5: {ID: 5, ManageSystemKey: false}})
+	withoutKey, err := GenerateKeysContent(acct.ID)
+	if err != nil {
+		t.Fatalf("GenerateKeysContent: %v", err)
+	}
+	store := &simpleFakeStore{accounts: []model.Account{acct}}
+	res, err := AuditAccounts(context.TODO(), store, &fakeDeployerManager{content: []byte(withoutKey)}, "strict", nil)
+	if err != nil || len(res) != 1 || res[0].Error != nil {
+		t.Fatalf("expected host without system key to pass, got %+v (%v)", res, err)
+	}
+
+	// The same host content drifts once the account manages the system key.
+	withAccountReader(t, mapAccountReader{5: {ID: 5, ManageSystemKey: true}})
+	res, err = AuditAccounts(context.TODO(), store, &fakeDeployerManager{content: []byte(withoutKey)}, "strict", nil)
+	if err != nil || len(res) != 1 || res[0].Error == nil {
+		t.Fatalf("expected drift when the system key is managed, got %+v (%v)", res, err)
+	}
+}
+
+func TestSetManageSystemKey(t *testing.T) {
+	st := &systemKeyPolicyStore{
+		accounts: []model.Account{{ID: 1, ManageSystemKey: true}},
+		manage:   map[int]bool{},
+	}
+	if err := SetManageSystemKey(st, 1, false); err != nil {
+		t.Fatalf("SetManageSystemKey: %v", err)
+	}
+	if manage, ok := st.manage[1]; !ok || manage {
+		t.Fatalf("expected system key to be disabled, got %v", st.manage)
+	}
+	if len(st.dirty) != 1 || st.dirty[0] != 1 {
+		t.Fatalf("expected account to be marked dirty, got %v", st.dirty)
+	}
+	if err := SetManageSystemKey(st, 99, false); err == nil {
+		t.Fatal("expected error for unknown account")
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/core/sshkey"
+	"golang.org/x/crypto/ssh"
+)
+
+// SystemKeyInfo summarizes a stored system key for listing. It never carries
+// the private key.
+type SystemKeyInfo struct {
+	Serial int
+	// Fingerprint is the SHA256 fingerprint of the public key, or empty when
+	// the stored key cannot be parsed.
+	Fingerprint string
+	IsActive    bool
+	// CreatedAt is zero when the creation time is unknown.
+	CreatedAt time.Time
+	// Accounts is the number of accounts last deployed with this serial, i.e.
+	// the hosts that still trust this key.
+	Accounts int
+}
+
+// ListSystemKeys returns every system key, active or retained, ordered by
+// serial, together with the number of accounts still deployed with it.
+func ListSystemKeys(st Store) ([]SystemKeyInfo, error) {
+	keys, err := st.GetAllSystemKeys()
+	if err != nil {
+		return nil, fmt.Errorf("get system keys: %w", err)
+	}
+	accounts, err := st.GetAllAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("get accounts: %w", err)
+	}
+	perSerial := make(map[int]int)
+	for _, acc := range accounts {
+		if acc.Serial != 0 {
+			perSerial[acc.Serial]++
+		}
+	}
+
+	out := make([]SystemKeyInfo, 0, len(keys))
+	for _, k := range keys {
+		info := SystemKeyInfo{Serial: k.Serial, IsActive: k.IsActive, CreatedAt: k.CreatedAt, Accounts: perSerial[k.Serial]}
+		if pk, _, _, _, perr := ssh.ParseAuthorizedKey([]byte(k.PublicKey)); perr == nil {
+			info.Fingerprint = ssh.FingerprintSHA256(pk)
+		}
+		out = append(out, info)
+	}
+	return out, nil
+}
+
+// PruneSystemKeysOptions controls PruneSystemKeys.
+type PruneSystemKeysOptions struct {
+	// DryRun reports which keys would be pruned without deleting them.
+	DryRun bool
+}
+
+// SystemKeyPruneResult reports the outcome of PruneSystemKeys.
+type SystemKeyPruneResult struct {
+	// Pruned lists the serials deleted (or, in a dry run, deletable).
+	Pruned []int
+	// Kept lists the serials retained: the active key and every key a host
+	// still depends on.
+	Kept []int
+}
+
+// UnverifiedHostsError is returned by PruneSystemKeys when the serial of some
+// active hosts could not be read. Nothing is pruned in that case, since an
+// unreachable host may still depend on an old key.
+type UnverifiedHostsError struct {
+	Accounts []model.Account
+}
+
+func (e *UnverifiedHostsError) Error() string {
+	names := make([]string, 0, len(e.Accounts))
+	for _, a := range e.Accounts {
+		names = append(names, a.String())
+	}
+	return fmt.Sprintf("could not verify the deployed serial on %d host(s): %s; no system keys were pruned", len(e.Accounts), strings.Join(names, ", "))
+}
+
+// PruneSystemKeys deletes system keys that no host depends on any more. A key
+// is kept when it is active, when any account (active or not) records it as
+// its last deployed serial, or when an active host reports it in the
+// Keymaster header of its authorized_keys. Every active host is read first;
+// if any cannot be read an *UnverifiedHostsError is returned and nothing is
+// deleted.
+func PruneSystemKeys(ctx context.Context, st Store, dm DeployerManager, opts PruneSystemKeysOptions) (SystemKeyPruneResult, error) {
+	var res SystemKeyPruneResult
+	keys, err := st.GetAllSystemKeys()
+	if err != nil {
+		return res, fmt.Errorf("get system keys: %w", err)
+	}
+	accounts, err := st.GetAllAccounts()
+	if err != nil {
+		return res, fmt.Errorf("get accounts: %w", err)
+	}
+
+	inUse := make(map[int]bool)
+	var unverified []model.Account
+	for _, acc := range accounts {
+		if acc.Serial != 0 {
+			inUse[acc.Serial] = true
+		}
+		if !acc.IsActive || acc.Serial == 0 {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		content, ferr := dm.FetchAuthorizedKeys(acc)
+		if ferr != nil {
+			DefaultLogger().Warn("could not read deployed serial", "account", acc.String(), "err", ferr)
+			unverified = append(unverified, acc)
+			continue
+		}
+		if serial, ok := deployedSerial(content); ok {
+			inUse[serial] = true
+		}
+	}
+	if len(unverified) > 0 {
+		return res, &UnverifiedHostsError{Accounts: unverified}
+	}
+
+	for _, k := range keys {
+		if k.IsActive || inUse[k.Serial] {
+			res.Kept = append(res.Kept, k.Serial)
+			continue
+		}
+		if !opts.DryRun {
+			if err := st.DeleteSystemKey(k.Serial); err != nil {
+				return res, fmt.Errorf("delete system key %d: %w", k.Serial, err)
+			}
+			DefaultLogger().Info("pruned system key", "serial", k.Serial)
+		}
+		res.Pruned = append(res.Pruned, k.Serial)
+	}
+	sort.Ints(res.Pruned)
+	sort.Ints(res.Kept)
+	return res, nil
+}
+
+// deployedSerial returns the serial from the Keymaster header, the first
+// non-empty line of an authorized_keys file.
+func deployedSerial(content []byte) (int, bool) {
+	for _, line := range strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		serial, err := sshkey.ParseSerial(line)
+		return serial, err == nil
+	}
+	return 0, false
+}
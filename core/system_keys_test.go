@@ -0,0 +1,153 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toeirei/keymaster/core/crypto/ssh"
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// systemKeyStore serves a fixed set of system keys on top of simpleFakeStore.
+type systemKeyStore struct {
+	simpleFakeStore
+	keys    []model.SystemKey
+	deleted []int
+}
+
+func (s *systemKeyStore) DeleteSystemKey(serial int) error {
+	s.deleted = append(s.deleted, serial)
+	return nil
+}
+
+func (s *systemKeyStore) GetAllSystemKeys() ([]model.SystemKey, error) { return s.keys, nil }
+func (s *systemKeyStore) GetAllAccounts() ([]model.Account, error)     { return s.accounts, nil }
+
+func TestListSystemKeys(t *testing.T) {
+	pub, _, err := ssh.GenerateAndMarshalEd25519Key("keymaster-system-key", "")
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	st := &systemKeyStore{
+		simpleFakeStore: simpleFakeStore{accounts: []model.Account{
+			{ID: 1, Serial: 1}, {ID: 2, Serial: 1}, {ID: 3, Serial: 2}, {ID: 4, Serial: 0},
+		}},
+		keys: []model.SystemKey{
+			{Serial: 1, PublicKey: pub, CreatedAt: created},
+			{Serial: 2, PublicKey: "not a key", IsActive: true},
+		},
+	}
+
+	infos, err := ListSystemKeys(st)
+	if err != nil {
+		t.Fatalf("ListSystemKeys: %v", err)
+	}
+	if len(infos) != 2 {
+		t.Fatalf("expected 2 keys, got %d", len(infos))
+	}
+	if !strings.HasPrefix(infos[0].Fingerprint, "SHA256:") || infos[0].Accounts != 2 || infos[0].IsActive || !infos[0].CreatedAt.Equal(created) {
+		t.Fatalf("unexpected first key: %+v", infos[0])
+	}
+	if infos[1].Fingerprint != "" || infos[1].Accounts != 1 || !infos[1].IsActive || !infos[1].CreatedAt.IsZero() {
+		t.Fatalf("unexpected second key: %+v", infos[1])
+	}
+}
+
+// serialHostsDM serves authorized_keys headers per account ID; accounts
+// missing from headers cannot be reached.
+type serialHostsDM struct {
+	fakeDeployerManager
+	headers map[int]int
+}
+
+func (d *serialHostsDM) FetchAuthorizedKeys(account model.Account) ([]byte, error) {
+	serial, ok := d.headers[account.ID]
+	if !ok {
+		return nil, errors.New("connection refused")
+	}
+	return []byte(fmt.Sprintf("# Keymaster Managed Keys (Serial: %d)\nssh-ed25519 AAAA user\n", serial)), nil
+}
+
+func pruneFixture(accounts []model.Account) *systemKeyStore {
+	return &systemKeyStore{
+		simpleFakeStore: simpleFakeStore{accounts: accounts},
+		keys: []model.SystemKey{
+			{Serial: 1}, {Serial: 2}, {Serial: 3}, {Serial: 4, IsActive: true},
+		},
+	}
+}
+
+func TestPruneSystemKeys_KeepsUsedAndPrunesSuperseded(t *testing.T) {
+	st := pruneFixture([]model.Account{
+		// Still deployed with serial 1.
+		{ID: 1, Username: "a", Hostname: "h1", Serial: 1, IsActive: true},
+		// Upgraded to the active key.
+		{ID: 2, Username: "b", Hostname: "h2", Serial: 4, IsActive: true},
+		// Recorded as upgraded, but the host still reports serial 3.
+		{ID: 3, Username: "c", Hostname: "h3", Serial: 4, IsActive: true},
+	})
+	dm := &serialHostsDM{headers: map[int]int{1: 1, 2: 4, 3: 3}}
+
+	res, err := PruneSystemKeys(context.Background(), st, dm, PruneSystemKeysOptions{})
+	if err != nil {
+		t.Fatalf("PruneSystemKeys: %v", err)
+	}
+	if !reflect.DeepEqual(res.Pruned, []int{2}) || !reflect.DeepEqual(st.deleted, []int{2}) {
+		t.Fatalf("expected only serial 2 pruned, got result %v deleted %v", res.Pruned, st.deleted)
+	}
+	if !reflect.DeepEqual(res.Kept, []int{1, 3, 4}) {
+		t.Fatalf("expected serials 1, 3 and 4 kept, got %v", res.Kept)
+	}
+}
+
+func TestPruneSystemKeys_InactiveAccountKeepsItsSerial(t *testing.T) {
+	st := pruneFixture([]model.Account{
+		{ID: 1, Username: "a", Hostname: "h1", Serial: 2, IsActive: false},
+	})
+	res, err := PruneSystemKeys(context.Background(), st, &serialHostsDM{}, PruneSystemKeysOptions{})
+	if err != nil {
+		t.Fatalf("PruneSystemKeys: %v", err)
+	}
+	if !reflect.DeepEqual(res.Pruned, []int{1, 3}) || !reflect.DeepEqual(res.Kept, []int{2, 4}) {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+}
+
+func TestPruneSystemKeys_UnreachableHostPrunesNothing(t *testing.T) {
+	st := pruneFixture([]model.Account{
+		{ID: 1, Username: "a", Hostname: "h1", Serial: 4, IsActive: true},
+		{ID: 2, Username: "b", Hostname: "down", Serial: 4, IsActive: true},
+	})
+	dm := &serialHostsDM{headers: map[int]int{1: 4}}
+
+	_, err := PruneSystemKeys(context.Background(), st, dm, PruneSystemKeysOptions{})
+	var uerr *UnverifiedHostsError
+	if !errors.As(err, &uerr) || len(uerr.Accounts) != 1 || uerr.Accounts[0].ID != 2 {
+		t.Fatalf("expected UnverifiedHostsError for account 2, got %v", err)
+	}
+	if len(st.deleted) != 0 {
+		t.Fatalf("expected nothing deleted, got %v", st.deleted)
+	}
+}
+
+func TestPruneSystemKeys_DryRun(t *testing.T) {
+	st := pruneFixture([]model.Account{{ID: 1, Username: "a", Hostname: "h1", Serial: 4, IsActive: true}})
+	dm := &serialHostsDM{headers: map[int]int{1: 4}}
+
+	res, err := PruneSystemKeys(context.Background(), st, dm, PruneSystemKeysOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("PruneSystemKeys: %v", err)
+	}
+	if !reflect.DeepEqual(res.Pruned, []int{1, 2, 3}) || len(st.deleted) != 0 {
+		t.Fatalf("dry run should report without deleting: %+v deleted %v", res, st.deleted)
+	}
+}
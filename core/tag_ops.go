@@ -68,3 +68,34 @@ func joinTags(parts []string) string {
 	}
 	return strings.Join(parts, ", ")
 }
+
+// ModifyAccountTags applies tag additions and removals to a comma-separated
+// tags string and returns the normalized result. Existing tags keep their
+// order, added tags are appended, duplicates are dropped and removing a tag
+// that is not present is a no-op. A tag both added and removed ends up
+// removed.
+func ModifyAccountTags(existing string, add, remove []string) string {
+	removed := make(map[string]struct{}, len(remove))
+	for _, t := range remove {
+		if t = strings.TrimSpace(t); t != "" {
+			removed[t] = struct{}{}
+		}
+	}
+	seen := make(map[string]struct{})
+	var out []string
+	for _, t := range append(SplitTags(existing), add...) {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+		if _, ok := removed[t]; ok {
+			continue
+		}
+		if _, ok := seen[t]; ok {
+			continue
+		}
+		seen[t] = struct{}{}
+		out = append(out, t)
+	}
+	return joinTags(out)
+}
@@ -71,3 +71,29 @@ func TestSuggestTagsAndApplySuggestion(t *testing.T) {
 		t.Fatalf("ApplySuggestion empty unexpected: %q", out)
 	}
 }
+
+func TestModifyAccountTags(t *testing.T) {
+	cases := []struct {
+		name     string
+		existing string
+		add      []string
+		remove   []string
+		want     string
+	}{
+		{"add to empty", "", []string{"env:prod"}, nil, "env:prod"},
+		{"add keeps existing order", "b, a", []string{"c"}, nil, "b, a, c"},
+		{"remove", "env:prod, role:old, team:x", nil, []string{"role:old"}, "env:prod, team:x"},
+		{"dedupe existing and added", "a,a, b", []string{"b", " c ", "c"}, nil, "a, b, c"},
+		{"remove absent is no-op", "a, b", nil, []string{"zzz"}, "a, b"},
+		{"add and remove same tag", "a", []string{"b"}, []string{"b"}, "a"},
+		{"remove everything", "a, b", nil, []string{"a", "b"}, ""},
+		{"blank input ignored", "a,,", []string{"", " "}, []string{""}, "a"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := ModifyAccountTags(c.existing, c.add, c.remove); got != c.want {
+				t.Fatalf("ModifyAccountTags(%q, %q, %q) = %q; want %q", c.existing, c.add, c.remove, got, c.want)
+			}
+		})
+	}
+}
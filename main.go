@@ -33,6 +33,6 @@ func main() {
 
 	if err := cli.Execute(); err != nil {
 		log.Printf("Keymaster CLI error: %v", err)
-		os.Exit(1)
+		os.Exit(cli.ExitCode(err))
 	}
 }
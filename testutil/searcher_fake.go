@@ -5,6 +5,7 @@ package testutil
 
 import (
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/toeirei/keymaster/core/model"
@@ -191,6 +192,14 @@ func (f *FakeKeyManager) AssignKeyToAccount(keyID, accountID int) error {
 	return nil
 }
 
+func (f *FakeKeyManager) AssignKeyToAccountWithOptions(keyID, accountID int, options string) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	f.Calls = append(f.Calls, [3]string{"AssignKeyToAccountWithOptions", strconv.Itoa(keyID), strconv.Itoa(accountID)})
+	return nil
+}
+
 func (f *FakeKeyManager) UnassignKeyFromAccount(keyID, accountID int) error {
 	if f.Err != nil {
 		return f.Err
@@ -220,3 +229,45 @@ func (f *FakeKeyManager) SetPublicKeyExpiry(id int, expiresAt time.Time) error {
 	f.Calls = append(f.Calls, [3]string{"SetPublicKeyExpiry", strconv.Itoa(id), expiresAt.UTC().Format(time.RFC3339)})
 	return nil
 }
+
+func (f *FakeKeyManager) SetPublicKeyCertAuthority(id int, isCA bool, principals []string) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	f.Calls = append(f.Calls, [3]string{"SetPublicKeyCertAuthority", strconv.Itoa(id), strings.Join(principals, ",")})
+	for i := range f.Results {
+		if f.Results[i].ID == id {
+			f.Results[i].IsCA = isCA
+			f.Results[i].Principals = principals
+		}
+	}
+	return nil
+}
+
+func (f *FakeKeyManager) UpdatePublicKey(id int, algorithm, keyData string) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	f.Calls = append(f.Calls, [3]string{"UpdatePublicKey", strconv.Itoa(id), algorithm})
+	for i := range f.Results {
+		if f.Results[i].ID == id {
+			f.Results[i].Algorithm = algorithm
+			f.Results[i].KeyData = keyData
+		}
+	}
+	return nil
+}
+
+func (f *FakeKeyManager) SetPublicKeyMetadata(id int, metadata map[string]string) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	encoded, _ := model.MarshalMetadata(metadata)
+	f.Calls = append(f.Calls, [3]string{"SetPublicKeyMetadata", strconv.Itoa(id), encoded})
+	for i := range f.Results {
+		if f.Results[i].ID == id {
+			f.Results[i].Metadata = metadata
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/toeirei/keymaster/core"
+	"github.com/toeirei/keymaster/core/model"
+	"golang.org/x/term"
+)
+
+// errAccountIdentifierRequired is returned when an account would have to be
+// picked interactively but no terminal is attached.
+var errAccountIdentifierRequired = errors.New("no account identifier given and no interactive terminal available; pass an account ID, user@host or label")
+
+// errAccountPickerCancelled is returned when the operator leaves the picker
+// without selecting an account.
+var errAccountPickerCancelled = errors.New("account selection cancelled")
+
+// accountPickerVisible is the number of matches listed below the filter.
+const accountPickerVisible = 10
+
+// isInteractiveTerminal reports whether both stdin and stdout are terminals.
+// Tests override it to exercise the non-interactive path.
+var isInteractiveTerminal = func() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// runAccountPicker shows the picker and returns the chosen account.
+var runAccountPicker = func(accounts []model.Account) (*model.Account, error) {
+	final, err := tea.NewProgram(newAccountPickerModel(accounts)).Run()
+	if err != nil {
+		return nil, err
+	}
+	m := final.(accountPickerModel)
+	if m.selected == nil {
+		return nil, errAccountPickerCancelled
+	}
+	return m.selected, nil
+}
+
+// selectAccountInteractively lets the operator pick one of accounts by
+// typing to filter them. Without a terminal it fails with
+// errAccountIdentifierRequired so scripts never block on a prompt.
+func selectAccountInteractively(accounts []model.Account) (*model.Account, error) {
+	if !isInteractiveTerminal() {
+		return nil, errAccountIdentifierRequired
+	}
+	if len(accounts) == 0 {
+		return nil, errors.New("no accounts found")
+	}
+	return runAccountPicker(accounts)
+}
+
+// accountPickerMatches reports whether account matches query. Every
+// whitespace-separated term must appear, case-insensitively, in the
+// username, hostname, label or tags, like the TUI account filter.
+func accountPickerMatches(account model.Account, query string) bool {
+	combined := account.Username + " " + account.Hostname + " " + account.Label + " " + account.Tags
+	for _, word := range strings.Fields(query) {
+		if !core.ContainsIgnoreCase(combined, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// accountPickerModel is a small bubbletea model: a filter input above the
+// list of matching accounts.
+type accountPickerModel struct {
+	input    textinput.Model
+	accounts []model.Account
+	matches  []model.Account
+	cursor   int
+	selected *model.Account
+}
+
+func newAccountPickerModel(accounts []model.Account) accountPickerModel {
+	input := textinput.New()
+	input.Prompt = "Filter: "
+	input.Placeholder = "user, host, label or tag"
+	input.Focus()
+	return accountPickerModel{input: input, accounts: accounts, matches: accounts}
+}
+
+func (m accountPickerModel) Init() tea.Cmd { return textinput.Blink }
+
+func (m accountPickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyEsc:
+			return m, tea.Quit
+		case tea.KeyEnter:
+			if len(m.matches) > 0 {
+				acc := m.matches[m.cursor]
+				m.selected = &acc
+			}
+			return m, tea.Quit
+		case tea.KeyUp, tea.KeyCtrlP:
+			if m.cursor > 0 {
+				m.cursor--
+			}
+			return m, nil
+		case tea.KeyDown, tea.KeyCtrlN:
+			if m.cursor < len(m.matches)-1 {
+				m.cursor++
+			}
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	m.matches = m.matches[:0:0]
+	for _, acc := range m.accounts {
+		if accountPickerMatches(acc, m.input.Value()) {
+			m.matches = append(m.matches, acc)
+		}
+	}
+	if m.cursor >= len(m.matches) {
+		m.cursor = max(len(m.matches)-1, 0)
+	}
+	return m, cmd
+}
+
+func (m accountPickerModel) View() string {
+	var b strings.Builder
+	b.WriteString(m.input.View())
+	b.WriteString("\n\n")
+
+	// Keep the cursor inside the visible window.
+	start := 0
+	if m.cursor >= accountPickerVisible {
+		start = m.cursor - accountPickerVisible + 1
+	}
+	end := min(start+accountPickerVisible, len(m.matches))
+	for i := start; i < end; i++ {
+		acc := m.matches[i]
+		marker := "  "
+		if i == m.cursor {
+			marker = "> "
+		}
+		status := "active"
+		if !acc.IsActive {
+			status = "inactive"
+		}
+		fmt.Fprintf(&b, "%s%s (%s)\n", marker, acc.String(), status)
+	}
+	if len(m.matches) == 0 {
+		b.WriteString("  no matching accounts\n")
+	}
+	fmt.Fprintf(&b, "\n%d/%d accounts · ↑/↓ move · enter select · esc cancel\n", len(m.matches), len(m.accounts))
+	return b.String()
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package cli
+
+import (
+	"errors"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/toeirei/keymaster/core/model"
+)
+
+func TestAccountPickerMatches(t *testing.T) {
+	acc := model.Account{Username: "deploy", Hostname: "web-01.example.com", Label: "Prod Web", Tags: "env:prod, team:web"}
+	cases := []struct {
+		query string
+		want  bool
+	}{
+		{"", true},
+		{"deploy", true},
+		{"WEB-01", true},
+		{"prod web", true},
+		{"team:web", true},
+		{"deploy staging", false},
+		{"db-01", false},
+	}
+	for _, tc := range cases {
+		if got := accountPickerMatches(acc, tc.query); got != tc.want {
+			t.Errorf("accountPickerMatches(%q) = %v, want %v", tc.query, got, tc.want)
+		}
+	}
+}
+
+func TestSelectAccountInteractively_NonTTY(t *testing.T) {
+	origTTY, origRun := isInteractiveTerminal, runAccountPicker
+	defer func() { isInteractiveTerminal, runAccountPicker = origTTY, origRun }()
+	isInteractiveTerminal = func() bool { return false }
+	runAccountPicker = func([]model.Account) (*model.Account, error) {
+		t.Fatal("picker must not run without a terminal")
+		return nil, nil
+	}
+
+	_, err := selectAccountInteractively([]model.Account{{ID: 1, Username: "deploy", Hostname: "web-01"}})
+	if !errors.Is(err, errAccountIdentifierRequired) {
+		t.Fatalf("expected errAccountIdentifierRequired, got %v", err)
+	}
+}
+
+func TestAccountPickerModel_FilterAndSelect(t *testing.T) {
+	accounts := []model.Account{
+		{ID: 1, Username: "deploy", Hostname: "web-01"},
+		{ID: 2, Username: "deploy", Hostname: "db-01", Tags: "env:prod"},
+		{ID: 3, Username: "backup", Hostname: "db-02", Tags: "env:prod"},
+	}
+	var m tea.Model = newAccountPickerModel(accounts)
+	for _, r := range "prod" {
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	if got := len(m.(accountPickerModel).matches); got != 2 {
+		t.Fatalf("expected 2 matches for 'prod', got %d", got)
+	}
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	sel := m.(accountPickerModel).selected
+	if sel == nil || sel.ID != 3 {
+		t.Fatalf("expected account 3 to be selected, got %+v", sel)
+	}
+
+	m = newAccountPickerModel(accounts)
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.(accountPickerModel).selected != nil {
+		t.Fatal("expected no selection after esc")
+	}
+}
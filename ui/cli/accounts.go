@@ -4,15 +4,21 @@
 package cli
 
 import (
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/toeirei/keymaster/core"
 	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/core/sshkey"
 	"github.com/toeirei/keymaster/uiadapters"
 )
 
@@ -26,42 +32,119 @@ var accountCmd = &cobra.Command{
   - Create new accounts
   - Update account properties (hostname, label, tags)
   - Enable/disable accounts (active/inactive status)
+  - Schedule accounts to be disabled/enabled at a future time
   - Delete accounts
-  - Assign and unassign SSH keys to/from accounts`,
+  - Assign and unassign SSH keys to/from accounts
+  - Check that account hosts are reachable on their SSH port`,
 }
 
+// Output formats accepted by 'account list --format'.
+const (
+	accountListFormatTable = "table"
+	accountListFormatJSON  = "json"
+	accountListFormatCSV   = "csv"
+)
+
 // accountListCmd lists all accounts with optional filtering.
 var accountListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all accounts",
-	Long: `Display all accounts in table format with their hostnames, labels, tags, and status.
-You can filter by status (active, inactive) or search by hostname/username.`,
+	Long: `Display all accounts with their hostnames, labels, tags, status, deployed
+system key serial and dirty flag.
+You can filter by status (active, inactive) or search by hostname/username.
+Use --format json or --format csv for scripting and spreadsheets.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		statusFilter, _ := cmd.Flags().GetString("status")
 		searchTerm, _ := cmd.Flags().GetString("search")
+		format, _ := cmd.Flags().GetString("format")
+
+		format = strings.ToLower(strings.TrimSpace(format))
+		switch format {
+		case accountListFormatTable, accountListFormatJSON, accountListFormatCSV:
+		default:
+			return fmt.Errorf("unknown format %q (use table, json or csv)", format)
+		}
 
 		st := uiadapters.NewStoreAdapter()
 		accounts, err := core.ListAccounts(st, statusFilter, searchTerm)
 		if err != nil {
 			return err
 		}
-		if len(accounts) == 0 {
-			fmt.Println("No accounts found.")
-			return nil
+		return writeAccountList(os.Stdout, accounts, format)
+	},
+}
+
+// accountListRow is the machine-readable representation of an account used
+// by the json and csv list formats.
+type accountListRow struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	Hostname string `json:"hostname"`
+	Label    string `json:"label"`
+	Tags     string `json:"tags"`
+	Active   bool   `json:"active"`
+	Serial   int    `json:"serial"`
+	Dirty    bool   `json:"dirty"`
+
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// writeAccountList renders accounts to w in the given format. Empty results
+// print a notice for the table format, "[]" for json and only the header for
+// csv so scripts can always parse the output.
+func writeAccountList(w io.Writer, accounts []model.Account, format string) error {
+	rows := make([]accountListRow, 0, len(accounts))
+	for _, acc := range accounts {
+		rows = append(rows, accountListRow{
+			ID:       acc.ID,
+			Username: acc.Username,
+			Hostname: acc.Hostname,
+			Label:    acc.Label,
+			Tags:     acc.Tags,
+			Active:   acc.IsActive,
+			Serial:   acc.Serial,
+			Dirty:    acc.IsDirty,
+			Metadata: acc.Metadata,
+		})
+	}
+
+	switch format {
+	case accountListFormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	case accountListFormatCSV:
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"id", "username", "hostname", "label", "tags", "active", "serial", "dirty"})
+		for _, r := range rows {
+			_ = cw.Write([]string{
+				strconv.Itoa(r.ID), r.Username, r.Hostname, r.Label, r.Tags,
+				strconv.FormatBool(r.Active), strconv.Itoa(r.Serial), strconv.FormatBool(r.Dirty),
+			})
 		}
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		_, _ = fmt.Fprintln(w, "ID\tUSERNAME\tHOSTNAME\tLABEL\tTAGS\tSTATUS")
-		for _, acc := range accounts {
-			status := "active"
-			if !acc.IsActive {
-				status = "inactive"
-			}
-			_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n",
-				acc.ID, acc.Username, acc.Hostname, acc.Label, acc.Tags, status)
+		cw.Flush()
+		return cw.Error()
+	}
+
+	if len(rows) == 0 {
+		_, err := fmt.Fprintln(w, "No accounts found.")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "ID\tUSERNAME\tHOSTNAME\tLABEL\tTAGS\tSTATUS\tSERIAL\tDIRTY")
+	for _, r := range rows {
+		status := "active"
+		if !r.Active {
+			status = "inactive"
 		}
-		_ = w.Flush()
-		return nil
-	},
+		dirty := ""
+		if r.Dirty {
+			dirty = "yes"
+		}
+		_, _ = fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%d\t%s\n",
+			r.ID, r.Username, r.Hostname, r.Label, r.Tags, status, r.Serial, dirty)
+	}
+	return tw.Flush()
 }
 
 // accountShowCmd displays detailed information about a specific account.
@@ -86,22 +169,52 @@ var accountShowCmd = &cobra.Command{
 		fmt.Printf("Hostname:  %s\n", account.Hostname)
 		fmt.Printf("Label:     %s\n", account.Label)
 		fmt.Printf("Tags:      %s\n", account.Tags)
+		if account.Group != "" {
+			fmt.Printf("Group:     %s\n", account.Group)
+		}
 		fmt.Printf("Status:    %s\n", status)
 		fmt.Printf("Serial:    %d\n", account.Serial)
-		km := core.DefaultKeyManager()
+		fmt.Printf("Remediation: %s\n", account.EffectiveRemediationPolicy())
+		fmt.Printf("Deploy mode: %s\n", account.EffectiveDeployMode())
+		if family := account.EffectiveOSFamily(); family != model.OSFamilyPOSIX {
+			fmt.Printf("OS family: %s (%s)\n", family, account.AuthorizedKeysPath())
+		}
+		if !account.DeployEnabled {
+			fmt.Println("Deploys:   disabled")
+		}
+		if account.DeployKnownHosts {
+			fmt.Println("Known hosts: deployed")
+		}
+		if !account.ManageSystemKey {
+			fmt.Println("System key: not managed")
+		}
+		if len(account.ExcludedGlobalKeys) > 0 {
+			fmt.Printf("Excluded global keys: %s\n", formatKeyIDs(account.ExcludedGlobalKeys))
+		}
+		if len(account.AuthorizedPrincipals) > 0 {
+			fmt.Printf("Authorized principals: %s\n", strings.Join(account.AuthorizedPrincipals, ", "))
+		}
+		if !account.DisableAt.IsZero() {
+			fmt.Printf("Disable at: %s\n", account.DisableAt.Local().Format(time.RFC3339))
+		}
+		if !account.EnableAt.IsZero() {
+			fmt.Printf("Enable at:  %s\n", account.EnableAt.Local().Format(time.RFC3339))
+		}
+		printMetadata(os.Stdout, account.Metadata)
+		km := core.KeyManagerFromContext(cmd.Context())
 		if km != nil {
 			keys, keyErr := km.GetKeysForAccount(account.ID)
 			if keyErr == nil && len(keys) > 0 {
 				fmt.Println("\nAssigned Keys:")
 				w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-				_, _ = fmt.Fprintln(w, "KEY_ID\tALGORITHM\tCOMMENT\tIS_GLOBAL")
+				_, _ = fmt.Fprintln(w, "KEY_ID\tALGORITHM\tCOMMENT\tIS_GLOBAL\tOPTIONS")
 				for _, key := range keys {
 					isGlobal := "no"
 					if key.IsGlobal {
 						isGlobal = "yes"
 					}
-					_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\n",
-						key.ID, key.Algorithm, key.Comment, isGlobal)
+					_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n",
+						key.ID, key.Algorithm, key.Comment, isGlobal, key.AssignmentOptions)
 				}
 				_ = w.Flush()
 			}
@@ -130,12 +243,49 @@ var accountCreateCmd = &cobra.Command{
 	},
 }
 
+// accountImportCSVCmd creates accounts in bulk from a CSV file.
+var accountImportCSVCmd = &cobra.Command{
+	Use:   "import-csv <file>",
+	Short: "Create accounts from a CSV file",
+	Long: `Create one account per CSV row. Columns are username,hostname,label,tags;
+label and tags are optional and a header row naming the columns may reorder
+them. Rows whose username@hostname already exists are skipped.
+
+Invalid rows are reported and skipped. With --strict, any invalid row aborts
+the import before an account is created.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		strict, _ := cmd.Flags().GetBool("strict")
+		f, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("open csv: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+
+		st := uiadapters.NewStoreAdapter()
+		existing, err := st.GetAllAccounts()
+		if err != nil {
+			return fmt.Errorf("failed to load accounts: %w", err)
+		}
+		res, err := core.ImportAccountsCSV(st, existing, f, core.AccountCSVImportOptions{Strict: strict})
+		for _, rowErr := range res.Errors {
+			fmt.Printf("  ! %v\n", rowErr)
+		}
+		fmt.Printf("Created %d, skipped %d, errors %d\n", res.Created, res.Skipped, len(res.Errors))
+		return err
+	},
+}
+
 // accountUpdateCmd updates account properties.
 var accountUpdateCmd = &cobra.Command{
 	Use:   "update <id>",
 	Short: "Update account properties",
-	Long:  `Update hostname, label, or tags for an existing account.`,
-	Args:  cobra.ExactArgs(1),
+	Long: `Update hostname, label, or tags for an existing account.
+
+Use --no-system-key for hosts where another tool owns the Keymaster system key
+line: deploys then omit it and audits expect it to be absent. Pass
+--no-system-key=false to manage the system key again.`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		id, err := strconv.Atoi(args[0])
 		if err != nil {
@@ -155,9 +305,22 @@ var accountUpdateCmd = &cobra.Command{
 			tags, _ := cmd.Flags().GetString("tags")
 			tagsPtr = &tags
 		}
-		err = core.UpdateAccount(st, id, hostnamePtr, labelPtr, tagsPtr)
-		if err != nil {
-			return err
+		systemKeyChanged := cmd.Flags().Changed("no-system-key")
+		if hostnamePtr != nil || labelPtr != nil || tagsPtr != nil || !systemKeyChanged {
+			if err := core.UpdateAccount(st, id, hostnamePtr, labelPtr, tagsPtr); err != nil {
+				return err
+			}
+		}
+		if systemKeyChanged {
+			noSystemKey, _ := cmd.Flags().GetBool("no-system-key")
+			if err := core.SetManageSystemKey(st, id, !noSystemKey); err != nil {
+				return err
+			}
+			if noSystemKey {
+				fmt.Println("System key will no longer be deployed to this account")
+			} else {
+				fmt.Println("System key will be deployed to this account")
+			}
 		}
 		if hostnamePtr != nil {
 			fmt.Printf("Hostname updated to: %s\n", *hostnamePtr)
@@ -168,13 +331,82 @@ var accountUpdateCmd = &cobra.Command{
 		if tagsPtr != nil {
 			fmt.Printf("Tags updated to: %s\n", *tagsPtr)
 		}
-		if hostnamePtr == nil && labelPtr == nil && tagsPtr == nil {
-			fmt.Println("No fields to update. Use --hostname, --label, or --tags flags.")
+		if hostnamePtr == nil && labelPtr == nil && tagsPtr == nil && !systemKeyChanged {
+			fmt.Println("No fields to update. Use --hostname, --label, --tags or --no-system-key flags.")
+		}
+		return nil
+	},
+}
+
+// accountTagCmd adds or removes individual tags on an account.
+var accountTagCmd = &cobra.Command{
+	Use:   "tag <id>",
+	Short: "Add or remove account tags",
+	Long: `Add or remove individual tags without replacing the whole tags string.
+Existing tags are kept, duplicates are dropped and removing a tag that is not
+set is a no-op.
+
+Example:
+  keymaster account tag 3 --add env:prod --remove role:old`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid account ID: %w", err)
+		}
+		add, _ := cmd.Flags().GetStringSlice("add")
+		remove, _ := cmd.Flags().GetStringSlice("remove")
+		if len(add) == 0 && len(remove) == 0 {
+			return fmt.Errorf("nothing to change. Use --add or --remove")
+		}
+		st := uiadapters.NewStoreAdapter()
+		tags, err := core.ModifyTagsForAccount(st, id, add, remove)
+		if err != nil {
+			return err
 		}
+		fmt.Printf("Tags updated to: %s\n", tags)
 		return nil
 	},
 }
 
+// accountSetTagsCmd replaces the tags of many accounts from a CSV file.
+var accountSetTagsCmd = &cobra.Command{
+	Use:   "set-tags --from-csv <file>",
+	Short: "Replace the tags of many accounts from a CSV file",
+	Long: `Replace the tags of each account listed in a CSV file. Rows are
+identifier,tags where identifier is an account ID, user@host or label. Tags
+may be quoted ("env:prod,team:web") or spread over the remaining columns; an
+empty tags column clears the tags. A header row starting with 'identifier'
+is skipped.
+
+Rows naming an unknown account or carrying a malformed tag (spaces or the
+tag expression operators &|!()*) are reported and skipped. With --strict,
+any such row aborts before a tag is changed.
+
+Example:
+  keymaster account set-tags --from-csv tags.csv`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, _ := cmd.Flags().GetString("from-csv")
+		if path == "" {
+			return fmt.Errorf("--from-csv is required")
+		}
+		strict, _ := cmd.Flags().GetBool("strict")
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("open csv: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+
+		res, err := core.SetAccountTagsCSV(uiadapters.NewStoreAdapter(), f, core.AccountTagsCSVOptions{Strict: strict})
+		for _, rowErr := range res.Errors {
+			fmt.Printf("  ! %v\n", rowErr)
+		}
+		fmt.Printf("Applied %d, skipped %d, errors %d\n", res.Applied, res.Skipped, len(res.Errors))
+		return err
+	},
+}
+
 // accountEnableCmd enables an account (sets it to active).
 var accountEnableCmd = &cobra.Command{
 	Use:   "enable <id>",
@@ -217,6 +449,698 @@ var accountDisableCmd = &cobra.Command{
 	},
 }
 
+// accountDeployEnableCmd lets deploys write to an account again.
+var accountDeployEnableCmd = &cobra.Command{
+	Use:   "deploy-enable <id>",
+	Short: "Include an account in deploys again",
+	Long:  `Re-enable deploys for an account excluded with 'account deploy-disable'.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setAccountDeployEnabled(args[0], true)
+	},
+}
+
+// accountDeployDisableCmd excludes an account from deploys.
+var accountDeployDisableCmd = &cobra.Command{
+	Use:   "deploy-disable <id>",
+	Short: "Exclude an account from deploys but keep auditing it",
+	Long: `Exclude an active account from deploys, for example while its host is under
+manual maintenance. Unlike 'account disable', the account is still audited;
+deploys of all or dirty accounts, canary rollouts and automatic drift
+remediation skip it, and deploying to it directly fails.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setAccountDeployEnabled(args[0], false)
+	},
+}
+
+func setAccountDeployEnabled(arg string, enabled bool) error {
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		return fmt.Errorf("invalid account ID: %w", err)
+	}
+	st := uiadapters.NewStoreAdapter()
+	if err := core.SetDeployEnabled(st, id, enabled); err != nil {
+		return err
+	}
+	if enabled {
+		fmt.Printf("Deploys enabled for account %d\n", id)
+	} else {
+		fmt.Printf("Deploys disabled for account %d\n", id)
+	}
+	return nil
+}
+
+// accountScheduleCmd schedules automatic disable/enable of an account.
+var accountScheduleCmd = &cobra.Command{
+	Use:   "schedule <id>",
+	Short: "Schedule an account to be disabled and/or re-enabled",
+	Long: `Schedule automatic status changes for an account, e.g. for a maintenance
+window. Times are RFC 3339 (2026-01-02T15:04:05Z) or local "2006-01-02 15:04".
+Schedules are applied by the background session reaper, so changes take
+effect within a few minutes of the scheduled time. Use --clear to remove a
+pending schedule.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid account ID: %w", err)
+		}
+		disableStr, _ := cmd.Flags().GetString("disable-at")
+		enableStr, _ := cmd.Flags().GetString("enable-at")
+		clear, _ := cmd.Flags().GetBool("clear")
+		if clear && (disableStr != "" || enableStr != "") {
+			return fmt.Errorf("--clear cannot be combined with --disable-at or --enable-at")
+		}
+		if !clear && disableStr == "" && enableStr == "" {
+			return fmt.Errorf("specify --disable-at, --enable-at or --clear")
+		}
+		disableAt, err := parseScheduleTime(disableStr)
+		if err != nil {
+			return fmt.Errorf("invalid --disable-at: %w", err)
+		}
+		enableAt, err := parseScheduleTime(enableStr)
+		if err != nil {
+			return fmt.Errorf("invalid --enable-at: %w", err)
+		}
+		st := uiadapters.NewStoreAdapter()
+		if err := core.ScheduleAccount(st, id, disableAt, enableAt); err != nil {
+			return err
+		}
+		if clear {
+			fmt.Printf("Schedule cleared for account %d\n", id)
+			return nil
+		}
+		if !disableAt.IsZero() {
+			fmt.Printf("Account %d will be disabled at %s\n", id, disableAt.Format(time.RFC3339))
+		}
+		if !enableAt.IsZero() {
+			fmt.Printf("Account %d will be enabled at %s\n", id, enableAt.Format(time.RFC3339))
+		}
+		return nil
+	},
+}
+
+// accountRemediationCmd sets an account's drift remediation policy.
+var accountRemediationCmd = &cobra.Command{
+	Use:   "remediation <id> <auto|alert|ignore>",
+	Short: "Set how audits react to drift on an account",
+	Long: `Set the drift remediation policy consulted by 'audit --remediate':
+  auto    redeploy the expected authorized_keys when drift is detected
+  alert   report the drift and write an audit entry (default)
+  ignore  suppress drift, for hosts where local changes are expected`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid account ID: %w", err)
+		}
+		policy := strings.ToLower(strings.TrimSpace(args[1]))
+		st := uiadapters.NewStoreAdapter()
+		if err := core.SetRemediationPolicy(st, id, policy); err != nil {
+			return err
+		}
+		fmt.Printf("Remediation policy for account %d set to %s\n", id, policy)
+		return nil
+	},
+}
+
+// accountDeployModeCmd sets how deploys write an account's authorized_keys.
+var accountDeployModeCmd = &cobra.Command{
+	Use:   "deploy-mode <id> <replace|append-only>",
+	Short: "Set how deploys write an account's authorized_keys",
+	Long: `Set the deploy mode of an account:
+  replace      overwrite authorized_keys with the keys managed by Keymaster (default)
+  append-only  only add and remove keys Keymaster deployed itself; keys added
+               by other teams or tools are left in place and ignored by audits`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid account ID: %w", err)
+		}
+		mode := strings.ToLower(strings.TrimSpace(args[1]))
+		st := uiadapters.NewStoreAdapter()
+		if err := core.SetDeployMode(st, id, mode); err != nil {
+			return err
+		}
+		fmt.Printf("Deploy mode for account %d set to %s\n", id, mode)
+		return nil
+	},
+}
+
+// accountSetLabelCmd sets or clears the label of an account.
+var accountSetLabelCmd = &cobra.Command{
+	Use:     "set-label <id> <label>",
+	Aliases: []string{"rename-label"},
+	Short:   "Set the label of an account",
+	Long: `Set the label of an account; pass "" to clear it. Labels are used as Host
+aliases by 'export-ssh-client-config'. With require_unique_labels: true in
+the config, a label already used by another account is rejected.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid account ID: %w", err)
+		}
+		label := strings.TrimSpace(args[1])
+		st := uiadapters.NewStoreAdapter()
+		if err := core.SetAccountLabel(st, id, label); err != nil {
+			return err
+		}
+		fmt.Printf("Label for account %d set to %q\n", id, label)
+		return nil
+	},
+}
+
+// accountSetMetaCmd sets free-form metadata on an account.
+var accountSetMetaCmd = &cobra.Command{
+	Use:   "set-meta <id> <key=value>...",
+	Short: "Set metadata on an account",
+	Long: `Set free-form metadata such as an owner or ticket URL on an account.
+Metadata is shown by 'account show' and included in JSON output and backups,
+but unlike tags it is not used to select accounts. An empty value removes
+the key.
+
+Examples:
+  keymaster account set-meta 3 owner=alice@example.com ticket=https://tracker.example/OPS-12
+  keymaster account set-meta 3 ticket=`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid account ID: %w", err)
+		}
+		st := uiadapters.NewStoreAdapter()
+		metadata, err := core.SetAccountMetadata(st, id, args[1:])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Metadata for account %d updated\n", id)
+		printMetadata(cmd.OutOrStdout(), metadata)
+		return nil
+	},
+}
+
+// accountHistoryCmd prints the audit trail of an account.
+var accountHistoryCmd = &cobra.Command{
+	Use:   "history <id>",
+	Short: "Show the audit history of an account",
+	Long: `Print a timeline of the audit log entries that reference an account,
+oldest first. Entries remain after the account is deleted, so the history
+of a decommissioned account can still be inspected by its ID.
+
+Examples:
+  keymaster account history 3`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid account ID: %w", err)
+		}
+		st := uiadapters.NewStoreAdapter()
+		entries, err := st.GetAuditLogForAccount(id)
+		if err != nil {
+			return fmt.Errorf("failed to read audit log: %w", err)
+		}
+		out := cmd.OutOrStdout()
+		if len(entries) == 0 {
+			_, _ = fmt.Fprintf(out, "No audit history for account %d\n", id)
+			return nil
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "TIMESTAMP\tUSER\tACTION\tDETAILS")
+		for _, e := range entries {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.Timestamp, e.Username, e.Action, e.Summary())
+		}
+		return w.Flush()
+	},
+}
+
+// accountReliabilityCmd summarizes the recorded deploy and audit outcomes of
+// an account.
+var accountReliabilityCmd = &cobra.Command{
+	Use:   "reliability <id>",
+	Short: "Summarize the deploy and audit success rate of an account",
+	Long: `Summarize the deploy and audit results recorded for an account over a
+window (30 days by default): how many runs succeeded, how long they took
+and why the failed ones failed (unreachable, auth, host_key, drift,
+post_deploy or error). Results are kept after the account is deleted.
+
+Examples:
+  keymaster account reliability 3
+  keymaster account reliability 3 --window 168h`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid account ID: %w", err)
+		}
+		window, _ := cmd.Flags().GetDuration("window")
+		rep, err := core.AccountReliability(uiadapters.NewStoreAdapter(), id, window, time.Now())
+		if err != nil {
+			return err
+		}
+		return writeReliabilityReport(cmd.OutOrStdout(), rep, window)
+	},
+}
+
+// writeReliabilityReport prints one row per operation plus a total, followed
+// by the failure categories.
+func writeReliabilityReport(out io.Writer, rep core.ReliabilityReport, window time.Duration) error {
+	if rep.Overall.Total == 0 {
+		_, _ = fmt.Fprintf(out, "No deploy or audit results for account %d in the last %s\n", rep.AccountID, window)
+		return nil
+	}
+	_, _ = fmt.Fprintf(out, "Account %d, last %s (since %s)\n\n", rep.AccountID, window, rep.Since.Local().Format("2006-01-02 15:04"))
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "OPERATION\tRUNS\tOK\tFAILED\tSUCCESS\tAVG DURATION\tLAST FAILURE")
+	row := func(name string, s core.ReliabilityStats) {
+		last := "-"
+		if !s.LastFailure.IsZero() {
+			last = s.LastFailure.Local().Format("2006-01-02 15:04")
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%.1f%%\t%s\t%s\n", name, s.Total, s.Succeeded, s.Failed(), 100*s.SuccessRate(), s.AverageDuration.Round(time.Millisecond), last)
+	}
+	for _, op := range []string{model.OperationDeploy, model.OperationAudit} {
+		if s, ok := rep.ByOperation[op]; ok {
+			row(op, s)
+		}
+	}
+	row("total", rep.Overall)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if len(rep.Overall.Failures) == 0 {
+		return nil
+	}
+	categories := make([]string, 0, len(rep.Overall.Failures))
+	for c := range rep.Overall.Failures {
+		categories = append(categories, c)
+	}
+	sort.Strings(categories)
+	_, _ = fmt.Fprintln(out, "\nFailures by category:")
+	for _, c := range categories {
+		_, _ = fmt.Fprintf(out, "  %s: %d\n", c, rep.Overall.Failures[c])
+	}
+	return nil
+}
+
+// printMetadata lists metadata in key order; nothing is printed when empty.
+func printMetadata(w io.Writer, metadata map[string]string) {
+	if len(metadata) == 0 {
+		return
+	}
+	_, _ = fmt.Fprintln(w, "Metadata:")
+	for _, k := range model.MetadataKeys(metadata) {
+		_, _ = fmt.Fprintf(w, "  %s=%s\n", k, metadata[k])
+	}
+}
+
+// accountOSFamilyCmd sets the remote OS family of an account.
+var accountOSFamilyCmd = &cobra.Command{
+	Use:   "os-family <id> <posix|windows|windows-admin>",
+	Short: "Set the operating system family of an account's host",
+	Long: `Set the remote OS family, which decides where deploys and audits find
+authorized_keys and how permissions are handled:
+  posix          ~/.ssh/authorized_keys, mode 0600 (default)
+  windows        Windows OpenSSH, C:\Users\<user>\.ssh\authorized_keys; file
+                 modes are left alone, access is governed by ACLs
+  windows-admin  Windows OpenSSH account in the Administrators group,
+                 C:\ProgramData\ssh\administrators_authorized_keys
+
+Windows accounts run deploy.post_deploy_command_windows instead of
+deploy.post_deploy_command.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid account ID: %w", err)
+		}
+		family := strings.ToLower(strings.TrimSpace(args[1]))
+		st := uiadapters.NewStoreAdapter()
+		if err := core.SetOSFamily(st, id, family); err != nil {
+			return err
+		}
+		fmt.Printf("OS family for account %d set to %s\n", id, family)
+		return nil
+	},
+}
+
+// accountKnownHostsCmd turns known_hosts deployment on or off for an account.
+var accountKnownHostsCmd = &cobra.Command{
+	Use:   "known-hosts <id> <on|off>",
+	Short: "Also deploy a known_hosts file to an account",
+	Long: `With known-hosts on, every deploy to the account also writes
+~/.ssh/known_hosts, rendered from the host keys Keymaster trusts, so a jump box
+can SSH onward to the other managed hosts without prompting. The file is
+replaced as a whole on each deploy; entries added on the host are lost.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid account ID: %w", err)
+		}
+		var enabled bool
+		switch strings.ToLower(strings.TrimSpace(args[1])) {
+		case "on":
+			enabled = true
+		case "off":
+		default:
+			return fmt.Errorf("invalid setting %q (use on or off)", args[1])
+		}
+		st := uiadapters.NewStoreAdapter()
+		if err := core.SetDeployKnownHosts(st, id, enabled); err != nil {
+			return err
+		}
+		if enabled {
+			fmt.Printf("known_hosts deployment enabled for account %d; it takes effect on the next deploy\n", id)
+		} else {
+			fmt.Printf("known_hosts deployment disabled for account %d\n", id)
+		}
+		return nil
+	},
+}
+
+// accountGlobalKeysCmd lists or changes the global keys excluded from an
+// account.
+var accountGlobalKeysCmd = &cobra.Command{
+	Use:   "global-keys <id>",
+	Short: "Exclude global keys from an account",
+	Long: `Without flags, list the global keys and whether each is rendered for the
+account. --exclude keeps a global key off the account, e.g. a DMZ host, while
+every other account still receives it; --include renders it again. A key that
+is also assigned to the account directly is still rendered. Changes mark the
+account dirty and take effect on the next deploy.
+
+Example:
+  keymaster account global-keys 3 --exclude 12`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid account ID: %w", err)
+		}
+		exclude, _ := cmd.Flags().GetIntSlice("exclude")
+		include, _ := cmd.Flags().GetIntSlice("include")
+		km := core.KeyManagerFromContext(cmd.Context())
+		if km == nil {
+			return fmt.Errorf("no key manager available")
+		}
+		st := uiadapters.NewStoreAdapter()
+		if len(exclude) > 0 || len(include) > 0 {
+			excluded, err := core.ModifyGlobalKeyExclusions(st, km, id, exclude, include)
+			if err != nil {
+				return err
+			}
+			if len(excluded) == 0 {
+				fmt.Printf("Account %d receives every global key\n", id)
+				return nil
+			}
+			fmt.Printf("Global keys excluded from account %d: %s\n", id, formatKeyIDs(excluded))
+			return nil
+		}
+
+		accounts, err := st.GetAllAccounts()
+		if err != nil {
+			return fmt.Errorf("failed to load accounts: %w", err)
+		}
+		account, err := core.FindAccountByIdentifier(args[0], accounts)
+		if err != nil {
+			return err
+		}
+		globalKeys, err := km.GetGlobalPublicKeys()
+		if err != nil {
+			return fmt.Errorf("failed to load global keys: %w", err)
+		}
+		if len(globalKeys) == 0 {
+			fmt.Println("No global keys.")
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "ID\tCOMMENT\tSTATUS")
+		for _, k := range globalKeys {
+			status := "rendered"
+			if account.ExcludesGlobalKey(k.ID) {
+				status = "excluded"
+			}
+			_, _ = fmt.Fprintf(w, "%d\t%s\t%s\n", k.ID, k.Comment, status)
+		}
+		return w.Flush()
+	},
+}
+
+// accountPrincipalsCmd shows or replaces the principals deployed to an
+// account's authorized_principals.
+var accountPrincipalsCmd = &cobra.Command{
+	Use:   "principals <id> [principal...]",
+	Short: "Manage the authorized_principals file of an account",
+	Long: `Without principals, show the principals deployed to the account. With
+principals, replace them: every deploy then also writes
+~/.ssh/authorized_principals with one principal per line, and strict audits
+compare it like authorized_keys. --clear stops managing the file and leaves the
+host's copy in place. Changes mark the account dirty.
+
+sshd only reads the file when sshd_config sets
+"AuthorizedPrincipalsFile .ssh/authorized_principals" for certificate logins.
+
+Example:
+  keymaster account principals 3 deploy ops-oncall`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid account ID: %w", err)
+		}
+		clear, _ := cmd.Flags().GetBool("clear")
+		principals := args[1:]
+		if clear && len(principals) > 0 {
+			return fmt.Errorf("--clear cannot be combined with principals")
+		}
+		st := uiadapters.NewStoreAdapter()
+		if !clear && len(principals) == 0 {
+			accounts, err := st.GetAllAccounts()
+			if err != nil {
+				return fmt.Errorf("failed to load accounts: %w", err)
+			}
+			account, err := core.FindAccountByIdentifier(args[0], accounts)
+			if err != nil {
+				return err
+			}
+			if len(account.AuthorizedPrincipals) == 0 {
+				fmt.Printf("Account %d does not manage authorized_principals\n", id)
+				return nil
+			}
+			for _, p := range account.AuthorizedPrincipals {
+				fmt.Println(p)
+			}
+			return nil
+		}
+		saved, err := core.SetAuthorizedPrincipals(st, id, principals)
+		if err != nil {
+			return err
+		}
+		if len(saved) == 0 {
+			fmt.Printf("Account %d no longer manages authorized_principals\n", id)
+			return nil
+		}
+		fmt.Printf("Principals for account %d: %s; they are deployed on the next deploy\n", id, strings.Join(saved, ", "))
+		return nil
+	},
+}
+
+// formatKeyIDs renders key IDs as a comma-separated list.
+func formatKeyIDs(ids []int) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// accountMoveGroupCmd moves an account into an account group.
+var accountMoveGroupCmd = &cobra.Command{
+	Use:   "move-group <id> [group]",
+	Short: "Move an account into an account group",
+	Long: `Move an account into the named group, replacing its previous group. Omit
+the group to remove the account from its group. Groups select accounts for
+'keymaster deploy --account-group', 'keymaster audit --account-group' and
+'keymaster group deploy'.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid account ID: %w", err)
+		}
+		group := ""
+		if len(args) == 2 {
+			group = strings.TrimSpace(args[1])
+		}
+		st := uiadapters.NewStoreAdapter()
+		if err := core.MoveAccountToGroup(st, id, group); err != nil {
+			return err
+		}
+		if group == "" {
+			fmt.Printf("Account %d removed from its group\n", id)
+		} else {
+			fmt.Printf("Account %d moved to group %s\n", id, group)
+		}
+		return nil
+	},
+}
+
+// accountInspectRemoteCmd reports the live authorized_keys of an account's
+// host.
+var accountInspectRemoteCmd = &cobra.Command{
+	Use:   "inspect-remote <account-identifier>",
+	Short: "Show the live authorized_keys state of an account's host",
+	Long: `Connects to the account's host and reports, without changing anything,
+the authorized_keys file as it is: its permissions and those of its directory,
+its size and modification time, the Keymaster serial header, the content hash
+next to the hash of the content Keymaster would deploy now, whether it passes a
+strict audit, and the file content itself.
+
+Select the account by ID, user@host or label.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		st := uiadapters.NewStoreAdapter()
+		accounts, err := st.GetAllAccounts()
+		if err != nil {
+			return fmt.Errorf("failed to get accounts: %w", err)
+		}
+		account, err := core.FindAccountByIdentifier(args[0], accounts)
+		if err != nil {
+			return err
+		}
+		installHostKeyChangePrompt()
+		defer core.SetHostKeyChangePrompt(nil)
+
+		ins, err := core.InspectRemote(&cliDeployerManager{}, *account)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Account:       %s (ID %d)\n", ins.Account.String(), ins.Account.ID)
+		if ins.StatErr != nil {
+			fmt.Printf("Permissions:   unknown (%v)\n", ins.StatErr)
+		} else {
+			fmt.Printf("File:          %s %s %04o, %d bytes, modified %s\n", ins.File.Path, ins.File.Mode, ins.File.Mode.Perm(), ins.File.Size, ins.File.ModTime.Local().Format(time.RFC3339))
+			fmt.Printf("Directory:     %s %s %04o\n", ins.Dir.Path, ins.Dir.Mode, ins.Dir.Mode.Perm())
+		}
+		if ins.HasHeader {
+			fmt.Printf("Serial header: %d (recorded: %d)\n", ins.Serial, ins.Account.Serial)
+		} else {
+			fmt.Printf("Serial header: none (recorded: %d)\n", ins.Account.Serial)
+		}
+		fmt.Printf("Hash:          %s\n", ins.Hash)
+		fmt.Printf("Expected hash: %s\n", ins.ExpectedHash)
+		if ins.Matches {
+			fmt.Println("Matches:       yes")
+		} else {
+			fmt.Println("Matches:       no (see 'keymaster audit --show-drift')")
+		}
+		fmt.Println()
+		fmt.Print(string(ins.Content))
+		if len(ins.Content) > 0 && !strings.HasSuffix(string(ins.Content), "\n") {
+			fmt.Println()
+		}
+		return nil
+	},
+}
+
+// accountPingCmd checks TCP reachability of account hosts' SSH ports.
+var accountPingCmd = &cobra.Command{
+	Use:   "ping [account-identifier]",
+	Short: "Check that account hosts accept connections on their SSH port",
+	Long: `Opens a plain TCP connection to the SSH port of each selected host, in
+parallel and with a timeout, and reports it as reachable, unreachable or
+timeout. Nothing is authenticated or read, so it is a fast, non-intrusive
+check before a large deploy or audit.
+
+Select a single account by ID, user@host or label, all active accounts with
+--all, or the active accounts carrying a tag with --tag. The command exits
+with status 1 when any host is not reachable.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		all, _ := cmd.Flags().GetBool("all")
+		tag, _ := cmd.Flags().GetString("tag")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		selectors := 0
+		for _, set := range []bool{len(args) == 1, all, tag != ""} {
+			if set {
+				selectors++
+			}
+		}
+		if selectors != 1 {
+			return fmt.Errorf("specify exactly one of an account, --all or --tag")
+		}
+
+		st := uiadapters.NewStoreAdapter()
+		var targets []model.Account
+		if len(args) == 1 {
+			accounts, err := st.GetAllAccounts()
+			if err != nil {
+				return fmt.Errorf("failed to get accounts: %w", err)
+			}
+			acc, err := core.FindAccountByIdentifier(args[0], accounts)
+			if err != nil {
+				return err
+			}
+			targets = []model.Account{*acc}
+		} else {
+			accounts, err := st.GetAllActiveAccounts()
+			if err != nil {
+				return fmt.Errorf("failed to get accounts: %w", err)
+			}
+			targets = accounts
+			if tag != "" {
+				targets = core.BuildAccountsByTag(accounts)[tag]
+			}
+		}
+		if len(targets) == 0 {
+			fmt.Println("No accounts selected.")
+			return nil
+		}
+
+		results := core.PingAccounts(cmd.Context(), &cliDeployerManager{}, targets, core.PingOptions{Timeout: timeout, Concurrency: concurrency})
+		failed := printPingResults(cmd.OutOrStdout(), results)
+		if failed > 0 {
+			cmd.SilenceUsage = true
+			return &ExitError{Code: 1, Err: fmt.Errorf("%d of %d host(s) not reachable", failed, len(results))}
+		}
+		return nil
+	},
+}
+
+// printPingResults writes one row per result and returns how many hosts
+// were not reachable.
+func printPingResults(w io.Writer, results []core.PingResult) int {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "ID\tACCOUNT\tADDRESS\tSTATUS\tTIME")
+	failed := 0
+	for _, r := range results {
+		if r.Status != core.PingReachable {
+			failed++
+		}
+		_, _ = fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", r.Account.ID, r.Account.String(), r.Address, r.Status, r.Latency.Round(time.Millisecond))
+	}
+	_ = tw.Flush()
+	return failed
+}
+
+// parseScheduleTime parses an RFC 3339 timestamp or a local
+// "YYYY-MM-DD HH:MM" time. An empty string yields the zero time.
+func parseScheduleTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02 15:04", s, time.Local)
+}
+
 // accountDeleteCmd deletes an account.
 var accountDeleteCmd = &cobra.Command{
 	Use:   "delete <id>",
@@ -258,7 +1182,11 @@ var accountAssignKeyCmd = &cobra.Command{
 	Use:   "assign-key <account-id> <key-id>",
 	Short: "Assign a public key to an account",
 	Long: `Assign a public key (by ID) to an account. The key will be deployed
-to this account's authorized_keys file on next deploy.`,
+to this account's authorized_keys file on next deploy.
+
+--options sets authorized_keys options rendered for this assignment only,
+e.g. --options 'command="rsync --server --sender . /srv",no-pty' restricts
+the key on a backup host while other accounts keep it unrestricted.`,
 	Args: cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		accountID, err := strconv.Atoi(args[0])
@@ -269,15 +1197,23 @@ to this account's authorized_keys file on next deploy.`,
 		if err != nil {
 			return fmt.Errorf("invalid key ID: %w", err)
 		}
-		km := core.DefaultKeyManager()
+		options, _ := cmd.Flags().GetString("options")
+		if err := sshkey.ValidateKeyOptions(options); err != nil {
+			return err
+		}
+		km := core.KeyManagerFromContext(cmd.Context())
 		st := uiadapters.NewStoreAdapter()
 		if km == nil {
 			return fmt.Errorf("no key manager available")
 		}
-		err = core.AssignKeyToAccount(func(k, a int) error { return km.AssignKeyToAccount(k, a) }, st, keyID, accountID)
+		err = core.AssignKeyToAccount(func(k, a int) error { return km.AssignKeyToAccountWithOptions(k, a, options) }, st, keyID, accountID)
 		if err != nil {
 			return err
 		}
+		if options != "" {
+			fmt.Printf("Key %d assigned to account %d with options: %s\n", keyID, accountID, options)
+			return nil
+		}
 		fmt.Printf("Key %d assigned to account %d\n", keyID, accountID)
 		return nil
 	},
@@ -299,7 +1235,7 @@ The key will no longer be deployed to this account's authorized_keys.`,
 		if err != nil {
 			return fmt.Errorf("invalid key ID: %w", err)
 		}
-		km := core.DefaultKeyManager()
+		km := core.KeyManagerFromContext(cmd.Context())
 		if km == nil {
 			return fmt.Errorf("no key manager available")
 		}
@@ -318,12 +1254,31 @@ func registerAccountCommands() {
 	accountCmd.AddCommand(accountListCmd)
 	accountCmd.AddCommand(accountShowCmd)
 	accountCmd.AddCommand(accountCreateCmd)
+	accountCmd.AddCommand(accountImportCSVCmd)
 	accountCmd.AddCommand(accountUpdateCmd)
+	accountCmd.AddCommand(accountTagCmd)
+	accountCmd.AddCommand(accountSetTagsCmd)
 	accountCmd.AddCommand(accountEnableCmd)
 	accountCmd.AddCommand(accountDisableCmd)
+	accountCmd.AddCommand(accountDeployEnableCmd)
+	accountCmd.AddCommand(accountDeployDisableCmd)
+	accountCmd.AddCommand(accountScheduleCmd)
+	accountCmd.AddCommand(accountRemediationCmd)
+	accountCmd.AddCommand(accountDeployModeCmd)
+	accountCmd.AddCommand(accountSetLabelCmd)
+	accountCmd.AddCommand(accountOSFamilyCmd)
+	accountCmd.AddCommand(accountMoveGroupCmd)
+	accountCmd.AddCommand(accountKnownHostsCmd)
+	accountCmd.AddCommand(accountGlobalKeysCmd)
+	accountCmd.AddCommand(accountPrincipalsCmd)
+	accountCmd.AddCommand(accountInspectRemoteCmd)
+	accountCmd.AddCommand(accountSetMetaCmd)
+	accountCmd.AddCommand(accountHistoryCmd)
+	accountCmd.AddCommand(accountReliabilityCmd)
 	accountCmd.AddCommand(accountDeleteCmd)
 	accountCmd.AddCommand(accountAssignKeyCmd)
 	accountCmd.AddCommand(accountUnassignKeyCmd)
+	accountCmd.AddCommand(accountPingCmd)
 
 	// Setup flags for create (only if not already defined)
 	if accountCreateCmd.Flags().Lookup("username") == nil {
@@ -333,21 +1288,74 @@ func registerAccountCommands() {
 		accountCreateCmd.Flags().String("tags", "", "Optional tags (comma-separated)")
 	}
 
+	// Setup flags for import-csv (only if not already defined)
+	if accountImportCSVCmd.Flags().Lookup("strict") == nil {
+		accountImportCSVCmd.Flags().Bool("strict", false, "Abort without creating accounts if any row is invalid")
+	}
+
 	// Setup flags for update (only if not already defined)
 	if accountUpdateCmd.Flags().Lookup("hostname") == nil {
 		accountUpdateCmd.Flags().String("hostname", "", "Update hostname")
 		accountUpdateCmd.Flags().String("label", "", "Update label")
 		accountUpdateCmd.Flags().String("tags", "", "Update tags")
 	}
+	if accountUpdateCmd.Flags().Lookup("no-system-key") == nil {
+		accountUpdateCmd.Flags().Bool("no-system-key", false, "Stop deploying the Keymaster system key to this account (use =false to resume)")
+	}
+
+	// Setup flags for tag (only if not already defined)
+	if accountPrincipalsCmd.Flags().Lookup("clear") == nil {
+		accountPrincipalsCmd.Flags().Bool("clear", false, "Stop managing authorized_principals for the account")
+	}
+	if accountGlobalKeysCmd.Flags().Lookup("exclude") == nil {
+		accountGlobalKeysCmd.Flags().IntSlice("exclude", nil, "Global key IDs to keep off the account (repeatable or comma-separated)")
+		accountGlobalKeysCmd.Flags().IntSlice("include", nil, "Excluded global key IDs to render again (repeatable or comma-separated)")
+	}
+	if accountTagCmd.Flags().Lookup("add") == nil {
+		accountTagCmd.Flags().StringSlice("add", nil, "Tags to add (repeatable or comma-separated)")
+		accountTagCmd.Flags().StringSlice("remove", nil, "Tags to remove (repeatable or comma-separated)")
+	}
+
+	// Setup flags for set-tags (only if not already defined)
+	if accountSetTagsCmd.Flags().Lookup("from-csv") == nil {
+		accountSetTagsCmd.Flags().String("from-csv", "", "CSV file of identifier,tags rows (required)")
+		accountSetTagsCmd.Flags().Bool("strict", false, "Change nothing if any row is invalid or names an unknown account")
+	}
+
+	// Setup flags for assign-key (only if not already defined)
+	if accountAssignKeyCmd.Flags().Lookup("options") == nil {
+		accountAssignKeyCmd.Flags().String("options", "", "authorized_keys options for this assignment only (e.g. command=\"...\",no-pty)")
+	}
 
 	// Setup flags for delete (only if not already defined)
 	if accountDeleteCmd.Flags().Lookup("force") == nil {
 		accountDeleteCmd.Flags().BoolP("force", "f", false, "Skip confirmation prompt")
 	}
 
+	// Setup flags for schedule (only if not already defined)
+	if accountScheduleCmd.Flags().Lookup("disable-at") == nil {
+		accountScheduleCmd.Flags().String("disable-at", "", "Time at which the account is disabled")
+		accountScheduleCmd.Flags().String("enable-at", "", "Time at which the account is re-enabled")
+		accountScheduleCmd.Flags().Bool("clear", false, "Remove any pending schedule")
+	}
+
+	// Setup flags for ping (only if not already defined)
+	if accountPingCmd.Flags().Lookup("all") == nil {
+		accountPingCmd.Flags().Bool("all", false, "Ping every active account")
+		accountPingCmd.Flags().String("tag", "", "Ping the active accounts carrying this tag")
+		accountPingCmd.Flags().Duration("timeout", core.DefaultPingTimeout, "Timeout for each connection attempt")
+		accountPingCmd.Flags().Int("concurrency", core.DefaultPingConcurrency, "Number of hosts checked at the same time")
+	}
+
+	// Setup flags for reliability (only if not already defined)
+	if accountReliabilityCmd.Flags().Lookup("window") == nil {
+		accountReliabilityCmd.Flags().Duration("window", 30*24*time.Hour, "How far back to look, e.g. 168h")
+	}
+
 	// Setup flags for list (only if not already defined)
 	if accountListCmd.Flags().Lookup("status") == nil {
 		accountListCmd.Flags().String("status", "", "Filter by status (active or inactive)")
 		accountListCmd.Flags().String("search", "", "Search by username, hostname, or label")
+		accountListCmd.Flags().String("format", accountListFormatTable, "Output format: table, json or csv")
 	}
 }
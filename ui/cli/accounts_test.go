@@ -4,8 +4,25 @@
 package cli
 
 import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"github.com/toeirei/keymaster/core"
+	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/uiadapters"
 )
 
 // TestAccountCommands_BasicFlow tests the account commands in a realistic workflow.
@@ -64,6 +81,52 @@ func TestAccountCommands_BasicFlow(t *testing.T) {
 	}
 }
 
+func TestAccountSetMetaCmd(t *testing.T) {
+	setupTestDB(t)
+	executeCommand(t, nil, "account", "create", "-u", "meta", "--hostname", "meta.example.com")
+
+	output := executeCommand(t, nil, "account", "set-meta", "1", "owner=alice@example.com", "ticket=https://tracker.example/browse?id=OPS-1&x=y", "note=ünïcode \"quoted\"")
+	if !strings.Contains(output, "Metadata for account 1 updated") {
+		t.Fatalf("expected confirmation, got: %s", output)
+	}
+	output = executeCommand(t, nil, "account", "show", "1")
+	for _, want := range []string{"owner=alice@example.com", "ticket=https://tracker.example/browse?id=OPS-1&x=y", `note=ünïcode "quoted"`} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in show output, got: %s", want, output)
+		}
+	}
+
+	executeCommand(t, nil, "account", "set-meta", "1", "ticket=")
+	output = executeCommand(t, nil, "account", "list", "--format", "json")
+	if strings.Contains(output, "tracker.example") || !strings.Contains(output, `"owner": "alice@example.com"`) {
+		t.Fatalf("expected ticket removed and owner kept in json output, got: %s", output)
+	}
+}
+
+func TestAccountHistoryCmd(t *testing.T) {
+	setupTestDB(t)
+	executeCommand(t, nil, "account", "create", "-u", "hist", "--hostname", "one.example.com")
+	executeCommand(t, nil, "account", "create", "-u", "hist", "--hostname", "two.example.com")
+	executeCommand(t, nil, "account", "disable", "1")
+	executeCommand(t, nil, "account", "disable", "2")
+
+	output := executeCommand(t, nil, "account", "history", "1")
+	if !strings.Contains(output, "ADD_ACCOUNT") || !strings.Contains(output, "TOGGLE_ACCOUNT_STATUS") {
+		t.Fatalf("expected account 1 events in history, got: %s", output)
+	}
+	if strings.Contains(output, "two.example.com") {
+		t.Fatalf("expected no events of account 2 in history of account 1, got: %s", output)
+	}
+	if strings.Index(output, "ADD_ACCOUNT") > strings.Index(output, "TOGGLE_ACCOUNT_STATUS") {
+		t.Fatalf("expected oldest entry first, got: %s", output)
+	}
+
+	output = executeCommand(t, nil, "account", "history", "42")
+	if !strings.Contains(output, "No audit history for account 42") {
+		t.Fatalf("expected empty history message, got: %s", output)
+	}
+}
+
 // TestAccountEnableCmd_Idempotent tests that enable is idempotent.
 func TestAccountEnableCmd_Idempotent(t *testing.T) {
 	setupTestDB(t)
@@ -163,3 +226,645 @@ func TestAccountCreateCmd_MissingRequired(t *testing.T) {
 	// As long as we don't panic, the test passes
 	_ = output
 }
+
+func TestAccountScheduleCmd(t *testing.T) {
+	setupTestDB(t)
+	t.Cleanup(func() {
+		_ = accountScheduleCmd.Flags().Set("disable-at", "")
+		_ = accountScheduleCmd.Flags().Set("enable-at", "")
+		_ = accountScheduleCmd.Flags().Set("clear", "false")
+	})
+
+	_ = executeCommand(t, nil, "account", "create", "-u", "sched", "--hostname", "sched.example.com")
+
+	output := executeCommand(t, nil, "account", "schedule", "1", "--disable-at", "2030-01-02T03:00:00Z", "--enable-at", "2030-01-02T05:00:00Z")
+	if !strings.Contains(output, "will be disabled at") || !strings.Contains(output, "will be enabled at") {
+		t.Fatalf("unexpected schedule output: %s", output)
+	}
+	output = executeCommand(t, nil, "account", "show", "1")
+	if !strings.Contains(output, "Disable at:") || !strings.Contains(output, "Enable at:") {
+		t.Fatalf("expected schedule in show output, got: %s", output)
+	}
+
+	_ = accountScheduleCmd.Flags().Set("disable-at", "")
+	_ = accountScheduleCmd.Flags().Set("enable-at", "")
+	output = executeCommand(t, nil, "account", "schedule", "1", "--clear")
+	if !strings.Contains(output, "Schedule cleared") {
+		t.Fatalf("unexpected clear output: %s", output)
+	}
+	output = executeCommand(t, nil, "account", "show", "1")
+	if strings.Contains(output, "Disable at:") {
+		t.Fatalf("expected schedule to be cleared, got: %s", output)
+	}
+}
+
+func TestAccountRemediationCmd(t *testing.T) {
+	setupTestDB(t)
+
+	_ = executeCommand(t, nil, "account", "create", "-u", "heal", "--hostname", "heal.example.com")
+	output := executeCommand(t, nil, "account", "show", "1")
+	if !strings.Contains(output, "Remediation: alert") {
+		t.Fatalf("expected default policy in show output, got: %s", output)
+	}
+
+	output = executeCommand(t, nil, "account", "remediation", "1", "auto")
+	if !strings.Contains(output, "set to auto") {
+		t.Fatalf("unexpected remediation output: %s", output)
+	}
+	output = executeCommand(t, nil, "account", "show", "1")
+	if !strings.Contains(output, "Remediation: auto") {
+		t.Fatalf("expected auto policy in show output, got: %s", output)
+	}
+}
+
+func TestAccountDeployModeCmd(t *testing.T) {
+	setupTestDB(t)
+
+	_ = executeCommand(t, nil, "account", "create", "-u", "svc", "--hostname", "shared.example.com")
+	output := executeCommand(t, nil, "account", "show", "1")
+	if !strings.Contains(output, "Deploy mode: replace") {
+		t.Fatalf("expected default deploy mode in show output, got: %s", output)
+	}
+
+	output = executeCommand(t, nil, "account", "deploy-mode", "1", "append-only")
+	if !strings.Contains(output, "set to append-only") {
+		t.Fatalf("unexpected deploy-mode output: %s", output)
+	}
+	output = executeCommand(t, nil, "account", "show", "1")
+	if !strings.Contains(output, "Deploy mode: append-only") {
+		t.Fatalf("expected append-only mode in show output, got: %s", output)
+	}
+}
+
+func TestAccountSetLabelCmd(t *testing.T) {
+	setupTestDB(t)
+	viper.Set("require_unique_labels", true)
+	t.Cleanup(func() {
+		core.SetRequireUniqueLabels(false)
+		_ = accountCreateCmd.Flags().Set("label", "")
+	})
+
+	_ = executeCommand(t, nil, "account", "create", "-u", "app", "--hostname", "web-01", "--label", "web")
+	_ = executeCommand(t, nil, "account", "create", "-u", "app", "--hostname", "web-02", "--label", "")
+
+	root := NewRootCmd()
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+	root.SetArgs([]string{"account", "set-label", "2", "web"})
+	if err := root.Execute(); !errors.Is(err, core.ErrDuplicateLabel) {
+		t.Fatalf("expected duplicate label to be rejected, got %v", err)
+	}
+
+	output := executeCommand(t, nil, "account", "set-label", "1", "web")
+	if !strings.Contains(output, `set to "web"`) {
+		t.Fatalf("expected account to keep its own label, got: %s", output)
+	}
+	_ = executeCommand(t, nil, "account", "rename-label", "2", "web-2")
+	acc, err := uiadapters.NewStoreAdapter().GetAccount(2)
+	if err != nil || acc == nil || acc.Label != "web-2" {
+		t.Fatalf("expected label web-2, got %+v (%v)", acc, err)
+	}
+}
+
+func TestAccountDeployEnableDisableCmd(t *testing.T) {
+	setupTestDB(t)
+
+	_ = executeCommand(t, nil, "account", "create", "-u", "svc", "--hostname", "maint.example.com")
+	output := executeCommand(t, nil, "account", "show", "1")
+	if strings.Contains(output, "Deploys:") {
+		t.Fatalf("expected no deploy line for deployable accounts, got: %s", output)
+	}
+
+	output = executeCommand(t, nil, "account", "deploy-disable", "1")
+	if !strings.Contains(output, "Deploys disabled for account 1") {
+		t.Fatalf("unexpected deploy-disable output: %s", output)
+	}
+	output = executeCommand(t, nil, "account", "show", "1")
+	if !strings.Contains(output, "Deploys:   disabled") || !strings.Contains(output, "Status:    active") {
+		t.Fatalf("expected an active account with deploys disabled, got: %s", output)
+	}
+
+	output = executeCommand(t, nil, "account", "deploy-enable", "1")
+	if !strings.Contains(output, "Deploys enabled for account 1") {
+		t.Fatalf("unexpected deploy-enable output: %s", output)
+	}
+	output = executeCommand(t, nil, "account", "show", "1")
+	if strings.Contains(output, "Deploys:") {
+		t.Fatalf("expected deploys to be enabled again, got: %s", output)
+	}
+}
+
+func TestAccountOSFamilyCmd(t *testing.T) {
+	setupTestDB(t)
+
+	_ = executeCommand(t, nil, "account", "create", "-u", "alice", "--hostname", "win-01.example.com")
+	output := executeCommand(t, nil, "account", "show", "1")
+	if strings.Contains(output, "OS family") {
+		t.Fatalf("expected no OS family line for POSIX accounts, got: %s", output)
+	}
+
+	output = executeCommand(t, nil, "account", "os-family", "1", "windows")
+	if !strings.Contains(output, "set to windows") {
+		t.Fatalf("unexpected os-family output: %s", output)
+	}
+	output = executeCommand(t, nil, "account", "show", "1")
+	if !strings.Contains(output, `OS family: windows (C:\Users\alice\.ssh\authorized_keys)`) {
+		t.Fatalf("expected Windows path in show output, got: %s", output)
+	}
+}
+
+func TestAccountUpdateNoSystemKey(t *testing.T) {
+	setupTestDB(t)
+	t.Cleanup(func() {
+		f := accountUpdateCmd.Flags().Lookup("no-system-key")
+		_ = f.Value.Set("false")
+		f.Changed = false
+	})
+
+	_ = executeCommand(t, nil, "account", "create", "-u", "app", "--hostname", "shared.example.com")
+	output := executeCommand(t, nil, "account", "update", "1", "--no-system-key")
+	if !strings.Contains(output, "no longer be deployed") {
+		t.Fatalf("unexpected update output: %s", output)
+	}
+	output = executeCommand(t, nil, "account", "show", "1")
+	if !strings.Contains(output, "System key: not managed") {
+		t.Fatalf("expected unmanaged system key in show output, got: %s", output)
+	}
+
+	_ = executeCommand(t, nil, "account", "update", "1", "--no-system-key=false")
+	output = executeCommand(t, nil, "account", "show", "1")
+	if strings.Contains(output, "System key: not managed") {
+		t.Fatalf("expected system key to be managed again, got: %s", output)
+	}
+}
+
+func TestAccountImportCSVCmd(t *testing.T) {
+	setupTestDB(t)
+	t.Cleanup(func() { _ = accountImportCSVCmd.Flags().Set("strict", "false") })
+
+	_ = executeCommand(t, nil, "account", "create", "-u", "deploy", "--hostname", "web-01")
+	path := filepath.Join(t.TempDir(), "hosts.csv")
+	content := "username,hostname,label,tags\n" +
+		"deploy,web-01,,\n" +
+		"deploy,web-02,Web 2,env:prod\n" +
+		",web-03,,\n" +
+		"backup,db-01,,\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	output := executeCommand(t, nil, "account", "import-csv", path)
+	if !strings.Contains(output, "Created 2, skipped 1, errors 1") || !strings.Contains(output, "line 4: username is required") {
+		t.Fatalf("unexpected import output: %s", output)
+	}
+	accounts, err := uiadapters.NewStoreAdapter().GetAllAccounts()
+	if err != nil || len(accounts) != 3 {
+		t.Fatalf("expected 3 accounts, got %d (%v)", len(accounts), err)
+	}
+}
+
+func TestParseScheduleTime(t *testing.T) {
+	if ts, err := parseScheduleTime(""); err != nil || !ts.IsZero() {
+		t.Fatalf("empty: got %v %v", ts, err)
+	}
+	if _, err := parseScheduleTime("2030-01-02T03:04:05Z"); err != nil {
+		t.Fatalf("rfc3339: %v", err)
+	}
+	if _, err := parseScheduleTime("2030-01-02 03:04"); err != nil {
+		t.Fatalf("local: %v", err)
+	}
+	if _, err := parseScheduleTime("tomorrow"); err == nil {
+		t.Fatalf("expected error for invalid time")
+	}
+}
+
+func TestWriteAccountList_Formats(t *testing.T) {
+	accounts := []model.Account{
+		{ID: 1, Username: "deploy", Hostname: "web-01", Label: "web", Tags: "env:prod,team:ops", IsActive: true, Serial: 3, IsDirty: true,
+			Metadata: map[string]string{"owner": "ops@example.com"}},
+		{ID: 2, Username: "backup", Hostname: "db-01"},
+	}
+
+	t.Run("table", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := writeAccountList(&buf, accounts, accountListFormatTable); err != nil {
+			t.Fatalf("writeAccountList: %v", err)
+		}
+		lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+		if len(lines) != 3 {
+			t.Fatalf("expected header plus 2 rows, got %q", buf.String())
+		}
+		// Columns are aligned: every row starts its HOSTNAME column at the same offset.
+		col := strings.Index(lines[0], "HOSTNAME")
+		if strings.Index(lines[1], "web-01") != col || strings.Index(lines[2], "db-01") != col {
+			t.Fatalf("columns not aligned:\n%s", buf.String())
+		}
+		if !strings.Contains(lines[1], "yes") || !strings.Contains(lines[2], "inactive") {
+			t.Fatalf("unexpected rows:\n%s", buf.String())
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := writeAccountList(&buf, accounts, accountListFormatJSON); err != nil {
+			t.Fatalf("writeAccountList: %v", err)
+		}
+		var rows []accountListRow
+		if err := json.Unmarshal(buf.Bytes(), &rows); err != nil {
+			t.Fatalf("invalid json %q: %v", buf.String(), err)
+		}
+		want := accountListRow{ID: 1, Username: "deploy", Hostname: "web-01", Label: "web", Tags: "env:prod,team:ops", Active: true, Serial: 3, Dirty: true,
+			Metadata: map[string]string{"owner": "ops@example.com"}}
+		if len(rows) != 2 || !reflect.DeepEqual(rows[0], want) || rows[1].Metadata != nil {
+			t.Fatalf("unexpected rows: %+v", rows)
+		}
+	})
+
+	t.Run("csv quotes tags", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := writeAccountList(&buf, accounts, accountListFormatCSV); err != nil {
+			t.Fatalf("writeAccountList: %v", err)
+		}
+		if !strings.Contains(buf.String(), `"env:prod,team:ops"`) {
+			t.Fatalf("expected quoted tags, got %q", buf.String())
+		}
+		records, err := csv.NewReader(&buf).ReadAll()
+		if err != nil {
+			t.Fatalf("invalid csv: %v", err)
+		}
+		if len(records) != 3 || records[1][4] != "env:prod,team:ops" || records[1][7] != "true" {
+			t.Fatalf("unexpected records: %q", records)
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		cases := map[string]string{
+			accountListFormatTable: "No accounts found.\n",
+			accountListFormatJSON:  "[]\n",
+			accountListFormatCSV:   "id,username,hostname,label,tags,active,serial,dirty\n",
+		}
+		for format, want := range cases {
+			var buf bytes.Buffer
+			if err := writeAccountList(&buf, nil, format); err != nil {
+				t.Fatalf("%s: %v", format, err)
+			}
+			if buf.String() != want {
+				t.Fatalf("%s: got %q, want %q", format, buf.String(), want)
+			}
+		}
+	})
+}
+
+func TestAccountListCmd_Format(t *testing.T) {
+	setupTestDB(t)
+	resetListFlags := func() {
+		_ = accountListCmd.Flags().Set("status", "")
+		_ = accountListCmd.Flags().Set("search", "")
+		_ = accountListCmd.Flags().Set("format", accountListFormatTable)
+	}
+	resetListFlags()
+	t.Cleanup(resetListFlags)
+
+	_ = executeCommand(t, nil, "account", "create", "-u", "csvuser", "--hostname", "csv.example.com", "-l", "csv", "--tags", "env:prod,team:ops")
+
+	output := executeCommand(t, nil, "account", "list", "--format", "json")
+	if !strings.Contains(output, `"username": "csvuser"`) || !strings.Contains(output, `"dirty":`) {
+		t.Fatalf("expected json output, got: %s", output)
+	}
+
+	output = executeCommand(t, nil, "account", "list", "--format", "csv")
+	if !strings.Contains(output, `csvuser,csv.example.com,csv,"env:prod,team:ops",true,0,`) {
+		t.Fatalf("expected csv row, got: %s", output)
+	}
+
+	root := NewRootCmd()
+	root.SetArgs([]string{"account", "list", "--format", "xml"})
+	root.SetOut(io.Discard)
+	root.SetErr(io.Discard)
+	if err := root.Execute(); err == nil || !strings.Contains(err.Error(), "unknown format") {
+		t.Fatalf("expected unknown format error, got %v", err)
+	}
+}
+
+// resetAccountTagFlags clears the --add/--remove slices, which would
+// otherwise accumulate values across executions of the shared command.
+func resetAccountTagFlags(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{"add", "remove"} {
+		f := accountTagCmd.Flags().Lookup(name)
+		if f == nil {
+			continue
+		}
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			_ = sv.Replace(nil)
+		}
+		f.Changed = false
+	}
+}
+
+func TestAccountTagCmd(t *testing.T) {
+	setupTestDB(t)
+	resetAccountTagFlags(t)
+	t.Cleanup(func() { resetAccountTagFlags(t) })
+
+	executeCommand(t, nil, "account", "create", "-u", "taguser", "--hostname", "tag-host", "-l", "Tagged", "--tags", "env:dev,role:old")
+
+	output := executeCommand(t, nil, "account", "tag", "1", "--add", "env:prod", "--add", "env:dev", "--remove", "role:old")
+	if !strings.Contains(output, "Tags updated to: env:dev, env:prod") {
+		t.Fatalf("expected merged tags, got: %s", output)
+	}
+
+	resetAccountTagFlags(t)
+	output = executeCommand(t, nil, "account", "tag", "1", "--remove", "missing:tag")
+	if !strings.Contains(output, "Tags updated to: env:dev, env:prod") {
+		t.Fatalf("removing an absent tag should be a no-op, got: %s", output)
+	}
+
+	output = executeCommand(t, nil, "account", "show", "1")
+	if !strings.Contains(output, "env:dev, env:prod") {
+		t.Fatalf("expected stored tags in show output, got: %s", output)
+	}
+}
+
+func TestDryRunDBFlag(t *testing.T) {
+	setupTestDB(t)
+	resetAccountTagFlags(t)
+	t.Cleanup(func() { resetAccountTagFlags(t) })
+
+	executeCommand(t, nil, "account", "create", "-u", "dryuser", "--hostname", "dry-host", "--tags", "env:dev")
+
+	output := executeCommand(t, nil, "--dry-run-db", "account", "tag", "1", "--add", "env:prod")
+	if !strings.Contains(output, "database change was not applied") || !strings.Contains(output, `UpdateAccountTags(1, "env:dev, env:prod")`) {
+		t.Fatalf("expected a dry-run summary, got: %s", output)
+	}
+
+	resetAccountTagFlags(t)
+	output = executeCommand(t, nil, "account", "show", "1")
+	if strings.Contains(output, "env:prod") {
+		t.Fatalf("expected the dry run to leave tags unchanged, got: %s", output)
+	}
+}
+
+func TestDryRunDBFlag_RefusesHostChanges(t *testing.T) {
+	root := NewRootCmd()
+	resetFlags := func(c *cobra.Command) {
+		c.Flags().VisitAll(func(f *pflag.Flag) {
+			_ = f.Value.Set(f.DefValue)
+			f.Changed = false
+		})
+	}
+
+	cases := []struct {
+		args    []string
+		refused bool
+	}{
+		{[]string{"deploy"}, true},
+		{[]string{"rotate-key"}, true},
+		{[]string{"decommission", "web-01"}, true},
+		{[]string{"group", "deploy", "web"}, true},
+		{[]string{"transfer", "accept", "pkg.json"}, true},
+		{[]string{"audit", "--remediate"}, true},
+		{[]string{"audit", "--repair-known-hosts"}, true},
+		{[]string{"trust-host", "deploy@web-01", "--retrust"}, true},
+		{[]string{"audit"}, false},
+		{[]string{"trust-host", "deploy@web-01"}, false},
+		{[]string{"account", "tag", "1", "--add", "env:prod"}, false},
+	}
+	for _, tc := range cases {
+		cmd, rest, err := root.Find(tc.args)
+		if err != nil {
+			t.Fatalf("%v: %v", tc.args, err)
+		}
+		if err := cmd.ParseFlags(rest); err != nil {
+			t.Fatalf("%v: %v", tc.args, err)
+		}
+		err = checkDryRunDB(cmd)
+		resetFlags(cmd)
+		if tc.refused && err == nil {
+			t.Errorf("%v: expected --dry-run-db to be refused", tc.args)
+		}
+		if !tc.refused && err != nil {
+			t.Errorf("%v: unexpected refusal: %v", tc.args, err)
+		}
+	}
+
+	// The TUI opens its own database client and would bypass the dry run.
+	if err := checkDryRunDB(root); err == nil {
+		t.Error("expected --dry-run-db to be refused for the TUI")
+	}
+}
+
+func resetAccountPingFlags(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{"all", "tag"} {
+		if f := accountPingCmd.Flags().Lookup(name); f != nil {
+			_ = f.Value.Set(f.DefValue)
+			f.Changed = false
+		}
+	}
+}
+
+func TestAccountPingCmd(t *testing.T) {
+	setupTestDB(t)
+	resetAccountPingFlags(t)
+	t.Cleanup(func() { resetAccountPingFlags(t) })
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer func() { _ = ln.Close() }()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	closedAddr := closed.Addr().String()
+	_ = closed.Close()
+
+	st := uiadapters.NewStoreAdapter()
+	if _, err := st.AddAccount("app", ln.Addr().String(), "up", "env:prod"); err != nil {
+		t.Fatalf("AddAccount: %v", err)
+	}
+	if _, err := st.AddAccount("app", closedAddr, "down", "env:dev"); err != nil {
+		t.Fatalf("AddAccount: %v", err)
+	}
+
+	out := executeCommand(t, nil, "account", "ping", "--tag", "env:prod")
+	if !strings.Contains(out, ln.Addr().String()) || !strings.Contains(out, "reachable") || strings.Contains(out, closedAddr) {
+		t.Fatalf("expected only the listening host, reachable, got:\n%s", out)
+	}
+	resetAccountPingFlags(t)
+
+	root := NewRootCmd()
+	var buf bytes.Buffer
+	root.SetOut(&buf)
+	root.SetArgs([]string{"account", "ping", "--all", "--timeout", "2s"})
+	err = root.Execute()
+	if ExitCode(err) != 1 || !strings.Contains(err.Error(), "1 of 2 host(s) not reachable") {
+		t.Fatalf("expected exit code 1 for the closed port, got %v", err)
+	}
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.Contains(line, closedAddr) && !strings.Contains(line, "unreachable") {
+			t.Fatalf("expected the closed port to be unreachable, got %q", line)
+		}
+	}
+	resetAccountPingFlags(t)
+
+	root = NewRootCmd()
+	root.SetArgs([]string{"account", "ping"})
+	if err := root.Execute(); err == nil || !strings.Contains(err.Error(), "exactly one") {
+		t.Fatalf("expected a selection error, got %v", err)
+	}
+}
+
+func TestAccountAssignKeyOptions(t *testing.T) {
+	setupTestDB(t)
+	t.Cleanup(func() {
+		f := accountAssignKeyCmd.Flags().Lookup("options")
+		_ = f.Value.Set(f.DefValue)
+		f.Changed = false
+	})
+
+	_ = executeCommand(t, nil, "account", "create", "-u", "backup", "--hostname", "nas.example.com")
+	_ = executeCommand(t, nil, "key", "add", "-a", "ssh-ed25519", "-k", "AAAAC3NzaC1lZDI1NTE5AAAAIAssignOptions", "-c", "alice")
+
+	opts := `command="rsync --server . /srv",no-pty`
+	output := executeCommand(t, nil, "account", "assign-key", "1", "1", "--options", opts)
+	if !strings.Contains(output, "with options: "+opts) {
+		t.Fatalf("unexpected assign-key output: %s", output)
+	}
+	output = executeCommand(t, nil, "account", "show", "1")
+	if !strings.Contains(output, opts) {
+		t.Fatalf("expected assignment options in show output, got: %s", output)
+	}
+
+	root := NewRootCmd()
+	root.SetOut(io.Discard)
+	root.SetErr(io.Discard)
+	root.SetArgs([]string{"account", "assign-key", "1", "1", "--options", "no-pty junk"})
+	if err := root.Execute(); err == nil || !strings.Contains(err.Error(), "invalid key options") {
+		t.Fatalf("expected malformed options to be rejected, got %v", err)
+	}
+}
+
+func resetAccountGlobalKeysFlags(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{"exclude", "include"} {
+		if f := accountGlobalKeysCmd.Flags().Lookup(name); f != nil {
+			_ = f.Value.(pflag.SliceValue).Replace(nil)
+			f.Changed = false
+		}
+	}
+}
+
+func TestAccountGlobalKeysCmd(t *testing.T) {
+	setupTestDB(t)
+	resetAccountGlobalKeysFlags(t)
+	t.Cleanup(func() { resetAccountGlobalKeysFlags(t) })
+
+	executeCommand(t, nil, "account", "create", "-u", "dmz", "--hostname", "dmz-01")
+	if _, err := core.DefaultKeyManager().AddPublicKeyAndGetModel("ssh-ed25519", "AAAAC3NzaC1lZDI1NTE5AAAAIGlobalOps", "ops", true, time.Time{}); err != nil {
+		t.Fatalf("failed to add key: %v", err)
+	}
+
+	output := executeCommand(t, nil, "account", "global-keys", "1", "--exclude", "1")
+	if !strings.Contains(output, "Global keys excluded from account 1: 1") {
+		t.Fatalf("expected the exclusion to be reported, got: %s", output)
+	}
+	resetAccountGlobalKeysFlags(t)
+	output = executeCommand(t, nil, "account", "global-keys", "1")
+	if !strings.Contains(output, "ops") || !strings.Contains(output, "excluded") {
+		t.Fatalf("expected the key listed as excluded, got: %s", output)
+	}
+	output = executeCommand(t, nil, "account", "show", "1")
+	if !strings.Contains(output, "Excluded global keys: 1") {
+		t.Fatalf("expected exclusions in show output, got: %s", output)
+	}
+
+	output = executeCommand(t, nil, "account", "global-keys", "1", "--include", "1")
+	if !strings.Contains(output, "receives every global key") {
+		t.Fatalf("expected the exclusion to be lifted, got: %s", output)
+	}
+}
+
+func TestAccountPrincipalsCmd(t *testing.T) {
+	setupTestDB(t)
+	resetFlag := func() {
+		if f := accountPrincipalsCmd.Flags().Lookup("clear"); f != nil {
+			_ = f.Value.Set("false")
+			f.Changed = false
+		}
+	}
+	resetFlag()
+	t.Cleanup(resetFlag)
+
+	executeCommand(t, nil, "account", "create", "-u", "deploy", "--hostname", "web-01")
+
+	output := executeCommand(t, nil, "account", "principals", "1")
+	if !strings.Contains(output, "does not manage authorized_principals") {
+		t.Fatalf("expected no principals, got: %s", output)
+	}
+	output = executeCommand(t, nil, "account", "principals", "1", "deploy", "ops-oncall")
+	if !strings.Contains(output, "Principals for account 1: deploy, ops-oncall") {
+		t.Fatalf("expected the principals to be saved, got: %s", output)
+	}
+	output = executeCommand(t, nil, "account", "show", "1")
+	if !strings.Contains(output, "Authorized principals: deploy, ops-oncall") {
+		t.Fatalf("expected principals in show output, got: %s", output)
+	}
+
+	output = executeCommand(t, nil, "account", "principals", "1", "--clear")
+	if !strings.Contains(output, "no longer manages authorized_principals") {
+		t.Fatalf("expected the principals to be cleared, got: %s", output)
+	}
+}
+
+// inspectDeployerManager serves a fixed authorized_keys and stat for
+// account inspect-remote; other methods are not used.
+type inspectDeployerManager struct {
+	core.DeployerManager
+	content string
+}
+
+func (m *inspectDeployerManager) FetchAuthorizedKeys(account model.Account) ([]byte, error) {
+	return []byte(m.content), nil
+}
+
+func (m *inspectDeployerManager) StatAuthorizedKeys(account model.Account) (core.RemoteFileStat, core.RemoteFileStat, error) {
+	return core.RemoteFileStat{Path: ".ssh/authorized_keys", Mode: 0600, Size: int64(len(m.content)), ModTime: time.Now()},
+		core.RemoteFileStat{Path: ".ssh", Mode: 0700 | os.ModeDir}, nil
+}
+
+func TestAccountInspectRemoteCmd(t *testing.T) {
+	setupTestDB(t)
+	if _, err := uiadapters.NewStoreAdapter().CreateSystemKey("sys-pub-test", "sys-priv-test"); err != nil {
+		t.Fatalf("create system key: %v", err)
+	}
+	executeCommand(t, nil, "account", "create", "-u", "deploy", "--hostname", "web-01")
+
+	origDM := core.DefaultDeployerManager
+	t.Cleanup(func() { core.DefaultDeployerManager = origDM })
+	core.DefaultDeployerManager = &inspectDeployerManager{content: "# Keymaster Managed Keys (Serial: 4)\nssh-ed25519 AAAAhand added-by-hand\n"}
+
+	output := executeCommand(t, nil, "account", "inspect-remote", "deploy@web-01")
+	for _, want := range []string{
+		"File:          .ssh/authorized_keys -rw------- 0600",
+		"Directory:     .ssh drwx------ 0700",
+		"Serial header: 4 (recorded: 0)",
+		"Matches:       no",
+		"ssh-ed25519 AAAAhand added-by-hand",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in output, got: %s", want, output)
+		}
+	}
+}
@@ -4,6 +4,7 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 
 	log "github.com/charmbracelet/log"
@@ -75,6 +76,26 @@ func (c *cliDeployerManager) FetchAuthorizedKeys(account model.Account) ([]byte,
 	return core.DefaultDeployerManager.FetchAuthorizedKeys(account)
 }
 
+// FetchManagedFile reads a managed file such as authorized_principals from
+// the account's host.
+func (c *cliDeployerManager) FetchManagedFile(account model.Account, name string) ([]byte, error) {
+	f, ok := core.DefaultDeployerManager.(core.ManagedFileFetcher)
+	if !ok {
+		return nil, fmt.Errorf("no deployer manager available")
+	}
+	return f.FetchManagedFile(account, name)
+}
+
+// StatAuthorizedKeys stats the account's authorized_keys file and its
+// directory.
+func (c *cliDeployerManager) StatAuthorizedKeys(account model.Account) (file, dir core.RemoteFileStat, err error) {
+	f, ok := core.DefaultDeployerManager.(core.AuthorizedKeysStatFetcher)
+	if !ok {
+		return file, dir, fmt.Errorf("no deployer manager available")
+	}
+	return f.StatAuthorizedKeys(account)
+}
+
 func (c *cliDeployerManager) ImportRemoteKeys(account model.Account) ([]model.PublicKey, int, string, error) {
 	if core.DefaultDeployerManager == nil {
 		return nil, 0, "", fmt.Errorf("no deployer manager available")
@@ -92,8 +113,12 @@ func (c *cliDeployerManager) IsPassphraseRequired(err error) bool {
 // cliDBMaintainer adapts db.RunDBMaintenance to core.DBMaintainer.
 type cliDBMaintainer struct{}
 
-func (c *cliDBMaintainer) RunDBMaintenance(dbType, dsn string) error {
-	return core.DefaultDBMaintainer().RunDBMaintenance(dbType, dsn)
+func (c *cliDBMaintainer) RunDBMaintenance(ctx context.Context, dbType, dsn string) error {
+	return core.DefaultDBMaintainer().RunDBMaintenance(ctx, dbType, dsn)
+}
+
+func (c *cliDBMaintainer) AnalyzeDatabase(ctx context.Context, dbType, dsn string) ([]model.TableStats, error) {
+	return core.DefaultDBMaintainer().(core.DBAnalyzer).AnalyzeDatabase(ctx, dbType, dsn)
 }
 
 // cliStoreFactory creates a new store for migration targets via db.NewStoreFromDSN.
@@ -115,6 +140,7 @@ func (c *cliKeyGenerator) GenerateAndMarshalEd25519Key(comment, passphrase strin
 
 // ensure adapters satisfy core interfaces at compile time
 var _ core.DBMaintainer = (*cliDBMaintainer)(nil)
+var _ core.DBAnalyzer = (*cliDBMaintainer)(nil)
 var _ core.StoreFactory = (*cliStoreFactory)(nil)
 var _ core.KeyGenerator = (*cliKeyGenerator)(nil)
 
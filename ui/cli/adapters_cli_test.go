@@ -43,15 +43,23 @@ func (f *fakeKeyManager) AddPublicKey(algorithm, keyData, comment string, isGlob
 func (f *fakeKeyManager) AddPublicKeyAndGetModel(algorithm, keyData, comment string, isGlobal bool, expiresAt time.Time) (*model.PublicKey, error) {
 	return &model.PublicKey{Comment: comment}, nil
 }
-func (f *fakeKeyManager) DeletePublicKey(id int) error                         { return nil }
-func (f *fakeKeyManager) TogglePublicKeyGlobal(id int) error                   { return nil }
-func (f *fakeKeyManager) SetPublicKeyExpiry(id int, expiresAt time.Time) error { return nil }
-func (f *fakeKeyManager) GetAllPublicKeys() ([]model.PublicKey, error)         { return nil, nil }
+func (f *fakeKeyManager) DeletePublicKey(id int) error                                  { return nil }
+func (f *fakeKeyManager) TogglePublicKeyGlobal(id int) error                            { return nil }
+func (f *fakeKeyManager) SetPublicKeyExpiry(id int, expiresAt time.Time) error          { return nil }
+func (f *fakeKeyManager) SetPublicKeyMetadata(id int, metadata map[string]string) error { return nil }
+func (f *fakeKeyManager) SetPublicKeyCertAuthority(id int, isCA bool, principals []string) error {
+	return nil
+}
+func (f *fakeKeyManager) UpdatePublicKey(id int, algorithm, keyData string) error { return nil }
+func (f *fakeKeyManager) GetAllPublicKeys() ([]model.PublicKey, error)            { return nil, nil }
 func (f *fakeKeyManager) GetPublicKeyByComment(comment string) (*model.PublicKey, error) {
 	return &model.PublicKey{Comment: comment}, nil
 }
-func (f *fakeKeyManager) GetGlobalPublicKeys() ([]model.PublicKey, error)   { return nil, nil }
-func (f *fakeKeyManager) AssignKeyToAccount(keyID, accountID int) error     { return nil }
+func (f *fakeKeyManager) GetGlobalPublicKeys() ([]model.PublicKey, error) { return nil, nil }
+func (f *fakeKeyManager) AssignKeyToAccount(keyID, accountID int) error   { return nil }
+func (f *fakeKeyManager) AssignKeyToAccountWithOptions(keyID, accountID int, options string) error {
+	return nil
+}
 func (f *fakeKeyManager) UnassignKeyFromAccount(keyID, accountID int) error { return nil }
 func (f *fakeKeyManager) GetKeysForAccount(accountID int) ([]model.PublicKey, error) {
 	return nil, nil
@@ -2,3 +2,29 @@
 // Keymaster - SSH key management system
 // This source code is licensed under the MIT license found in the LICENSE file.
 package cli
+
+import "errors"
+
+// ExitError carries a specific process exit code through cobra's error
+// return so commands can signal results (e.g. audit drift) to scripts.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string { return e.Err.Error() }
+
+func (e *ExitError) Unwrap() error { return e.Err }
+
+// ExitCode returns the process exit code for an error returned by Execute:
+// 0 for nil, the embedded code for an ExitError and 1 otherwise.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var ee *ExitError
+	if errors.As(err, &ee) {
+		return ee.Code
+	}
+	return 1
+}
@@ -609,3 +609,30 @@ func findSubcommand(cmd *cobra.Command, name string) *cobra.Command {
 	}
 	return nil
 }
+
+// TestDeployAuditCmd_GroupFlags verifies that --group keeps selecting the
+// canary tag while account groups use --account-group.
+func TestDeployAuditCmd_GroupFlags(t *testing.T) {
+	cmd := NewRootCmd()
+	deployCmd := findSubcommand(cmd, "deploy")
+	auditCmd := findSubcommand(cmd, "audit")
+	if deployCmd == nil || auditCmd == nil {
+		t.Fatalf("deploy and audit commands should exist")
+	}
+
+	groupFlag := deployCmd.Flags().Lookup("group")
+	if groupFlag == nil || !strings.Contains(groupFlag.Usage, "tag") {
+		t.Fatalf("deploy --group should filter a --canary rollout by tag, got %+v", groupFlag)
+	}
+	if deployCmd.Flags().Lookup("tag") != nil {
+		t.Fatalf("deploy should not have a --tag flag")
+	}
+	for _, c := range []*cobra.Command{deployCmd, auditCmd} {
+		if c.Flags().Lookup("account-group") == nil {
+			t.Fatalf("%s command should have --account-group flag", c.Name())
+		}
+	}
+	if auditCmd.Flags().Lookup("group") != nil {
+		t.Fatalf("audit should not have a --group flag")
+	}
+}
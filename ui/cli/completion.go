@@ -0,0 +1,183 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/toeirei/keymaster/core"
+	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/uiadapters"
+)
+
+// completionCmd writes a shell completion script to stdout.
+var completionCmd = &cobra.Command{
+	Use:   "completion <bash|zsh|fish|powershell>",
+	Short: "Generate a shell completion script",
+	Long: `Generate a completion script for the given shell. Besides commands and
+flags, account IDs, user@host identifiers and key IDs are completed from the
+configured database.
+
+Examples:
+  # bash, current session
+  source <(keymaster completion bash)
+  # zsh, installed permanently
+  keymaster completion zsh > "${fpath[1]}/_keymaster"
+  # fish
+  keymaster completion fish > ~/.config/fish/completions/keymaster.fish
+  # PowerShell
+  keymaster completion powershell | Out-String | Invoke-Expression`,
+	ValidArgs: []string{"bash", "zsh", "fish", "powershell"},
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	// Generating a script needs neither config nor database.
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root := cmd.Root()
+		out := cmd.OutOrStdout()
+		switch args[0] {
+		case "bash":
+			return root.GenBashCompletionV2(out, true)
+		case "zsh":
+			return root.GenZshCompletion(out)
+		case "fish":
+			return root.GenFishCompletion(out, true)
+		case "powershell":
+			return root.GenPowerShellCompletionWithDesc(out)
+		}
+		return fmt.Errorf("unsupported shell: %s", args[0])
+	},
+}
+
+// completionStore is the data dynamic completions are drawn from.
+type completionStore interface {
+	GetAllAccounts() ([]model.Account, error)
+	GetAllPublicKeys() ([]model.PublicKey, error)
+}
+
+// newCompletionStore returns the store queried by dynamic completions.
+// Tests replace it with a fake.
+var newCompletionStore = func() completionStore { return cliCompletionStore{} }
+
+// cliCompletionStore reads accounts through the store adapter and keys
+// through the default key manager.
+type cliCompletionStore struct{}
+
+func (cliCompletionStore) GetAllAccounts() ([]model.Account, error) {
+	return uiadapters.NewStoreAdapter().GetAllAccounts()
+}
+
+func (cliCompletionStore) GetAllPublicKeys() ([]model.PublicKey, error) {
+	km := core.DefaultKeyManager()
+	if km == nil {
+		return nil, fmt.Errorf("no key manager available")
+	}
+	return km.GetAllPublicKeys()
+}
+
+// registerCompletions attaches dynamic argument completion to the commands
+// taking account or key arguments.
+func registerCompletions() {
+	for _, c := range []*cobra.Command{
+		accountShowCmd, accountUpdateCmd, accountTagCmd, accountEnableCmd, accountDisableCmd,
+		accountScheduleCmd, accountRemediationCmd, accountDeployModeCmd, accountOSFamilyCmd, accountMoveGroupCmd,
+		accountKnownHostsCmd, accountGlobalKeysCmd, accountPrincipalsCmd, accountSetMetaCmd, accountHistoryCmd, accountReliabilityCmd,
+		accountDeleteCmd,
+	} {
+		c.ValidArgsFunction = completeAccountIDs
+	}
+	accountAssignKeyCmd.ValidArgsFunction = completeAccountThenKeyIDs
+	accountUnassignKeyCmd.ValidArgsFunction = completeAccountThenKeyIDs
+	for _, c := range []*cobra.Command{deployCmd, decommissionCmd, auditCompareCmd, importRemoteCmd, accountPingCmd, accountInspectRemoteCmd, shellCmd} {
+		c.ValidArgsFunction = completeAccountIdentifiers
+	}
+	for _, c := range []*cobra.Command{
+		keyShowCmd, keyDeleteCmd, keySetExpiryCmd, keySetMetaCmd, keySetCACmd,
+		keyEnableGlobalCmd, keyDisableGlobalCmd, keyGlobalSetCmd, keyGlobalUnsetCmd,
+		keyAssignCmd, keyUnassignCmd, keyExportCmd,
+	} {
+		c.ValidArgsFunction = completeKeyIDs
+	}
+}
+
+// completeAccountIDs completes the first argument with account IDs,
+// described by the account's label and user@host.
+func completeAccountIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	accounts, err := newCompletionStore().GetAllAccounts()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	var out []string
+	for _, a := range accounts {
+		id := strconv.Itoa(a.ID)
+		if strings.HasPrefix(id, toComplete) {
+			out = append(out, id+"\t"+a.String())
+		}
+	}
+	return out, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeAccountIdentifiers completes the first argument with user@host
+// identifiers, described by label.
+func completeAccountIdentifiers(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	accounts, err := newCompletionStore().GetAllAccounts()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	var out []string
+	for _, a := range accounts {
+		ident := a.Username + "@" + a.Hostname
+		if !strings.HasPrefix(ident, toComplete) {
+			continue
+		}
+		if a.Label != "" {
+			ident += "\t" + a.Label
+		}
+		out = append(out, ident)
+	}
+	return out, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeKeyIDs completes the first argument with public key IDs,
+// described by the key comment.
+func completeKeyIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return keyIDCompletions(toComplete)
+}
+
+// completeAccountThenKeyIDs completes an account ID followed by a key ID.
+func completeAccountThenKeyIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return completeAccountIDs(cmd, args, toComplete)
+	case 1:
+		return keyIDCompletions(toComplete)
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+func keyIDCompletions(toComplete string) ([]string, cobra.ShellCompDirective) {
+	keys, err := newCompletionStore().GetAllPublicKeys()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	var out []string
+	for _, k := range keys {
+		id := strconv.Itoa(k.ID)
+		if strings.HasPrefix(id, toComplete) {
+			out = append(out, id+"\t"+k.Comment)
+		}
+	}
+	return out, cobra.ShellCompDirectiveNoFileComp
+}
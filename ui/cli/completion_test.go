@@ -0,0 +1,101 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package cli
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/toeirei/keymaster/core/model"
+)
+
+type fakeCompletionStore struct {
+	accounts []model.Account
+	keys     []model.PublicKey
+	err      error
+}
+
+func (f fakeCompletionStore) GetAllAccounts() ([]model.Account, error) { return f.accounts, f.err }
+func (f fakeCompletionStore) GetAllPublicKeys() ([]model.PublicKey, error) {
+	return f.keys, f.err
+}
+
+func withCompletionStore(t *testing.T, st completionStore) {
+	t.Helper()
+	orig := newCompletionStore
+	t.Cleanup(func() { newCompletionStore = orig })
+	newCompletionStore = func() completionStore { return st }
+}
+
+func TestCompletionCmd_GeneratesScripts(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish", "powershell"} {
+		output := executeCommand(t, nil, "completion", shell)
+		if len(strings.TrimSpace(output)) == 0 || !strings.Contains(output, "keymaster") {
+			t.Fatalf("expected a %s completion script, got: %q", shell, output)
+		}
+	}
+
+	root := NewRootCmd()
+	root.SetArgs([]string{"completion", "tcsh"})
+	root.SilenceErrors, root.SilenceUsage = true, true
+	if err := root.Execute(); err == nil {
+		t.Fatal("expected error for unsupported shell")
+	}
+}
+
+func TestCompletionFunctions_FakeStore(t *testing.T) {
+	withCompletionStore(t, fakeCompletionStore{
+		accounts: []model.Account{
+			{ID: 1, Username: "deploy", Hostname: "web-01"},
+			{ID: 12, Username: "deploy", Hostname: "db-01", Label: "Primary DB"},
+			{ID: 2, Username: "backup", Hostname: "web-01"},
+		},
+		keys: []model.PublicKey{{ID: 3, Comment: "alice@laptop"}, {ID: 31, Comment: "ci-runner"}},
+	})
+
+	got, directive := completeAccountIDs(accountShowCmd, nil, "1")
+	if want := []string{"1\tdeploy@web-01", "12\tPrimary DB (deploy@db-01)"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("account IDs: got %q, want %q", got, want)
+	}
+	if directive != cobra.ShellCompDirectiveNoFileComp {
+		t.Fatalf("expected no file completion, got %v", directive)
+	}
+	if got, _ := completeAccountIDs(accountShowCmd, []string{"1"}, ""); got != nil {
+		t.Fatalf("expected no candidates for a second argument, got %q", got)
+	}
+
+	got, _ = completeAccountIdentifiers(deployCmd, nil, "deploy@")
+	if want := []string{"deploy@web-01", "deploy@db-01\tPrimary DB"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("account identifiers: got %q, want %q", got, want)
+	}
+
+	got, _ = completeAccountThenKeyIDs(accountAssignKeyCmd, []string{"1"}, "3")
+	if want := []string{"3\talice@laptop", "31\tci-runner"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("key IDs: got %q, want %q", got, want)
+	}
+	if got, _ = completeKeyIDs(keyShowCmd, nil, "31"); len(got) != 1 || got[0] != "31\tci-runner" {
+		t.Fatalf("key IDs with prefix 31: got %q", got)
+	}
+
+	withCompletionStore(t, fakeCompletionStore{err: errors.New("db down")})
+	if got, directive := completeAccountIDs(accountShowCmd, nil, ""); got != nil || directive != cobra.ShellCompDirectiveError {
+		t.Fatalf("expected error directive when the store fails, got %q %v", got, directive)
+	}
+}
+
+func TestCompletion_RegisteredOnCommands(t *testing.T) {
+	root := NewRootCmd()
+	for _, path := range [][]string{{"account", "show"}, {"account", "assign-key"}, {"key", "delete"}, {"deploy"}, {"completion"}} {
+		c, _, err := root.Find(path)
+		if err != nil || c == nil {
+			t.Fatalf("command %v not found: %v", path, err)
+		}
+		if c.ValidArgsFunction == nil && len(c.ValidArgs) == 0 {
+			t.Fatalf("command %v has no argument completion", path)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/toeirei/keymaster/config"
+)
+
+// configCmd groups commands that help with the configuration file.
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Work with the Keymaster configuration file",
+	// Describing the configuration needs neither config nor database.
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+}
+
+// configSchemaCmd prints a JSON Schema for keymaster.yaml.
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print a JSON Schema describing keymaster.yaml",
+	Long: `Print a JSON Schema describing every setting in keymaster.yaml with its type,
+default and description. Editors can use it to validate and complete the
+configuration file.
+
+Example (YAML language server):
+  keymaster config schema > keymaster.schema.json
+  # then add to the top of keymaster.yaml:
+  # yaml-language-server: $schema=./keymaster.schema.json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := config.JSONSchema()
+		if err != nil {
+			return fmt.Errorf("generate schema: %w", err)
+		}
+		_, err = fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		return err
+	},
+}
+
+func registerConfigCommands() {
+	configCmd.AddCommand(configSchemaCmd)
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/toeirei/keymaster/core"
+	"github.com/toeirei/keymaster/core/model"
+)
+
+// runDeployPlanOut writes the plan for identifier, or for the whole fleet
+// when it is nil, to path. Only accounts the deploy would change are kept, so
+// the file lists exactly what --plan-in executes.
+func runDeployPlanOut(w io.Writer, st core.Store, identifier *string, path string) error {
+	var targets []model.Account
+	if identifier != nil {
+		accounts, err := st.GetAllActiveAccounts()
+		if err != nil {
+			return fmt.Errorf("get accounts: %w", err)
+		}
+		acc, err := core.FindAccountByIdentifier(*identifier, accounts)
+		if err != nil {
+			return err
+		}
+		if !acc.DeployEnabled {
+			return fmt.Errorf("deploys are disabled for account %s", acc.String())
+		}
+		targets = []model.Account{*acc}
+	}
+	full, err := core.BuildDeployPlan(st, targets)
+	if err != nil {
+		return err
+	}
+	plan := full.OnlyChanged()
+	if err := core.WriteDeployPlan(path, plan); err != nil {
+		return err
+	}
+	printDeployPlan(w, plan)
+	_, _ = fmt.Fprintf(w, "%d of %d account(s) would change; plan written to %s.\n", len(plan.Entries), len(full.Entries), path)
+	_, _ = fmt.Fprintf(w, "After review, run 'keymaster deploy --plan-in %s --confirm' to execute it.\n", path)
+	return nil
+}
+
+// runDeployPlanIn checks the plan at path against the live state and, with
+// confirm, deploys it. Without confirm nothing is deployed. Verification
+// failures wrap core.ErrDeployPlanStale.
+func runDeployPlanIn(ctx context.Context, w io.Writer, st core.Store, dm core.DeployerManager, path string, confirm bool, opts core.DeployRunOptions) ([]core.DeployResult, error) {
+	plan, err := core.ReadDeployPlan(path)
+	if err != nil {
+		return nil, err
+	}
+	printDeployPlan(w, plan)
+	live, err := core.VerifyDeployPlan(st, plan)
+	if err != nil {
+		return nil, err
+	}
+	if !confirm {
+		_, _ = fmt.Fprintln(w, "The plan still matches the live state; rerun with --confirm to deploy it.")
+		return nil, nil
+	}
+	return core.ExecutePlan(ctx, dm, live, opts)
+}
+
+// printDeployPlan lists the accounts of plan with what changes on each.
+func printDeployPlan(w io.Writer, plan *core.DeployPlan) {
+	_, _ = fmt.Fprintf(w, "Deploy plan (system key serial %d, created %s):\n", plan.SystemKeySerial, plan.CreatedAt.Format("2006-01-02 15:04:05 MST"))
+	if len(plan.Entries) == 0 {
+		_, _ = fmt.Fprintln(w, "  nothing to deploy")
+		return
+	}
+	for _, e := range plan.Entries {
+		var reasons []string
+		if e.SerialChanged {
+			reasons = append(reasons, "system key")
+		}
+		if e.KeysChanged {
+			reasons = append(reasons, "keys")
+		}
+		if e.Account.IsDirty {
+			reasons = append(reasons, "dirty")
+		}
+		hash := e.ContentHash
+		if len(hash) > 12 {
+			hash = hash[:12]
+		}
+		_, _ = fmt.Fprintf(w, "  %-40s %d key(s)  %s  %s\n", e.Account.String(), len(e.Keys), hash, strings.Join(reasons, ", "))
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/toeirei/keymaster/core"
+	"github.com/toeirei/keymaster/uiadapters"
+)
+
+// groupCmd is the root command for account group operations.
+var groupCmd = &cobra.Command{
+	Use:   "group",
+	Short: "List and deploy account groups",
+	Long: `The 'group' command group works with account groups. An account belongs
+to at most one group (see 'keymaster account move-group'); unlike tags, group
+names match exactly.`,
+}
+
+// groupListCmd lists the account groups in use.
+var groupListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List account groups and their member counts",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		accounts, err := uiadapters.NewStoreAdapter().GetAllAccounts()
+		if err != nil {
+			return fmt.Errorf("failed to list accounts: %w", err)
+		}
+		writeAccountGroupList(os.Stdout, core.ListAccountGroups(accounts))
+		return nil
+	},
+}
+
+// groupDeployCmd deploys the members of one account group.
+var groupDeployCmd = &cobra.Command{
+	Use:   "deploy <group>",
+	Short: "Deploy authorized_keys to the members of an account group",
+	Long: `Deploys to the active members of the group, exactly like
+'keymaster deploy --account-group <group>'.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		st := uiadapters.NewStoreAdapter()
+		results, err := core.RunDeployWithOptionsCmd(cmd.Context(), st, &cliDeployerManager{}, nil, core.DeployRunOptions{AccountGroup: args[0]}, nil)
+		printDeployResults(os.Stdout, results)
+		return err
+	},
+}
+
+// writeAccountGroupList renders account groups as a table.
+func writeAccountGroupList(out io.Writer, groups []core.AccountGroupInfo) {
+	if len(groups) == 0 {
+		_, _ = fmt.Fprintln(out, "No account groups found.")
+		return
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "GROUP\tMEMBERS\tACTIVE")
+	for _, g := range groups {
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%d\n", g.Name, g.Members, g.Active)
+	}
+	_ = w.Flush()
+}
+
+// registerGroupCommands registers all group subcommands.
+func registerGroupCommands() {
+	groupCmd.AddCommand(groupListCmd)
+	groupCmd.AddCommand(groupDeployCmd)
+}
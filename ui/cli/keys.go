@@ -4,6 +4,9 @@
 package cli
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -14,19 +17,23 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/toeirei/keymaster/core"
 	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/core/sshkey"
+	"github.com/toeirei/keymaster/uiadapters"
 )
 
 // keyCmd is the root command for public key management operations.
 var keyCmd = &cobra.Command{
-	Use:   "key",
-	Short: "Manage SSH public keys (list, add, delete, set-expiry)",
+	Use:     "key",
+	Aliases: []string{"keys"},
+	Short:   "Manage SSH public keys (list, add, delete, set-expiry)",
 	Long: `The 'key' command group provides full public key management capabilities:
   - List all public keys with status and metadata
   - View detailed key information
   - Add new public keys
   - Delete public keys
   - Set or clear key expiration dates
-  - Enable/disable global deployment status`,
+  - Export stored keys in authorized_keys format
+  - Enable/disable global deployment status ('key global list/set/unset')`,
 }
 
 // keyListCmd lists all public keys with optional filtering.
@@ -39,7 +46,7 @@ You can filter by global status or search by comment/algorithm.`,
 		globalFilter, _ := cmd.Flags().GetString("global")
 		searchTerm, _ := cmd.Flags().GetString("search")
 
-		km := core.DefaultKeyManager()
+		km := core.KeyManagerFromContext(cmd.Context())
 		if km == nil {
 			return fmt.Errorf("no key manager available")
 		}
@@ -112,7 +119,7 @@ var keyShowCmd = &cobra.Command{
 			return fmt.Errorf("invalid key ID: %w", err)
 		}
 
-		km := core.DefaultKeyManager()
+		km := core.KeyManagerFromContext(cmd.Context())
 		if km == nil {
 			return fmt.Errorf("no key manager available")
 		}
@@ -150,7 +157,11 @@ var keyShowCmd = &cobra.Command{
 		fmt.Printf("Comment:    %s\n", key.Comment)
 		fmt.Printf("Global:     %s\n", globalStatus)
 		fmt.Printf("Expires:    %s\n", expires)
+		if key.IsCA {
+			fmt.Printf("Cert CA:    %s\n", model.CertAuthorityOptions(key.IsCA, key.Principals))
+		}
 		fmt.Printf("Key Data:   %s... (truncated)\n", truncateString(key.KeyData, 50))
+		printMetadata(os.Stdout, key.Metadata)
 
 		// Get assigned accounts
 		accounts, accountErr := km.GetAccountsForKey(key.ID)
@@ -173,13 +184,19 @@ var keyShowCmd = &cobra.Command{
 var keyAddCmd = &cobra.Command{
 	Use:   "add",
 	Short: "Add a new public key",
-	Long:  `Add a new SSH public key with algorithm, key data, and comment.`,
+	Long: `Add a new SSH public key with algorithm, key data, and comment.
+
+With --cert-authority the key is an SSH certificate authority: it is rendered
+with the cert-authority option, so hosts accept user certificates signed by
+it. --principals restricts the accepted certificate principals.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		algorithm, _ := cmd.Flags().GetString("algorithm")
 		keyData, _ := cmd.Flags().GetString("key-data")
 		comment, _ := cmd.Flags().GetString("comment")
 		isGlobal, _ := cmd.Flags().GetBool("global")
 		expiresStr, _ := cmd.Flags().GetString("expires")
+		isCA, _ := cmd.Flags().GetBool("cert-authority")
+		principals, _ := cmd.Flags().GetStringSlice("principals")
 
 		if algorithm == "" {
 			return fmt.Errorf("--algorithm is required")
@@ -190,6 +207,12 @@ var keyAddCmd = &cobra.Command{
 		if comment == "" {
 			return fmt.Errorf("--comment is required")
 		}
+		if len(principals) > 0 && !isCA {
+			return fmt.Errorf("--principals requires --cert-authority")
+		}
+		if err := sshkey.ValidatePrincipals(principals); err != nil {
+			return err
+		}
 
 		var expiresAt time.Time
 		if expiresStr != "" {
@@ -200,7 +223,7 @@ var keyAddCmd = &cobra.Command{
 			expiresAt = parsed
 		}
 
-		km := core.DefaultKeyManager()
+		km := core.KeyManagerFromContext(cmd.Context())
 		if km == nil {
 			return fmt.Errorf("no key manager available")
 		}
@@ -209,6 +232,11 @@ var keyAddCmd = &cobra.Command{
 		if err != nil {
 			return fmt.Errorf("failed to add key: %w", err)
 		}
+		if isCA {
+			if err := core.SetKeyCertAuthority(km, addedKey.ID, true, principals); err != nil {
+				return fmt.Errorf("key %d added, but marking it as a certificate authority failed: %w", addedKey.ID, err)
+			}
+		}
 
 		fmt.Printf("Key added successfully with ID: %d\n", addedKey.ID)
 		return nil
@@ -229,7 +257,7 @@ var keyDeleteCmd = &cobra.Command{
 
 		force, _ := cmd.Flags().GetBool("force")
 
-		km := core.DefaultKeyManager()
+		km := core.KeyManagerFromContext(cmd.Context())
 		if km == nil {
 			return fmt.Errorf("no key manager available")
 		}
@@ -299,7 +327,7 @@ Keys past their expiration date will not be deployed.`,
 			expiresAt = parsed
 		}
 
-		km := core.DefaultKeyManager()
+		km := core.KeyManagerFromContext(cmd.Context())
 		if km == nil {
 			return fmt.Errorf("no key manager available")
 		}
@@ -317,6 +345,68 @@ Keys past their expiration date will not be deployed.`,
 	},
 }
 
+// keySetMetaCmd sets free-form metadata on a key.
+var keySetMetaCmd = &cobra.Command{
+	Use:   "set-meta <id> <key=value>...",
+	Short: "Set metadata on a key",
+	Long: `Set free-form metadata such as an owner or ticket URL on a public key.
+An empty value removes the key.`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid key ID: %w", err)
+		}
+		km := core.KeyManagerFromContext(cmd.Context())
+		if km == nil {
+			return fmt.Errorf("no key manager available")
+		}
+		metadata, err := core.SetKeyMetadata(km, id, args[1:])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Metadata for key %d updated\n", id)
+		printMetadata(cmd.OutOrStdout(), metadata)
+		return nil
+	},
+}
+
+// keySetCACmd marks a key as an SSH certificate authority.
+var keySetCACmd = &cobra.Command{
+	Use:   "set-ca <id>",
+	Short: "Mark a key as an SSH certificate authority",
+	Long: `Render the key with the cert-authority option, so hosts accept user
+certificates signed by it instead of the key itself. --principals restricts
+the certificate principals accepted (principals="..."); without it a
+certificate must name the account's user. Use --unset to turn the key back
+into a plain key.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid key ID: %w", err)
+		}
+		unset, _ := cmd.Flags().GetBool("unset")
+		principals, _ := cmd.Flags().GetStringSlice("principals")
+		km := core.KeyManagerFromContext(cmd.Context())
+		if km == nil {
+			return fmt.Errorf("no key manager available")
+		}
+		if err := core.SetKeyCertAuthority(km, id, !unset, principals); err != nil {
+			return err
+		}
+		switch {
+		case unset:
+			fmt.Printf("Key %d is no longer a certificate authority\n", id)
+		case len(principals) > 0:
+			fmt.Printf("Key %d is a certificate authority for principals: %s\n", id, strings.Join(principals, ", "))
+		default:
+			fmt.Printf("Key %d is a certificate authority\n", id)
+		}
+		return nil
+	},
+}
+
 // keyEnableGlobalCmd enables global deployment for a key.
 var keyEnableGlobalCmd = &cobra.Command{
 	Use:   "enable-global <id>",
@@ -324,97 +414,586 @@ var keyEnableGlobalCmd = &cobra.Command{
 	Long:  `Mark a key as global, so it will be deployed to all active accounts automatically.`,
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		id, err := strconv.Atoi(args[0])
+		return setKeyGlobal(cmd.Context(), args[0], true)
+	},
+}
+
+// keyDisableGlobalCmd disables global deployment for a key.
+var keyDisableGlobalCmd = &cobra.Command{
+	Use:   "disable-global <id>",
+	Short: "Disable global deployment for a key",
+	Long:  `Remove global status from a key, so it will only be deployed to explicitly assigned accounts.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setKeyGlobal(cmd.Context(), args[0], false)
+	},
+}
+
+// keyGlobalCmd groups the commands that manage global keys.
+var keyGlobalCmd = &cobra.Command{
+	Use:   "global",
+	Short: "List, set or unset global keys",
+	Long: `Global keys are deployed to every account. Changing a key's global status
+marks all accounts dirty so the next deploy picks up the change.`,
+}
+
+// keyGlobalListCmd lists the global keys.
+var keyGlobalListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List global keys",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		km := core.KeyManagerFromContext(cmd.Context())
+		if km == nil {
+			return fmt.Errorf("no key manager available")
+		}
+		keys, err := km.GetGlobalPublicKeys()
 		if err != nil {
-			return fmt.Errorf("invalid key ID: %w", err)
+			return fmt.Errorf("failed to list global keys: %w", err)
+		}
+		if len(keys) == 0 {
+			fmt.Println("No global keys.")
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "ID\tALGORITHM\tCOMMENT")
+		for _, key := range keys {
+			_, _ = fmt.Fprintf(w, "%d\t%s\t%s\n", key.ID, key.Algorithm, key.Comment)
 		}
+		return w.Flush()
+	},
+}
 
-		km := core.DefaultKeyManager()
+// keyGlobalSetCmd makes a key global.
+var keyGlobalSetCmd = &cobra.Command{
+	Use:   "set <id>",
+	Short: "Deploy a key to every account",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setKeyGlobal(cmd.Context(), args[0], true)
+	},
+}
+
+// keyGlobalUnsetCmd removes a key's global status.
+var keyGlobalUnsetCmd = &cobra.Command{
+	Use:   "unset <id>",
+	Short: "Stop deploying a key to every account",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setKeyGlobal(cmd.Context(), args[0], false)
+	},
+}
+
+// setKeyGlobal sets the global status of the key with the given ID and
+// reports the outcome. Asking for the current status is not an error.
+func setKeyGlobal(ctx context.Context, arg string, global bool) error {
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		return fmt.Errorf("invalid key ID: %w", err)
+	}
+	km := core.KeyManagerFromContext(ctx)
+	if km == nil {
+		return fmt.Errorf("no key manager available")
+	}
+	changed, err := core.SetKeyGlobal(uiadapters.NewStoreAdapter(), km, id, global)
+	if err != nil {
+		return err
+	}
+	switch {
+	case !changed && global:
+		fmt.Printf("Key %d is already global\n", id)
+	case !changed:
+		fmt.Printf("Key %d is already non-global\n", id)
+	case global:
+		fmt.Printf("Key %d enabled for global deployment; all accounts marked for redeploy\n", id)
+	default:
+		fmt.Printf("Key %d disabled from global deployment; all accounts marked for redeploy\n", id)
+	}
+	return nil
+}
+
+// keyPruneUnassignedCmd removes public keys that are neither global nor
+// assigned to any account.
+var keyPruneUnassignedCmd = &cobra.Command{
+	Use:   "prune-unassigned",
+	Short: "Remove keys that are not assigned to any account",
+	Long: `List public keys with no account assignments that are not global.
+By default (or with --dry-run) the keys are only listed. Use --apply to delete them;
+every deletion is recorded in the audit log.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		apply, _ := cmd.Flags().GetBool("apply")
+		if dryRun && apply {
+			return fmt.Errorf("--dry-run and --apply are mutually exclusive")
+		}
+
+		km := core.KeyManagerFromContext(cmd.Context())
 		if km == nil {
 			return fmt.Errorf("no key manager available")
 		}
 
-		// Check current status
-		keys, err := km.GetAllPublicKeys()
+		var rep core.Reporter
+		if apply {
+			rep = &cliReporter{}
+		}
+		pruned, err := core.PruneUnassignedKeys(cmd.Context(), uiadapters.NewStoreAdapter(), km, apply, rep)
 		if err != nil {
-			return fmt.Errorf("failed to load keys: %w", err)
+			return fmt.Errorf("failed to prune keys: %w", err)
 		}
 
-		var key *model.PublicKey
-		for i, k := range keys {
-			if k.ID == id {
-				key = &keys[i]
-				break
-			}
+		if len(pruned) == 0 {
+			fmt.Println("No unassigned keys found.")
+			return nil
 		}
 
-		if key == nil {
-			return fmt.Errorf("key not found: %d", id)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "ID\tALGORITHM\tCOMMENT")
+		for _, key := range pruned {
+			_, _ = fmt.Fprintf(w, "%d\t%s\t%s\n", key.ID, key.Algorithm, key.Comment)
 		}
+		_ = w.Flush()
 
-		// If already global, report but don't error
-		if key.IsGlobal {
-			fmt.Printf("Key %d is already global\n", id)
+		if apply {
+			fmt.Printf("Pruned %d unassigned key(s).\n", len(pruned))
+		} else {
+			fmt.Printf("%d unassigned key(s) would be pruned. Re-run with --apply to delete them.\n", len(pruned))
+		}
+		return nil
+	},
+}
+
+// keyAssignCmd assigns a key to a selection of accounts.
+var keyAssignCmd = &cobra.Command{
+	Use:   "assign <key-id>",
+	Short: "Assign a key to all accounts with a tag",
+	Long: `Assign a key to every account carrying --tag and/or listed with --account.
+Accounts that already have the key are left alone; the others are marked dirty
+for redeployment. A summary is shown for confirmation unless --force is given.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBulkKeyAssignment(cmd, args[0], false)
+	},
+}
+
+// keyUnassignCmd removes a key from a selection of accounts.
+var keyUnassignCmd = &cobra.Command{
+	Use:   "unassign <key-id>",
+	Short: "Unassign a key from all accounts with a tag",
+	Long: `Remove a key from every account carrying --tag and/or listed with --account.
+Accounts without the key are left alone; the others are marked dirty for
+redeployment. A summary is shown for confirmation unless --force is given.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBulkKeyAssignment(cmd, args[0], true)
+	},
+}
+
+// runBulkKeyAssignment selects accounts by the --tag and --account flags,
+// shows the plan and applies it after confirmation.
+func runBulkKeyAssignment(cmd *cobra.Command, arg string, unassign bool) error {
+	keyID, err := strconv.Atoi(arg)
+	if err != nil {
+		return fmt.Errorf("invalid key ID: %w", err)
+	}
+	tag, _ := cmd.Flags().GetString("tag")
+	ids, _ := cmd.Flags().GetIntSlice("account")
+	force, _ := cmd.Flags().GetBool("force")
+	if tag == "" && len(ids) == 0 {
+		return fmt.Errorf("select accounts with --tag or --account")
+	}
+
+	km := core.KeyManagerFromContext(cmd.Context())
+	if km == nil {
+		return fmt.Errorf("no key manager available")
+	}
+	st := uiadapters.NewStoreAdapter()
+	all, err := st.GetAllAccounts()
+	if err != nil {
+		return fmt.Errorf("failed to load accounts: %w", err)
+	}
+	selected, err := selectBulkAccounts(all, tag, ids)
+	if err != nil {
+		return err
+	}
+
+	plan, err := core.PlanBulkKeyAssignment(km, keyID, selected, unassign)
+	if err != nil {
+		return err
+	}
+	fmt.Println(plan.Summary())
+	if len(plan.Change) == 0 {
+		fmt.Println("Nothing to do.")
+		return nil
+	}
+	for _, acc := range plan.Change {
+		fmt.Printf("  %d\t%s\n", acc.ID, acc.String())
+	}
+
+	if !force {
+		fmt.Print("Proceed? (yes/no): ")
+		var response string
+		_, _ = fmt.Scanln(&response)
+		if strings.ToLower(response) != "yes" {
+			fmt.Println("Cancelled.")
 			return nil
 		}
+	}
+
+	res := core.ApplyBulkKeyAssignment(st, km, plan)
+	for _, f := range res.Failed {
+		fmt.Printf("Failed for %s: %v\n", f.Account.String(), f.Err)
+	}
+	fmt.Printf("Updated %d account(s); marked dirty for redeploy.\n", len(res.Changed))
+	if len(res.Failed) > 0 {
+		return fmt.Errorf("%d account(s) failed", len(res.Failed))
+	}
+	return nil
+}
 
-		// Toggle to enable global
-		if err := km.TogglePublicKeyGlobal(id); err != nil {
-			return fmt.Errorf("failed to enable global: %w", err)
+// selectBulkAccounts returns the accounts carrying tag plus those with the
+// given IDs, each once and in store order.
+func selectBulkAccounts(all []model.Account, tag string, ids []int) ([]model.Account, error) {
+	want := make(map[int]bool)
+	if tag != "" {
+		for _, acc := range core.BuildAccountsByTag(all)[tag] {
+			want[acc.ID] = true
 		}
+	}
+	for _, id := range ids {
+		found := false
+		for _, acc := range all {
+			if acc.ID == id {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("account not found: %d", id)
+		}
+		want[id] = true
+	}
+	var out []model.Account
+	for _, acc := range all {
+		if want[acc.ID] {
+			out = append(out, acc)
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no accounts match tag %q", tag)
+	}
+	return out, nil
+}
 
-		fmt.Printf("Key %d enabled for global deployment\n", id)
-		return nil
+// errAssignInteractiveNeedsTTY is returned by 'key assign-interactive' when
+// no terminal is attached.
+var errAssignInteractiveNeedsTTY = errors.New("key assign-interactive needs an interactive terminal; use 'keymaster key assign <key-id> --tag <tag> --account <id>' instead")
+
+// keyAssignInteractiveCmd assigns keys picked from a list to accounts picked
+// from a list.
+var keyAssignInteractiveCmd = &cobra.Command{
+	Use:   "assign-interactive",
+	Short: "Pick keys and accounts to assign them to",
+	Long: `Shows a fuzzy multi-select of the non-global keys, then of the accounts,
+and assigns every chosen key to every chosen account. Type to filter, tab to
+check an entry, ctrl+a to check all matches and enter to confirm.
+
+Accounts that already have a key are left alone; the others are marked dirty
+for redeployment. A summary is shown for confirmation unless --force is given.
+Needs a terminal; scripts use 'key assign' with --tag or --account.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		force, _ := cmd.Flags().GetBool("force")
+		return runKeyAssignInteractive(cmd.Context(), force)
 	},
 }
 
-// keyDisableGlobalCmd disables global deployment for a key.
-var keyDisableGlobalCmd = &cobra.Command{
-	Use:   "disable-global <id>",
-	Short: "Disable global deployment for a key",
-	Long:  `Remove global status from a key, so it will only be deployed to explicitly assigned accounts.`,
-	Args:  cobra.ExactArgs(1),
+// runKeyAssignInteractive lets the operator pick keys and accounts, shows the
+// resulting plans and applies them after confirmation.
+func runKeyAssignInteractive(ctx context.Context, force bool) error {
+	if !isInteractiveTerminal() {
+		return errAssignInteractiveNeedsTTY
+	}
+	km := core.KeyManagerFromContext(ctx)
+	if km == nil {
+		return fmt.Errorf("no key manager available")
+	}
+	allKeys, err := km.GetAllPublicKeys()
+	if err != nil {
+		return fmt.Errorf("failed to load keys: %w", err)
+	}
+	// Global keys are deployed to every account already.
+	var keys []model.PublicKey
+	for _, k := range allKeys {
+		if !k.IsGlobal {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("no assignable keys found (global keys are deployed to every account already)")
+	}
+	st := uiadapters.NewStoreAdapter()
+	accounts, err := st.GetAllAccounts()
+	if err != nil {
+		return fmt.Errorf("failed to load accounts: %w", err)
+	}
+	if len(accounts) == 0 {
+		return fmt.Errorf("no accounts found")
+	}
+
+	keyItems := make([]multiSelectItem, len(keys))
+	for i, k := range keys {
+		keyItems[i] = multiSelectItem{Label: fmt.Sprintf("%d  %s  %s", k.ID, k.Algorithm, k.Comment)}
+	}
+	keyIdx, err := runMultiSelect("Select keys to assign:", keyItems)
+	if err != nil {
+		return err
+	}
+	accountItems := make([]multiSelectItem, len(accounts))
+	for i, acc := range accounts {
+		accountItems[i] = multiSelectItem{
+			Label:  fmt.Sprintf("%d  %s", acc.ID, acc.String()),
+			Search: acc.Username + " " + acc.Hostname + " " + acc.Label + " " + acc.Tags,
+		}
+	}
+	accountIdx, err := runMultiSelect("Select accounts to assign them to:", accountItems)
+	if err != nil {
+		return err
+	}
+
+	keyIDs := make([]int, len(keyIdx))
+	for i, n := range keyIdx {
+		keyIDs[i] = keys[n].ID
+	}
+	selected := make([]model.Account, len(accountIdx))
+	for i, n := range accountIdx {
+		selected[i] = accounts[n]
+	}
+	return applyKeyAccountAssignments(st, km, keyIDs, selected, force)
+}
+
+// applyKeyAccountAssignments plans the assignment of every key to every
+// account, prints the plans and applies them after confirmation.
+func applyKeyAccountAssignments(st core.AccountDirtyMarker, km core.KeyManager, keyIDs []int, accounts []model.Account, force bool) error {
+	plans, err := core.PlanKeyAccountAssignments(km, keyIDs, accounts)
+	if err != nil {
+		return err
+	}
+	total := 0
+	for _, plan := range plans {
+		fmt.Println(plan.Summary())
+		for _, acc := range plan.Change {
+			fmt.Printf("  %d\t%s\n", acc.ID, acc.String())
+		}
+		total += len(plan.Change)
+	}
+	if total == 0 {
+		fmt.Println("Nothing to do.")
+		return nil
+	}
+
+	if !force {
+		fmt.Print("Proceed? (yes/no): ")
+		var response string
+		_, _ = fmt.Scanln(&response)
+		if strings.ToLower(response) != "yes" {
+			fmt.Println("Cancelled.")
+			return nil
+		}
+	}
+
+	changed, failed := 0, 0
+	for _, plan := range plans {
+		res := core.ApplyBulkKeyAssignment(st, km, plan)
+		for _, f := range res.Failed {
+			fmt.Printf("Failed to assign key %d to %s: %v\n", plan.Key.ID, f.Account.String(), f.Err)
+		}
+		changed += len(res.Changed)
+		failed += len(res.Failed)
+	}
+	fmt.Printf("Made %d assignment(s); accounts marked dirty for redeploy.\n", changed)
+	if failed > 0 {
+		return fmt.Errorf("%d assignment(s) failed", failed)
+	}
+	return nil
+}
+
+// keyCheckCompromisedCmd flags stored keys whose fingerprints appear in a
+// list of known-compromised fingerprints.
+var keyCheckCompromisedCmd = &cobra.Command{
+	Use:   "check-compromised --list <file>",
+	Short: "Check stored keys against a list of compromised fingerprints",
+	Long: `Fingerprint every stored public key and report those listed in --list,
+together with the accounts they are assigned to. The list holds one SHA256
+("SHA256:...") or MD5 ("aa:bb:...") fingerprint per line, optionally followed
+by a label; '#' starts a comment line. The comparison is purely local.
+
+Exits with status 1 when a compromised key is found.`,
+	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		id, err := strconv.Atoi(args[0])
+		path, _ := cmd.Flags().GetString("list")
+		f, err := os.Open(path)
 		if err != nil {
-			return fmt.Errorf("invalid key ID: %w", err)
+			return fmt.Errorf("failed to open fingerprint list: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+		list, err := core.ParseCompromisedFingerprints(f)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
 		}
 
-		km := core.DefaultKeyManager()
+		km := core.KeyManagerFromContext(cmd.Context())
 		if km == nil {
 			return fmt.Errorf("no key manager available")
 		}
-
-		// Check current status
-		keys, err := km.GetAllPublicKeys()
+		matches, err := core.FindCompromisedKeys(km, list)
 		if err != nil {
-			return fmt.Errorf("failed to load keys: %w", err)
+			return fmt.Errorf("failed to check keys: %w", err)
+		}
+		if len(matches) == 0 {
+			fmt.Printf("No compromised keys found (%d fingerprint(s) checked).\n", len(list))
+			return nil
 		}
 
-		var key *model.PublicKey
-		for i, k := range keys {
-			if k.ID == id {
-				key = &keys[i]
-				break
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "ID\tCOMMENT\tFINGERPRINT\tLABEL\tACCOUNTS")
+		for _, m := range matches {
+			accounts := make([]string, 0, len(m.Accounts))
+			for _, acc := range m.Accounts {
+				accounts = append(accounts, acc.String())
+			}
+			assigned := strings.Join(accounts, ", ")
+			if m.Key.IsGlobal {
+				assigned = "all (global)"
+			} else if assigned == "" {
+				assigned = "-"
 			}
+			_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", m.Key.ID, m.Key.Comment, m.Fingerprint, m.Label, assigned)
 		}
+		_ = w.Flush()
+		return &ExitError{Code: 1, Err: fmt.Errorf("%d compromised key(s) found", len(matches))}
+	},
+}
 
-		if key == nil {
-			return fmt.Errorf("key not found: %d", id)
+// keyLintCmd reports weak or policy-violating keys in the database.
+var keyLintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Report weak or policy-violating public keys",
+	Long: `Check every stored public key and report, in one list ordered by severity:
+  - error:   RSA keys below --min-rsa-bits, DSA keys and unparseable key data
+  - warning: keys without a comment and keys stored more than once
+  - info:    non-global keys not assigned to any account
+
+Use --format json for machine-readable output in CI. Exits with status 1
+when an error-severity finding is reported.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		format = strings.ToLower(strings.TrimSpace(format))
+		if format != keyLintFormatTable && format != keyLintFormatJSON {
+			return fmt.Errorf("unknown format %q (use table or json)", format)
 		}
+		minBits, _ := cmd.Flags().GetInt("min-rsa-bits")
 
-		// If already non-global, report but don't error
-		if !key.IsGlobal {
-			fmt.Printf("Key %d is already non-global\n", id)
-			return nil
+		km := core.KeyManagerFromContext(cmd.Context())
+		if km == nil {
+			return fmt.Errorf("no key manager available")
+		}
+		findings, err := core.LintKeys(km, uiadapters.NewStoreAdapter(), core.KeyLintOptions{MinRSABits: minBits})
+		if err != nil {
+			return fmt.Errorf("failed to lint keys: %w", err)
 		}
 
-		// Toggle to disable global
-		if err := km.TogglePublicKeyGlobal(id); err != nil {
-			return fmt.Errorf("failed to disable global: %w", err)
+		out := cmd.OutOrStdout()
+		errorCount := 0
+		for _, f := range findings {
+			if f.Severity == core.LintSeverityError {
+				errorCount++
+			}
+		}
+		if format == keyLintFormatJSON {
+			if findings == nil {
+				findings = []core.KeyLintFinding{}
+			}
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(findings); err != nil {
+				return err
+			}
+		} else if len(findings) == 0 {
+			_, _ = fmt.Fprintln(out, "No key issues found.")
+		} else {
+			w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+			_, _ = fmt.Fprintln(w, "SEVERITY\tRULE\tID\tCOMMENT\tMESSAGE")
+			for _, f := range findings {
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", f.Severity, f.Rule, f.KeyID, f.Comment, f.Message)
+			}
+			_ = w.Flush()
+		}
+		if errorCount > 0 {
+			cmd.SilenceUsage = true
+			return &ExitError{Code: 1, Err: fmt.Errorf("%d key(s) failed lint", errorCount)}
 		}
+		return nil
+	},
+}
+
+// Output formats accepted by 'key lint --format'.
+const (
+	keyLintFormatTable = "table"
+	keyLintFormatJSON  = "json"
+)
+
+// keyExportCmd prints stored public keys in authorized_keys format.
+var keyExportCmd = &cobra.Command{
+	Use:   "export [<comment|id>]",
+	Short: "Print a stored public key, or the whole key catalog with --all",
+	Long: `Print the 'algorithm keydata comment' line of one stored key, looked up by
+comment or ID. With --all every stored key is written as an authorized_keys
+file (CA keys keep their cert-authority options); account assignments are not
+included. The output can be fed back to 'keymaster import'.
 
-		fmt.Printf("Key %d disabled from global deployment\n", id)
+Examples:
+  keymaster key export alice@laptop
+  keymaster key export --all -o catalog.pub`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if all, _ := cmd.Flags().GetBool("all"); all {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		all, _ := cmd.Flags().GetBool("all")
+		outFile, _ := cmd.Flags().GetString("out")
+
+		km := core.KeyManagerFromContext(cmd.Context())
+		if km == nil {
+			return fmt.Errorf("no key manager available")
+		}
+		keys, err := km.GetAllPublicKeys()
+		if err != nil {
+			return fmt.Errorf("failed to load keys: %w", err)
+		}
+		if !all {
+			key, err := core.FindPublicKeyByIdentifier(args[0], keys)
+			if err != nil {
+				return err
+			}
+			keys = []model.PublicKey{{Algorithm: key.Algorithm, KeyData: key.KeyData, Comment: key.Comment}}
+		}
+
+		out := cmd.OutOrStdout()
+		if outFile != "" {
+			f, err := os.OpenFile(outFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+			if err != nil {
+				return fmt.Errorf("failed to create %s: %w", outFile, err)
+			}
+			defer func() { _ = f.Close() }()
+			out = f
+		}
+		if err := core.WriteKeyCatalog(out, keys); err != nil {
+			return fmt.Errorf("failed to write keys: %w", err)
+		}
 		return nil
 	},
 }
@@ -427,8 +1006,21 @@ func registerKeyCommands() {
 	keyCmd.AddCommand(keyAddCmd)
 	keyCmd.AddCommand(keyDeleteCmd)
 	keyCmd.AddCommand(keySetExpiryCmd)
+	keyCmd.AddCommand(keySetMetaCmd)
+	keyCmd.AddCommand(keySetCACmd)
 	keyCmd.AddCommand(keyEnableGlobalCmd)
 	keyCmd.AddCommand(keyDisableGlobalCmd)
+	keyCmd.AddCommand(keyGlobalCmd)
+	keyGlobalCmd.AddCommand(keyGlobalListCmd)
+	keyGlobalCmd.AddCommand(keyGlobalSetCmd)
+	keyGlobalCmd.AddCommand(keyGlobalUnsetCmd)
+	keyCmd.AddCommand(keyPruneUnassignedCmd)
+	keyCmd.AddCommand(keyAssignCmd)
+	keyCmd.AddCommand(keyUnassignCmd)
+	keyCmd.AddCommand(keyAssignInteractiveCmd)
+	keyCmd.AddCommand(keyCheckCompromisedCmd)
+	keyCmd.AddCommand(keyLintCmd)
+	keyCmd.AddCommand(keyExportCmd)
 
 	// Setup flags for add (only if not already defined)
 	if keyAddCmd.Flags().Lookup("algorithm") == nil {
@@ -437,16 +1029,62 @@ func registerKeyCommands() {
 		keyAddCmd.Flags().StringP("comment", "c", "", "Key comment/identifier (required)")
 		keyAddCmd.Flags().BoolP("global", "g", false, "Deploy to all accounts")
 		keyAddCmd.Flags().String("expires", "", "Expiration date (YYYY-MM-DD)")
+		keyAddCmd.Flags().Bool("cert-authority", false, "Add the key as an SSH certificate authority (cert-authority option)")
+		keyAddCmd.Flags().StringSlice("principals", nil, "Certificate principals accepted from a CA key (comma-separated)")
 		_ = keyAddCmd.MarkFlagRequired("algorithm")
 		_ = keyAddCmd.MarkFlagRequired("key-data")
 		_ = keyAddCmd.MarkFlagRequired("comment")
 	}
 
+	// Setup flags for set-ca (only if not already defined)
+	if keySetCACmd.Flags().Lookup("principals") == nil {
+		keySetCACmd.Flags().StringSlice("principals", nil, "Certificate principals accepted from the CA (comma-separated)")
+		keySetCACmd.Flags().Bool("unset", false, "Turn the key back into a plain key")
+	}
+
 	// Setup flags for delete (only if not already defined)
 	if keyDeleteCmd.Flags().Lookup("force") == nil {
 		keyDeleteCmd.Flags().BoolP("force", "f", false, "Skip confirmation prompt")
 	}
 
+	// Setup flags for prune-unassigned (only if not already defined)
+	if keyPruneUnassignedCmd.Flags().Lookup("apply") == nil {
+		keyPruneUnassignedCmd.Flags().Bool("dry-run", false, "Only list unassigned keys (default behavior)")
+		keyPruneUnassignedCmd.Flags().Bool("apply", false, "Delete the unassigned keys")
+	}
+
+	// Setup flags for assign/unassign (only if not already defined)
+	for _, c := range []*cobra.Command{keyAssignCmd, keyUnassignCmd} {
+		if c.Flags().Lookup("tag") == nil {
+			c.Flags().String("tag", "", "Select accounts carrying this tag")
+			c.Flags().IntSlice("account", nil, "Select accounts by ID (repeatable)")
+			c.Flags().BoolP("force", "f", false, "Skip confirmation prompt")
+		}
+	}
+
+	// Setup flags for assign-interactive (only if not already defined)
+	if keyAssignInteractiveCmd.Flags().Lookup("force") == nil {
+		keyAssignInteractiveCmd.Flags().BoolP("force", "f", false, "Skip confirmation prompt")
+	}
+
+	// Setup flags for check-compromised (only if not already defined)
+	if keyCheckCompromisedCmd.Flags().Lookup("list") == nil {
+		keyCheckCompromisedCmd.Flags().String("list", "", "File with known-compromised fingerprints (required)")
+		_ = keyCheckCompromisedCmd.MarkFlagRequired("list")
+	}
+
+	// Setup flags for lint (only if not already defined)
+	if keyLintCmd.Flags().Lookup("format") == nil {
+		keyLintCmd.Flags().Int("min-rsa-bits", core.DefaultMinRSABits, "Minimum accepted RSA key size in bits")
+		keyLintCmd.Flags().String("format", keyLintFormatTable, "Output format: table or json")
+	}
+
+	// Setup flags for export (only if not already defined)
+	if keyExportCmd.Flags().Lookup("all") == nil {
+		keyExportCmd.Flags().Bool("all", false, "Export every stored key as an authorized_keys file")
+		keyExportCmd.Flags().StringP("out", "o", "", "Write to this file instead of stdout")
+	}
+
 	// Setup flags for list (only if not already defined)
 	if keyListCmd.Flags().Lookup("global") == nil {
 		keyListCmd.Flags().String("global", "", "Filter by global status (yes or no)")
@@ -4,12 +4,26 @@
 package cli
 
 import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/spf13/cobra"
 	"github.com/toeirei/keymaster/core"
+	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/testutil"
+	"github.com/toeirei/keymaster/uiadapters"
+	"golang.org/x/crypto/ssh"
 )
 
 // TestKeyCommands_BasicFlow tests the key management workflow: add → list → show → set-expiry → enable-global → delete.
@@ -141,6 +155,76 @@ func TestKeyDisableGlobalCmd_Idempotent(t *testing.T) {
 	}
 }
 
+// TestSetKeyGlobal_UsesContextKeyManager verifies that a KeyManager carried
+// by the command context is used instead of the package-level default.
+func TestSetKeyGlobal_UsesContextKeyManager(t *testing.T) {
+	setupTestDB(t)
+
+	fake := &testutil.FakeKeyManager{Results: []model.PublicKey{{ID: 7, Comment: "ctx-key"}}}
+	ctx := core.ContextWithKeyManager(context.Background(), fake)
+	if err := setKeyGlobal(ctx, "7", true); err != nil {
+		t.Fatalf("setKeyGlobal: %v", err)
+	}
+	if len(fake.Calls) != 1 || fake.Calls[0] != [3]string{"TogglePublicKeyGlobal", "7", ""} {
+		t.Fatalf("expected the context key manager to be toggled, got calls %v", fake.Calls)
+	}
+	// The database behind the package-level default has no such key.
+	if err := setKeyGlobal(context.Background(), "7", true); err == nil {
+		t.Fatal("expected key not found without the context override")
+	}
+}
+
+// TestKeyGlobalCmds sets and unsets a global key through 'keys global' and
+// checks that every account is marked for redeployment.
+func TestKeyGlobalCmds(t *testing.T) {
+	setupTestDB(t)
+
+	st := uiadapters.NewStoreAdapter()
+	accountID, err := st.AddAccount("deploy", "web-01", "", "")
+	if err != nil {
+		t.Fatalf("AddAccount: %v", err)
+	}
+	if err := st.UpdateAccountIsDirty(accountID, false); err != nil {
+		t.Fatalf("UpdateAccountIsDirty: %v", err)
+	}
+	km := core.DefaultKeyManager()
+	key, err := km.AddPublicKeyAndGetModel("ssh-ed25519", "AAAAC3NzaC1lZDI1NTE5AAAAIGlobalCmdTest", "ops@example.com", false, time.Time{})
+	if err != nil {
+		t.Fatalf("failed to add key: %v", err)
+	}
+	keyID := fmt.Sprintf("%d", key.ID)
+
+	out := executeCommand(t, nil, "keys", "global", "list")
+	if !strings.Contains(out, "No global keys.") {
+		t.Fatalf("expected no global keys, got: %s", out)
+	}
+
+	out = executeCommand(t, nil, "keys", "global", "set", keyID)
+	if !strings.Contains(out, "enabled for global deployment") {
+		t.Fatalf("unexpected set output: %s", out)
+	}
+	globals, err := km.GetGlobalPublicKeys()
+	if err != nil || len(globals) != 1 || globals[0].ID != key.ID {
+		t.Fatalf("expected key in GetGlobalPublicKeys, got %+v (%v)", globals, err)
+	}
+	accounts, _ := st.GetAllAccounts()
+	if len(accounts) != 1 || !accounts[0].IsDirty {
+		t.Fatalf("expected account to be marked dirty, got %+v", accounts)
+	}
+	out = executeCommand(t, nil, "keys", "global", "list")
+	if !strings.Contains(out, "ops@example.com") {
+		t.Fatalf("expected key in global list, got: %s", out)
+	}
+
+	out = executeCommand(t, nil, "keys", "global", "unset", keyID)
+	if !strings.Contains(out, "disabled from global deployment") {
+		t.Fatalf("unexpected unset output: %s", out)
+	}
+	if globals, _ := km.GetGlobalPublicKeys(); len(globals) != 0 {
+		t.Fatalf("expected no global keys after unset, got %+v", globals)
+	}
+}
+
 // TestKeyListCmd_Filtering tests filtering keys by global status and search terms.
 func TestKeyListCmd_Filtering(t *testing.T) {
 	setupTestDB(t)
@@ -221,3 +305,400 @@ func TestKeySetExpiryCmd_ClearExpiry(t *testing.T) {
 		t.Fatalf("expected 'never' in expiry column, got: %s", out)
 	}
 }
+
+// TestKeyPruneUnassignedCmd verifies only non-global keys without assignments are pruned.
+func TestKeyPruneUnassignedCmd(t *testing.T) {
+	setupTestDB(t)
+	t.Cleanup(func() {
+		_ = keyPruneUnassignedCmd.Flags().Set("apply", "false")
+		_ = keyPruneUnassignedCmd.Flags().Set("dry-run", "false")
+	})
+
+	km := core.DefaultKeyManager()
+	mgr := core.DefaultAccountManager()
+	accountID, err := mgr.AddAccount("deploy", "prune.example.com", "", "")
+	if err != nil {
+		t.Fatalf("failed to add account: %v", err)
+	}
+	assigned, err := km.AddPublicKeyAndGetModel("ssh-ed25519", "AAAAC3NzaC1lZDI1NTE5AAAAIAssigned", "assigned@example.com", false, time.Time{})
+	if err != nil {
+		t.Fatalf("failed to add key: %v", err)
+	}
+	if err := km.AssignKeyToAccount(assigned.ID, accountID); err != nil {
+		t.Fatalf("failed to assign key: %v", err)
+	}
+	if _, err := km.AddPublicKeyAndGetModel("ssh-ed25519", "AAAAC3NzaC1lZDI1NTE5AAAAIGlobal", "global@example.com", true, time.Time{}); err != nil {
+		t.Fatalf("failed to add key: %v", err)
+	}
+	if _, err := km.AddPublicKeyAndGetModel("ssh-ed25519", "AAAAC3NzaC1lZDI1NTE5AAAAIOrphan", "orphan@example.com", false, time.Time{}); err != nil {
+		t.Fatalf("failed to add key: %v", err)
+	}
+
+	// Dry run lists the orphan only and deletes nothing.
+	out := executeCommand(t, nil, "key", "prune-unassigned", "--dry-run")
+	if !strings.Contains(out, "orphan@example.com") || strings.Contains(out, "assigned@example.com") || strings.Contains(out, "global@example.com") {
+		t.Fatalf("unexpected dry-run output: %s", out)
+	}
+	if !strings.Contains(out, "would be pruned") {
+		t.Fatalf("expected dry-run summary, got: %s", out)
+	}
+	keys, _ := km.GetAllPublicKeys()
+	if len(keys) != 3 {
+		t.Fatalf("dry run must not delete keys, have %d", len(keys))
+	}
+
+	// Flags persist on the package-level command between executions.
+	_ = keyPruneUnassignedCmd.Flags().Set("dry-run", "false")
+	out = executeCommand(t, nil, "key", "prune-unassigned", "--apply")
+	if !strings.Contains(out, "Pruned 1 unassigned key(s).") {
+		t.Fatalf("expected apply summary, got: %s", out)
+	}
+	keys, _ = km.GetAllPublicKeys()
+	if len(keys) != 2 {
+		t.Fatalf("expected 2 keys after prune, got %d", len(keys))
+	}
+	for _, k := range keys {
+		if k.Comment == "orphan@example.com" {
+			t.Fatalf("orphan key should have been pruned")
+		}
+	}
+}
+
+func TestKeyAssignUnassignByTag(t *testing.T) {
+	setupTestDB(t)
+	t.Cleanup(func() {
+		for _, c := range []*cobra.Command{keyAssignCmd, keyUnassignCmd} {
+			_ = c.Flags().Set("tag", "")
+			_ = c.Flags().Set("force", "false")
+		}
+	})
+
+	st := uiadapters.NewStoreAdapter()
+	var prod []int
+	for _, host := range []string{"web-01", "web-02", "dev-01"} {
+		id, err := st.AddAccount("deploy", host, "", "")
+		if err != nil {
+			t.Fatalf("AddAccount: %v", err)
+		}
+		if host != "dev-01" {
+			_ = st.UpdateAccountTags(id, "env:prod")
+			prod = append(prod, id)
+		}
+		_ = st.UpdateAccountIsDirty(id, false)
+	}
+	km := core.DefaultKeyManager()
+	key, err := km.AddPublicKeyAndGetModel("ssh-ed25519", "AAAAC3NzaC1lZDI1NTE5AAAAIBulkAssign", "bulk@example.com", false, time.Time{})
+	if err != nil {
+		t.Fatalf("failed to add key: %v", err)
+	}
+	keyID := fmt.Sprintf("%d", key.ID)
+
+	out := executeCommand(t, nil, "key", "assign", keyID, "--tag", "env:prod", "--force")
+	if !strings.Contains(out, "to 2 account(s); 0 already have it") || !strings.Contains(out, "Updated 2 account(s)") {
+		t.Fatalf("unexpected assign output: %s", out)
+	}
+	holders, _ := km.GetAccountsForKey(key.ID)
+	if len(holders) != 2 {
+		t.Fatalf("expected key on 2 accounts, got %+v", holders)
+	}
+	accounts, _ := st.GetAllAccounts()
+	for _, acc := range accounts {
+		if acc.IsDirty != (acc.ID == prod[0] || acc.ID == prod[1]) {
+			t.Fatalf("unexpected dirty state for %s: %v", acc.String(), acc.IsDirty)
+		}
+	}
+
+	out = executeCommand(t, nil, "key", "assign", keyID, "--tag", "env:prod", "--force")
+	if !strings.Contains(out, "Nothing to do.") {
+		t.Fatalf("expected repeated assign to be a no-op, got: %s", out)
+	}
+
+	out = executeCommand(t, nil, "key", "unassign", keyID, "--tag", "env:prod", "--force")
+	if !strings.Contains(out, "Updated 2 account(s)") {
+		t.Fatalf("unexpected unassign output: %s", out)
+	}
+	if holders, _ := km.GetAccountsForKey(key.ID); len(holders) != 0 {
+		t.Fatalf("expected key unassigned everywhere, got %+v", holders)
+	}
+}
+
+func TestKeyAssignInteractive(t *testing.T) {
+	setupTestDB(t)
+	origTTY, origRun := isInteractiveTerminal, runMultiSelect
+	t.Cleanup(func() {
+		isInteractiveTerminal, runMultiSelect = origTTY, origRun
+		_ = keyAssignInteractiveCmd.Flags().Set("force", "false")
+	})
+
+	isInteractiveTerminal = func() bool { return false }
+	runMultiSelect = func(string, []multiSelectItem) ([]int, error) {
+		t.Fatal("multi-select must not run without a terminal")
+		return nil, nil
+	}
+	cmd := NewRootCmd()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"key", "assign-interactive"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "key assign <key-id>") {
+		t.Fatalf("expected non-TTY error pointing to 'key assign', got %v", err)
+	}
+
+	st := uiadapters.NewStoreAdapter()
+	var ids []int
+	for _, host := range []string{"web-01", "web-02", "db-01"} {
+		id, err := st.AddAccount("deploy", host, "", "")
+		if err != nil {
+			t.Fatalf("AddAccount: %v", err)
+		}
+		_ = st.UpdateAccountIsDirty(id, false)
+		ids = append(ids, id)
+	}
+	km := core.DefaultKeyManager()
+	alice, err := km.AddPublicKeyAndGetModel("ssh-ed25519", "AAAAC3NzaC1lZDI1NTE5AAAAIPickAlice", "alice@example.com", false, time.Time{})
+	if err != nil {
+		t.Fatalf("failed to add key: %v", err)
+	}
+	bob, err := km.AddPublicKeyAndGetModel("ssh-ed25519", "AAAAC3NzaC1lZDI1NTE5AAAAIPickBob", "bob@example.com", false, time.Time{})
+	if err != nil {
+		t.Fatalf("failed to add key: %v", err)
+	}
+	if _, err := km.AddPublicKeyAndGetModel("ssh-ed25519", "AAAAC3NzaC1lZDI1NTE5AAAAIPickAll", "all@example.com", true, time.Time{}); err != nil {
+		t.Fatalf("failed to add key: %v", err)
+	}
+	if err := km.AssignKeyToAccount(alice.ID, ids[0]); err != nil {
+		t.Fatalf("AssignKeyToAccount: %v", err)
+	}
+
+	// Pick both keys and the two web accounts; the global key is not offered.
+	isInteractiveTerminal = func() bool { return true }
+	var offered [][]multiSelectItem
+	runMultiSelect = func(title string, items []multiSelectItem) ([]int, error) {
+		offered = append(offered, items)
+		var picked []int
+		for i, item := range items {
+			if strings.Contains(item.Label, "@example.com") || strings.Contains(item.Label, "web-") {
+				picked = append(picked, i)
+			}
+		}
+		return picked, nil
+	}
+	out := executeCommand(t, nil, "key", "assign-interactive", "--force")
+	if len(offered) != 2 || len(offered[0]) != 2 || len(offered[1]) != 3 {
+		t.Fatalf("expected 2 keys and 3 accounts offered, got %+v", offered)
+	}
+	if !strings.Contains(out, "Made 3 assignment(s)") {
+		t.Fatalf("unexpected output: %s", out)
+	}
+	for _, tc := range []struct {
+		keyID int
+		want  int
+	}{{alice.ID, 2}, {bob.ID, 2}} {
+		holders, _ := km.GetAccountsForKey(tc.keyID)
+		if len(holders) != tc.want {
+			t.Fatalf("expected key %d on %d accounts, got %+v", tc.keyID, tc.want, holders)
+		}
+	}
+
+	out = executeCommand(t, nil, "key", "assign-interactive", "--force")
+	if !strings.Contains(out, "Nothing to do.") {
+		t.Fatalf("expected second run to be a no-op, got: %s", out)
+	}
+}
+
+func TestKeyCheckCompromised(t *testing.T) {
+	setupTestDB(t)
+
+	priv := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	pub, err := ssh.NewPublicKey(priv.Public())
+	if err != nil {
+		t.Fatalf("NewPublicKey: %v", err)
+	}
+	km := core.DefaultKeyManager()
+	key, err := km.AddPublicKeyAndGetModel(pub.Type(), base64.StdEncoding.EncodeToString(pub.Marshal()), "leaked@example.com", false, time.Time{})
+	if err != nil {
+		t.Fatalf("failed to add key: %v", err)
+	}
+	st := uiadapters.NewStoreAdapter()
+	accID, err := st.AddAccount("deploy", "web-01", "", "")
+	if err != nil {
+		t.Fatalf("AddAccount: %v", err)
+	}
+	if err := km.AssignKeyToAccount(key.ID, accID); err != nil {
+		t.Fatalf("AssignKeyToAccount: %v", err)
+	}
+
+	dir := t.TempDir()
+	clean := filepath.Join(dir, "clean.txt")
+	_ = os.WriteFile(clean, []byte("# nothing of ours\nSHA256:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA\n"), 0o600)
+	out := executeCommand(t, nil, "keys", "check-compromised", "--list", clean)
+	if !strings.Contains(out, "No compromised keys found") {
+		t.Fatalf("unexpected output for a clean list: %s", out)
+	}
+
+	leaked := filepath.Join(dir, "leaked.txt")
+	_ = os.WriteFile(leaked, []byte(ssh.FingerprintSHA256(pub)+" incident-42\n"), 0o600)
+	root := NewRootCmd()
+	root.SetArgs([]string{"keys", "check-compromised", "--list", leaked})
+	if err := root.Execute(); ExitCode(err) != 1 || !strings.Contains(err.Error(), "1 compromised key(s) found") {
+		t.Fatalf("expected exit code 1 for a compromised key, got %v", err)
+	}
+}
+
+func TestKeyLint(t *testing.T) {
+	setupTestDB(t)
+
+	km := core.DefaultKeyManager()
+	priv := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	pub, err := ssh.NewPublicKey(priv.Public())
+	if err != nil {
+		t.Fatalf("NewPublicKey: %v", err)
+	}
+	if _, err := km.AddPublicKeyAndGetModel(pub.Type(), base64.StdEncoding.EncodeToString(pub.Marshal()), "ops@example.com", true, time.Time{}); err != nil {
+		t.Fatalf("failed to add key: %v", err)
+	}
+	out := executeCommand(t, nil, "keys", "lint")
+	if !strings.Contains(out, "No key issues found") {
+		t.Fatalf("unexpected output for a clean key set: %s", out)
+	}
+
+	rsaPriv, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	rsaPub, err := ssh.NewPublicKey(&rsaPriv.PublicKey)
+	if err != nil {
+		t.Fatalf("NewPublicKey: %v", err)
+	}
+	weak, err := km.AddPublicKeyAndGetModel(rsaPub.Type(), base64.StdEncoding.EncodeToString(rsaPub.Marshal()), "old@example.com", false, time.Time{})
+	if err != nil {
+		t.Fatalf("failed to add key: %v", err)
+	}
+
+	var buf bytes.Buffer
+	root := NewRootCmd()
+	root.SetOut(&buf)
+	root.SetArgs([]string{"keys", "lint", "--format", "json"})
+	if err := root.Execute(); ExitCode(err) != 1 {
+		t.Fatalf("expected exit code 1 for a weak key, got %v", err)
+	}
+	var findings []core.KeyLintFinding
+	if err := json.Unmarshal(buf.Bytes(), &findings); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, buf.String())
+	}
+	if len(findings) != 2 || findings[0].Rule != core.LintRuleWeakRSA || findings[0].KeyID != weak.ID ||
+		findings[1].Rule != core.LintRuleUnassigned || findings[1].KeyID != weak.ID {
+		t.Fatalf("unexpected findings: %+v", findings)
+	}
+
+	// Lowering the threshold leaves only the informational finding.
+	buf.Reset()
+	root = NewRootCmd()
+	root.SetOut(&buf)
+	root.SetArgs([]string{"keys", "lint", "--format", "table", "--min-rsa-bits", "1024"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("expected success without error findings, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "unassigned") || strings.Contains(buf.String(), "weak-rsa") {
+		t.Fatalf("unexpected table output: %s", buf.String())
+	}
+}
+
+func resetKeyCAFlags(t *testing.T) {
+	t.Helper()
+	for _, cmd := range []*cobra.Command{keyAddCmd, keySetCACmd} {
+		for _, name := range []string{"cert-authority", "principals", "unset"} {
+			f := cmd.Flags().Lookup(name)
+			if f == nil {
+				continue
+			}
+			if sv, ok := f.Value.(interface{ Replace([]string) error }); ok {
+				_ = sv.Replace(nil)
+			} else {
+				_ = f.Value.Set(f.DefValue)
+			}
+			f.Changed = false
+		}
+	}
+}
+
+func TestKeySetCA(t *testing.T) {
+	setupTestDB(t)
+	resetKeyCAFlags(t)
+	t.Cleanup(func() { resetKeyCAFlags(t) })
+
+	priv := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	pub, err := ssh.NewPublicKey(priv.Public())
+	if err != nil {
+		t.Fatalf("NewPublicKey: %v", err)
+	}
+	out := executeCommand(t, nil, "key", "add",
+		"--algorithm", pub.Type(),
+		"--key-data", base64.StdEncoding.EncodeToString(pub.Marshal()),
+		"--comment", "user-ca",
+		"--cert-authority", "--principals", "deploy,ops")
+	if !strings.Contains(out, "Key added successfully") {
+		t.Fatalf("add command failed, output: %s", out)
+	}
+
+	km := core.DefaultKeyManager()
+	keys, err := km.GetAllPublicKeys()
+	if err != nil || len(keys) != 1 {
+		t.Fatalf("failed to retrieve keys: %v (%d keys)", err, len(keys))
+	}
+	if !keys[0].IsCA || strings.Join(keys[0].Principals, ",") != "deploy,ops" {
+		t.Fatalf("expected CA key with principals, got %+v", keys[0])
+	}
+	if line := keys[0].AuthorizedKeysLine(); !strings.HasPrefix(line, `cert-authority,principals="deploy,ops" `) {
+		t.Fatalf("unexpected authorized_keys line: %s", line)
+	}
+
+	keyID := fmt.Sprintf("%d", keys[0].ID)
+	out = executeCommand(t, nil, "key", "show", keyID)
+	if !strings.Contains(out, "deploy,ops") {
+		t.Fatalf("expected principals in show output, got: %s", out)
+	}
+
+	executeCommand(t, nil, "key", "set-ca", keyID, "--unset")
+	keys, err = km.GetAllPublicKeys()
+	if err != nil || len(keys) != 1 {
+		t.Fatalf("failed to retrieve keys: %v", err)
+	}
+	if keys[0].IsCA || len(keys[0].Principals) != 0 {
+		t.Fatalf("expected CA marker cleared, got %+v", keys[0])
+	}
+}
+
+func resetKeyExportFlags(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{"all", "out"} {
+		if f := keyExportCmd.Flags().Lookup(name); f != nil {
+			_ = f.Value.Set(f.DefValue)
+			f.Changed = false
+		}
+	}
+}
+
+func TestKeyExport(t *testing.T) {
+	setupTestDB(t)
+	resetKeyExportFlags(t)
+	t.Cleanup(func() { resetKeyExportFlags(t) })
+
+	executeCommand(t, nil, "key", "add", "--algorithm", "ssh-ed25519", "--key-data", "AAAAC3NzaC1lZDI1NTE5AAAAIExportAlice", "--comment", "alice@laptop")
+	executeCommand(t, nil, "key", "add", "--algorithm", "ssh-ed25519", "--key-data", "AAAAC3NzaC1lZDI1NTE5AAAAIExportBob", "--comment", "bob@desk", "--global")
+
+	out := executeCommand(t, nil, "key", "export", "alice@laptop")
+	if out != "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIExportAlice alice@laptop\n" {
+		t.Fatalf("unexpected single key export: %q", out)
+	}
+
+	path := filepath.Join(t.TempDir(), "catalog.pub")
+	executeCommand(t, nil, "key", "export", "--all", "-o", path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read export: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || !strings.Contains(string(data), "AAAAC3NzaC1lZDI1NTE5AAAAIExportAlice alice@laptop\n") || !strings.Contains(string(data), "AAAAC3NzaC1lZDI1NTE5AAAAIExportBob bob@desk\n") {
+		t.Fatalf("unexpected catalog export:\n%s", data)
+	}
+}
@@ -16,7 +16,9 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	"runtime/debug"
@@ -35,6 +37,7 @@ import (
 	"github.com/toeirei/keymaster/core"
 	"github.com/toeirei/keymaster/core/deploy"
 	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/core/security"
 	"github.com/toeirei/keymaster/core/sshkey"
 	"github.com/toeirei/keymaster/ui/i18n"
 	"github.com/toeirei/keymaster/ui/tui"
@@ -52,13 +55,69 @@ var fullRestore bool // Flag for the restore command
 
 var password string // Flag for rotate-key password
 var verbose bool
+var logFormat string
 var showVersionFlag bool
 var auditReferrer string
 
+// dryRunDB records database writes instead of applying them (--dry-run-db).
+var dryRunDB bool
+
+// dryRunDBRefusedCommands change remote hosts, which would then no longer
+// match a database whose writes are discarded, so --dry-run-db refuses them.
+var dryRunDBRefusedCommands = map[string]bool{
+	"deploy":          true,
+	"rotate-key":      true,
+	"decommission":    true,
+	"group deploy":    true,
+	"transfer accept": true,
+}
+
+// dryRunDBRefusedFlags lists flags that make an otherwise local command
+// change remote hosts or their trust.
+var dryRunDBRefusedFlags = map[string][]string{
+	"audit":      {"remediate", "repair-known-hosts"},
+	"trust-host": {"retrust"},
+}
+
+// checkDryRunDB returns an error when cmd cannot run under --dry-run-db. The
+// TUI is refused as well: its client opens the database on its own and would
+// bypass the dry-run store.
+func checkDryRunDB(cmd *cobra.Command) error {
+	if cmd == cmd.Root() {
+		return errors.New("--dry-run-db is not supported by the TUI; run a subcommand instead")
+	}
+	path := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+	if dryRunDBRefusedCommands[path] {
+		return fmt.Errorf("--dry-run-db cannot be used with %q: it changes remote hosts", path)
+	}
+	for _, name := range dryRunDBRefusedFlags[path] {
+		if on, _ := cmd.Flags().GetBool(name); on {
+			return fmt.Errorf("--dry-run-db cannot be used with %q --%s: it changes remote hosts", path, name)
+		}
+	}
+	return nil
+}
+
 // TODO should be moved to project root
 var appConfig config.Config
 
+// configDefaults returns the values used for settings missing from the
+// config file.
+func configDefaults() map[string]any {
+	return map[string]any{
+		"database.type": "sqlite",
+		"database.dsn":  "./keymaster.db",
+		"language":      "en",
+	}
+}
+
 func setupDefaultServices(cmd *cobra.Command, args []string) error {
+	if dryRunDB {
+		if err := checkDryRunDB(cmd); err != nil {
+			return err
+		}
+	}
+
 	// Load optional config file argument from cli
 	optionalConfigPath, err := getConfigPathFromCli(cmd)
 	if err != nil {
@@ -79,11 +138,7 @@ func setupDefaultServices(cmd *cobra.Command, args []string) error {
 	}
 
 	// Load config
-	defauls := map[string]any{
-		"database.type": "sqlite",
-		"database.dsn":  "./keymaster.db",
-		"language":      "en",
-	}
+	defauls := configDefaults()
 
 	appConfig, err = config.LoadConfig[config.Config](cmd, defauls, optionalConfigPath)
 	// A "file not found" error is expected on first run, so we handle it specifically.
@@ -137,6 +192,14 @@ func setupDefaultServices(cmd *cobra.Command, args []string) error {
 		viper.Set("language", appConfig.Language)
 	}
 
+	// Apply the log format; the flag overrides the config file.
+	if logFormat != "" {
+		appConfig.Log.Format = logFormat
+	}
+	if err := core.ConfigureLogger(appConfig.Log.Format, verbose); err != nil {
+		return err
+	}
+
 	// Initialize i18n
 	i18n.Init(appConfig.Language)
 
@@ -146,16 +209,60 @@ func setupDefaultServices(cmd *cobra.Command, args []string) error {
 			return errors.New(i18n.T("config.error_init_db", err))
 		}
 	}
+	if dryRunDB {
+		if err := core.EnableDBDryRun(); err != nil {
+			return fmt.Errorf("enable database dry run: %w", err)
+		}
+	}
 
 	// Recover from any previous crashes
 	if err := core.RecoverFromCrash(); err != nil {
 		log.Errorf("Bootstrap recovery error: %v", err)
 	}
 
+	// Apply deploy settings used by every deployment in this process.
+	core.SetDefaultDeployOptions(core.DeployOptions{
+		PostDeployCommand:           appConfig.Deploy.PostDeployCommand,
+		PostDeployCommandWindows:    appConfig.Deploy.PostDeployCommandWindows,
+		RollbackOnPostDeployFailure: appConfig.Deploy.RollbackOnPostDeployFailure,
+		LockTimeout:                 appConfig.Deploy.LockTimeout,
+		LockFailFast:                appConfig.Deploy.LockFailFast,
+	})
+	deploy.SetHostConnectionInterval(appConfig.Deploy.MinHostConnectionInterval, appConfig.Deploy.MaxHostThrottleWait)
+	hostKeyPolicy, err := deploy.ParseHostKeyPolicy(appConfig.SSH.HostKeyPolicy)
+	if err != nil {
+		return fmt.Errorf("ssh.host_key_policy: %w", err)
+	}
+	deploy.SetHostKeyPolicy(hostKeyPolicy)
+	if hostKeyPolicy == deploy.HostKeyPolicyInsecure {
+		log.Warn("ssh.host_key_policy is insecure: host keys are not verified")
+	}
+	sshkey.SetAllowedAlgorithms(appConfig.Security.AllowedAlgorithms)
+	connAlgorithms := sshkey.ConnectionAlgorithms{
+		Ciphers:      appConfig.SSH.Ciphers,
+		MACs:         appConfig.SSH.MACs,
+		KeyExchanges: appConfig.SSH.KexAlgorithms,
+	}
+	if err := connAlgorithms.Validate(); err != nil {
+		return fmt.Errorf("ssh: %w", err)
+	}
+	sshkey.SetConnectionAlgorithms(connAlgorithms)
+	if insecure := connAlgorithms.Insecure(); len(insecure) > 0 {
+		log.Warn("ssh algorithms with known weaknesses are enabled", "algorithms", strings.Join(insecure, ", "))
+	}
+	totpSecret = nil
+	if appConfig.Security.TOTPSecret != "" {
+		if totpSecret, err = security.ParseTOTPSecret(appConfig.Security.TOTPSecret); err != nil {
+			return fmt.Errorf("security.totp_secret: %w", err)
+		}
+	}
+
 	// Start background session reaper
 	core.StartSessionReaper()
 
 	core.SetAuditContext("cli", sanitizeAuditReferrer(auditReferrer))
+	core.SetAuditIdentity(appConfig.AuditIdentity)
+	core.SetRequireUniqueLabels(appConfig.RequireUniqueLabels)
 
 	return nil
 }
@@ -264,6 +371,12 @@ Running without a subcommand will launch the interactive TUI.`,
 			}
 			return setupDefaultServices(cmd, args)
 		},
+		PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
+			if !dryRunDB {
+				return nil
+			}
+			return core.WriteDBDryRunSummary(cmd.ErrOrStderr())
+		},
 		Run: func(cmd *cobra.Command, args []string) {
 			core.SetAuditContext("tui", sanitizeAuditReferrer(auditReferrer))
 			// The database is already initialized by PersistentPreRunE.
@@ -306,28 +419,153 @@ Running without a subcommand will launch the interactive TUI.`,
 	registerKeyCommands()
 	cmd.AddCommand(keyCmd)
 
+	// Register system key command
+	registerSystemKeyCommands()
+	cmd.AddCommand(systemKeyCmd)
+
+	// Register account group command
+	registerGroupCommands()
+	cmd.AddCommand(groupCmd)
+
+	// Register config command
+	registerConfigCommands()
+	cmd.AddCommand(configCmd)
+
+	// Register status command
+	registerStatusCommand()
+	cmd.AddCommand(statusCmd)
+
+	// Register shell command
+	registerShellCommand()
+	cmd.AddCommand(shellCmd)
+
 	// Define flags
 	cmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose output (sets -v for DB logs)")
+	cmd.PersistentFlags().StringVar(&logFormat, "log-format", "", `Log output format ("text" or "json"); overrides log.format from the config`)
 	cmd.PersistentFlags().BoolVarP(&showVersionFlag, "version", "V", false, "Print version and exit")
 	cmd.PersistentFlags().StringVar(&auditReferrer, "referrer", "", "Optional referrer metadata included in audit logs")
 	cmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file")
+	cmd.PersistentFlags().BoolVar(&dryRunDB, "dry-run-db", false, "Record database writes instead of applying them and print a summary at the end; commands that change hosts are refused")
 	cmd.PersistentFlags().String("language", "en", `TUI language ("en", "de")`)
 	applyDefaultFlags(cmd)
 
 	// Add subcommand flags
 	applyDefaultFlags(deployCmd)
+	if deployCmd.Flags().Lookup("canary") == nil {
+		deployCmd.Flags().Int("canary", 0, "Deploy to this many hosts first and audit them before deploying to the rest")
+	}
+	if deployCmd.Flags().Lookup("group") == nil {
+		deployCmd.Flags().String("group", "", "Limit a --canary rollout to accounts carrying this tag")
+	}
+	if deployCmd.Flags().Lookup("account-group") == nil {
+		deployCmd.Flags().String("account-group", "", "Deploy only the members of this account group")
+	}
+	if deployCmd.Flags().Lookup("slowest") == nil {
+		deployCmd.Flags().Int("slowest", 0, "After deploying, list the N hosts that took longest")
+	}
+	if deployCmd.Flags().Lookup("output-serial") == nil {
+		deployCmd.Flags().Bool("output-serial", false, "After deploying, print the system key serial recorded for each host")
+	}
+	if deployCmd.Flags().Lookup("stop-on-error") == nil {
+		deployCmd.Flags().Bool("stop-on-error", false, "Stop at the first host that fails instead of attempting every host")
+	}
+	if deployCmd.Flags().Lookup("checkpoint") == nil {
+		deployCmd.Flags().String("checkpoint", "", "Record the accounts deployed successfully in this file after each host")
+		deployCmd.Flags().String("resume", "", "Skip the accounts completed in this checkpoint file and keep updating it")
+		deployCmd.Flags().Duration("pause", 0, "Wait this long between two hosts, e.g. 500ms")
+	}
+	if deployCmd.Flags().Lookup("keys-only") == nil {
+		deployCmd.Flags().StringSlice("keys-only", nil, "Deploy only the keys with these comments (plus the system key) to one account, without changing its assignments")
+	}
+	if deployCmd.Flags().Lookup("plan-out") == nil {
+		deployCmd.Flags().String("plan-out", "", "Write the deploy plan to this file for review instead of deploying")
+		deployCmd.Flags().String("plan-in", "", "Check the plan in this file against the live state; deploy it with --confirm")
+		deployCmd.Flags().Bool("confirm", false, "With --plan-in, deploy the plan if the live state still matches it")
+	}
 	applyDefaultFlags(rotateKeyCmd)
 	applyDefaultFlags(auditCmd)
 	if rotateKeyCmd.Flags().Lookup("password") == nil {
 		rotateKeyCmd.Flags().StringVarP(&password, "password", "p", "", "Optional password to encrypt the new private key")
 	}
+	if rotateKeyCmd.Flags().Lookup("stage") == nil {
+		rotateKeyCmd.Flags().Bool("stage", false, "Add the new key as active for deploys without deactivating the previous keys")
+		rotateKeyCmd.Flags().Bool("finalize", false, "Deactivate the keys superseded by a staged rotation once every host is confirmed on the newest key")
+	}
 	if auditCmd.Flags().Lookup("mode") == nil {
 		auditCmd.Flags().StringVarP(&auditMode, "mode", "m", "strict", "Audit mode: 'strict' (full file comparison) or 'serial' (header serial only)")
 	}
+	if auditCmd.Flags().Lookup("fail-fast") == nil {
+		auditCmd.Flags().Bool("fail-fast", false, "Stop at the first account that fails the audit")
+	}
+	if auditCmd.Flags().Lookup("parallel-groups") == nil {
+		auditCmd.Flags().StringSlice("parallel-groups", nil, "Audit the accounts of each tag in turn (comma-separated, in order), auditing the hosts of a tag in parallel")
+	}
+	if auditCmd.Flags().Lookup("skip-recent") == nil {
+		auditCmd.Flags().Duration("skip-recent", 0, "Skip hosts audited clean within this window (e.g. 30m)")
+	}
+	if auditCmd.Flags().Lookup("account-group") == nil {
+		auditCmd.Flags().String("account-group", "", "Audit only the members of this account group")
+	}
+	if auditCmd.Flags().Lookup("only-reachable") == nil {
+		auditCmd.Flags().Bool("only-reachable", false, "Ping each host's SSH port first and report hosts that do not answer as unreachable instead of auditing them")
+		auditCmd.Flags().Duration("ping-timeout", core.DefaultPingTimeout, "Timeout of the --only-reachable check per host")
+	}
+	if auditCmd.Flags().Lookup("show-drift") == nil {
+		auditCmd.Flags().Bool("show-drift", false, "List the keys added to or removed from hosts that failed a strict audit")
+	}
+	if auditCmd.Flags().Lookup("remediate") == nil {
+		auditCmd.Flags().Bool("remediate", false, "Apply each drifted account's remediation policy (auto redeploys, alert reports, ignore suppresses)")
+	}
+	if auditCmd.Flags().Lookup("output-file") == nil {
+		auditCmd.Flags().String("output-file", "", "Write a JSON report of the run to this path; supports {{.Date}}, {{.Time}}, {{.Timestamp}} and {{.Mode}} (e.g. report-{{.Date}}.json)")
+	}
+	if auditCmd.Flags().Lookup("diff-output") == nil {
+		auditCmd.Flags().String("diff-output", "", "Write a user@host.diff unified diff (expected vs remote authorized_keys) per drifted host into this directory")
+	}
+	if auditCmd.Flags().Lookup("slowest") == nil {
+		auditCmd.Flags().Int("slowest", 0, "After auditing, list the N hosts that took longest")
+	}
+	if auditCmd.Flags().Lookup("compare-to-backup") == nil {
+		auditCmd.Flags().String("compare-to-backup", "", "Offline: list database changes since this backup file instead of contacting hosts")
+	}
+	if auditCmd.Flags().Lookup("format") == nil {
+		auditCmd.Flags().String("format", "text", "Output format: 'text' (one line per host) or 'junit' (JUnit XML on stdout for CI dashboards)")
+	}
+	if auditCmd.Flags().Lookup("repair-known-hosts") == nil {
+		auditCmd.Flags().Bool("repair-known-hosts", false, "Store the changed host keys of hosts matching ssh.auto_retrust_hosts without asking")
+	}
 
 	applyDefaultFlags(importCmd)
+	if importCmd.Flags().Lookup("source") == nil {
+		importCmd.Flags().String("source", "", "Import from a key source instead of a file, as <source>:<identifier> (e.g. github:alice)")
+	}
+	if importCmd.Flags().Lookup("replace") == nil {
+		importCmd.Flags().Bool("replace", false, "Update the key data of stored keys with the same comment instead of skipping them")
+	}
+	applyDefaultFlags(importRemoteCmd)
 	applyDefaultFlags(trustHostCmd)
+	if trustHostCmd.Flags().Lookup("retrust") == nil {
+		trustHostCmd.Flags().Bool("retrust", false, "Re-fetch and re-trust the host keys of all accounts selected by --tag")
+	}
+	if trustHostCmd.Flags().Lookup("tag") == nil {
+		trustHostCmd.Flags().String("tag", "", "With --retrust, select the accounts carrying this tag")
+	}
+	if trustHostCmd.Flags().Lookup("yes") == nil {
+		trustHostCmd.Flags().Bool("yes", false, "With --retrust, save the new keys without prompting")
+	}
 	applyDefaultFlags(exportSSHConfigCmd)
+	if exportSSHConfigCmd.Flags().Lookup("format") == nil {
+		exportSSHConfigCmd.Flags().String("format", core.SSHConfigFormatFlat, "Output format: 'flat' (single file) or 'include' (Include stub plus one file per tag)")
+	}
+	if exportSSHConfigCmd.Flags().Lookup("identity-file") == nil {
+		exportSSHConfigCmd.Flags().String("identity-file", "", "Emit IdentityFile with this path for every host (e.g. ~/.ssh/id_ed25519)")
+	}
+	if exportSSHConfigCmd.Flags().Lookup("strict-host-key-checking") == nil {
+		exportSSHConfigCmd.Flags().String("strict-host-key-checking", "", "Emit StrictHostKeyChecking with this value (yes, no, ask, accept-new, off)")
+	}
+	if exportSSHConfigCmd.Flags().Lookup("dedupe-aliases") == nil {
+		exportSSHConfigCmd.Flags().Bool("dedupe-aliases", false, "Append -2, -3, ... to Host aliases already used by another account")
+	}
 	applyDefaultFlags(dbMaintainCmd)
 	if dbMaintainCmd.Flags().Lookup("skip-integrity") == nil {
 		dbMaintainCmd.Flags().Bool("skip-integrity", false, "Skip integrity_check (SQLite) during maintenance")
@@ -335,12 +573,23 @@ Running without a subcommand will launch the interactive TUI.`,
 	if dbMaintainCmd.Flags().Lookup("timeout") == nil {
 		dbMaintainCmd.Flags().Int("timeout", 0, "Timeout in seconds for maintenance (0 means no timeout)")
 	}
+	if dbMaintainCmd.Flags().Lookup("analyze") == nil {
+		dbMaintainCmd.Flags().Bool("analyze", false, "Report row counts and table/index sizes per table (runs ANALYZE on SQLite)")
+	}
 	applyDefaultFlags(restoreCmd)
+	if backupCmd.Flags().Lookup("tables") == nil {
+		backupCmd.Flags().StringSlice("tables", nil, "Only back up these tables (comma-separated: "+strings.Join(model.BackupTables, ", ")+")")
+		backupCmd.Flags().String("compression", core.BackupCompressionDefault, "zstd compression level: fast, default or best")
+		backupCmd.Flags().Bool("no-compress", false, "Write plain, uncompressed JSON")
+	}
 	if restoreCmd.Flags().Lookup("full") == nil {
 		restoreCmd.Flags().BoolVar(&fullRestore, "full", false, "Perform a full, destructive restore (wipes all existing data first)")
 	}
 
 	applyDefaultFlags(migrateCmd)
+	if migrateCmd.Flags().Lookup("verify") == nil {
+		migrateCmd.Flags().Bool("verify", false, "After importing, compare per-table row counts and checksums of source and target")
+	}
 	applyDefaultFlags(decommissionCmd)
 	if decommissionCmd.Flags().Lookup("skip-remote") == nil {
 		decommissionCmd.Flags().Bool("skip-remote", false, "Skip remote SSH cleanup (only delete from database)")
@@ -357,6 +606,9 @@ Running without a subcommand will launch the interactive TUI.`,
 	if decommissionCmd.Flags().Lookup("tag") == nil {
 		decommissionCmd.Flags().String("tag", "", "Decommission all accounts with this tag (format: key:value)")
 	}
+	if decommissionCmd.Flags().Lookup("confirm") == nil {
+		decommissionCmd.Flags().String("confirm", "", "Skip the prompt if this equals the number of affected accounts; abort otherwise")
+	}
 
 	// Add a lightweight `version` subcommand so users and CI can run `keymaster version`.
 	versionCmd := &cobra.Command{
@@ -400,16 +652,25 @@ Running without a subcommand will launch the interactive TUI.`,
 		auditCmd,
 		auditCompareCmd,
 		importCmd,
+		importRemoteCmd,
 		transferCmd,
 		trustHostCmd,
 		exportSSHConfigCmd,
 		dbMaintainCmd,
 		backupCmd,
 		restoreCmd,
+		verifyBackupCmd,
 		migrateCmd,
 		decommissionCmd,
 		versionCmd,
+		whoamiCmd,
+		completionCmd,
+		selfTestCmd,
 	)
+	// completionCmd replaces Cobra's default, which would run the root
+	// PersistentPreRunE and touch config and database.
+	cmd.CompletionOptions.DisableDefaultCmd = true
+	registerCompletions()
 
 	return cmd
 }
@@ -478,34 +739,280 @@ var deployCmd = &cobra.Command{
 	Short: "Deploy authorized_keys to one or all hosts",
 	Long: `Renders the authorized_keys file from the database state and deploys it.
 If an account (user@host) is specified, deploys only to that account.
-If no account is specified, deploys to all active accounts in the database.`,
+If no account is specified, deploys to all active accounts in the database.
+
+Use --account-group web to deploy only the members of the account group web
+(see 'account move-group'). Unlike tags, groups match exactly.
+
+Use --canary N for a staged rollout: N hosts (of the --account-group, or of
+the --group tag, if given) are deployed and audited first, and the remaining
+hosts are only deployed when every canary passed.
+
+Use --slowest N to list the N hosts that took longest to deploy.
+
+Use --output-serial to print, for each host deployed successfully, the system
+key serial now recorded for it in the database.
+
+Use --stop-on-error to stop at the first host that fails; the remaining
+hosts are not attempted. By default every host is attempted and failures
+are reported at the end.
+
+Use --checkpoint <file> to record, after each host, the accounts deployed
+successfully. If the rollout is interrupted, run it again with --resume
+<file> to skip those accounts; the checkpoint keeps being updated. Use
+--pause to wait between hosts and spread a large rollout out.
+
+Use --keys-only comment1,comment2 with an account to test keys on one host:
+authorized_keys is rendered from just those keys plus the system key, the
+account's assignments are left alone, and the account is marked dirty so the
+next normal deploy restores its assigned keys.
+
+Use --plan-out <file> to write the deploy plan (per account: content hash,
+key and system key changes) for offline approval without deploying. Once
+approved, --plan-in <file> --confirm deploys it, but only if the live state
+still matches every hash in the plan; otherwise it aborts with "state
+changed". Without --confirm, --plan-in only checks the plan.`,
 
 	Args:    cobra.MaximumNArgs(1),
 	PreRunE: setupDefaultServices,
 	Run: func(cmd *cobra.Command, args []string) {
+		canary, _ := cmd.Flags().GetInt("canary")
+		tag, _ := cmd.Flags().GetString("group")
+		group, _ := cmd.Flags().GetString("account-group")
+		slowest, _ := cmd.Flags().GetInt("slowest")
+		stopOnError, _ := cmd.Flags().GetBool("stop-on-error")
+		outputSerial, _ := cmd.Flags().GetBool("output-serial")
+		checkpointPath, _ := cmd.Flags().GetString("checkpoint")
+		resumePath, _ := cmd.Flags().GetString("resume")
+		pause, _ := cmd.Flags().GetDuration("pause")
+		keysOnly, _ := cmd.Flags().GetStringSlice("keys-only")
+		planOut, _ := cmd.Flags().GetString("plan-out")
+		planIn, _ := cmd.Flags().GetString("plan-in")
+		confirm, _ := cmd.Flags().GetBool("confirm")
+		if canary > 0 && len(args) > 0 {
+			log.Fatal("--canary deploys to a group of accounts and cannot be combined with an account")
+		}
+		if tag != "" && canary == 0 {
+			log.Fatal("--group requires --canary")
+		}
+		if group != "" && len(args) > 0 {
+			log.Fatal("--account-group deploys the members of a group and cannot be combined with an account")
+		}
+		if group != "" && (planOut != "" || planIn != "") {
+			log.Fatal("--account-group cannot be combined with --plan-out or --plan-in")
+		}
+		if stopOnError && canary > 0 {
+			log.Fatal("--stop-on-error cannot be combined with --canary, which already stops at a failing canary")
+		}
+		if (checkpointPath != "" || resumePath != "" || pause > 0) && (canary > 0 || len(args) > 0) {
+			log.Fatal("--checkpoint, --resume and --pause apply to fleet deploys and cannot be combined with --canary or an account")
+		}
+		opts := core.DeployRunOptions{StopOnError: stopOnError, CheckpointPath: checkpointPath, Pause: pause, AccountGroup: group}
+		if resumePath != "" {
+			cp, err := core.ReadDeployCheckpoint(resumePath)
+			if err != nil {
+				log.Fatalf("%v", err)
+			}
+			opts.Resume = cp
+			if opts.CheckpointPath == "" {
+				opts.CheckpointPath = resumePath
+			}
+			fmt.Printf("Resuming from %s: %d account(s) already completed.\n", resumePath, len(cp.Completed))
+		}
+		if len(keysOnly) > 0 && (len(args) == 0 || canary > 0 || checkpointPath != "" || resumePath != "") {
+			log.Fatal("--keys-only deploys to a single account; pass user@host and no rollout flags")
+		}
+		if (planOut != "" || planIn != "") && (canary > 0 || len(keysOnly) > 0 || checkpointPath != "" || resumePath != "") {
+			log.Fatal("--plan-out and --plan-in cannot be combined with --canary, --keys-only, --checkpoint or --resume")
+		}
+		if planOut != "" && planIn != "" {
+			log.Fatal("use either --plan-out or --plan-in")
+		}
+		if planIn != "" && len(args) > 0 {
+			log.Fatal("--plan-in deploys the accounts of the plan and cannot be combined with an account")
+		}
+		if confirm && planIn == "" {
+			log.Fatal("--confirm requires --plan-in")
+		}
+		installHostKeyChangePrompt()
+		defer core.SetHostKeyChangePrompt(nil)
+
 		// Build adapters for core facades
 		st := uiadapters.NewStoreAdapter()
 		dm := &cliDeployerManager{}
 
+		if len(keysOnly) > 0 {
+			if err := runKeysOnlyDeploy(st, args[0], keysOnly); err != nil {
+				log.Fatalf("%v", err)
+			}
+			return
+		}
+
+		if planOut != "" {
+			var identifier *string
+			if len(args) > 0 {
+				identifier = &args[0]
+			}
+			if err := runDeployPlanOut(os.Stdout, st, identifier, planOut); err != nil {
+				log.Fatalf("%v", err)
+			}
+			return
+		}
+		if planIn != "" {
+			results, err := runDeployPlanIn(cmd.Context(), os.Stdout, st, dm, planIn, confirm, opts)
+			printDeployResults(os.Stdout, results)
+			printSlowest(os.Stdout, "deploy", core.SlowestDeployResults(results, slowest), deployTiming)
+			if outputSerial {
+				printDeployedSerials(os.Stdout, st, results)
+			}
+			if err != nil {
+				log.Fatalf("%v", err)
+			}
+			return
+		}
+
+		if canary > 0 {
+			res, err := core.RunCanaryDeploy(cmd.Context(), st, dm, core.CanaryOptions{Canaries: canary, Tag: tag, AccountGroup: group}, &cliReporter{})
+			if res != nil {
+				all := append(append([]core.DeployResult{}, res.Canaries...), res.Rest...)
+				printCanaryDeployResult(os.Stdout, res)
+				printSlowest(os.Stdout, "deploy", core.SlowestDeployResults(all, slowest), deployTiming)
+				if outputSerial {
+					printDeployedSerials(os.Stdout, st, all)
+				}
+			}
+			if err != nil {
+				log.Fatalf("%v", err)
+			}
+			return
+		}
+
 		var identifier *string
 		if len(args) > 0 {
 			s := args[0]
 			identifier = &s
 		}
 
-		results, err := core.RunDeployCmd(cmd.Context(), st, dm, identifier, nil)
+		results, err := core.RunDeployWithOptionsCmd(cmd.Context(), st, dm, identifier, opts, nil)
+		if err != nil && results == nil {
+			log.Fatalf("%v", err)
+		}
+		printDeployResults(os.Stdout, results)
+		if stopOnError && len(results) > 0 && results[len(results)-1].Error != nil {
+			fmt.Println("Stopped at the first failure; remaining hosts were not attempted.")
+		}
+		printSlowest(os.Stdout, "deploy", core.SlowestDeployResults(results, slowest), deployTiming)
+		if outputSerial {
+			printDeployedSerials(os.Stdout, st, results)
+		}
 		if err != nil {
 			log.Fatalf("%v", err)
 		}
-		// Print results similarly to previous behavior
-		for _, r := range results {
+	},
+}
+
+// runKeysOnlyDeploy deploys the keys with the given comments, plus the system
+// key, to the account identified by identifier as a one-off.
+func runKeysOnlyDeploy(st core.Store, identifier string, comments []string) error {
+	accounts, err := st.GetAllActiveAccounts()
+	if err != nil {
+		return fmt.Errorf("get accounts: %w", err)
+	}
+	acc, err := core.FindAccountByIdentifier(identifier, accounts)
+	if err != nil {
+		return err
+	}
+	if !acc.DeployEnabled {
+		return fmt.Errorf("deploys are disabled for account %s", acc.String())
+	}
+	kl := core.DefaultKeyLister()
+	if kl == nil {
+		return fmt.Errorf("no key lister available")
+	}
+	keys, err := core.ResolveKeysByComment(kl, comments)
+	if err != nil {
+		return err
+	}
+	if err := core.DeployAccountWithKeys(st, *acc, keys); err != nil {
+		return fmt.Errorf("%s", i18n.T("parallel_task.deploy_fail_message", acc.String(), err))
+	}
+	fmt.Printf("Deployed %d key(s) to %s for testing; assignments are unchanged.\n", len(keys), acc.String())
+	fmt.Println("The account is marked dirty; the next deploy restores its assigned keys.")
+	return nil
+}
+
+// printDeployResults writes a success or failure line per deployed host.
+func printDeployResults(w io.Writer, results []core.DeployResult) {
+	for _, r := range results {
+		if r.Error != nil {
+			_, _ = fmt.Fprintf(w, "%s\n", i18n.T("parallel_task.deploy_fail_message", r.Account.String(), r.Error))
+		} else {
+			_, _ = fmt.Fprintf(w, "%s\n", i18n.T("parallel_task.deploy_success_message", r.Account.String()))
+		}
+	}
+}
+
+// printDeployedSerials prints the system key serial recorded in the database
+// for each host that deployed successfully.
+func printDeployedSerials(w io.Writer, st core.Store, results []core.DeployResult) {
+	accounts, err := st.GetAllAccounts()
+	if err != nil {
+		log.Errorf("could not read deployed serials: %v", err)
+		return
+	}
+	serials := make(map[int]int, len(accounts))
+	for _, acc := range accounts {
+		serials[acc.ID] = acc.Serial
+	}
+	for _, r := range results {
+		if r.Error == nil {
+			_, _ = fmt.Fprintf(w, "%s serial %d\n", r.Account.String(), serials[r.Account.ID])
+		}
+	}
+}
+
+// printSlowest lists the hosts in slow, as picked by
+// core.SlowestDeployResults or core.SlowestAuditResults, with the time each
+// took to verb (e.g. "deploy"). timing extracts the host and its duration.
+func printSlowest[R any](w io.Writer, verb string, slow []R, timing func(R) (model.Account, time.Duration)) {
+	if len(slow) == 0 {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "Slowest %d hosts to %s:\n", len(slow), verb)
+	for _, r := range slow {
+		account, d := timing(r)
+		_, _ = fmt.Fprintf(w, "  %-40s %s\n", account.String(), d.Round(time.Millisecond))
+	}
+}
+
+// deployTiming and auditTiming are the printSlowest timing functions for
+// deploy and audit results.
+func deployTiming(r core.DeployResult) (model.Account, time.Duration) { return r.Account, r.Duration }
+func auditTiming(r core.AuditResult) (model.Account, time.Duration)   { return r.Account, r.Duration }
+
+// printCanaryDeployResult writes the deploy and audit outcome of each stage
+// of a canary rollout.
+func printCanaryDeployResult(w io.Writer, res *core.CanaryDeployResult) {
+	_, _ = fmt.Fprintln(w, "Canary deployment:")
+	printDeployResults(w, res.Canaries)
+	if len(res.CanaryAudit) > 0 {
+		_, _ = fmt.Fprintln(w, "Canary audit:")
+		for _, r := range res.CanaryAudit {
 			if r.Error != nil {
-				fmt.Printf("%s\n", i18n.T("parallel_task.deploy_fail_message", r.Account.String(), r.Error))
+				_, _ = fmt.Fprintf(w, "%s\n", i18n.T("parallel_task.audit_fail_message", r.Account.String(), r.Error))
 			} else {
-				fmt.Printf("%s\n", i18n.T("parallel_task.deploy_success_message", r.Account.String()))
+				_, _ = fmt.Fprintf(w, "%s\n", i18n.T("parallel_task.audit_success_message", r.Account.String()))
 			}
 		}
-	},
+	}
+	if res.Aborted {
+		_, _ = fmt.Fprintln(w, "Rollout aborted; remaining hosts were not touched.")
+		return
+	}
+	if len(res.Rest) > 0 {
+		_, _ = fmt.Fprintln(w, "Remaining hosts:")
+		printDeployResults(w, res.Rest)
+	}
 }
 
 // rotateKeyCmd represents the 'rotate-key' command.
@@ -515,9 +1022,29 @@ var rotateKeyCmd = &cobra.Command{
 	Use:   "rotate-key",
 	Short: "Rotates the active Keymaster system key",
 	Long: `Generates a new ed25519 key pair, saves it to the database, and sets it as the active key.
-The previous key is kept for accessing hosts that have not yet been updated.`,
+The previous key is kept for accessing hosts that have not yet been updated.
+
+Use --stage to add the new key as the active key for deploys while the
+previous keys stay active too, for a rollout spread over time. Deploy the
+hosts, then run 'keymaster rotate-key --finalize': it checks that every
+deployable account was deployed with the newest key and passes a serial audit,
+and only then deactivates the superseded keys.`,
 	PreRunE: setupDefaultServices,
 	Run: func(cmd *cobra.Command, args []string) {
+		if err := requireTOTP("rotate-key"); err != nil {
+			log.Fatalf("%v", err)
+		}
+		stage, _ := cmd.Flags().GetBool("stage")
+		finalize, _ := cmd.Flags().GetBool("finalize")
+		if stage && finalize {
+			log.Fatalf("--stage and --finalize cannot be combined")
+		}
+		if finalize {
+			if err := runRotateKeyFinalize(cmd.Context(), os.Stdout, uiadapters.NewStoreAdapter(), &cliDeployerManager{}); err != nil {
+				log.Fatalf("%v", err)
+			}
+			return
+		}
 		fmt.Println(i18n.T("rotate_key.cli_rotating"))
 		passphrase := password
 		if passphrase == "" {
@@ -533,6 +1060,15 @@ The previous key is kept for accessing hosts that have not yet been updated.`,
 		}
 
 		st := uiadapters.NewStoreAdapter()
+		if stage {
+			serial, err := core.RunStageRotateKeyCmd(cmd.Context(), &cliKeyGenerator{}, st, passphrase)
+			if err != nil {
+				log.Fatalf("%s", i18n.T("rotate_key.cli_error_save", err))
+			}
+			fmt.Printf("Staged system key serial %d; it is used for deploys while the previous keys stay active.\n", serial)
+			fmt.Println("Deploy all hosts, then run 'keymaster rotate-key --finalize' to deactivate the previous keys.")
+			return
+		}
 		serial, err := core.RunRotateKeyCmd(cmd.Context(), &cliKeyGenerator{}, st, passphrase)
 		if err != nil {
 			log.Fatalf("%s", i18n.T("rotate_key.cli_error_save", err))
@@ -550,25 +1086,293 @@ var auditCmd = &cobra.Command{
 	Short: "Audit hosts for configuration drift",
 	Long: `Connects to all active hosts and compares the fully rendered, normalized authorized_keys content against the expected configuration from the database to detect drift.
 
-Use --mode=serial to only verify the Keymaster header serial number on the remote host matches the account's last deployed serial (useful during staged rotations).`,
+Use --mode=serial to only verify the Keymaster header serial number on the remote host matches the account's last deployed serial (useful during staged rotations).
+
+Use --show-drift to list, for each drifted host, the keys found on the host that Keymaster did not deploy (+) and the expected keys that are missing (-).
+
+Use --diff-output <dir> to write, for each drifted host, a user@host.diff file
+with the unified diff from the expected authorized_keys to the one on the
+host, for review before remediating.
+
+Use --remediate to act on drift according to each account's remediation policy (see 'account remediation'): auto redeploys the host, alert reports the drift and ignore suppresses it. Run it from a timer for continuous auditing.
+
+Use --parallel-groups web,db to audit every host tagged web before any host
+tagged db, auditing the hosts within a tag in parallel. Accounts without any
+of the listed tags are audited last. With --fail-fast no further tag is
+audited after one with a failure.
+
+Use --skip-recent 30m to skip hosts whose last audit, within the past 30
+minutes, found them clean. Hosts that failed their last audit are always
+audited again. This spreads the load of frequent audit loops.
+
+Use --only-reachable to check each host's SSH port over plain TCP first (like
+'account ping') and report hosts that do not answer within --ping-timeout as
+unreachable, instead of waiting for their SSH connections to time out.
+Unreachable hosts still count as failed.
+
+Use --account-group web to audit only the members of the account group web.
+
+Use --slowest N to list the N hosts that took longest to audit.
+
+Use --compare-to-backup <file> to skip the hosts entirely and list the
+accounts, keys and key assignments changed in the database since the backup
+was taken.
+
+Use --format junit to print a JUnit XML report instead, with one test case per
+account: drift is a failure, a host that could not be audited is an error.
+
+Use --repair-known-hosts to accept changed host keys of routinely rebuilt hosts:
+when a host matching one of the ssh.auto_retrust_hosts patterns in the config
+presents a new key, it replaces the stored key, is logged as
+HOST_KEY_AUTO_RETRUST and the host is audited over the new connection. Other
+hosts with changed keys still fail, or prompt on a terminal.`,
 	PreRunE: setupDefaultServices,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if backupFile, _ := cmd.Flags().GetString("compare-to-backup"); backupFile != "" {
+			return runAuditCompareToBackup(cmd, backupFile)
+		}
+		failFast, _ := cmd.Flags().GetBool("fail-fast")
+		groups, _ := cmd.Flags().GetStringSlice("parallel-groups")
+		accountGroup, _ := cmd.Flags().GetString("account-group")
+		remediate, _ := cmd.Flags().GetBool("remediate")
+		outputFile, _ := cmd.Flags().GetString("output-file")
+		diffOutput, _ := cmd.Flags().GetString("diff-output")
+		slowest, _ := cmd.Flags().GetInt("slowest")
+		skipRecent, _ := cmd.Flags().GetDuration("skip-recent")
+		onlyReachable, _ := cmd.Flags().GetBool("only-reachable")
+		pingTimeout, _ := cmd.Flags().GetDuration("ping-timeout")
+		format, _ := cmd.Flags().GetString("format")
+		repairKnownHosts, _ := cmd.Flags().GetBool("repair-known-hosts")
+		if skipRecent < 0 {
+			return fmt.Errorf("--skip-recent must not be negative")
+		}
+		if pingTimeout <= 0 {
+			return fmt.Errorf("--ping-timeout must be positive")
+		}
+		if format != "text" && format != "junit" {
+			return fmt.Errorf("unknown audit format %q (want text or junit)", format)
+		}
+		if remediate && !strings.EqualFold(auditMode, "strict") {
+			return fmt.Errorf("--remediate requires --mode=strict")
+		}
+		if diffOutput != "" && !strings.EqualFold(auditMode, "strict") {
+			return fmt.Errorf("--diff-output requires --mode=strict")
+		}
+		installHostKeyChangePrompt()
+		defer core.SetHostKeyChangePrompt(nil)
+		if repairKnownHosts {
+			patterns := appConfig.SSH.AutoRetrustHosts
+			if len(patterns) == 0 {
+				return fmt.Errorf("--repair-known-hosts requires ssh.auto_retrust_hosts in the config")
+			}
+			if err := core.ValidateRetrustPatterns(patterns); err != nil {
+				return fmt.Errorf("ssh.auto_retrust_hosts: %w", err)
+			}
+			core.SetHostKeyChangePrompt(core.AutoRetrustPrompt(patterns, terminalHostKeyChangePrompt()))
+		}
+
 		st := uiadapters.NewStoreAdapter()
 		dm := &cliDeployerManager{}
-		results, err := core.RunAuditCmd(cmd.Context(), st, dm, auditMode, nil)
-		if err != nil {
+		started := time.Now()
+		results, err := core.RunAuditWithOptionsCmd(cmd.Context(), st, dm, auditMode, core.AuditOptions{
+			FailFast:      failFast,
+			Groups:        groups,
+			SkipRecent:    skipRecent,
+			OnlyReachable: onlyReachable,
+			PingTimeout:   pingTimeout,
+			AccountGroup:  accountGroup,
+		}, nil)
+		if err != nil && results == nil {
 			log.Fatalf("%s", i18n.T("audit.cli_error_get_accounts", err))
 		}
-		for _, r := range results {
-			if r.Error != nil {
-				fmt.Printf("%s\n", i18n.T("parallel_task.audit_fail_message", r.Account.String(), r.Error))
-			} else {
-				fmt.Printf("%s\n", i18n.T("parallel_task.audit_success_message", r.Account.String()))
+		// Keep stdout parseable when it carries the XML report.
+		var progress io.Writer = os.Stdout
+		if format == "junit" {
+			progress = os.Stderr
+		}
+		if diffOutput != "" {
+			paths, werr := core.WriteDriftPatches(diffOutput, results)
+			if werr != nil {
+				return werr
+			}
+			if len(paths) > 0 {
+				log.Info("drift patches written", "dir", diffOutput, "count", len(paths))
+			}
+		}
+		if remediate {
+			var outcomes []core.RemediationOutcome
+			results, outcomes = core.ApplyRemediationPolicies(dm, results)
+			printRemediationOutcomes(progress, outcomes)
+		}
+		if outputFile != "" {
+			if werr := writeAuditReportFile(outputFile, auditMode, started, time.Since(started), results); werr != nil {
+				return werr
 			}
 		}
+		var rerr error
+		if format == "junit" {
+			rerr = reportAuditJUnit(cmd, os.Stdout, started, time.Since(started), results, err)
+		} else {
+			rerr = reportAuditResults(cmd, results, err)
+		}
+		printSlowest(progress, "audit", core.SlowestAuditResults(results, slowest), auditTiming)
+		return rerr
 	},
 }
 
+// runAuditCompareToBackup prints the database changes since the backup at
+// path. Changes exit with core.AuditExitDrift like host drift does.
+func runAuditCompareToBackup(cmd *cobra.Command, path string) error {
+	backup, err := core.LoadBackup(path)
+	if err != nil {
+		return fmt.Errorf("load backup: %w", err)
+	}
+	cmp, err := core.CompareToBackup(uiadapters.NewStoreAdapter(), backup)
+	if err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+	if cmp.Empty() {
+		_, _ = fmt.Fprintf(out, "No changes since backup %s\n", path)
+		return nil
+	}
+	_, _ = fmt.Fprintf(out, "Changes since backup %s:\n", path)
+	printBackupComparison(out, cmp)
+	cmd.SilenceUsage = true
+	return &ExitError{Code: core.AuditExitDrift, Err: errors.New("database differs from backup")}
+}
+
+// printBackupComparison writes one line per change: + added since the
+// backup, - removed since the backup, ~ changed settings.
+func printBackupComparison(w io.Writer, cmp *core.BackupComparison) {
+	for _, acc := range cmp.AddedAccounts {
+		_, _ = fmt.Fprintf(w, "+ account %d %s\n", acc.ID, acc.String())
+	}
+	for _, acc := range cmp.RemovedAccounts {
+		_, _ = fmt.Fprintf(w, "- account %d %s\n", acc.ID, acc.String())
+	}
+	for _, ch := range cmp.ChangedAccounts {
+		_, _ = fmt.Fprintf(w, "~ account %d %s: %s\n", ch.Account.ID, ch.Account.String(), strings.Join(ch.Fields, ", "))
+	}
+	for _, k := range cmp.AddedKeys {
+		_, _ = fmt.Fprintf(w, "+ key %d %s\n", k.ID, k.Comment)
+	}
+	for _, k := range cmp.RemovedKeys {
+		_, _ = fmt.Fprintf(w, "- key %d %s\n", k.ID, k.Comment)
+	}
+	for _, ch := range cmp.ChangedKeys {
+		_, _ = fmt.Fprintf(w, "~ key %d %s: %s\n", ch.Key.ID, ch.Key.Comment, strings.Join(ch.Fields, ", "))
+	}
+	for _, a := range cmp.AddedAssignments {
+		_, _ = fmt.Fprintf(w, "+ assignment %s -> %s\n", backupKeyName(a.Key), backupAccountName(a.Account))
+	}
+	for _, a := range cmp.RemovedAssignments {
+		_, _ = fmt.Fprintf(w, "- assignment %s -> %s\n", backupKeyName(a.Key), backupAccountName(a.Account))
+	}
+}
+
+func backupKeyName(k model.PublicKey) string {
+	if k.Comment == "" {
+		return fmt.Sprintf("key %d", k.ID)
+	}
+	return k.Comment
+}
+
+func backupAccountName(acc model.Account) string {
+	if acc.Username == "" {
+		return fmt.Sprintf("account %d", acc.ID)
+	}
+	return acc.String()
+}
+
+// writeAuditReportFile expands pattern for this run and writes the JSON report
+// there. The destination is logged to stderr so stdout stays unchanged.
+func writeAuditReportFile(pattern, mode string, started time.Time, duration time.Duration, results []core.AuditResult) error {
+	path, err := core.ExpandAuditReportPath(pattern, started, mode)
+	if err != nil {
+		return err
+	}
+	if err := core.WriteAuditReport(path, core.NewAuditReport(mode, started, duration, results)); err != nil {
+		return err
+	}
+	log.Info("audit report written", "path", path)
+	return nil
+}
+
+// printRemediationOutcomes writes the action taken for each drifted account.
+func printRemediationOutcomes(w io.Writer, outcomes []core.RemediationOutcome) {
+	for _, o := range outcomes {
+		switch {
+		case o.Err != nil:
+			_, _ = fmt.Fprintf(w, "Remediation failed for %s: %v\n", o.Account.String(), o.Err)
+		case o.Policy == model.RemediationAuto:
+			_, _ = fmt.Fprintf(w, "Remediated drift on %s\n", o.Account.String())
+		case o.Policy == model.RemediationIgnore:
+			_, _ = fmt.Fprintf(w, "Ignored drift on %s\n", o.Account.String())
+		}
+	}
+}
+
+// printDriftAnalysis writes the added and removed keys of a drifted host.
+func printDriftAnalysis(w io.Writer, d model.DriftAnalysis) {
+	for _, line := range d.Added {
+		_, _ = fmt.Fprintf(w, "%s\n", i18n.T("audit.cli_drift_added", line))
+	}
+	for _, line := range d.Removed {
+		_, _ = fmt.Fprintf(w, "%s\n", i18n.T("audit.cli_drift_removed", line))
+	}
+}
+
+// reportAuditResults prints one line per audited account and returns an
+// ExitError carrying core.AuditExitCode when any account failed.
+func reportAuditResults(cmd *cobra.Command, results []core.AuditResult, err error) error {
+	showDrift, _ := cmd.Flags().GetBool("show-drift")
+	for _, r := range results {
+		if r.Error != nil {
+			fmt.Printf("%s\n", i18n.T("parallel_task.audit_fail_message", r.Account.String(), r.Error))
+			if showDrift && r.Drift != nil {
+				printDriftAnalysis(os.Stdout, *r.Drift)
+			}
+		} else {
+			fmt.Printf("%s\n", i18n.T("parallel_task.audit_success_message", r.Account.String()))
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return auditExitError(cmd, results)
+}
+
+// reportAuditJUnit writes results as a JUnit XML report to w and returns the
+// same exit error as reportAuditResults.
+func reportAuditJUnit(cmd *cobra.Command, w io.Writer, started time.Time, duration time.Duration, results []core.AuditResult, err error) error {
+	if werr := core.WriteAuditJUnit(w, auditMode, started, duration, results); werr != nil {
+		return werr
+	}
+	if err != nil {
+		return err
+	}
+	return auditExitError(cmd, results)
+}
+
+// auditExitError returns an ExitError carrying core.AuditExitCode when any
+// account failed, and nil otherwise.
+func auditExitError(cmd *cobra.Command, results []core.AuditResult) error {
+	code := core.AuditExitCode(results)
+	if code == core.AuditExitOK {
+		return nil
+	}
+	failed := 0
+	for _, r := range results {
+		if r.Error != nil {
+			failed++
+		}
+	}
+	// Drift is a result, not a usage error.
+	cmd.SilenceUsage = true
+	return &ExitError{Code: code, Err: errors.New(i18n.T("audit.cli_failed_summary", failed, len(results)))}
+}
+
 // auditCompareCmd compares a local or fetched authorized_keys file against
 // the stored `accounts.key_hash` for a single account.
 var auditCompareCmd = &cobra.Command{
@@ -640,26 +1444,87 @@ var auditCompareCmd = &cobra.Command{
 // It parses a standard authorized_keys file and adds the public keys
 // found within it to the Keymaster database.
 var importCmd = &cobra.Command{
-	Use:     "import [authorized_keys_file]",
-	Short:   "Import public keys from an authorized_keys file",
-	Long:    `Reads a standard authorized_keys file and imports the public keys into the Keymaster database.`,
-	Args:    cobra.ExactArgs(1), // Ensures we get exactly one file path
+	Use:   "import [authorized_keys_file]",
+	Short: "Import public keys from an authorized_keys file or a key source",
+	Long: `Reads a standard authorized_keys file and imports the public keys into the Keymaster database.
+
+Use --source <source>:<identifier> instead of a file to import the keys a
+registered key source returns, e.g. --source github:alice for the keys alice
+publishes on GitHub or --source file:/etc/keys/alice.pub. Built-in sources are
+file and github; further sources can be registered with core.RegisterKeySource.
+
+Keys whose comment is already stored are skipped. With --replace their stored
+algorithm and key data are updated instead, for a key rotated under the same
+comment; the change is written to the audit log and the accounts using the key
+are marked dirty, so the next deploy rolls it out.`,
+	Args:    cobra.MaximumNArgs(1),
 	PreRunE: setupDefaultServices,
 	Run: func(cmd *cobra.Command, args []string) {
-		filePath := args[0]
-		fmt.Println(i18n.T("import.start", filePath))
-		file, err := os.Open(filePath)
-		if err != nil {
-			log.Fatalf("%s", i18n.T("import.error_opening_file", err))
+		source, _ := cmd.Flags().GetString("source")
+		replace, _ := cmd.Flags().GetBool("replace")
+		if (source == "") == (len(args) == 0) {
+			log.Fatalf("specify either an authorized_keys file or --source")
 		}
-		defer func() { _ = file.Close() }()
 
-		km := core.DefaultKeyManager()
+		km := core.KeyManagerFromContext(cmd.Context())
 		rep := &cliReporter{}
-		imported, skipped, ierr := core.RunImportCmd(cmd.Context(), file, km, rep)
+		opts := core.ImportOptions{Replace: replace}
+		var res core.ImportResult
+		var ierr error
+		if source != "" {
+			fmt.Println(i18n.T("import.start", source))
+			res, ierr = core.ImportFromKeySourceWithOptions(cmd.Context(), source, km, rep, opts)
+		} else {
+			filePath := args[0]
+			fmt.Println(i18n.T("import.start", filePath))
+			file, err := os.Open(filePath)
+			if err != nil {
+				log.Fatalf("%s", i18n.T("import.error_opening_file", err))
+			}
+			defer func() { _ = file.Close() }()
+			res, ierr = core.RunImportWithOptionsCmd(cmd.Context(), file, km, rep, opts)
+		}
 		if ierr != nil {
 			log.Fatalf("%s", i18n.T("import.error_adding_key", ierr))
 		}
+		if replace {
+			fmt.Printf("\nImport complete. Imported %d keys, replaced %d, skipped %d.\n", res.Imported, res.Replaced, res.Skipped)
+		} else {
+			fmt.Printf("\nImport complete. Imported %d keys, skipped %d.\n", res.Imported, res.Skipped)
+		}
+	},
+}
+
+// importRemoteCmd represents the 'import-remote' command.
+// It reads the authorized_keys file of an existing account's host and
+// imports the keys Keymaster does not manage yet, leaving out the system key.
+var importRemoteCmd = &cobra.Command{
+	Use:   "import-remote <account-identifier>",
+	Short: "Import public keys from a host's authorized_keys",
+	Long: `Connects to the host of an account, fetches its current authorized_keys
+file and imports the public keys into the Keymaster database. The Keymaster
+system key is skipped.`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: setupDefaultServices,
+	Run: func(cmd *cobra.Command, args []string) {
+		st := uiadapters.NewStoreAdapter()
+		accounts, err := st.GetAllAccounts()
+		if err != nil {
+			log.Fatalf("error fetching accounts: %v", err)
+		}
+		account, err := core.FindAccountByIdentifier(args[0], accounts)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+
+		fmt.Println(i18n.T("import.start", account.String()))
+		imported, skipped, warning, ierr := core.RunImportRemoteCmd(cmd.Context(), *account, &cliDeployerManager{}, core.KeyManagerFromContext(cmd.Context()), &cliReporter{})
+		if ierr != nil {
+			log.Fatalf("import from %s failed: %v", account.String(), ierr)
+		}
+		if warning != "" {
+			fmt.Println(warning)
+		}
 		fmt.Printf("\nImport complete. Imported %d keys, skipped %d.\n", imported, skipped)
 	},
 }
@@ -677,10 +1542,27 @@ var trustHostCmd = &cobra.Command{
 	Short: "Adds a host's public key to the list of known hosts",
 	Long: `Connects to a host for the first time, retrieves its public key,
 and prompts the user to save it to the database. This is a required
-step before Keymaster can manage a new host.`,
-	Args:    cobra.ExactArgs(1),
+step before Keymaster can manage a new host.
+
+After an environment is reimaged, --retrust --tag <tag> re-establishes trust
+for all hosts of the accounts carrying the tag in one go: each host's current
+key is fetched, the changed and new fingerprints are summarized, and after
+confirmation (or with --yes) all of them are saved. Hosts whose key did not
+change are left alone.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if retrust, _ := cmd.Flags().GetBool("retrust"); retrust {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
 	PreRunE: setupDefaultServices,
 	Run: func(cmd *cobra.Command, args []string) {
+		if retrust, _ := cmd.Flags().GetBool("retrust"); retrust {
+			if err := runHostRetrust(cmd, core.DefaultDeployerManager, uiadapters.NewStoreAdapter()); err != nil {
+				log.Fatalf("%v", err)
+			}
+			return
+		}
 		target := args[0]
 		var hostname string
 		if strings.Contains(target, "@") {
@@ -723,6 +1605,84 @@ step before Keymaster can manage a new host.`,
 	},
 }
 
+// retrustStore is the store surface used by trust-host --retrust.
+type retrustStore interface {
+	core.KnownHostStore
+	GetAllActiveAccounts() ([]model.Account, error)
+}
+
+// runHostRetrust fetches the current host key of every account selected by
+// --tag, summarizes the changes and, once confirmed, saves the new keys.
+func runHostRetrust(cmd *cobra.Command, dm core.HostKeyFetcher, st retrustStore) error {
+	tag, _ := cmd.Flags().GetString("tag")
+	if tag == "" {
+		return fmt.Errorf("--retrust requires --tag")
+	}
+	accounts, err := st.GetAllActiveAccounts()
+	if err != nil {
+		return fmt.Errorf("failed to get accounts: %w", err)
+	}
+	targets := core.BuildAccountsByTag(accounts)[tag]
+	out := cmd.OutOrStdout()
+	if len(targets) == 0 {
+		_, _ = fmt.Fprintf(out, "No active accounts with tag '%s'.\n", tag)
+		return nil
+	}
+
+	checks := core.CheckHostKeys(cmd.Context(), dm, st, targets, 0)
+	pending := printHostKeyChecks(out, checks)
+	if pending == 0 {
+		_, _ = fmt.Fprintln(out, "No host keys to update.")
+		return nil
+	}
+	if yes, _ := cmd.Flags().GetBool("yes"); !yes {
+		ans := promptForConfirmation(fmt.Sprintf("Trust the new keys of %d host(s) (yes/no)? ", pending))
+		if ans != "yes" && ans != "y" {
+			_, _ = fmt.Fprintln(out, "Cancelled.")
+			return nil
+		}
+	}
+	n, err := core.RetrustHostKeys(st, checks)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(out, "Updated the known host key of %d host(s).\n", n)
+	return nil
+}
+
+// printHostKeyChecks writes one row per checked host with the fingerprint of
+// the key it presented, and returns how many hosts need their key saved.
+func printHostKeyChecks(w io.Writer, checks []core.HostKeyCheck) int {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "HOST\tSTATUS\tOLD FINGERPRINT\tNEW FINGERPRINT")
+	pending := 0
+	for _, c := range checks {
+		if c.NeedsTrust() {
+			pending++
+		}
+		if c.Err != nil {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%v\n", c.Host, c.Status, hostKeyFingerprint(c.StoredKey), c.Err)
+			continue
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", c.Host, c.Status, hostKeyFingerprint(c.StoredKey), hostKeyFingerprint(c.RemoteKey))
+	}
+	_ = tw.Flush()
+	return pending
+}
+
+// hostKeyFingerprint returns the SHA256 fingerprint of an authorized_keys
+// formatted host key, or "-" when there is none.
+func hostKeyFingerprint(key string) string {
+	if key == "" {
+		return "-"
+	}
+	pubKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key))
+	if err != nil {
+		return "unparseable"
+	}
+	return ssh.FingerprintSHA256(pubKey)
+}
+
 // runParallelTasks executes a given task concurrently for a list of accounts.
 // It uses a wait group to manage goroutines and a channel to collect results,
 // printing status messages as tasks complete.
@@ -736,12 +1696,61 @@ var exportSSHConfigCmd = &cobra.Command{
 	Short: "Export SSH config from active accounts",
 	Long: `Generates an SSH config file with Host entries for all active accounts.
 If no output file is specified, prints to stdout.
-Each account with a label will use the label as the Host alias.`,
+Each account with a label will use the label as the Host alias.
+Accounts tagged 'proxyjump:<host>' get a ProxyJump directive through that bastion.
+
+With --format include, the output file becomes a stub containing a single
+Include directive and the Host entries are written to one file per account
+tag inside a keymaster.d directory next to it. The Include uses the absolute
+path of that directory, because ssh resolves relative paths against ~/.ssh.
+
+Accounts sharing a label would get the same Host alias, and ssh only uses the
+first. Such collisions are reported as a warning; with --dedupe-aliases every
+later account gets a suffixed alias instead (web, web-2, web-3).`,
 	Args:    cobra.MaximumNArgs(1),
 	PreRunE: setupDefaultServices,
 	Run: func(cmd *cobra.Command, args []string) {
+		format, _ := cmd.Flags().GetString("format")
+		identityFile, _ := cmd.Flags().GetString("identity-file")
+		strictHostKeyChecking, _ := cmd.Flags().GetString("strict-host-key-checking")
+		dedupeAliases, _ := cmd.Flags().GetBool("dedupe-aliases")
+		opts := core.SSHConfigOptions{IdentityFile: identityFile, StrictHostKeyChecking: strictHostKeyChecking, DedupeAliases: dedupeAliases}
+		if err := opts.Validate(); err != nil {
+			log.Fatalf("%v", err)
+		}
 		st := uiadapters.NewStoreAdapter()
-		out, err := core.RunExportSSHConfigCmd(cmd.Context(), st)
+		if !dedupeAliases {
+			warnDuplicateSSHConfigAliases(st)
+		}
+		switch format {
+		case core.SSHConfigFormatFlat:
+		case core.SSHConfigFormatInclude:
+			if len(args) == 0 {
+				log.Fatalf("%s", i18n.T("export_ssh_config.error_include_needs_file"))
+			}
+			includeDir, err := sshConfigIncludeDir(args[0])
+			if err != nil {
+				log.Fatalf("%s", i18n.T("export_ssh_config.error_write_file", err))
+			}
+			opts.IncludeDir = includeDir
+			split, err := core.RunExportSSHConfigSplitCmd(cmd.Context(), st, opts)
+			if err != nil {
+				log.Fatalf("%s", i18n.T("export_ssh_config.error_get_accounts", err))
+			}
+			if split == nil {
+				fmt.Println(i18n.T("export_ssh_config.no_accounts"))
+				return
+			}
+			if err := writeSSHConfigSplit(args[0], includeDir, split); err != nil {
+				log.Fatalf("%s", i18n.T("export_ssh_config.error_write_file", err))
+			}
+			fmt.Printf("%s\n", i18n.T("export_ssh_config.success", args[0]))
+			return
+		default:
+			log.Fatalf("%s", i18n.T("export_ssh_config.error_unknown_format", format))
+		}
+
+		out, err := core.RunExportSSHConfigCmd(cmd.Context(), st, opts)
 		if err != nil {
 			log.Fatalf("%s", i18n.T("export_ssh_config.error_get_accounts", err))
 		}
@@ -761,47 +1770,156 @@ Each account with a label will use the label as the Host alias.`,
 	},
 }
 
+// warnDuplicateSSHConfigAliases logs a warning naming the Host aliases that
+// several active accounts would be exported under.
+func warnDuplicateSSHConfigAliases(st core.Store) {
+	accounts, err := st.GetAllActiveAccounts()
+	if err != nil {
+		return
+	}
+	if dups := core.DuplicateSSHConfigAliases(accounts); len(dups) > 0 {
+		log.Warn("several accounts share a Host alias; ssh only uses the first (use --dedupe-aliases or 'account set-label')",
+			"aliases", strings.Join(dups, ", "))
+	}
+}
+
+// sshConfigIncludeDir returns the absolute path of the keymaster.d directory
+// next to stubPath.
+func sshConfigIncludeDir(stubPath string) (string, error) {
+	absStub, err := filepath.Abs(stubPath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(absStub), core.SSHConfigIncludeDir), nil
+}
+
+// writeSSHConfigSplit writes the Include stub to stubPath and the per-tag
+// files into includeDir. Stale *.conf files left in that directory by a
+// previous export are removed so deleted tags disappear.
+func writeSSHConfigSplit(stubPath, includeDir string, split *core.SSHConfigSplit) error {
+	if err := os.MkdirAll(includeDir, 0755); err != nil {
+		return err
+	}
+	stale, err := filepath.Glob(filepath.Join(includeDir, "*.conf"))
+	if err != nil {
+		return err
+	}
+	for _, path := range stale {
+		if _, keep := split.Files[filepath.Base(path)]; !keep {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+		}
+	}
+	for _, name := range split.FileNames() {
+		if err := os.WriteFile(filepath.Join(includeDir, name), []byte(split.Files[name]), 0644); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(stubPath, []byte(split.Stub), 0644)
+}
+
 // dbMaintainCmd runs database maintenance tasks for the configured database.
 var dbMaintainCmd = &cobra.Command{
-	Use:     "db-maintain",
-	Short:   "Run database maintenance (VACUUM/OPTIMIZE) for the configured DB",
-	Long:    `Runs engine-specific maintenance tasks (VACUUM, OPTIMIZE TABLE, PRAGMA optimize).`,
+	Use:   "db-maintain",
+	Short: "Run database maintenance (VACUUM/OPTIMIZE) for the configured DB",
+	Long: `Runs engine-specific maintenance tasks (VACUUM, OPTIMIZE TABLE, PRAGMA optimize).
+
+With --analyze, maintenance is followed by a report of row counts and
+table/index sizes per table. On SQLite this also runs ANALYZE to refresh the
+query planner statistics.`,
 	PreRunE: setupDefaultServices,
 	Run: func(cmd *cobra.Command, args []string) {
 		skipIntegrity, _ := cmd.Flags().GetBool("skip-integrity")
 		timeoutSec, _ := cmd.Flags().GetInt("timeout")
+		analyze, _ := cmd.Flags().GetBool("analyze")
 		dsn := appConfig.Database.Dsn
 		dbType := appConfig.Database.Type
 		if skipIntegrity {
 			fmt.Println("Skipping integrity_check may speed up maintenance on large databases")
 		}
 		maint := &cliDBMaintainer{}
+		opts := core.DBMaintenanceOptions{SkipIntegrity: skipIntegrity, Analyze: analyze}
 		if timeoutSec > 0 {
-			done := make(chan error, 1)
-			go func() {
-				done <- core.RunDBMaintenance(cmd.Context(), maint, dbType, dsn, core.DBMaintenanceOptions{SkipIntegrity: skipIntegrity, Timeout: time.Duration(timeoutSec) * time.Second})
-			}()
-			select {
-			case err := <-done:
-				if err != nil {
-					fmt.Printf("Maintenance failed: %v\n", err)
-					os.Exit(1)
-				}
-				fmt.Println("Maintenance completed successfully")
-			case <-time.After(time.Duration(timeoutSec) * time.Second):
-				fmt.Println("Maintenance timed out")
-				os.Exit(2)
-			}
-			return
+			opts.Timeout = time.Duration(timeoutSec) * time.Second
+		}
+		stats, err := core.RunDBMaintenanceWithStats(cmd.Context(), maint, dbType, dsn, opts)
+		if errors.Is(err, context.DeadlineExceeded) {
+			fmt.Println("Maintenance timed out")
+			os.Exit(2)
 		}
-		if err := core.RunDBMaintenance(cmd.Context(), maint, dbType, dsn, core.DBMaintenanceOptions{SkipIntegrity: skipIntegrity}); err != nil {
+		if err != nil {
 			fmt.Printf("Maintenance failed: %v\n", err)
 			os.Exit(1)
 		}
 		fmt.Println("Maintenance completed successfully")
+		if analyze {
+			printTableStats(cmd.OutOrStdout(), stats)
+		}
 	},
 }
 
+// printTableStats writes the db-maintain --analyze report as a table.
+func printTableStats(out io.Writer, stats []model.TableStats) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "TABLE\tROWS\tTABLE SIZE\tINDEXES\tINDEX SIZE")
+	var rows, tableBytes, indexBytes int64
+	for _, s := range stats {
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%s\t%d\t%s\n", s.Table, s.Rows, formatStatBytes(s.TableBytes), s.Indexes, formatStatBytes(s.IndexBytes))
+		rows += s.Rows
+		tableBytes += max(s.TableBytes, 0)
+		indexBytes += max(s.IndexBytes, 0)
+	}
+	_, _ = fmt.Fprintf(w, "TOTAL\t%d\t%s\t\t%s\n", rows, formatStatBytes(tableBytes), formatStatBytes(indexBytes))
+	_ = w.Flush()
+}
+
+// formatStatBytes renders a byte size with a binary unit; negative sizes
+// are unknown.
+func formatStatBytes(n int64) string {
+	if n < 0 {
+		return "n/a"
+	}
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// installHostKeyChangePrompt lets interactive deploy and audit runs accept a
+// changed host key, mirroring trust-host. Without a terminal on stdin no
+// prompt is installed and host key changes keep failing.
+func installHostKeyChangePrompt() {
+	core.SetHostKeyChangePrompt(terminalHostKeyChangePrompt())
+}
+
+// terminalHostKeyChangePrompt returns hostKeyChangePrompt when stdin is a
+// terminal and nil otherwise.
+func terminalHostKeyChangePrompt() core.HostKeyChangePrompt {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return nil
+	}
+	return hostKeyChangePrompt
+}
+
+// hostKeyChangePrompt shows the new fingerprint for host and asks whether to
+// replace the stored key.
+func hostKeyChangePrompt(host, presentedKey string) bool {
+	fmt.Printf("WARNING: the host key for '%s' has changed.\n", host)
+	if pubKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(presentedKey)); err == nil {
+		fmt.Printf("New key fingerprint: %s\n", ssh.FingerprintSHA256(pubKey))
+	}
+	fmt.Println("This could be a man-in-the-middle attack, or the host was reinstalled.")
+	ans := promptForConfirmation("Replace the stored host key and retry (yes/no)? ")
+	return ans == "yes" || ans == "y"
+}
+
 // promptForConfirmation displays a prompt and reads a line from stdin.
 func promptForConfirmation(prompt string) string {
 	fmt.Print(prompt)
@@ -840,9 +1958,14 @@ Account can be identified by:
 - User@host format (e.g., "deploy@server-01")
 - Label (e.g., "prod-web-01")
 
-If no account is specified, you will be prompted to select from a list.
+If no account is specified and the terminal is interactive, a picker lets
+you type to filter accounts by username, hostname, label or tags. Without a
+terminal an identifier is required.
+
+Use --tag to decommission all accounts with specific tags (e.g., --tag env:staging).
 
-Use --tag to decommission all accounts with specific tags (e.g., --tag env:staging).`,
+For unattended runs, --confirm <count> replaces the prompt: the count must
+equal the number of selected accounts, otherwise nothing is touched.`,
 	Args:    cobra.MaximumNArgs(1),
 	PreRunE: setupDefaultServices,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -853,6 +1976,11 @@ Use --tag to decommission all accounts with specific tags (e.g., --tag env:stagi
 		force, _ := cmd.Flags().GetBool("force")
 		dryRun, _ := cmd.Flags().GetBool("dry-run")
 		tagFilter, _ := cmd.Flags().GetString("tag")
+		if !dryRun {
+			if err := requireTOTP("decommission"); err != nil {
+				log.Fatalf("%v", err)
+			}
+		}
 
 		options := core.DecommissionOptions{
 			SkipRemoteCleanup: skipRemote,
@@ -907,37 +2035,26 @@ Use --tag to decommission all accounts with specific tags (e.g., --tag env:stagi
 			targetAccounts = []model.Account{*account}
 			fmt.Printf("Selected account: %s\n", account.String())
 		} else {
-			// No specific target - show interactive selection
-			fmt.Println("Available accounts:")
-			for i, acc := range allAccounts {
-				status := "active"
-				if !acc.IsActive {
-					status = "inactive"
-				}
-				fmt.Printf("  %d: %s (%s)\n", i+1, acc.String(), status)
-			}
-			fmt.Print("Enter account number to decommission (or 'q' to quit): ")
-
-			reader := bufio.NewReader(os.Stdin)
-			input, _ := reader.ReadString('\n')
-			input = strings.TrimSpace(input)
-
-			if input == "q" || input == "quit" {
+			// No specific target - let the operator pick one
+			account, err := selectAccountInteractively(allAccounts)
+			if errors.Is(err, errAccountPickerCancelled) {
 				fmt.Println("Cancelled.")
 				return
 			}
-
-			var selection int
-			if _, err := fmt.Sscanf(input, "%d", &selection); err != nil || selection < 1 || selection > len(allAccounts) {
-				log.Fatal("Invalid selection")
+			if err != nil {
+				log.Fatalf("Error selecting account: %v", err)
 			}
+			targetAccounts = []model.Account{*account}
+			fmt.Printf("Selected account: %s\n", account.String())
+		}
 
-			targetAccounts = []model.Account{allAccounts[selection-1]}
-			fmt.Printf("Selected account: %s\n", allAccounts[selection-1].String())
+		confirmed, cerr := checkDecommissionConfirm(cmd, len(targetAccounts))
+		if cerr != nil {
+			log.Fatalf("Decommission aborted: %v", cerr)
 		}
 
-		// Confirmation prompt (unless dry-run)
-		if !dryRun && !force {
+		// Confirmation prompt (unless dry-run or confirmed by token)
+		if !dryRun && !force && !confirmed {
 			fmt.Printf("\nWARNING: This will decommission %d account(s) by:\n", len(targetAccounts))
 			if !skipRemote {
 				if keepFile {
@@ -971,6 +2088,16 @@ Use --tag to decommission all accounts with specific tags (e.g., --tag env:stagi
 
 // helper: account identification is delegated to core.FindAccountByIdentifier
 
+// checkDecommissionConfirm validates --confirm against the number of selected
+// accounts. It reports whether a token was given; a mismatch is an error.
+func checkDecommissionConfirm(cmd *cobra.Command, affected int) (bool, error) {
+	if !cmd.Flags().Changed("confirm") {
+		return false, nil
+	}
+	token, _ := cmd.Flags().GetString("confirm")
+	return true, core.CheckConfirmToken(token, affected)
+}
+
 // restoreCmd represents the 'restore' command.
 // It restores the database from a compressed JSON backup file.
 var restoreCmd = &cobra.Command{
@@ -994,39 +2121,92 @@ Example (Full Restore):
 	PreRunE: setupDefaultServices, // This was correct, just confirming.
 	Run: func(cmd *cobra.Command, args []string) {
 		inputFile := args[0]
+		if fullRestore {
+			if err := requireTOTP("full restore"); err != nil {
+				log.Fatalf("%v", err)
+			}
+		}
 		fmt.Println(i18n.T("restore.cli_starting", inputFile))
 		f, err := os.Open(inputFile)
 		if err != nil {
 			log.Fatalf("%s", i18n.T("restore.cli_error_read", err))
 		}
 		defer func() { _ = f.Close() }()
-		if err := core.RunRestoreCmd(cmd.Context(), f, core.RestoreOptions{Full: fullRestore}, uiadapters.NewStoreAdapter()); err != nil {
+		summary, err := core.RunRestoreCmd(cmd.Context(), f, core.RestoreOptions{Full: fullRestore}, uiadapters.NewStoreAdapter())
+		if err != nil {
 			log.Fatalf("%s", i18n.T("restore.cli_error_import", err))
 		}
+		if summary != nil {
+			printRestoreSummary(summary)
+		}
 		fmt.Println(i18n.T("restore.cli_success"))
 	},
 }
 
-// readCompressedBackup handles reading and decoding a zstd-compressed JSON backup file.
+// printRestoreSummary prints per-table import and skip counts of an
+// integrating restore.
+func printRestoreSummary(s *model.RestoreSummary) {
+	fmt.Println(i18n.T("restore.cli_summary_row", "accounts", s.Accounts.Imported, s.Accounts.Skipped))
+	fmt.Println(i18n.T("restore.cli_summary_row", "public_keys", s.PublicKeys.Imported, s.PublicKeys.Skipped))
+	fmt.Println(i18n.T("restore.cli_summary_row", "account_keys", s.AccountKeys.Imported, s.AccountKeys.Skipped))
+	if n := s.Skipped(); n > 0 {
+		fmt.Println(i18n.T("restore.cli_summary_skipped", n))
+	}
+}
+
+// verifyBackupCmd checks that a backup file can be restored.
+var verifyBackupCmd = &cobra.Command{
+	Use:   "verify-backup <backup-file.zst>",
+	Short: "Check that a backup can be restored, without touching the database",
+	Long: `Reads a backup file, validates it (schema version, duplicate IDs and
+references between accounts, keys and system keys) and restores it into a
+throwaway in-memory SQLite database. The restored data is compared with the
+backup table by table. The configured database is never opened.
+
+Exits non-zero with the reason when the backup cannot be restored.
+
+Example:
+  keymaster verify-backup ./keymaster-backup-2025-10-26.json.zst`,
+	Args: cobra.ExactArgs(1),
+	// Like completion, skip the root PersistentPreRunE: verifying a backup
+	// must not depend on or touch the configured database.
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		out := cmd.OutOrStdout()
+		data, err := readCompressedBackup(args[0])
+		if err != nil {
+			return &ExitError{Code: 1, Err: fmt.Errorf("cannot read backup: %w", err)}
+		}
+		digests, err := core.VerifyBackupRestore(cmd.Context(), &cliStoreFactory{}, data)
+		var verr *core.BackupValidationError
+		if errors.As(err, &verr) {
+			for _, p := range verr.Problems {
+				_, _ = fmt.Fprintf(out, "Problem: %s\n", p)
+			}
+			return &ExitError{Code: 1, Err: fmt.Errorf("backup %s is not restorable: %d problem(s) found", args[0], len(verr.Problems))}
+		}
+		if err != nil {
+			return &ExitError{Code: 1, Err: fmt.Errorf("backup %s is not restorable: %w", args[0], err)}
+		}
+		for _, d := range digests {
+			if data.IncludesTable(d.Table) {
+				_, _ = fmt.Fprintf(out, "Restored %s: %d rows\n", d.Table, d.Rows)
+			}
+		}
+		_, _ = fmt.Fprintf(out, "Backup %s is restorable (schema version %d).\n", args[0], data.SchemaVersion)
+		return nil
+	},
+}
+
+// readCompressedBackup reads a backup file, detecting zstd or plain JSON.
 func readCompressedBackup(filename string) (*model.BackupData, error) {
 	file, err := os.Open(filename)
 	if err != nil {
 		return nil, fmt.Errorf("could not open file: %w", err)
 	}
 	defer func() { _ = file.Close() }()
-
-	zstdReader, err := zstd.NewReader(file)
-	if err != nil {
-		return nil, fmt.Errorf("could not create zstd reader: %w", err)
-	}
-	defer zstdReader.Close()
-
-	var backupData model.BackupData
-	if err := json.NewDecoder(zstdReader).Decode(&backupData); err != nil {
-		return nil, fmt.Errorf("could not decode json from zstd reader: %w", err)
-	}
-
-	return &backupData, nil
+	return core.ReadBackup(file)
 }
 
 // backupCmd represents the 'backup' command.
@@ -1039,6 +2219,10 @@ into a single, Zstandard-compressed JSON file.
 
 If an output file is specified, '.zst' will be appended to the name if it's not already present.
 If no output file is specified, a default filename 'keymaster-backup-YYYY-MM-DD.json.zst' is used.
+Use --compression fast|default|best to trade speed for size, or --no-compress
+to write plain JSON (no '.zst' suffix is added). Restore detects the format automatically.
+Rows are streamed to the file as they are read, so large audit logs do not
+need to fit in memory.
 
 This file can be used for disaster recovery or for migrating to a different database backend.
 
@@ -1047,31 +2231,50 @@ Examples:
   keymaster backup
 
   # Backup to a specific file
-  keymaster backup my-backup.json`, // .zst will be appended
+  keymaster backup my-backup.json
+
+  # Backup only accounts and keys, leaving out the audit log
+  keymaster backup --tables accounts,public_keys,account_keys`, // .zst will be appended
 	Args:    cobra.MaximumNArgs(1),
 	PreRunE: setupDefaultServices,
 	Run: func(cmd *cobra.Command, args []string) {
+		compression, _ := cmd.Flags().GetString("compression")
+		if noCompress, _ := cmd.Flags().GetBool("no-compress"); noCompress {
+			compression = core.BackupCompressionNone
+		}
+		if err := core.ValidateBackupCompression(compression); err != nil {
+			log.Fatalf("%s", i18n.T("backup.cli_error_write", err))
+		}
 		var outputFile string
 		if len(args) == 0 {
-			outputFile = fmt.Sprintf("keymaster-backup-%s.json.zst", time.Now().Format("2006-01-02"))
+			outputFile = fmt.Sprintf("keymaster-backup-%s.json", time.Now().Format("2006-01-02"))
+			if compression != core.BackupCompressionNone {
+				outputFile += ".zst"
+			}
 		} else {
 			outputFile = args[0]
-			if !strings.HasSuffix(outputFile, ".zst") {
+			if compression != core.BackupCompressionNone && !strings.HasSuffix(outputFile, ".zst") {
 				outputFile += ".zst"
 			}
 		}
+		tables, _ := cmd.Flags().GetStringSlice("tables")
 		fmt.Println(i18n.T("backup.cli_starting"))
 		st := uiadapters.NewStoreAdapter()
-		data, err := core.RunBackupCmd(cmd.Context(), st)
-		if err != nil {
+		if err := model.ValidateBackupTables(tables); err != nil {
 			log.Fatalf("%s", i18n.T("backup.cli_error_export", err))
 		}
 		outf, err := os.Create(outputFile)
 		if err != nil {
 			log.Fatalf("%s", i18n.T("backup.cli_error_write", err))
 		}
-		defer func() { _ = outf.Close() }()
-		if err := core.RunWriteBackupCmd(cmd.Context(), data, outf); err != nil {
+		// Rows are streamed straight into the file, so a failed export
+		// leaves a truncated backup behind; remove it.
+		if err := core.RunWriteBackupStreamCmd(cmd.Context(), st, core.BackupOptions{Tables: tables}, outf, core.WriteBackupOptions{Compression: compression}); err != nil {
+			_ = outf.Close()
+			_ = os.Remove(outputFile)
+			log.Fatalf("%s", i18n.T("backup.cli_error_write", err))
+		}
+		if err := outf.Close(); err != nil {
 			log.Fatalf("%s", i18n.T("backup.cli_error_write", err))
 		}
 		fmt.Println(i18n.T("backup.cli_success", outputFile))
@@ -1117,6 +2320,10 @@ This command automates the following steps:
 3. Applies all necessary database schema migrations to the target.
 4. Performs a full, destructive restore into the target database.
 
+With --verify, both databases are exported again afterwards and every table's
+row count and checksum is compared. Any difference is reported and the command
+exits non-zero.
+
 Example:
   keymaster migrate --type postgres --dsn "host=localhost user=keymaster dbname=keymaster"`,
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -1129,9 +2336,19 @@ Example:
 		fmt.Println(i18n.T("migrate.cli_starting_backup"))
 		st := uiadapters.NewStoreAdapter()
 		factory := &cliStoreFactory{}
-		if err := core.RunMigrateCmd(cmd.Context(), factory, st, targetType, targetDsn); err != nil {
+		verify, _ := cmd.Flags().GetBool("verify")
+		digests, err := core.RunMigrateWithOptionsCmd(cmd.Context(), factory, st, targetType, targetDsn, core.MigrateOptions{Verify: verify})
+		var verr *core.MigrationVerifyError
+		if errors.As(err, &verr) {
+			printMigrationMismatches(os.Stdout, verr.Mismatches)
+			log.Fatalf("%v", err)
+		}
+		if err != nil {
 			log.Fatalf("%s", i18n.T("migrate.cli_error_backup", err))
 		}
+		if verify {
+			printMigrationDigests(os.Stdout, digests)
+		}
 		fmt.Println(i18n.T("migrate.cli_success"))
 		fmt.Println(i18n.T("migrate.cli_next_steps"))
 		return nil
@@ -1139,3 +2356,27 @@ Example:
 }
 
 // migration target initialization is handled by `core.RunMigrateCmd` and `cliStoreFactory`.
+
+// printMigrationDigests lists the verified row count of every table.
+func printMigrationDigests(w io.Writer, digests []core.TableDigest) {
+	for _, d := range digests {
+		_, _ = fmt.Fprintf(w, "Verified %s: %d rows\n", d.Table, d.Rows)
+	}
+}
+
+// printMigrationMismatches lists every table that differs between source
+// and target after a verified migration.
+func printMigrationMismatches(w io.Writer, mismatches []core.MigrationMismatch) {
+	for _, m := range mismatches {
+		_, _ = fmt.Fprintf(w, "Mismatch in %s: source %d rows (%s), target %d rows (%s)\n",
+			m.Table, m.Source.Rows, shortChecksum(m.Source.Checksum), m.Target.Rows, shortChecksum(m.Target.Checksum))
+	}
+}
+
+// shortChecksum abbreviates a hex checksum for display.
+func shortChecksum(sum string) string {
+	if len(sum) > 12 {
+		return sum[:12]
+	}
+	return sum
+}
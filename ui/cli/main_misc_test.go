@@ -4,12 +4,21 @@
 package cli
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/klauspost/compress/zstd"
 	"github.com/toeirei/keymaster/core"
 	"github.com/toeirei/keymaster/core/model"
+	"github.com/toeirei/keymaster/ui/i18n"
+	"github.com/toeirei/keymaster/uiadapters"
 )
 
 func TestFindAccountByIdentifier_ID_UserAtHost_Label_And_NotFound(t *testing.T) {
@@ -83,3 +92,243 @@ func TestWriteAndReadCompressedBackup_RoundTrip(t *testing.T) {
 		t.Fatalf("unexpected backup roundtrip result: %+v", got)
 	}
 }
+
+func TestReadCompressedBackup_PlainJSON(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "backup.json")
+	if err := os.WriteFile(name, []byte(`{"schema_version": 2, "accounts": [{"ID": 1, "Username": "u"}]}`), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	got, err := readCompressedBackup(name)
+	if err != nil {
+		t.Fatalf("readCompressedBackup failed: %v", err)
+	}
+	if got.SchemaVersion != 2 || len(got.Accounts) != 1 {
+		t.Fatalf("unexpected plain JSON result: %+v", got)
+	}
+}
+
+func TestLogFormatFlag(t *testing.T) {
+	setupTestDB(t)
+	t.Cleanup(func() {
+		logFormat = ""
+		_ = core.ConfigureLogger("", false)
+	})
+
+	_ = executeCommand(t, nil, "--log-format", "json", "account", "list")
+	if appConfig.Log.Format != "json" {
+		t.Fatalf("expected log format json, got %q", appConfig.Log.Format)
+	}
+
+	root := NewRootCmd()
+	root.SetArgs([]string{"--log-format", "xml", "account", "list"})
+	root.SetOut(io.Discard)
+	root.SetErr(io.Discard)
+	if err := root.Execute(); err == nil || !strings.Contains(err.Error(), "unknown log format") {
+		t.Fatalf("expected unknown log format error, got %v", err)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	if got := ExitCode(nil); got != 0 {
+		t.Fatalf("nil: expected 0, got %d", got)
+	}
+	if got := ExitCode(errors.New("x")); got != 1 {
+		t.Fatalf("plain error: expected 1, got %d", got)
+	}
+	wrapped := fmt.Errorf("wrap: %w", &ExitError{Code: 3, Err: errors.New("x")})
+	if got := ExitCode(wrapped); got != 3 {
+		t.Fatalf("ExitError: expected 3, got %d", got)
+	}
+}
+
+func TestReportAuditResults_ExitCodes(t *testing.T) {
+	pass := core.AuditResult{Account: model.Account{ID: 1, Username: "a", Hostname: "h"}}
+	drift := core.AuditResult{Account: model.Account{ID: 2, Username: "b", Hostname: "h"}, Error: errors.New("drift")}
+
+	if err := reportAuditResults(auditCmd, []core.AuditResult{pass}, nil); ExitCode(err) != core.AuditExitOK {
+		t.Fatalf("all pass: expected exit 0, got %v", err)
+	}
+	err := reportAuditResults(auditCmd, []core.AuditResult{pass, drift}, nil)
+	if ExitCode(err) != core.AuditExitDrift {
+		t.Fatalf("drift: expected exit %d, got %v", core.AuditExitDrift, err)
+	}
+}
+
+func TestReportAuditJUnit(t *testing.T) {
+	pass := core.AuditResult{Account: model.Account{ID: 1, Username: "a", Hostname: "h1"}}
+	broken := core.AuditResult{Account: model.Account{ID: 2, Username: "b", Hostname: "h2"}, Error: errors.New("connection refused")}
+
+	var buf strings.Builder
+	err := reportAuditJUnit(auditCmd, &buf, time.Now(), time.Second, []core.AuditResult{pass, broken}, nil)
+	if ExitCode(err) != core.AuditExitDrift {
+		t.Fatalf("expected exit %d, got %v", core.AuditExitDrift, err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, `<testsuites name="keymaster" tests="2" failures="0" errors="1"`) {
+		t.Fatalf("unexpected junit report:\n%s", out)
+	}
+	if !strings.Contains(out, `<error message="connection refused" type="error">`) {
+		t.Fatalf("expected connection error element:\n%s", out)
+	}
+}
+
+func TestWriteAuditReportFile_ExpandsDate(t *testing.T) {
+	dir := t.TempDir()
+	started := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	results := []core.AuditResult{{Account: model.Account{ID: 7, Username: "a", Hostname: "h"}}}
+
+	if err := writeAuditReportFile(filepath.Join(dir, "report-{{.Date}}.json"), "serial", started, time.Second, results); err != nil {
+		t.Fatalf("writeAuditReportFile: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "report-2026-05-01.json"))
+	if err != nil {
+		t.Fatalf("expected expanded report path: %v", err)
+	}
+	if !strings.Contains(string(data), `"mode": "serial"`) || !strings.Contains(string(data), `"account_id": 7`) {
+		t.Fatalf("unexpected report content: %s", data)
+	}
+}
+
+func TestPrintDriftAnalysis(t *testing.T) {
+	i18n.Init("en")
+	var b strings.Builder
+	printDriftAnalysis(&b, model.DriftAnalysis{
+		Added:   []string{"ssh-ed25519 AAAAadded intruder"},
+		Removed: []string{"ssh-ed25519 AAAAgone alice"},
+	})
+	out := b.String()
+	if !strings.Contains(out, "+ ssh-ed25519 AAAAadded intruder") || !strings.Contains(out, "- ssh-ed25519 AAAAgone alice") {
+		t.Fatalf("unexpected drift output: %q", out)
+	}
+}
+
+func TestPrintCanaryDeployResult_Aborted(t *testing.T) {
+	i18n.Init("en")
+	canary := model.Account{ID: 1, Username: "app", Hostname: "web-01"}
+	var b strings.Builder
+	printCanaryDeployResult(&b, &core.CanaryDeployResult{
+		Canaries:    []core.DeployResult{{Account: canary}},
+		CanaryAudit: []core.AuditResult{{Account: canary, Error: errors.New("drift")}},
+		Aborted:     true,
+	})
+	out := b.String()
+	if !strings.Contains(out, "Canary audit:") || !strings.Contains(out, "Rollout aborted") || strings.Contains(out, "Remaining hosts:") {
+		t.Fatalf("unexpected canary output: %q", out)
+	}
+}
+
+func TestPrintSlowest(t *testing.T) {
+	results := []core.DeployResult{
+		{Account: model.Account{Username: "app", Hostname: "fast"}, Duration: 10 * time.Millisecond},
+		{Account: model.Account{Username: "app", Hostname: "slow"}, Duration: 2 * time.Second},
+	}
+	var b strings.Builder
+	printSlowest(&b, "deploy", core.SlowestDeployResults(results, 1), deployTiming)
+	out := b.String()
+	if !strings.Contains(out, "Slowest 1 hosts to deploy:") || !strings.Contains(out, "app@slow") || strings.Contains(out, "app@fast") {
+		t.Fatalf("unexpected slowest output: %q", out)
+	}
+
+	b.Reset()
+	printSlowest(&b, "deploy", core.SlowestDeployResults(results, 0), deployTiming)
+	if b.Len() != 0 {
+		t.Fatalf("expected no output without --slowest, got %q", b.String())
+	}
+}
+
+func TestPrintDeployedSerials(t *testing.T) {
+	setupTestDB(t)
+	st := uiadapters.NewStoreAdapter()
+	deployed, _ := st.AddAccount("app", "web-01", "", "")
+	failed, _ := st.AddAccount("app", "web-02", "", "")
+	if err := core.UpdateAccountSerial(deployed, 3); err != nil {
+		t.Fatalf("UpdateAccountSerial: %v", err)
+	}
+
+	var b strings.Builder
+	printDeployedSerials(&b, st, []core.DeployResult{
+		{Account: model.Account{ID: deployed, Username: "app", Hostname: "web-01"}},
+		{Account: model.Account{ID: failed, Username: "app", Hostname: "web-02"}, Error: errors.New("refused")},
+	})
+	if out := b.String(); out != "app@web-01 serial 3\n" {
+		t.Fatalf("expected only the deployed host's serial, got %q", out)
+	}
+}
+
+func TestStatusStaleSerialsCmd(t *testing.T) {
+	setupTestDB(t)
+	t.Cleanup(func() {
+		f := statusCmd.Flags().Lookup("stale-serials")
+		_ = f.Value.Set(f.DefValue)
+		f.Changed = false
+	})
+	st := uiadapters.NewStoreAdapter()
+	if _, err := st.CreateSystemKey("sys-pub-test", "sys-priv-test"); err != nil {
+		t.Fatalf("create system key: %v", err)
+	}
+	current, _ := st.AddAccount("app", "web-01", "", "")
+	pending, _ := st.AddAccount("app", "web-02", "", "")
+	if err := core.UpdateAccountSerial(current, 1); err != nil {
+		t.Fatalf("UpdateAccountSerial: %v", err)
+	}
+
+	out := executeCommand(t, nil, "status", "--stale-serials")
+	if !strings.Contains(out, "1 active account(s) not yet deployed with system key serial 1") ||
+		!strings.Contains(out, "app@web-02") || !strings.Contains(out, "never deployed") || strings.Contains(out, "app@web-01") {
+		t.Fatalf("unexpected stale serial output: %q", out)
+	}
+
+	if err := core.UpdateAccountSerial(pending, 1); err != nil {
+		t.Fatalf("UpdateAccountSerial: %v", err)
+	}
+	out = executeCommand(t, nil, "status", "--stale-serials")
+	if !strings.Contains(out, "All active accounts are deployed with system key serial 1.") {
+		t.Fatalf("expected no stale accounts, got %q", out)
+	}
+}
+
+func TestPrintTableStats(t *testing.T) {
+	stats := []model.TableStats{
+		{Table: "accounts", Rows: 3, TableBytes: 4096, IndexBytes: 8192, Indexes: 2},
+		{Table: "audit_log", Rows: 120000, TableBytes: 5 << 20, IndexBytes: -1},
+	}
+	var b strings.Builder
+	printTableStats(&b, stats)
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected header, 2 rows and total, got %q", b.String())
+	}
+	if !strings.Contains(lines[1], "accounts") || !strings.Contains(lines[1], "4.0 KiB") || !strings.Contains(lines[1], "8.0 KiB") {
+		t.Fatalf("unexpected accounts row: %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "120000") || !strings.Contains(lines[2], "5.0 MiB") || !strings.Contains(lines[2], "n/a") {
+		t.Fatalf("unexpected audit_log row: %q", lines[2])
+	}
+	if !strings.HasPrefix(lines[3], "TOTAL") || !strings.Contains(lines[3], "120003") {
+		t.Fatalf("unexpected total row: %q", lines[3])
+	}
+}
+
+func TestWhoamiCmd(t *testing.T) {
+	setupTestDB(t)
+
+	output := executeCommand(t, nil, "whoami")
+	if !strings.Contains(output, "Audit user:        "+core.CurrentAuditUser()) {
+		t.Fatalf("expected audit user in output, got: %s", output)
+	}
+	if !strings.Contains(output, "Active system key:") {
+		t.Fatalf("expected system key line in output, got: %s", output)
+	}
+}
+
+func TestConfigSchemaCmd(t *testing.T) {
+	output := executeCommand(t, nil, "config", "schema")
+	var schema map[string]any
+	if err := json.Unmarshal([]byte(output), &schema); err != nil {
+		t.Fatalf("expected JSON schema output, got %v: %s", err, output)
+	}
+	props, _ := schema["properties"].(map[string]any)
+	if props["database"] == nil || props["deploy"] == nil {
+		t.Fatalf("expected config sections in schema, got: %s", output)
+	}
+}
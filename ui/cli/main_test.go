@@ -12,6 +12,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -20,8 +21,11 @@ import (
 
 	"github.com/spf13/viper"
 	"github.com/toeirei/keymaster/core"
+	genssh "github.com/toeirei/keymaster/core/crypto/ssh"
 	"github.com/toeirei/keymaster/core/deploy"
+	"github.com/toeirei/keymaster/core/security"
 	"github.com/toeirei/keymaster/ui/i18n"
+	"github.com/toeirei/keymaster/uiadapters"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -214,6 +218,149 @@ ssh-ed25519 CCCCC3NzaC1lZDI1NTE5AAAAIGy5E/P9Ea45T/k+s/p3g4zJzE4Q3g== user@exampl
 	})
 }
 
+// remoteKeysDeployer serves a fixed authorized_keys file.
+type remoteKeysDeployer struct{ content string }
+
+func (d *remoteKeysDeployer) DeployAuthorizedKeys(content string) error { return nil }
+func (d *remoteKeysDeployer) GetAuthorizedKeys() ([]byte, error)        { return []byte(d.content), nil }
+func (d *remoteKeysDeployer) Close()                                    {}
+
+// staticKeySource returns the same keys for every identifier.
+type staticKeySource []core.ParsedKey
+
+func (s staticKeySource) FetchKeys(string) ([]core.ParsedKey, error) { return s, nil }
+
+func TestImportCmd_Source(t *testing.T) {
+	setupTestDB(t)
+	if err := core.RegisterKeySource("fake", staticKeySource{
+		{Algorithm: "ssh-ed25519", KeyData: "AAAAC3NzaC1lZDI1NTE5AAAAIGy5E/P9Ea45T/k+s/p3g4zJzE4Q3g==", Comment: "alice@fake"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { core.UnregisterKeySource("fake") })
+
+	output := executeCommand(t, nil, "import", "--source", "fake:alice")
+	if !strings.Contains(output, "Imported key: alice@fake") || !strings.Contains(output, "Imported 1 keys, skipped 0.") {
+		t.Fatalf("unexpected output:\n%s", output)
+	}
+	keys, err := core.DefaultKeyManager().GetAllPublicKeys()
+	if err != nil || len(keys) != 1 || keys[0].Comment != "alice@fake" {
+		t.Fatalf("expected the fake key in the database, got %v (err %v)", keys, err)
+	}
+}
+
+func TestImportRemoteCmd(t *testing.T) {
+	setupTestDB(t)
+
+	sysPub, sysPriv, err := genssh.GenerateAndMarshalEd25519Key("keymaster-system-key", "")
+	if err != nil {
+		t.Fatalf("generate system key: %v", err)
+	}
+	st := uiadapters.NewStoreAdapter()
+	if _, err := st.CreateSystemKey(sysPub, sysPriv); err != nil {
+		t.Fatalf("create system key: %v", err)
+	}
+	if _, err := core.CreateAccount(core.DefaultAccountManager(), "deploy", "remote-host", "", ""); err != nil {
+		t.Fatalf("create account: %v", err)
+	}
+
+	content := "# Keymaster Managed Keys (Serial: 1)\n" +
+		core.SystemKeyRestrictions + " " + sysPub + "\n\n" +
+		"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIGy5E/P9Ea45T/k+s/p3g4zJzE4Q3g== alice@laptop\n" +
+		"ssh-ed25519 BBBBC3NzaC1lZDI1NTE5AAAAIGy5E/P9Ea45T/k+s/p3g4zJzE4Q3g== bob@desktop\n"
+	origFactory := core.NewDeployerFactory
+	core.NewDeployerFactory = func(host, user string, privateKey security.Secret, passphrase []byte) (core.RemoteDeployer, error) {
+		return &remoteKeysDeployer{content: content}, nil
+	}
+	defer func() { core.NewDeployerFactory = origFactory }()
+
+	output := executeCommand(t, nil, "import-remote", "deploy@remote-host")
+	if !strings.Contains(output, "Import complete. Imported 2 keys, skipped 1.") {
+		t.Fatalf("unexpected summary, output:\n%s", output)
+	}
+
+	keys, err := core.DefaultKeyManager().GetAllPublicKeys()
+	if err != nil {
+		t.Fatalf("get public keys: %v", err)
+	}
+	var comments []string
+	for _, k := range keys {
+		comments = append(comments, k.Comment)
+	}
+	sort.Strings(comments)
+	if strings.Join(comments, ",") != "alice@laptop,bob@desktop" {
+		t.Fatalf("expected only the user keys to be imported, got %v", comments)
+	}
+}
+
+func TestTrustHostRetrustCmd(t *testing.T) {
+	setupTestDB(t)
+	t.Cleanup(func() {
+		for _, name := range []string{"retrust", "tag", "yes"} {
+			f := trustHostCmd.Flags().Lookup(name)
+			if f == nil {
+				continue
+			}
+			_ = f.Value.Set(f.DefValue)
+			f.Changed = false
+		}
+	})
+	hostKey := func() string {
+		_, priv, err := genssh.GenerateAndMarshalEd25519Key("", "")
+		if err != nil {
+			t.Fatalf("generate host key: %v", err)
+		}
+		signer, err := ssh.ParsePrivateKey([]byte(priv))
+		if err != nil {
+			t.Fatalf("parse host key: %v", err)
+		}
+		return string(ssh.MarshalAuthorizedKey(signer.PublicKey()))
+	}
+	oldKey, sameKey, newKey := hostKey(), hostKey(), hostKey()
+
+	st := uiadapters.NewStoreAdapter()
+	for _, host := range []string{"web-01", "web-02", "other-01"} {
+		tags := "env:staging"
+		if host == "other-01" {
+			tags = "env:prod"
+		}
+		if _, err := st.AddAccount("app", host, "", tags); err != nil {
+			t.Fatalf("add account: %v", err)
+		}
+	}
+	dm := core.DefaultDeployerManager
+	web01, web02 := dm.CanonicalizeHostPort("web-01"), dm.CanonicalizeHostPort("web-02")
+	_ = st.AddKnownHostKey(web01, oldKey)
+	_ = st.AddKnownHostKey(web02, sameKey)
+
+	origFetch := core.GetRemoteHostKey
+	t.Cleanup(func() { core.GetRemoteHostKey = origFetch })
+	core.GetRemoteHostKey = func(host string) (string, error) {
+		switch host {
+		case web01:
+			return newKey, nil
+		case web02:
+			return sameKey, nil
+		}
+		return "", fmt.Errorf("unexpected host %s", host)
+	}
+
+	out := executeCommand(t, nil, "trust-host", "--retrust", "--tag", "env:staging", "--yes")
+	newPub, _, _, _, _ := ssh.ParseAuthorizedKey([]byte(newKey))
+	if !strings.Contains(out, ssh.FingerprintSHA256(newPub)) || !strings.Contains(out, "changed") || !strings.Contains(out, "unchanged") {
+		t.Fatalf("expected a summary of changed fingerprints, got:\n%s", out)
+	}
+	if !strings.Contains(out, "Updated the known host key of 1 host(s).") {
+		t.Fatalf("expected one host to be re-trusted, got:\n%s", out)
+	}
+	if got, _ := st.GetKnownHostKey(web01); got != newKey {
+		t.Fatalf("expected the new key to be stored for %s, got %q", web01, got)
+	}
+	if got, _ := st.GetKnownHostKey(web02); got != sameKey {
+		t.Fatalf("expected the unchanged key to stay for %s, got %q", web02, got)
+	}
+}
+
 func TestTrustHostCmd(t *testing.T) {
 	// 1. Setup
 	setupTestDB(t)
@@ -621,4 +768,243 @@ func TestExportSSHConfigCmd(t *testing.T) {
 			t.Error("Expected file content to contain the correct Host entry")
 		}
 	})
+
+	t.Run("should write include stub and per-tag files", func(t *testing.T) {
+		setupTestDB(t) // Fresh DB
+		// Flags live on package-level commands; restore the default format.
+		t.Cleanup(func() { _ = exportSSHConfigCmd.Flags().Set("format", core.SSHConfigFormatFlat) })
+
+		mgr := core.DefaultAccountManager()
+		if mgr == nil {
+			t.Fatalf("no account manager available")
+		}
+		_, _ = mgr.AddAccount("user1", "host1.com", "prod-web-1", "env:prod")
+		_, _ = mgr.AddAccount("user2", "host2.com", "stage-web-1", "env:stage")
+
+		dir := t.TempDir()
+		// Leftover file from an earlier export with a tag that no longer exists.
+		includeDir := filepath.Join(dir, core.SSHConfigIncludeDir)
+		if err := os.MkdirAll(includeDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(includeDir, "env-old.conf"), []byte("Host old\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		stubPath := filepath.Join(dir, "keymaster.conf")
+
+		output := executeCommand(t, nil, "export-ssh-client-config", "--format", "include", stubPath)
+		if !strings.Contains(output, "Successfully exported SSH config to") {
+			t.Errorf("Expected success message in stdout, but got: %s", output)
+		}
+
+		stub, err := os.ReadFile(stubPath)
+		if err != nil {
+			t.Fatalf("Failed to read stub: %v", err)
+		}
+		// ssh resolves relative Include paths against ~/.ssh, so the stub
+		// must reference the directory next to it by absolute path.
+		if !strings.Contains(string(stub), "Include "+filepath.ToSlash(includeDir)+"/*.conf") {
+			t.Errorf("Expected stub to include %s, got: %s", includeDir, stub)
+		}
+		prod, err := os.ReadFile(filepath.Join(includeDir, "env-prod.conf"))
+		if err != nil {
+			t.Fatalf("Failed to read prod file: %v", err)
+		}
+		if !strings.Contains(string(prod), "Host prod-web-1") || strings.Contains(string(prod), "Host stage-web-1") {
+			t.Errorf("Unexpected prod file content: %s", prod)
+		}
+		if _, err := os.Stat(filepath.Join(includeDir, "env-stage.conf")); err != nil {
+			t.Errorf("Expected stage file to exist: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(includeDir, "env-old.conf")); !os.IsNotExist(err) {
+			t.Errorf("Expected stale tag file to be removed, got err=%v", err)
+		}
+	})
+}
+
+func TestDecommissionConfirmToken(t *testing.T) {
+	setupTestDB(t)
+	t.Cleanup(func() {
+		for _, name := range []string{"confirm", "tag", "skip-remote"} {
+			f := decommissionCmd.Flags().Lookup(name)
+			if f == nil {
+				continue
+			}
+			_ = f.Value.Set(f.DefValue)
+			f.Changed = false
+		}
+	})
+
+	st := uiadapters.NewStoreAdapter()
+	if _, err := st.CreateSystemKey("sys-pub-test", "sys-priv-test"); err != nil {
+		t.Fatalf("create system key: %v", err)
+	}
+	for _, host := range []string{"ci-01", "ci-02"} {
+		if _, err := core.CreateAccount(core.DefaultAccountManager(), "deploy", host, "", "env:ci"); err != nil {
+			t.Fatalf("create account: %v", err)
+		}
+	}
+
+	// A mismatched token must abort before anything is touched.
+	NewRootCmd()
+	if err := decommissionCmd.Flags().Set("confirm", "3"); err != nil {
+		t.Fatalf("set --confirm: %v", err)
+	}
+	confirmed, err := checkDecommissionConfirm(decommissionCmd, 2)
+	if !confirmed || err == nil {
+		t.Fatalf("expected mismatch error for token 3 and 2 accounts, got confirmed=%v err=%v", confirmed, err)
+	}
+
+	// The matching token skips the prompt and proceeds.
+	out := executeCommand(t, nil, "decommission", "--tag", "env:ci", "--skip-remote", "--confirm", "2")
+	if !strings.Contains(out, "Summary: 2 successful") {
+		t.Fatalf("expected both accounts to be decommissioned, got: %s", out)
+	}
+	accounts, err := st.GetAllAccounts()
+	if err != nil {
+		t.Fatalf("get accounts: %v", err)
+	}
+	if len(accounts) != 0 {
+		t.Fatalf("expected no accounts left, got %d", len(accounts))
+	}
+}
+
+func TestAuditCompareToBackup(t *testing.T) {
+	setupTestDB(t)
+
+	st := uiadapters.NewStoreAdapter()
+	km := core.DefaultKeyManager()
+	webID, err := st.AddAccount("app", "web-01", "", "")
+	if err != nil {
+		t.Fatalf("AddAccount: %v", err)
+	}
+	dbID, err := st.AddAccount("app", "db-01", "", "")
+	if err != nil {
+		t.Fatalf("AddAccount: %v", err)
+	}
+	key, err := km.AddPublicKeyAndGetModel("ssh-ed25519", "AAAAC3NzaC1lZDI1NTE5AAAAIGy5E/P9Ea45T/k+s/p3g4zJzE4Q3g==", "alice@example.com", false, time.Time{})
+	if err != nil {
+		t.Fatalf("AddPublicKey: %v", err)
+	}
+	if err := km.AssignKeyToAccount(key.ID, webID); err != nil {
+		t.Fatalf("AssignKeyToAccount: %v", err)
+	}
+
+	backupFile := filepath.Join(t.TempDir(), "snapshot.json.zst")
+	executeCommand(t, nil, "backup", backupFile)
+
+	out := executeCommand(t, nil, "audit", "--compare-to-backup", backupFile)
+	if !strings.Contains(out, "No changes since backup") {
+		t.Fatalf("expected no changes right after the backup, got:\n%s", out)
+	}
+
+	if err := km.AssignKeyToAccount(key.ID, dbID); err != nil {
+		t.Fatalf("AssignKeyToAccount: %v", err)
+	}
+	if _, err := st.AddAccount("deploy", "web-02", "", ""); err != nil {
+		t.Fatalf("AddAccount: %v", err)
+	}
+
+	root := NewRootCmd()
+	var buf bytes.Buffer
+	root.SetOut(&buf)
+	root.SetArgs([]string{"audit", "--compare-to-backup", backupFile})
+	if err := root.Execute(); ExitCode(err) != core.AuditExitDrift {
+		t.Fatalf("expected exit code %d, got %v", core.AuditExitDrift, err)
+	}
+	got := buf.String()
+	for _, want := range []string{"+ account 3 deploy@web-02", "+ assignment alice@example.com -> app@db-01"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("expected %q in output:\n%s", want, got)
+		}
+	}
+	if strings.Count(got, "\n") != 3 {
+		t.Fatalf("expected a header and two changes, got:\n%s", got)
+	}
+}
+
+func TestVerifyBackupCmd(t *testing.T) {
+	setupTestDB(t)
+
+	st := uiadapters.NewStoreAdapter()
+	km := core.DefaultKeyManager()
+	accID, err := st.AddAccount("app", "web-01", "", "")
+	if err != nil {
+		t.Fatalf("AddAccount: %v", err)
+	}
+	key, err := km.AddPublicKeyAndGetModel("ssh-ed25519", "AAAAC3NzaC1lZDI1NTE5AAAAIVerifyBackupKey", "alice@example.com", false, time.Time{})
+	if err != nil {
+		t.Fatalf("AddPublicKey: %v", err)
+	}
+	if err := km.AssignKeyToAccount(key.ID, accID); err != nil {
+		t.Fatalf("AssignKeyToAccount: %v", err)
+	}
+	dir := t.TempDir()
+	backupFile := filepath.Join(dir, "snapshot.json.zst")
+	executeCommand(t, nil, "backup", backupFile)
+
+	out := executeCommand(t, nil, "verify-backup", backupFile)
+	for _, want := range []string{"Restored accounts: 1 rows", "Restored account_keys: 1 rows", "is restorable"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output:\n%s", want, out)
+		}
+	}
+	if accounts, _ := st.GetAllAccounts(); len(accounts) != 1 {
+		t.Fatalf("verify-backup must not touch the database, got %d accounts", len(accounts))
+	}
+
+	dangling := filepath.Join(dir, "dangling.json")
+	content := `{"schema_version": 2, "accounts": [{"ID": 1, "Username": "app", "Hostname": "web-01"}],
+		"public_keys": [{"ID": 1, "Algorithm": "ssh-ed25519", "KeyData": "AAAA", "Comment": "alice"}],
+		"account_keys": [{"key_id": 1, "account_id": 2}]}`
+	if err := os.WriteFile(dangling, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	var buf bytes.Buffer
+	root := NewRootCmd()
+	root.SetOut(&buf)
+	root.SetErr(io.Discard)
+	root.SetArgs([]string{"verify-backup", dangling})
+	err = root.Execute()
+	if ExitCode(err) != 1 {
+		t.Fatalf("expected exit code 1 for a dangling reference, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "Problem: assignment of key 1 references missing account 2") {
+		t.Fatalf("expected the dangling reference to be named, got:\n%s", buf.String())
+	}
+
+	corrupt := filepath.Join(dir, "corrupt.json.zst")
+	if err := os.WriteFile(corrupt, []byte("not a backup"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	root = NewRootCmd()
+	root.SetOut(io.Discard)
+	root.SetErr(io.Discard)
+	root.SetArgs([]string{"verify-backup", corrupt})
+	if err := root.Execute(); ExitCode(err) != 1 || !strings.Contains(err.Error(), "cannot read backup") {
+		t.Fatalf("expected a read error for a corrupt file, got %v", err)
+	}
+}
+
+func TestAuditRepairKnownHostsRequiresAllowList(t *testing.T) {
+	setupTestDB(t)
+	resetFlags := func() {
+		for name, def := range map[string]string{"repair-known-hosts": "false", "compare-to-backup": ""} {
+			if f := auditCmd.Flags().Lookup(name); f != nil {
+				_ = f.Value.Set(def)
+				f.Changed = false
+			}
+		}
+	}
+	resetFlags()
+	t.Cleanup(resetFlags)
+
+	root := NewRootCmd()
+	root.SetOut(io.Discard)
+	root.SetErr(io.Discard)
+	root.SetArgs([]string{"audit", "--repair-known-hosts"})
+	err := root.Execute()
+	if err == nil || !strings.Contains(err.Error(), "ssh.auto_retrust_hosts") {
+		t.Fatalf("expected the missing allow-list to be reported, got %v", err)
+	}
 }
@@ -0,0 +1,200 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// errMultiSelectCancelled is returned when the operator leaves a multi-select
+// without confirming.
+var errMultiSelectCancelled = errors.New("selection cancelled")
+
+// multiSelectItem is one entry of a multi-select list. Search is the text the
+// filter matches against; it defaults to Label.
+type multiSelectItem struct {
+	Label  string
+	Search string
+}
+
+// runMultiSelect shows a multi-select over items and returns the indexes of
+// the chosen items in list order. Tests override it.
+var runMultiSelect = func(title string, items []multiSelectItem) ([]int, error) {
+	final, err := tea.NewProgram(newMultiSelectModel(title, items)).Run()
+	if err != nil {
+		return nil, err
+	}
+	m := final.(multiSelectModel)
+	if !m.confirmed {
+		return nil, errMultiSelectCancelled
+	}
+	return m.chosen(), nil
+}
+
+// fuzzyMatches reports whether every whitespace-separated term of query
+// appears in text as a case-insensitive subsequence, so "wb1" matches
+// "web-01".
+func fuzzyMatches(text, query string) bool {
+	text = strings.ToLower(text)
+	for _, term := range strings.Fields(strings.ToLower(query)) {
+		rest := text
+		for _, r := range term {
+			i := strings.IndexRune(rest, r)
+			if i < 0 {
+				return false
+			}
+			rest = rest[i+len(string(r)):]
+		}
+	}
+	return true
+}
+
+// multiSelectModel is a bubbletea model like accountPickerModel that keeps a
+// set of checked items instead of returning the first one chosen.
+type multiSelectModel struct {
+	title     string
+	input     textinput.Model
+	items     []multiSelectItem
+	matches   []int
+	cursor    int
+	checked   map[int]bool
+	confirmed bool
+}
+
+func newMultiSelectModel(title string, items []multiSelectItem) multiSelectModel {
+	input := textinput.New()
+	input.Prompt = "Filter: "
+	input.Focus()
+	m := multiSelectModel{title: title, input: input, items: items, checked: make(map[int]bool)}
+	m.filter()
+	return m
+}
+
+// chosen returns the checked item indexes in list order. Confirming with
+// nothing checked takes the item under the cursor.
+func (m multiSelectModel) chosen() []int {
+	var out []int
+	for i := range m.items {
+		if m.checked[i] {
+			out = append(out, i)
+		}
+	}
+	if len(out) == 0 && len(m.matches) > 0 {
+		out = append(out, m.matches[m.cursor])
+	}
+	return out
+}
+
+func (m *multiSelectModel) filter() {
+	m.matches = m.matches[:0:0]
+	for i, item := range m.items {
+		search := item.Search
+		if search == "" {
+			search = item.Label
+		}
+		if fuzzyMatches(search, m.input.Value()) {
+			m.matches = append(m.matches, i)
+		}
+	}
+	if m.cursor >= len(m.matches) {
+		m.cursor = max(len(m.matches)-1, 0)
+	}
+}
+
+// toggle checks or unchecks item i; checked only holds checked items.
+func (m multiSelectModel) toggle(i int, on bool) {
+	if on {
+		m.checked[i] = true
+	} else {
+		delete(m.checked, i)
+	}
+}
+
+func (m multiSelectModel) Init() tea.Cmd { return textinput.Blink }
+
+func (m multiSelectModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyEsc:
+			return m, tea.Quit
+		case tea.KeyEnter:
+			m.confirmed = len(m.chosen()) > 0
+			if !m.confirmed {
+				return m, nil
+			}
+			return m, tea.Quit
+		case tea.KeyTab:
+			if len(m.matches) > 0 {
+				m.toggle(m.matches[m.cursor], !m.checked[m.matches[m.cursor]])
+				if m.cursor < len(m.matches)-1 {
+					m.cursor++
+				}
+			}
+			return m, nil
+		case tea.KeyCtrlA:
+			// Check all matches, or uncheck them when all are checked.
+			all := true
+			for _, i := range m.matches {
+				all = all && m.checked[i]
+			}
+			for _, i := range m.matches {
+				m.toggle(i, !all)
+			}
+			return m, nil
+		case tea.KeyUp, tea.KeyCtrlP:
+			if m.cursor > 0 {
+				m.cursor--
+			}
+			return m, nil
+		case tea.KeyDown, tea.KeyCtrlN:
+			if m.cursor < len(m.matches)-1 {
+				m.cursor++
+			}
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	m.filter()
+	return m, cmd
+}
+
+func (m multiSelectModel) View() string {
+	var b strings.Builder
+	b.WriteString(m.title)
+	b.WriteString("\n")
+	b.WriteString(m.input.View())
+	b.WriteString("\n\n")
+
+	// Keep the cursor inside the visible window.
+	start := 0
+	if m.cursor >= accountPickerVisible {
+		start = m.cursor - accountPickerVisible + 1
+	}
+	end := min(start+accountPickerVisible, len(m.matches))
+	for n := start; n < end; n++ {
+		i := m.matches[n]
+		marker := "  "
+		if n == m.cursor {
+			marker = "> "
+		}
+		box := "[ ]"
+		if m.checked[i] {
+			box = "[x]"
+		}
+		fmt.Fprintf(&b, "%s%s %s\n", marker, box, m.items[i].Label)
+	}
+	if len(m.matches) == 0 {
+		b.WriteString("  no matches\n")
+	}
+	fmt.Fprintf(&b, "\n%d/%d shown · %d selected · tab toggle · ctrl+a all · enter confirm · esc cancel\n",
+		len(m.matches), len(m.items), len(m.checked))
+	return b.String()
+}
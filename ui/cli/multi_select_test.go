@@ -0,0 +1,70 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package cli
+
+import (
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFuzzyMatches(t *testing.T) {
+	cases := []struct {
+		text, query string
+		want        bool
+	}{
+		{"deploy@web-01.example.com", "", true},
+		{"deploy@web-01.example.com", "wb1", true},
+		{"deploy@web-01.example.com", "DEP web", true},
+		{"deploy@web-01.example.com", "10bew", false},
+		{"deploy@web-01.example.com", "web staging", false},
+	}
+	for _, tc := range cases {
+		if got := fuzzyMatches(tc.text, tc.query); got != tc.want {
+			t.Errorf("fuzzyMatches(%q, %q) = %v, want %v", tc.text, tc.query, got, tc.want)
+		}
+	}
+}
+
+func TestMultiSelectModel_ToggleAndConfirm(t *testing.T) {
+	items := []multiSelectItem{
+		{Label: "1  deploy@web-01"},
+		{Label: "2  deploy@db-01", Search: "deploy db-01 env:prod"},
+		{Label: "3  backup@db-02", Search: "backup db-02 env:prod"},
+	}
+	var m tea.Model = newMultiSelectModel("Select accounts:", items)
+	for _, r := range "prod" {
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	if got := m.(multiSelectModel).matches; !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Fatalf("expected matches [1 2] for 'prod', got %v", got)
+	}
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlA})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	sel := m.(multiSelectModel)
+	if !sel.confirmed || !reflect.DeepEqual(sel.chosen(), []int{1, 2}) {
+		t.Fatalf("expected items 1 and 2 chosen, got %v (confirmed=%v)", sel.chosen(), sel.confirmed)
+	}
+
+	// Tab toggles the item under the cursor and moves down.
+	m = newMultiSelectModel("Select keys:", items)
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	if got := m.(multiSelectModel).chosen(); !reflect.DeepEqual(got, []int{0, 1}) {
+		t.Fatalf("expected items 0 and 1 checked, got %v", got)
+	}
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	if got := m.(multiSelectModel).chosen(); !reflect.DeepEqual(got, []int{1}) {
+		t.Fatalf("expected only item 1 checked, got %v", got)
+	}
+
+	m = newMultiSelectModel("Select keys:", items)
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.(multiSelectModel).confirmed {
+		t.Fatal("expected no confirmation after esc")
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/toeirei/keymaster/core"
+)
+
+// runRotateKeyFinalize ends a staged rotation and reports the deactivated
+// serials, or the hosts still to migrate.
+func runRotateKeyFinalize(ctx context.Context, w io.Writer, st core.Store, dm core.DeployerManager) error {
+	res, err := core.FinalizeSystemKeyRotation(ctx, st, dm)
+	var incomplete *core.RolloutIncompleteError
+	if errors.As(err, &incomplete) {
+		_, _ = fmt.Fprintf(w, "Hosts not yet confirmed on system key serial %d:\n", incomplete.Serial)
+		for _, p := range incomplete.Pending {
+			_, _ = fmt.Fprintf(w, "  %-40s %s\n", p.Account.String(), p.Reason)
+		}
+		_, _ = fmt.Fprintln(w, "Deploy them and run 'keymaster rotate-key --finalize' again.")
+	}
+	if err != nil {
+		return err
+	}
+	if len(res.Deactivated) == 0 {
+		_, _ = fmt.Fprintf(w, "No staged rotation to finalize; system key serial %d is the only active key.\n", res.Serial)
+		return nil
+	}
+	serials := make([]string, 0, len(res.Deactivated))
+	for _, s := range res.Deactivated {
+		serials = append(serials, strconv.Itoa(s))
+	}
+	_, _ = fmt.Fprintf(w, "Deactivated superseded system key serial(s) %s; serial %d is now the only active key.\n", strings.Join(serials, ", "), res.Serial)
+	return nil
+}
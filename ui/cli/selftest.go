@@ -0,0 +1,224 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/toeirei/keymaster/config"
+	"github.com/toeirei/keymaster/core"
+	"github.com/toeirei/keymaster/core/deploy"
+	"github.com/toeirei/keymaster/core/logging"
+	"github.com/toeirei/keymaster/core/security"
+	"github.com/toeirei/keymaster/core/sshkey"
+	"github.com/toeirei/keymaster/ui/i18n"
+)
+
+// selfTestCheck is one line of the self-test checklist. A check without an
+// error passed; skipped checks could not run because an earlier one failed.
+type selfTestCheck struct {
+	Name    string
+	Detail  string
+	Err     error
+	Hint    string
+	Skipped bool
+}
+
+// selfTestCmd checks the local environment without contacting any host.
+var selfTestCmd = &cobra.Command{
+	Use:   "self-test",
+	Short: "Check config, database, migrations, system key and locale offline",
+	Long: `Runs a checklist against the local environment and prints PASS or FAIL
+for each item, with a hint on how to fix failures:
+
+  - the config file loads and its settings are valid
+  - the configured language has a locale
+  - the database is reachable
+  - the database migrations match this build
+  - an active system key exists
+
+No host is contacted. The command exits with status 1 when any check fails,
+so it can be attached to support requests or run from provisioning scripts.`,
+	Args: cobra.NoArgs,
+	// An invalid config is a finding here, not a reason to stop, so the
+	// root PersistentPreRunE is skipped and config is loaded below.
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		checks := runSelfTest(cmd)
+		if failed := printSelfTestChecks(cmd.OutOrStdout(), checks); failed > 0 {
+			cmd.SilenceUsage = true
+			return &ExitError{Code: 1, Err: fmt.Errorf("%d of %d self-test check(s) failed", failed, len(checks))}
+		}
+		return nil
+	},
+}
+
+// runSelfTest runs the checks in order. The database checks use the loaded
+// config even when it failed validation, falling back to defaults.
+func runSelfTest(cmd *cobra.Command) []selfTestCheck {
+	cfg, cfgCheck := selfTestConfig(cmd)
+	checks := []selfTestCheck{cfgCheck, selfTestLocale(cfg.Language)}
+
+	dbCheck := selfTestCheck{Name: "Database", Detail: cfg.Database.Type}
+	if !core.IsDBInitialized() {
+		if err := core.InitDB(cfg.Database.Type, cfg.Database.Dsn); err != nil {
+			dbCheck.Err = err
+			dbCheck.Hint = "Check database.type and database.dsn, and that the database server is reachable."
+		}
+	}
+	checks = append(checks, dbCheck)
+	if dbCheck.Err != nil {
+		return append(checks,
+			selfTestCheck{Name: "Migrations", Skipped: true},
+			selfTestCheck{Name: "System key", Skipped: true},
+		)
+	}
+	return append(checks, selfTestMigrations(cfg.Database.Type), selfTestSystemKey())
+}
+
+// selfTestConfig loads the config like setupDefaultServices does, without
+// writing a default file, and validates it.
+func selfTestConfig(cmd *cobra.Command) (config.Config, selfTestCheck) {
+	check := selfTestCheck{Name: "Configuration"}
+	defaults := configDefaults()
+	fallback := config.Config{Language: defaults["language"].(string)}
+	fallback.Database.Type = defaults["database.type"].(string)
+	fallback.Database.Dsn = defaults["database.dsn"].(string)
+
+	path, err := getConfigPathFromCli(cmd)
+	if err != nil {
+		check.Err, check.Hint = err, "Pass an existing file to --config."
+		return fallback, check
+	}
+	cfg, err := config.LoadConfig[config.Config](cmd, defaults, path)
+	switch {
+	case errors.As(err, &viper.ConfigFileNotFoundError{}):
+		check.Detail = "no config file, using defaults"
+	case err != nil:
+		check.Err, check.Hint = err, "Run 'keymaster debug' to inspect the config files."
+		return fallback, check
+	default:
+		check.Detail = viper.ConfigFileUsed()
+	}
+	if cfg.Database.Type == "" {
+		cfg.Database.Type = fallback.Database.Type
+	}
+	if cfg.Database.Dsn == "" {
+		cfg.Database.Dsn = fallback.Database.Dsn
+	}
+	if cfg.Language == "" {
+		cfg.Language = fallback.Language
+	}
+	if err := validateConfig(cfg); err != nil {
+		check.Err, check.Hint = err, "Fix the setting; 'keymaster config schema' lists the valid values."
+	}
+	return cfg, check
+}
+
+// validateConfig checks the settings setupDefaultServices parses, so a bad
+// value is reported before it stops a command.
+func validateConfig(cfg config.Config) error {
+	switch cfg.Database.Type {
+	case "sqlite", "postgres", "mysql":
+	default:
+		return fmt.Errorf("database.type: unsupported database type %q (use sqlite, postgres or mysql)", cfg.Database.Type)
+	}
+	if _, err := logging.New(io.Discard, cfg.Log.Format, false); err != nil {
+		return fmt.Errorf("log.format: %w", err)
+	}
+	if _, err := deploy.ParseHostKeyPolicy(cfg.SSH.HostKeyPolicy); err != nil {
+		return fmt.Errorf("ssh.host_key_policy: %w", err)
+	}
+	algs := sshkey.ConnectionAlgorithms{Ciphers: cfg.SSH.Ciphers, MACs: cfg.SSH.MACs, KeyExchanges: cfg.SSH.KexAlgorithms}
+	if err := algs.Validate(); err != nil {
+		return fmt.Errorf("ssh: %w", err)
+	}
+	if err := core.ValidateRetrustPatterns(cfg.SSH.AutoRetrustHosts); err != nil {
+		return fmt.Errorf("ssh.auto_retrust_hosts: %w", err)
+	}
+	if cfg.Security.TOTPSecret != "" {
+		if _, err := security.ParseTOTPSecret(cfg.Security.TOTPSecret); err != nil {
+			return fmt.Errorf("security.totp_secret: %w", err)
+		}
+	}
+	return nil
+}
+
+func selfTestLocale(lang string) selfTestCheck {
+	check := selfTestCheck{Name: "Locale", Detail: lang}
+	locales := i18n.GetAvailableLocales()
+	if _, ok := locales[lang]; ok {
+		return check
+	}
+	codes := make([]string, 0, len(locales))
+	for code := range locales {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	check.Err = fmt.Errorf("no locale for language %q", lang)
+	check.Hint = "Set language to one of: " + strings.Join(codes, ", ") + "."
+	return check
+}
+
+func selfTestMigrations(dbType string) selfTestCheck {
+	check := selfTestCheck{Name: "Migrations"}
+	pending, unknown, err := core.MigrationStatus(dbType)
+	switch {
+	case err != nil:
+		check.Err = err
+	case len(unknown) > 0:
+		check.Err = fmt.Errorf("database has migrations this build does not know: %s", strings.Join(unknown, ", "))
+		check.Hint = "The database was migrated by a newer Keymaster; upgrade this binary."
+	case len(pending) > 0:
+		check.Err = fmt.Errorf("%d migration(s) not applied: %s", len(pending), strings.Join(pending, ", "))
+		check.Hint = "Check that the database user may alter the schema, then run any keymaster command."
+	default:
+		check.Detail = "up to date"
+	}
+	return check
+}
+
+func selfTestSystemKey() selfTestCheck {
+	check := selfTestCheck{Name: "System key"}
+	key, err := core.GetActiveSystemKey()
+	switch {
+	case err != nil:
+		check.Err = err
+	case key == nil:
+		check.Err = errors.New("no active system key")
+		check.Hint = "Generate one with 'keymaster rotate-key'."
+	default:
+		check.Detail = fmt.Sprintf("serial %d", key.Serial)
+	}
+	return check
+}
+
+// printSelfTestChecks writes the checklist and returns the number of failed
+// checks.
+func printSelfTestChecks(w io.Writer, checks []selfTestCheck) int {
+	failed := 0
+	for _, c := range checks {
+		switch {
+		case c.Skipped:
+			_, _ = fmt.Fprintf(w, "[SKIP] %s\n", c.Name)
+		case c.Err != nil:
+			failed++
+			_, _ = fmt.Fprintf(w, "[FAIL] %s: %v\n", c.Name, c.Err)
+			if c.Hint != "" {
+				_, _ = fmt.Fprintf(w, "       hint: %s\n", c.Hint)
+			}
+		case c.Detail != "":
+			_, _ = fmt.Fprintf(w, "[PASS] %s (%s)\n", c.Name, c.Detail)
+		default:
+			_, _ = fmt.Fprintf(w, "[PASS] %s\n", c.Name)
+		}
+	}
+	return failed
+}
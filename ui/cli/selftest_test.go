@@ -0,0 +1,71 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/toeirei/keymaster/uiadapters"
+)
+
+// runSelfTestCmd executes self-test with args and returns its output and
+// exit code.
+func runSelfTestCmd(t *testing.T, args ...string) (string, int) {
+	t.Helper()
+	root := NewRootCmd()
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetErr(&out)
+	root.SetArgs(append([]string{"self-test"}, args...))
+	err := root.Execute()
+	return out.String(), ExitCode(err)
+}
+
+func TestSelfTest_NoSystemKey(t *testing.T) {
+	setupTestDB(t)
+
+	out, code := runSelfTestCmd(t)
+	if code != 1 {
+		t.Fatalf("expected exit code 1, got %d:\n%s", code, out)
+	}
+	if !strings.Contains(out, "[FAIL] System key: no active system key") || !strings.Contains(out, "keymaster rotate-key") {
+		t.Fatalf("expected the missing system key to fail with a hint, got:\n%s", out)
+	}
+	for _, pass := range []string{"[PASS] Database", "[PASS] Migrations (up to date)", "[PASS] Locale (en)"} {
+		if !strings.Contains(out, pass) {
+			t.Fatalf("expected %q, got:\n%s", pass, out)
+		}
+	}
+
+	if _, err := uiadapters.NewStoreAdapter().CreateSystemKey("sys-pub-test", "sys-priv-test"); err != nil {
+		t.Fatalf("create system key: %v", err)
+	}
+	out, code = runSelfTestCmd(t)
+	if code != 0 || !strings.Contains(out, "[PASS] System key (serial 1)") {
+		t.Fatalf("expected all checks to pass with a system key, got %d:\n%s", code, out)
+	}
+}
+
+func TestSelfTest_InvalidConfig(t *testing.T) {
+	setupTestDB(t)
+	path := filepath.Join(t.TempDir(), "keymaster.yaml")
+	if err := os.WriteFile(path, []byte("ssh:\n  host_key_policy: trust-everyone\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	out, code := runSelfTestCmd(t, "--config", path)
+	if code != 1 {
+		t.Fatalf("expected exit code 1, got %d:\n%s", code, out)
+	}
+	if !strings.Contains(out, "[FAIL] Configuration: ssh.host_key_policy") || !strings.Contains(out, "keymaster config schema") {
+		t.Fatalf("expected the invalid setting to fail with a hint, got:\n%s", out)
+	}
+	if !strings.Contains(out, "[PASS] Database") {
+		t.Fatalf("expected the remaining checks to still run, got:\n%s", out)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/toeirei/keymaster/core"
+	"github.com/toeirei/keymaster/uiadapters"
+)
+
+// shellCmd opens an interactive SSH session on a managed account.
+var shellCmd = &cobra.Command{
+	Use:   "shell <account-identifier>",
+	Short: "Open an interactive SSH shell on an account using the system key",
+	Long: `Connect to an account (ID, user@host or label) the way deploys do and
+attach the terminal to a login shell: the system key the account was last
+deployed with (falling back to the ssh agent), the account's port, host key
+verification under ssh.host_key_policy, and the bastions of a
+'proxyjump:[user@]host[:port]' tag. Bastions without a user are reached as the
+account's user.
+
+When run from a terminal a PTY is allocated and window size changes are
+forwarded. The command exits with the remote shell's exit status.
+
+Examples:
+  keymaster shell deploy@web-01
+  keymaster shell 3`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		accounts, err := uiadapters.NewStoreAdapter().GetAllAccounts()
+		if err != nil {
+			return fmt.Errorf("failed to list accounts: %w", err)
+		}
+		account, err := core.FindAccountByIdentifier(args[0], accounts)
+		if err != nil {
+			return err
+		}
+		err = core.OpenShell(core.DefaultKeyReader(), *account, core.ShellIO{Stdin: os.Stdin, Stdout: os.Stdout, Stderr: os.Stderr})
+		var exitErr *core.ShellExitError
+		if errors.As(err, &exitErr) {
+			cmd.SilenceUsage = true
+			return &ExitError{Code: exitErr.Status, Err: err}
+		}
+		if err != nil {
+			return fmt.Errorf("shell on %s failed: %w", account.String(), err)
+		}
+		return nil
+	},
+}
+
+// registerShellCommand sets up the flags of the shell command.
+func registerShellCommand() {
+	applyDefaultFlags(shellCmd)
+}
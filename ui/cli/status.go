@@ -0,0 +1,78 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package cli
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/toeirei/keymaster/core"
+	"github.com/toeirei/keymaster/uiadapters"
+)
+
+// statusCmd summarizes the fleet as recorded in the database.
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Summarize accounts and system key rollout from the database",
+	Long: `Prints account counts and how many active hosts were last deployed with the
+active system key. Only the database is read; no host is contacted.
+
+Use --stale-serials after a key rotation to list the active accounts still on
+an older system key serial, or never deployed at all. Unlike
+'audit --mode=serial' this shows the database's view and returns instantly.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		st := uiadapters.NewStoreAdapter()
+		out := cmd.OutOrStdout()
+		if stale, _ := cmd.Flags().GetBool("stale-serials"); stale {
+			report, err := core.FindStaleSerials(st)
+			if err != nil {
+				return err
+			}
+			printStaleSerials(out, report)
+			return nil
+		}
+
+		data, err := core.BuildDashboardData(st)
+		if err != nil {
+			return fmt.Errorf("read status: %w", err)
+		}
+		_, _ = fmt.Fprintf(out, "Accounts:    %d (%d active, %d dirty)\n", data.AccountCount, data.ActiveAccountCount, data.DirtyAccountCount)
+		if data.SystemKeySerial == 0 {
+			_, _ = fmt.Fprintln(out, "System key:  none")
+			return nil
+		}
+		_, _ = fmt.Fprintf(out, "System key:  serial %d\n", data.SystemKeySerial)
+		_, _ = fmt.Fprintf(out, "Up to date:  %d active host(s)\n", data.HostsUpToDate)
+		_, _ = fmt.Fprintf(out, "Outdated:    %d active host(s)\n", data.HostsOutdated)
+		return nil
+	},
+}
+
+// printStaleSerials lists the accounts of a stale serial report, oldest
+// serial first.
+func printStaleSerials(w io.Writer, r core.StaleSerialReport) {
+	if r.Total() == 0 {
+		_, _ = fmt.Fprintf(w, "All active accounts are deployed with system key serial %d.\n", r.ActiveSerial)
+		return
+	}
+	_, _ = fmt.Fprintf(w, "%d active account(s) not yet deployed with system key serial %d:\n", r.Total(), r.ActiveSerial)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "ID\tACCOUNT\tSERIAL")
+	for _, acc := range r.Stale {
+		_, _ = fmt.Fprintf(tw, "%d\t%s\t%d\n", acc.ID, acc.String(), acc.Serial)
+	}
+	for _, acc := range r.NeverDeployed {
+		_, _ = fmt.Fprintf(tw, "%d\t%s\tnever deployed\n", acc.ID, acc.String())
+	}
+	_ = tw.Flush()
+}
+
+func registerStatusCommand() {
+	if statusCmd.Flags().Lookup("stale-serials") == nil {
+		statusCmd.Flags().Bool("stale-serials", false, "List active accounts not yet deployed with the active system key")
+	}
+}
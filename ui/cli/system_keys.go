@@ -0,0 +1,120 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/toeirei/keymaster/core"
+	"github.com/toeirei/keymaster/uiadapters"
+)
+
+// systemKeyCmd is the root command for system key operations.
+var systemKeyCmd = &cobra.Command{
+	Use:   "system-key",
+	Short: "Inspect and prune Keymaster system keys",
+	Long: `The 'system-key' command group shows the keys Keymaster uses to connect
+to hosts. Rotated keys are retained until every host has been redeployed with
+the active key.`,
+}
+
+// systemKeyListCmd lists all system keys with their serial and status.
+var systemKeyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List active and retained system keys",
+	Long: `Display every system key with its serial, fingerprint, active flag and
+creation date. The ACCOUNTS column counts the accounts last deployed with that
+serial, so retained keys with a non-zero count are still in use during a
+staged rotation (see 'keymaster audit --mode=serial').`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		keys, err := core.ListSystemKeys(uiadapters.NewStoreAdapter())
+		if err != nil {
+			return fmt.Errorf("failed to list system keys: %w", err)
+		}
+		writeSystemKeyList(os.Stdout, keys)
+		return nil
+	},
+}
+
+// systemKeyPruneCmd deletes retained system keys no host depends on.
+var systemKeyPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete retained system keys no host still uses",
+	Long: `Reads the Keymaster header serial from every active host and deletes the
+retained system keys that neither a host nor an account record still
+references. The active key is never deleted. If any active host cannot be
+reached, nothing is pruned.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		res, err := core.PruneSystemKeys(cmd.Context(), uiadapters.NewStoreAdapter(), &cliDeployerManager{}, core.PruneSystemKeysOptions{DryRun: dryRun})
+		if err != nil {
+			return err
+		}
+		writeSystemKeyPruneResult(os.Stdout, res, dryRun)
+		return nil
+	},
+}
+
+// writeSystemKeyPruneResult prints the pruned and kept serials.
+func writeSystemKeyPruneResult(out io.Writer, res core.SystemKeyPruneResult, dryRun bool) {
+	verb := "Pruned"
+	if dryRun {
+		verb = "Would prune"
+	}
+	if len(res.Pruned) == 0 {
+		_, _ = fmt.Fprintln(out, "No system keys to prune.")
+	}
+	for _, serial := range res.Pruned {
+		_, _ = fmt.Fprintf(out, "%s system key serial %d\n", verb, serial)
+	}
+	if len(res.Kept) > 0 {
+		kept := make([]string, 0, len(res.Kept))
+		for _, serial := range res.Kept {
+			kept = append(kept, strconv.Itoa(serial))
+		}
+		_, _ = fmt.Fprintf(out, "Kept serials: %s\n", strings.Join(kept, ", "))
+	}
+}
+
+// writeSystemKeyList renders system keys as a table.
+func writeSystemKeyList(out io.Writer, keys []core.SystemKeyInfo) {
+	if len(keys) == 0 {
+		_, _ = fmt.Fprintln(out, "No system keys found.")
+		return
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "SERIAL\tFINGERPRINT\tACTIVE\tCREATED\tACCOUNTS")
+	for _, k := range keys {
+		active := "no"
+		if k.IsActive {
+			active = "yes"
+		}
+		created := "unknown"
+		if !k.CreatedAt.IsZero() {
+			created = k.CreatedAt.Local().Format("2006-01-02 15:04")
+		}
+		fingerprint := k.Fingerprint
+		if fingerprint == "" {
+			fingerprint = "(invalid key)"
+		}
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%d\n", k.Serial, fingerprint, active, created, k.Accounts)
+	}
+	_ = w.Flush()
+}
+
+// registerSystemKeyCommands registers all system-key subcommands.
+func registerSystemKeyCommands() {
+	systemKeyCmd.AddCommand(systemKeyListCmd)
+	systemKeyCmd.AddCommand(systemKeyPruneCmd)
+
+	if systemKeyPruneCmd.Flags().Lookup("dry-run") == nil {
+		systemKeyPruneCmd.Flags().Bool("dry-run", false, "Show which keys would be pruned without deleting them")
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/toeirei/keymaster/core"
+	genssh "github.com/toeirei/keymaster/core/crypto/ssh"
+	"github.com/toeirei/keymaster/uiadapters"
+)
+
+func TestSystemKeyListCmd(t *testing.T) {
+	setupTestDB(t)
+
+	out := executeCommand(t, nil, "system-key", "list")
+	if !strings.Contains(out, "No system keys found.") {
+		t.Fatalf("expected empty message, got: %s", out)
+	}
+
+	st := uiadapters.NewStoreAdapter()
+	for i := 0; i < 3; i++ {
+		pub, priv, err := genssh.GenerateAndMarshalEd25519Key("keymaster-system-key", "")
+		if err != nil {
+			t.Fatalf("generate key: %v", err)
+		}
+		if i == 0 {
+			_, err = st.CreateSystemKey(pub, priv)
+		} else {
+			_, err = st.RotateSystemKey(pub, priv)
+		}
+		if err != nil {
+			t.Fatalf("store system key: %v", err)
+		}
+	}
+	id, err := core.CreateAccount(core.DefaultAccountManager(), "deploy", "staged-host", "", "")
+	if err != nil {
+		t.Fatalf("create account: %v", err)
+	}
+	if err := core.UpdateAccountSerial(id, 2); err != nil {
+		t.Fatalf("set serial: %v", err)
+	}
+
+	out = executeCommand(t, nil, "system-key", "list")
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "SERIAL") {
+		t.Fatalf("expected header and 3 rows, got: %s", out)
+	}
+	active := 0
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		if len(fields) < 6 || !strings.HasPrefix(fields[1], "SHA256:") {
+			t.Fatalf("unexpected row %q", line)
+		}
+		if fields[2] == "yes" {
+			active++
+			if fields[0] != "3" {
+				t.Fatalf("expected serial 3 to be active, got row %q", line)
+			}
+		}
+		if fields[0] == "2" && fields[len(fields)-1] != "1" {
+			t.Fatalf("expected one account on serial 2, got row %q", line)
+		}
+	}
+	if active != 1 {
+		t.Fatalf("expected exactly one active key, got %d in: %s", active, out)
+	}
+}
+
+func TestWriteSystemKeyPruneResult(t *testing.T) {
+	var b strings.Builder
+	writeSystemKeyPruneResult(&b, core.SystemKeyPruneResult{Pruned: []int{1, 2}, Kept: []int{3, 4}}, true)
+	out := b.String()
+	if !strings.Contains(out, "Would prune system key serial 1") || !strings.Contains(out, "Would prune system key serial 2") || !strings.Contains(out, "Kept serials: 3, 4") {
+		t.Fatalf("unexpected dry-run output: %s", out)
+	}
+
+	b.Reset()
+	writeSystemKeyPruneResult(&b, core.SystemKeyPruneResult{Kept: []int{4}}, false)
+	if !strings.Contains(b.String(), "No system keys to prune.") {
+		t.Fatalf("unexpected output: %s", b.String())
+	}
+}
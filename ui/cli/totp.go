@@ -0,0 +1,41 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/toeirei/keymaster/core/security"
+)
+
+// totpSecret is the parsed security.totp_secret. When set, destructive
+// commands ask for a TOTP code before doing anything.
+var totpSecret security.Secret
+
+// requireTOTP asks for a TOTP code on stdin before action when a TOTP secret
+// is configured, and returns an error unless the code is valid.
+func requireTOTP(action string) error {
+	return checkTOTP(os.Stdin, os.Stdout, totpSecret, action, time.Now())
+}
+
+// checkTOTP implements requireTOTP with explicit input, output, secret and
+// clock. A nil secret disables the check.
+func checkTOTP(in io.Reader, out io.Writer, secret security.Secret, action string, now time.Time) error {
+	if secret == nil {
+		return nil
+	}
+	_, _ = fmt.Fprintf(out, "Enter the TOTP code to confirm %s: ", action)
+	code, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && code == "" {
+		return fmt.Errorf("%s refused: no TOTP code entered", action)
+	}
+	if err := security.VerifyTOTP(secret, code, now); err != nil {
+		return fmt.Errorf("%s refused: %w", action, err)
+	}
+	return nil
+}
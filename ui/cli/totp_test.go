@@ -0,0 +1,46 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toeirei/keymaster/core/security"
+)
+
+func TestCheckTOTP(t *testing.T) {
+	secret, err := security.ParseTOTPSecret("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+	if err != nil {
+		t.Fatalf("ParseTOTPSecret: %v", err)
+	}
+	now := time.Unix(1234567890, 0)
+	valid := security.TOTPCode(secret, now)
+
+	var out bytes.Buffer
+	if err := checkTOTP(strings.NewReader(valid+"\n"), &out, secret, "rotate-key", now); err != nil {
+		t.Fatalf("valid code rejected: %v", err)
+	}
+	if !strings.Contains(out.String(), "TOTP code to confirm rotate-key") {
+		t.Fatalf("expected a prompt, got %q", out.String())
+	}
+
+	if err := checkTOTP(strings.NewReader("123456\n"), &out, secret, "decommission", now); !errors.Is(err, security.ErrInvalidTOTPCode) || !strings.Contains(err.Error(), "decommission refused") {
+		t.Fatalf("expected a refused invalid code, got %v", err)
+	}
+	if err := checkTOTP(strings.NewReader(valid+"\n"), &out, secret, "full restore", now.Add(5*time.Minute)); !errors.Is(err, security.ErrInvalidTOTPCode) {
+		t.Fatalf("expected an expired code to be refused, got %v", err)
+	}
+	if err := checkTOTP(strings.NewReader(""), &out, secret, "full restore", now); err == nil {
+		t.Fatal("expected missing input to be refused")
+	}
+
+	out.Reset()
+	if err := checkTOTP(strings.NewReader(""), &out, nil, "rotate-key", now); err != nil || out.Len() != 0 {
+		t.Fatalf("expected no prompt without a secret, got %v / %q", err, out.String())
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/toeirei/keymaster/core"
+	"github.com/toeirei/keymaster/uiadapters"
+)
+
+// whoamiCmd prints the identity recorded in audit log entries.
+var whoamiCmd = &cobra.Command{
+	Use:   "whoami",
+	Short: "Show the identity recorded in the audit log",
+	Long: `Prints the username Keymaster writes to the audit log for actions run by
+this process, after the same Windows domain stripping applied to audit
+entries, together with the serial of the active system key.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Printf("Audit user:        %s\n", core.CurrentAuditUser())
+
+		st := uiadapters.NewStoreAdapter()
+		sk, err := st.GetActiveSystemKey()
+		if err != nil {
+			return fmt.Errorf("get active system key: %w", err)
+		}
+		if sk == nil {
+			fmt.Println("Active system key: none")
+			return nil
+		}
+		fmt.Printf("Active system key: serial %d\n", sk.Serial)
+		return nil
+	},
+}
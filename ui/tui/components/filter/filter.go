@@ -0,0 +1,144 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package filter
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/toeirei/keymaster/ui/tui/util"
+)
+
+// FnMatch reports whether record matches the (non-empty) search query.
+type FnMatch[T any] = func(query string, record T) bool
+
+type State int
+
+const (
+	// Inactive: no query, key presses are left to the surrounding view.
+	Inactive State = iota
+	// Editing: the query is being typed, every key press is consumed.
+	Editing
+	// Applied: the query filters the records, navigation keys are left to
+	// the surrounding view.
+	Applied
+)
+
+// Model is a search line shared by list views: "/" starts typing, the
+// records are filtered on every key press, enter keeps the query and esc
+// clears it.
+type Model[T any] struct {
+	fnMatch FnMatch[T]
+
+	state State
+
+	textModel *textinput.Model
+}
+
+func New[T any](fnMatch FnMatch[T]) *Model[T] {
+	textModel := textinput.New()
+	textModel.Prompt = "/"
+	return &Model[T]{
+		fnMatch:   fnMatch,
+		textModel: &textModel,
+	}
+}
+
+func (m *Model[T]) State() State { return m.state }
+
+func (m *Model[T]) Query() string { return m.textModel.Value() }
+
+// Update handles key presses and reports whether msg was consumed. A
+// consumed msg may have changed the query, so callers should refilter.
+func (m *Model[T]) Update(msg tea.Msg) (tea.Cmd, bool) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return nil, false
+	}
+
+	switch m.state {
+	case Inactive, Applied:
+		switch {
+		case key.Matches(keyMsg, FilterBaseKeyMap.Search):
+			m.state = Editing
+			m.textModel.CursorEnd()
+			return m.textModel.Focus(), true
+		case m.state == Applied && key.Matches(keyMsg, FilterBaseKeyMap.Clear):
+			m.clear()
+			return nil, true
+		}
+		return nil, false
+
+	case Editing:
+		switch {
+		case key.Matches(keyMsg, FilterBaseKeyMap.Clear):
+			m.clear()
+			return nil, true
+		case key.Matches(keyMsg, FilterBaseKeyMap.Apply):
+			m.textModel.Blur()
+			if m.Query() == "" {
+				m.state = Inactive
+			} else {
+				m.state = Applied
+			}
+			return nil, true
+		}
+		return util.UpdateTeaModelInplace(msg, m.textModel), true
+	}
+
+	return nil, false
+}
+
+// View renders the search line, or nothing while the filter is inactive.
+func (m *Model[T]) View() string {
+	if m.state == Inactive {
+		return ""
+	}
+	return m.textModel.View()
+}
+
+// SetWidth sets the width of the search line.
+func (m *Model[T]) SetWidth(width int) {
+	m.textModel.Width = max(width-len(m.textModel.Prompt)-1, 0)
+}
+
+// Filter returns the records matching the current query.
+func (m *Model[T]) Filter(records []T) []T {
+	return Apply(m.Query(), records, m.fnMatch)
+}
+
+func (m *Model[T]) clear() {
+	m.textModel.Reset()
+	m.textModel.Blur()
+	m.state = Inactive
+}
+
+// Apply returns the records matching query. An empty query matches all
+// records.
+func Apply[T any](query string, records []T, fnMatch FnMatch[T]) []T {
+	if query == "" || fnMatch == nil {
+		return records
+	}
+	out := make([]T, 0, len(records))
+	for _, record := range records {
+		if fnMatch(query, record) {
+			out = append(out, record)
+		}
+	}
+	return out
+}
+
+// Contains is a [FnMatch] building block: it reports whether any of fields
+// contains query, ignoring case.
+func Contains(query string, fields ...string) bool {
+	query = strings.ToLower(query)
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), query) {
+			return true
+		}
+	}
+	return false
+}
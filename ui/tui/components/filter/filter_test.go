@@ -0,0 +1,125 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package filter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func runeKey(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+var (
+	keyEnter = tea.KeyMsg{Type: tea.KeyEnter}
+	keyEsc   = tea.KeyMsg{Type: tea.KeyEsc}
+	keyDown  = tea.KeyMsg{Type: tea.KeyDown}
+)
+
+func TestContains(t *testing.T) {
+	if !Contains("WEB", "deploy", "web-01") {
+		t.Fatal("expected case-insensitive match on the second field")
+	}
+	if Contains("db", "deploy", "web-01") {
+		t.Fatal("unexpected match")
+	}
+	if Contains("x") {
+		t.Fatal("expected no match without fields")
+	}
+}
+
+func TestApply(t *testing.T) {
+	records := []string{"alpha", "beta", "alphabet"}
+	match := func(query, record string) bool { return strings.HasPrefix(record, query) }
+
+	if got := Apply("alpha", records, match); !reflect.DeepEqual(got, []string{"alpha", "alphabet"}) {
+		t.Fatalf("unexpected result: %v", got)
+	}
+	if got := Apply("", records, match); !reflect.DeepEqual(got, records) {
+		t.Fatalf("expected an empty query to match all, got %v", got)
+	}
+	if got := Apply("zeta", records, match); len(got) != 0 {
+		t.Fatalf("expected no records, got %v", got)
+	}
+	if got := Apply[string]("alpha", records, nil); !reflect.DeepEqual(got, records) {
+		t.Fatalf("expected no predicate to match all, got %v", got)
+	}
+}
+
+func TestModel_StateTransitions(t *testing.T) {
+	records := []string{"web-01", "web-02", "db-01"}
+	m := New(func(query, record string) bool { return Contains(query, record) })
+
+	// Inactive: only "/" is consumed.
+	if _, handled := m.Update(keyDown); handled {
+		t.Fatal("inactive filter must not consume navigation keys")
+	}
+	if _, handled := m.Update(runeKey("w")); handled || m.Query() != "" {
+		t.Fatal("inactive filter must not consume typing")
+	}
+	if m.View() != "" {
+		t.Fatalf("expected no view while inactive, got %q", m.View())
+	}
+	if _, handled := m.Update(runeKey("/")); !handled || m.State() != Editing {
+		t.Fatalf("expected / to start editing, got state %v", m.State())
+	}
+
+	// Editing: typing filters incrementally.
+	for _, r := range "web" {
+		if _, handled := m.Update(runeKey(string(r))); !handled {
+			t.Fatalf("expected %q to be consumed while editing", r)
+		}
+	}
+	if got := m.Filter(records); !reflect.DeepEqual(got, []string{"web-01", "web-02"}) {
+		t.Fatalf("unexpected filter result after typing: %v", got)
+	}
+	if _, handled := m.Update(runeKey("-02")); !handled || m.Query() != "web-02" {
+		t.Fatalf("expected query web-02, got %q", m.Query())
+	}
+
+	// Enter keeps the query; navigation is left to the view again.
+	if _, handled := m.Update(keyEnter); !handled || m.State() != Applied {
+		t.Fatalf("expected enter to apply, got state %v", m.State())
+	}
+	if _, handled := m.Update(keyDown); handled {
+		t.Fatal("applied filter must not consume navigation keys")
+	}
+	if got := m.Filter(records); !reflect.DeepEqual(got, []string{"web-02"}) {
+		t.Fatalf("unexpected applied result: %v", got)
+	}
+
+	// "/" resumes editing the same query.
+	if _, handled := m.Update(runeKey("/")); !handled || m.State() != Editing || m.Query() != "web-02" {
+		t.Fatalf("expected to resume editing web-02, got %v %q", m.State(), m.Query())
+	}
+	if _, handled := m.Update(keyEnter); !handled || m.State() != Applied {
+		t.Fatalf("expected applied state, got %v", m.State())
+	}
+
+	// Esc clears the query.
+	if _, handled := m.Update(keyEsc); !handled || m.State() != Inactive || m.Query() != "" {
+		t.Fatalf("expected esc to clear, got %v %q", m.State(), m.Query())
+	}
+	if got := m.Filter(records); !reflect.DeepEqual(got, records) {
+		t.Fatalf("expected all records after clearing, got %v", got)
+	}
+	if _, handled := m.Update(keyEsc); handled {
+		t.Fatal("esc on an inactive filter belongs to the view")
+	}
+
+	// Esc while editing clears, enter on an empty query deactivates.
+	m.Update(runeKey("/"))
+	m.Update(runeKey("db"))
+	if m.Update(keyEsc); m.State() != Inactive || m.Query() != "" {
+		t.Fatalf("expected esc while editing to clear, got %v %q", m.State(), m.Query())
+	}
+	m.Update(runeKey("/"))
+	if m.Update(keyEnter); m.State() != Inactive {
+		t.Fatalf("expected an empty query to deactivate, got %v", m.State())
+	}
+}
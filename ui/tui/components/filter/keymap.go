@@ -0,0 +1,35 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package filter
+
+import (
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/toeirei/keymaster/ui/tui/util/keys"
+)
+
+type FilterKeyMap struct {
+	Search key.Binding
+	Apply  key.Binding
+	Clear  key.Binding
+}
+
+func (km FilterKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{km.Search}
+}
+
+func (km FilterKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{km.Search, km.Apply, km.Clear},
+	}
+}
+
+// *[FilterKeyMap] implements [help.KeyMap]
+var _ help.KeyMap = (*FilterKeyMap)(nil)
+
+var FilterBaseKeyMap = FilterKeyMap{
+	Search: keys.Search(),
+	Apply:  keys.ApplySearch(),
+	Clear:  keys.ClearSearch(),
+}
@@ -11,6 +11,8 @@ import (
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/toeirei/keymaster/ui/tui/components/filter"
 	windowtitle "github.com/toeirei/keymaster/ui/tui/helpers/title"
 	"github.com/toeirei/keymaster/ui/tui/popups/choicepopup"
 	"github.com/toeirei/keymaster/ui/tui/popups/messagepopup"
@@ -30,14 +32,16 @@ type ListModel[
 	crud *Crud[TRecord, TRecordCreate, TRecordUpdate, TRecordId, TFilter]
 
 	// state
-	records  []TRecord
-	focussed bool
+	records      []TRecord
+	shownRecords []TRecord // records passing the filter
+	focussed     bool
 
 	// util
 	size util.Size
 
 	// sub models
-	table *table.Model
+	table  *table.Model
+	filter *filter.Model[TRecord] // optional
 }
 
 func NewList[
@@ -47,10 +51,14 @@ func NewList[
 	TRecordId comparable,
 	TFilter comparable,
 ](crud *Crud[TRecord, TRecordCreate, TRecordUpdate, TRecordId, TFilter]) *ListModel[TRecord, TRecordCreate, TRecordUpdate, TRecordId, TFilter] {
-	return &ListModel[TRecord, TRecordCreate, TRecordUpdate, TRecordId, TFilter]{
+	m := &ListModel[TRecord, TRecordCreate, TRecordUpdate, TRecordId, TFilter]{
 		crud:  crud,
 		table: util.NewPointer(table.New()),
 	}
+	if crud.listFilter != nil {
+		m.filter = filter.New(crud.listFilter)
+	}
+	return m
 }
 
 // Init implements util.Model.
@@ -64,11 +72,19 @@ func (m *ListModel[TRecord, TRecordCreate, TRecordUpdate, TRecordId, TFilter]) U
 	// Handle resizing
 	if m.size.UpdateFromMsg(msg) {
 		m.table.SetWidth(m.size.Width)
-		m.table.SetHeight(m.size.Height)
 		m.refreshTable()
 		return nil
 	}
 
+	// Let the search line take key presses first, so typed queries do not
+	// trigger list actions.
+	if m.filter != nil && m.focussed {
+		if cmd, handled := m.filter.Update(msg); handled {
+			m.refreshTable()
+			return cmd
+		}
+	}
+
 	// Intercept messages
 	selectedRecord := m.selectedRecord()
 	if cmd, done := Intercept(
@@ -191,6 +207,9 @@ func (m *ListModel[TRecord, TRecordCreate, TRecordUpdate, TRecordId, TFilter]) U
 
 // View implements util.Model.
 func (m *ListModel[TRecord, TRecordCreate, TRecordUpdate, TRecordId, TFilter]) View() string {
+	if m.filter != nil && m.filter.State() != filter.Inactive {
+		return lipgloss.JoinVertical(lipgloss.Left, m.filter.View(), m.table.View())
+	}
 	return m.table.View()
 }
 
@@ -203,9 +222,13 @@ func (m *ListModel[TRecord, TRecordCreate, TRecordUpdate, TRecordId, TFilter]) F
 	}
 	m.focussed = true
 	m.table.Focus()
+	keyMaps := []help.KeyMap{parentKeyMap, ListBaseKeyMap, m.crud.listGlobalKeyMap}
+	if m.filter != nil {
+		keyMaps = append(keyMaps, filter.FilterBaseKeyMap)
+	}
 	return tea.Batch(
 		windowtitle.Announce(m.crud.Texts.EntityNameMultiple()),
-		util.AnnounceKeyMapCmd(parentKeyMap, ListBaseKeyMap, m.crud.listGlobalKeyMap),
+		util.AnnounceKeyMapCmd(keyMaps...),
 	)
 }
 
@@ -230,22 +253,37 @@ func (m *ListModel[TRecord, TRecordCreate, TRecordUpdate, TRecordId, TFilter]) r
 }
 
 func (m *ListModel[TRecord, TRecordCreate, TRecordUpdate, TRecordId, TFilter]) refreshTable() {
+	// apply the filter, which takes a line while shown
+	m.shownRecords = m.records
+	height := m.size.Height
+	if m.filter != nil {
+		m.shownRecords = m.filter.Filter(m.records)
+		m.filter.SetWidth(m.size.Width)
+		if m.filter.State() != filter.Inactive {
+			height--
+		}
+	}
+	m.table.SetHeight(max(height, 0))
+
 	// generate and apply columns and rows
-	columns, rows := m.crud.buildListTable(m.records, m.size.Width)
+	columns, rows := m.crud.buildListTable(m.shownRecords, m.size.Width)
 	m.table.SetColumns(columns)
 	m.table.SetRows(rows)
 
 	// reposition cursor
-	if m.table.Cursor() <= 0 && len(m.records) > 0 {
+	if m.table.Cursor() >= len(m.shownRecords) {
+		m.table.SetCursor(len(m.shownRecords) - 1)
+	}
+	if m.table.Cursor() <= 0 && len(m.shownRecords) > 0 {
 		m.table.MoveUp(1)
 	}
 }
 
 func (m *ListModel[TRecord, TRecordCreate, TRecordUpdate, TRecordId, TFilter]) selectedRecord() *TRecord {
-	if m.table.Cursor() == -1 {
+	if m.table.Cursor() < 0 || m.table.Cursor() >= len(m.shownRecords) {
 		return nil
 	}
 	// copy selectedRecord to avoid unwanted changes by weird devs
-	selectedRecord := m.records[m.table.Cursor()]
+	selectedRecord := m.shownRecords[m.table.Cursor()]
 	return &selectedRecord
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/toeirei/keymaster/ui/tui/components/filter"
 	"github.com/toeirei/keymaster/ui/tui/components/router"
 	"github.com/toeirei/keymaster/ui/tui/helpers/form"
 	"github.com/toeirei/keymaster/ui/tui/popups/messagepopup"
@@ -89,6 +90,20 @@ func WithListKeyBindings[
 	}
 }
 
+// WithListFilter enables "/" search in the list, showing only the records
+// fnMatch accepts for the typed query.
+func WithListFilter[
+	TRecord any,
+	TRecordCreate comparable,
+	TRecordUpdate comparable,
+	TRecordId comparable,
+	TFilter comparable,
+](fnMatch filter.FnMatch[TRecord]) Option[TRecord, TRecordCreate, TRecordUpdate, TRecordId, TFilter] {
+	return func(c *Crud[TRecord, TRecordCreate, TRecordUpdate, TRecordId, TFilter]) {
+		c.listFilter = fnMatch
+	}
+}
+
 func WithListMsgInterceptor[
 	TRecord any,
 	TRecordCreate comparable,
@@ -12,6 +12,7 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/toeirei/keymaster/ui/tui/components/filter"
 	"github.com/toeirei/keymaster/ui/tui/helpers/popup"
 	"github.com/toeirei/keymaster/ui/tui/helpers/tablecontroll"
 	"github.com/toeirei/keymaster/ui/tui/popups/choicepopup"
@@ -25,7 +26,6 @@ type (
 	FnLoadRecords[T any]    = func(ctx context.Context) ([]T, error)
 	FnOnRecordSelect[T any] = func(record T) tea.Cmd
 	FnBuildTable[T any]     = func(records []T, width int) ([]table.Column, []table.Row)
-	FnFilterRecords[T any]  = func(filter string, records []T) []T
 )
 
 type Model[T any] struct {
@@ -34,7 +34,7 @@ type Model[T any] struct {
 	fnLoadRecords    FnLoadRecords[T]
 	fnOnRecordSelect FnOnRecordSelect[T]
 	tableControll    tablecontroll.Controll[T]
-	fnFilterRecords  FnFilterRecords[T] // optional
+	fnFilterRecords  filter.FnMatch[T] // optional
 
 	records         []T
 	filteredRecords []T
@@ -49,7 +49,9 @@ type Model[T any] struct {
 
 type Option[T any] func(*Model[T])
 
-func WithFilter[T any](fn FnFilterRecords[T]) Option[T] {
+// WithFilter shows a search line above the records, listing only those
+// fn matches.
+func WithFilter[T any](fn filter.FnMatch[T]) Option[T] {
 	return func(m *Model[T]) {
 		m.fnFilterRecords = fn
 	}
@@ -190,11 +192,7 @@ func (m *Model[T]) reload() tea.Cmd {
 }
 
 func (m *Model[T]) filterRecords() {
-	if m.prevFilterValue == "" {
-		m.filteredRecords = m.records
-	} else {
-		m.filteredRecords = m.fnFilterRecords(m.prevFilterValue, m.records)
-	}
+	m.filteredRecords = filter.Apply(m.prevFilterValue, m.records, m.fnFilterRecords)
 }
 
 func (m *Model[T]) refreshTable() {
@@ -223,3 +223,22 @@ func Delete() key.Binding {
 		key.WithHelp("del", "delete"),
 	)
 }
+
+func Search() key.Binding {
+	return key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "search"),
+	)
+}
+func ClearSearch() key.Binding {
+	return key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "clear search"),
+	)
+}
+func ApplySearch() key.Binding {
+	return key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "apply search"),
+	)
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package account
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/toeirei/keymaster/client"
+	"github.com/toeirei/keymaster/ui/tui/popups/messagepopup"
+	"github.com/toeirei/keymaster/ui/tui/popups/progresspopup"
+)
+
+// copyAuthorizedKeys renders the authorized_keys of account and copies it to
+// the clipboard, confirming with a message popup.
+func copyAuthorizedKeys(c client.Client, account client.Account) tea.Cmd {
+	return progresspopup.Open(
+		progresspopup.Spinner,
+		"Rendering authorized_keys",
+		func(ctx context.Context, _ progresspopup.ProgressChan) tea.Cmd {
+			content, err := c.RenderAuthorizedKeys(ctx, account.Id)
+			if err != nil {
+				return messagepopup.Open(messagepopup.Error, "Error rendering authorized_keys:\n"+err.Error(), nil)
+			}
+			text, keyCount := renderForClipboard(content)
+			if err := clipboard.WriteAll(text); err != nil {
+				return messagepopup.Open(messagepopup.Error, "Error copying to clipboard:\n"+err.Error(), nil)
+			}
+			return messagepopup.Open(messagepopup.Info, fmt.Sprintf("Copied authorized_keys of %s@%s to the clipboard (%d keys).", account.Username, account.Host, keyCount), nil)
+		},
+		progresspopup.WithCancel(),
+	)
+}
+
+// renderForClipboard normalizes authorized_keys content for pasting: LF line
+// endings, no trailing blank lines and a single final newline. It also
+// returns the number of key lines, ignoring comments and blank lines.
+func renderForClipboard(content string) (string, int) {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	content = strings.TrimRight(content, " \t\n")
+	if content == "" {
+		return "", 0
+	}
+
+	keyCount := 0
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			keyCount++
+		}
+	}
+	return content + "\n", keyCount
+}
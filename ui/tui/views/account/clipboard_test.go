@@ -0,0 +1,49 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package account
+
+import "testing"
+
+func TestRenderForClipboard(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		content  string
+		want     string
+		keyCount int
+	}{
+		{
+			name:     "managed file",
+			content:  "# Keymaster Managed Keys (Serial: 3)\ncommand=\"internal-sftp\" ssh-ed25519 AAAA system\n\n# User Keys\nssh-ed25519 BBBB alice\nssh-rsa CCCC bob\n",
+			want:     "# Keymaster Managed Keys (Serial: 3)\ncommand=\"internal-sftp\" ssh-ed25519 AAAA system\n\n# User Keys\nssh-ed25519 BBBB alice\nssh-rsa CCCC bob\n",
+			keyCount: 3,
+		},
+		{
+			name:     "CRLF and trailing blank lines",
+			content:  "ssh-ed25519 AAAA alice\r\nssh-ed25519 BBBB bob\r\n\r\n  \n",
+			want:     "ssh-ed25519 AAAA alice\nssh-ed25519 BBBB bob\n",
+			keyCount: 2,
+		},
+		{
+			name:     "missing final newline",
+			content:  "ssh-ed25519 AAAA alice",
+			want:     "ssh-ed25519 AAAA alice\n",
+			keyCount: 1,
+		},
+		{
+			name:    "empty",
+			content: "\n\n",
+			want:    "",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, keyCount := renderForClipboard(tc.content)
+			if got != tc.want {
+				t.Fatalf("got %q, want %q", got, tc.want)
+			}
+			if keyCount != tc.keyCount {
+				t.Fatalf("got %d keys, want %d", keyCount, tc.keyCount)
+			}
+		})
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/toeirei/keymaster/client"
+	"github.com/toeirei/keymaster/ui/tui/components/filter"
 	"github.com/toeirei/keymaster/ui/tui/components/router"
 	"github.com/toeirei/keymaster/ui/tui/helpers/crud"
 	"github.com/toeirei/keymaster/ui/tui/helpers/form"
@@ -118,7 +119,7 @@ func NewCrud(c client.Client, rc router.Controll) *crud.Crud[recordT, recordCrea
 		},
 		func(ctx context.Context, recordCreate recordCreateT) (recordT, error) {
 			var record recordT
-			err := c.WithTransaction(ctx, func(c client.Client) error {
+			err := c.WithTransaction(ctx, func(ctx context.Context, c client.Client) error {
 				port, err := strconv.Atoi(recordCreate.Port)
 				if err != nil {
 					return err
@@ -143,7 +144,7 @@ func NewCrud(c client.Client, rc router.Controll) *crud.Crud[recordT, recordCrea
 		},
 		func(ctx context.Context, id recordIdT, recordUpdate recordUpdateT) (recordT, error) {
 			var record recordT
-			err := c.WithTransaction(ctx, func(c client.Client) error {
+			err := c.WithTransaction(ctx, func(ctx context.Context, c client.Client) error {
 				port, err := strconv.Atoi(recordUpdate.Port)
 				if err != nil {
 					return err
@@ -222,6 +223,26 @@ func NewCrud(c client.Client, rc router.Controll) *crud.Crud[recordT, recordCrea
 				key.WithHelp("l", "links"),
 			),
 		),
+		crud.WithListAction(
+			func(ctx crud.ListMsgInterceptorCtx[recordT, recordCreateT, recordUpdateT, recordIdT, filterT]) tea.Cmd {
+				if ctx.SelectedRecord == nil {
+					return messagepopup.Open(messagepopup.Error, "Please select a "+ctx.Crud.Texts.EntityNameSingular()+".", nil)
+				}
+				return copyAuthorizedKeys(c, ctx.SelectedRecord.account)
+			},
+			key.NewBinding(
+				key.WithKeys("c"),
+				key.WithHelp("c", "copy authorized_keys"),
+			),
+		),
 		crud.WithListReloadAfterChange[recordT, recordCreateT, recordUpdateT, recordIdT, filterT](true),
+		crud.WithListFilter[recordT, recordCreateT, recordUpdateT, recordIdT, filterT](func(query string, record recordT) bool {
+			return filter.Contains(query,
+				record.account.Username,
+				record.account.Host,
+				fmt.Sprint(record.account.Port),
+				record.account.DeployMethod,
+			)
+		}),
 	)
 }
@@ -6,13 +6,13 @@ package content
 import (
 	"context"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/help"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/toeirei/keymaster/client"
 	"github.com/toeirei/keymaster/ui/i18n"
+	"github.com/toeirei/keymaster/ui/tui/components/filter"
 	"github.com/toeirei/keymaster/ui/tui/components/menu"
 	"github.com/toeirei/keymaster/ui/tui/components/router"
 	"github.com/toeirei/keymaster/ui/tui/components/stack"
@@ -25,7 +25,6 @@ import (
 	"github.com/toeirei/keymaster/ui/tui/views/account"
 	"github.com/toeirei/keymaster/ui/tui/views/dashboard"
 	"github.com/toeirei/keymaster/ui/tui/views/publickey"
-	"github.com/toeirei/keymaster/util/slicest"
 )
 
 type Model struct {
@@ -137,13 +136,8 @@ func (m *Model) Update(msg tea.Msg) tea.Cmd {
 					{Title: func() string { return "Port" }, View: func(r client.Account) string { return fmt.Sprint(r.Port) }},
 					{Title: func() string { return "Deploy Method" }, View: func(r client.Account) string { return r.DeployMethod }},
 				}),
-				selectpopup.WithFilter(func(filter string, records []client.Account) []client.Account {
-					return slicest.Filter(records, func(record client.Account) bool {
-						return strings.Contains(record.Username, filter) ||
-							strings.Contains(record.Host, filter) ||
-							strings.Contains(fmt.Sprint(record.Port), filter) ||
-							strings.Contains(record.DeployMethod, filter)
-					})
+				selectpopup.WithFilter(func(query string, record client.Account) bool {
+					return filter.Contains(query, record.Username, record.Host, fmt.Sprint(record.Port), record.DeployMethod)
 				}),
 			)
 
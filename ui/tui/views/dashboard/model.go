@@ -31,8 +31,12 @@ type Data = struct {
 	HostsOutdated      int
 	SystemKeySerial    int
 	AuditLogs          []AuditLogEntry
+	DriftEvents        []AuditLogEntry
 }
 
+// maxDriftEvents caps the drift events shown on the dashboard.
+const maxDriftEvents = 5
+
 type AuditLogEntry = client.AuditLog
 
 type recentActivityRow struct {
@@ -101,11 +105,6 @@ func (m Model) View() string {
 		return lipgloss.JoinVertical(lipgloss.Left, errTitle, "", errBody)
 	}
 
-	// height := m.size.Height
-	// if height <= 0 {
-	// 	height = 24
-	// }
-
 	recentActivityRows := recentActivityTableRows(m.data.AuditLogs)
 	accountsLine := fmt.Sprintf(i18n.T("dashboard.accounts"), m.data.ActiveAccountCount, m.data.AccountCount)
 	publicKeysLine := fmt.Sprintf(i18n.T("dashboard.public_keys"), m.data.PublicKeyCount, m.data.GlobalKeyCount)
@@ -129,17 +128,33 @@ func (m Model) View() string {
 		"",
 		bodyStyle.Render(fmt.Sprintf(i18n.T("dashboard.key_type_spread"), formatAlgoSpread(m.data.AlgoCounts, warnValueStyle))),
 		"",
-		sectionTitleStyle.Render(i18n.T("dashboard.recent_activity")),
+		sectionTitleStyle.Render(i18n.T("dashboard.recent_drift")),
 		"",
 	}
 
+	if len(m.data.DriftEvents) == 0 {
+		lines = append(lines, bodyStyle.Italic(true).Render(i18n.T("dashboard.no_recent_drift")))
+	} else {
+		driftStyle := warnValueStyle.UnsetBold().MaxWidth(max(contentWidth, 1))
+		for _, row := range recentActivityTableRows(m.data.DriftEvents) {
+			lines = append(lines, driftStyle.Render(fmt.Sprintf("%s  %s  %s", row.Timestamp, row.Action, row.Details)))
+		}
+	}
+
+	lines = append(lines,
+		"",
+		sectionTitleStyle.Render(i18n.T("dashboard.recent_activity")),
+		"",
+	)
+
 	if len(recentActivityRows) == 0 {
 		lines = append(lines, bodyStyle.Italic(true).Render(i18n.T("dashboard.no_recent_activity")))
 	} else {
-		// maxLogRows := util.Clamp(3, height-14, 10)
-		// if len(recentActivityRows) > maxLogRows {
-		// 	recentActivityRows = recentActivityRows[:maxLogRows]
-		// }
+		// Fit the table into the remaining height, leaving room for its header.
+		if height := m.size.Height; height > 0 {
+			maxLogRows := util.Clamp(3, height-len(lines)-2, len(recentActivityRows))
+			recentActivityRows = recentActivityRows[:min(maxLogRows, len(recentActivityRows))]
+		}
 
 		recentActivityControll := tablecontroll.New(tablecontroll.Columns[recentActivityRow]{
 			{Title: func() string { return i18n.T("dashboard.log_col_time") }, View: func(row recentActivityRow) string { return row.Timestamp }},
@@ -277,32 +292,40 @@ func (m *Model) reload() tea.Cmd {
 			return msgReloadResult{err: err}
 		}
 
-		return msgReloadResult{data: Data{
-			AccountCount:       len(accounts),
-			ActiveAccountCount: len(accounts), // TODO client API currently has no account activation state
-			PublicKeyCount:     len(publicKeys),
-			GlobalKeyCount:     0, // TODO client API currently has no global-key flag on PublicKey
-			AlgoCounts: slicest.ToMap(
-				slicest.Reduce(
-					slicest.Map(
-						publicKeys,
-						func(publicKey client.PublicKey) string { return publicKey.Algorithm },
-					),
-					func(algo string, algos []string) []string {
-						if !slices.Contains(algos, algo) {
-							algos = append(algos, algo)
-						}
-						return algos
-					},
-				),
-				func(algo string) (string, int) {
-					return algo, len(slicest.Filter(publicKeys, func(publicKey client.PublicKey) bool { return publicKey.Algorithm == algo }))
-				},
-			),
-			HostsUpToDate:   len(accounts) - len(dirtyAccounts),
-			HostsOutdated:   len(dirtyAccounts),
-			SystemKeySerial: 0,
-			AuditLogs:       auditLogs,
-		}}
+		return msgReloadResult{data: summarize(accounts, publicKeys, dirtyAccounts, auditLogs)}
+	}
+}
+
+// summarize computes the dashboard figures from the client data. Audit logs
+// are expected oldest first, as returned by [client.Client.ListAuditLogs],
+// and the most recent drift events keep that order.
+func summarize(accounts []client.Account, publicKeys []client.PublicKey, dirtyAccounts []client.Account, auditLogs []AuditLogEntry) Data {
+	algoCounts := make(map[string]int)
+	for _, publicKey := range publicKeys {
+		algoCounts[publicKey.Algorithm]++
+	}
+
+	var driftEvents []AuditLogEntry
+	for _, al := range slices.Backward(auditLogs) {
+		if strings.Contains(strings.ToUpper(al.Action), "DRIFT") {
+			driftEvents = append(driftEvents, al)
+			if len(driftEvents) == maxDriftEvents {
+				break
+			}
+		}
+	}
+	slices.Reverse(driftEvents)
+
+	return Data{
+		AccountCount:       len(accounts),
+		ActiveAccountCount: len(accounts), // TODO client API currently has no account activation state
+		PublicKeyCount:     len(publicKeys),
+		GlobalKeyCount:     0, // TODO client API currently has no global-key flag on PublicKey
+		AlgoCounts:         algoCounts,
+		HostsUpToDate:      max(len(accounts)-len(dirtyAccounts), 0),
+		HostsOutdated:      len(dirtyAccounts),
+		SystemKeySerial:    0, // TODO client API currently has no system key
+		AuditLogs:          auditLogs,
+		DriftEvents:        driftEvents,
 	}
 }
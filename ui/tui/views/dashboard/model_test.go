@@ -0,0 +1,64 @@
+// Copyright (c) 2026 Keymaster Team
+// Keymaster - SSH key management system
+// This source code is licensed under the MIT license found in the LICENSE file.
+package dashboard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/toeirei/keymaster/client"
+)
+
+func TestSummarize(t *testing.T) {
+	accounts := []client.Account{{Id: 1}, {Id: 2}, {Id: 3}}
+	dirty := []client.Account{{Id: 2}}
+	publicKeys := []client.PublicKey{
+		{Id: 1, Algorithm: "ssh-ed25519"},
+		{Id: 2, Algorithm: "ssh-ed25519"},
+		{Id: 3, Algorithm: "ssh-rsa"},
+	}
+
+	// Oldest first, with more drift events than the dashboard keeps.
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var logs []AuditLogEntry
+	for i := 0; i < maxDriftEvents+2; i++ {
+		logs = append(logs, AuditLogEntry{Id: client.AuditLogId(2*i + 1), Timestamp: start.Add(time.Duration(i) * time.Hour), Action: "AUDIT_DRIFT_ALERT"})
+		logs = append(logs, AuditLogEntry{Id: client.AuditLogId(2*i + 2), Timestamp: start.Add(time.Duration(i) * time.Hour), Action: "DEPLOY_SUCCESS"})
+	}
+	logs = append(logs, AuditLogEntry{Id: 100, Action: "DRIFT_REMEDIATED"})
+
+	data := summarize(accounts, publicKeys, dirty, logs)
+
+	if data.AccountCount != 3 || data.PublicKeyCount != 3 {
+		t.Fatalf("unexpected counts: %d accounts, %d keys", data.AccountCount, data.PublicKeyCount)
+	}
+	if data.HostsUpToDate != 2 || data.HostsOutdated != 1 {
+		t.Fatalf("unexpected hosts up-to-date/dirty: %d/%d", data.HostsUpToDate, data.HostsOutdated)
+	}
+	if data.AlgoCounts["ssh-ed25519"] != 2 || data.AlgoCounts["ssh-rsa"] != 1 || len(data.AlgoCounts) != 2 {
+		t.Fatalf("unexpected algorithm counts: %v", data.AlgoCounts)
+	}
+	if len(data.AuditLogs) != len(logs) {
+		t.Fatalf("expected all audit logs to be kept, got %d", len(data.AuditLogs))
+	}
+
+	if len(data.DriftEvents) != maxDriftEvents {
+		t.Fatalf("expected %d drift events, got %d", maxDriftEvents, len(data.DriftEvents))
+	}
+	if first, last := data.DriftEvents[0], data.DriftEvents[maxDriftEvents-1]; first.Id != 7 || last.Id != 100 {
+		t.Fatalf("expected the most recent drift events oldest first, got %d..%d", first.Id, last.Id)
+	}
+	for _, e := range data.DriftEvents {
+		if e.Action == "DEPLOY_SUCCESS" {
+			t.Fatalf("unexpected non-drift event %+v", e)
+		}
+	}
+}
+
+func TestSummarize_Empty(t *testing.T) {
+	data := summarize(nil, nil, nil, nil)
+	if data.AccountCount != 0 || data.HostsUpToDate != 0 || data.HostsOutdated != 0 || len(data.DriftEvents) != 0 {
+		t.Fatalf("expected zero values, got %+v", data)
+	}
+}
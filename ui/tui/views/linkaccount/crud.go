@@ -78,7 +78,7 @@ func NewCrud(c client.Client, rc router.Controll, account client.Account) *crud.
 		},
 		func(ctx context.Context, recordCreate recordCreateT) (recordT, error) {
 			var record recordT
-			err := c.WithTransaction(ctx, func(c client.Client) error {
+			err := c.WithTransaction(ctx, func(ctx context.Context, c client.Client) error {
 				expr, err := tags.ParseMatcher(recordCreate.TagMatcher)
 				if err != nil {
 					return err
@@ -106,7 +106,7 @@ func NewCrud(c client.Client, rc router.Controll, account client.Account) *crud.
 		},
 		func(ctx context.Context, id recordIdT, recordUpdate recordUpdateT) (recordT, error) {
 			var record recordT
-			err := c.WithTransaction(ctx, func(c client.Client) error {
+			err := c.WithTransaction(ctx, func(ctx context.Context, c client.Client) error {
 				expr, err := tags.ParseMatcher(recordUpdate.TagMatcher)
 				if err != nil {
 					return err
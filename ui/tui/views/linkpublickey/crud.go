@@ -12,6 +12,7 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/toeirei/keymaster/client"
 	"github.com/toeirei/keymaster/tags"
+	"github.com/toeirei/keymaster/ui/tui/components/filter"
 	"github.com/toeirei/keymaster/ui/tui/components/router"
 	"github.com/toeirei/keymaster/ui/tui/helpers/crud"
 	"github.com/toeirei/keymaster/ui/tui/helpers/form"
@@ -73,13 +74,8 @@ func formRows[T comparable](c client.Client) func() []form.FormOpt[T] {
 							{Title: func() string { return "Deploy Method" }, View: func(r client.Account) string { return r.DeployMethod }},
 						}),
 						// extra options
-						selectpopup.WithFilter(func(filter string, records []client.Account) []client.Account {
-							return slicest.Filter(records, func(record client.Account) bool {
-								return strings.Contains(record.Username, filter) ||
-									strings.Contains(record.Host, filter) ||
-									strings.Contains(fmt.Sprint(record.Port), filter) ||
-									strings.Contains(record.DeployMethod, filter)
-							})
+						selectpopup.WithFilter(func(query string, record client.Account) bool {
+							return filter.Contains(query, record.Username, record.Host, fmt.Sprint(record.Port), record.DeployMethod)
 						}),
 					)
 				},
@@ -124,7 +120,7 @@ func NewCrud(c client.Client, rc router.Controll, publicKey client.PublicKey) *c
 		},
 		func(ctx context.Context, recordCreate recordCreateT) (recordT, error) {
 			var record recordT
-			err := c.WithTransaction(ctx, func(c client.Client) error {
+			err := c.WithTransaction(ctx, func(ctx context.Context, c client.Client) error {
 				expr, err := tags.ParseMatcher(recordCreate.TagMatcher)
 				if err != nil {
 					return err
@@ -147,7 +143,7 @@ func NewCrud(c client.Client, rc router.Controll, publicKey client.PublicKey) *c
 		},
 		func(ctx context.Context, id recordIdT, recordUpdate recordUpdateT) (recordT, error) {
 			var record recordT
-			err := c.WithTransaction(ctx, func(c client.Client) error {
+			err := c.WithTransaction(ctx, func(ctx context.Context, c client.Client) error {
 				expr, err := tags.ParseMatcher(recordUpdate.TagMatcher)
 				if err != nil {
 					return err
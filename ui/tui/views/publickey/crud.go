@@ -12,6 +12,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/toeirei/keymaster/client"
 	"github.com/toeirei/keymaster/tags"
+	"github.com/toeirei/keymaster/ui/tui/components/filter"
 	"github.com/toeirei/keymaster/ui/tui/components/router"
 	"github.com/toeirei/keymaster/ui/tui/helpers/crud"
 	"github.com/toeirei/keymaster/ui/tui/helpers/form"
@@ -118,7 +119,7 @@ func NewCrud(c client.Client, rc router.Controll) *crud.Crud[recordT, recordCrea
 		},
 		func(ctx context.Context, recordCreate recordCreateT) (recordT, error) {
 			var record recordT
-			err := c.WithTransaction(ctx, func(c client.Client) error {
+			err := c.WithTransaction(ctx, func(ctx context.Context, c client.Client) error {
 				publicKey, err := c.CreatePublicKey(
 					ctx,
 					recordCreate.Algorithm+" "+recordCreate.Data,
@@ -136,7 +137,7 @@ func NewCrud(c client.Client, rc router.Controll) *crud.Crud[recordT, recordCrea
 		},
 		func(ctx context.Context, id recordIdT, recordCreate recordUpdateT) (recordT, error) {
 			var record recordT
-			err := c.WithTransaction(ctx, func(c client.Client) error {
+			err := c.WithTransaction(ctx, func(ctx context.Context, c client.Client) error {
 				publicKey, err := c.UpdatePublicKey(
 					ctx,
 					id,
@@ -263,5 +264,12 @@ func NewCrud(c client.Client, rc router.Controll) *crud.Crud[recordT, recordCrea
 			return nil, false
 		}),
 		crud.WithListReloadAfterChange[recordT, recordCreateT, recordUpdateT, recordIdT, filterT](true),
+		crud.WithListFilter[recordT, recordCreateT, recordUpdateT, recordIdT, filterT](func(query string, record recordT) bool {
+			return filter.Contains(query,
+				record.publicKey.Comment,
+				record.publicKey.Algorithm,
+				record.publicKey.Tags.String(),
+			)
+		}),
 	)
 }
@@ -7,6 +7,7 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/toeirei/keymaster/core"
 	"github.com/toeirei/keymaster/core/db"
@@ -19,6 +20,11 @@ import (
 var (
 	_ core.Store = (*storeAdapter)(nil) // storeAdapter implements core.Store
 	_ core.Store = (*db.BunStore)(nil)  // db.BunStore implements core.Store
+
+	_ core.UnassignedKeyLister  = (*storeAdapter)(nil)
+	_ core.Locker               = (*storeAdapter)(nil)
+	_ core.BackupStreamer       = (*storeAdapter)(nil)
+	_ core.AccountHistoryReader = (*storeAdapter)(nil)
 )
 
 // Package uiadapters provides thin, canonical adapters that adapt package-level
@@ -38,6 +44,10 @@ var (
 type storeAdapter struct{}
 
 func (s *storeAdapter) GetAccounts() ([]model.Account, error) { return db.GetAllAccounts() }
+func (s *storeAdapter) GetAccountsBelowSerial(serial int) ([]model.Account, error) {
+	return db.GetAccountsBelowSerial(serial)
+}
+
 func (s *storeAdapter) GetAllActiveAccounts() ([]model.Account, error) {
 	return db.GetAllActiveAccounts()
 }
@@ -95,18 +105,98 @@ func (s *storeAdapter) RotateSystemKey(publicKey, privateKey string) (int, error
 func (s *storeAdapter) GetActiveSystemKey() (*model.SystemKey, error) {
 	return db.GetActiveSystemKey()
 }
+func (s *storeAdapter) GetAllSystemKeys() ([]model.SystemKey, error) {
+	return db.GetAllSystemKeys()
+}
+func (s *storeAdapter) DeleteSystemKey(serial int) error {
+	return db.DeleteSystemKey(serial)
+}
+func (s *storeAdapter) DeactivateSystemKeysBelow(serial int) (int, error) {
+	return db.DeactivateSystemKeysBelow(serial)
+}
+func (s *storeAdapter) GetKnownHostKey(hostname string) (string, error) {
+	return db.GetKnownHostKey(hostname)
+}
 func (s *storeAdapter) AddKnownHostKey(hostname, key string) error {
 	return db.AddKnownHostKey(hostname, key)
 }
 func (s *storeAdapter) ExportDataForBackup() (*model.BackupData, error) {
 	return db.ExportDataForBackup()
 }
+func (s *storeAdapter) ExportTablesForBackup(tables []string) (*model.BackupData, error) {
+	return db.ExportTablesForBackup(tables)
+}
+func (s *storeAdapter) StreamBackup(tables []string, fn func(table string, row any) error) error {
+	return db.StreamBackup(tables, fn)
+}
 func (s *storeAdapter) ImportDataFromBackup(d *model.BackupData) error {
 	return db.ImportDataFromBackup(d)
 }
-func (s *storeAdapter) IntegrateDataFromBackup(d *model.BackupData) error {
+func (s *storeAdapter) IntegrateDataFromBackup(d *model.BackupData) (model.RestoreSummary, error) {
 	return db.IntegrateDataFromBackup(d)
 }
+func (s *storeAdapter) SetAccountSchedule(id int, disableAt, enableAt time.Time) error {
+	return db.SetAccountSchedule(id, disableAt, enableAt)
+}
+
+func (s *storeAdapter) SetAccountRemediationPolicy(id int, policy string) error {
+	return db.SetAccountRemediationPolicy(id, policy)
+}
+
+func (s *storeAdapter) SetAccountDeployEnabled(id int, enabled bool) error {
+	return db.SetAccountDeployEnabled(id, enabled)
+}
+
+func (s *storeAdapter) SetAccountManageSystemKey(id int, manage bool) error {
+	return db.SetAccountManageSystemKey(id, manage)
+}
+
+func (s *storeAdapter) SetAccountDeployMode(id int, mode string) error {
+	return db.SetAccountDeployMode(id, mode)
+}
+
+func (s *storeAdapter) SetAccountOSFamily(id int, family string) error {
+	return db.SetAccountOSFamily(id, family)
+}
+
+func (s *storeAdapter) SetAccountDeployKnownHosts(id int, enabled bool) error {
+	return db.SetAccountDeployKnownHosts(id, enabled)
+}
+
+func (s *storeAdapter) SetAccountExcludedGlobalKeys(id int, keyIDs []int) error {
+	return db.SetAccountExcludedGlobalKeys(id, keyIDs)
+}
+
+func (s *storeAdapter) SetAccountAuthorizedPrincipals(id int, principals []string) error {
+	return db.SetAccountAuthorizedPrincipals(id, principals)
+}
+
+func (s *storeAdapter) SetAccountGroup(id int, group string) error {
+	return db.SetAccountGroup(id, group)
+}
+
+func (s *storeAdapter) SetAccountMetadata(id int, metadata map[string]string) error {
+	return db.SetAccountMetadata(id, metadata)
+}
+func (s *storeAdapter) SetAccountDeployedKeys(id int, keys []string) error {
+	return db.SetAccountDeployedKeys(id, keys)
+}
+
+func (s *storeAdapter) AcquireLock(name string, ttl time.Duration) (bool, error) {
+	return db.AcquireLock(name, ttl)
+}
+
+func (s *storeAdapter) ReleaseLock(name string) error {
+	return db.ReleaseLock(name)
+}
+
+func (s *storeAdapter) RenewLock(name string, ttl time.Duration) error {
+	return db.RenewLock(name, ttl)
+}
+
+func (s *storeAdapter) GetUnassignedPublicKeys() ([]model.PublicKey, error) {
+	return db.GetUnassignedPublicKeys()
+}
 
 // FindByIdentifier mirrors existing logic used in other adapters.
 func (s *storeAdapter) FindByIdentifier(ctx context.Context, identifier string) (*model.Account, error) {
@@ -196,6 +286,9 @@ func (s *storeAdapter) GenerateAuthorizedKeysContent(ctx context.Context, accoun
 	if err != nil {
 		return "", err
 	}
+	if acc, err := db.GetAccount(accountID); err == nil {
+		gks = keys.GlobalKeysForAccount(acc, gks)
+	}
 	aks, err := km.GetKeysForAccount(accountID)
 	if err != nil {
 		return "", err
@@ -220,6 +313,17 @@ func (s *storeAdapter) GetAllAuditLogEntries() ([]model.AuditLogEntry, error) {
 	return db.GetAllAuditLogEntries()
 }
 
+// GetAuditLogForAccount retrieves the audit log entries referencing an account.
+func (s *storeAdapter) GetAuditLogForAccount(accountID int) ([]model.AuditLogEntry, error) {
+	return db.GetAuditLogForAccount(accountID)
+}
+
+// GetOperationResults retrieves an account's deploy and audit results recorded
+// at or after since.
+func (s *storeAdapter) GetOperationResults(accountID int, since time.Time) ([]model.OperationResult, error) {
+	return db.GetOperationResults(accountID, since)
+}
+
 // ensure uiStoreAdapter satisfies core.Store at compile time
 var _ core.Store = (*storeAdapter)(nil)
 
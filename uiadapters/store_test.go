@@ -53,9 +53,14 @@ func (f *fakeKeyManager) AddPublicKeyAndGetModel(algorithm, keyData, comment str
 	return nil, nil
 }
 
-func (f *fakeKeyManager) DeletePublicKey(id int) error                         { return nil }
-func (f *fakeKeyManager) TogglePublicKeyGlobal(id int) error                   { return nil }
-func (f *fakeKeyManager) SetPublicKeyExpiry(id int, expiresAt time.Time) error { return nil }
+func (f *fakeKeyManager) DeletePublicKey(id int) error                                  { return nil }
+func (f *fakeKeyManager) TogglePublicKeyGlobal(id int) error                            { return nil }
+func (f *fakeKeyManager) SetPublicKeyExpiry(id int, expiresAt time.Time) error          { return nil }
+func (f *fakeKeyManager) SetPublicKeyMetadata(id int, metadata map[string]string) error { return nil }
+func (f *fakeKeyManager) SetPublicKeyCertAuthority(id int, isCA bool, principals []string) error {
+	return nil
+}
+func (f *fakeKeyManager) UpdatePublicKey(id int, algorithm, keyData string) error { return nil }
 
 func (f *fakeKeyManager) GetAllPublicKeys() ([]model.PublicKey, error) {
 	if f.getErr != nil {
@@ -79,6 +84,9 @@ func (f *fakeKeyManager) AssignKeyToAccount(keyID, accountID int) error {
 	f.lastAssignIDs = [2]int{keyID, accountID}
 	return f.assignErr
 }
+func (f *fakeKeyManager) AssignKeyToAccountWithOptions(keyID, accountID int, options string) error {
+	return f.AssignKeyToAccount(keyID, accountID)
+}
 
 func (f *fakeKeyManager) UnassignKeyFromAccount(keyID, accountID int) error { return nil }
 func (f *fakeKeyManager) GetKeysForAccount(accountID int) ([]model.PublicKey, error) {
@@ -347,7 +355,7 @@ func TestStoreAdapter_IntegrateDataFromBackup(t *testing.T) {
 	a := NewStoreAdapter()
 
 	data := &model.BackupData{}
-	err := a.IntegrateDataFromBackup(data)
+	_, err := a.IntegrateDataFromBackup(data)
 	if err != nil {
 		t.Fatalf("IntegrateDataFromBackup failed: %v", err)
 	}